@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
+	"github.com/chukul/cloudctl/internal/ui"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// adoptOptions are the flags of `cloudctl adopt`.
+type adoptOptions struct {
+	profile string
+	secret  string
+	region  string
+	expires string
+	paste   bool
+	replace bool
+}
+
+var adoptCmd = newAdoptCmd()
+
+// newAdoptCmd builds `cloudctl adopt` with its own options.
+func newAdoptCmd() *cobra.Command {
+	o := &adoptOptions{}
+	cmd := &cobra.Command{
+		Use:   "adopt",
+		Short: "Store temporary credentials from your environment as an encrypted session",
+		Long: `Take the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN in your
+environment, for example pasted from the SSO portal, check them with
+sts:GetCallerIdentity and store them encrypted like any other session, so switch, exec,
+console and sync can use them.
+
+STS doesn't tell how long credentials stay valid. The expiration is taken from --expires,
+or from AWS_CREDENTIAL_EXPIRATION or AWS_SESSION_EXPIRATION when another tool exported
+it; otherwise one hour is assumed for role credentials and 12 hours for session tokens.
+
+With --paste, the credentials come from the block copied from the SSO portal instead:
+"Set AWS environment variables" for bash, Windows cmd or PowerShell, or the credentials
+file profile. The clipboard is read, or stdin when it isn't a terminal, and the
+clipboard is cleared once the session is stored.
+
+Adopted sessions have no source, so refresh and the daemon can't renew them.`,
+		Example: `  cloudctl adopt --profile sso-dev
+  cloudctl adopt --profile sso-dev --expires 45m
+  cloudctl adopt --profile sso-dev --expires 2025-01-02T15:04:05Z
+  cloudctl adopt --profile sso-dev --paste
+  pbpaste | cloudctl adopt --profile sso-dev --paste`,
+		Args: cobra.NoArgs,
+		Run:  o.run,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&o.profile, "profile", "", "Name to store the session as")
+	flags.StringVar(&o.secret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret for encryption (or set CLOUDCTL_SECRET env var)")
+	flags.StringVar(&o.region, "region", "", "AWS region of the session (default: AWS_REGION, then ap-southeast-1)")
+	flags.StringVar(&o.expires, "expires", "", "When the credentials expire: a time (RFC 3339) or a duration from now, e.g. 45m")
+	flags.BoolVar(&o.replace, "replace", false, "Replace an active session of another role stored under the same name")
+	flags.BoolVar(&o.paste, "paste", false, "Read the credentials block copied from the SSO portal from the clipboard (or stdin)")
+	cmd.MarkFlagRequired("profile")
+	return cmd
+}
+
+// run implements `cloudctl adopt`.
+func (o *adoptOptions) run(cmd *cobra.Command, args []string) {
+	var opts internal.AdoptOptions
+	var pasted string
+	var err error
+	if o.paste {
+		if pasted, err = readPasted(); err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+		opts, err = internal.ParseCredentialBlock(pasted)
+		if err != nil {
+			printer.Error("%v", err)
+			printer.Tip("Copy the 'Set AWS environment variables' block of the account and role in the SSO portal.")
+			os.Exit(1)
+		}
+	} else if opts, err = internal.EnvAdoptOptions(); err != nil {
+		printer.Error("%v", err)
+		printer.Tip("Paste the credentials from the SSO portal (option 1, 'Set AWS environment variables') first, or use --paste.")
+		os.Exit(1)
+	}
+	opts.Profile = o.profile
+	if o.region != "" {
+		opts.Region = o.region
+	}
+	if o.expires != "" {
+		if opts.Expiration, err = internal.ParseExpiration(o.expires, time.Now()); err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+	}
+	knownExpiration := !opts.Expiration.IsZero()
+
+	secret, err := internal.GetSecret(o.secret)
+	if err != nil {
+		printer.Error("%s", i18n.T("secret.required"))
+		os.Exit(1)
+	}
+
+	ctx := cmd.Context()
+	region := opts.Region
+	if region == "" {
+		region = internal.DefaultRegion
+	}
+	cfg, err := internal.LoadAmbientConfig(ctx, region)
+	if err != nil {
+		printer.Error("%v", err)
+		os.Exit(1)
+	}
+	res, err := ui.Spin(ctx, "Checking credentials...", func(ctx context.Context) (any, error) {
+		return internal.AdoptSession(ctx, cfg, opts)
+	})
+	if err != nil {
+		printer.Error("%v", err)
+		os.Exit(1)
+	}
+	session := res.(*internal.AWSSession)
+	// The role is only known once STS has checked the credentials
+	if !checkSessionName(&session.Profile, session.RoleArn, o.secret, o.replace, true) {
+		return
+	}
+	o.profile = session.Profile
+
+	if err := internal.StoreSession(ctx, session, secret, warnStderr); err != nil {
+		printer.Error("Failed to save encrypted session: %v", err)
+		os.Exit(1)
+	}
+	printer.Success("%s", i18n.T("login.stored_encrypted", o.profile))
+	printer.Detail("%s", i18n.T("label.role", session.RoleArn))
+	printer.Detail("Account: %s", session.AccountID)
+	printer.Detail("%s", i18n.T("label.expires", internal.FormatExpiry(session.Expiration)))
+	if !knownExpiration {
+		printer.Warn("The expiration is a guess; pass --expires if you know it.")
+	}
+	if !o.paste {
+		printer.Tip("\nThe credentials can now be removed from your shell:")
+		printer.Detail("unset AWS_ACCESS_KEY_ID AWS_SECRET_ACCESS_KEY AWS_SESSION_TOKEN")
+	} else if term.IsTerminal(int(os.Stdin.Fd())) {
+		// Leave nothing behind for the next paste; anything copied since is kept
+		if cleared, err := internal.ClearClipboardIfUnchanged(internal.ClipboardFingerprint(pasted)); err != nil {
+			printer.Warn("Could not clear the clipboard: %v", err)
+		} else if cleared {
+			printer.Info("📋 Cleared the credentials from the clipboard.")
+		}
+	}
+}
+
+// readPasted returns the text piped to stdin, or else the clipboard contents.
+func readPasted() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return string(b), nil
+	}
+	return internal.ReadClipboard()
+}
+
+func init() {
+	rootCmd.AddCommand(adoptCmd)
+}
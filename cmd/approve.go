@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var approveInit bool
+var approveRole string
+var approveFor string
+var approveValid time.Duration
+
+var approveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "Approve a teammate's login to a dual-control role",
+	Long: `Issue a one-time approval token that lets a teammate log in to a role listed in
+security.dual_control_roles. The token is signed with your approver key, so it is only
+accepted by teammates who have your public key in security.approvers. Each token is
+bound to the role and the requesting OS user, and works once.
+
+Run 'cloudctl approve --init' once to create your approver key and print the public key
+to share with your team.`,
+	Example: `  cloudctl approve --init
+  cloudctl approve --role arn:aws:iam::123456789012:role/BreakGlass --for alice
+  cloudctl approve --role prod-admin --for alice --valid 15m`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if approveInit {
+			pub, err := internal.InitApproverKey()
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("🔑 Your approver public key:")
+			fmt.Printf("   %s\n\n", pub)
+			fmt.Println("💡 Teammates add it to their config with:")
+			fmt.Printf("   cloudctl config set security.approvers.%s %s\n", internal.CurrentUser(), pub)
+			return
+		}
+
+		if approveRole == "" || approveFor == "" {
+			fmt.Println("❌ --role and --for are required")
+			os.Exit(1)
+		}
+		role := approveRole
+		if r, found := internal.GetRoleAlias(role); found {
+			role = r.ARN
+		}
+		approver := internal.CurrentUser()
+		if approveFor == approver {
+			fmt.Println("❌ You can't approve your own login; ask a teammate.")
+			os.Exit(1)
+		}
+
+		token, approval, err := internal.IssueApproval(role, approveFor, approver, approveValid)
+		if errors.Is(err, internal.ErrNoApproverKey) {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		} else if err != nil {
+			fmt.Printf("❌ Failed to issue approval: %v\n", err)
+			os.Exit(1)
+		}
+		if err := internal.AppendAudit(internal.AuditEvent{
+			Event: internal.AuditApprovalIssued, Role: role, Approver: approver, Nonce: approval.Nonce,
+			Detail: "for " + approveFor,
+		}); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+
+		fmt.Printf("✅ Approved %s for %s until %s\n", role, approveFor, internal.FormatTime(approval.Expires))
+		fmt.Println("   Send them this token; it works once:")
+		fmt.Printf("\n%s\n\n", token)
+		fmt.Println("💡 They log in with: cloudctl login --role <role> --approval <token>")
+	},
+}
+
+// checkDualControl lets a login to a dual-control role through with a valid approval
+// token or, when security.dual_control_delay_minutes is set, once a request made that
+// long ago is due. It returns the audit event that uses up the approval (with its
+// approver) or the request, which the caller records once the session is stored, so a
+// failed login doesn't waste it; or an error explaining what is missing.
+func checkDualControl(role, token string) (internal.AuditEvent, error) {
+	cfg := internal.CurrentConfig()
+	user := internal.CurrentUser()
+	now := time.Now()
+	events, err := internal.ReadAuditLog()
+	if err != nil {
+		return internal.AuditEvent{}, err
+	}
+
+	if token != "" {
+		approval, err := internal.VerifyApproval(token, role, user, cfg.Security.Approvers, now)
+		if err != nil {
+			return internal.AuditEvent{}, err
+		}
+		if internal.ApprovalUsed(events, approval.Nonce) {
+			return internal.AuditEvent{}, errors.New("this approval has already been used; ask for a new one")
+		}
+		return internal.AuditEvent{
+			Event: internal.AuditApprovalUsed, Role: role, Approver: approval.Approver, Nonce: approval.Nonce,
+		}, nil
+	}
+
+	askHint := fmt.Sprintf("ask a teammate to run: cloudctl approve --role %s --for %s", role, user)
+	delay := time.Duration(cfg.Security.DualControlDelayMinutes) * time.Minute
+	if delay == 0 {
+		return internal.AuditEvent{}, fmt.Errorf("%s requires dual control; %s", role, askHint)
+	}
+
+	requested, pending := internal.PendingDualControl(events, role, user, delay, now)
+	if !pending {
+		if err := internal.AppendAudit(internal.AuditEvent{Event: internal.AuditDualControlRequested, Role: role}); err != nil {
+			return internal.AuditEvent{}, err
+		}
+		return internal.AuditEvent{}, fmt.Errorf("%s requires dual control; login request recorded, run login again after %s, or %s",
+			role, internal.FormatTime(now.Add(delay)), askHint)
+	}
+	if due := requested.Add(delay); now.Before(due) {
+		return internal.AuditEvent{}, fmt.Errorf("%s requires dual control; your request is due in %s (at %s), or %s",
+			role, internal.FormatDurationShort(time.Until(due)), internal.FormatTime(due), askHint)
+	}
+	return internal.AuditEvent{Event: internal.AuditDualControlDelayEnded, Role: role}, nil
+}
+
+func init() {
+	approveCmd.Flags().BoolVar(&approveInit, "init", false, "Create your approver key and print the public key to share")
+	approveCmd.Flags().StringVar(&approveRole, "role", "", "Role ARN or alias to approve")
+	approveCmd.Flags().StringVar(&approveFor, "for", "", "OS user name of the teammate logging in")
+	approveCmd.Flags().DurationVar(&approveValid, "valid", time.Hour, "How long the token can be used")
+	rootCmd.AddCommand(approveCmd)
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditProfile string
+	auditSince   string
+	auditRegion  string
+	auditSecret  string
+	auditAll     bool
+)
+
+// cloudTrailRetention is how far back CloudTrail LookupEvents can search.
+const cloudTrailRetention = 90 * 24 * time.Hour
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit the credentials cloudctl has issued",
+}
+
+var auditCloudCmd = &cobra.Command{
+	Use:   "cloud",
+	Short: "Show CloudTrail records of STS credentials minted for your sessions",
+	Long: `Query CloudTrail for AssumeRole and GetSessionToken events in the account of --profile and
+match them with locally stored sessions, to see exactly what was minted and when.
+
+Events are shown when their credentials belong to a stored session (by access key, or by
+role and session name for credentials that have since been refreshed), or when they were
+made by the --profile identity. Use --all to list every event.
+
+CloudTrail only returns events of the queried region, and calls to the global STS endpoint
+are recorded in us-east-1. AssumeRole events appear in the account that owns the role.`,
+	Example: `  cloudctl audit cloud --profile prod-admin --since 24h
+  cloudctl audit cloud --profile prod-admin --since 7d --region ap-southeast-1`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if auditProfile == "" {
+			fmt.Println("❌ --profile is required")
+			return
+		}
+		lookback, err := parseLookback(auditSince)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		if lookback > cloudTrailRetention {
+			fmt.Println("⚠️  CloudTrail only keeps 90 days of events; searching the last 90 days.")
+			lookback = cloudTrailRetention
+		}
+		since := time.Now().Add(-lookback)
+
+		secret, err := internal.GetSecret(auditSecret)
+		if errors.Is(err, internal.ErrStoreLocked) {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+
+		ctx := cmd.Context()
+		cfg, err := internal.LoadSourceConfig(ctx, auditProfile, secret, auditRegion)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+
+		var principal string
+		if identity, err := internal.NewSTSClient(cfg, auditProfile).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err == nil {
+			principal = aws.ToString(identity.Arn)
+		}
+
+		res, err := ui.Spin(ctx, fmt.Sprintf("Searching CloudTrail in %s since %s...", auditRegion, internal.FormatTime(since)), func(ctx context.Context) (any, error) {
+			return internal.LookupMintEvents(ctx, cfg, since)
+		})
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			fmt.Println("💡 The profile needs cloudtrail:LookupEvents permission.")
+			os.Exit(1)
+		}
+		events := res.([]*internal.MintEvent)
+
+		if secret != "" {
+			if sessions, err := internal.ListAllSessions(secret); err == nil {
+				internal.CorrelateMintEvents(events, sessions)
+			}
+		}
+		total := len(events)
+		if !auditAll {
+			events = internal.FilterMintEvents(events, principal)
+		}
+
+		if len(events) == 0 {
+			fmt.Printf("📭 No matching STS events in %s since %s (%d in total, use --all to list them).\n",
+				auditRegion, internal.FormatTime(since), total)
+			return
+		}
+
+		fmt.Printf("STS credentials minted since %s (%s)\n", internal.FormatTime(since), auditRegion)
+		fmt.Println(strings.Repeat("─", 100))
+		matched := 0
+		for _, e := range events {
+			target := "MFA session (GetSessionToken)"
+			if e.RoleArn != "" {
+				target = e.RoleArn
+				if name := internal.RoleName(e.RoleArn); name != "" {
+					target = fmt.Sprintf("%s (%s)", name, internal.RoleAccountID(e.RoleArn))
+				}
+				target += " as " + e.SessionName
+			}
+
+			stored := "not stored"
+			switch {
+			case e.ErrorCode != "":
+				stored = "❌ " + e.ErrorCode
+			case e.Profile != "" && e.Current:
+				stored = fmt.Sprintf("✅ %s (current)", e.Profile)
+				matched++
+			case e.Profile != "":
+				stored = fmt.Sprintf("✅ %s (since refreshed)", e.Profile)
+				matched++
+			}
+
+			fmt.Printf("%-20s %-16s %-45s %s\n", internal.FormatTime(e.Time), e.EventName, target, stored)
+			fmt.Printf("   by %s from %s\n", e.Principal, e.SourceIP)
+		}
+		fmt.Println(strings.Repeat("─", 100))
+		fmt.Printf("%d event(s), %d matched stored sessions.\n", len(events), matched)
+	},
+}
+
+var auditLogSince string
+
+var auditLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show the local audit log of dual-control approvals and logins",
+	Long: `Show events recorded on this machine: approvals you issued with 'cloudctl approve',
+approvals used and time-delayed requests made for dual-control roles, and the logins
+they allowed.`,
+	Example: `  cloudctl audit log
+  cloudctl audit log --since 30d`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		lookback, err := parseLookback(auditLogSince)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		since := time.Now().Add(-lookback)
+
+		events, err := internal.ReadAuditLog()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		var shown int
+		for _, e := range events {
+			if e.Time.Before(since) || e.IsUsageEvent() {
+				continue
+			}
+			if shown == 0 {
+				fmt.Printf("Audit log since %s (%s)\n", internal.FormatTime(since), internal.AuditLogPath())
+				fmt.Println(strings.Repeat("─", 100))
+			}
+			shown++
+			line := fmt.Sprintf("%-20s %-28s %-12s %s", internal.FormatTime(e.Time), e.Event, e.User, e.Role)
+			if e.Profile != "" {
+				line += " as " + e.Profile
+			}
+			if e.Approver != "" {
+				line += ", approver " + e.Approver
+			}
+			if e.Detail != "" {
+				line += ", " + e.Detail
+			}
+			fmt.Println(line)
+		}
+		if shown == 0 {
+			fmt.Printf("📭 No audit events since %s.\n", internal.FormatTime(since))
+		}
+	},
+}
+
+// parseLookback parses a duration such as 90m, 24h or 7d.
+func parseLookback(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid --since '%s' (use e.g. 90m, 24h or 7d)", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid --since '%s' (use e.g. 90m, 24h or 7d)", value)
+	}
+	return d, nil
+}
+
+func init() {
+	auditCloudCmd.Flags().StringVar(&auditProfile, "profile", "", "Session or AWS CLI profile used to query CloudTrail")
+	auditCloudCmd.Flags().StringVar(&auditSince, "since", "24h", "How far back to search (e.g. 90m, 24h, 7d)")
+	auditCloudCmd.Flags().StringVar(&auditRegion, "region", "us-east-1", "CloudTrail region to search (global STS calls are logged in us-east-1)")
+	auditCloudCmd.Flags().BoolVar(&auditAll, "all", false, "Show every AssumeRole/GetSessionToken event, not just your own")
+	auditCloudCmd.Flags().StringVar(&auditSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+
+	auditLogCmd.Flags().StringVar(&auditLogSince, "since", "7d", "How far back to show (e.g. 90m, 24h, 30d)")
+
+	auditCmd.AddCommand(auditCloudCmd, auditLogCmd)
+	rootCmd.AddCommand(auditCmd)
+}
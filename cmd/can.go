@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	canVia    string
+	canRegion string
+	canSecret string
+)
+
+var canCmd = &cobra.Command{
+	Use:   "can <profile> <action>[,<action>...] [resource-arn]",
+	Short: "Check whether a session's role is allowed to perform an action",
+	Long: `Simulate IAM actions against the policies of a stored session's role with
+iam:SimulatePrincipalPolicy, so a missing permission shows up before a long terraform run.
+
+The simulation is called with the session's own credentials unless --via names another
+session or AWS CLI profile that has iam:SimulatePrincipalPolicy. It evaluates identity
+policies, permissions boundaries and SCPs, but not resource policies. Exits with status 1
+if any action is denied.`,
+	Example: `  cloudctl can prod-admin s3:PutObject arn:aws:s3:::my-bucket/*
+  cloudctl can prod-admin ec2:RunInstances,iam:PassRole --via security-audit`,
+	Args: cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		profile := args[0]
+		var actions []string
+		for _, a := range strings.Split(args[1], ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				actions = append(actions, a)
+			}
+		}
+		var resource string
+		if len(args) == 3 {
+			resource = args[2]
+		}
+
+		secret, err := internal.GetSecret(canSecret)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		s, err := internal.LoadCredentials(profile, secret)
+		if err != nil {
+			fmt.Printf("❌ Failed to load session for profile '%s': %v\n", profile, err)
+			return
+		}
+		if s.RoleArn == "MFA-Session" || s.RoleArn == "" {
+			fmt.Println("❌ MFA base sessions have no role to simulate.")
+			return
+		}
+
+		via := canVia
+		if via == "" {
+			via = profile
+		}
+		ctx := cmd.Context()
+		cfg, err := internal.LoadSourceConfig(ctx, via, secret, canRegion)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+
+		res, err := ui.Spin(ctx, fmt.Sprintf("Simulating %d action(s) for %s...", len(actions), internal.RoleName(s.RoleArn)), func(ctx context.Context) (any, error) {
+			return internal.SimulateRolePermissions(ctx, cfg, s.RoleArn, actions, resource)
+		})
+		if err != nil {
+			fmt.Printf("❌ Simulation failed: %v\n", err)
+			if canVia == "" {
+				fmt.Println("💡 The role may lack iam:SimulatePrincipalPolicy; retry with --via <profile-that-has-it>.")
+			}
+			os.Exit(1)
+		}
+		checks := res.([]internal.PermissionCheck)
+
+		denied := 0
+		for _, c := range checks {
+			target := c.Resource
+			if target == "" {
+				target = "*"
+			}
+			if c.Allowed() {
+				fmt.Printf("✅ %s on %s: allowed\n", c.Action, target)
+			} else {
+				denied++
+				fmt.Printf("❌ %s on %s: %s\n", c.Action, target, c.Decision)
+			}
+			if len(c.MatchedPolicies) > 0 {
+				fmt.Printf("   Matched: %s\n", strings.Join(c.MatchedPolicies, ", "))
+			}
+			if c.DeniedByOrganizations {
+				fmt.Println("   Blocked by an organization SCP")
+			}
+			if c.DeniedByBoundary {
+				fmt.Println("   Blocked by the role's permissions boundary")
+			}
+			if len(c.MissingContext) > 0 {
+				fmt.Printf("   ⚠️  Conditions on %s could not be evaluated\n", strings.Join(c.MissingContext, ", "))
+			}
+		}
+
+		if denied > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	canCmd.Flags().StringVar(&canVia, "via", "", "Session or AWS CLI profile used to call IAM (default: the session itself)")
+	canCmd.Flags().StringVar(&canRegion, "region", "us-east-1", "AWS region for the IAM client")
+	canCmd.Flags().StringVar(&canSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	rootCmd.AddCommand(canCmd)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	clipboardClearAfter       time.Duration
+	clipboardClearFingerprint string
+)
+
+// clipboardClearCmd is started in the background by copyToClipboard. It is hidden
+// because it is an implementation detail, not something users run.
+var clipboardClearCmd = &cobra.Command{
+	Use:    "clipboard-clear",
+	Short:  "Clear the clipboard after a delay if it still holds copied credentials",
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		internal.SleepUntil(time.Now().Add(clipboardClearAfter))
+		if _, err := internal.ClearClipboardIfUnchanged(clipboardClearFingerprint); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// copyToClipboard copies text and schedules it to be cleared after
+// security.clipboard_clear_seconds, printing a notice to stderr.
+func copyToClipboard(text, what string) error {
+	if err := internal.CopyToClipboard(text); err != nil {
+		return err
+	}
+
+	timeout := internal.ClipboardClearTimeout()
+	if timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "📋 Copied %s to the clipboard.\n", what)
+		return nil
+	}
+
+	execPath, _ := os.Executable()
+	clearCmd := exec.Command(execPath, "clipboard-clear",
+		"--after", timeout.String(),
+		"--fingerprint", internal.ClipboardFingerprint(text))
+	if err := clearCmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "📋 Copied %s to the clipboard.\n", what)
+		fmt.Fprintf(os.Stderr, "⚠️  Could not schedule clearing the clipboard: %v\n", err)
+		return nil
+	}
+	// Don't wait: the clear runs after this command has exited
+	clearCmd.Process.Release()
+
+	fmt.Fprintf(os.Stderr, "📋 Copied %s to the clipboard. It will be cleared in %v.\n", what, timeout)
+	return nil
+}
+
+func init() {
+	clipboardClearCmd.Flags().DurationVar(&clipboardClearAfter, "after", 45*time.Second, "Delay before clearing")
+	clipboardClearCmd.Flags().StringVar(&clipboardClearFingerprint, "fingerprint", "", "Fingerprint of the copied text")
+	rootCmd.AddCommand(clipboardClearCmd)
+}
@@ -0,0 +1,379 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configViewRaw        bool
+	configExportSanitize bool
+	configImportConflict string
+	configImportDryRun   bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View, change and validate the cloudctl config file",
+	Long: `Manage ~/.cloudctl/config.json without hand-editing it. Every change is validated
+before it is written. String values may refer to environment variables as ${VAR} or
+${VAR:-default}; they are expanded when the config is loaded.
+
+Every key can also be set with a CLOUDCTL_<SECTION>_<KEY> environment variable, e.g.
+CLOUDCTL_SECURITY_AUTO_LOCK_MINUTES=30, which takes precedence over the file.`,
+	Example: `  cloudctl config view
+  cloudctl config get display.timezone
+  cloudctl config set display.timezone Asia/Bangkok
+  cloudctl config set security.auto_lock_minutes 30
+  cloudctl config set accounts.123456789012.region eu-west-1
+  cloudctl config set display.locale ""      # remove the key
+  cloudctl config edit
+  cloudctl config validate
+  cloudctl config export --sanitized team.yaml
+  cloudctl config import team.yaml`,
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Print the effective config, including defaults",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if configViewRaw {
+			b, err := internal.ReadConfigFile()
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(string(b))
+			return
+		}
+
+		cfg, err := internal.LoadConfig()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		b, _ := json.MarshalIndent(cfg, "", "  ")
+		fmt.Println(string(b))
+		if names := internal.ConfigEnvOverrides(); len(names) > 0 {
+			fmt.Fprintf(os.Stderr, "💡 Overridden by the environment: %s\n", strings.Join(names, ", "))
+		}
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get <key>",
+	Short:             "Print the effective value of a key",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := internal.LoadConfig()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		value, err := internal.ConfigValue(cfg, args[0])
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		switch v := value.(type) {
+		case nil:
+		case string:
+			fmt.Println(v)
+		default:
+			b, _ := json.MarshalIndent(v, "", "  ")
+			fmt.Println(string(b))
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a key in the config file (an empty value removes it)",
+	Long: `Set a key in the config file. Numbers, booleans and lists are given as JSON
+(e.g. 30, true, '["age1..."]'); strings as they are. An empty value removes the key.
+The change is only written if the resulting config is valid.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfigKeys,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := internal.SetConfigValue(args[0], args[1]); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if args[1] == "" {
+			fmt.Printf("✅ Removed %s\n", args[0])
+		} else {
+			fmt.Printf("✅ Set %s = %s\n", args[0], args[1])
+		}
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in $VISUAL or $EDITOR and validate it on save",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		original, err := internal.ReadConfigFile()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		tmp, err := os.CreateTemp(filepath.Dir(internal.ConfigPath()), "config-*.json")
+		if err != nil {
+			// The directory may not exist yet
+			tmp, err = os.CreateTemp("", "cloudctl-config-*.json")
+		}
+		if err != nil {
+			fmt.Printf("❌ Failed to create a temporary file: %v\n", err)
+			os.Exit(1)
+		}
+		defer os.Remove(tmp.Name())
+		tmp.Write(original)
+		tmp.Close()
+
+		for {
+			if err := runEditor(tmp.Name()); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+			edited, err := os.ReadFile(tmp.Name())
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+			if bytes.Equal(edited, original) {
+				fmt.Println("📭 No changes.")
+				return
+			}
+
+			err = internal.WriteConfigFile(edited)
+			if err == nil {
+				fmt.Printf("✅ Saved %s\n", internal.ConfigPath())
+				return
+			}
+			fmt.Printf("❌ %v\n", err)
+			fmt.Print("   Edit again? (y/n): ")
+			var response string
+			fmt.Scanln(&response)
+			if strings.ToLower(response) != "y" {
+				fmt.Println("⚠️  Changes discarded.")
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file, including unknown keys",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		b, err := internal.ReadConfigFile()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := internal.ParseConfig(b, true); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ %s is valid\n", internal.ConfigPath())
+	},
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export [file.yaml]",
+	Short: "Export settings, role aliases and MFA devices as YAML",
+	Long: `Write the config file settings (as written, with ${VAR} references kept), role
+aliases and MFA device aliases as one YAML document, to a file or stdout. Stored sessions
+are never exported.
+
+With --sanitized, settings that hold credentials or only make sense on this machine
+(encryption.age_identity_file, encryption.tpm_device, remote.host,
+security.allow_insecure_storage and endpoint access keys) are left out, so the file can
+be committed to a team dotfiles repo and applied with 'config import'.`,
+	Example: `  cloudctl config export --sanitized > team.yaml
+  cloudctl config export --sanitized ~/dotfiles/cloudctl.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		export, err := internal.ExportConfig(configExportSanitize)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		b, err := internal.MarshalConfigExport(export, configExportSanitize)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(args) == 0 {
+			fmt.Print(string(b))
+			return
+		}
+		mode := os.FileMode(0644)
+		if !configExportSanitize {
+			mode = 0600
+		}
+		if err := os.WriteFile(args[0], b, mode); err != nil {
+			fmt.Printf("❌ Failed to write file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Exported %d settings, %d roles and %d MFA devices to %s\n", len(export.Settings), len(export.Roles), len(export.MFADevices), args[0])
+		if !configExportSanitize {
+			fmt.Println("💡 Use --sanitized for a file to share: this one may hold machine-specific settings and endpoint keys.")
+		}
+	},
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import <file.yaml>",
+	Short: "Apply settings, role aliases and MFA devices from a config export",
+	Long: `Apply a 'config export' file ("-" reads stdin). Settings in the file replace the
+local values of the same keys, and keys only set locally are kept; machine-specific keys
+are never applied. Role and MFA device aliases are merged as with 'role import', with
+--on-conflict deciding about aliases that exist with a different value.
+
+Nothing is written unless the resulting config is valid.`,
+	Example: `  cloudctl config import ~/dotfiles/cloudctl.yaml --dry-run
+  cloudctl config import ~/dotfiles/cloudctl.yaml --on-conflict overwrite`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := internal.ValidateConflictStrategy(configImportConflict); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		var b []byte
+		var err error
+		if args[0] == "-" {
+			b, err = io.ReadAll(os.Stdin)
+		} else {
+			b, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			fmt.Printf("❌ Failed to read file: %v\n", err)
+			os.Exit(1)
+		}
+		incoming, err := internal.ParseConfigExport(b)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		current, err := internal.ReadConfigFile()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		merged, settingChanges, skipped, err := internal.MergeConfigSettings(current, incoming.Settings)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		for _, key := range skipped {
+			fmt.Printf("⚠️  Ignoring %s: it is specific to the machine it was exported from\n", key)
+		}
+		for _, c := range settingChanges {
+			newValue, _ := json.Marshal(c.New)
+			if c.Old == nil {
+				fmt.Printf("  + set  %-20s %s\n", c.Key, newValue)
+			} else {
+				oldValue, _ := json.Marshal(c.Old)
+				fmt.Printf("  ~ set  %-20s %s → %s\n", c.Key, oldValue, newValue)
+			}
+		}
+
+		for name, alias := range incoming.Roles {
+			if err := alias.Validate(); err != nil {
+				fmt.Printf("⚠️  Skipping role '%s': %v\n", name, err)
+				delete(incoming.Roles, name)
+			}
+		}
+		aliases, err := internal.ExportAliases()
+		if err != nil {
+			fmt.Printf("❌ Failed to load aliases: %v\n", err)
+			os.Exit(1)
+		}
+		mergedAliases, changes := internal.MergeAliases(aliases, &internal.AliasBundle{Roles: incoming.Roles, MFADevices: incoming.MFADevices}, configImportConflict)
+		counts := printMergeChanges(changes)
+		aliasChanges := counts["add"] + counts["overwrite"] + counts["rename"]
+
+		if configImportDryRun {
+			fmt.Println("\n💡 Dry run: no changes were saved.")
+			return
+		}
+		if len(settingChanges) == 0 && aliasChanges == 0 {
+			fmt.Println("\n✅ Nothing to import.")
+			return
+		}
+
+		if len(settingChanges) > 0 {
+			if err := internal.WriteConfigFile(merged); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if aliasChanges > 0 {
+			if err := internal.SaveAliasBundle(mergedAliases); err != nil {
+				fmt.Printf("❌ Failed to save aliases: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("\n✅ Imported %d settings; aliases: %d added, %d overwritten, %d renamed, %d skipped\n",
+			len(settingChanges), counts["add"], counts["overwrite"], counts["rename"], counts["skip"])
+	},
+}
+
+// runEditor opens path in the user's editor and waits for it to exit.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// EDITOR may carry flags, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", fields[0], err)
+	}
+	return nil
+}
+
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return internal.ConfigKeys(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	configViewCmd.Flags().BoolVar(&configViewRaw, "raw", false, "Print the file as written, without defaults or ${VAR} expansion")
+	configExportCmd.Flags().BoolVar(&configExportSanitize, "sanitized", false, "Leave out credentials and machine-specific settings, for sharing")
+	configImportCmd.Flags().StringVar(&configImportConflict, "on-conflict", internal.ConflictSkip, "How to handle existing aliases with a different value: skip, overwrite or rename")
+	configImportCmd.Flags().BoolVar(&configImportDryRun, "dry-run", false, "Show what would change without saving")
+	configCmd.AddCommand(configViewCmd, configGetCmd, configSetCmd, configEditCmd, configValidateCmd, configExportCmd, configImportCmd)
+	rootCmd.AddCommand(configCmd)
+}
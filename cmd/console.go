@@ -1,37 +1,65 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"os"
-	"os/exec"
-	"runtime"
+	"os/signal"
 	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
 	"github.com/chukul/cloudctl/internal/ui"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var consoleProfile string
 var consoleSecret string
 var consoleOpen bool
 var consoleRegion string
+var consoleClipboard bool
+var consoleRedirect bool
+var consolePrintOnly bool
+var consoleHeadless bool
+var consoleQR bool
+var consoleListen string
+var consoleSwitchRole bool
+var consoleRole string
+var consoleDisplayName string
+var consoleColor string
+var consoleProfiles string
+var consoleSelector string
+var consoleFresh bool
+
+// consoleRedirectTimeout is how long the one-time link waits to be opened.
+const consoleRedirectTimeout = 2 * time.Minute
+
+// consoleHeadlessTimeout leaves time to pick up a phone or switch machines.
+const consoleHeadlessTimeout = 5 * time.Minute
 
 var consoleCmd = &cobra.Command{
 	Use:   "console",
 	Short: "Generate AWS console sign-in URL from stored session",
 	Run: func(cmd *cobra.Command, args []string) {
+		if consoleSwitchRole {
+			runConsoleSwitchRole()
+			return
+		}
+		if consoleProfiles != "" || consoleSelector != "" {
+			runConsoleBatch(cmd)
+			return
+		}
 
 		// Get secret from flag, env, or keychain
 		secret, err := internal.GetSecret(consoleSecret)
 		if err != nil {
-			fmt.Println("❌ Encryption secret required")
-			fmt.Println("\n💡 Set the secret:")
+			fmt.Println("❌ " + i18n.T("secret.required"))
+			fmt.Println("\n💡 " + i18n.T("secret.set_hint"))
 			fmt.Println("   export CLOUDCTL_SECRET=\"your-32-char-encryption-key\"")
 			return
 		}
@@ -44,27 +72,26 @@ var consoleCmd = &cobra.Command{
 				return
 			}
 
-			var validProfiles []string
+			var valid []*internal.AWSSession
 			for _, s := range allSessions {
 				// Filter out expired sessions
-				if time.Now().After(s.Expiration) {
+				if time.Now().After(s.Expiration) || s.SelfDestructed(time.Now()) {
 					continue
 				}
 				// Filter out MFA sessions
-				if s.RoleArn == "MFA-Session" || s.RoleArn == "" {
+				if s.RoleArn == "MFA-Session" || s.RoleArn == "" || s.IsRoot() {
 					continue
 				}
-				validProfiles = append(validProfiles, s.Profile)
+				valid = append(valid, s)
 			}
 
-			if len(validProfiles) == 0 {
+			if len(valid) == 0 {
 				fmt.Println("❌ No valid active sessions found.")
 				fmt.Println("💡 Please login or refresh your sessions first.")
 				return
 			}
-			sort.Strings(validProfiles)
 
-			selected, err := ui.SelectProfile("Select Profile", validProfiles)
+			selected, err := selectSession("Select Profile", valid)
 			if err != nil {
 				return
 			}
@@ -76,6 +103,9 @@ var consoleCmd = &cobra.Command{
 			fmt.Printf("❌ Failed to load session for profile '%s': %v\n", consoleProfile, err)
 			return
 		}
+		if refuseSelfDestructed(s) {
+			return
+		}
 
 		// Check if this is an MFA session (can't be used for console federation)
 		// Check if session is expired
@@ -89,6 +119,11 @@ var consoleCmd = &cobra.Command{
 		// Check if this is an MFA session (can't be used for console federation)
 		// MFA sessions (GetSessionToken) do not have a RoleArn usually, or we marked them specifically.
 		// Our internal storage marks them as "MFA-Session".
+		if s.IsRoot() {
+			fmt.Println("❌ Root sessions from root-login cannot be used for console access.")
+			fmt.Println("💡 Use them with the CLI instead: cloudctl exec", s.Profile, "-- aws ...")
+			return
+		}
 		if s.RoleArn == "MFA-Session" || s.RoleArn == "" {
 			fmt.Println("❌ MFA base sessions cannot be used for console access.")
 			fmt.Println("💡 You must assume a role first. Try one of these:")
@@ -96,57 +131,38 @@ var consoleCmd = &cobra.Command{
 			return
 		}
 
-		// Create session JSON
-		sessionJSON := map[string]string{
-			"sessionId":    s.AccessKey,
-			"sessionKey":   s.SecretKey,
-			"sessionToken": s.SessionToken,
-		}
-
-		sessionData, _ := json.Marshal(sessionJSON)
-
 		// Get signin token
-		fmt.Println("🔐 Getting sign-in token...")
-		federationURL := "https://signin.aws.amazon.com/federation"
-		params := url.Values{}
-		params.Add("Action", "getSigninToken")
-		params.Add("Session", string(sessionData))
-
-		resp, err := http.Get(fmt.Sprintf("%s?%s", federationURL, params.Encode()))
+		consoleURL, cached, err := consoleURLFor(cmd, s, secret)
 		if err != nil {
-			fmt.Printf("❌ Failed to get sign-in token: %v\n", err)
+			fmt.Printf("❌ %v\n", err)
 			return
 		}
-		defer resp.Body.Close()
-
-		body, _ := io.ReadAll(resp.Body)
-		var tokenResp map[string]string
-		if err := json.Unmarshal(body, &tokenResp); err != nil {
-			fmt.Printf("❌ Failed to parse token response: %v\n", err)
-			return
-		}
-
-		signinToken := tokenResp["SigninToken"]
-		if signinToken == "" {
-			fmt.Println("❌ Failed to get sign-in token")
-			return
-		}
-
-		// Build console URL
-		destination := "https://console.aws.amazon.com/"
-		if consoleRegion != "" {
-			destination = fmt.Sprintf("https://%s.console.aws.amazon.com/console/home?region=%s", consoleRegion, consoleRegion)
+		if cached {
+			fmt.Println("🔐 Reused a cached sign-in token (--fresh gets a new one)")
+		} else {
+			fmt.Println("🔐 Got a new sign-in token")
 		}
-		consoleURL := fmt.Sprintf("%s?Action=login&Issuer=cloudctl&Destination=%s&SigninToken=%s",
-			federationURL, url.QueryEscape(destination), signinToken)
 
 		fmt.Printf("\n✅ Console URL generated for profile '%s'\n", s.Profile)
 		fmt.Printf("   Role: %s\n", s.RoleArn)
-		fmt.Printf("   Expires: %s\n\n", internal.FormatBKK(s.Expiration))
+		fmt.Printf("   Expires: %s\n\n", internal.FormatExpiry(s.Expiration))
 
-		if consoleOpen {
+		if consoleHeadless {
+			serveHeadlessLink(consoleURL)
+		} else if consoleRedirect {
+			openOneTimeRedirect(consoleURL)
+		} else if consoleQR {
+			printConsoleQR(consoleURL)
+		} else if consoleClipboard {
+			if err := copyToClipboard(consoleURL, "the console URL"); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				fmt.Printf("\nConsole URL:\n%s\n", consoleURL)
+			}
+		} else if consoleOpen && !consolePrintOnly {
 			fmt.Println("🌐 Opening AWS Console in browser...")
-			if err := openBrowser(consoleURL); err != nil {
+			if err := internal.OpenURL(consoleBrowserURL(s.Profile, consoleURL)); errors.Is(err, internal.ErrBrowserPrintOnly) {
+				fmt.Printf("Console URL:\n%s\n", consoleURL)
+			} else if err != nil {
 				fmt.Printf("❌ Failed to open browser: %v\n", err)
 				fmt.Printf("\nPlease open this URL manually:\n%s\n", consoleURL)
 			}
@@ -156,25 +172,306 @@ var consoleCmd = &cobra.Command{
 	},
 }
 
-func openBrowser(url string) error {
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("open", url)
-	case "linux":
-		cmd = exec.Command("xdg-open", url)
-	case "windows":
-		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
-	default:
-		return fmt.Errorf("unsupported platform")
-	}
-	return cmd.Start()
+// sessionConsoleRegion is --region, or the account's console home from the config file
+// when --region isn't given.
+func sessionConsoleRegion(cmd *cobra.Command, s *internal.AWSSession) string {
+	if !cmd.Flags().Changed("region") {
+		if r := internal.CurrentConfig().AccountConsoleRegion(internal.SessionAccountID(s)); r != "" {
+			return r
+		}
+	}
+	return consoleRegion
+}
+
+// consoleURLFor returns the console URL of a session, reusing a cached sign-in token
+// unless --fresh is given. Without a usable store provider, it gets a new token
+// uncached.
+func consoleURLFor(cmd *cobra.Command, s *internal.AWSSession, secret string) (string, bool, error) {
+	region := sessionConsoleRegion(cmd, s)
+	provider, err := internal.StoreProvider(secret)
+	if err != nil {
+		consoleURL, err := internal.FederatedConsoleURL(s, region)
+		return consoleURL, false, err
+	}
+	return internal.CachedConsoleURL(s, region, provider, consoleFresh)
+}
+
+// consoleBrowserURL is the URL to launch the browser with: with browser.containers, the
+// console opens in a Firefox container named after the profile.
+func consoleBrowserURL(profile, consoleURL string) string {
+	if internal.CurrentConfig().Browser.Containers {
+		return internal.ContainerURL(profile, consoleURL)
+	}
+	return consoleURL
+}
+
+// runConsoleBatch opens a console tab for every profile in --profiles or matching
+// --selector, e.g. to look at all production accounts during an incident.
+func runConsoleBatch(cmd *cobra.Command) {
+	for _, name := range []string{"redirect", "headless", "qr", "clipboard", "profile"} {
+		if cmd.Flags().Changed(name) {
+			fmt.Printf("❌ --%s can't be used with --profiles or --selector\n", name)
+			return
+		}
+	}
+
+	secret, err := internal.GetSecret(consoleSecret)
+	if err != nil {
+		fmt.Println("❌ " + i18n.T("secret.required"))
+		fmt.Println("\n💡 " + i18n.T("secret.set_hint"))
+		return
+	}
+
+	var sessions []*internal.AWSSession
+	if consoleSelector != "" {
+		sel, err := internal.ParseSessionSelector(consoleSelector)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		all, err := internal.ListAllSessions(secret)
+		if err != nil {
+			fmt.Printf("❌ Failed to list sessions: %v\n", err)
+			return
+		}
+		cfg := internal.CurrentConfig()
+		for _, s := range all {
+			if s.RoleArn != "MFA-Session" && s.RoleArn != "" && !s.IsRoot() && sel.Matches(cfg, s) {
+				sessions = append(sessions, s)
+			}
+		}
+		sort.Slice(sessions, func(i, j int) bool { return sessions[i].Profile < sessions[j].Profile })
+	}
+	for _, name := range strings.Split(consoleProfiles, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		s, err := internal.LoadCredentials(name, secret)
+		if err != nil {
+			fmt.Printf("❌ Failed to load session for profile '%s': %v\n", name, err)
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	if len(sessions) == 0 {
+		fmt.Println("❌ No sessions matched.")
+		fmt.Println("💡 Check the profile names with: cloudctl list")
+		return
+	}
+
+	now := time.Now()
+	opened, seen := 0, make(map[string]bool)
+	for _, s := range sessions {
+		if seen[s.Profile] {
+			continue
+		}
+		seen[s.Profile] = true
+		switch {
+		case s.RoleArn == "MFA-Session" || s.RoleArn == "":
+			fmt.Printf("⚠️  Skipping '%s': MFA base sessions cannot be used for console access.\n", s.Profile)
+			continue
+		case s.IsRoot():
+			fmt.Printf("⚠️  Skipping '%s': root sessions cannot be used for console access.\n", s.Profile)
+			continue
+		case now.After(s.Expiration) || s.SelfDestructed(now):
+			fmt.Printf("⚠️  Skipping '%s': the session has expired (cloudctl refresh --profile %s).\n", s.Profile, s.Profile)
+			continue
+		}
+
+		consoleURL, _, err := consoleURLFor(cmd, s, secret)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", s.Profile, err)
+			continue
+		}
+		err = internal.ErrBrowserPrintOnly
+		if !consolePrintOnly {
+			err = internal.OpenURL(consoleBrowserURL(s.Profile, consoleURL))
+		}
+		if errors.Is(err, internal.ErrBrowserPrintOnly) {
+			fmt.Printf("🔗 %s (%s):\n%s\n", s.Profile, s.RoleArn, consoleURL)
+		} else if err != nil {
+			fmt.Printf("❌ %s: failed to open browser: %v\n", s.Profile, err)
+			continue
+		} else {
+			fmt.Printf("🌐 %s (%s), expires %s\n", s.Profile, s.RoleArn, internal.FormatExpiry(s.Expiration))
+		}
+		opened++
+	}
+	fmt.Printf("\n✅ Opened %d of %d console(s).\n", opened, len(seen))
+	if opened > 1 && !internal.CurrentConfig().Browser.Containers {
+		fmt.Println("💡 Tabs in one browser share the console sign-in; set browser.containers to keep each account in its own Firefox container.")
+	}
+}
+
+// runConsoleSwitchRole prints the console's /switchrole link for a role alias or ARN.
+// The link needs no stored session: it adds the role to the role switcher of a console
+// the user is already signed in to, with the alias name and color as its label.
+func runConsoleSwitchRole() {
+	if consoleRole == "" {
+		roles, err := internal.ListRoleAliases()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		if len(roles) == 0 {
+			fmt.Println("❌ No role aliases found.")
+			fmt.Println("💡 Add one with: cloudctl role add <name> <role-arn>, or pass --role <role-arn>")
+			return
+		}
+		names := make([]string, 0, len(roles))
+		for name := range roles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		selected, err := ui.SelectProfile("Select Role", names)
+		if err != nil {
+			return
+		}
+		consoleRole = selected
+	}
+
+	roleArn, displayName, color := consoleRole, "", ""
+	if alias, found := internal.GetRoleAlias(consoleRole); found {
+		roleArn, displayName, color = alias.ARN, consoleRole, alias.Color
+	}
+	if consoleDisplayName != "" {
+		displayName = consoleDisplayName
+	}
+	if consoleColor != "" {
+		color = consoleColor
+	}
+	switchURL, err := internal.SwitchRoleURL(roleArn, displayName, color)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		fmt.Println("💡 Use a role alias (cloudctl role list) or a role ARN.")
+		return
+	}
+
+	fmt.Printf("✅ Switch-role link for %s\n", roleArn)
+	fmt.Println("   Open it while signed in to the console to switch to the role.")
+	fmt.Println()
+	if consoleQR {
+		printConsoleQR(switchURL)
+	} else if consoleClipboard {
+		if err := copyToClipboard(switchURL, "the switch-role link"); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			fmt.Printf("\nSwitch-role URL:\n%s\n", switchURL)
+		}
+	} else if consoleOpen && !consolePrintOnly {
+		fmt.Println("🌐 Opening AWS Console in browser...")
+		if err := internal.OpenURL(switchURL); errors.Is(err, internal.ErrBrowserPrintOnly) {
+			fmt.Printf("Switch-role URL:\n%s\n", switchURL)
+		} else if err != nil {
+			fmt.Printf("❌ Failed to open browser: %v\n", err)
+			fmt.Printf("\nPlease open this URL manually:\n%s\n", switchURL)
+		}
+	} else {
+		fmt.Printf("Switch-role URL:\n%s\n", switchURL)
+	}
+}
+
+// openOneTimeRedirect opens the console through a localhost link that works once, so the
+// sign-in token never appears in the terminal, shell history or logs.
+func openOneTimeRedirect(consoleURL string) {
+	redirect, err := internal.NewOneTimeRedirect(consoleURL)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	defer redirect.Close()
+
+	err = internal.ErrBrowserPrintOnly
+	if !consolePrintOnly {
+		fmt.Println("🌐 Opening AWS Console through a one-time local link...")
+		err = internal.OpenURL(redirect.URL())
+	}
+	if errors.Is(err, internal.ErrBrowserPrintOnly) {
+		fmt.Printf("Open this one-time link (valid for %v):\n%s\n", consoleRedirectTimeout, redirect.URL())
+	} else if err != nil {
+		fmt.Printf("⚠️  Failed to open browser: %v\n", err)
+		fmt.Printf("\nOpen this one-time link manually (valid for %v):\n%s\n", consoleRedirectTimeout, redirect.URL())
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, consoleRedirectTimeout)
+	defer cancelTimeout()
+
+	if err := redirect.Wait(ctx); err != nil {
+		fmt.Printf("❌ %v. The link is no longer valid.\n", err)
+		return
+	}
+	fmt.Println("✅ Signed in. The local link has been invalidated.")
+}
+
+// printConsoleQR renders the sign-in URL as a QR code. Sign-in URLs are long, so the
+// code may not fit narrow terminals; --headless gives a much smaller one.
+func printConsoleQR(consoleURL string) {
+	qr, err := ui.QRCode(consoleURL)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	width := utf8.RuneCountInString(qr[:strings.Index(qr, "\n")])
+	if cols, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > cols {
+		fmt.Printf("⚠️  The QR code needs %d columns but the terminal has %d. Widen it, zoom out, or use --headless for a short link.\n\n", width, cols)
+	}
+	fmt.Println(qr)
+	fmt.Println("⚠️  Anyone who scans this code can sign in to the console with this role. Sign-in links are valid for 15 minutes.")
+}
+
+// serveHeadlessLink prints a short one-time link and its QR code for opening the console
+// from another device, for SSH sessions without a browser.
+func serveHeadlessLink(consoleURL string) {
+	redirect, err := internal.NewShortRedirect(consoleURL, consoleListen)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	defer redirect.Close()
+
+	fmt.Printf("📱 Open this one-time link (valid for %v):\n\n", consoleHeadlessTimeout)
+	if qr, err := ui.QRCode(redirect.URL()); err == nil {
+		fmt.Println(qr)
+	}
+	fmt.Printf("   %s\n\n", redirect.URL())
+	if redirect.Loopback() {
+		fmt.Println("💡 The link only works on this machine. Over SSH, forward its port: ssh -L <port>:127.0.0.1:<port>")
+		fmt.Println("   To open it from a phone on this network, pass --listen with a LAN address (e.g. --listen :0).")
+	} else {
+		fmt.Println("⚠️  The link is plain HTTP on the network: the sign-in token travels in cleartext, and anyone who")
+		fmt.Println("   sees or opens it first gets the console session. Use it only on networks you trust.")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, consoleHeadlessTimeout)
+	defer cancelTimeout()
+
+	if err := redirect.Wait(ctx); err != nil {
+		fmt.Printf("❌ %v. The link is no longer valid.\n", err)
+		return
+	}
+	fmt.Println("✅ Signed in. The link has been invalidated.")
 }
 
 func init() {
 	consoleCmd.Flags().StringVar(&consoleProfile, "profile", "", "Profile to generate console URL for")
 	consoleCmd.Flags().StringVar(&consoleSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
 	consoleCmd.Flags().BoolVar(&consoleOpen, "open", false, "Automatically open URL in browser")
-	consoleCmd.Flags().StringVar(&consoleRegion, "region", "ap-southeast-1", "AWS region for console (default: ap-southeast-1)")
+	consoleCmd.Flags().BoolVar(&consolePrintOnly, "print-only", false, "With --open or --redirect, print the URL instead of launching a browser (e.g. over SSH)")
+	consoleCmd.Flags().BoolVar(&consoleRedirect, "redirect", false, "Open the console through a one-time localhost link so the sign-in URL is never printed")
+	consoleCmd.Flags().BoolVar(&consoleHeadless, "headless", false, "Print a short one-time link and QR code to open the console from another device")
+	consoleCmd.Flags().BoolVar(&consoleQR, "qr", false, "Show the sign-in URL as a QR code to scan with a phone or tablet")
+	consoleCmd.Flags().StringVar(&consoleListen, "listen", "127.0.0.1:0", "Address the --headless link is served on; use a LAN address or \":0\" to open it from another device")
+	consoleCmd.Flags().BoolVar(&consoleClipboard, "clipboard", false, "Copy the URL to the clipboard instead of printing it")
+	consoleCmd.Flags().BoolVar(&consoleSwitchRole, "switch-role", false, "Print the console's switch-role link for --role instead of signing in with a session")
+	consoleCmd.Flags().StringVar(&consoleRole, "role", "", "With --switch-role, the role alias or ARN (default: pick an alias)")
+	consoleCmd.Flags().StringVar(&consoleDisplayName, "display-name", "", "With --switch-role, the name shown in the console (default: the alias name)")
+	consoleCmd.Flags().StringVar(&consoleColor, "color", "", "With --switch-role, the \"#RRGGBB\" label color (default: the alias color)")
+	consoleCmd.Flags().StringVar(&consoleProfiles, "profiles", "", "Open a console for each of these comma-separated profiles")
+	consoleCmd.Flags().StringVar(&consoleSelector, "selector", "", "Open a console for each session matching key=value terms (env, account, profile, role), e.g. env=prod")
+	consoleCmd.Flags().BoolVar(&consoleFresh, "fresh", false, "Get a new sign-in token instead of reusing a cached one")
+	consoleCmd.Flags().StringVar(&consoleRegion, "region", "ap-southeast-1", "AWS region for console (default: the account's console_region from config, else ap-southeast-1)")
 	rootCmd.AddCommand(consoleCmd)
 }
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+// credentialProcessMinRemaining is how much time the returned credentials should have
+// left. SDKs call credential_process again a few minutes before Expiration, so shorter
+// credentials would be fetched over and over.
+const credentialProcessMinRemaining = 15 * time.Minute
+
+var credentialProcessSecret string
+
+var credentialProcessCmd = &cobra.Command{
+	Use:   "credential-process <profile>",
+	Short: "Print a session's credentials for an AWS credential_process",
+	Long: `Print the credentials of a stored session as JSON, in the format the AWS SDKs and
+CLI expect from a credential_process command, refreshing the session first when it
+expires within 15 minutes and can be refreshed.
+
+Use it in ~/.aws/config (ide-setup writes these profiles for you):
+
+  [profile cloudctl-dev-admin]
+  credential_process = cloudctl credential-process dev-admin
+
+The process gets the encryption secret like other commands, from the keychain or
+CLOUDCTL_SECRET, but isn't started from your shell: on Linux, CLOUDCTL_SECRET (and
+CLOUDCTL_HOME if you use it) must be in the environment the IDE or tool was started with.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		profile := args[0]
+		// Whatever we print goes to stderr: stdout is read by the SDK
+		secret, err := internal.GetSecret(credentialProcessSecret)
+		if err != nil {
+			printer.Error("%s", i18n.T("secret.required"))
+			os.Exit(1)
+		}
+
+		s, err := freshSession(cmd.Context(), profile, secret)
+		if err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+
+		b, _ := json.Marshal(internal.NewProcessCredentials(s))
+		fmt.Println(string(b))
+	},
+}
+
+// freshSession loads a session for a tool that can't be asked anything, refreshing it
+// first when it has less than credentialProcessMinRemaining left and can be refreshed.
+// It is also what the daemon answers get-credentials with.
+func freshSession(ctx context.Context, profile, secret string) (*internal.AWSSession, error) {
+	s, err := internal.LoadCredentials(profile, secret)
+	if err != nil {
+		return nil, err
+	}
+	if s.SelfDestructed(time.Now()) {
+		return nil, fmt.Errorf("'%s' self-destructed at %s and can no longer be used", profile, internal.FormatTime(s.SelfDestruct))
+	}
+
+	if time.Until(s.Expiration) < credentialProcessMinRemaining && canAutoRefresh(s) {
+		refreshed, err := internal.PerformRefresh(ctx, s, secret, s.Region)
+		if err == nil {
+			return refreshed, nil
+		}
+		if !s.Expiration.After(time.Now()) {
+			return nil, fmt.Errorf("failed to refresh '%s': %w", profile, err)
+		}
+		// Still valid for a while; the next call tries again
+	}
+	if !s.Expiration.After(time.Now()) {
+		return nil, fmt.Errorf("session '%s' expired at %s (run: cloudctl refresh --profile %s)", profile, internal.FormatTime(s.Expiration), profile)
+	}
+	return s, nil
+}
+
+func init() {
+	credentialProcessCmd.Flags().StringVar(&credentialProcessSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	rootCmd.AddCommand(credentialProcessCmd)
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"html"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,11 +20,6 @@ var (
 	daemonForeground bool
 )
 
-const (
-	daemonPIDFile = ".cloudctl/daemon.pid"
-	daemonLogFile = ".cloudctl/daemon.log"
-)
-
 var daemonCmd = &cobra.Command{
 	Use:   "daemon",
 	Short: "Manage the background auto-refresh daemon",
@@ -33,8 +31,7 @@ var daemonStartCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Start the auto-refresh daemon",
 	Run: func(cmd *cobra.Command, args []string) {
-		home, _ := os.UserHomeDir()
-		pidPath := filepath.Join(home, daemonPIDFile)
+		pidPath := daemonPIDPath()
 
 		// Check if already running
 		if _, err := os.Stat(pidPath); err == nil {
@@ -45,42 +42,66 @@ var daemonStartCmd = &cobra.Command{
 
 		if daemonForeground {
 			fmt.Printf("🚀 Starting CloudCtl daemon in foreground (Interval: %d minutes)...\n", daemonInterval)
-			startDaemonLoop(daemonInterval)
+			startDaemonLoop(cmd.Context(), daemonInterval)
 			return
 		}
 
-		// Self-forking logic
-		execPath, _ := os.Executable()
-		bgCmd := exec.Command(execPath, "daemon", "start", "--foreground", "--interval", fmt.Sprintf("%d", daemonInterval))
-
-		// Redirect output to log files for the background process
-		logDir := filepath.Join(home, ".cloudctl")
-		os.MkdirAll(logDir, 0700)
-
-		stdoutFile, _ := os.OpenFile(filepath.Join(logDir, "daemon.stdout.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-		stderrFile, _ := os.OpenFile(filepath.Join(logDir, "daemon.stderr.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-
-		bgCmd.Stdout = stdoutFile
-		bgCmd.Stderr = stderrFile
-
-		err := bgCmd.Start()
+		pid, logPath, err := startDaemonBackground(daemonInterval)
 		if err != nil {
-			fmt.Printf("❌ Failed to start daemon in background: %v\n", err)
+			fmt.Printf("❌ %v\n", err)
 			return
 		}
 
-		fmt.Printf("🚀 CloudCtl daemon started in background (PID: %d)\n", bgCmd.Process.Pid)
-		fmt.Printf("📝 Logs: ~/%s\n", daemonLogFile)
+		fmt.Printf("🚀 CloudCtl daemon started in background (PID: %d)\n", pid)
+		fmt.Printf("📝 Logs: %s\n", logPath)
 	},
 }
 
-func startDaemonLoop(intervalMins int) {
-	home, _ := os.UserHomeDir()
-	pidPath := filepath.Join(home, daemonPIDFile)
-	logPath := filepath.Join(home, daemonLogFile)
+// startDaemonBackground starts the daemon as a background copy of this executable and
+// returns its PID and log file.
+func startDaemonBackground(interval int) (int, string, error) {
+	execPath, _ := os.Executable()
+	bgCmd := exec.Command(execPath, "daemon", "start", "--foreground", "--interval", fmt.Sprintf("%d", interval))
+
+	// Redirect output to log files for the background process
+	logDir, err := internal.EnsureDaemonDir()
+	if err != nil {
+		return 0, "", err
+	}
+
+	stdoutFile, _ := os.OpenFile(filepath.Join(logDir, internal.DaemonStdoutFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	stderrFile, _ := os.OpenFile(filepath.Join(logDir, internal.DaemonStderrFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	bgCmd.Stdout = stdoutFile
+	bgCmd.Stderr = stderrFile
+
+	if err := bgCmd.Start(); err != nil {
+		return 0, "", fmt.Errorf("failed to start daemon in background: %w", err)
+	}
+	return bgCmd.Process.Pid, filepath.Join(logDir, internal.DaemonLogFile), nil
+}
+
+// daemonRunning reports whether a daemon answers on its socket or left a PID file.
+func daemonRunning() bool {
+	if _, err := internal.RequestDaemon(internal.DaemonRequestStatus); err == nil {
+		return true
+	}
+	_, err := os.Stat(daemonPIDPath())
+	return err == nil
+}
+
+func startDaemonLoop(ctx context.Context, intervalMins int) {
+	// Each user's daemon keeps its files in its own directory, so daemons of users
+	// sharing a store don't clash
+	dir, err := internal.EnsureDaemonDir()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	pidPath := filepath.Join(dir, internal.DaemonPIDFile)
+	logPath := filepath.Join(dir, internal.DaemonLogFile)
 
 	// Create PID file
-	os.MkdirAll(filepath.Dir(pidPath), 0700)
 	os.WriteFile(pidPath, []byte(fmt.Sprintf("%d", os.Getpid())), 0600)
 	defer os.Remove(pidPath)
 
@@ -92,11 +113,52 @@ func startDaemonLoop(intervalMins int) {
 		return
 	}
 
-	fmt.Fprintf(logFile, "[%s] 🚀 [Daemon] Started (Interval: %d mins)\n", internal.FormatBKK(time.Now()), intervalMins)
+	fmt.Fprintf(logFile, "[%s] 🚀 [Daemon] Started (Interval: %d mins)\n", internal.FormatTime(time.Now()), intervalMins)
+
+	// 'daemon status' and 'daemon stop' of the same user, and editor plugins (see
+	// LOCAL_API.md), talk to the daemon over its socket; connections of other users are
+	// refused and logged by the loop
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	refused := make(chan int, 8)
+	status := internal.DaemonStatus{PID: os.Getpid(), Started: time.Now(), Interval: intervalMins}
+	server, err := internal.ListenDaemon(ctx, status, internal.DaemonHandlers{
+		Stop: stop,
+		Refused: func(uid int) {
+			select {
+			case refused <- uid:
+			default:
+			}
+		},
+		Credentials: freshSession,
+	})
+	if err != nil {
+		fmt.Fprintf(logFile, "[%s] ⚠️  [Daemon] No control socket, 'daemon stop' falls back to signals: %v\n", internal.FormatTime(time.Now()), err)
+	} else {
+		defer server.Close()
+	}
+
+	// Schedules were validated when the config was loaded
+	schedules, _ := internal.ParseSchedules(internal.CurrentConfig().Daemon.Schedules)
+	if len(schedules) > 0 {
+		fmt.Fprintf(logFile, "[%s] ⏰ [Daemon] %d scheduled refresh(es), next at %s\n", internal.FormatTime(time.Now()), len(schedules),
+			internal.FormatTime(internal.NextScheduledRefresh(schedules, time.Now(), internal.DisplayLocation())))
+	}
+	lastScheduleCheck := time.Now()
+	idle := &idleMonitor{threshold: time.Duration(internal.CurrentConfig().Daemon.IdlePauseMinutes) * time.Minute}
 
 	ticker := time.NewTicker(time.Duration(intervalMins) * time.Minute)
 	defer ticker.Stop()
 
+	// Sessions logged in by other processes are checked right away instead of at the next tick
+	storeEvents, err := internal.WatchStore(ctx)
+	if err != nil {
+		fmt.Fprintf(logFile, "[%s] ⚠️  [Daemon] Not watching the store, new sessions wait for the next check: %v\n", internal.FormatTime(time.Now()), err)
+	}
+
+	// Update checks run here rather than on every command; each new release is logged once
+	announcedVersion := ""
+
 	for {
 		// Log Rotation: If day has changed, truncate the log file
 		now := time.Now()
@@ -109,124 +171,372 @@ func startDaemonLoop(intervalMins int) {
 				return
 			}
 			currentDay = now.YearDay()
-			fmt.Fprintf(logFile, "[%s] 🔄 [Daemon] Log rotated (new day started)\n", internal.FormatBKK(now))
+			fmt.Fprintf(logFile, "[%s] 🔄 [Daemon] Log rotated (new day started)\n", internal.FormatTime(now))
 		}
 
-		// Run refresh check
-		runRefreshCheck(logFile)
+		// Run scheduled refreshes that came due since the last pass, then the expiry check
+		if len(schedules) > 0 {
+			runScheduledRefreshes(ctx, logFile, schedules, lastScheduleCheck, now)
+			lastScheduleCheck = now
+		}
+		if !idle.paused(logFile) {
+			runRefreshCheck(ctx, logFile)
+		}
+		if latest, url, err := internal.CheckForUpdates(ctx); err == nil && latest != "" && latest != announcedVersion {
+			fmt.Fprintf(logFile, "[%s] 💡 [Daemon] Update available: %s → %s (%s)\n", internal.FormatTime(time.Now()), internal.CurrentVersion, latest, url)
+			announcedVersion = latest
+		}
 
-		<-ticker.C
+		// Wake up for the next scheduled refresh if it comes before the next tick
+		var timer *time.Timer
+		var scheduled <-chan time.Time
+		if next := internal.NextScheduledRefresh(schedules, now, internal.DisplayLocation()); !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			scheduled = timer.C
+		}
+		for {
+			select {
+			case <-ticker.C:
+			case <-scheduled:
+			case e, ok := <-storeEvents:
+				if !ok {
+					storeEvents = nil
+					continue
+				}
+				if e.Op != internal.SessionAdded {
+					// Refreshes, including the daemon's own, and logouts need no check
+					continue
+				}
+				fmt.Fprintf(logFile, "[%s] 📥 [Daemon] New session '%s', checking now\n", internal.FormatTime(time.Now()), e.Profile)
+			case uid := <-refused:
+				fmt.Fprintf(logFile, "[%s] 🚫 [Daemon] Refused a socket connection from uid %d\n", internal.FormatTime(time.Now()), uid)
+				continue
+			case <-ctx.Done():
+				fmt.Fprintf(logFile, "[%s] 🛑 [Daemon] Stopped\n", internal.FormatTime(time.Now()))
+				logFile.Close()
+				return
+			}
+			break
+		}
+		if timer != nil {
+			timer.Stop()
+		}
 	}
 }
 
-func runRefreshCheck(logWriter *os.File) {
+// daemonSecret gets the encryption secret, logging why it isn't available.
+func daemonSecret(logWriter *os.File) (string, error) {
 	secret, err := internal.GetSecret("")
+	if errors.Is(err, internal.ErrStoreLocked) {
+		fmt.Fprintf(logWriter, "[%s] 🔒 [Daemon] Store is locked, skipping until 'cloudctl unlock'\n", internal.FormatTime(time.Now()))
+	} else if err != nil {
+		fmt.Fprintf(logWriter, "[%s] ❌ [Daemon] Error: encryption secret required\n", internal.FormatTime(time.Now()))
+	}
+	return secret, err
+}
+
+// idleMonitor pauses expiry-driven refreshes while nobody is at the machine, so an
+// unattended laptop stops minting credentials. It logs only on state changes.
+type idleMonitor struct {
+	threshold   time.Duration
+	isPaused    bool
+	unavailable bool
+}
+
+// paused reports whether the user has been idle for at least the threshold. If idle time
+// can't be read, refreshes continue as if the option were off.
+func (m *idleMonitor) paused(logWriter *os.File) bool {
+	if m.threshold <= 0 || m.unavailable {
+		return false
+	}
+
+	idleFor, err := internal.UserIdleTime()
+	if err != nil {
+		m.unavailable = true
+		fmt.Fprintf(logWriter, "[%s] ⚠️  [Daemon] Idle detection unavailable, refreshes will not pause: %v\n", internal.FormatTime(time.Now()), err)
+		return false
+	}
+
+	wasPaused := m.isPaused
+	m.isPaused = idleFor >= m.threshold
+	switch {
+	case m.isPaused && !wasPaused:
+		fmt.Fprintf(logWriter, "[%s] 💤 [Daemon] No input for %v, pausing refreshes until activity resumes\n", internal.FormatTime(time.Now()), idleFor.Round(time.Minute))
+	case !m.isPaused && wasPaused:
+		fmt.Fprintf(logWriter, "[%s] ▶️  [Daemon] Activity detected, resuming refreshes\n", internal.FormatTime(time.Now()))
+	}
+	return m.isPaused
+}
+
+// runScheduledRefreshes refreshes the profiles whose daemon.schedules entry fired in
+// (since, now]. Unlike the expiry check, expired role sessions are refreshed too, as
+// long as their source is still valid.
+func runScheduledRefreshes(ctx context.Context, logWriter *os.File, schedules []internal.ScheduledRefresh, since, now time.Time) {
+	due := internal.DueProfiles(schedules, since, now, internal.DisplayLocation())
+	if len(due) == 0 {
+		return
+	}
+
+	secret, err := daemonSecret(logWriter)
+	if err != nil {
+		return
+	}
+
+	refreshed := 0
+	// Refreshed sessions are written to the store once, after the loop
+	err = internal.CredentialStore().Batch(func() error {
+		for _, profile := range due {
+			s, err := internal.LoadCredentials(profile, secret)
+			if err != nil {
+				fmt.Fprintf(logWriter, "[%s] ❌ [%s] Scheduled refresh failed: profile not found\n", internal.FormatTime(time.Now()), profile)
+				continue
+			}
+			if s.RoleArn == "MFA-Session" || s.SourceProfile == "" || s.IsRoot() {
+				fmt.Fprintf(logWriter, "[%s] ⚠️  [%s] Scheduled refresh skipped: MFA sessions, root sessions and sessions without a source need an interactive login\n", internal.FormatTime(time.Now()), profile)
+				continue
+			}
+
+			fmt.Fprintf(logWriter, "[%s] ⏰ [%s] Scheduled refresh starting...\n", internal.FormatTime(time.Now()), profile)
+
+			refreshRegion := s.Region
+			if refreshRegion == "" {
+				refreshRegion = "ap-southeast-1"
+			}
+
+			refreshStart := time.Now()
+			newSess, err := internal.PerformRefresh(ctx, s, secret, refreshRegion)
+			duration := time.Since(refreshStart).Round(10 * time.Millisecond)
+			var busy *internal.RenewalInProgressError
+			if errors.As(err, &busy) {
+				fmt.Fprintf(logWriter, "[%s] ⏳ [%s] Scheduled refresh skipped: being refreshed on %s\n", internal.FormatTime(time.Now()), profile, busy.Host)
+				continue
+			}
+			if err != nil {
+				fmt.Fprintf(logWriter, "[%s] ❌ [%s] Scheduled refresh failed: %v\n", internal.FormatTime(time.Now()), profile, err)
+				continue
+			}
+			fmt.Fprintf(logWriter, "[%s] ✅ [%s] Scheduled refresh done, valid until %s (took %v)\n",
+				internal.FormatTime(time.Now()), profile, internal.FormatTime(newSess.Expiration), duration)
+			refreshed++
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(logWriter, "[%s] ❌ [Daemon] Failed to save refreshed sessions: %v\n", internal.FormatTime(time.Now()), err)
+	}
+
+	if refreshed > 0 {
+		count, err := internal.SyncAllToAWS(secret)
+		if err != nil {
+			fmt.Fprintf(logWriter, "[%s] ⚠️  [Daemon] Auto-sync failed: %v\n", internal.FormatTime(time.Now()), err)
+		} else {
+			fmt.Fprintf(logWriter, "[%s] ✅ [Daemon] Synced %d sessions to ~/.aws/credentials\n", internal.FormatTime(time.Now()), count)
+		}
+	}
+}
+
+func runRefreshCheck(ctx context.Context, logWriter *os.File) {
+	secret, err := daemonSecret(logWriter)
 	if err != nil {
-		fmt.Fprintf(logWriter, "[%s] ❌ [Daemon] Error: encryption secret required\n", internal.FormatBKK(time.Now()))
 		return
 	}
 
 	sessions, err := internal.ListAllSessions(secret)
 	if err != nil {
-		fmt.Fprintf(logWriter, "[%s] ❌ [Daemon] Error: failed to list sessions: %v\n", internal.FormatBKK(time.Now()), err)
+		fmt.Fprintf(logWriter, "[%s] ❌ [Daemon] Error: failed to list sessions: %v\n", internal.FormatTime(time.Now()), err)
 		return
 	}
 
-	fmt.Fprintf(logWriter, "[%s] 🔍 [Daemon] Checking %d sessions...\n", internal.FormatBKK(time.Now()), len(sessions))
+	fmt.Fprintf(logWriter, "[%s] 🔍 [Daemon] Checking %d sessions...\n", internal.FormatTime(time.Now()), len(sessions))
+
+	// Sessions past their `login --self-destruct` deadline are deleted, not refreshed
+	purged, err := internal.PurgeSelfDestructed(sessions, time.Now())
+	for _, profile := range purged {
+		fmt.Fprintf(logWriter, "[%s] 💣 [%s] Self-destruct deadline passed, session deleted\n", internal.FormatTime(time.Now()), profile)
+	}
+	if err != nil {
+		fmt.Fprintf(logWriter, "[%s] ❌ [Daemon] Self-destruct failed: %v\n", internal.FormatTime(time.Now()), err)
+	}
 
 	now := time.Now()
 	actionTaken := false
-	for _, s := range sessions {
-		// 1. Skip sessions that are not near expiration (> 15 mins)
-		if time.Until(s.Expiration) >= 15*time.Minute {
-			continue
-		}
+	reauth := internal.NeedsReauth(sessions, now)
+	// Refreshed sessions are written to the store once, after the loop
+	err = internal.CredentialStore().Batch(func() error {
+		for _, s := range sessions {
+			if s.SelfDestructed(now) {
+				continue
+			}
 
-		// 2. Skip sessions that are already expired (the user must relogin manually)
-		if now.After(s.Expiration) {
-			continue
-		}
+			// 1. Skip sessions that are not near expiration (> 15 mins)
+			if time.Until(s.Expiration) >= 15*time.Minute {
+				continue
+			}
 
-		// 3. Skip sessions that cannot be silently refreshed (MFA sessions)
-		if s.RoleArn == "MFA-Session" {
-			// Silently skip MFA sessions to avoid log noise
-			continue
-		}
+			// 2. Skip sessions that are already expired (the user must relogin manually)
+			if now.After(s.Expiration) {
+				continue
+			}
 
-		// 4. Skip sessions with no source
-		if s.SourceProfile == "" {
-			continue
-		}
+			// 3. Skip sessions that cannot be silently refreshed (MFA and root sessions)
+			if s.RoleArn == "MFA-Session" || s.IsRoot() {
+				// Silently skip MFA sessions to avoid log noise
+				continue
+			}
 
-		// 5. Attempt Refresh
-		fmt.Fprintf(logWriter, "[%s] 🔄 [%s] Expiring in %v, starting silent refresh...\n",
-			internal.FormatBKK(now), s.Profile, time.Until(s.Expiration).Round(time.Second))
+			// 4. Skip sessions with no source
+			if s.SourceProfile == "" {
+				continue
+			}
 
-		refreshRegion := s.Region
-		if refreshRegion == "" {
-			refreshRegion = "ap-southeast-1"
-		}
+			// 5. Skip sessions whose MFA session has expired; STS would only reject the call
+			if root, ok := reauth[s.Profile]; ok {
+				fmt.Fprintf(logWriter, "[%s] ⚠️  [%s] Refresh skipped: needs re-auth, MFA session '%s' expired (run: cloudctl refresh --all -i)\n", internal.FormatTime(now), s.Profile, root)
+				continue
+			}
 
-		refreshStart := time.Now()
-		_, err := internal.PerformRefresh(s, secret, refreshRegion)
-		duration := time.Since(refreshStart).Round(10 * time.Millisecond)
+			// 6. Attempt Refresh
+			fmt.Fprintf(logWriter, "[%s] 🔄 [%s] Expiring in %v, starting silent refresh...\n",
+				internal.FormatTime(now), s.Profile, time.Until(s.Expiration).Round(time.Second))
 
-		if err != nil {
-			fmt.Fprintf(logWriter, "[%s] ❌ [%s] Refresh failed: %v\n", internal.FormatBKK(time.Now()), s.Profile, err)
-		} else {
-			fmt.Fprintf(logWriter, "[%s] ✅ [%s] Successfully refreshed (took %v)\n", internal.FormatBKK(time.Now()), s.Profile, duration)
+			refreshRegion := s.Region
+			if refreshRegion == "" {
+				refreshRegion = "ap-southeast-1"
+			}
+
+			refreshStart := time.Now()
+			_, err := internal.PerformRefresh(ctx, s, secret, refreshRegion)
+			duration := time.Since(refreshStart).Round(10 * time.Millisecond)
+
+			var busy *internal.RenewalInProgressError
+			if errors.As(err, &busy) {
+				fmt.Fprintf(logWriter, "[%s] ⏳ [%s] Refresh skipped: being refreshed on %s, retrying at the next check\n", internal.FormatTime(time.Now()), s.Profile, busy.Host)
+				continue
+			} else if err != nil {
+				fmt.Fprintf(logWriter, "[%s] ❌ [%s] Refresh failed: %v\n", internal.FormatTime(time.Now()), s.Profile, err)
+			} else {
+				fmt.Fprintf(logWriter, "[%s] ✅ [%s] Successfully refreshed (took %v)\n", internal.FormatTime(time.Now()), s.Profile, duration)
+			}
+			actionTaken = true
 		}
-		actionTaken = true
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(logWriter, "[%s] ❌ [Daemon] Failed to save refreshed sessions: %v\n", internal.FormatTime(time.Now()), err)
 	}
 
 	if actionTaken {
 		count, err := internal.SyncAllToAWS(secret)
 		if err != nil {
-			fmt.Fprintf(logWriter, "[%s] ⚠️  [Daemon] Auto-sync failed: %v\n", internal.FormatBKK(time.Now()), err)
+			fmt.Fprintf(logWriter, "[%s] ⚠️  [Daemon] Auto-sync failed: %v\n", internal.FormatTime(time.Now()), err)
 		} else {
-			fmt.Fprintf(logWriter, "[%s] ✅ [Daemon] Synced %d sessions to ~/.aws/credentials\n", internal.FormatBKK(time.Now()), count)
+			fmt.Fprintf(logWriter, "[%s] ✅ [Daemon] Synced %d sessions to ~/.aws/credentials\n", internal.FormatTime(time.Now()), count)
 		}
 	} else {
-		fmt.Fprintf(logWriter, "[%s] 🟢 [Daemon] All sessions healthy. Next check in 5m.\n", internal.FormatBKK(time.Now()))
+		fmt.Fprintf(logWriter, "[%s] 🟢 [Daemon] All sessions healthy. Next check in 5m.\n", internal.FormatTime(time.Now()))
+	}
+
+	// Push what was refreshed and pull what other machines did; pulled sessions are
+	// checked at the next pass
+	if internal.StoreSyncEnabled() {
+		runStoreSync(ctx, logWriter, secret)
 	}
 }
 
+// runStoreSync syncs the store with store_sync.url and logs what changed.
+func runStoreSync(ctx context.Context, logWriter *os.File, secret string) {
+	result, err := internal.SyncStore(ctx, secret, false)
+	if err != nil {
+		fmt.Fprintf(logWriter, "[%s] ⚠️  [Daemon] Store sync failed: %v\n", internal.FormatTime(time.Now()), err)
+		return
+	}
+	pushed, pulled := 0, 0
+	for _, c := range result.Changes {
+		if c.Pulled {
+			pulled++
+		} else {
+			pushed++
+		}
+	}
+	if pushed+pulled > 0 {
+		fmt.Fprintf(logWriter, "[%s] 🔁 [Daemon] Store sync: %d pushed, %d pulled\n", internal.FormatTime(time.Now()), pushed, pulled)
+	}
+	for _, name := range result.Rejected {
+		fmt.Fprintf(logWriter, "[%s] ⚠️  [%s] Not pulled: failed its integrity check in the sync copy\n", internal.FormatTime(time.Now()), name)
+	}
+}
+
+// daemonPIDPath returns the PID file of the current user's daemon, or the one in the
+// store directory where a daemon started by an older version keeps it.
+func daemonPIDPath() string {
+	path := internal.DaemonPath(internal.DaemonPIDFile)
+	if _, err := os.Stat(path); err != nil {
+		legacy := internal.LegacyDaemonPath(internal.DaemonPIDFile)
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy
+		}
+	}
+	return path
+}
+
 var daemonStopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "Stop the background daemon",
 	Run: func(cmd *cobra.Command, args []string) {
-		home, _ := os.UserHomeDir()
-		pidPath := filepath.Join(home, daemonPIDFile)
-
-		data, err := os.ReadFile(pidPath)
-		if err != nil {
+		pid, err := stopDaemon()
+		if errors.Is(err, errDaemonNotRunning) {
 			fmt.Println("❌ Daemon is not running.")
 			return
-		}
-
-		var pid int
-		fmt.Sscanf(string(data), "%d", &pid)
-
-		process, err := os.FindProcess(pid)
-		if err != nil {
-			fmt.Printf("❌ Could not find process %d\n", pid)
-			os.Remove(pidPath)
+		} else if err != nil {
+			fmt.Printf("❌ %v\n", err)
 			return
 		}
-
 		fmt.Printf("🛑 Stopping CloudCtl daemon (PID: %d)...\n", pid)
-		process.Signal(os.Interrupt)
-		os.Remove(pidPath)
 		fmt.Println("✅ Daemon stopped.")
 	},
 }
 
+var errDaemonNotRunning = errors.New("daemon is not running")
+
+// stopDaemon asks the daemon to stop and returns its PID, or errDaemonNotRunning.
+func stopDaemon() (int, error) {
+	if status, err := internal.RequestDaemon(internal.DaemonRequestStop); err == nil {
+		return status.PID, nil
+	}
+
+	// Daemons without a socket (started by older versions) are stopped by signal
+	pidPath := daemonPIDPath()
+
+	data, err := os.ReadFile(pidPath)
+	if err != nil {
+		return 0, errDaemonNotRunning
+	}
+
+	var pid int
+	fmt.Sscanf(string(data), "%d", &pid)
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		os.Remove(pidPath)
+		return 0, fmt.Errorf("could not find process %d", pid)
+	}
+
+	process.Signal(os.Interrupt)
+	os.Remove(pidPath)
+	return pid, nil
+}
+
 var daemonStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Check daemon status",
 	Run: func(cmd *cobra.Command, args []string) {
-		home, _ := os.UserHomeDir()
-		pidPath := filepath.Join(home, daemonPIDFile)
+		if status, err := internal.RequestDaemon(internal.DaemonRequestStatus); err == nil {
+			fmt.Printf("🟢 Daemon is running (PID: %d, uid %s, since %s, every %d mins)\n",
+				status.PID, status.User, internal.FormatTime(status.Started), status.Interval)
+			return
+		}
+
+		pidPath := daemonPIDPath()
 
 		if _, err := os.Stat(pidPath); err != nil {
 			fmt.Println("⚪ Daemon is NOT running.")
@@ -242,10 +552,10 @@ var daemonLogsCmd = &cobra.Command{
 	Use:   "logs",
 	Short: "View daemon logs",
 	Run: func(cmd *cobra.Command, args []string) {
-		home, _ := os.UserHomeDir()
-		logPath := filepath.Join(home, daemonLogFile)
-
-		data, err := os.ReadFile(logPath)
+		data, err := os.ReadFile(internal.DaemonPath(internal.DaemonLogFile))
+		if err != nil {
+			data, err = os.ReadFile(internal.LegacyDaemonPath(internal.DaemonLogFile))
+		}
 		if err != nil {
 			fmt.Println("❌ No logs found.")
 			return
@@ -255,6 +565,34 @@ var daemonLogsCmd = &cobra.Command{
 	},
 }
 
+var daemonScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Show scheduled refreshes from the config file",
+	Run: func(cmd *cobra.Command, args []string) {
+		schedules, err := internal.ParseSchedules(internal.CurrentConfig().Daemon.Schedules)
+		if err != nil {
+			fmt.Printf("❌ Invalid daemon.schedules: %v\n", err)
+			return
+		}
+		if len(schedules) == 0 {
+			fmt.Println("📭 No scheduled refreshes configured.")
+			fmt.Printf("💡 Add \"daemon\": {\"schedules\": [{\"profile\": \"prod\", \"cron\": \"45 8 * * 1-5\"}]} to %s\n", internal.ConfigPath())
+			return
+		}
+
+		now := time.Now()
+		loc := internal.DisplayLocation()
+		fmt.Printf("%-20s %-18s %s\n", "PROFILE", "CRON", "NEXT RUN")
+		for _, s := range schedules {
+			next := "never"
+			if t := s.Cron.Next(now.In(loc)); !t.IsZero() {
+				next = internal.FormatTime(t)
+			}
+			fmt.Printf("%-20s %-18s %s\n", s.Profile, s.Cron, next)
+		}
+	},
+}
+
 var daemonSetupCmd = &cobra.Command{
 	Use:   "setup",
 	Short: "Setup automatic startup on macOS",
@@ -264,10 +602,26 @@ var daemonSetupCmd = &cobra.Command{
 			return
 		}
 
+		logDir, err := internal.EnsureDaemonDir()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
 		home, _ := os.UserHomeDir()
 		execPath, _ := os.Executable()
 		plistPath := filepath.Join(home, "Library/LaunchAgents/com.chukul.cloudctl.plist")
 
+		// launchd doesn't inherit the shell environment, so pass a custom store location on
+		envBlock := ""
+		if dir := os.Getenv("CLOUDCTL_HOME"); dir != "" {
+			envBlock = fmt.Sprintf(`
+    <key>EnvironmentVariables</key>
+    <dict>
+        <key>CLOUDCTL_HOME</key>
+        <string>%s</string>
+    </dict>`, html.EscapeString(dir))
+		}
+
 		plistContent := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
 <plist version="1.0">
@@ -284,16 +638,16 @@ var daemonSetupCmd = &cobra.Command{
     <key>RunAtLoad</key>
     <true/>
     <key>KeepAlive</key>
-    <true/>
+    <true/>%s
     <key>StandardOutPath</key>
-    <string>%s/.cloudctl/daemon.stdout.log</string>
+    <string>%s</string>
     <key>StandardErrorPath</key>
-    <string>%s/.cloudctl/daemon.stderr.log</string>
+    <string>%s</string>
 </dict>
-</plist>`, execPath, home, home)
+</plist>`, execPath, envBlock, html.EscapeString(filepath.Join(logDir, internal.DaemonStdoutFile)), html.EscapeString(filepath.Join(logDir, internal.DaemonStderrFile)))
 
 		os.MkdirAll(filepath.Dir(plistPath), 0755)
-		err := os.WriteFile(plistPath, []byte(plistContent), 0644)
+		err = os.WriteFile(plistPath, []byte(plistContent), 0644)
 		if err != nil {
 			fmt.Printf("❌ Failed to create plist: %v\n", err)
 			return
@@ -313,6 +667,7 @@ func init() {
 	daemonCmd.AddCommand(daemonStopCmd)
 	daemonCmd.AddCommand(daemonStatusCmd)
 	daemonCmd.AddCommand(daemonLogsCmd)
+	daemonCmd.AddCommand(daemonScheduleCmd)
 	daemonCmd.AddCommand(daemonSetupCmd)
 
 	rootCmd.AddCommand(daemonCmd)
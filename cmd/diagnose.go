@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diagnoseBundle   bool
+	diagnoseOutput   string
+	diagnoseLogLines int
+	diagnoseNetwork  bool
+	diagnoseRegions  []string
+)
+
+var diagnoseCmd = &cobra.Command{
+	Use:     "diagnose",
+	Aliases: []string{"doctor"},
+	Short:   "Check your cloudctl setup and collect a redacted bug report bundle",
+	Long: `Print environment, store and permission checks for troubleshooting.
+With --bundle, the report plus recent daemon logs, alias files and the config are
+written to a .tar.gz you can attach to a GitHub issue. Access keys, secret keys,
+session tokens and the encryption secret are redacted; credentials are never decrypted.
+
+With --network, reachability of the console federation endpoint, global and regional
+STS, SSO OIDC, the GitHub release API and IMDS is tested through your proxy settings.`,
+	Example: `  # Show diagnostics in the terminal
+  cloudctl diagnose
+
+  # Create a bundle to attach to an issue
+  cloudctl diagnose --bundle
+  cloudctl diagnose --bundle --output /tmp/cloudctl-report.tar.gz
+
+  # Check which AWS endpoints are reachable from this network
+  cloudctl doctor --network --region us-east-1,ap-southeast-1`,
+	Run: func(cmd *cobra.Command, args []string) {
+		report := buildDiagnoseReport()
+		if diagnoseNetwork {
+			report += buildNetworkReport(cmd.Context(), diagnoseRegions)
+		}
+
+		if !diagnoseBundle {
+			fmt.Print(report)
+			return
+		}
+
+		outPath := diagnoseOutput
+		if outPath == "" {
+			outPath = fmt.Sprintf("cloudctl-diagnose-%s.tar.gz", time.Now().Format("20060102-150405"))
+		}
+
+		files := internal.DiagnoseFiles(diagnoseLogLines)
+		files["report.txt"] = []byte(report)
+		files["env.txt"] = []byte(collectDiagnoseEnv())
+
+		if err := writeTarGz(outPath, files); err != nil {
+			fmt.Printf("❌ Failed to write bundle: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Diagnostic bundle written to %s\n", outPath)
+		fmt.Println("💡 Secrets are redacted, but please review the contents before attaching it to an issue:")
+		fmt.Printf("   tar -tzvf %s\n", outPath)
+	},
+}
+
+func buildDiagnoseReport() string {
+	var b strings.Builder
+	home, _ := os.UserHomeDir()
+
+	fmt.Fprintln(&b, "cloudctl diagnostics")
+	fmt.Fprintln(&b, strings.Repeat("─", 60))
+	fmt.Fprintf(&b, "Version:     %s\n", internal.CurrentVersion)
+	fmt.Fprintf(&b, "OS/Arch:     %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Go:          %s\n", runtime.Version())
+	fmt.Fprintf(&b, "Shell:       %s\n", detectShell())
+	fmt.Fprintf(&b, "Generated:   %s\n", time.Now().Format(time.RFC3339))
+
+	_, secretErr := internal.GetSecret("")
+	secretSource := "not found"
+	if os.Getenv("CLOUDCTL_SECRET") != "" {
+		secretSource = "CLOUDCTL_SECRET environment variable"
+	} else if secretErr == nil && internal.CurrentConfig().Encryption.SecretsManagerID != "" {
+		secretSource = "AWS Secrets Manager"
+	} else if secretErr == nil {
+		secretSource = "system keychain"
+	}
+	fmt.Fprintf(&b, "Secret:      %s\n", secretSource)
+
+	fmt.Fprintln(&b, "\nCredential store")
+	fmt.Fprintln(&b, strings.Repeat("─", 60))
+	summary, err := internal.DescribeStore()
+	if err != nil {
+		fmt.Fprintf(&b, "❌ %v\n", err)
+	} else if !summary.Exists {
+		fmt.Fprintf(&b, "Path:        %s (not created yet)\n", summary.Path)
+	} else {
+		fmt.Fprintf(&b, "Path:        %s\n", summary.Path)
+		fmt.Fprintf(&b, "Schema:      %s\n", summary.SchemaVersion)
+		fmt.Fprintf(&b, "Profiles:    %d\n", summary.ProfileCount)
+		fmt.Fprintf(&b, "Fields:      %s\n", strings.Join(summary.Fields, ", "))
+	}
+
+	encryption := internal.CurrentConfig().Encryption
+	switch encryption.Provider {
+	case internal.ProviderAge:
+		fmt.Fprintf(&b, "Encryption:  age (%d recipient(s))\n", len(encryption.AgeRecipients))
+	case internal.ProviderKMS:
+		fmt.Fprintf(&b, "Encryption:  kms (%s)\n", encryption.KMSKeyID)
+	default:
+		fmt.Fprintln(&b, "Encryption:  secret")
+	}
+
+	roles, _ := internal.ListRoles()
+	devices, _ := internal.ListMFADevices()
+	fmt.Fprintf(&b, "Role aliases: %d\n", len(roles))
+	fmt.Fprintf(&b, "MFA aliases:  %d\n", len(devices))
+
+	fmt.Fprintln(&b, "\nPermissions")
+	fmt.Fprintln(&b, strings.Repeat("─", 60))
+	checks := []struct {
+		path string
+		want os.FileMode
+	}{
+		{internal.StoreDir(), 0700},
+		{internal.DaemonDir(), 0700},
+		{filepath.Join(internal.StoreDir(), "credentials.json"), 0600},
+		{filepath.Join(internal.StoreDir(), "roles.json"), 0600},
+		{filepath.Join(internal.StoreDir(), "mfa.json"), 0600},
+		{internal.AWSCredentialsPath(), 0600},
+	}
+	for _, c := range checks {
+		fmt.Fprintln(&b, checkPermission(c.path, c.want))
+	}
+	for _, w := range internal.CheckStorageExposure(home) {
+		// Permissions are already covered by the checks above
+		if w.Synced {
+			fmt.Fprintf(&b, "⚠️  %-45s %s\n", w.Path, w.Reason)
+		}
+	}
+
+	fmt.Fprintln(&b, "\nDaemon")
+	fmt.Fprintln(&b, strings.Repeat("─", 60))
+	if data, err := os.ReadFile(daemonPIDPath()); err == nil {
+		fmt.Fprintf(&b, "🟢 Running (PID: %s)\n", strings.TrimSpace(string(data)))
+	} else {
+		fmt.Fprintln(&b, "⚪ Not running")
+	}
+
+	return b.String()
+}
+
+// buildNetworkReport probes the endpoints cloudctl uses and prints a pass/fail table
+// with a hint for every failure.
+func buildNetworkReport(ctx context.Context, regions []string) string {
+	var b strings.Builder
+
+	fmt.Fprintln(&b, "\nNetwork")
+	fmt.Fprintln(&b, strings.Repeat("─", 60))
+	proxies := internal.ProxySettings()
+	if len(proxies) == 0 {
+		fmt.Fprintf(&b, "%-16s%s\n", "Proxy:", "none (direct connections)")
+	} else {
+		names := make([]string, 0, len(proxies))
+		for name := range proxies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "%-16s%s\n", name+":", proxies[name])
+		}
+	}
+	fmt.Fprintln(&b)
+
+	results := internal.CheckNetwork(ctx, internal.NetworkTargets(regions))
+	var hints []string
+	for _, r := range results {
+		switch {
+		case r.OK():
+			fmt.Fprintf(&b, "✅ %-24s %-6s %6dms  %s\n", r.Target.Name, "pass", r.Latency.Milliseconds(), describeNetworkCheck(r))
+		case r.Target.Optional:
+			fmt.Fprintf(&b, "⚪ %-24s %-6s %8s  %s\n", r.Target.Name, "n/a", "", r.Target.URL)
+			hints = append(hints, fmt.Sprintf("%s: %s", r.Target.Name, r.Hint()))
+		default:
+			fmt.Fprintf(&b, "❌ %-24s %-6s %8s  %s\n", r.Target.Name, "fail", "", r.Target.URL)
+			hints = append(hints, fmt.Sprintf("%s: %s", r.Target.Name, r.Hint()))
+		}
+	}
+	for _, h := range hints {
+		fmt.Fprintf(&b, "💡 %s\n", h)
+	}
+	return b.String()
+}
+
+// describeNetworkCheck shows what answered a successful check.
+func describeNetworkCheck(r internal.NetworkCheck) string {
+	detail := fmt.Sprintf("HTTP %d", r.Status)
+	if r.Proxy != "" {
+		detail += " via " + r.Proxy
+	}
+	return detail
+}
+
+// checkPermission reports whether a path is no more permissive than want.
+func checkPermission(path string, want os.FileMode) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Sprintf("⚪ %-45s missing", path)
+		}
+		return fmt.Sprintf("❌ %-45s %v", path, err)
+	}
+	mode := info.Mode().Perm()
+	if mode&^want != 0 {
+		return fmt.Sprintf("⚠️  %-45s %04o (expected %04o)", path, mode, want)
+	}
+	return fmt.Sprintf("✅ %-45s %04o", path, mode)
+}
+
+// collectDiagnoseEnv lists cloudctl/AWS related environment variables with secrets masked.
+func collectDiagnoseEnv() string {
+	var lines []string
+	for _, e := range os.Environ() {
+		name, value, _ := strings.Cut(e, "=")
+		if !strings.HasPrefix(name, "CLOUDCTL_") && !strings.HasPrefix(name, "AWS_") &&
+			!strings.EqualFold(name, "HTTPS_PROXY") && !strings.EqualFold(name, "HTTP_PROXY") && !strings.EqualFold(name, "NO_PROXY") {
+			continue
+		}
+		if internal.IsSensitiveEnv(name) {
+			value = internal.RedactedPlaceholder
+		}
+		lines = append(lines, fmt.Sprintf("%s=%s", name, internal.RedactSecrets(value)))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func writeTarGz(path string, files map[string][]byte) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	for _, name := range names {
+		data := files[name]
+		hdr := &tar.Header{
+			Name:    filepath.Join("cloudctl-diagnose", name),
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+func init() {
+	diagnoseCmd.Flags().BoolVar(&diagnoseBundle, "bundle", false, "Write a redacted .tar.gz bundle for bug reports")
+	diagnoseCmd.Flags().StringVarP(&diagnoseOutput, "output", "o", "", "Bundle output path (default: ./cloudctl-diagnose-<timestamp>.tar.gz)")
+	diagnoseCmd.Flags().IntVar(&diagnoseLogLines, "log-lines", 200, "Number of recent daemon log lines to include in the bundle")
+	diagnoseCmd.Flags().BoolVar(&diagnoseNetwork, "network", false, "Also test reachability of AWS sign-in, STS, SSO, GitHub and IMDS endpoints")
+	diagnoseCmd.Flags().StringSliceVar(&diagnoseRegions, "region", []string{"us-east-1"}, "Regions whose STS and SSO endpoints --network checks")
+	rootCmd.AddCommand(diagnoseCmd)
+}
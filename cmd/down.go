@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	downDeleteSessions bool
+	downYes            bool
+)
+
+// sessionEnvVars are the variables switch exports; down clears the ones that are set.
+var sessionEnvVars = []string{
+	"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+	"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ENDPOINT_URL",
+	"CLOUDCTL_PROFILE", "CLOUDCTL_ACCESS",
+}
+
+var downCmd = &cobra.Command{
+	Use:   "down",
+	Short: "End the work session: remove synced profiles, clear the shell and stop the daemon",
+	Long: `End the work session: remove the profiles sync wrote to ~/.aws/credentials (your
+own profiles stay), stop the daemon and print the commands that clear the credentials
+'switch' exported, for eval. With --delete-sessions the stored sessions are deleted
+too, e.g. before handing the machine over.
+
+Messages go to stderr, so only the shell commands are evaluated.`,
+	Example: `  eval $(cloudctl down)
+
+  # fish
+  eval (cloudctl down)
+
+  # Also delete every stored session
+  eval $(cloudctl down --delete-sessions)`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if downDeleteSessions && !downYes {
+			fmt.Fprint(os.Stderr, internal.Icon(internal.IconWarning)+" "+i18n.T("logout.confirm_all"))
+			input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.TrimSpace(input) != "yes" {
+				fmt.Fprintln(os.Stderr, internal.Icon(internal.IconError)+" "+i18n.T("common.cancelled"))
+				os.Exit(1)
+			}
+		}
+
+		failed := false
+		if removed, err := internal.RemoveManagedCredentials(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", internal.Icon(internal.IconError), err)
+			failed = true
+		} else if len(removed) > 0 {
+			fmt.Fprintf(os.Stderr, "%s Removed %d synced profiles from ~/.aws/credentials\n", internal.Icon(internal.IconSuccess), len(removed))
+		}
+
+		if pid, err := stopDaemon(); err == nil {
+			fmt.Fprintf(os.Stderr, "%s Daemon stopped (PID: %d)\n", internal.Icon(internal.IconSuccess), pid)
+		} else if !errors.Is(err, errDaemonNotRunning) {
+			fmt.Fprintf(os.Stderr, "%s %v\n", internal.Icon(internal.IconError), err)
+			failed = true
+		}
+
+		if downDeleteSessions {
+			profiles, _ := internal.ListProfiles()
+			unpublishRemote(cmd.Context(), profiles)
+			if err := internal.ClearAllCredentials(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s %v\n", internal.Icon(internal.IconError), err)
+				failed = true
+			} else {
+				fmt.Fprintln(os.Stderr, internal.Icon(internal.IconSuccess)+" "+i18n.T("logout.all_removed"))
+			}
+		}
+
+		// Only clear what switch set, not credentials the user exported themselves
+		if os.Getenv("CLOUDCTL_PROFILE") != "" {
+			fmt.Print(clearSessionExports())
+			fmt.Fprintln(os.Stderr, internal.Icon(internal.IconSuccess)+" Cleared the session from the shell environment")
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// clearSessionExports returns the commands that empty the session variables set in
+// this environment. Exporting empty values rather than using unset keeps the output
+// valid for fish, and the AWS SDKs and CLI ignore empty variables.
+func clearSessionExports() string {
+	var exports string
+	for _, name := range sessionEnvVars {
+		if os.Getenv(name) != "" {
+			exports += fmt.Sprintf("export %s=\n", name)
+		}
+	}
+	return exports
+}
+
+func init() {
+	downCmd.Flags().BoolVar(&downDeleteSessions, "delete-sessions", false, "Also delete all stored sessions")
+	downCmd.Flags().BoolVarP(&downYes, "yes", "y", false, "Skip the confirmation for --delete-sessions")
+	rootCmd.AddCommand(downCmd)
+}
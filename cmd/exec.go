@@ -1,26 +1,44 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"sort"
 	"time"
 
 	"github.com/chukul/cloudctl/internal"
-	"github.com/chukul/cloudctl/internal/ui"
+	"github.com/chukul/cloudctl/internal/i18n"
 	"github.com/spf13/cobra"
 )
 
 var execSecret string
+var execProfile string
+var execMinRemaining time.Duration
+var execRetryOnExpiry bool
+var execYes bool
 
 var execCmd = &cobra.Command{
-	Use:   "exec [profile] -- <command> [args...]",
-	Short: "Execute a command with AWS credentials injected into the environment",
-	Long:  `Executes a specific command with temporary AWS credentials from the chosen session without altering your global shell environment.`,
+	Use:     "exec [profile] -- <command> [args...]",
+	Aliases: []string{"run"},
+	Short:   "Execute a command with AWS credentials injected into the environment",
+	Long:    `Executes a specific command with temporary AWS credentials from the chosen session without altering your global shell environment.`,
 	Example: `  # Run terraform with a specific profile
   cloudctl exec prod-admin -- terraform plan
   
+  # Same, with the profile as a flag (run is an alias of exec)
+  cloudctl run --profile prod-admin -- aws s3 ls
+
+  # Refresh first (or refuse) if the session expires within 20 minutes
+  cloudctl exec prod-admin --min-remaining 20m -- terraform apply
+
+  # Re-run once with a refreshed session if credentials expire mid-run
+  cloudctl exec prod-admin --retry-on-expiry -- ./long-script.sh
+
+  # Skip the typed confirmation for a production session (e.g. in CI)
+  cloudctl exec prod-admin --yes -- terraform apply
+
   # Run interactively (it will prompt for profile automatically)
   cloudctl exec -- aws s3 ls`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -28,7 +46,15 @@ var execCmd = &cobra.Command{
 		var profile string
 		var commandArgs []string
 
-		if dashIndex == 0 {
+		if execProfile != "" {
+			// e.g. cloudctl run --profile prod-admin -- aws s3 ls
+			if dashIndex > 0 {
+				fmt.Fprintln(os.Stderr, "❌ Give the profile either as --profile or before --, not both.")
+				os.Exit(1)
+			}
+			profile = execProfile
+			commandArgs = args
+		} else if dashIndex == 0 {
 			// e.g. cloudctl exec -- aws s3 ls
 			commandArgs = args
 		} else if dashIndex == 1 {
@@ -65,8 +91,8 @@ var execCmd = &cobra.Command{
 		// Get secret to decrypt session
 		secret, err := internal.GetSecret(execSecret)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "❌ Encryption secret required")
-			fmt.Fprintln(os.Stderr, "\n💡 Set the secret or use macOS Keychain:")
+			fmt.Fprintln(os.Stderr, "❌ "+i18n.T("secret.required"))
+			fmt.Fprintln(os.Stderr, "\n💡 "+i18n.T("secret.set_hint_keychain"))
 			fmt.Fprintln(os.Stderr, "   export CLOUDCTL_SECRET=\"your-32-char-encryption-key\"")
 			os.Exit(1)
 		}
@@ -80,33 +106,24 @@ var execCmd = &cobra.Command{
 			}
 
 			now := time.Now()
-			var options []string
-			optionToProfile := make(map[string]string)
-
+			var active []*internal.AWSSession
 			for _, s := range allSessions {
 				// Only show active sessions
-				if s.Expiration.After(now) {
-					sessionType := "Role"
-					if s.RoleArn == "MFA-Session" {
-						sessionType = "MFA"
-					}
-					displayName := fmt.Sprintf("%-15s (%s)", s.Profile, sessionType)
-					options = append(options, displayName)
-					optionToProfile[displayName] = s.Profile
+				if s.Expiration.After(now) && !s.SelfDestructed(now) {
+					active = append(active, s)
 				}
 			}
 
-			if len(options) == 0 {
+			if len(active) == 0 {
 				fmt.Fprintln(os.Stderr, "📭 No active sessions found. Create one first.")
 				os.Exit(1)
 			}
-			sort.Strings(options)
 
-			selected, err := ui.SelectProfile("Select Account context for Execution", options)
+			selected, err := selectSession("Select Account context for Execution", active)
 			if err != nil {
 				os.Exit(1)
 			}
-			profile = optionToProfile[selected]
+			profile = selected
 		}
 
 		// Load credentials
@@ -116,52 +133,127 @@ var execCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Set up environment
-		env := os.Environ()
-		
-		// Remove existing AWS_* environment variables to avoid conflicts
-		var cleanEnv []string
-		for _, e := range env {
-			if !hasPrefix(e, "AWS_ACCESS_KEY_ID=") &&
-				!hasPrefix(e, "AWS_SECRET_ACCESS_KEY=") &&
-				!hasPrefix(e, "AWS_SESSION_TOKEN=") &&
-				!hasPrefix(e, "AWS_PROFILE=") &&
-				!hasPrefix(e, "AWS_REGION=") &&
-				!hasPrefix(e, "AWS_DEFAULT_REGION=") {
-				cleanEnv = append(cleanEnv, e)
-			}
+		if refuseSelfDestructed(s) || !confirmProduction(s, execYes) {
+			os.Exit(1)
 		}
 
-		// Inject new credentials
-		cleanEnv = append(cleanEnv, fmt.Sprintf("AWS_ACCESS_KEY_ID=%s", s.AccessKey))
-		cleanEnv = append(cleanEnv, fmt.Sprintf("AWS_SECRET_ACCESS_KEY=%s", s.SecretKey))
-		cleanEnv = append(cleanEnv, fmt.Sprintf("AWS_SESSION_TOKEN=%s", s.SessionToken))
-		if s.Region != "" {
-			cleanEnv = append(cleanEnv, fmt.Sprintf("AWS_REGION=%s", s.Region))
-			cleanEnv = append(cleanEnv, fmt.Sprintf("AWS_DEFAULT_REGION=%s", s.Region))
+		if execMinRemaining > 0 {
+			if s, err = ensureMinRemaining(cmd.Context(), s, secret, execMinRemaining); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				os.Exit(1)
+			}
 		}
-		
-		targetCmd := exec.Command(commandArgs[0], commandArgs[1:]...)
-		targetCmd.Env = cleanEnv
-		targetCmd.Stdin = os.Stdin
-		targetCmd.Stdout = os.Stdout
-		targetCmd.Stderr = os.Stderr
-
-		if err := targetCmd.Run(); err != nil {
-			if exitError, ok := err.(*exec.ExitError); ok {
-				os.Exit(exitError.ExitCode())
+
+		internal.RecordExport(s.Profile, "exec")
+		exitCode, stderrTail := runWithSession(s, commandArgs, execRetryOnExpiry)
+		if exitCode != 0 && execRetryOnExpiry && internal.IsExpiredTokenError(stderrTail) {
+			if !canAutoRefresh(s) {
+				fmt.Fprintf(os.Stderr, "❌ Credentials expired and '%s' can't be refreshed automatically (run: cloudctl refresh --profile %s)\n", s.Profile, s.Profile)
+				os.Exit(exitCode)
 			}
-			fmt.Fprintf(os.Stderr, "❌ Failed to execute command: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintf(os.Stderr, "🔄 Credentials for '%s' expired, refreshing and running the command again...\n", s.Profile)
+			refreshed, err := internal.PerformRefresh(cmd.Context(), s, secret, s.Region)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Failed to refresh '%s': %v\n", s.Profile, err)
+				os.Exit(exitCode)
+			}
+			exitCode, _ = runWithSession(refreshed, commandArgs, false)
+		}
+		if exitCode != 0 {
+			os.Exit(exitCode)
 		}
 	},
 }
 
+// runWithSession runs the command with the session's credentials in its environment and
+// returns its exit code. With captureStderr, stderr is also kept (the last 64 KB) so the
+// caller can check it for expired-token errors.
+func runWithSession(s *internal.AWSSession, commandArgs []string, captureStderr bool) (int, string) {
+	// Remove existing AWS_* environment variables to avoid conflicts
+	var cleanEnv []string
+	for _, e := range os.Environ() {
+		if !hasPrefix(e, "AWS_ACCESS_KEY_ID=") &&
+			!hasPrefix(e, "AWS_SECRET_ACCESS_KEY=") &&
+			!hasPrefix(e, "AWS_SESSION_TOKEN=") &&
+			!hasPrefix(e, "AWS_PROFILE=") &&
+			!hasPrefix(e, "AWS_REGION=") &&
+			!hasPrefix(e, "AWS_DEFAULT_REGION=") &&
+			!hasPrefix(e, "CLOUDCTL_ACCESS=") {
+			cleanEnv = append(cleanEnv, e)
+		}
+	}
+
+	// Inject new credentials
+	cleanEnv = append(cleanEnv, fmt.Sprintf("AWS_ACCESS_KEY_ID=%s", s.AccessKey))
+	cleanEnv = append(cleanEnv, fmt.Sprintf("AWS_SECRET_ACCESS_KEY=%s", s.SecretKey))
+	cleanEnv = append(cleanEnv, fmt.Sprintf("AWS_SESSION_TOKEN=%s", s.SessionToken))
+	if s.Access != "" {
+		cleanEnv = append(cleanEnv, fmt.Sprintf("CLOUDCTL_ACCESS=%s", s.Access))
+	}
+	if region := sessionRegion(s); region != "" {
+		cleanEnv = append(cleanEnv, fmt.Sprintf("AWS_REGION=%s", region))
+		cleanEnv = append(cleanEnv, fmt.Sprintf("AWS_DEFAULT_REGION=%s", region))
+	}
+
+	targetCmd := exec.Command(commandArgs[0], commandArgs[1:]...)
+	targetCmd.Env = cleanEnv
+	targetCmd.Stdin = os.Stdin
+	targetCmd.Stdout = os.Stdout
+	targetCmd.Stderr = os.Stderr
+
+	tail := &internal.TailBuffer{Max: 64 * 1024}
+	if captureStderr {
+		targetCmd.Stderr = io.MultiWriter(os.Stderr, tail)
+	}
+
+	if err := targetCmd.Run(); err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return exitError.ExitCode(), tail.String()
+		}
+		fmt.Fprintf(os.Stderr, "❌ Failed to execute command: %v\n", err)
+		return 1, ""
+	}
+	return 0, ""
+}
+
+// canAutoRefresh reports whether a session can be refreshed without user interaction.
+func canAutoRefresh(s *internal.AWSSession) bool {
+	return s.RoleArn != "MFA-Session" && s.RoleArn != "" && s.SourceProfile != ""
+}
+
+// ensureMinRemaining refreshes a session that expires within min, and refuses when it
+// can't be refreshed or even a fresh session would be too short for the task.
+func ensureMinRemaining(ctx context.Context, s *internal.AWSSession, secret string, min time.Duration) (*internal.AWSSession, error) {
+	remaining := time.Until(s.Expiration)
+	if remaining >= min {
+		return s, nil
+	}
+
+	if !canAutoRefresh(s) {
+		return nil, fmt.Errorf("session '%s' has %s left, less than --min-remaining %s, and can't be refreshed automatically (run: cloudctl refresh --profile %s)",
+			s.Profile, internal.FormatDurationShort(max(remaining, 0)), min, s.Profile)
+	}
+
+	fmt.Fprintf(os.Stderr, "🔄 Session '%s' has %s left (< %s), refreshing...\n", s.Profile, internal.FormatDurationShort(max(remaining, 0)), min)
+	refreshed, err := internal.PerformRefresh(ctx, s, secret, s.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh '%s': %w", s.Profile, err)
+	}
+	if left := time.Until(refreshed.Expiration); left < min {
+		return nil, fmt.Errorf("refreshed session '%s' only lasts %s, less than --min-remaining %s", s.Profile, internal.FormatDurationShort(left), min)
+	}
+	return refreshed, nil
+}
+
 func hasPrefix(s, prefix string) bool {
 	return len(s) >= len(prefix) && s[0:len(prefix)] == prefix
 }
 
 func init() {
+	execCmd.Flags().StringVar(&execProfile, "profile", "", "Profile to run the command with (instead of the positional profile)")
+	execCmd.Flags().DurationVar(&execMinRemaining, "min-remaining", 0, "Refresh the session first, or refuse to start, if it expires sooner than this (e.g. 20m)")
+	execCmd.Flags().BoolVar(&execRetryOnExpiry, "retry-on-expiry", false, "If the command fails with an expired token, refresh the session and run it once more")
+	execCmd.Flags().BoolVarP(&execYes, "yes", "y", false, "Skip the confirmation for production sessions")
 	execCmd.Flags().StringVar(&execSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
 	rootCmd.AddCommand(execCmd)
 }
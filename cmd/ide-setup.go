@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ideSetupSecret string
+	ideSetupPrefix string
+	ideSetupDryRun bool
+	ideSetupForce  bool
+)
+
+var ideSetupCmd = &cobra.Command{
+	Use:   "ide-setup [profile...]",
+	Short: "Write ~/.aws/config profiles that give IDEs cloudctl's sessions",
+	Long: `Write one profile per stored session (or per given profile) into ~/.aws/config, with a
+credential_process that runs 'cloudctl credential-process'. The AWS Toolkits for VS Code
+and JetBrains IDEs list these profiles, and fetch the credentials again whenever they
+expire, so the IDE's AWS explorer follows the sessions cloudctl and its daemon keep
+refreshed.
+
+Running it again replaces the profiles it wrote before; everything else in the file is
+kept. Profiles with the same name written by hand are not touched unless --force is given.`,
+	Example: `  cloudctl ide-setup --dry-run
+  cloudctl ide-setup
+  cloudctl ide-setup dev-admin prod-readonly --prefix ""`,
+	Run: func(cmd *cobra.Command, args []string) {
+		secret, err := internal.GetSecret(ideSetupSecret)
+		if err != nil {
+			printer.Error("%s", i18n.T("secret.required"))
+			os.Exit(1)
+		}
+		sessions, err := internal.ListAllSessions(secret)
+		if err != nil {
+			printer.Error("Failed to load sessions: %v", err)
+			os.Exit(1)
+		}
+		byProfile := make(map[string]*internal.AWSSession)
+		for _, s := range sessions {
+			byProfile[s.Profile] = s
+		}
+
+		names := args
+		if len(names) == 0 {
+			for _, s := range sessions {
+				// Root sessions are single-task and never refreshed, so not worth a profile
+				if s.IsRoot() {
+					continue
+				}
+				if !internal.IsCommandSafeName(s.Profile) {
+					printer.Warn("Skipping '%s': its name isn't safe in a credential_process command.", s.Profile)
+					continue
+				}
+				names = append(names, s.Profile)
+			}
+		}
+		if len(names) == 0 {
+			printer.Error("No sessions found. Log in first.")
+			os.Exit(1)
+		}
+
+		var profiles []internal.IDEProfile
+		for _, name := range names {
+			s, ok := byProfile[name]
+			if !ok {
+				printer.Error("Profile '%s' not found.", name)
+				os.Exit(1)
+			}
+			if !internal.IsCommandSafeName(name) {
+				printer.Error("'%s' can't be used in a credential_process command: use only letters, digits and . _ @ + = , : -", name)
+				os.Exit(1)
+			}
+			profiles = append(profiles, internal.IDEProfile{Name: ideSetupPrefix + name, Session: name, Region: sessionRegion(s)})
+		}
+
+		executable, err := os.Executable()
+		if err == nil {
+			executable, err = filepath.EvalSymlinks(executable)
+		}
+		if err != nil {
+			printer.Error("Could not find the cloudctl executable: %v", err)
+			os.Exit(1)
+		}
+
+		if ideSetupDryRun {
+			printer.Print("%s", strings.TrimSuffix(internal.RenderIDEConfig(profiles, executable), "\n"))
+			return
+		}
+		conflicts, err := internal.WriteIDEConfig(profiles, executable, ideSetupForce)
+		if err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+		if len(conflicts) > 0 {
+			printer.Error("%s already has sections cloudctl didn't generate: %s", internal.AWSConfigPath(), strings.Join(conflicts, ", "))
+			printer.Tip("Use another --prefix, or --force to replace them.")
+			os.Exit(1)
+		}
+
+		printer.Success("Wrote %d profile(s) to %s", len(profiles), internal.AWSConfigPath())
+		for _, p := range profiles {
+			printer.Detail("• %-30s → %s", p.Name, p.Session)
+		}
+		printer.Tip("\nIn VS Code or a JetBrains IDE, pick one of these profiles in the AWS Toolkit's connection list.")
+		if runtime.GOOS != "darwin" && os.Getenv("CLOUDCTL_SECRET") != "" {
+			printer.Warn("The IDE must be started with CLOUDCTL_SECRET set, or credential-process can't decrypt the sessions.")
+		}
+		if os.Getenv("CLOUDCTL_HOME") != "" {
+			printer.Warn("The IDE must be started with CLOUDCTL_HOME=%s, or credential-process looks in ~/.cloudctl.", internal.StoreDir())
+		}
+	},
+}
+
+func init() {
+	ideSetupCmd.Flags().StringVar(&ideSetupSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	ideSetupCmd.Flags().StringVar(&ideSetupPrefix, "prefix", internal.DefaultIDEProfilePrefix, "Put before session names to name the profiles")
+	ideSetupCmd.Flags().BoolVar(&ideSetupDryRun, "dry-run", false, "Print the generated blocks instead of writing them")
+	ideSetupCmd.Flags().BoolVar(&ideSetupForce, "force", false, "Replace hand-written sections with the same names")
+	rootCmd.AddCommand(ideSetupCmd)
+}
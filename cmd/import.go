@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importConflict string
+	importDryRun   bool
+	importProfiles []string
+	importUp       bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import settings from other tools",
+}
+
+var importAWSConfigCmd = &cobra.Command{
+	Use:   "aws-config",
+	Short: "Import AWS CLI role profiles as role aliases",
+	Long: `Turn the role profiles of the AWS CLI config file (AWS_CONFIG_FILE when set) into
+cloudctl role aliases named after the profile. A profile's region, duration_seconds and
+mfa_serial become the alias defaults login uses, and each mfa_serial is saved as an MFA
+device alias named after the device. Aliases that already exist with a different value
+are handled by --on-conflict, like role import.
+
+With --up, the up section of the config is filled in to log in to the imported roles of
+the source profile most of them use (with an MFA login first when they have an
+mfa_serial), so ` + "`cloudctl up`" + ` starts a work session right away. An up section that
+already has roles is left alone.`,
+	Example: `  # Preview what would be imported
+  cloudctl import aws-config --dry-run
+
+  # Import two profiles and set up ` + "`cloudctl up`" + ` for them
+  cloudctl import aws-config --profiles prod-admin,dev-admin --up`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := internal.ValidateConflictStrategy(importConflict); err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+
+		profiles := internal.ListAWSProfiles()
+		if len(importProfiles) > 0 {
+			wanted := make(map[string]bool, len(importProfiles))
+			for _, p := range importProfiles {
+				wanted[p] = true
+			}
+			var selected []internal.AWSProfile
+			for _, p := range profiles {
+				// Source profiles stay available to resolve source_profile chains
+				if wanted[p.Name] || p.RoleArn == "" {
+					selected = append(selected, p)
+				}
+				delete(wanted, p.Name)
+			}
+			for name := range wanted {
+				printer.Warn("No AWS profile '%s' in %s", name, internal.AWSConfigPath())
+			}
+			profiles = selected
+		}
+
+		imported := internal.ImportAWSConfigProfiles(profiles)
+		for _, reason := range imported.Skipped {
+			printer.Warn("Skipping %s", reason)
+		}
+		if len(imported.Aliases.Roles) == 0 {
+			printer.Info("No role profiles to import from %s", internal.AWSConfigPath())
+			return
+		}
+
+		current, err := internal.ExportAliases()
+		if err != nil {
+			printer.Error("Failed to load aliases: %v", err)
+			os.Exit(1)
+		}
+		merged, changes := internal.MergeAliases(current, imported.Aliases, importConflict)
+		printer.Print("Role profiles in %s:", internal.AWSConfigPath())
+		counts := printMergeChanges(changes)
+
+		up := imported.UpTemplate()
+		if up != nil {
+			up.Roles = upRolesAfterMerge(up.Roles, changes)
+		}
+		if importUp && (up == nil || len(up.Roles) == 0) {
+			printer.Warn("None of the imported roles has a source_profile; not setting up `cloudctl up`.")
+		} else if importUp {
+			importUp = printUpTemplate(up)
+		}
+
+		if importDryRun {
+			printer.Tip("\nDry run: no changes were saved.")
+			return
+		}
+		if counts["add"]+counts["overwrite"]+counts["rename"] > 0 {
+			if err := internal.SaveAliasBundle(merged); err != nil {
+				printer.Error("Failed to save aliases: %v", err)
+				os.Exit(1)
+			}
+		}
+		if importUp {
+			if err := saveUpTemplate(up); err != nil {
+				printer.Error("Failed to save the up section: %v", err)
+				os.Exit(1)
+			}
+		}
+
+		printer.Success("\nImported: %d added, %d overwritten, %d renamed, %d skipped",
+			counts["add"], counts["overwrite"], counts["rename"], counts["skip"])
+		if importUp {
+			printer.Tip("Start the work session with: cloudctl up")
+		} else {
+			printer.Tip("Log in with: cloudctl login --source <profile> --role <alias>")
+		}
+	},
+}
+
+// printUpTemplate shows the up section import would write and reports whether it can
+// be written: an existing up section with roles is kept.
+func printUpTemplate(up *internal.UpConfig) bool {
+	if existing := internal.CurrentConfig().Up; len(existing.Roles) > 0 {
+		printer.Warn("The up section already has %d roles; leaving it alone.", len(existing.Roles))
+		return false
+	}
+	printer.Print("\nup section:")
+	printer.Detail("source: %s", up.Source)
+	if up.MFADevice != "" {
+		printer.Detail("mfa_device: %s", up.MFADevice)
+	}
+	roles := make([]string, 0, len(up.Roles))
+	for _, r := range up.Roles {
+		roles = append(roles, r.Role)
+	}
+	printer.Detail("roles: %s", strings.Join(roles, ", "))
+	return true
+}
+
+// upRolesAfterMerge follows renamed aliases and drops the roles whose alias kept
+// another role, so the up section logs in to the imported roles.
+func upRolesAfterMerge(roles []internal.UpRole, changes []internal.MergeChange) []internal.UpRole {
+	renamed := map[string]string{}
+	kept := map[string]bool{}
+	for _, c := range changes {
+		if c.Kind != "role" {
+			continue
+		}
+		switch c.Action {
+		case "rename":
+			renamed[c.Name] = c.NewName
+		case "skip":
+			kept[c.Name] = true
+		}
+	}
+	var result []internal.UpRole
+	for _, r := range roles {
+		if kept[r.Role] {
+			continue
+		}
+		if name, ok := renamed[r.Role]; ok {
+			r.Role = name
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// saveUpTemplate writes the up section's source, MFA device and roles, keeping its other
+// settings.
+func saveUpTemplate(up *internal.UpConfig) error {
+	roles, err := json.Marshal(up.Roles)
+	if err != nil {
+		return err
+	}
+	if err := internal.SetConfigValue("up.source", up.Source); err != nil {
+		return err
+	}
+	if err := internal.SetConfigValue("up.mfa_device", up.MFADevice); err != nil {
+		return err
+	}
+	return internal.SetConfigValue("up.roles", string(roles))
+}
+
+func init() {
+	importAWSConfigCmd.Flags().StringVar(&importConflict, "on-conflict", internal.ConflictSkip, "How to handle existing aliases with a different value: skip, overwrite or rename")
+	importAWSConfigCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would change without saving")
+	importAWSConfigCmd.Flags().StringSliceVar(&importProfiles, "profiles", nil, "Only import these role profiles (comma-separated)")
+	importAWSConfigCmd.Flags().BoolVar(&importUp, "up", false, "Also fill in the up section to log in to the imported roles")
+	importCmd.AddCommand(importAWSConfigCmd)
+	rootCmd.AddCommand(importCmd)
+}
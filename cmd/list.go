@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	listJSON    bool
+	listSort    string
+	listReverse bool
+	listWatch   bool
+)
+
+// listedSession is the JSON shape of one `list --json` entry.
+type listedSession struct {
+	Profile    string     `json:"profile"`
+	Type       string     `json:"type"`
+	Expiration *time.Time `json:"expiration"`
+	Expired    bool       `json:"expired"`
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List stored profiles and expirations without decrypting anything",
+	Long: `List stored profile names, types and expirations from the session index. Unlike status,
+list needs no encryption secret and makes no AWS calls, so it is fast enough for scripts
+and shell completion. Profiles stored by older versions show an unknown expiry until the
+next status or refresh.
+
+With --watch the list is printed again whenever a session is added, refreshed or removed,
+by any cloudctl process, until Ctrl-C.`,
+	Example: `  cloudctl list
+  cloudctl list --sort expiration
+  cloudctl list --json
+  cloudctl list --watch`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if listSort != "name" && listSort != "expiration" {
+			printer.Error("Invalid --sort '%s' (use name or expiration)", listSort)
+			os.Exit(1)
+		}
+		if err := printSessionList(); err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+		if !listWatch {
+			return
+		}
+
+		events, err := internal.WatchStore(cmd.Context())
+		if err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+		redraw := !useJSON(listJSON) && term.IsTerminal(int(os.Stdout.Fd()))
+		for range events {
+			// A change often touches several sessions; print once for all of them
+			for drained := false; !drained; {
+				select {
+				case _, ok := <-events:
+					drained = !ok
+				default:
+					drained = true
+				}
+			}
+			if redraw {
+				fmt.Print("\033[H\033[2J")
+			}
+			if err := printSessionList(); err != nil {
+				printer.Error("%v", err)
+			}
+		}
+	},
+}
+
+// printSessionList prints the stored sessions, sorted by --sort.
+func printSessionList() error {
+	sessions, err := internal.ListIndexedSessions()
+	if err != nil {
+		return err
+	}
+
+	if listSort == "expiration" {
+		// Unknown expirations sort last
+		sort.SliceStable(sessions, func(i, j int) bool {
+			if sessions[i].Known() != sessions[j].Known() {
+				return sessions[i].Known()
+			}
+			return sessions[i].Expiration.Before(sessions[j].Expiration)
+		})
+	}
+	if listReverse {
+		for i, j := 0, len(sessions)-1; i < j; i, j = i+1, j-1 {
+			sessions[i], sessions[j] = sessions[j], sessions[i]
+		}
+	}
+
+	now := time.Now()
+	if useJSON(listJSON) {
+		out := make([]listedSession, 0, len(sessions))
+		for _, s := range sessions {
+			entry := listedSession{Profile: s.Profile, Type: "role"}
+			if s.MFA {
+				entry.Type = "mfa"
+			}
+			if s.Known() {
+				exp := s.Expiration
+				entry.Expiration = &exp
+				entry.Expired = !exp.After(now)
+			}
+			out = append(out, entry)
+		}
+		printer.Result(out)
+		return nil
+	}
+
+	if len(sessions) == 0 {
+		printer.Info("%s No stored sessions found.", internal.Icon(internal.IconEmpty))
+		return nil
+	}
+	printer.Print("%-30s %-5s %-22s %s", "PROFILE", "TYPE", "EXPIRES", "REMAINING")
+	for _, s := range sessions {
+		kind := "role"
+		if s.MFA {
+			kind = "mfa"
+		}
+		expires, remaining := "unknown", "-"
+		if s.Known() {
+			expires = internal.FormatTime(s.Expiration)
+			remaining = "expired"
+			if left := s.Expiration.Sub(now); left > 0 {
+				remaining = internal.FormatDurationShort(left)
+			}
+		}
+		printer.Print("%-30s %-5s %-22s %s", s.Profile, kind, expires, remaining)
+	}
+	return nil
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Output as JSON")
+	listCmd.Flags().StringVar(&listSort, "sort", "name", "Sort by 'name' or 'expiration'")
+	listCmd.Flags().BoolVar(&listReverse, "reverse", false, "Reverse the sort order")
+	listCmd.Flags().BoolVar(&listWatch, "watch", false, "Print the list again whenever a session changes, until Ctrl-C")
+	rootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the credential store until 'cloudctl unlock'",
+	Long: `Lock the credential store immediately. While locked, no command (including the
+daemon and shell prompt) can read the encryption secret until you run 'cloudctl unlock'.
+Set security.auto_lock_minutes in ~/.cloudctl/config.json to lock automatically after inactivity.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := internal.Lock(); err != nil {
+			fmt.Printf("❌ Failed to lock store: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("🔒 Store locked. Run 'cloudctl unlock' to continue.")
+	},
+}
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock the credential store after re-authenticating",
+	Long: `Unlock a store that was locked manually or by security.auto_lock_minutes.
+On macOS the secret is read from the Keychain through the system prompt (password or Touch ID);
+otherwise, or if that fails, you are asked to enter the encryption secret.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		state, err := internal.LoadLockState()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if !state.IsLocked(time.Now(), internal.AutoLockTimeout()) {
+			fmt.Println("✅ Store is not locked.")
+			return
+		}
+
+		// A background process must never be able to unlock on its own
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Println("❌ 'cloudctl unlock' must be run from an interactive terminal.")
+			os.Exit(1)
+		}
+
+		expected, err := internal.ResolveSecretForUnlock()
+		if err != nil {
+			fmt.Println("❌ No encryption secret is configured, nothing to unlock against.")
+			fmt.Println("💡 Set CLOUDCTL_SECRET or run 'cloudctl init' on macOS to use the Keychain.")
+			os.Exit(1)
+		}
+
+		if !verifyUnlock(expected) {
+			fmt.Println("❌ Authentication failed. Store remains locked.")
+			os.Exit(1)
+		}
+
+		if err := internal.Unlock(); err != nil {
+			fmt.Printf("❌ Failed to unlock store: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("🔓 Store unlocked.")
+		if timeout := internal.AutoLockTimeout(); timeout > 0 {
+			fmt.Printf("💡 It locks again after %v without cloudctl activity.\n", timeout)
+		}
+	},
+}
+
+// verifyUnlock re-authenticates the user against the configured secret, through the
+// Keychain prompt on macOS and by asking for the secret everywhere else.
+func verifyUnlock(expected string) bool {
+	if internal.IsMacOS() {
+		fmt.Println("🔐 Approve Keychain access to unlock...")
+		if secret, err := internal.ReadKeychainSecretWithPrompt(); err == nil {
+			return subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) == 1
+		}
+		fmt.Println("⚠️  Keychain access failed, falling back to the secret.")
+	}
+
+	fmt.Fprint(os.Stderr, "Encryption secret: ")
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(b, []byte(expected)) == 1
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(unlockCmd)
+}
@@ -3,490 +3,615 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
 	"github.com/chukul/cloudctl/internal/ui"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
-var (
-	sourceProfile string // Base AWS CLI profile for assume role
+// loginOptions are the flags of `cloudctl login`. Each command made by newLoginCmd binds
+// its own, so they can't leak into another command or a concurrent invocation.
+type loginOptions struct {
+	source        string // Base AWS CLI profile for assume role
 	profile       string // The name for storing the assumed session
 	roleArn       string
 	mfaArn        string
-	secretKey     string
+	secret        string
 	region        string
 	openConsole   bool
-	loginDuration int32
-	sessionDir    = filepath.Join(os.Getenv("HOME"), ".cloudctl", "sessions")
-)
+	printOnly     bool
+	duration      int32
+	group         string
+	approval      string
+	justification string
+	checkAccess   bool
+	selfDestruct  time.Duration
+	replace       bool
+	json          bool
+}
 
-// loginCmd implements `cloudctl login`
-var loginCmd = &cobra.Command{
-	Use:   "login",
-	Short: "Assume an AWS role and store credentials locally (supports MFA)",
-	Run: func(cmd *cobra.Command, args []string) {
-		// Interactive prompts for missing parameters
-		if sourceProfile == "" {
-			awsProfiles := listAWSProfiles()
-
-			// Get secret to list full session info for filtering/labeling
-			secret, _ := internal.GetSecret("")
-			allSessions, _ := internal.ListAllSessions(secret)
-
-			var options []string
-			optionToProfile := make(map[string]string)
-			seen := make(map[string]bool)
-
-			// 1. Add AWS Profiles
-			for _, p := range awsProfiles {
-				displayName := fmt.Sprintf("%-15s (AWS Profile)", p)
-				options = append(options, displayName)
-				optionToProfile[displayName] = p
-				seen[p] = true
-			}
+// loginResult is the JSON shape of `login --json`.
+type loginResult struct {
+	Profile      string     `json:"profile"`
+	Role         string     `json:"role"`
+	Account      string     `json:"account"`
+	AccountAlias string     `json:"account_alias,omitempty"`
+	Expiration   time.Time  `json:"expiration"`
+	Source       string     `json:"source"`
+	Encrypted    bool       `json:"encrypted"`
+	SelfDestruct *time.Time `json:"self_destruct,omitempty"`
+	Access       string     `json:"access,omitempty"`
+}
 
-			// 2. Add CloudCtl Sessions (only active ones)
-			now := time.Now()
-			for _, s := range allSessions {
-				if s.Expiration.After(now) {
-					label := "CloudCtl Session"
-					if s.RoleArn == "MFA-Session" {
-						label = "MFA Session"
-					}
+var sessionDir = filepath.Join(internal.StoreDir(), "sessions")
 
-					displayName := fmt.Sprintf("%-15s (%s)", s.Profile, label)
-					// If a profile name exists in both AWS and CloudCtl, show both with different labels
-					options = append(options, displayName)
-					optionToProfile[displayName] = s.Profile
-				}
-			}
+var loginCmd = newLoginCmd()
+
+// newLoginCmd builds `cloudctl login` with its own options.
+func newLoginCmd() *cobra.Command {
+	o := &loginOptions{}
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Assume an AWS role and store credentials locally (supports MFA)",
+		Run:   o.run,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&o.source, "source", "", "Source AWS CLI profile for base credentials")
+	flags.StringVar(&o.profile, "profile", "", "Name to store the new session as (default: derived from the role alias or ARN)")
+	flags.StringVar(&o.roleArn, "role", "", "Target IAM role ARN to assume")
+	flags.StringVar(&o.mfaArn, "mfa", "", "MFA device ARN (optional)")
+	flags.StringVar(&o.secret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Optional secret for encryption (or set CLOUDCTL_SECRET env var)")
+	flags.StringVar(&o.region, "region", "ap-southeast-1", "AWS region (default: ap-southeast-1)")
+	flags.StringVar(&o.group, "group", "", "Only offer role aliases from this group in the interactive picker")
+	flags.StringVar(&o.justification, "justification", "", "Reason for logging in to a break-glass role, e.g. an incident reference")
+	flags.StringVar(&o.approval, "approval", "", "Approval token from 'cloudctl approve', for roles under dual control")
+	flags.BoolVar(&o.checkAccess, "check-access", false, "Classify the role as read-only, admin or custom from its attached policies")
+	flags.BoolVar(&o.openConsole, "open", false, "Automatically open AWS Console after login")
+	flags.BoolVar(&o.printOnly, "print-only", false, "With --open, print the console URL instead of launching a browser (e.g. over SSH)")
+	flags.DurationVar(&o.selfDestruct, "self-destruct", 0, "Stop using and delete the session after this long (e.g. 2h), before it expires")
+	flags.BoolVar(&o.replace, "replace", false, "Replace an active session of another role stored under the same name")
+	flags.Int32Var(&o.duration, "duration", 3600, "Session duration in seconds (default: 3600 = 1 hr, max: 43200 = 12 hrs)")
+	flags.BoolVar(&o.json, "json", false, "Print the stored session as JSON on stdout, with messages on stderr")
+	return cmd
+}
 
-			sort.Strings(options)
+// run implements `cloudctl login`.
+func (o *loginOptions) run(cmd *cobra.Command, args []string) {
+	useJSON(o.json)
 
-			if len(options) > 0 {
-				selected, err := ui.SelectProfile("Select Source Profile", options)
-				if err != nil {
-					return
+	// Interactive prompts for missing parameters
+	if o.source == "" {
+		o.source = defaultAmbientSource()
+	}
+	if o.source == "" {
+		// Get secret to list full session info for filtering/labeling
+		secret, _ := internal.GetSecret("")
+		allSessions, _ := internal.ListAllSessions(secret)
+
+		// 1. Add AWS Profiles
+		options, optionToProfile := awsProfileOptions()
+
+		// 2. Add CloudCtl Sessions (only active ones)
+		now := time.Now()
+		for _, s := range allSessions {
+			if s.Expiration.After(now) {
+				label := "CloudCtl Session"
+				if s.RoleArn == "MFA-Session" {
+					label = "MFA Session"
 				}
-				sourceProfile = optionToProfile[selected]
+
+				displayName := fmt.Sprintf("%-15s (%s)", s.Profile, label)
+				// If a profile name exists in both AWS and CloudCtl, show both with different labels
+				options = append(options, displayName)
+				optionToProfile[displayName] = s.Profile
 			}
 		}
 
-		if profile == "" {
-			var err error
-			profile, err = ui.GetInput("Enter Session Name", "prod-admin", false)
+		sort.Strings(options)
+
+		// Credentials already in the environment come first, e.g. from another tool
+		if option, source := ambientSourceOption(); option != "" {
+			options = append([]string{option}, options...)
+			optionToProfile[option] = source
+		}
+
+		if len(options) > 0 {
+			selected, err := ui.SelectProfile("Select Source Profile", options)
 			if err != nil {
 				return
 			}
+			o.source = optionToProfile[selected]
 		}
+	}
 
-		if roleArn == "" {
-			// Check for saved roles
-			roles, _ := internal.ListRoles()
-			if len(roles) > 0 {
-				var roleNames []string
-				for name, arn := range roles {
-					roleNames = append(roleNames, fmt.Sprintf("%s (%s)", name, arn))
-				}
-				sort.Strings(roleNames)
-
-				// Add option to enter manually
-				roleNames = append(roleNames, "Enter Role ARN Manually...")
-
-				selected, err := ui.SelectProfile("Select Role to Assume", roleNames)
-				if err == nil {
-					if selected == "Enter Role ARN Manually..." {
-						// proceed to prompt
-					} else {
-						// Parse "name (arn)"
-						parts := strings.SplitN(selected, " (", 2)
-						// Trim matching closing paren
-						rawArn := strings.TrimSuffix(parts[1], ")")
-						roleArn = rawArn
-						fmt.Printf("🎭 Selected Role: %s\n", selected)
-					}
-				}
+	// Alias metadata (region, duration, MFA) used as defaults below
+	var alias *internal.RoleAlias
+	var aliasName string
+
+	if o.roleArn == "" {
+		// Check for saved roles
+		roles, _ := internal.ListRoleAliases()
+		roles = pickRoleGroup(roles, o.group, cmd.Flags().Changed("group"))
+		if len(roles) > 0 {
+			var roleNames []string
+			for name, r := range roles {
+				roleNames = append(roleNames, fmt.Sprintf("%s (%s)", name, r.ARN))
 			}
-
-			if roleArn == "" {
-				var err error
-				roleArn, err = ui.GetInput("Enter Role ARN", "arn:aws:iam::123456789012:role/RoleName", false)
-				if err != nil {
-					return
+			sort.Strings(roleNames)
+
+			// Add option to enter manually
+			roleNames = append(roleNames, "Enter Role ARN Manually...")
+
+			selected, err := ui.SelectProfile("Select Role to Assume", roleNames)
+			if err == nil {
+				if selected == "Enter Role ARN Manually..." {
+					// proceed to prompt
+				} else {
+					// Parse "name (arn)"
+					parts := strings.SplitN(selected, " (", 2)
+					// Trim matching closing paren
+					rawArn := strings.TrimSuffix(parts[1], ")")
+					o.roleArn = rawArn
+					if r, ok := roles[parts[0]]; ok {
+						alias, aliasName = &r, parts[0]
+					}
+					printer.Info("%s Selected Role: %s", internal.Icon(internal.IconRole), selected)
 				}
 			}
-		} else {
-			// Check if provided roleArn is an alias
-			if realArn, found := internal.GetRole(roleArn); found {
-				fmt.Printf("🎭 Using stored role alias '%s'\n", roleArn)
-				roleArn = realArn
-			}
 		}
 
-		if sourceProfile == "" || profile == "" || roleArn == "" {
-			fmt.Println("❌ Missing required parameters")
-			if sourceProfile == "" {
-				fmt.Println("   --source: Source AWS profile or cloudctl session")
-			}
-			if profile == "" {
-				fmt.Println("   --profile: Name for this session")
-			}
-			if roleArn == "" {
-				fmt.Println("   --role: IAM role ARN to assume")
+		if o.roleArn == "" {
+			var err error
+			o.roleArn, err = ui.GetInput("Enter Role ARN", "arn:aws:iam::123456789012:role/RoleName", false)
+			if err != nil {
+				return
 			}
-			fmt.Println("\n💡 Example:")
-			fmt.Println("   cloudctl login --source default --profile prod-admin --role arn:aws:iam::123456789012:role/AdminRole")
-			os.Exit(1)
 		}
-
-		// Create session directory if not exists
-		if err := os.MkdirAll(sessionDir, 0700); err != nil {
-			fmt.Printf("❌ Failed to create session directory: %v\n", err)
-			fmt.Printf("💡 Check permissions for: %s\n", sessionDir)
-			os.Exit(1)
+	} else {
+		// Check if provided roleArn is an alias
+		if r, found := internal.GetRoleAlias(o.roleArn); found {
+			printer.Info("%s Using stored role alias '%s'", internal.Icon(internal.IconRole), o.roleArn)
+			aliasName = o.roleArn
+			o.roleArn = r.ARN
+			alias = &r
 		}
+	}
 
-		// Prepare config (blocking, but usually fast)
-		ctx := context.TODO()
-		var cfg aws.Config
-		var err error
-
-		// Config loading logic...
+	// Explicit flags always win over alias defaults
+	if alias != nil {
+		if alias.Description != "" {
+			printer.Detail("%s", alias.Description)
+		}
+		if alias.Region != "" && !cmd.Flags().Changed("region") {
+			o.region = alias.Region
+		}
+		if alias.Duration > 0 && !cmd.Flags().Changed("duration") {
+			o.duration = alias.Duration
+		}
+	}
 
-		// Detect or request secret
-		var secret string
-		// Only check for secret if user provided one manually, or if they haven't disabled encryption
-		// Actually, logic is: try to get secret. If found, use it. If not, ask to create (on macOS) or fail/fallback to plain file ??
-		// Original logic: "if secretKey != """.
-		// New Logic: Always try to get a secure secret if we are going to store credentials securely.
-		// However, we must respect the existing flow.
+	// Then the per-account defaults from the config file
+	accountID := internal.RoleAccountID(o.roleArn)
+	consoleRegion := o.region
+	if !cmd.Flags().Changed("region") && (alias == nil || alias.Region == "") {
+		if r := internal.CurrentConfig().AccountRegion(accountID); r != "" {
+			o.region = r
+		}
+		consoleRegion = o.region
+		if r := internal.CurrentConfig().AccountConsoleRegion(accountID); r != "" {
+			consoleRegion = r
+		}
+	}
 
-		useEncryption := false
-		secret, err = internal.GetSecret(secretKey)
-		if err == nil {
-			useEncryption = true
-		} else {
-			// No secret found. If on macOS, offer to setup keychain.
-			if internal.IsMacOS() {
-				// Only prompt if we are in interactive mode (profile was not empty means likely non-interactive? No, args check)
-				fmt.Println("🔑 No encryption secret found.")
-				fmt.Println("   Would you like to generate a secure key and store it in your System Keychain? (y/n)")
-				var response string
-				fmt.Scanln(&response)
-				if strings.ToLower(response) == "y" {
-					newSecret, keychainErr := internal.SetupKeychain()
-					if keychainErr != nil {
-						fmt.Printf("❌ Failed to setup keychain: %v\n", keychainErr)
-						// Fallback to unencrypted
-					} else {
-						secret = newSecret
-						useEncryption = true
-						fmt.Println("✅ Secure key generated and stored in Keychain.")
-					}
-				}
+	// Without --profile the session is named after the alias or role, which the user
+	// can change when interactive
+	if o.profile == "" && o.roleArn != "" {
+		o.profile = internal.DefaultSessionName(o.roleArn, aliasName, alias, sessionNameTaken(o.secret, o.roleArn))
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			name, err := ui.GetInputValue("Session Name", o.profile)
+			if err != nil {
+				return
 			}
+			o.profile = strings.TrimSpace(name)
+		} else {
+			printer.Info("Storing the session as '%s' (choose another name with --profile)", o.profile)
 		}
+	}
 
-		// Config loading logic...
-		if useEncryption {
-			session, sessionErr := internal.LoadCredentials(sourceProfile, secret)
-			if sessionErr == nil {
-				// Source is a cloudctl session, use its credentials
-				cfg, err = config.LoadDefaultConfig(ctx,
-					config.WithRegion(region),
-					config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-						session.AccessKey,
-						session.SecretKey,
-						session.SessionToken,
-					)),
-				)
-				if err != nil {
-					fmt.Printf("❌ Failed to configure AWS SDK with session credentials: %v\n", err)
-					os.Exit(1)
-				}
-			} else {
-				// Source is an AWS CLI profile
-				cfg, err = config.LoadDefaultConfig(ctx,
-					config.WithSharedConfigProfile(sourceProfile),
-					config.WithRegion(region))
-				if err != nil {
-					fmt.Printf("❌ Profile '%s' not found\n", sourceProfile)
-
-					// Try to list available profiles
-					if profiles := listAWSProfiles(); len(profiles) > 0 {
-						fmt.Println("\n💡 Available AWS profiles:")
-						for _, p := range profiles {
-							fmt.Printf("   • %s\n", p)
-						}
-					}
-
-					// Check for cloudctl sessions
-					if sessions, _ := internal.ListProfiles(); len(sessions) > 0 {
-						fmt.Println("\n💡 Available cloudctl sessions:")
-						for _, s := range sessions {
-							fmt.Printf("   • %s\n", s)
-						}
-					}
+	if o.source == "" || o.profile == "" || o.roleArn == "" {
+		printer.Error("%s", i18n.T("login.missing_params"))
+		if o.source == "" {
+			printer.Detail("--source: Source AWS profile or cloudctl session")
+		}
+		if o.profile == "" {
+			printer.Detail("--profile: Name for this session")
+		}
+		if o.roleArn == "" {
+			printer.Detail("--role: IAM role ARN to assume")
+		}
+		printer.Tip("\n%s", i18n.T("common.example"))
+		printer.Detail("cloudctl login --source default --profile prod-admin --role arn:aws:iam::123456789012:role/AdminRole")
+		os.Exit(1)
+	}
+	if o.selfDestruct < 0 || (o.selfDestruct > 0 && o.selfDestruct >= time.Duration(o.duration)*time.Second) {
+		printer.Error("--self-destruct must be shorter than the session duration (%s)",
+			internal.FormatDurationShort(time.Duration(o.duration)*time.Second))
+		os.Exit(1)
+	}
+	if _, err := internal.ParseRoleARN(o.roleArn); err != nil {
+		printer.Error("%v", err)
+		printer.Tip("Use a role alias (cloudctl role list) or a full role ARN.")
+		os.Exit(1)
+	}
+	if !checkSessionName(&o.profile, o.roleArn, o.secret, o.replace, true) {
+		return
+	}
 
-					fmt.Println("\n💡 To create a new profile:")
-					fmt.Println("   aws configure --profile", sourceProfile)
-					os.Exit(1)
-				}
-			}
+	// Dual-control roles need a teammate's approval or a delayed request before anything else
+	dualControl := internal.CurrentConfig().RequiresDualControl(o.roleArn)
+	var dualControlUse internal.AuditEvent
+	if dualControl {
+		var err error
+		if dualControlUse, err = checkDualControl(o.roleArn, o.approval); err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+		if dualControlUse.Approver != "" {
+			printer.Success("Dual control: approved by %s", dualControlUse.Approver)
 		} else {
-			// No secret provided, try AWS CLI profile
-			cfg, err = config.LoadDefaultConfig(ctx,
-				config.WithSharedConfigProfile(sourceProfile),
-				config.WithRegion(region))
-			if err != nil {
-				fmt.Printf("❌ Profile '%s' not found\n", sourceProfile)
-
-				if profiles := listAWSProfiles(); len(profiles) > 0 {
-					fmt.Println("\n💡 Available AWS profiles:")
-					for _, p := range profiles {
-						fmt.Printf("   • %s\n", p)
-					}
-				}
+			printer.Success("Dual control: request delay has passed")
+		}
+	}
 
-				fmt.Println("\n💡 To create a new profile:")
-				fmt.Println("   aws configure --profile", sourceProfile)
-				os.Exit(1)
-			}
+	// Break-glass roles need a justification, which travels with the session as a tag
+	breakGlass := internal.CurrentConfig().IsBreakGlass(o.roleArn)
+	var justification string
+	if breakGlass {
+		justification = o.justification
+		if justification == "" && term.IsTerminal(int(os.Stdin.Fd())) {
+			printer.Warn("%s is a break-glass role.", o.roleArn)
+			justification, _ = ui.GetInput("Justification", "INC-1234: restore the orders database", false)
+		}
+		var err error
+		if justification, err = internal.ValidateJustification(justification); err != nil {
+			printer.Error("%s is a break-glass role: %v", o.roleArn, err)
+			printer.Tip("Pass it with --justification \"...\"")
+			os.Exit(1)
 		}
+	}
 
-		// Handle MFA if provided
-		if mfaArn != "" {
-			fmt.Printf("🔒 MFA device detected: %s\n", mfaArn)
-			mfaCode := readMFACode()
+	// Create session directory if not exists
+	if err := os.MkdirAll(sessionDir, 0700); err != nil {
+		printer.Error("Failed to create session directory: %v", err)
+		printer.Tip("Check permissions for: %s", sessionDir)
+		os.Exit(1)
+	}
 
-			stsClient := sts.NewFromConfig(cfg)
-			input := &sts.GetSessionTokenInput{
-				DurationSeconds: aws.Int32(3600),
-				SerialNumber:    &mfaArn,
-				TokenCode:       &mfaCode,
-			}
+	ctx := cmd.Context()
+	secret, useEncryption := loginSecret(o.secret)
 
-			result, err := stsClient.GetSessionToken(ctx, input)
-			if err != nil {
-				fmt.Printf("❌ MFA authentication failed: %v\n", err)
-				fmt.Println("\n💡 Common issues:")
-				fmt.Println("   • Check your MFA code is current (not expired)")
-				fmt.Println("   • Verify MFA device ARN is correct")
-				fmt.Println("   • Ensure device time is synchronized")
-				fmt.Printf("   • MFA ARN format: arn:aws:iam::<account-id>:mfa/<username>\n")
-				os.Exit(1)
-			}
+	// Without a secret only AWS CLI profiles can be sources
+	sourceSecret := ""
+	if useEncryption {
+		sourceSecret = secret
+	}
+	src, err := internal.LoadLoginSource(ctx, o.source, sourceSecret, o.region)
+	if err != nil {
+		if internal.IsBuiltinSource(o.source) || errors.Is(err, internal.ErrSourceExpired) || errors.Is(err, internal.ErrSourceSelfDestructed) {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+		printer.Error("%s", i18n.T("profile.not_found", o.source))
+		printSourceHints(o.source, useEncryption)
+		os.Exit(1)
+	}
 
-			cfg.Credentials = aws.NewCredentialsCache(
-				credentials.NewStaticCredentialsProvider(
-					*result.Credentials.AccessKeyId,
-					*result.Credentials.SecretAccessKey,
-					*result.Credentials.SessionToken,
-				),
-			)
-			fmt.Println("✅ MFA verification successful.")
+	// An alias that requires MFA needs a device unless the source already is an MFA session
+	if alias != nil && alias.MFARequired && o.mfaArn == "" && !src.MFA {
+		printer.Info("%s This role alias requires MFA.", internal.Icon(internal.IconMFA))
+		o.mfaArn, err = selectMFADevice()
+		if err != nil || o.mfaArn == "" {
+			return
 		}
+	}
 
-		// Assume target IAM role with spinner
-		stsClient := sts.NewFromConfig(cfg)
-		sessionName := profile // Use profile name as session name
-		duration := loginDuration
-
-		res, err := ui.Spin(fmt.Sprintf("Assuming role %s...", roleArn), func() (any, error) {
-			return stsClient.AssumeRole(ctx, &sts.AssumeRoleInput{
-				RoleArn:         &roleArn,
-				RoleSessionName: &sessionName,
-				DurationSeconds: &duration,
-			})
-		})
-
-		if err != nil {
-			fmt.Printf("❌ Failed to assume role: %v\n", err)
-			fmt.Println("\n💡 Common issues:")
-			fmt.Println("   • Check the role ARN is correct")
-			fmt.Println("   • Verify the role's trust policy allows your source identity")
-			fmt.Println("   • Ensure your source credentials have sts:AssumeRole permission")
-			fmt.Println("   • Check if the role requires MFA (use --mfa flag)")
-			fmt.Printf("\n💡 Role ARN format: arn:aws:iam::<account-id>:role/<role-name>\n")
-			os.Exit(1)
+	opts := internal.RoleLoginOptions{
+		Profile:       o.profile,
+		RoleArn:       o.roleArn,
+		Source:        o.source,
+		SourceSession: src.Session,
+		Region:        o.region,
+		Duration:      o.duration,
+		MFASerial:     o.mfaArn,
+		Justification: justification,
+		SelfDestruct:  o.selfDestruct,
+		CheckAccess:   o.checkAccess,
+		Warn:          printer.Warn,
+	}
+	// Check the policy before asking for an MFA code it would make pointless
+	if err := internal.CheckAssumePolicy(opts.AssumeRequest()); err != nil {
+		printer.Error("%v", err)
+		os.Exit(1)
+	}
+	if o.mfaArn != "" {
+		printer.Info("%s MFA device detected: %s", internal.Icon(internal.IconMFA), o.mfaArn)
+		opts.TokenCode = readMFACode()
+	}
+
+	// Assume target IAM role with spinner
+	res, err := ui.Spin(ctx, fmt.Sprintf("Assuming role %s...", o.roleArn), func(ctx context.Context) (any, error) {
+		return internal.LoginRole(ctx, src.Config, opts)
+	})
+	if internal.IsMFAError(err) {
+		printer.Error("%s", mfaFailedMessage(err))
+		printMFAIssues(err)
+		os.Exit(1)
+	}
+	if err != nil {
+		printer.Error("%s", i18n.T("login.assume_failed", err))
+		printer.Tip("\n%s", i18n.T("common.issues"))
+		printer.Detail("• Check the role ARN is correct")
+		printer.Detail("• Verify the role's trust policy allows your source identity")
+		printer.Detail("• Ensure your source credentials have sts:AssumeRole permission")
+		printer.Detail("• Check if the role requires MFA (use --mfa flag)")
+		if breakGlass {
+			printer.Detail("• Break-glass logins need sts:TagSession and sts:SetSourceIdentity in the role's trust policy")
 		}
+		printer.Tip("\nRole ARN format: arn:aws:iam::<account-id>:role/<role-name>")
+		os.Exit(1)
+	}
+	if o.mfaArn != "" {
+		printer.Success("%s", i18n.T("mfa.verified"))
+	}
+	session := res.(*internal.AWSSession)
 
-		roleResult, ok := res.(*sts.AssumeRoleOutput)
-		if !ok || roleResult == nil {
-			fmt.Println("❌ Internal error: invalid response from AssumeRole")
+	if useEncryption {
+		if err := internal.StoreSession(ctx, session, secret, warnStderr); err != nil {
+			printer.Error("Failed to save encrypted session: %v", err)
+			printer.Tip("Check permissions for: %s", internal.StoreDir())
 			os.Exit(1)
 		}
-		expiration := *roleResult.Credentials.Expiration
-
-		session := &internal.AWSSession{
-			Profile:       profile,
-			AccessKey:     *roleResult.Credentials.AccessKeyId,
-			SecretKey:     *roleResult.Credentials.SecretAccessKey,
-			SessionToken:  *roleResult.Credentials.SessionToken,
-			Expiration:    expiration,
-			RoleArn:       roleArn,
-			SourceProfile: sourceProfile,
-			Region:        region,
-			MfaArn:        mfaArn,
-			Duration:      duration,
+		printer.Success("%s", i18n.T("login.stored_encrypted", o.profile))
+	} else {
+		sessionFile := filepath.Join(sessionDir, fmt.Sprintf("%s.json", o.profile))
+		data, _ := json.MarshalIndent(session, "", "  ")
+		if err := os.WriteFile(sessionFile, data, 0600); err != nil {
+			log.Fatalf(internal.Icon(internal.IconError)+" Failed to write session file: %v", err)
 		}
+		printer.Success("%s", i18n.T("login.stored", o.profile))
+	}
 
-		if useEncryption {
-			if err := internal.SaveCredentials(profile, session, secret); err != nil {
-				fmt.Printf("❌ Failed to save encrypted session: %v\n", err)
-				fmt.Printf("💡 Check permissions for: %s\n", filepath.Join(os.Getenv("HOME"), ".cloudctl"))
-				os.Exit(1)
-			}
-			fmt.Printf("✅ Encrypted session stored as '%s'\n", profile)
-		} else {
-			sessionFile := filepath.Join(sessionDir, fmt.Sprintf("%s.json", profile))
-			data, _ := json.MarshalIndent(session, "", "  ")
-			if err := os.WriteFile(sessionFile, data, 0600); err != nil {
-				log.Fatalf("❌ Failed to write session file: %v", err)
+	// The approval or delayed request is only used up now that the session is stored
+	if dualControl {
+		for _, e := range []internal.AuditEvent{dualControlUse, {
+			Event: internal.AuditLogin, Role: o.roleArn, Profile: o.profile, Approver: dualControlUse.Approver,
+		}} {
+			if err := internal.AppendAudit(e); err != nil {
+				printer.Warn("%v", err)
 			}
-			fmt.Printf("✅ Session stored as '%s'\n", profile)
 		}
+	}
 
-		remaining := time.Until(expiration).Round(time.Minute)
-		fmt.Printf("   Role: %s\n", roleArn)
-		fmt.Printf("   Source: %s\n", sourceProfile)
-		fmt.Printf("   Expires: %s (%v remaining)\n",
-			internal.FormatBKK(expiration), remaining)
-
-		// Open console if requested
-		if openConsole {
-			fmt.Println("\n🌐 Opening AWS Console...")
-			if err := openAWSConsole(session, region); err != nil {
-				fmt.Printf("⚠️  Failed to open console: %v\n", err)
-				fmt.Println("💡 You can open it manually with: cloudctl console --profile", profile, "--open")
-			}
+	if breakGlass {
+		if err := internal.AppendAudit(internal.AuditEvent{
+			Event: internal.AuditBreakGlass, Role: o.roleArn, Profile: o.profile, Detail: justification,
+		}); err != nil {
+			printer.Warn("%v", err)
+		}
+	}
+
+	printer.Detail("%s", i18n.T("label.role", o.roleArn))
+	printer.Detail("%s", i18n.T("label.account", accountLabel(session)))
+	printer.Detail("%s", i18n.T("label.source", o.source))
+	printer.Detail("%s", i18n.T("label.expires", internal.FormatExpiry(session.Expiration)))
+	if !session.SelfDestruct.IsZero() {
+		printer.Detail("%s", i18n.T("label.self_destruct", internal.FormatExpiry(session.SelfDestruct)))
+	}
+	if session.Access != "" {
+		printer.Detail("%s", i18n.T("label.access", session.Access))
+	}
+
+	result := loginResult{
+		Profile:      o.profile,
+		Role:         session.RoleArn,
+		Account:      internal.SessionAccountID(session),
+		AccountAlias: session.AccountAlias,
+		Expiration:   session.Expiration,
+		Source:       o.source,
+		Encrypted:    useEncryption,
+		Access:       session.Access,
+	}
+	if !session.SelfDestruct.IsZero() {
+		result.SelfDestruct = &session.SelfDestruct
+	}
+	printer.Result(result)
+
+	// Open console if requested
+	if o.openConsole {
+		printer.Info("\n%s Opening AWS Console...", internal.Icon(internal.IconConsole))
+		if err := openAWSConsole(session, consoleRegion, o.printOnly); err != nil {
+			printer.Warn("Failed to open console: %v", err)
+			printer.Tip("You can open it manually with: cloudctl console --profile %s --open", o.profile)
 		}
-	},
+	} else if printer.Format() != internal.FormatJSON && quietLevel == 0 {
+		printConsoleLink(session)
+	}
 }
 
-func openAWSConsole(session *internal.AWSSession, consoleRegion string) error {
-	// Create session JSON
-	sessionJSON := map[string]string{
-		"sessionId":    session.AccessKey,
-		"sessionKey":   session.SecretKey,
-		"sessionToken": session.SessionToken,
+// accountLabel shows the session's account ID with its alias, when login resolved one.
+func accountLabel(session *internal.AWSSession) string {
+	account := internal.SessionAccountID(session)
+	if account == "" {
+		account = "unknown"
 	}
+	if session.AccountAlias != "" {
+		return fmt.Sprintf("%s (%s)", account, session.AccountAlias)
+	}
+	return account
+}
 
-	sessionData, _ := json.Marshal(sessionJSON)
+// printConsoleLink prints the console's /switchrole link for the new session's role and
+// how to sign in. The link signs no one in, so unlike a federation URL it is safe in
+// scrollback and CI logs; `console --open` gets a sign-in token when one is wanted.
+func printConsoleLink(session *internal.AWSSession) {
+	if link, err := internal.SwitchRoleURL(session.RoleArn, session.Profile, ""); err == nil {
+		printer.Detail("%s", i18n.T("label.console", link))
+	}
+	printer.Tip("Sign in to the console with: cloudctl console --profile %s --open", session.Profile)
+}
 
-	// Get signin token
-	federationURL := "https://signin.aws.amazon.com/federation"
-	params := url.Values{}
-	params.Add("Action", "getSigninToken")
-	params.Add("Session", string(sessionData))
+// sessionNameTaken reports the stored sessions that a login to roleArn must not replace:
+// those of another role, and those it can't decrypt.
+func sessionNameTaken(secretFlag, roleArn string) func(name string) bool {
+	secret, _ := internal.GetSecret(secretFlag)
+	profiles, _ := internal.ListProfiles()
+	stored := make(map[string]bool, len(profiles))
+	for _, p := range profiles {
+		stored[p] = true
+	}
+	return func(name string) bool {
+		if !stored[name] {
+			return false
+		}
+		s, err := internal.LoadCredentials(name, secret)
+		return err != nil || s.RoleArn != roleArn
+	}
+}
 
-	resp, err := http.Get(fmt.Sprintf("%s?%s", federationURL, params.Encode()))
+// loginSecret finds the secret to encrypt new sessions with. Without one it offers to
+// create a keychain secret where that is available; false means the session is stored
+// unencrypted.
+func loginSecret(flag string) (string, bool) {
+	secret, err := internal.GetSecret(flag)
+	if err == nil {
+		return secret, true
+	}
+	if !internal.HasKeychain() {
+		return "", false
+	}
+	fmt.Fprintln(os.Stderr, internal.Icon(internal.IconKey)+" No encryption secret found.")
+	fmt.Fprintln(os.Stderr, "   Would you like to generate a secure key and store it in your System Keychain? (y/n)")
+	var response string
+	fmt.Scanln(&response)
+	if strings.ToLower(response) != "y" {
+		return "", false
+	}
+	secret, err = internal.SetupKeychain()
 	if err != nil {
-		return fmt.Errorf("failed to get sign-in token: %w", err)
+		printer.Error("Failed to setup keychain: %v", err)
+		return "", false
+	}
+	printer.Success("Secure key generated and stored in Keychain.")
+	offerRecoveryPhrase(secret)
+	return secret, true
+}
+
+// printSourceHints lists the sources a login could use after the given one wasn't found.
+func printSourceHints(source string, withSessions bool) {
+	if profiles := listAWSProfiles(); len(profiles) > 0 {
+		printer.Tip("\nAvailable AWS profiles:")
+		for _, p := range profiles {
+			printer.Detail("• %s", p)
+		}
 	}
-	defer resp.Body.Close()
+	if withSessions {
+		if sessions, _ := internal.ListProfiles(); len(sessions) > 0 {
+			printer.Tip("\nAvailable cloudctl sessions:")
+			for _, s := range sessions {
+				printer.Detail("• %s", s)
+			}
+		}
+	}
+	printer.Tip("\nTo create a new profile:")
+	printer.Detail("aws configure --profile %v", source)
+}
 
-	body, _ := io.ReadAll(resp.Body)
-	var tokenResp map[string]string
-	if err := json.Unmarshal(body, &tokenResp); err != nil {
-		return fmt.Errorf("failed to parse token response: %w", err)
+// pickRoleGroup narrows the role picker to one group. With --group the choice is made
+// up front; otherwise the user picks a group first when aliases are grouped.
+func pickRoleGroup(roles map[string]internal.RoleAlias, group string, groupFlagSet bool) map[string]internal.RoleAlias {
+	if groupFlagSet {
+		return internal.FilterRolesByGroup(roles, group)
 	}
 
-	signinToken := tokenResp["SigninToken"]
-	if signinToken == "" {
-		return fmt.Errorf("failed to get sign-in token")
+	groups := internal.RoleGroups(roles)
+	if len(groups) == 0 {
+		return roles
 	}
 
-	// Build console URL
-	destination := "https://console.aws.amazon.com/"
-	if consoleRegion != "" {
-		destination = fmt.Sprintf("https://%s.console.aws.amazon.com/console/home?region=%s", consoleRegion, consoleRegion)
+	const allOption = "All roles"
+	const ungroupedOption = "(ungrouped)"
+	options := make([]string, 0, len(groups)+2)
+	for _, g := range groups {
+		options = append(options, fmt.Sprintf("%s (%d)", g, len(internal.FilterRolesByGroup(roles, g))))
+	}
+	if n := len(internal.FilterRolesByGroup(roles, "")); n > 0 {
+		options = append(options, fmt.Sprintf("%s (%d)", ungroupedOption, n))
 	}
-	consoleURL := fmt.Sprintf("%s?Action=login&Issuer=cloudctl&Destination=%s&SigninToken=%s",
-		federationURL, url.QueryEscape(destination), signinToken)
+	options = append(options, allOption)
 
-	// Open in browser
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("open", consoleURL)
-	case "linux":
-		cmd = exec.Command("xdg-open", consoleURL)
-	case "windows":
-		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", consoleURL)
-	default:
-		return fmt.Errorf("unsupported platform")
+	selected, err := ui.SelectProfile("Select Role Group", options)
+	if err != nil || selected == allOption {
+		return roles
 	}
-	return cmd.Start()
+	group = selected[:strings.LastIndex(selected, " (")]
+	if group == ungroupedOption {
+		group = ""
+	}
+	return internal.FilterRolesByGroup(roles, group)
 }
 
-// listAWSProfiles reads AWS CLI profiles from ~/.aws/credentials and ~/.aws/config
-func listAWSProfiles() []string {
-	profiles := make(map[string]bool)
-
-	// Check credentials file
-	credPath := filepath.Join(os.Getenv("HOME"), ".aws", "credentials")
-	if data, err := os.ReadFile(credPath); err == nil {
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-				profile := strings.Trim(line, "[]")
-				profiles[profile] = true
-			}
-		}
+func openAWSConsole(session *internal.AWSSession, consoleRegion string, printOnly bool) error {
+	consoleURL, err := internal.FederatedConsoleURL(session, consoleRegion)
+	if err != nil {
+		return err
 	}
 
-	// Check config file
-	configPath := filepath.Join(os.Getenv("HOME"), ".aws", "config")
-	if data, err := os.ReadFile(configPath); err == nil {
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if strings.HasPrefix(line, "[profile ") && strings.HasSuffix(line, "]") {
-				profile := strings.TrimPrefix(strings.Trim(line, "[]"), "profile ")
-				profiles[profile] = true
-			} else if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") && !strings.Contains(line, " ") {
-				profile := strings.Trim(line, "[]")
-				profiles[profile] = true
-			}
-		}
+	err = internal.ErrBrowserPrintOnly
+	if !printOnly {
+		err = internal.OpenURL(consoleBrowserURL(session.Profile, consoleURL))
 	}
+	if errors.Is(err, internal.ErrBrowserPrintOnly) {
+		printer.Print("Console URL:\n%s", consoleURL)
+		return nil
+	}
+	return err
+}
+
+// listAWSProfiles returns the names of the AWS CLI profiles.
+func listAWSProfiles() []string {
+	profiles := internal.ListAWSProfiles()
+	names := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
 
-	// Convert to sorted slice
-	result := make([]string, 0, len(profiles))
-	for p := range profiles {
-		result = append(result, p)
+// awsProfileOptions labels the AWS CLI profiles for a source picker with where their
+// credentials come from, so static-key profiles can be told from role profiles. It
+// returns the options and the profile each one selects.
+func awsProfileOptions() ([]string, map[string]string) {
+	profiles := internal.ListAWSProfiles()
+	options := make([]string, 0, len(profiles))
+	optionToProfile := make(map[string]string, len(profiles))
+	for _, p := range profiles {
+		option := fmt.Sprintf("%-15s (AWS Profile: %s)", p.Name, p.Kind())
+		options = append(options, option)
+		optionToProfile[option] = p.Name
 	}
-	sort.Strings(result)
-	return result
+	return options, optionToProfile
 }
 
 func init() {
-	loginCmd.Flags().StringVar(&sourceProfile, "source", "", "Source AWS CLI profile for base credentials")
-	loginCmd.Flags().StringVar(&profile, "profile", "", "Name to store the new session as")
-	loginCmd.Flags().StringVar(&roleArn, "role", "", "Target IAM role ARN to assume")
-	loginCmd.Flags().StringVar(&mfaArn, "mfa", "", "MFA device ARN (optional)")
-	loginCmd.Flags().StringVar(&secretKey, "secret", os.Getenv("CLOUDCTL_SECRET"), "Optional secret for encryption (or set CLOUDCTL_SECRET env var)")
-	loginCmd.Flags().StringVar(&region, "region", "ap-southeast-1", "AWS region (default: ap-southeast-1)")
-	loginCmd.Flags().BoolVar(&openConsole, "open", false, "Automatically open AWS Console after login")
-	loginCmd.Flags().Int32Var(&loginDuration, "duration", 3600, "Session duration in seconds (default: 3600 = 1 hr, max: 43200 = 12 hrs)")
 	rootCmd.AddCommand(loginCmd)
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
 	"github.com/chukul/cloudctl/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -31,7 +33,7 @@ var logoutCmd = &cobra.Command{
 		if !logoutAll && logoutProfile == "" {
 			profiles, err := internal.ListProfiles()
 			if err != nil || len(profiles) == 0 {
-				fmt.Println("❌ No stored profiles found.")
+				fmt.Println("❌ " + i18n.T("profile.none_stored"))
 				return
 			}
 			sort.Strings(profiles)
@@ -44,19 +46,21 @@ var logoutCmd = &cobra.Command{
 		}
 
 		if logoutAll {
-			fmt.Print("⚠️  This will remove all stored credentials. Type 'yes' to confirm: ")
+			fmt.Print("⚠️  " + i18n.T("logout.confirm_all"))
 			reader := bufio.NewReader(os.Stdin)
 			input, _ := reader.ReadString('\n')
 			if strings.TrimSpace(input) != "yes" {
-				fmt.Println("❌ Operation cancelled.")
+				fmt.Println("❌ " + i18n.T("common.cancelled"))
 				return
 			}
 
+			profiles, _ := internal.ListProfiles()
+			unpublishRemote(cmd.Context(), profiles)
 			err := internal.ClearAllCredentials()
 			if err != nil {
 				log.Fatalf("Failed to clear credentials: %v", err)
 			}
-			fmt.Println("✅ All profiles removed successfully.")
+			fmt.Println("✅ " + i18n.T("logout.all_removed"))
 			return
 		}
 
@@ -65,6 +69,23 @@ var logoutCmd = &cobra.Command{
 			log.Fatalf("Failed to remove profile %s: %v", logoutProfile, err)
 		}
 
-		fmt.Printf("✅ Profile '%s' removed successfully.\n", logoutProfile)
+		unpublishRemote(cmd.Context(), []string{logoutProfile})
+
+		fmt.Println("✅ " + i18n.T("logout.removed", logoutProfile))
 	},
 }
+
+// unpublishRemote drops this machine's remote state entries for logged-out profiles.
+// logout --all works without the secret, so this is skipped (with a warning) when there is none.
+func unpublishRemote(ctx context.Context, profiles []string) {
+	if !internal.RemoteStateEnabled() {
+		return
+	}
+	secret, err := internal.GetSecret(os.Getenv("CLOUDCTL_SECRET"))
+	if err == nil {
+		err = internal.UnpublishRemoteSessions(ctx, profiles, secret)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to update remote state: %v\n", err)
+	}
+}
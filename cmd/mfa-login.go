@@ -2,226 +2,246 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
-	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
 	"github.com/chukul/cloudctl/internal/ui"
 	"github.com/spf13/cobra"
 )
 
-var (
-	mfaSourceProfile string
-	mfaProfile       string
-	mfaDeviceArn     string
-	mfaSecretKey     string
-	mfaDuration      int32
-)
+// mfaLoginOptions are the flags of `cloudctl mfa-login`.
+type mfaLoginOptions struct {
+	source   string
+	profile  string
+	mfaArn   string
+	secret   string
+	region   string
+	duration int32
+	replace  bool
+}
 
-var mfaLoginCmd = &cobra.Command{
-	Use:   "mfa-login",
-	Short: "Get MFA session token to use for multiple role assumptions",
-	Long: `Authenticate with MFA once and get a session token valid for up to 12 hours.
+var mfaLoginCmd = newMFALoginCmd()
+
+// newMFALoginCmd builds `cloudctl mfa-login` with its own options.
+func newMFALoginCmd() *cobra.Command {
+	o := &mfaLoginOptions{}
+	cmd := &cobra.Command{
+		Use:   "mfa-login",
+		Short: "Get MFA session token to use for multiple role assumptions",
+		Long: `Authenticate with MFA once and get a session token valid for up to 12 hours.
 Use this session as source profile for subsequent role assumptions without re-entering MFA.`,
-	Example: `  # Get MFA session (valid for 12 hours)
+		Example: `  # Get MFA session (valid for 12 hours)
   cloudctl mfa-login --source default --profile mfa-session --mfa arn:aws:iam::123:mfa/user
   
   # Use MFA session to assume multiple roles (no MFA needed)
   cloudctl login --source mfa-session --profile role1 --role arn:aws:iam::123:role/Role1
   cloudctl login --source mfa-session --profile role2 --role arn:aws:iam::456:role/Role2`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Interactive prompts for missing parameters
-		if mfaSourceProfile == "" {
-			awsProfiles := listAWSProfiles()
-			if len(awsProfiles) > 0 {
-				selected, err := ui.SelectProfile("Select Source Profile", awsProfiles)
-				if err != nil {
-					return
-				}
-				mfaSourceProfile = selected
-			}
-		}
+		Run: o.run,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&o.source, "source", "", "Source AWS CLI profile for base credentials")
+	flags.StringVar(&o.profile, "profile", "", "Name to store the MFA session as")
+	flags.StringVar(&o.mfaArn, "mfa", "", "MFA device ARN")
+	flags.StringVar(&o.secret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret for encryption (or set CLOUDCTL_SECRET env var)")
+	flags.StringVar(&o.region, "region", "ap-southeast-1", "AWS region of the STS endpoint")
+	flags.BoolVar(&o.replace, "replace", false, "Replace an active session of another role stored under the same name")
+	flags.Int32Var(&o.duration, "duration", 43200, "Session duration in seconds (default: 43200 = 12 hours, max: 129600 = 36 hours)")
+	return cmd
+}
 
-		if mfaProfile == "" {
-			var err error
-			mfaProfile, err = ui.GetInput("Enter MFA Session Name", "mfa-session", false)
+// run implements `cloudctl mfa-login`.
+func (o *mfaLoginOptions) run(cmd *cobra.Command, args []string) {
+	// Interactive prompts for missing parameters
+	if o.source == "" {
+		o.source = defaultAmbientSource()
+	}
+	if o.source == "" {
+		awsProfiles, optionToProfile := awsProfileOptions()
+		if option, source := ambientSourceOption(); option != "" {
+			awsProfiles = append([]string{option}, awsProfiles...)
+			optionToProfile[option] = source
+		}
+		if len(awsProfiles) > 0 {
+			selected, err := ui.SelectProfile("Select Source Profile", awsProfiles)
 			if err != nil {
 				return
 			}
+			o.source = optionToProfile[selected]
 		}
+	}
 
-		if mfaDeviceArn == "" {
-			// Check if we have stored devices
-			devices, _ := internal.ListMFADevices()
-			if len(devices) > 0 {
-				// Convert to selection list
-				var deviceNames []string
-				for name, arn := range devices {
-					deviceNames = append(deviceNames, fmt.Sprintf("%s (%s)", name, arn))
-				}
-				sort.Strings(deviceNames)
-
-				selected, err := ui.SelectProfile("Select MFA Device", deviceNames)
-				if err == nil {
-					// Parse selected string "name (arn)"
-					parts := strings.SplitN(selected, " (", 2)
-					mfaDeviceArn = devices[parts[0]]
-				}
+	if o.profile == "" {
+		var err error
+		o.profile, err = ui.GetInput("Enter MFA Session Name", "mfa-session", false)
+		if err != nil {
+			return
+		}
+	}
+
+	if o.mfaArn == "" {
+		// Check if we have stored devices
+		devices, _ := internal.ListMFADevices()
+		if len(devices) > 0 {
+			// Convert to selection list
+			var deviceNames []string
+			for name, arn := range devices {
+				deviceNames = append(deviceNames, fmt.Sprintf("%s (%s)", name, arn))
 			}
+			sort.Strings(deviceNames)
 
-			// If still empty (no selection or no saved devices), prompt for input
-			if mfaDeviceArn == "" {
-				var err error
-				mfaDeviceArn, err = ui.GetInput("Enter MFA Device ARN", "arn:aws:iam::123:mfa/user", false)
-				if err != nil {
-					return
-				}
-			}
-		} else {
-			// Check if input matches an alias
-			if arn, found := internal.GetMFADevice(mfaDeviceArn); found {
-				fmt.Printf("📱 Using stored device '%s'\n", mfaDeviceArn)
-				mfaDeviceArn = arn
+			selected, err := ui.SelectProfile("Select MFA Device", deviceNames)
+			if err == nil {
+				// Parse selected string "name (arn)"
+				parts := strings.SplitN(selected, " (", 2)
+				o.mfaArn = devices[parts[0]]
 			}
 		}
 
-		if mfaSourceProfile == "" || mfaProfile == "" || mfaDeviceArn == "" {
-			fmt.Println("❌ Missing required parameters")
-			if mfaSourceProfile == "" {
-				fmt.Println("   --source: Source AWS profile")
-			}
-			if mfaProfile == "" {
-				fmt.Println("   --profile: Name for this MFA session")
-			}
-			if mfaDeviceArn == "" {
-				fmt.Println("   --mfa: MFA device ARN")
+		// If still empty (no selection or no saved devices), prompt for input
+		if o.mfaArn == "" {
+			var err error
+			o.mfaArn, err = ui.GetInput("Enter MFA Device ARN", "arn:aws:iam::123:mfa/user", false)
+			if err != nil {
+				return
 			}
-			fmt.Println("\n💡 Example:")
-			fmt.Println("   cloudctl mfa-login --source default --profile mfa-session --mfa arn:aws:iam::123456789012:mfa/username")
-			os.Exit(1)
 		}
-
-		fmt.Printf("🔐 Getting MFA session token from profile %s...\n", mfaSourceProfile)
-
-		ctx := context.TODO()
-
-		// Load source profile config
-		cfg, err := config.LoadDefaultConfig(ctx,
-			config.WithSharedConfigProfile(mfaSourceProfile),
-			config.WithRegion(region))
-		if err != nil {
-			fmt.Printf("❌ Profile '%s' not found\n", mfaSourceProfile)
-			fmt.Println("\n💡 To create a new profile:")
-			fmt.Println("   aws configure --profile", mfaSourceProfile)
-			os.Exit(1)
+	} else {
+		// Check if input matches an alias
+		if arn, found := internal.GetMFADevice(o.mfaArn); found {
+			printer.Info("📱 Using stored device '%s'", o.mfaArn)
+			o.mfaArn = arn
 		}
+	}
 
-		// Prompt for MFA code (masked input)
-		mfaCode := readMFACode()
-
-		// Get session token with MFA using spinner
-		stsClient := sts.NewFromConfig(cfg)
-		input := &sts.GetSessionTokenInput{
-			DurationSeconds: &mfaDuration,
-			SerialNumber:    &mfaDeviceArn,
-			TokenCode:       &mfaCode,
+	if o.source == "" || o.profile == "" || o.mfaArn == "" {
+		printer.Error("%s", i18n.T("login.missing_params"))
+		if o.source == "" {
+			printer.Detail("--source: Source AWS profile")
 		}
-
-		res, err := ui.Spin("Authenticating with MFA...", func() (any, error) {
-			return stsClient.GetSessionToken(ctx, input)
-		})
-
-		if err != nil {
-			fmt.Printf("❌ MFA authentication failed: %v\n", err)
-			fmt.Println("\n💡 Common issues:")
-			fmt.Println("   • Check your MFA code is current (not expired)")
-			fmt.Println("   • Verify MFA device ARN is correct")
-			fmt.Println("   • Ensure device time is synchronized")
-			fmt.Printf("   • MFA ARN format: arn:aws:iam::<account-id>:mfa/<username>\n")
-			os.Exit(1)
-		}
-
-		result, ok := res.(*sts.GetSessionTokenOutput)
-		if !ok || result == nil {
-			fmt.Println("❌ Internal error: invalid response from GetSessionToken")
-			os.Exit(1)
+		if o.profile == "" {
+			printer.Detail("--profile: Name for this MFA session")
 		}
-
-		expiration := *result.Credentials.Expiration
-
-		// Store the MFA session
-		session := &internal.AWSSession{
-			Profile:       mfaProfile,
-			AccessKey:     *result.Credentials.AccessKeyId,
-			SecretKey:     *result.Credentials.SecretAccessKey,
-			SessionToken:  *result.Credentials.SessionToken,
-			Expiration:    expiration,
-			RoleArn:       "MFA-Session", // Special marker
-			SourceProfile: mfaSourceProfile,
-			Region:        region,
-			MfaArn:        mfaDeviceArn,
-			Duration:      mfaDuration,
+		if o.mfaArn == "" {
+			printer.Detail("--mfa: MFA device ARN")
 		}
-
-		// Get secret from flag, env, or keychain
-		secret, err := internal.GetSecret(mfaSecretKey)
-		if err != nil {
-			// If on macOS and no secret found, offer to create one in keychain
-			if internal.IsMacOS() {
-				fmt.Println("🔑 No encryption secret found.")
-				fmt.Println("   Would you like to generate a secure key and store it in your System Keychain? (y/n)")
-				var response string
-				fmt.Scanln(&response)
-				if strings.ToLower(response) == "y" {
-					newSecret, err := internal.SetupKeychain()
-					if err != nil {
-						fmt.Printf("❌ Failed to setup keychain: %v\n", err)
-						return
-					}
-					secret = newSecret
-					fmt.Println("✅ Secure key generated and stored in Keychain.")
-				} else {
-					fmt.Println("❌ Operation cancelled. Secret required.")
+		printer.Tip("\n%s", i18n.T("common.example"))
+		printer.Detail("cloudctl mfa-login --source default --profile mfa-session --mfa arn:aws:iam::123456789012:mfa/username")
+		os.Exit(1)
+	}
+
+	if !checkSessionName(&o.profile, "MFA-Session", o.secret, o.replace, true) {
+		return
+	}
+
+	printer.Info("🔐 Getting MFA session token from profile %s...", o.source)
+
+	ctx := cmd.Context()
+	cfg, err := internal.LoadProfileConfig(ctx, o.source, o.region)
+	if err != nil {
+		printer.Error("%s", i18n.T("profile.not_found", o.source))
+		printer.Tip("\nTo create a new profile:")
+		printer.Detail("aws configure --profile %v", o.source)
+		os.Exit(1)
+	}
+
+	// Prompt for MFA code (masked input)
+	opts := internal.MFALoginOptions{
+		Profile:   o.profile,
+		Source:    o.source,
+		Region:    o.region,
+		Duration:  o.duration,
+		MFASerial: o.mfaArn,
+		TokenCode: readMFACode(),
+		Warn:      printer.Warn,
+	}
+
+	// Get session token with MFA using spinner
+	res, err := ui.Spin(ctx, "Authenticating with MFA...", func(ctx context.Context) (any, error) {
+		return internal.LoginMFA(ctx, cfg, opts)
+	})
+	if err != nil {
+		printer.Error("%s", mfaFailedMessage(err))
+		printMFAIssues(err)
+		os.Exit(1)
+	}
+	session := res.(*internal.AWSSession)
+
+	// Get secret from flag, env, or keychain
+	secret, err := internal.GetSecret(o.secret)
+	if err != nil {
+		// If on macOS or WSL and no secret found, offer to create one in keychain
+		if internal.HasKeychain() {
+			fmt.Fprintln(os.Stderr, "🔑 No encryption secret found.")
+			fmt.Fprintln(os.Stderr, "   Would you like to generate a secure key and store it in your System Keychain? (y/n)")
+			var response string
+			fmt.Scanln(&response)
+			if strings.ToLower(response) == "y" {
+				newSecret, err := internal.SetupKeychain()
+				if err != nil {
+					printer.Error("Failed to setup keychain: %v", err)
 					return
 				}
+				secret = newSecret
+				printer.Success("Secure key generated and stored in Keychain.")
+				offerRecoveryPhrase(secret)
 			} else {
-				fmt.Println("❌ Encryption secret required")
-				fmt.Println("\n💡 Set the secret:")
-				fmt.Println("   export CLOUDCTL_SECRET=\"your-32-char-encryption-key\"")
-				fmt.Println("   cloudctl mfa-login --source", mfaSourceProfile, "--profile", mfaProfile, "--mfa", mfaDeviceArn)
-				os.Exit(1)
+				printer.Error("Operation cancelled. Secret required.")
+				return
 			}
-		}
-
-		if err := internal.SaveCredentials(mfaProfile, session, secret); err != nil {
-			fmt.Printf("❌ Failed to save encrypted session: %v\n", err)
+		} else {
+			printer.Error("%s", i18n.T("secret.required"))
+			printer.Tip("\n%s", i18n.T("secret.set_hint"))
+			printer.Detail("export CLOUDCTL_SECRET=\"your-32-char-encryption-key\"")
+			printer.Detail("cloudctl mfa-login --source %v --profile %v --mfa %v", o.source, o.profile, o.mfaArn)
 			os.Exit(1)
 		}
-		fmt.Printf("✅ MFA session stored as '%s'\n", mfaProfile)
-
-		remaining := time.Until(expiration).Round(time.Minute)
-		hours := int(remaining.Hours())
-		minutes := int(remaining.Minutes()) % 60
-
-		fmt.Printf("   MFA Device: %s\n", mfaDeviceArn)
-		fmt.Printf("   Source: %s\n", mfaSourceProfile)
-		fmt.Printf("   Expires: %s (%dh%dm remaining)\n",
-			internal.FormatBKK(expiration), hours, minutes)
-		fmt.Printf("\n💡 Now you can assume roles without MFA:\n")
-		fmt.Printf("   cloudctl login --source %s --profile <name> --role <role-arn>\n", mfaProfile)
-	},
+	}
+
+	if err := internal.StoreSession(ctx, session, secret, warnStderr); err != nil {
+		printer.Error("Failed to save encrypted session: %v", err)
+		os.Exit(1)
+	}
+	printer.Success("%s", i18n.T("mfa.stored", o.profile))
+
+	printer.Detail("%s", i18n.T("label.mfa_device", o.mfaArn))
+	printer.Detail("%s", i18n.T("label.source", o.source))
+	printer.Detail("%s", i18n.T("label.expires", internal.FormatExpiry(session.Expiration)))
+	printer.Tip("\n%s", i18n.T("mfa.next_steps"))
+	printer.Detail("cloudctl login --source %s --profile <name> --role <role-arn>", o.profile)
 }
 
 func init() {
-	mfaLoginCmd.Flags().StringVar(&mfaSourceProfile, "source", "", "Source AWS CLI profile for base credentials")
-	mfaLoginCmd.Flags().StringVar(&mfaProfile, "profile", "", "Name to store the MFA session as")
-	mfaLoginCmd.Flags().StringVar(&mfaDeviceArn, "mfa", "", "MFA device ARN")
-	mfaLoginCmd.Flags().StringVar(&mfaSecretKey, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret for encryption (or set CLOUDCTL_SECRET env var)")
-	mfaLoginCmd.Flags().Int32Var(&mfaDuration, "duration", 43200, "Session duration in seconds (default: 43200 = 12 hours, max: 129600 = 36 hours)")
 	rootCmd.AddCommand(mfaLoginCmd)
 }
+
+// mfaFailedMessage is the error message for a rejected MFA code, naming how far off the
+// clock is when that is the likely cause.
+func mfaFailedMessage(err error) string {
+	msg := i18n.T("mfa.auth_failed", errors.Unwrap(err))
+	var mfaErr *internal.MFAError
+	if errors.As(err, &mfaErr) && internal.ClockSkewed(mfaErr.ClockSkew) {
+		msg += " (" + internal.DescribeClockSkew(mfaErr.ClockSkew) + ")"
+	}
+	return msg
+}
+
+// printMFAIssues explains a rejected MFA code: how far off this machine's clock is when
+// that is the likely cause, the usual checklist otherwise.
+func printMFAIssues(err error) {
+	var mfaErr *internal.MFAError
+	if errors.As(err, &mfaErr) && internal.ClockSkewed(mfaErr.ClockSkew) {
+		printer.Tip("\nThe code was probably rejected because %s.", internal.DescribeClockSkew(mfaErr.ClockSkew))
+		printer.Detail("Sync the clock (macOS: sudo sntp -sS time.apple.com, Linux: sudo timedatectl set-ntp true, Windows: w32tm /resync) and try again with a new code")
+		return
+	}
+	printer.Tip("\n%s", i18n.T("common.issues"))
+	printer.Detail("• Check your MFA code is current (not expired)")
+	printer.Detail("• Verify MFA device ARN is correct")
+	printer.Detail("• Ensure device time is synchronized")
+	printer.Detail("• MFA ARN format: arn:aws:iam::<account-id>:mfa/<username>")
+}
@@ -56,7 +56,7 @@ var mfaAddCmd = &cobra.Command{
 		arn := args[1]
 
 		// Basic validation
-		if !strings.HasPrefix(arn, "arn:aws:iam::") || !strings.Contains(arn, ":mfa/") {
+		if a, err := internal.ParseARN(arn); err != nil || !a.IsMFADevice() {
 			fmt.Println("⚠️  Warning: The ARN provided doesn't look like a standard MFA ARN.")
 			fmt.Println("   Standard format: arn:aws:iam::<account-id>:mfa/<username>")
 		}
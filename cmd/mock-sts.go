@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var mockSTSProfile string
+var mockSTSListen string
+var mockSTSSecret string
+
+var mockSTSCmd = &cobra.Command{
+	Use:   "mock-sts",
+	Short: "Serve an STS-compatible endpoint that hands out a stored session",
+	Long: `Serve the STS AssumeRole, GetSessionToken and GetCallerIdentity actions on a local
+endpoint, answering every request with the credentials of a stored session. Point
+integration tests or local tools at it to run them against a cloudctl-managed session
+without giving them the session itself.
+
+Requests are not authenticated and AWS is never contacted: whatever role the caller asks
+for, it gets the stored session. The session is re-read on every request, so refreshing
+it is picked up without a restart.`,
+	Example: `  cloudctl mock-sts --profile dev
+  AWS_ENDPOINT_URL_STS=http://127.0.0.1:8443 go test ./integration/...`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if mockSTSProfile == "" {
+			fmt.Println("❌ --profile is required")
+			os.Exit(1)
+		}
+		secret, err := internal.GetSecret(mockSTSSecret)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		// Fail early on an unknown profile or wrong secret rather than on the first request
+		if _, err := internal.LoadCredentials(mockSTSProfile, secret); err != nil {
+			fmt.Printf("❌ Failed to load session for profile '%s': %v\n", mockSTSProfile, err)
+			os.Exit(1)
+		}
+
+		listener, err := net.Listen("tcp", mockSTSListen)
+		if err != nil {
+			fmt.Printf("❌ Failed to listen on %s: %v\n", mockSTSListen, err)
+			os.Exit(1)
+		}
+		addr := listener.Addr().(*net.TCPAddr)
+		if !addr.IP.IsLoopback() {
+			fmt.Printf("⚠️  Listening on %s: anyone who can reach this address gets the credentials of '%s'.\n", addr, mockSTSProfile)
+		}
+
+		handler := &internal.MockSTS{Load: func() (*internal.AWSSession, error) {
+			return internal.LoadCredentials(mockSTSProfile, secret)
+		}}
+		server := &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				fmt.Printf("%s  %s from %s\n", internal.FormatLogTime(time.Now()), r.Form.Get("Action"), r.RemoteAddr)
+				handler.ServeHTTP(w, r)
+			}),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		endpoint := fmt.Sprintf("http://127.0.0.1:%d", addr.Port)
+		if !addr.IP.IsLoopback() && !addr.IP.IsUnspecified() {
+			endpoint = "http://" + addr.String()
+		}
+		fmt.Printf("🔄 Serving STS for '%s' on %s (Ctrl+C to stop)\n", mockSTSProfile, endpoint)
+		fmt.Printf("💡 export AWS_ENDPOINT_URL_STS=%s\n", endpoint)
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancelShutdown()
+			server.Shutdown(shutdownCtx)
+		}()
+
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("\n✅ Stopped.")
+	},
+}
+
+func init() {
+	mockSTSCmd.Flags().StringVar(&mockSTSProfile, "profile", "", "Stored session to hand out")
+	mockSTSCmd.Flags().StringVar(&mockSTSListen, "listen", "127.0.0.1:8443", "Address to serve on (e.g. :8443 for all interfaces)")
+	mockSTSCmd.Flags().StringVar(&mockSTSSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	rootCmd.AddCommand(mockSTSCmd)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	noteSecret string
+	noteFile   string
+	noteForce  bool
+)
+
+var noteCmd = &cobra.Command{
+	Use:   "note",
+	Short: "Keep encrypted notes about accounts",
+	Long: `Store small notes next to your sessions, such as account IDs, SSO start URLs or VPN
+requirements for an account. The text is encrypted with the same secret (or encryption
+provider) as the credential store; note names are not encrypted.`,
+}
+
+var noteAddCmd = &cobra.Command{
+	Use:   "add <name> [text]",
+	Short: "Add a note",
+	Long: `Add a note. The text is taken from the arguments, from --file, or from stdin; in a
+terminal, type the note and end it with Ctrl-D.`,
+	Example: `  cloudctl note add prod "Account 123456789012, SSO https://example.awsapps.com/start, VPN required"
+  cloudctl note add staging --file staging.txt
+  pbpaste | cloudctl note add sandbox`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if internal.NoteExists(name) && !noteForce {
+			fmt.Printf("❌ Note '%s' already exists.\n", name)
+			fmt.Println("💡 Use --force to replace it.")
+			os.Exit(1)
+		}
+
+		var text string
+		switch {
+		case len(args) > 1:
+			text = strings.Join(args[1:], " ")
+		case noteFile != "":
+			b, err := os.ReadFile(noteFile)
+			if err != nil {
+				fmt.Printf("❌ Failed to read note: %v\n", err)
+				os.Exit(1)
+			}
+			text = string(b)
+		default:
+			if term.IsTerminal(int(os.Stdin.Fd())) {
+				fmt.Fprintln(os.Stderr, "📝 Type the note, then press Ctrl-D:")
+			}
+			b, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Printf("❌ Failed to read note: %v\n", err)
+				os.Exit(1)
+			}
+			text = string(b)
+		}
+		text = strings.TrimRight(text, "\n")
+		if strings.TrimSpace(text) == "" {
+			fmt.Println("❌ The note is empty.")
+			os.Exit(1)
+		}
+
+		secret, err := internal.GetSecret(noteSecret)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if err := internal.SaveNote(name, text, secret); err != nil {
+			fmt.Printf("❌ Failed to save note: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Saved note '%s'\n", name)
+	},
+}
+
+var noteListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List notes",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		notes, err := internal.ListNotes()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if len(notes) == 0 {
+			fmt.Println("📭 No notes found.")
+			fmt.Println("\n💡 Add one with:")
+			fmt.Println("   cloudctl note add <name> <text>")
+			return
+		}
+
+		fmt.Println("Notes")
+		fmt.Println(strings.Repeat("─", 60))
+		for _, n := range notes {
+			fmt.Printf("%-30s updated %s\n", n.Name, internal.FormatTime(n.Updated))
+		}
+	},
+}
+
+var noteShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a note",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		secret, err := internal.GetSecret(noteSecret)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		note, err := internal.LoadNote(args[0], secret)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(note.Text)
+	},
+}
+
+var noteRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm", "delete"},
+	Short:   "Remove a note",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := internal.RemoveNote(args[0]); err != nil {
+			fmt.Printf("❌ Failed to remove note: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Removed note '%s'\n", args[0])
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{noteAddCmd, noteShowCmd} {
+		c.Flags().StringVar(&noteSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	}
+	noteAddCmd.Flags().StringVar(&noteFile, "file", "", "Read the note from a file")
+	noteAddCmd.Flags().BoolVar(&noteForce, "force", false, "Replace an existing note")
+
+	noteCmd.AddCommand(noteAddCmd)
+	noteCmd.AddCommand(noteListCmd)
+	noteCmd.AddCommand(noteShowCmd)
+	noteCmd.AddCommand(noteRemoveCmd)
+	rootCmd.AddCommand(noteCmd)
+}
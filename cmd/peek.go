@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var peekSecret string
+
+var peekCmd = &cobra.Command{
+	Use:   "peek <profile>",
+	Short: "Show identity, account alias and policies of a stored session",
+	Long: `Look at a stored session without switching to it: the caller identity, the account alias
+and the managed and inline policies of its role (or user, for MFA sessions). Your shell
+environment is not touched. Alias and policy lookups need IAM read permissions.`,
+	Example: `  cloudctl peek prod-admin`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		profile := args[0]
+
+		secret, err := internal.GetSecret(peekSecret)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		s, err := internal.LoadCredentials(profile, secret)
+		if err != nil {
+			fmt.Printf("❌ Failed to load session for profile '%s': %v\n", profile, err)
+			return
+		}
+		if time.Now().After(s.Expiration) {
+			fmt.Printf("❌ Session for profile '%s' has expired.\n", profile)
+			fmt.Printf("💡 Refresh it first: cloudctl refresh --profile %s\n", profile)
+			return
+		}
+		if refuseSelfDestructed(s) {
+			return
+		}
+
+		region := s.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		ctx := cmd.Context()
+		cfg, err := internal.LoadSourceConfig(ctx, profile, secret, region)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+
+		res, err := ui.Spin(ctx, fmt.Sprintf("Looking up '%s'...", profile), func(ctx context.Context) (any, error) {
+			return internal.PeekSession(ctx, cfg)
+		})
+		if err != nil {
+			fmt.Printf("❌ Failed to get caller identity: %v\n", err)
+			os.Exit(1)
+		}
+		peek := res.(*internal.SessionPeek)
+
+		account := peek.Account
+		if peek.AccountAlias != "" {
+			account = fmt.Sprintf("%s (%s)", peek.Account, peek.AccountAlias)
+		}
+
+		fmt.Printf("👀 %s\n", profile)
+		fmt.Println(strings.Repeat("─", 64))
+		fmt.Printf("   Identity: %s\n", peek.ARN)
+		fmt.Printf("   Account:  %s\n", account)
+		fmt.Printf("   Expires:  %s\n", internal.FormatExpiry(s.Expiration))
+		if peek.AliasErr != nil {
+			fmt.Println("   ⚠️  Account alias unavailable (needs iam:ListAccountAliases)")
+		}
+
+		if peek.PoliciesErr != nil {
+			fmt.Printf("   ⚠️  Policies of '%s' unavailable: %v\n", peek.Principal, peek.PoliciesErr)
+			return
+		}
+		fmt.Printf("\n   Policies of %s:\n", peek.Principal)
+		if len(peek.AttachedPolicies) == 0 && len(peek.InlinePolicies) == 0 {
+			fmt.Println("   (none)")
+		}
+		for _, p := range peek.AttachedPolicies {
+			fmt.Printf("   • %s (managed)\n", p)
+		}
+		for _, p := range peek.InlinePolicies {
+			fmt.Printf("   • %s (inline)\n", p)
+		}
+	},
+}
+
+func init() {
+	peekCmd.Flags().StringVar(&peekSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	rootCmd.AddCommand(peekCmd)
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	policySource string
+	policyMFA    bool
+	policySecret string
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Show and test the local policy for role logins",
+	Long: `The policy file (policy.json in the cloudctl directory) holds guardrails checked
+before every AssumeRole call cloudctl makes: login, up, restore, silent refresh (including
+the daemon's), the Go package and serve. Each rule matches calls by the role and its
+account env, and by the source profile, its role and its account env. A "deny" rule
+refuses the calls it matches; a "require_mfa" rule refuses them unless they come from an
+MFA session or send an MFA code. The first rule that refuses a call wins.
+
+A policy file that can't be parsed refuses every call until it is fixed.`,
+	Example: `  # ~/.cloudctl/policy.json
+  {
+    "rules": [
+      {"name": "no-dev-to-prod", "envs": ["prod"], "source_envs": ["dev"],
+       "message": "log in to prod from your MFA session"},
+      {"name": "admin-needs-mfa", "effect": "require_mfa", "roles": ["arn:aws:iam::*:role/*Admin*"]}
+    ]
+  }
+
+  cloudctl policy show
+  cloudctl policy check prod-admin --source dev`,
+}
+
+var policyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Validate the policy file and list its rules",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		p, err := internal.LoadPolicy()
+		if err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+		if len(p.Rules) == 0 {
+			printer.Info("No policy rules (%s)", internal.PolicyPath())
+			return
+		}
+		printer.Print("Policy rules (%s):", internal.PolicyPath())
+		for _, r := range p.Rules {
+			effect := r.Effect
+			if effect == "" {
+				effect = internal.PolicyDeny
+			}
+			printer.Print("\n  %s  %s", r.Name, effect)
+			printPolicyCondition("roles", r.Roles)
+			printPolicyCondition("envs", r.Envs)
+			printPolicyCondition("sources", r.Sources)
+			printPolicyCondition("source roles", r.SourceRoles)
+			printPolicyCondition("source envs", r.SourceEnvs)
+			if r.Message != "" {
+				printer.Detail("message: %s", r.Message)
+			}
+		}
+	},
+}
+
+var policyCheckCmd = &cobra.Command{
+	Use:   "check <role>",
+	Short: "Check whether the policy allows assuming a role",
+	Long: `Evaluate the policy for assuming a role (alias or ARN) from --source, without calling
+AWS. Source role and env conditions need the source's cloudctl session, which is
+decrypted with the secret when there is one.`,
+	Example: `  cloudctl policy check prod-admin --source dev
+  cloudctl policy check arn:aws:iam::123456789012:role/Admin --source mfa-session`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		roleArn := args[0]
+		if alias, found := internal.GetRoleAlias(roleArn); found {
+			roleArn = alias.ARN
+		}
+		req := internal.AssumeRequest{RoleArn: roleArn, Source: policySource, MFA: policyMFA}
+		if policySource != "" && !internal.IsBuiltinSource(policySource) {
+			if secret, err := internal.GetSecret(policySecret); err == nil {
+				if s, err := internal.LoadCredentials(policySource, secret); err == nil {
+					req.SourceSession = s
+				}
+			}
+			if req.SourceSession == nil {
+				printer.Warn("'%s' is not a stored session; source role and env conditions won't match.", policySource)
+			}
+		}
+
+		if err := internal.CheckAssumePolicy(req); err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+		printer.Success("Allowed: %s", roleArn)
+	},
+}
+
+func printPolicyCondition(name string, values []string) {
+	if len(values) > 0 {
+		printer.Detail("%s: %s", name, strings.Join(values, ", "))
+	}
+}
+
+func init() {
+	policyCheckCmd.Flags().StringVar(&policySource, "source", "", "Source profile the role would be assumed from")
+	policyCheckCmd.Flags().BoolVar(&policyMFA, "mfa", false, "Check as if an MFA code were sent with the call")
+	policyCheckCmd.Flags().StringVar(&policySecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	policyCmd.AddCommand(policyShowCmd, policyCheckCmd)
+	rootCmd.AddCommand(policyCmd)
+}
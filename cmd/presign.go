@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var presignProfile string
+var presignSecret string
+var presignExpires time.Duration
+var presignRegion string
+var presignPut bool
+var presignClipboard bool
+
+var presignCmd = &cobra.Command{
+	Use:   "presign s3://bucket/key",
+	Short: "Create a presigned S3 URL with a stored session",
+	Long: `Create a presigned URL for an S3 object, signed with a stored session, without exporting
+credentials or calling the AWS CLI. The URL is signed locally; the bucket's region is
+looked up unless --region is given.
+
+A presigned URL stops working when the session that signed it expires, even if
+--expires is longer. Only the URL is printed to stdout, so it can be captured by scripts.`,
+	Example: `  cloudctl presign s3://reports/2025/q1.csv --profile prod-read
+  cloudctl presign s3://uploads/drop/file.zip --profile dev --put --expires 1h
+  curl -o q1.csv "$(cloudctl presign s3://reports/2025/q1.csv --profile prod-read)"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		obj, err := internal.ParseS3URI(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		if presignProfile == "" {
+			fmt.Fprintln(os.Stderr, "❌ --profile is required")
+			os.Exit(1)
+		}
+		if presignExpires <= 0 || presignExpires > internal.MaxPresignExpiry {
+			fmt.Fprintf(os.Stderr, "❌ --expires must be between 1s and %v\n", internal.MaxPresignExpiry)
+			os.Exit(1)
+		}
+
+		secret, err := internal.GetSecret(presignSecret)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		s, err := internal.LoadCredentials(presignProfile, secret)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to load session for profile '%s': %v\n", presignProfile, err)
+			os.Exit(1)
+		}
+		if refuseSelfDestructed(s) {
+			os.Exit(1)
+		}
+		if time.Now().After(s.Expiration) {
+			fmt.Fprintf(os.Stderr, "❌ Session for profile '%s' has expired.\n", presignProfile)
+			fmt.Fprintf(os.Stderr, "💡 Refresh it first: cloudctl refresh --profile %s\n", presignProfile)
+			os.Exit(1)
+		}
+
+		ctx := cmd.Context()
+		region := presignRegion
+		if region == "" {
+			region, err = internal.BucketRegion(ctx, obj.Bucket)
+			if err != nil {
+				region = sessionRegion(s)
+				if region == "" {
+					region = "us-east-1"
+				}
+				fmt.Fprintf(os.Stderr, "⚠️  %v; signing for %s (use --region if that's wrong)\n", err, region)
+			}
+		}
+
+		cfg, err := internal.LoadSourceConfig(ctx, presignProfile, secret, region)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		presigned, err := internal.PresignS3(ctx, cfg, obj, presignExpires, presignPut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		if remaining := time.Until(s.Expiration); remaining < presignExpires {
+			fmt.Fprintf(os.Stderr, "⚠️  The URL stops working when '%s' expires (%s), before the requested %v.\n",
+				presignProfile, internal.FormatExpiry(s.Expiration), presignExpires)
+		}
+
+		if presignClipboard {
+			if err := copyToClipboard(presigned, fmt.Sprintf("presigned URL for %s", args[0])); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Println(presigned)
+	},
+}
+
+func init() {
+	presignCmd.Flags().StringVar(&presignProfile, "profile", "", "Stored session to sign with")
+	presignCmd.Flags().DurationVar(&presignExpires, "expires", 15*time.Minute, "How long the URL is valid, e.g. 15m or 12h (max 168h)")
+	presignCmd.Flags().StringVar(&presignRegion, "region", "", "Bucket region (default: looked up from the bucket)")
+	presignCmd.Flags().BoolVar(&presignPut, "put", false, "Presign an upload (PUT) instead of a download")
+	presignCmd.Flags().BoolVar(&presignClipboard, "clipboard", false, "Copy the URL to the clipboard instead of printing it")
+	presignCmd.Flags().StringVar(&presignSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	rootCmd.AddCommand(presignCmd)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"time"
 
@@ -25,6 +26,16 @@ var promptCmd = &cobra.Command{
 			return // No AWS context
 		}
 
+		// Endpoint profiles have no session or expiry; show where requests go instead
+		if e, ok := internal.CurrentConfig().Endpoint(activeProfile); ok && os.Getenv("AWS_ENDPOINT_URL") == e.URL {
+			host := e.URL
+			if u, err := url.Parse(e.URL); err == nil {
+				host = u.Host
+			}
+			fmt.Print(promptColor(internal.ColorActive, fmt.Sprintf("%s %s (%s)", internal.Icon(internal.IconPrompt), activeProfile, host)))
+			return
+		}
+
 		secret, err := internal.GetSecret(promptSecret)
 		if err != nil {
 			return // Silent fail for prompt
@@ -60,30 +71,57 @@ var promptCmd = &cobra.Command{
 
 		// Calculate remaining time
 		remaining := time.Until(currentSession.Expiration)
+		icon := internal.Icon(internal.IconPrompt)
 		if remaining <= 0 {
-			// Red for expired
-			fmt.Printf("\033[31m☁️  %s (expired)\033[0m", currentSession.Profile)
+			fmt.Print(promptColor(internal.ColorExpired, fmt.Sprintf("%s %s (expired)", icon, currentSession.Profile)))
 			return
 		}
 
-		// Format remaining time
-		hours := int(remaining.Hours())
-		minutes := int(remaining.Minutes()) % 60
+		// Active color for >15m, expiring color for <=15m; production always stands out
+		color := internal.ColorActive
+		if internal.CurrentConfig().IsProduction(currentSession) {
+			color = internal.ColorProduction
+		} else if remaining <= 15*time.Minute {
+			color = internal.ColorExpiring
+		}
 
-		// ANSI Colors: Green for >15m, Yellow for <=15m
-		color := "\033[32m" // Green
-		if remaining <= 15*time.Minute {
-			color = "\033[33m" // Yellow
+		// The shell switched to a read-only session, but the exported keys are admin
+		if os.Getenv("CLOUDCTL_ACCESS") == internal.AccessReadOnly && currentSession.Access == internal.AccessAdmin {
+			fmt.Print(promptColor(internal.ColorProduction, fmt.Sprintf("%s %s (admin, not read-only!)", internal.Icon(internal.IconWarning), currentSession.Profile)))
+			return
 		}
 
-		if hours > 0 {
-			fmt.Printf("%s☁️  %s (%dh%dm)\033[0m", color, currentSession.Profile, hours, minutes)
-		} else {
-			fmt.Printf("%s☁️  %s (%dm)\033[0m", color, currentSession.Profile, minutes)
+		details := formatPromptExpiry(currentSession.Expiration)
+		if currentSession.Access != "" {
+			details += ", " + currentSession.Access
 		}
+		fmt.Print(promptColor(color, fmt.Sprintf("%s %s (%s)", icon, currentSession.Profile, details)))
 	},
 }
 
+// promptColor wraps text in the raw ANSI sequence for a theme color; lipgloss would
+// drop colors here because the prompt runs with stdout captured by the shell.
+func promptColor(color, text string) string {
+	code := internal.ANSIColor(color)
+	if code == "" {
+		return text
+	}
+	return code + text + internal.ANSIReset
+}
+
+// formatPromptExpiry is the compact prompt variant of display.expiry_format.
+func formatPromptExpiry(expiration time.Time) string {
+	clock := internal.InDisplayZone(expiration).Format("15:04")
+	switch internal.CurrentConfig().Display.ExpiryFormat {
+	case internal.ExpiryFormatAbsolute:
+		return "until " + clock
+	case internal.ExpiryFormatBoth:
+		return fmt.Sprintf("%s, %s", internal.FormatDurationShort(time.Until(expiration)), clock)
+	default:
+		return internal.FormatDurationShort(time.Until(expiration))
+	}
+}
+
 var promptInfoCmd = &cobra.Command{
 	Use:   "info",
 	Short: "Display detailed session info in JSON format",
@@ -129,11 +167,17 @@ var promptInfoCmd = &cobra.Command{
 
 		remaining := time.Until(currentSession.Expiration)
 		info := map[string]interface{}{
-			"profile":    currentSession.Profile,
-			"role_arn":   currentSession.RoleArn,
-			"expiration": internal.FormatBKK(currentSession.Expiration),
-			"remaining":  int(remaining.Seconds()),
-			"expired":    remaining <= 0,
+			"profile":            currentSession.Profile,
+			"role_arn":           currentSession.RoleArn,
+			"expiration":         internal.FormatISO(currentSession.Expiration),
+			"expiration_display": internal.FormatExpiry(currentSession.Expiration),
+			"remaining":          int(remaining.Seconds()),
+			"expired":            remaining <= 0,
+			"account_id":         internal.SessionAccountID(currentSession),
+		}
+		if currentSession.PrincipalArn != "" {
+			info["principal_arn"] = currentSession.PrincipalArn
+			info["user_id"] = currentSession.UserID
 		}
 
 		output, _ := json.Marshal(info)
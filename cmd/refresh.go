@@ -2,16 +2,15 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
 	"github.com/chukul/cloudctl/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -21,23 +20,62 @@ var (
 	refreshAll     bool
 	refreshProfile string
 	forceRefresh   bool
+
+	refreshInteractive bool
+	refreshAt          string
+	refreshFailOn      string
 )
 
 var refreshCmd = &cobra.Command{
 	Use:   "refresh [profile]",
 	Short: "Smart refresh or restore AWS sessions",
 	Long: `Automatically refreshes active sessions or restores expired ones by re-using metadata.
-If a session is still active, it attempts a silent refresh. If expired or requires MFA, it will prompt for input.`,
+If a session is still active, it attempts a silent refresh. If expired or requires MFA, it will prompt for input.
+
+With --all, the exit status tells schedulers how the batch went: 0 when it succeeded
+under --fail-on, 1 when it couldn't run at all (no secret, unreadable store), and 2
+when sessions failed to refresh: any of them (--fail-on any, the default), all that
+were tried (--fail-on all), or never (--fail-on none). Skipped sessions don't count.`,
+	Example: `  cloudctl refresh prod
+  cloudctl refresh --all
+  cloudctl refresh --all --fail-on all   # in cron, alert only when nothing refreshed`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := internal.ValidateFailOn(refreshFailOn); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+
 		secret, err := internal.GetSecret(refreshSecret)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "❌ Encryption secret required")
-			return
+			fmt.Fprintln(os.Stderr, "❌ "+i18n.T("secret.required"))
+			os.Exit(1)
+		}
+
+		// Validate --at up front so a typo doesn't surface after the picker
+		var refreshTime time.Time
+		if refreshAt != "" {
+			refreshTime, err = internal.NextClockTime(refreshAt, time.Now(), internal.DisplayLocation())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				return
+			}
 		}
 
 		if refreshAll {
-			refreshAllSessions(secret)
+			waitForRefreshTime(refreshTime, "all sessions")
+			var summary internal.RefreshSummary
+			if refreshInteractive {
+				summary, err = refreshAllInteractive(cmd.Context(), secret)
+			} else {
+				summary, err = refreshAllSessions(cmd.Context(), secret)
+			}
+			if err != nil {
+				os.Exit(1)
+			}
+			if summary.FailsOn(refreshFailOn) {
+				os.Exit(2)
+			}
 			return
 		}
 
@@ -59,49 +97,159 @@ If a session is still active, it attempts a silent refresh. If expired or requir
 				return
 			}
 
-			var options []string
-			for _, s := range allSessions {
-				status := "Expired"
-				if time.Now().Before(s.Expiration) {
-					status = "Active"
-				}
-				displayName := fmt.Sprintf("%-15s [%s]", s.Profile, status)
-				options = append(options, displayName)
-			}
-			sort.Strings(options)
-
-			selected, err := ui.SelectProfile("Select Session to Refresh/Restore", options)
+			selected, err := selectSession("Select Session to Refresh/Restore", allSessions)
 			if err != nil {
 				return
 			}
-			fmt.Sscanf(selected, "%s", &profile)
+			profile = selected
 		}
 
-		smartRefresh(profile, secret, forceRefresh)
+		waitForRefreshTime(refreshTime, fmt.Sprintf("'%s'", profile))
+		// Restoring an expired MFA session, directly or as the source of this profile,
+		// lets the other sessions that depend on it refresh again
+		sessions, _ := internal.ListAllSessions(secret)
+		root, dependents := reauthDependents(profile, sessions)
+		if smartRefresh(cmd.Context(), profile, secret, forceRefresh) && len(dependents) > 0 {
+			offerRebuild(cmd.Context(), root, dependents, secret)
+		}
 	},
 }
 
-func smartRefresh(profile string, secret string, force bool) {
+// reauthDependents returns the expired MFA session profile is or depends on and the
+// other sessions that need re-auth because of it, nearest first.
+func reauthDependents(profile string, sessions []*internal.AWSSession) (string, []*internal.AWSSession) {
+	reauth := internal.NeedsReauth(sessions, time.Now())
+	root := profile
+	if r, ok := reauth[profile]; ok {
+		root = r
+	}
+	var selected []*internal.AWSSession
+	for _, s := range sessions {
+		if reauth[s.Profile] == root && s.Profile != profile {
+			selected = append(selected, s)
+		}
+	}
+	if len(selected) == 0 {
+		return root, nil
+	}
+	for _, g := range internal.GroupBySourceRoot(selected, sessions) {
+		if g.Root == root {
+			return root, g.Dependents
+		}
+	}
+	return root, nil
+}
+
+// offerRebuild asks to refresh the sessions of a restored MFA session that couldn't be
+// refreshed while it was expired.
+func offerRebuild(ctx context.Context, root string, dependents []*internal.AWSSession, secret string) {
+	names := make([]string, 0, len(dependents))
+	for _, d := range dependents {
+		names = append(names, d.Profile)
+	}
+	fmt.Printf("\n🔗 %d sessions depend on '%s': %s\n", len(dependents), root, strings.Join(names, ", "))
+	fmt.Print("   Rebuild them now? (Y/n): ")
+	var response string
+	fmt.Scanln(&response)
+	if strings.EqualFold(response, "n") || strings.EqualFold(response, "no") {
+		fmt.Printf("💡 Rebuild them later with: cloudctl refresh --all -i\n")
+		return
+	}
+	if refreshed, _ := rebuildDependents(ctx, dependents, secret); refreshed > 0 {
+		autoSyncAfterRefresh(secret)
+	}
+}
+
+// rebuildDependents refreshes the dependents of a freshly restored source, falling back
+// to an interactive restore for those that can't be refreshed silently. Dependents are
+// ordered nearest-first, so each one's source is fresh by the time it is refreshed.
+func rebuildDependents(ctx context.Context, dependents []*internal.AWSSession, secret string) (refreshed, failed int) {
+	for _, d := range dependents {
+		if _, err := internal.PerformRefresh(ctx, d, secret, d.Region); err == nil {
+			fmt.Printf("✅ Refreshed '%s' silently.\n", d.Profile)
+			refreshed++
+			continue
+		}
+		if smartRefresh(ctx, d.Profile, secret, true) {
+			refreshed++
+		} else {
+			failed++
+		}
+	}
+	return refreshed, failed
+}
+
+// waitForRefreshTime blocks until t for `refresh --at`; a zero t returns immediately.
+func waitForRefreshTime(t time.Time, what string) {
+	if t.IsZero() {
+		return
+	}
+	fmt.Printf("⏰ Waiting until %s to refresh %s (in %s, Ctrl+C to cancel)...\n",
+		internal.FormatTime(t), what, time.Until(t).Round(time.Minute))
+	internal.SleepUntil(t)
+}
+
+// smartRefresh refreshes or restores a profile and reports whether it succeeded.
+func smartRefresh(ctx context.Context, profile string, secret string, force bool) bool {
+	return refreshSession(ctx, profile, secret, force, make(map[string]bool))
+}
+
+// refreshSession does the work of smartRefresh. visited holds the profiles already being
+// refreshed further up a source chain, so a misconfigured loop can't recurse forever.
+func refreshSession(ctx context.Context, profile string, secret string, force bool, visited map[string]bool) bool {
 	s, err := internal.LoadCredentials(profile, secret)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Profile '%s' not found.\n", profile)
-		return
+		fmt.Fprintln(os.Stderr, "❌ "+i18n.T("profile.not_found", profile))
+		return false
+	}
+
+	// 0. An expired cloudctl source makes both the silent and the interactive path fail,
+	// so restore the source first (prompting for MFA once) and cascade to this profile
+	if s.RoleArn != "MFA-Session" && s.SourceProfile != "" {
+		if ok, handled := recoverExpiredSource(ctx, s, secret, visited); handled {
+			return ok
+		}
 	}
 
 	now := time.Now()
 	isExpired := s.Expiration.Before(now)
+	if s.SelfDestructed(now) {
+		fmt.Printf("💣 '%s' self-destructed at %s; log in again instead of refreshing it.\n", s.Profile, internal.FormatTime(s.SelfDestruct))
+		return false
+	}
 
 	// 1. Try Silent Refresh if not expired and not forced
 	if !isExpired && !force && s.RoleArn != "MFA-Session" && s.SourceProfile != "" {
-		fmt.Printf("🔄 Attempting silent refresh for '%s'...\n", profile)
-		_, err := internal.PerformRefresh(s, secret, s.Region)
+		fmt.Println("🔄 " + i18n.T("refresh.silent_attempt", profile))
+		_, err := internal.PerformRefresh(ctx, s, secret, s.Region)
 		if err == nil {
-			fmt.Printf("✅ Session '%s' refreshed silently.\n", profile)
-			return
+			fmt.Println("✅ " + i18n.T("refresh.silent_success", profile))
+			return true
+		}
+		var busy *internal.RenewalInProgressError
+		if errors.As(err, &busy) {
+			fmt.Printf("⏳ %v\n", busy)
+			return false
 		}
 		fmt.Printf("⚠️  Silent refresh failed: %v. Switching to interactive restore...\n", err)
 	}
 
+	if s.IsRoot() {
+		fmt.Printf("🔒 '%s' can't be restored: root sessions last at most 15 minutes.\n", s.Profile)
+		fmt.Printf("💡 Start a new one: cloudctl root-login --source %s --account %s --task %s --profile %s\n", s.SourceProfile, s.AccountID, internal.RootTaskName(s.RootTask), s.Profile)
+		return false
+	}
+
+	// Dual-control and break-glass roles need an approval or justification per login
+	if cfg := internal.CurrentConfig(); cfg.RequiresDualControl(s.RoleArn) || cfg.IsBreakGlass(s.RoleArn) {
+		fmt.Printf("🔒 '%s' can't be restored: %s needs a new approval or justification.\n", s.Profile, s.RoleArn)
+		fmt.Printf("💡 Log in again: cloudctl login --source %s --profile %s --role %s\n", s.SourceProfile, s.Profile, s.RoleArn)
+		return false
+	}
+	if !claimRenewal(ctx, s.Profile, secret) {
+		return false
+	}
+
 	// 2. Interactive Restore (Relogin)
 	fmt.Printf("🔄 Restoring session '%s'...\n", s.Profile)
 	fmt.Printf("   Source: %s\n", s.SourceProfile)
@@ -110,140 +258,99 @@ func smartRefresh(profile string, secret string, force bool) {
 	}
 	region := s.Region
 	if region == "" {
-		region = "ap-southeast-1"
+		region = internal.DefaultRegion
 	}
-
-	duration := s.Duration
-	if duration < 900 {
-		duration = 3600 // Default to 1 hour
-	}
-
 	fmt.Printf("   Region: %s\n", region)
 
-	ctx := context.TODO()
-	var cfg aws.Config
-
-	// Load Source Config
-	sourceSession, sourceErr := internal.LoadCredentials(s.SourceProfile, secret)
-	if sourceErr == nil {
-		cfg, err = config.LoadDefaultConfig(ctx,
-			config.WithRegion(region),
-			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-				sourceSession.AccessKey,
-				sourceSession.SecretKey,
-				sourceSession.SessionToken,
-			)),
-		)
-	} else {
-		cfg, err = config.LoadDefaultConfig(ctx,
-			config.WithRegion(region),
-			config.WithSharedConfigProfile(s.SourceProfile),
-		)
+	var tokenCode string
+	if internal.NeedsMFACode(s) {
+		if tokenCode = readMFACode(); tokenCode == "" {
+			return false
+		}
+	}
+	label := "Assuming role..."
+	if s.RoleArn == "MFA-Session" {
+		label = "Verifying MFA Token..."
+	}
+	res, err := ui.Spin(ctx, label, func(ctx context.Context) (any, error) {
+		return internal.RestoreSession(ctx, s, secret, tokenCode, warnStderr)
+	})
+	if internal.IsMFAError(err) && s.RoleArn == "MFA-Session" {
+		fmt.Fprintln(os.Stderr, "❌ "+mfaFailedMessage(err))
+		return false
 	}
-
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Failed to load source config: %v\n", err)
-		return
+		fmt.Fprintln(os.Stderr, "❌ "+i18n.T("login.assume_failed", err))
+		return false
 	}
+	newSession := res.(*internal.AWSSession)
 
-	stsClient := sts.NewFromConfig(cfg)
-	var newSession *internal.AWSSession
-
-	if s.RoleArn == "MFA-Session" {
-		// MFA Session Flow
-		tokenCode := readMFACode()
-		if tokenCode == "" {
-			return
-		}
-
-		res, err := ui.Spin("Verifying MFA Token...", func() (any, error) {
-			return stsClient.GetSessionToken(ctx, &sts.GetSessionTokenInput{
-				DurationSeconds: &duration,
-				SerialNumber:    &s.MfaArn,
-				TokenCode:       &tokenCode,
-			})
-		})
-
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ MFA login failed: %v\n", err)
-			return
-		}
-
-		result := res.(*sts.GetSessionTokenOutput)
-		newSession = &internal.AWSSession{
-			Profile:       s.Profile,
-			AccessKey:     *result.Credentials.AccessKeyId,
-			SecretKey:     *result.Credentials.SecretAccessKey,
-			SessionToken:  *result.Credentials.SessionToken,
-			Expiration:    *result.Credentials.Expiration,
-			RoleArn:       "MFA-Session",
-			SourceProfile: s.SourceProfile,
-			Region:        region,
-			MfaArn:        s.MfaArn,
-			Duration:      duration,
-		}
-	} else {
-		// Role Assumption Flow
-		input := &sts.AssumeRoleInput{
-			RoleArn:         &s.RoleArn,
-			RoleSessionName: &s.Profile,
-			DurationSeconds: &duration,
-		}
+	fmt.Println("\n✅ " + i18n.T("refresh.success", s.Profile))
+	fmt.Println("   " + i18n.T("label.expires", internal.FormatExpiry(newSession.Expiration)))
+	return true
+}
 
-		if s.MfaArn != "" {
-			tokenCode := readMFACode()
-			if tokenCode == "" {
-				return
-			}
-			input.SerialNumber = &s.MfaArn
-			input.TokenCode = &tokenCode
-		}
+// recoverExpiredSource detects a role session whose cloudctl source has expired and offers
+// to restore the source first. handled is false when there is nothing to recover or the
+// caller should continue with the normal restore flow.
+func recoverExpiredSource(ctx context.Context, s *internal.AWSSession, secret string, visited map[string]bool) (ok bool, handled bool) {
+	source, err := internal.LoadCredentials(s.SourceProfile, secret)
+	if err != nil || time.Now().Before(source.Expiration) {
+		return false, false
+	}
 
-		res, err := ui.Spin("Assuming role...", func() (any, error) {
-			return stsClient.AssumeRole(ctx, input)
-		})
+	kind := "session"
+	if source.RoleArn == "MFA-Session" {
+		kind = "MFA session"
+	}
+	fmt.Printf("⚠️  Source %s '%s' needed by '%s' has expired.\n", kind, s.SourceProfile, s.Profile)
 
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Failed to assume role: %v\n", err)
-			return
-		}
+	visited[s.Profile] = true
+	if visited[s.SourceProfile] {
+		fmt.Printf("❌ Source chain loops back to '%s'; fix the source of '%s' with cloudctl login.\n", s.SourceProfile, s.Profile)
+		return false, true
+	}
 
-		result := res.(*sts.AssumeRoleOutput)
-		newSession = &internal.AWSSession{
-			Profile:       s.Profile,
-			AccessKey:     *result.Credentials.AccessKeyId,
-			SecretKey:     *result.Credentials.SecretAccessKey,
-			SessionToken:  *result.Credentials.SessionToken,
-			Expiration:    *result.Credentials.Expiration,
-			RoleArn:       s.RoleArn,
-			SourceProfile: s.SourceProfile,
-			Region:        region,
-			MfaArn:        s.MfaArn,
-			Duration:      duration,
-		}
+	fmt.Printf("   Refresh '%s' first, then '%s'? (Y/n): ", s.SourceProfile, s.Profile)
+	var response string
+	fmt.Scanln(&response)
+	if strings.EqualFold(response, "n") || strings.EqualFold(response, "no") {
+		fmt.Printf("💡 Restore the source later with: cloudctl refresh %s\n", s.SourceProfile)
+		return false, true
 	}
 
-	if err := internal.SaveCredentials(s.Profile, newSession, secret); err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Failed to save refreshed session: %v\n", err)
-		return
+	if !refreshSession(ctx, s.SourceProfile, secret, false, visited) {
+		fmt.Printf("❌ Could not restore source '%s'; '%s' was not refreshed.\n", s.SourceProfile, s.Profile)
+		return false, true
 	}
 
-	fmt.Printf("\n✅ Session '%s' refreshed/restored successfully!\n", s.Profile)
-	fmt.Printf("   Expires: %s\n", internal.FormatBKK(newSession.Expiration))
+	// Cascade: the source is valid again, so the role can usually be refreshed silently
+	fmt.Println("\n🔄 " + i18n.T("refresh.silent_attempt", s.Profile))
+	newSession, err := internal.PerformRefresh(ctx, s, secret, s.Region)
+	if err != nil {
+		fmt.Printf("⚠️  Silent refresh failed: %v. Switching to interactive restore...\n", err)
+		return false, false
+	}
+	fmt.Println("✅ " + i18n.T("refresh.success", s.Profile))
+	fmt.Println("   " + i18n.T("label.expires", internal.FormatExpiry(newSession.Expiration)))
+	return true, true
 }
 
-func refreshAllSessions(secret string) {
+// refreshAllSessions refreshes every session it can silently, offering to restore
+// expired MFA sessions and sources. The error is only set when the sessions couldn't
+// be loaded; it has been printed.
+func refreshAllSessions(ctx context.Context, secret string) (internal.RefreshSummary, error) {
 	fmt.Println("🔄 Intelligent batch refresh starting...")
 
 	sessions, err := internal.ListAllSessions(secret)
 	if err != nil {
-		fmt.Printf("❌ Failed to load sessions: %v\n", err)
-		return
+		fmt.Println("❌ " + i18n.T("sessions.load_failed", err))
+		return internal.RefreshSummary{}, err
 	}
 
 	if len(sessions) == 0 {
 		fmt.Println("📭 No sessions found.")
-		return
+		return internal.RefreshSummary{}, nil
 	}
 
 	// 1. Sort sessions to process MFA sessions first (they are often sources)
@@ -258,6 +365,7 @@ func refreshAllSessions(secret string) {
 	skipped := 0
 	failed := 0
 	restoredSources := make(map[string]bool)
+	reauth := internal.NeedsReauth(sessions, time.Now())
 
 	for _, s := range sessions {
 		now := time.Now()
@@ -266,7 +374,7 @@ func refreshAllSessions(secret string) {
 		// 2. Handle MFA Sessions (Potential Sources)
 		if s.RoleArn == "MFA-Session" {
 			if !isExpired {
-				fmt.Printf("✅ MFA Session '%s' is still active (%v remaining).\n", s.Profile, time.Until(s.Expiration).Round(time.Minute))
+				fmt.Printf("✅ MFA Session '%s' is still active (%s).\n", s.Profile, internal.FormatRelative(s.Expiration))
 				continue
 			}
 
@@ -276,9 +384,12 @@ func refreshAllSessions(secret string) {
 			var response string
 			fmt.Scanln(&response)
 			if response == "y" || response == "Y" {
-				smartRefresh(s.Profile, secret, false)
-				restoredSources[s.Profile] = true
-				refreshed++
+				if smartRefresh(ctx, s.Profile, secret, false) {
+					restoredSources[s.Profile] = true
+					refreshed++
+				} else {
+					failed++
+				}
 			} else {
 				fmt.Printf("⏭️  Skipping '%s'.\n", s.Profile)
 				skipped++
@@ -287,8 +398,15 @@ func refreshAllSessions(secret string) {
 		}
 
 		// 3. Handle Role Sessions
+		// Sessions of an expired MFA session that wasn't restored above can't be refreshed
+		if root, ok := reauth[s.Profile]; ok && !restoredSources[root] {
+			fmt.Printf("⏭️  Skipping '%s' (needs re-auth: MFA session '%s' expired)\n", s.Profile, root)
+			skipped++
+			continue
+		}
+
 		// Try silent refresh first
-		_, err := internal.PerformRefresh(s, secret, s.Region)
+		_, err := internal.PerformRefresh(ctx, s, secret, s.Region)
 		if err == nil {
 			fmt.Printf("✅ Refreshed '%s' silently.\n", s.Profile)
 			refreshed++
@@ -309,11 +427,16 @@ func refreshAllSessions(secret string) {
 					var response string
 					fmt.Scanln(&response)
 					if response == "y" || response == "Y" {
-						smartRefresh(s.SourceProfile, secret, false)
+						if !smartRefresh(ctx, s.SourceProfile, secret, false) {
+							fmt.Printf("❌ Failed to refresh '%s': source '%s' could not be restored.\n", s.Profile, s.SourceProfile)
+							failed++
+							restoredSources[s.SourceProfile] = false // Don't offer it again
+							continue
+						}
 						restoredSources[s.SourceProfile] = true
 
 						// Retry silent refresh for the role after source is restored
-						_, retryErr := internal.PerformRefresh(s, secret, s.Region)
+						_, retryErr := internal.PerformRefresh(ctx, s, secret, s.Region)
 						if retryErr == nil {
 							fmt.Printf("✅ Refreshed '%s' after source restore.\n", s.Profile)
 							refreshed++
@@ -344,20 +467,121 @@ func refreshAllSessions(secret string) {
 	fmt.Printf("\n📊 Summary: %d refreshed/active, %d skipped, %d failed\n", refreshed, skipped, failed)
 
 	if refreshed > 0 {
-		fmt.Println("🔄 Automatically syncing sessions to credentials file...")
-		syncCount, err := internal.SyncAllToAWS(secret)
-		if err != nil {
-			fmt.Printf("⚠️  Auto-sync failed: %v\n", err)
-		} else {
-			fmt.Printf("✅ Synced %d sessions to ~/.aws/credentials\n", syncCount)
+		autoSyncAfterRefresh(secret)
+	}
+	return internal.RefreshSummary{Refreshed: refreshed, Skipped: skipped, Failed: failed}, nil
+}
+
+func autoSyncAfterRefresh(secret string) {
+	fmt.Println("🔄 Automatically syncing sessions to credentials file...")
+	syncCount, err := internal.SyncAllToAWS(secret)
+	if err != nil {
+		fmt.Printf("⚠️  Auto-sync failed: %v\n", err)
+	} else {
+		fmt.Printf("✅ Synced %d sessions to ~/.aws/credentials\n", syncCount)
+	}
+}
+
+// refreshAllInteractive walks expired sessions grouped by their source root, so every
+// dependent of an MFA session is restored after a single MFA prompt. Like
+// refreshAllSessions, it returns an error only when the sessions couldn't be loaded.
+func refreshAllInteractive(ctx context.Context, secret string) (internal.RefreshSummary, error) {
+	sessions, err := internal.ListAllSessions(secret)
+	if err != nil {
+		fmt.Println("❌ " + i18n.T("sessions.load_failed", err))
+		return internal.RefreshSummary{}, err
+	}
+
+	// Active sessions of an expired MFA session are rebuilt with it, after the same prompt
+	now := time.Now()
+	reauth := internal.NeedsReauth(sessions, now)
+	var expired []*internal.AWSSession
+	for _, s := range sessions {
+		if _, ok := reauth[s.Profile]; ok || now.After(s.Expiration) {
+			expired = append(expired, s)
 		}
 	}
+	if len(expired) == 0 {
+		fmt.Println("✅ No expired sessions.")
+		return internal.RefreshSummary{}, nil
+	}
+
+	byProfile := make(map[string]*internal.AWSSession, len(sessions))
+	for _, s := range sessions {
+		byProfile[s.Profile] = s
+	}
+
+	groups := internal.GroupBySourceRoot(expired, sessions)
+	if len(reauth) > 0 {
+		fmt.Printf("🔄 %d sessions to restore in %d groups (%d need re-auth)\n", len(expired), len(groups), len(reauth))
+	} else {
+		fmt.Printf("🔄 %d expired sessions in %d groups\n", len(expired), len(groups))
+	}
+
+	refreshed, skipped, failed := 0, 0, 0
+	for i, g := range groups {
+		root := byProfile[g.Root]
+		names := make([]string, 0, len(g.Dependents))
+		for _, d := range g.Dependents {
+			names = append(names, d.Profile)
+		}
+
+		fmt.Printf("\n[%d/%d] ", i+1, len(groups))
+		switch {
+		case root.RoleArn == "MFA-Session" && len(names) > 0:
+			fmt.Printf("🔒 MFA session '%s' → %s\n", g.Root, strings.Join(names, ", "))
+		case len(names) > 0:
+			fmt.Printf("'%s' → %s\n", g.Root, strings.Join(names, ", "))
+		default:
+			fmt.Printf("'%s'\n", g.Root)
+		}
+
+		fmt.Print("   Restore now? (Y/n/q): ")
+		var response string
+		fmt.Scanln(&response)
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response == "q" {
+			skipped += len(g.Dependents) + 1
+			for _, rest := range groups[i+1:] {
+				skipped += len(rest.Dependents) + 1
+			}
+			break
+		}
+		if response == "n" || response == "no" {
+			fmt.Printf("⏭️  Skipping '%s'.\n", g.Root)
+			skipped += len(g.Dependents) + 1
+			continue
+		}
+
+		// The root needs a real restore only when it has expired; this is the one MFA prompt
+		if now.After(root.Expiration) {
+			if !smartRefresh(ctx, g.Root, secret, false) {
+				fmt.Printf("❌ Could not restore '%s'; skipping its %d dependents.\n", g.Root, len(g.Dependents))
+				failed += len(g.Dependents) + 1
+				continue
+			}
+			refreshed++
+		}
+
+		r, f := rebuildDependents(ctx, g.Dependents, secret)
+		refreshed += r
+		failed += f
+	}
+
+	fmt.Printf("\n📊 Summary: %d refreshed, %d skipped, %d failed\n", refreshed, skipped, failed)
+	if refreshed > 0 {
+		autoSyncAfterRefresh(secret)
+	}
+	return internal.RefreshSummary{Refreshed: refreshed, Skipped: skipped, Failed: failed}, nil
 }
 
 func init() {
 	refreshCmd.Flags().StringVar(&refreshSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption")
 	refreshCmd.Flags().BoolVar(&refreshAll, "all", false, "Refresh all active sessions silently")
+	refreshCmd.Flags().BoolVarP(&refreshInteractive, "interactive", "i", false, "With --all, walk expired sessions grouped by MFA source (one MFA prompt per source)")
 	refreshCmd.Flags().StringVar(&refreshProfile, "profile", "", "Profile to refresh")
+	refreshCmd.Flags().StringVar(&refreshAt, "at", "", "Wait until the next HH:MM (display time zone), then refresh")
+	refreshCmd.Flags().StringVar(&refreshFailOn, "fail-on", internal.FailOnAny, "With --all, exit with status 2 when 'any' session failed, when 'all' tried failed, or 'none'")
 	refreshCmd.Flags().BoolVarP(&forceRefresh, "force", "f", false, "Force interactive re-login even if session is active")
 	rootCmd.AddCommand(refreshCmd)
 }
@@ -2,19 +2,34 @@ package cmd
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/chukul/cloudctl/internal"
 	"github.com/chukul/cloudctl/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	roleRemoveAll bool
+	roleRemoveAll   bool
+	roleDescription string
+	roleRegion      string
+	roleDuration    int32
+	roleMFARequired bool
+	roleColor       string
+	roleGroup       string
+	roleListGroup   string
+	roleListResolve bool
+	roleListSource  string
+
+	roleExportEncrypt  bool
+	roleImportConflict string
+	roleImportDryRun   bool
 )
 
 var roleCmd = &cobra.Command{
@@ -27,12 +42,16 @@ var roleListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all saved IAM Roles",
 	Run: func(cmd *cobra.Command, args []string) {
-		roles, err := internal.ListRoles()
+		roles, err := internal.ListRoleAliases()
 		if err != nil {
 			fmt.Printf("❌ Failed to load roles: %v\n", err)
 			return
 		}
 
+		if cmd.Flags().Changed("group") {
+			roles = internal.FilterRolesByGroup(roles, roleListGroup)
+		}
+
 		if len(roles) == 0 {
 			fmt.Println("📭 No IAM Roles found.")
 			fmt.Println("\n💡 Add one with:")
@@ -40,36 +59,209 @@ var roleListCmd = &cobra.Command{
 			return
 		}
 
-		// Sort by name
-		names := make([]string, 0, len(roles))
-		for k := range roles {
-			names = append(names, k)
+		if roleListResolve {
+			resolveRoleList(cmd.Context(), roles)
+			return
 		}
-		sort.Strings(names)
 
 		fmt.Println("IAM Roles")
-		fmt.Println(strings.Repeat("─", 80))
-		for _, name := range names {
-			fmt.Printf("%-20s %s\n", name, roles[name])
+		groups := internal.RoleGroups(roles)
+		if len(groups) == 0 {
+			printRoleTable(roles)
+			return
+		}
+
+		// Grouped aliases first, ungrouped ones last
+		for _, group := range groups {
+			fmt.Printf("\n📁 %s\n", group)
+			printRoleTable(internal.FilterRolesByGroup(roles, group))
+		}
+		if ungrouped := internal.FilterRolesByGroup(roles, ""); len(ungrouped) > 0 {
+			fmt.Println("\n📁 (ungrouped)")
+			printRoleTable(ungrouped)
+		}
+	},
+}
+
+func printRoleTable(roles map[string]internal.RoleAlias) {
+	// Sort by name
+	names := make([]string, 0, len(roles))
+	for k := range roles {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	fmt.Println(strings.Repeat("─", 100))
+	fmt.Printf("%-20s %-14s %-30s %-16s %-5s %s\n", "NAME", "ACCOUNT", "ROLE", "REGION", "MFA", "DESCRIPTION")
+	for _, name := range names {
+		r := roles[name]
+		nameCol := lipgloss.NewStyle().Width(20).Render(name)
+		if r.Color != "" {
+			nameCol = lipgloss.NewStyle().Width(20).Foreground(lipgloss.Color(r.Color)).Bold(true).Render(name)
+		}
+
+		roleCol := internal.RoleName(r.ARN)
+		if roleCol == "" {
+			roleCol = r.ARN
+		}
+		region := r.Region
+		if r.Duration > 0 {
+			region = fmt.Sprintf("%s %s", region, internal.FormatDurationShort(time.Duration(r.Duration)*time.Second))
+		}
+		mfa := ""
+		if r.MFARequired {
+			mfa = "yes"
+		}
+		fmt.Printf("%s %-14s %-30s %-16s %-5s %s\n", nameCol, r.AccountID(), roleCol, strings.TrimSpace(region), mfa, r.Description)
+	}
+}
+
+type roleResolveResult struct {
+	checks  []internal.RoleCheck
+	account string
+}
+
+// resolveRoleList checks every alias against IAM using --source credentials and flags
+// stale aliases and metadata that no longer matches the role.
+func resolveRoleList(ctx context.Context, roles map[string]internal.RoleAlias) {
+	source := roleListSource
+	if source == "" {
+		profiles := listAWSProfiles()
+		if cloudctlProfiles, _ := internal.ListProfiles(); len(cloudctlProfiles) > 0 {
+			profiles = append(profiles, cloudctlProfiles...)
+		}
+		sort.Strings(profiles)
+		if len(profiles) == 0 {
+			fmt.Println("❌ --source is required to resolve roles")
+			return
+		}
+		selected, err := ui.SelectProfile("Select Source Profile for IAM Lookups", profiles)
+		if err != nil {
+			return
+		}
+		source = selected
+	}
+
+	secret, _ := internal.GetSecret("")
+	cfg, err := internal.LoadSourceConfig(ctx, source, secret, "ap-southeast-1")
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	res, err := ui.Spin(ctx, fmt.Sprintf("Looking up %d roles with iam:GetRole...", len(roles)), func(ctx context.Context) (any, error) {
+		checks, account, err := internal.ResolveRoles(ctx, cfg, roles)
+		return roleResolveResult{checks, account}, err
+	})
+	if err != nil {
+		fmt.Printf("❌ Failed to resolve roles: %v\n", err)
+		return
+	}
+	result := res.(roleResolveResult)
+
+	fmt.Printf("IAM Roles (resolved in account %s via '%s')\n", result.account, source)
+	fmt.Println(strings.Repeat("─", 100))
+	fmt.Printf("%-20s %-14s %-30s %-10s %-5s %s\n", "NAME", "ACCOUNT", "ROLE", "MAX", "MFA", "STATUS")
+
+	stale := 0
+	for _, c := range result.checks {
+		roleCol := internal.RoleName(c.Alias.ARN)
+		if roleCol == "" {
+			roleCol = c.Alias.ARN
+		}
+
+		maxCol, mfaCol := "", ""
+		var status string
+		switch c.Status {
+		case internal.RoleStatusOK:
+			maxCol = internal.FormatDurationShort(time.Duration(c.MaxSessionDuration) * time.Second)
+			if c.TrustRequiresMFA {
+				mfaCol = "yes"
+			} else {
+				mfaCol = "no"
+			}
+			var notes []string
+			if c.Alias.Duration > c.MaxSessionDuration {
+				notes = append(notes, fmt.Sprintf("alias duration %ds exceeds max", c.Alias.Duration))
+			}
+			if c.TrustRequiresMFA && !c.Alias.MFARequired {
+				notes = append(notes, "trust requires MFA (add --mfa-required)")
+			}
+			status = "✅ exists"
+			if len(notes) > 0 {
+				status = "⚠️  " + strings.Join(notes, "; ")
+			}
+		case internal.RoleStatusStale:
+			status = "❌ stale: role no longer exists"
+			stale++
+		case internal.RoleStatusOtherAccount:
+			status = "➖ not checked (other account)"
+		default:
+			status = fmt.Sprintf("⚠️  lookup failed: %v", c.Err)
+		}
+		fmt.Printf("%-20s %-14s %-30s %-10s %-5s %s\n", c.Name, c.Alias.AccountID(), roleCol, maxCol, mfaCol, status)
+	}
+
+	if stale > 0 {
+		fmt.Printf("\n💡 %d stale alias(es). Remove with: cloudctl role remove <name>\n", stale)
+	}
+}
+
+var roleGroupsCmd = &cobra.Command{
+	Use:   "groups",
+	Short: "List role alias groups",
+	Run: func(cmd *cobra.Command, args []string) {
+		roles, err := internal.ListRoleAliases()
+		if err != nil {
+			fmt.Printf("❌ Failed to load roles: %v\n", err)
+			return
+		}
+
+		groups := internal.RoleGroups(roles)
+		if len(groups) == 0 {
+			fmt.Println("📭 No role groups found.")
+			fmt.Println("\n💡 Assign a group with:")
+			fmt.Println("   cloudctl role add <name> <arn> --group <group>")
+			return
+		}
+
+		for _, group := range groups {
+			fmt.Printf("%-20s %d roles\n", group, len(internal.FilterRolesByGroup(roles, group)))
+		}
+		if ungrouped := internal.FilterRolesByGroup(roles, ""); len(ungrouped) > 0 {
+			fmt.Printf("%-20s %d roles\n", "(ungrouped)", len(ungrouped))
 		}
 	},
 }
 
 var roleAddCmd = &cobra.Command{
 	Use:   "add <name> <arn>",
-	Short: "Add an IAM Role alias",
-	Args:  cobra.ExactArgs(2),
+	Short: "Add or update an IAM Role alias",
+	Long: `Add an IAM Role alias. Optional metadata is used as defaults when logging in
+with --role <name>; explicit login flags always win. Re-running add replaces the alias.`,
+	Example: `  cloudctl role add prod-admin arn:aws:iam::123456789012:role/Admin \
+    --group payments --description "Production admin" --region us-east-1 --duration 3600 --mfa-required --color "#D0021B"`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		name := args[0]
 		arn := args[1]
 
 		// Basic validation
-		if !strings.HasPrefix(arn, "arn:aws:iam::") || !strings.Contains(arn, ":role/") {
+		if _, err := internal.ParseRoleARN(arn); err != nil {
 			fmt.Println("⚠️  Warning: The ARN provided doesn't look like a standard IAM Role ARN.")
 			fmt.Println("   Standard format: arn:aws:iam::<account-id>:role/<role-name>")
 		}
 
-		if err := internal.SaveRole(name, arn); err != nil {
+		alias := internal.RoleAlias{
+			ARN:         arn,
+			Description: roleDescription,
+			Region:      roleRegion,
+			Duration:    roleDuration,
+			MFARequired: roleMFARequired,
+			Color:       roleColor,
+			Group:       roleGroup,
+		}
+		if err := internal.SaveRoleAlias(name, alias); err != nil {
 			fmt.Printf("❌ Failed to save role: %v\n", err)
 			return
 		}
@@ -135,23 +327,42 @@ var roleRemoveCmd = &cobra.Command{
 
 var roleExportCmd = &cobra.Command{
 	Use:   "export [file.json]",
-	Short: "Export all IAM Role aliases to JSON",
+	Short: "Export all IAM Role and MFA device aliases to JSON",
+	Long: `Export role aliases (with metadata) and MFA device aliases to JSON.
+With --encrypt the file is encrypted with a passphrase (prompted, or CLOUDCTL_EXPORT_PASSPHRASE).`,
 	Run: func(cmd *cobra.Command, args []string) {
-		roles, err := internal.ListRoles()
+		bundle, err := internal.ExportAliases()
 		if err != nil {
-			fmt.Printf("❌ Failed to load roles: %v\n", err)
+			fmt.Printf("❌ Failed to load aliases: %v\n", err)
 			return
 		}
 
-		b, _ := json.MarshalIndent(roles, "", "  ")
+		passphrase := ""
+		if roleExportEncrypt {
+			passphrase, err = readPassphrase("Export passphrase", true)
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				return
+			}
+		}
+
+		b, err := internal.MarshalAliasBundle(bundle, passphrase)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
 
 		if len(args) > 0 {
-			err := os.WriteFile(args[0], b, 0644)
+			mode := os.FileMode(0644)
+			if roleExportEncrypt {
+				mode = 0600
+			}
+			err := os.WriteFile(args[0], b, mode)
 			if err != nil {
 				fmt.Printf("❌ Failed to write file: %v\n", err)
 				return
 			}
-			fmt.Printf("✅ Exported %d roles to %s\n", len(roles), args[0])
+			fmt.Printf("✅ Exported %d roles and %d MFA devices to %s\n", len(bundle.Roles), len(bundle.MFADevices), args[0])
 		} else {
 			fmt.Println(string(b))
 		}
@@ -160,9 +371,21 @@ var roleExportCmd = &cobra.Command{
 
 var roleImportCmd = &cobra.Command{
 	Use:   "import <file.json>",
-	Short: "Import IAM Role aliases from JSON",
-	Args:  cobra.ExactArgs(1),
+	Short: "Import IAM Role and MFA device aliases from JSON",
+	Long: `Import aliases from a role export (plain, encrypted, or the older name → ARN format).
+Aliases that already exist with a different value are handled by --on-conflict.`,
+	Example: `  # Preview what would change
+  cloudctl role import team-roles.json --dry-run
+
+  # Keep both versions of conflicting aliases (existing stays, import gets a -2 suffix)
+  cloudctl role import team-roles.json --on-conflict rename`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := internal.ValidateConflictStrategy(roleImportConflict); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+
 		filePath := args[0]
 		b, err := os.ReadFile(filePath)
 		if err != nil {
@@ -170,32 +393,100 @@ var roleImportCmd = &cobra.Command{
 			return
 		}
 
-		var importedRoles map[string]string
-		if err := json.Unmarshal(b, &importedRoles); err != nil {
-			fmt.Printf("❌ Failed to parse JSON: %v\n", err)
+		passphrase := ""
+		if internal.IsEncryptedAliasBundle(b) {
+			passphrase, err = readPassphrase("Import passphrase", false)
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				return
+			}
+		}
+
+		incoming, err := internal.ParseAliasBundle(b, passphrase)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		for name, alias := range incoming.Roles {
+			if err := alias.Validate(); err != nil {
+				fmt.Printf("⚠️  Skipping role '%s': %v\n", name, err)
+				delete(incoming.Roles, name)
+			}
+		}
+
+		current, err := internal.ExportAliases()
+		if err != nil {
+			fmt.Printf("❌ Failed to load aliases: %v\n", err)
 			return
 		}
 
-		currentRoles, _ := internal.ListRoles()
-		mergedCount := 0
-		for name, arn := range importedRoles {
-			currentRoles[name] = arn
-			mergedCount++
+		merged, changes := internal.MergeAliases(current, incoming, roleImportConflict)
+		counts := printMergeChanges(changes)
+
+		if roleImportDryRun {
+			fmt.Println("\n💡 Dry run: no changes were saved.")
+			return
+		}
+		if counts["add"]+counts["overwrite"]+counts["rename"] == 0 {
+			fmt.Println("\n✅ Nothing to import.")
+			return
 		}
 
-		if err := internal.SaveAllRoles(currentRoles); err != nil {
-			fmt.Printf("❌ Failed to save roles: %v\n", err)
+		if err := internal.SaveAliasBundle(merged); err != nil {
+			fmt.Printf("❌ Failed to save aliases: %v\n", err)
 			return
 		}
 
-		fmt.Printf("✅ Successfully imported/merged %d roles\n", mergedCount)
+		fmt.Printf("\n✅ Imported: %d added, %d overwritten, %d renamed, %d skipped\n",
+			counts["add"], counts["overwrite"], counts["rename"], counts["skip"])
 	},
 }
 
+// printMergeChanges prints an import diff and returns the number of changes per action.
+func printMergeChanges(changes []internal.MergeChange) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range changes {
+		counts[c.Action]++
+		kind := "role"
+		if c.Kind == "mfa" {
+			kind = "mfa "
+		}
+		switch c.Action {
+		case "add":
+			fmt.Printf("  + %s %-20s %s\n", kind, c.Name, c.New)
+		case "overwrite":
+			fmt.Printf("  ~ %s %-20s %s → %s\n", kind, c.Name, c.Old, c.New)
+		case "rename":
+			fmt.Printf("  + %s %-20s %s (exists, imported as '%s')\n", kind, c.Name, c.New, c.NewName)
+		case "skip":
+			fmt.Printf("  ! %s %-20s kept %s (import has %s)\n", kind, c.Name, c.Old, c.New)
+		}
+	}
+	if counts["unchanged"] > 0 {
+		fmt.Printf("  = %d aliases already up to date\n", counts["unchanged"])
+	}
+	return counts
+}
+
 func init() {
 	roleRemoveCmd.Flags().BoolVar(&roleRemoveAll, "all", false, "Remove all stored IAM Role aliases")
+	roleAddCmd.Flags().StringVar(&roleDescription, "description", "", "Description shown in role list")
+	roleAddCmd.Flags().StringVar(&roleRegion, "region", "", "Default region when logging in with this alias")
+	roleAddCmd.Flags().Int32Var(&roleDuration, "duration", 0, "Default session duration in seconds (900-43200)")
+	roleAddCmd.Flags().BoolVar(&roleMFARequired, "mfa-required", false, "Ask for an MFA device when logging in with this alias")
+	roleAddCmd.Flags().StringVar(&roleGroup, "group", "", "Group (e.g. client or environment) for listing and the login picker")
+	roleAddCmd.Flags().StringVar(&roleColor, "color", "", "Display color (\"#RRGGBB\" or ANSI color number)")
+
+	roleListCmd.Flags().BoolVar(&roleListResolve, "resolve", false, "Look up each role with iam:GetRole and flag stale aliases")
+	roleListCmd.Flags().StringVar(&roleListSource, "source", "", "AWS profile or cloudctl session used for --resolve lookups")
+	roleListCmd.Flags().StringVar(&roleListGroup, "group", "", "Only list roles in this group (\"\" for ungrouped)")
+
+	roleExportCmd.Flags().BoolVar(&roleExportEncrypt, "encrypt", false, "Encrypt the export with a passphrase")
+	roleImportCmd.Flags().StringVar(&roleImportConflict, "on-conflict", internal.ConflictSkip, "How to handle existing aliases with a different value: skip, overwrite or rename")
+	roleImportCmd.Flags().BoolVar(&roleImportDryRun, "dry-run", false, "Show what would change without saving")
 
 	roleCmd.AddCommand(roleListCmd)
+	roleCmd.AddCommand(roleGroupsCmd)
 	roleCmd.AddCommand(roleAddCmd)
 	roleCmd.AddCommand(roleRemoveCmd)
 	roleCmd.AddCommand(roleExportCmd)
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
+	"github.com/chukul/cloudctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// rootLoginOptions are the flags of `cloudctl root-login`.
+type rootLoginOptions struct {
+	source   string
+	profile  string
+	account  string
+	task     string
+	secret   string
+	region   string
+	duration int32
+	replace  bool
+}
+
+var rootLoginCmd = newRootLoginCmd()
+
+// newRootLoginCmd builds `cloudctl root-login` with its own options.
+func newRootLoginCmd() *cobra.Command {
+	o := &rootLoginOptions{}
+	cmd := &cobra.Command{
+		Use:   "root-login",
+		Short: "Start a short root session in a member account with sts:AssumeRoot",
+		Long: `Start a privileged root session in a member account of your organization, scoped to
+one task policy, for the few tasks that need the root user: deleting or auditing root
+credentials, or unlocking an S3 bucket or SQS queue policy that denies everyone.
+
+This needs centralized root access enabled in the organization, and --source must hold
+credentials of the management account or the delegated administrator for it. Root
+sessions last at most 15 minutes, can't be refreshed or used for console sign-in, and
+are recorded in the local audit log.
+
+Tasks: IAMAuditRootUserCredentials, IAMCreateRootUserPassword,
+IAMDeleteRootUserCredentials, S3UnlockBucketPolicy, SQSUnlockQueuePolicy.`,
+		Example: `  cloudctl root-login --source org-admin --account 123456789012 --task S3UnlockBucketPolicy
+  cloudctl exec root-123456789012 -- aws s3api delete-bucket-policy --bucket locked-bucket`,
+		Args: cobra.NoArgs,
+		Run:  o.run,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&o.source, "source", "", "Management or delegated administrator profile (cloudctl session or AWS CLI profile)")
+	flags.StringVar(&o.account, "account", "", "Member account ID to start the root session in")
+	flags.StringVar(&o.task, "task", "", "Task policy name (e.g. S3UnlockBucketPolicy) or root-task policy ARN")
+	flags.StringVar(&o.profile, "profile", "", "Name to store the root session as (default: root-<account>)")
+	flags.StringVar(&o.secret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for encryption (or set CLOUDCTL_SECRET env var)")
+	flags.StringVar(&o.region, "region", "ap-southeast-1", "AWS region of the regional STS endpoint (AssumeRoot has no global endpoint)")
+	flags.BoolVar(&o.replace, "replace", false, "Replace an active session of another role stored under the same name")
+	flags.Int32Var(&o.duration, "duration", internal.MaxRootSessionSeconds, "Session duration in seconds (max: 900 = 15 min)")
+	return cmd
+}
+
+// run implements `cloudctl root-login`.
+func (o *rootLoginOptions) run(cmd *cobra.Command, args []string) {
+	if o.source == "" {
+		o.source = defaultAmbientSource()
+	}
+	if o.source == "" {
+		awsProfiles, optionToProfile := awsProfileOptions()
+		if option, source := ambientSourceOption(); option != "" {
+			awsProfiles = append([]string{option}, awsProfiles...)
+			optionToProfile[option] = source
+		}
+		if len(awsProfiles) > 0 {
+			selected, err := ui.SelectProfile("Select Source Profile", awsProfiles)
+			if err != nil {
+				return
+			}
+			o.source = optionToProfile[selected]
+		}
+	}
+	if o.account == "" {
+		var err error
+		o.account, err = ui.GetInput("Enter Member Account ID", "123456789012", false)
+		if err != nil {
+			return
+		}
+	}
+	if o.task == "" {
+		selected, err := ui.SelectProfile("Select Task", internal.RootTasks)
+		if err != nil {
+			return
+		}
+		o.task = selected
+	}
+	if o.source == "" || o.account == "" {
+		printer.Error("%s", i18n.T("login.missing_params"))
+		printer.Detail("--source: Management or delegated administrator profile")
+		printer.Detail("--account: Member account ID")
+		printer.Tip("\n%s", i18n.T("common.example"))
+		printer.Detail("cloudctl root-login --source org-admin --account 123456789012 --task S3UnlockBucketPolicy")
+		os.Exit(1)
+	}
+
+	taskArn, err := internal.RootTaskPolicyArn(o.task)
+	if err != nil {
+		printer.Error("%v", err)
+		os.Exit(1)
+	}
+	if o.profile == "" {
+		o.profile = "root-" + o.account
+	}
+	if !checkSessionName(&o.profile, internal.RootSessionArn(o.account), o.secret, o.replace, true) {
+		return
+	}
+
+	secret, err := internal.GetSecret(o.secret)
+	if err != nil {
+		printer.Error("%s", i18n.T("secret.required"))
+		printer.Tip("\n%s", i18n.T("secret.set_hint"))
+		os.Exit(1)
+	}
+
+	ctx := cmd.Context()
+	cfg, err := internal.LoadSourceConfig(ctx, o.source, secret, o.region)
+	if err != nil {
+		printer.Error("%v", err)
+		os.Exit(1)
+	}
+
+	res, err := ui.Spin(ctx, fmt.Sprintf("Assuming root in %s...", o.account), func(ctx context.Context) (any, error) {
+		return internal.AssumeRoot(ctx, cfg, o.profile, o.account, taskArn, o.duration)
+	})
+	if err != nil {
+		printer.Error("AssumeRoot failed: %v", err)
+		printer.Tip("\n%s", i18n.T("common.issues"))
+		printer.Detail("• Centralized root access must be enabled in the organization (IAM > Root access management)")
+		printer.Detail("• --source must be the management account or the delegated administrator, with sts:AssumeRoot")
+		printer.Detail("• The account must be a member account of the organization")
+		os.Exit(1)
+	}
+	session := res.(*internal.AWSSession)
+	session.SourceProfile = o.source
+
+	if err := internal.StoreSession(ctx, session, secret, warnStderr); err != nil {
+		printer.Error("Failed to save encrypted session: %v", err)
+		os.Exit(1)
+	}
+	if err := internal.AppendAudit(internal.AuditEvent{
+		Event: internal.AuditRootLogin, Role: session.RoleArn, Profile: o.profile, Detail: internal.RootTaskName(taskArn),
+	}); err != nil {
+		printer.Warn("%v", err)
+	}
+
+	printer.Success("%s", i18n.T("login.stored_encrypted", o.profile))
+	printer.Detail("%s", i18n.T("label.role", session.RoleArn))
+	printer.Detail("Task: %s", internal.RootTaskName(taskArn))
+	printer.Detail("%s", i18n.T("label.source", o.source))
+	printer.Detail("%s", i18n.T("label.expires", internal.FormatExpiry(session.Expiration)))
+	printer.Tip("\nRun the task with:")
+	printer.Detail("cloudctl exec %s -- aws ...", o.profile)
+}
+
+func init() {
+	rootCmd.AddCommand(rootLoginCmd)
+}
@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/chukul/cloudctl/internal"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func printLogo() {
@@ -53,23 +58,194 @@ func printLogo() {
 	fmt.Println()
 }
 
+var (
+	outputFormat string
+	quietLevel   int
+)
+
+// printer is where commands write their output. It is set from --format and --quiet
+// before every command runs.
+var printer, _ = internal.NewPrinter(internal.FormatAuto, internal.QuietNone, os.Stdout, os.Stderr)
+
 var rootCmd = &cobra.Command{
 	Use:   "cloudctl",
 	Short: "cloudctl is a CLI tool for managing AWS sessions and credentials",
 	Long:  `CloudCtl helps you manage multiple AWS accounts and sessions securely with encryption and system keychain integration.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// Check for updates on every command (non-blocking)
-		internal.CheckForUpdates()
+		enableVirtualTerminal()
+		p, err := internal.NewPrinter(outputFormat, quietLevel, os.Stdout, os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --format: %v\n", err)
+			os.Exit(1)
+		}
+		printer = p
+		if !formattedCommands[topLevelCommand(cmd).Name()] && (printer.Format() == internal.FormatJSON || quietLevel > 0) {
+			fmt.Fprintf(os.Stderr, "❌ '%s' doesn't support --format json or --quiet yet\n", cmd.CommandPath())
+			os.Exit(1)
+		}
+		internal.ApplyLocale()
+		recordActivity(cmd)
+		internal.SetAuditCommand(topLevelCommand(cmd).Name())
+		requireValidConfig(cmd)
+		warnStorageExposure(cmd)
 	},
 }
 
+func init() {
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", internal.FormatAuto, "Output format: auto, plain, color or json (auto is color on a terminal unless NO_COLOR is set)")
+	rootCmd.PersistentFlags().CountVarP(&quietLevel, "quiet", "q", "Print less: -q hides info and tips, -qq also success messages and warnings")
+}
+
+// formattedCommands print through the printer, so --format json and --quiet apply to
+// them. Other commands still print text with fmt and refuse those flags rather than
+// ignore them.
+var formattedCommands = map[string]bool{
+	"adopt":      true,
+	"ide-setup":  true,
+	"import":     true,
+	"list":       true,
+	"login":      true,
+	"mfa-login":  true,
+	"policy":     true,
+	"root-login": true,
+	"serve":      true,
+	"stats":      true,
+	"suggest":    true,
+	"sync-store": true,
+	"timeline":   true,
+	"up":         true,
+}
+
+// passiveCommands run without the user typing them (shell prompt, background daemon, SDK
+// and IDE credential processes) or keep running long after they did (serve, mock-sts),
+// so they must not postpone the auto-lock.
+var passiveCommands = map[string]bool{
+	"prompt":             true,
+	"daemon":             true,
+	"clipboard-clear":    true,
+	"credential-process": true,
+	"serve":              true,
+	"mock-sts":           true,
+}
+
+// configGuardedCommands mint or hand out credentials under the config's dual-control,
+// break-glass and auto-lock settings, so they don't run on the defaults a broken config
+// file falls back to.
+var configGuardedCommands = map[string]bool{
+	"login":   true,
+	"refresh": true,
+	"up":      true,
+	"serve":   true,
+}
+
+// requireValidConfig exits when a guarded command would evaluate security settings
+// against defaults because the config file doesn't parse.
+func requireValidConfig(cmd *cobra.Command) {
+	if !configGuardedCommands[topLevelCommand(cmd).Name()] {
+		return
+	}
+	if err := internal.ConfigError(); err != nil {
+		printer.Error("%v", err)
+		printer.Tip("Its dual-control, break-glass and auto-lock settings can't be checked until it is fixed; see: cloudctl config validate")
+		os.Exit(1)
+	}
+}
+
+// topLevelCommand returns the direct child of the root that cmd belongs to.
+func topLevelCommand(cmd *cobra.Command) *cobra.Command {
+	top := cmd
+	for top.HasParent() && top.Parent().HasParent() {
+		top = top.Parent()
+	}
+	return top
+}
+
+// warnStorageExposure warns on stderr when credentials live in a cloud-synced folder or
+// are readable by other users, unless security.allow_insecure_storage is set.
+func warnStorageExposure(cmd *cobra.Command) {
+	if passiveCommands[topLevelCommand(cmd).Name()] || internal.CurrentConfig().Security.AllowInsecureStorage {
+		return
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	warnings := internal.CheckStorageExposure(home)
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "⚠️  Your AWS credentials may be exposed:")
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "   • %s %s\n", w.Path, w.Reason)
+	}
+	fmt.Fprintln(os.Stderr, "💡 Move them out of synced folders and run: chmod 700 ~/.cloudctl && chmod 600 ~/.cloudctl/*.json ~/.aws/credentials")
+	fmt.Fprintf(os.Stderr, "   To accept the risk, set \"security\": {\"allow_insecure_storage\": true} in %s\n\n", internal.ConfigPath())
+}
+
+// recordActivity postpones the auto-lock for commands the user runs.
+func recordActivity(cmd *cobra.Command) {
+	if passiveCommands[topLevelCommand(cmd).Name()] {
+		return
+	}
+	if err := internal.RecordActivity(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	}
+}
+
 // Execute runs the CLI
 func Execute() {
+	if err := internal.DisableCoreDumps(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not disable core dumps: %v\n", err)
+	}
 	if len(os.Args) <= 1 || (len(os.Args) > 1 && os.Args[1] == "help") {
 		printLogo()
 	}
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := interruptContext()
+	defer stop()
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
+
+// interruptGrace is how long a command gets to return after Ctrl-C cancelled its
+// context, before cloudctl exits anyway.
+const interruptGrace = time.Second
+
+// interruptContext returns the context commands run with. Ctrl-C or SIGTERM cancels it,
+// which stops AWS calls in flight. A command still running after interruptGrace (such as
+// one waiting at an MFA prompt) or a second signal exits with the terminal restored, so
+// a prompt that turned off echo doesn't leave the shell broken.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fd := int(os.Stdin.Fd())
+	var state *term.State
+	if term.IsTerminal(fd) {
+		state, _ = term.GetState(fd)
+	}
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+		case <-ctx.Done():
+			return
+		}
+		cancel()
+		select {
+		case <-signals:
+		case <-time.After(interruptGrace):
+		}
+		if state != nil {
+			term.Restore(fd, state)
+		}
+		fmt.Fprintln(os.Stderr, "\n❌ Interrupted")
+		os.Exit(130)
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var scrubDirs []string
+var scrubYes bool
+var scrubDryRun bool
+var scrubSecret string
+
+var scrubCmd = &cobra.Command{
+	Use:   "scrub",
+	Short: "Redact credentials of expired sessions from shell history and .env files",
+	Long: `Search shell history files, .env files in project directories and backups in ~/.aws
+for the access keys, secret keys and session tokens of expired cloudctl sessions, and
+offer to replace them with [REDACTED].
+
+Project directories are the current directory, security.scrub_dirs and any --dir, each
+searched a few levels deep. The live ~/.aws/config and ~/.aws/credentials are left to
+'cloudctl sync'. Credentials of active sessions are never touched.`,
+	Example: `  cloudctl scrub
+  cloudctl scrub --dir ~/src --dry-run
+  cloudctl scrub --yes`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		secret, err := internal.GetSecret(scrubSecret)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		sessions, err := internal.ListAllSessions(secret)
+		if err != nil {
+			fmt.Printf("❌ Failed to load sessions: %v\n", err)
+			os.Exit(1)
+		}
+		secrets := internal.ExpiredSessionSecrets(sessions, time.Now())
+		if len(secrets) == 0 {
+			fmt.Println("📭 No expired sessions to look for.")
+			return
+		}
+
+		home, err := os.UserHomeDir()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		dirs := append([]string{}, internal.CurrentConfig().Security.ScrubDirs...)
+		dirs = append(dirs, scrubDirs...)
+		if wd, err := os.Getwd(); err == nil {
+			dirs = append(dirs, wd)
+		}
+		for i, dir := range dirs {
+			if strings.HasPrefix(dir, "~/") {
+				dirs[i] = filepath.Join(home, dir[2:])
+			}
+		}
+
+		var findings []internal.ScrubFinding
+		var files []string
+		for _, path := range internal.ScrubCandidates(home, dirs) {
+			found, err := internal.ScanForSecrets(path, secrets)
+			if err != nil {
+				fmt.Printf("⚠️  Skipping %s: %v\n", path, err)
+				continue
+			}
+			if len(found) > 0 {
+				findings = append(findings, found...)
+				files = append(files, path)
+			}
+		}
+		if len(findings) == 0 {
+			fmt.Println("✅ No credentials of expired sessions found.")
+			return
+		}
+
+		fmt.Println("🔒 Credentials of expired sessions found:")
+		for _, f := range findings {
+			fmt.Printf("   %s:%d  %s (%s)\n", f.Path, f.Line, f.Profile, strings.Join(f.Kinds, ", "))
+		}
+		fmt.Println()
+		if scrubDryRun {
+			fmt.Printf("💡 Run without --dry-run to redact them from %d file(s).\n", len(files))
+			return
+		}
+		if !scrubYes {
+			fmt.Printf("Redact them in %d file(s)? (y/n): ", len(files))
+			var response string
+			fmt.Scanln(&response)
+			if response != "y" && response != "Y" {
+				fmt.Println("⏭️  Nothing changed.")
+				return
+			}
+		}
+
+		total := 0
+		for _, path := range files {
+			n, err := internal.RedactSecretsInFile(path, secrets)
+			if err != nil {
+				fmt.Printf("❌ Failed to redact %s: %v\n", path, err)
+				continue
+			}
+			total += n
+		}
+		fmt.Printf("✅ Redacted %d occurrence(s).\n", total)
+		fmt.Println("💡 Shells keep history in memory too; open a new shell so it isn't written back.")
+	},
+}
+
+func init() {
+	scrubCmd.Flags().StringSliceVar(&scrubDirs, "dir", nil, "Additional project directory to search for .env files (repeatable)")
+	scrubCmd.Flags().BoolVarP(&scrubYes, "yes", "y", false, "Redact without asking")
+	scrubCmd.Flags().BoolVar(&scrubDryRun, "dry-run", false, "Only list what would be redacted")
+	scrubCmd.Flags().StringVar(&scrubSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	rootCmd.AddCommand(scrubCmd)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/chukul/cloudctl/internal"
@@ -18,10 +19,10 @@ var secretCmd = &cobra.Command{
 var secretShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show current keychain secret",
-	Long:  "Reveal the secret stored in your macOS Keychain. Usage of this command requires Touch ID authentication.",
+	Long:  "Reveal the secret stored in your macOS Keychain (or, inside WSL, the Windows Credential Manager). On macOS this requires Touch ID authentication.",
 	Run: func(cmd *cobra.Command, args []string) {
-		if !internal.IsMacOS() {
-			fmt.Println("❌ Keychain integration is only available on macOS")
+		if !internal.HasKeychain() {
+			fmt.Println("❌ Keychain integration is only available on macOS and WSL")
 			return
 		}
 
@@ -45,11 +46,11 @@ var secretShowCmd = &cobra.Command{
 var secretImportCmd = &cobra.Command{
 	Use:   "import [key]",
 	Short: "Import a secret into keychain",
-	Long:  "Save an existing secret key into your macOS Keychain for passwordless operation.",
+	Long:  "Save an existing secret key into your macOS Keychain (or, inside WSL, the Windows Credential Manager) for passwordless operation.",
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		if !internal.IsMacOS() {
-			fmt.Println("❌ Keychain integration is only available on macOS")
+		if !internal.HasKeychain() {
+			fmt.Println("❌ Keychain integration is only available on macOS and WSL")
 			return
 		}
 
@@ -78,8 +79,180 @@ var secretImportCmd = &cobra.Command{
 	},
 }
 
+var secretRecoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Reconstruct the secret from a recovery phrase",
+	Long: `Rebuild the generated encryption secret from the 24-word recovery phrase recorded when it
+was created, e.g. on a new machine. On macOS the secret is saved to the Keychain, inside
+WSL to the Windows Credential Manager.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		phrase, err := ui.GetInput("Enter your 24-word recovery phrase", "word1 word2 ...", true)
+		if err != nil {
+			return
+		}
+
+		secret, err := internal.SecretFromRecoveryPhrase(phrase)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		// Confirm the phrase matches the existing store before overwriting anything
+		if profiles, _ := internal.ListProfiles(); len(profiles) > 0 && !internal.CurrentConfig().Encryption.UsesEnvelope() {
+			if _, err := internal.ListAllSessionsWith(internal.NewSecretProvider(secret)); err != nil {
+				fmt.Println("❌ The recovered secret does not decrypt the existing credential store.")
+				fmt.Println("💡 Check that this is the phrase for this store.")
+				os.Exit(1)
+			}
+		}
+
+		if internal.HasKeychain() {
+			if err := internal.StoreKeychainSecret(secret); err != nil {
+				fmt.Printf("❌ Failed to store secret: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("✅ Secret recovered and saved to Keychain!")
+			return
+		}
+
+		fmt.Println("✅ Secret recovered. Set it in your environment:")
+		fmt.Printf("   export CLOUDCTL_SECRET=\"%s\"\n", secret)
+	},
+}
+
+// recoveryCheckWords is how many words of the phrase the user must repeat back.
+const recoveryCheckWords = 3
+
+// offerRecoveryPhrase shows the recovery phrase for a newly generated secret and checks
+// a few words to make sure it was written down.
+func offerRecoveryPhrase(secret string) {
+	phrase, err := internal.RecoveryPhrase(secret)
+	if err != nil {
+		return
+	}
+
+	fmt.Println("\n📋 Create a recovery phrase to restore this key on another machine? (y/n)")
+	var response string
+	fmt.Scanln(&response)
+	if strings.ToLower(response) != "y" {
+		fmt.Println("💡 You can still back up the key with: cloudctl secret show")
+		return
+	}
+
+	words := strings.Fields(phrase)
+	fmt.Println("\n🔐 Write down these words in order and keep them somewhere safe:")
+	fmt.Println(strings.Repeat("─", 64))
+	for i := 0; i < len(words); i += 4 {
+		for j := i; j < i+4 && j < len(words); j++ {
+			fmt.Printf("%2d. %-12s", j+1, words[j])
+		}
+		fmt.Println()
+	}
+	fmt.Println(strings.Repeat("─", 64))
+	fmt.Println("⚠️  Anyone with these words can decrypt your credentials.")
+
+	positions, err := internal.RecoveryCheckPositions(recoveryCheckWords)
+	if err != nil {
+		return
+	}
+	fmt.Println("\nPress Enter once you have recorded the phrase.")
+	fmt.Scanln()
+	// Scroll the phrase out of view before asking for words back
+	fmt.Print("\033[H\033[2J")
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		ok := true
+		for _, p := range positions {
+			answer, err := ui.GetInput(fmt.Sprintf("Word #%d", p), "", false)
+			if err != nil {
+				return
+			}
+			if strings.ToLower(strings.TrimSpace(answer)) != words[p-1] {
+				ok = false
+			}
+		}
+		if ok {
+			fmt.Println("✅ Recovery phrase verified. Restore with: cloudctl secret recover")
+			return
+		}
+		fmt.Println("❌ That doesn't match the phrase.")
+	}
+	fmt.Println("💡 Back up the key with 'cloudctl secret show' instead.")
+}
+
+var (
+	migrateFrom   string
+	migrateSecret string
+)
+
+var secretMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Re-encrypt the store with the configured encryption provider",
+	Long: `Re-encrypt every stored session after changing encryption.provider in ~/.cloudctl/config.json.
+--from names the provider the store is currently encrypted with. The store and keyring are
+backed up to .bak files first.`,
+	Example: `  # After setting "encryption": {"provider": "kms", "kms_key_id": "alias/cloudctl"}
+  cloudctl secret migrate --from secret`,
+	Run: func(cmd *cobra.Command, args []string) {
+		target := internal.CurrentConfig().Encryption
+		if target.Provider == "" {
+			target.Provider = internal.ProviderSecret
+		}
+		if migrateFrom == target.Provider {
+			fmt.Printf("❌ The store is already configured for the '%s' provider.\n", target.Provider)
+			fmt.Println("💡 Change encryption.provider in the config file first, then pass the old provider with --from.")
+			return
+		}
+
+		secret, err := internal.GetSecret(migrateSecret)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+
+		sourceCfg := target
+		sourceCfg.Provider = migrateFrom
+		from, err := internal.NewCryptoProvider(sourceCfg, secret)
+		if err != nil {
+			fmt.Printf("❌ Source provider '%s': %v\n", migrateFrom, err)
+			return
+		}
+		to, err := internal.NewCryptoProvider(target, secret)
+		if err != nil {
+			fmt.Printf("❌ Target provider '%s': %v\n", target.Provider, err)
+			return
+		}
+
+		backups, err := internal.BackupStoreFiles()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+
+		count, err := internal.MigrateStore(from, to)
+		if err != nil {
+			fmt.Printf("❌ Migration failed after %d session(s): %v\n", count, err)
+			if len(backups) > 0 {
+				fmt.Printf("💡 Restore the previous store from: %s\n", strings.Join(backups, ", "))
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("✅ Re-encrypted %d session(s) from '%s' to '%s'.\n", count, migrateFrom, target.Provider)
+		if len(backups) > 0 {
+			fmt.Printf("💡 Backups: %s (delete them once everything works)\n", strings.Join(backups, ", "))
+		}
+	},
+}
+
 func init() {
+	secretMigrateCmd.Flags().StringVar(&migrateFrom, "from", internal.ProviderSecret, "Provider the store is currently encrypted with (secret, age, kms or tpm)")
+	secretMigrateCmd.Flags().StringVar(&migrateSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for the secret provider")
+
 	secretCmd.AddCommand(secretShowCmd)
 	secretCmd.AddCommand(secretImportCmd)
+	secretCmd.AddCommand(secretMigrateCmd)
+	secretCmd.AddCommand(secretRecoverCmd)
 	rootCmd.AddCommand(secretCmd)
 }
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen   string
+	serveIssuer   string
+	serveAudience string
+	serveTLSCert  string
+	serveTLSKey   string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a credential broker that hands out short-lived role sessions to OIDC users",
+	Long: `Run cloudctl as a credential broker on a team bastion. Users authenticate with an ID
+token from your OIDC identity provider and get short-lived credentials for the roles the
+'serve' section of the config grants them. Roles are assumed from the bastion's own AWS
+identity (serve.source, default the instance role) with the user as SourceIdentity and
+session name, so CloudTrail shows who was behind every session; every issue is also
+recorded in the audit log.
+
+The API:
+  GET  /healthz         liveness, unauthenticated
+  GET  /v1/roles        the roles the caller may assume
+  POST /v1/credentials  {"role": "<alias or ARN>", "duration_seconds": 3600}
+
+Send the ID token as "Authorization: Bearer <token>". Credentials come back in the
+credential_process format. The broker keeps no sessions and needs no cloudctl secret.
+Break-glass and dual-control roles can't be granted.`,
+	Example: `  # ~/.cloudctl/config.json on the bastion
+  "serve": {
+    "audience": "cloudctl",
+    "grants": [
+      {"role": "prod-readonly", "groups": ["sre", "dev"]},
+      {"role": "prod-admin", "users": ["alice@example.com"]}
+    ]
+  }
+
+  cloudctl serve --listen :8088 --oidc https://login.example.com \
+    --tls-cert /etc/cloudctl/tls.crt --tls-key /etc/cloudctl/tls.key
+
+  # From a workstation
+  curl -s -H "Authorization: Bearer $ID_TOKEN" -d '{"role":"prod-readonly"}' \
+    https://bastion.example.com:8088/v1/credentials`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := internal.CurrentConfig().Serve
+		if serveAudience != "" {
+			c.Audience = serveAudience
+		}
+		if serveIssuer == "" {
+			printer.Error("--oidc is required")
+			os.Exit(1)
+		}
+		if c.Audience == "" {
+			printer.Error("No OIDC audience: set serve.audience in %s or pass --audience", internal.ConfigPath())
+			os.Exit(1)
+		}
+		if (serveTLSCert == "") != (serveTLSKey == "") {
+			printer.Error("--tls-cert and --tls-key must be given together")
+			os.Exit(1)
+		}
+		roles, err := internal.ResolveServeGrants(c)
+		if err != nil {
+			printer.Error("Invalid serve.grants: %v", err)
+			os.Exit(1)
+		}
+		if len(roles) == 0 {
+			printer.Error("serve.grants is empty, so no role can be handed out.")
+			printer.Tip("Add grants to the 'serve' section of %s (see cloudctl serve --help)", internal.ConfigPath())
+			os.Exit(1)
+		}
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		verifier, err := internal.NewOIDCVerifier(ctx, serveIssuer, c.Audience)
+		if err != nil {
+			printer.Error("OIDC issuer %s: %v", serveIssuer, err)
+			os.Exit(1)
+		}
+		source, err := internal.LoadProfileConfig(ctx, c.SourceName(), c.STSRegion())
+		if err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+
+		listener, err := net.Listen("tcp", serveListen)
+		if err != nil {
+			printer.Error("Failed to listen on %s: %v", serveListen, err)
+			os.Exit(1)
+		}
+		addr := listener.Addr().(*net.TCPAddr)
+		if serveTLSCert == "" && !addr.IP.IsLoopback() {
+			printer.Warn("Serving without TLS on %s: ID tokens and credentials cross the network in clear text.", addr)
+			printer.Tip("Pass --tls-cert and --tls-key, or put a TLS-terminating proxy in front.")
+		}
+
+		broker := &internal.CredentialBroker{
+			Config:   c,
+			Roles:    roles,
+			Verifier: verifier,
+			Source:   source,
+			Log: func(format string, args ...any) {
+				printer.Print(format, args...)
+			},
+		}
+		server := &http.Server{
+			Handler:           broker,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		scheme := "http"
+		if serveTLSCert != "" {
+			scheme = "https"
+		}
+		printer.Info("Brokering %d role(s) from '%s' for %s on %s://%s (Ctrl+C to stop)",
+			len(roles), c.SourceName(), serveIssuer, scheme, addr)
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancelShutdown()
+			server.Shutdown(shutdownCtx)
+		}()
+
+		if serveTLSCert != "" {
+			err = server.ServeTLS(listener, serveTLSCert, serveTLSKey)
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+		fmt.Println()
+		printer.Success("Stopped.")
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8088", "Address to serve on (e.g. :8088 for all interfaces)")
+	serveCmd.Flags().StringVar(&serveIssuer, "oidc", "", "OIDC issuer URL that authenticates users (e.g. https://login.example.com)")
+	serveCmd.Flags().StringVar(&serveAudience, "audience", "", "Client ID the ID tokens must be issued to (default serve.audience)")
+	serveCmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", "TLS certificate file to serve HTTPS with")
+	serveCmd.Flags().StringVar(&serveTLSKey, "tls-key", "", "TLS private key file of --tls-cert")
+	rootCmd.AddCommand(serveCmd)
+}
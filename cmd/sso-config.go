@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ssoConfigDryRun bool
+	ssoConfigForce  bool
+)
+
+var ssoConfigCmd = &cobra.Command{
+	Use:   "sso-config <spec.json>",
+	Short: "Generate sso-session and profile blocks in ~/.aws/config",
+	Long: `Write an [sso-session] block and one [profile] block per account and permission set
+into ~/.aws/config, from a JSON spec. Running it again replaces the blocks generated for
+the same sso-session, so profiles removed from the spec disappear; everything else in the
+file is kept. Profiles with the same name written by hand are not touched unless --force
+is given.
+
+The generated profiles work with 'aws sso login' and can be used as --source for
+cloudctl login.`,
+	Example: `  cloudctl sso-config sso.json --dry-run
+  cloudctl sso-config sso.json
+
+  # sso.json
+  {
+    "sso_session": "my-org",
+    "start_url": "https://my-org.awsapps.com/start",
+    "sso_region": "us-east-1",
+    "default_region": "ap-southeast-1",
+    "accounts": [
+      {"name": "prod", "account_id": "123456789012", "permission_sets": ["ReadOnlyAccess", "AdministratorAccess"]}
+    ]
+  }`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		spec, err := internal.LoadSSOSpec(args[0])
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		if ssoConfigDryRun {
+			fmt.Print(internal.RenderSSOConfig(spec))
+			return
+		}
+
+		conflicts, err := internal.WriteSSOConfig(spec, ssoConfigForce)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if len(conflicts) > 0 {
+			fmt.Printf("❌ %s already has sections cloudctl didn't generate: %s\n", internal.AWSConfigPath(), strings.Join(conflicts, ", "))
+			fmt.Println("💡 Rename them, or use --force to replace them.")
+			os.Exit(1)
+		}
+
+		profiles := spec.Profiles()
+		fmt.Printf("✅ Wrote sso-session '%s' and %d profile(s) to %s\n", spec.Session, len(profiles), internal.AWSConfigPath())
+		for _, p := range profiles {
+			fmt.Printf("   • %-30s %s / %s\n", p.Name, p.AccountID, p.PermissionSet)
+		}
+		fmt.Printf("\n💡 Sign in with: aws sso login --sso-session %s\n", spec.Session)
+	},
+}
+
+func init() {
+	ssoConfigCmd.Flags().BoolVar(&ssoConfigDryRun, "dry-run", false, "Print the generated blocks instead of writing them")
+	ssoConfigCmd.Flags().BoolVar(&ssoConfigForce, "force", false, "Replace hand-written sections with the same names")
+	rootCmd.AddCommand(ssoConfigCmd)
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsSince   string
+	statsProfile string
+	statsJSON    bool
+)
+
+// statsEntry is one profile in `stats --json`; latencies and intervals are in milliseconds.
+type statsEntry struct {
+	Profile             string         `json:"profile"`
+	STSCalls            int            `json:"sts_calls"`
+	STSLatencyMS        int64          `json:"sts_latency_ms"`
+	Refreshes           int            `json:"refreshes"`
+	RefreshLatencyMS    int64          `json:"refresh_latency_ms"`
+	RefreshIntervalMS   int64          `json:"refresh_interval_ms,omitempty"`
+	Federations         int            `json:"console_federations"`
+	FederationLatencyMS int64          `json:"console_federation_latency_ms"`
+	Failures            int            `json:"failures"`
+	Commands            map[string]int `json:"commands"`
+	Operations          map[string]int `json:"operations"`
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show STS calls, refreshes and console federations per profile",
+	Long: `Summarize the STS calls, session refreshes and console federations cloudctl made on this
+machine, per profile, from the local audit log: how many, how long they took on average,
+how often sessions were refreshed and which commands made the calls. Use it to find noisy
+automations and to tune the daemon's interval.`,
+	Example: `  cloudctl stats
+  cloudctl stats --since 30d --profile prod-admin
+  cloudctl stats --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		lookback, err := parseLookback(statsSince)
+		if err != nil {
+			printer.Error("%v", err)
+			return
+		}
+		since := time.Now().Add(-lookback)
+
+		events, err := internal.ReadAuditLog()
+		if err != nil {
+			printer.Error("%v", err)
+			return
+		}
+		profiles, days := internal.UsageStats(events, since, statsProfile)
+
+		if useJSON(statsJSON) {
+			out := make([]statsEntry, 0, len(profiles))
+			for _, p := range profiles {
+				out = append(out, statsEntry{
+					Profile:             p.Profile,
+					STSCalls:            p.STSCalls,
+					STSLatencyMS:        p.STSLatency.Milliseconds(),
+					Refreshes:           p.Refreshes,
+					RefreshLatencyMS:    p.RefreshLatency.Milliseconds(),
+					RefreshIntervalMS:   p.RefreshInterval.Milliseconds(),
+					Federations:         p.Federations,
+					FederationLatencyMS: p.FederationLatency.Milliseconds(),
+					Failures:            p.Failures,
+					Commands:            p.Commands,
+					Operations:          p.Operations,
+				})
+			}
+			printer.Result(out)
+			return
+		}
+
+		if len(profiles) == 0 {
+			printer.Info("%s No STS calls, refreshes or console federations recorded since %s.", internal.Icon(internal.IconEmpty), internal.FormatTime(since))
+			return
+		}
+
+		printer.Print("Usage since %s", internal.FormatTime(since))
+		printer.Print("%s", strings.Repeat("─", 100))
+		printer.Print("%-26s %-16s %-26s %-16s %-8s %s", "PROFILE", "STS CALLS", "REFRESHES", "CONSOLE", "FAILED", "TOP COMMANDS")
+		for _, p := range profiles {
+			refreshes := fmt.Sprintf("%d", p.Refreshes)
+			if p.RefreshInterval > 0 {
+				refreshes += fmt.Sprintf(" (every ~%s)", internal.FormatDurationShort(p.RefreshInterval))
+			}
+			printer.Print("%-26s %-16s %-26s %-16s %-8d %s", p.Profile,
+				withLatency(p.STSCalls, p.STSLatency), refreshes,
+				withLatency(p.Federations, p.FederationLatency), p.Failures, topCounts(p.Commands, 3))
+		}
+
+		printer.Print("\nPer day")
+		printer.Print("%s", strings.Repeat("─", 100))
+		printer.Print("%-12s %10s %10s %10s", "DAY", "STS CALLS", "REFRESHES", "CONSOLE")
+		for _, d := range days {
+			printer.Print("%-12s %10d %10d %10d", d.Day, d.STSCalls, d.Refreshes, d.Federations)
+		}
+	},
+}
+
+// withLatency formats a count with its average latency, e.g. "42 (~180ms)".
+func withLatency(n int, avg time.Duration) string {
+	if n == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d (~%s)", n, avg.Round(time.Millisecond))
+}
+
+// topCounts lists the n largest counts, e.g. "daemon 40, exec 2".
+func topCounts(counts map[string]int, n int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s %d", k, counts[k])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func init() {
+	statsCmd.Flags().StringVar(&statsSince, "since", "7d", "How far back to summarize (e.g. 24h, 7d, 30d)")
+	statsCmd.Flags().StringVar(&statsProfile, "profile", "", "Only show this profile")
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Print per-profile statistics as JSON")
+	rootCmd.AddCommand(statsCmd)
+}
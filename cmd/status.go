@@ -1,46 +1,77 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"regexp"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
+	"github.com/chukul/cloudctl/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var statusSecret string
+var statusGroupBy string
+var statusRemote bool
+var statusICal bool
+var statusRemind time.Duration
 
-// ANSI color codes are replaced with lipgloss styles
+// Styles are built from the configured theme when the command runs
 
 var (
-	titleStyle = lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#4A90E2")).
-		MarginBottom(1)
+	titleStyle lipgloss.Style
 
 	rowActiveStyle   = lipgloss.NewStyle().MarginBottom(0)
 	rowExpiringStyle = lipgloss.NewStyle().MarginBottom(0)
 	rowExpiredStyle  = lipgloss.NewStyle().MarginBottom(0).Faint(true)
 
-	profileActiveStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
-	profileExpiringStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#F5A623")).Bold(true)
-	profileExpiredStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#D0021B")).Bold(true)
-
-	currentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#4A90E2"))
-	roleStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#B0BEC5"))
-	sourceStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#78909C"))
-	timeStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#90A4AE"))
-	
-	activeTagStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#7ED321")).Bold(true)
-	expiringTagStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#F5A623")).Bold(true)
-	expiredTagStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#D0021B")).Bold(true)
+	profileActiveStyle   lipgloss.Style
+	profileExpiringStyle lipgloss.Style
+	profileExpiredStyle  lipgloss.Style
+
+	currentStyle lipgloss.Style
+	roleStyle    lipgloss.Style
+	sourceStyle  lipgloss.Style
+	timeStyle    lipgloss.Style
+
+	activeTagStyle   lipgloss.Style
+	expiringTagStyle lipgloss.Style
+	expiredTagStyle  lipgloss.Style
 )
 
+// themeColor wraps internal.ThemeColor for lipgloss
+func themeColor(name string) lipgloss.TerminalColor {
+	if c := internal.ThemeColor(name); c != "" {
+		return lipgloss.Color(c)
+	}
+	return lipgloss.NoColor{}
+}
+
+func loadStatusStyles() {
+	titleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(themeColor(internal.ColorAccent)).
+		MarginBottom(1)
+
+	profileActiveStyle = lipgloss.NewStyle().Foreground(themeColor(internal.ColorProfile)).Bold(true)
+	profileExpiringStyle = lipgloss.NewStyle().Foreground(themeColor(internal.ColorExpiring)).Bold(true)
+	profileExpiredStyle = lipgloss.NewStyle().Foreground(themeColor(internal.ColorExpired)).Bold(true)
+
+	currentStyle = lipgloss.NewStyle().Foreground(themeColor(internal.ColorAccent))
+	roleStyle = lipgloss.NewStyle().Foreground(themeColor(internal.ColorRole))
+	sourceStyle = lipgloss.NewStyle().Foreground(themeColor(internal.ColorMuted))
+	timeStyle = lipgloss.NewStyle().Foreground(themeColor(internal.ColorTime))
+
+	activeTagStyle = lipgloss.NewStyle().Foreground(themeColor(internal.ColorActive)).Bold(true)
+	expiringTagStyle = lipgloss.NewStyle().Foreground(themeColor(internal.ColorExpiring)).Bold(true)
+	expiredTagStyle = lipgloss.NewStyle().Foreground(themeColor(internal.ColorExpired)).Bold(true)
+}
+
 type sessionStatus int
 
 const (
@@ -55,70 +86,104 @@ type sessionDisplay struct {
 	remaining time.Duration
 	icon      string
 	isCurrent bool
+	// reauthRoot is the expired MFA session the session depends on, if any.
+	reauthRoot string
 }
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show stored AWS sessions",
 	Run: func(cmd *cobra.Command, args []string) {
+		if statusGroupBy != "status" && statusGroupBy != "account" {
+			fmt.Printf("%s Invalid --group-by '%s' (use status or account)\n", internal.Icon(internal.IconError), statusGroupBy)
+			return
+		}
+		if statusRemind < 0 {
+			fmt.Printf("%s --remind must not be negative\n", internal.Icon(internal.IconError))
+			return
+		}
+
+		// Show the auto-lock state first; a locked store can't list sessions
+		lockState, _ := internal.LoadLockState()
+		lockTimeout := internal.AutoLockTimeout()
+		if lockState.IsLocked(time.Now(), lockTimeout) {
+			fmt.Println(internal.Icon(internal.IconLocked) + " " + i18n.T("status.locked", internal.FormatTime(lockState.LockedSince(lockTimeout))))
+			fmt.Println("\n" + internal.Icon(internal.IconTip) + " " + i18n.T("status.unlock_hint"))
+			return
+		}
+
 		// Get secret from flag, env, or keychain
 		secret, err := internal.GetSecret(statusSecret)
 		if err != nil {
-			fmt.Println("❌ Encryption secret required to view session status")
-			fmt.Println("\n💡 Set the secret:")
+			fmt.Println(internal.Icon(internal.IconError) + " " + i18n.T("secret.required_status"))
+			fmt.Println("\n" + internal.Icon(internal.IconTip) + " " + i18n.T("secret.set_hint"))
 			fmt.Println("   export CLOUDCTL_SECRET=\"your-32-char-encryption-key\"")
 			return
 		}
 
+		if statusRemote {
+			printRemoteStatus(cmd.Context(), secret)
+			return
+		}
+
 		sessions, err := internal.ListAllSessions(secret)
 		if err != nil {
-			fmt.Printf("❌ Failed to load sessions: %v\n", err)
+			fmt.Println(internal.Icon(internal.IconError) + " " + i18n.T("sessions.load_failed", err))
+			return
+		}
+
+		if statusICal {
+			fmt.Print(internal.ExpiryCalendar(sessions, time.Now(), statusRemind))
 			return
 		}
 
 		if len(sessions) == 0 {
-			fmt.Println("📭 No stored sessions found.")
-			fmt.Println("\n💡 Get started:")
+			fmt.Println(internal.Icon(internal.IconEmpty) + " " + i18n.T("status.empty"))
+			fmt.Println("\n" + internal.Icon(internal.IconTip) + " " + i18n.T("status.get_started"))
 			fmt.Println("   cloudctl mfa-login --source <profile> --profile mfa-session --mfa <mfa-arn>")
 			fmt.Println("   cloudctl login --source <profile> --profile <name> --role <role-arn>")
 			return
 		}
 
+		loadStatusStyles()
+
 		// Get current session from environment
 		currentAccessKey := os.Getenv("AWS_ACCESS_KEY_ID")
 
 		// Prepare display data
 		now := time.Now()
 		displays := make([]sessionDisplay, 0, len(sessions))
+		reauth := internal.NeedsReauth(sessions, now)
 
 		for _, s := range sessions {
-			remaining := s.Expiration.Sub(now)
+			remaining := s.UsableUntil().Sub(now)
 			var status sessionStatus
 			var icon string
 
 			if remaining <= 0 {
 				status = statusExpired
-				icon = "🔴"
+				icon = internal.Icon(internal.IconExpired)
 				remaining = 0
 			} else if remaining <= 15*time.Minute {
 				status = statusExpiring
-				icon = "🟡"
+				icon = internal.Icon(internal.IconExpiring)
 			} else {
 				status = statusActive
-				icon = "🟢"
+				icon = internal.Icon(internal.IconActive)
 			}
 
 			// Check if MFA session
 			if s.RoleArn == "MFA-Session" || s.RoleArn == "" {
-				icon = "🔒"
+				icon = internal.Icon(internal.IconMFA)
 			}
 
 			displays = append(displays, sessionDisplay{
-				session:   s,
-				status:    status,
-				remaining: remaining,
-				icon:      icon,
-				isCurrent: s.AccessKey == currentAccessKey,
+				session:    s,
+				status:     status,
+				remaining:  remaining,
+				icon:       icon,
+				isCurrent:  s.AccessKey == currentAccessKey,
+				reauthRoot: reauth[s.Profile],
 			})
 		}
 
@@ -130,10 +195,30 @@ var statusCmd = &cobra.Command{
 			return displays[i].remaining > displays[j].remaining
 		})
 
-		// Print grouped by status
-		printSessionGroup(displays, statusActive, "Active Sessions")
-		printSessionGroup(displays, statusExpiring, "Expiring Soon")
-		printSessionGroup(displays, statusExpired, "Expired Sessions")
+		if statusGroupBy == "account" {
+			printAccountGroups(displays, internal.SessionUsageByAccount(sessions, now))
+		} else {
+			// Print grouped by status
+			printSessionGroup(displays, statusActive, i18n.T("status.title.active"))
+			printSessionGroup(displays, statusExpiring, i18n.T("status.title.expiring"))
+			printSessionGroup(displays, statusExpired, i18n.T("status.title.expired"))
+		}
+
+		printLimitWarnings(internal.CheckSessionLimits(sessions, internal.CurrentConfig().Limits, now))
+
+		// Best-effort: an unreadable credentials file just has nothing to compare
+		if managed, err := internal.ReadManagedCredentials(); err == nil {
+			printCredentialsDrift(internal.DetectCredentialsDrift(sessions, managed))
+		}
+
+		if lockTimeout > 0 {
+			remaining := lockTimeout
+			if !lockState.LastActivity.IsZero() {
+				remaining = time.Until(lockState.LastActivity.Add(lockTimeout))
+			}
+			fmt.Println(lipgloss.NewStyle().MarginTop(1).Foreground(themeColor(internal.ColorMuted)).Render(
+				internal.Icon(internal.IconUnlocked) + " " + i18n.T("status.auto_lock", formatDuration(remaining))))
+		}
 
 		// Add tip for expired sessions
 		hasExpired := false
@@ -143,11 +228,14 @@ var statusCmd = &cobra.Command{
 				break
 			}
 		}
-		if hasExpired {
-			fmt.Println(lipgloss.NewStyle().MarginTop(1).Foreground(lipgloss.Color("#4A90E2")).Render("💡 Tip: ") +
-				lipgloss.NewStyle().Foreground(lipgloss.Color("#B0BEC5")).Render("Use ") +
-				lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF")).Render("cloudctl refresh [profile]") +
-				lipgloss.NewStyle().Foreground(lipgloss.Color("#B0BEC5")).Render(" to quickly restore expired sessions."))
+		if len(reauth) > 0 {
+			command := lipgloss.NewStyle().Bold(true).Foreground(themeColor(internal.ColorProfile)).Render("cloudctl refresh --all -i")
+			fmt.Println(lipgloss.NewStyle().MarginTop(1).Foreground(themeColor(internal.ColorAccent)).Render(internal.Icon(internal.IconTip)+" "+i18n.T("status.tip")) +
+				lipgloss.NewStyle().Foreground(themeColor(internal.ColorRole)).Render(i18n.T("status.tip.reauth", command)))
+		} else if hasExpired {
+			command := lipgloss.NewStyle().Bold(true).Foreground(themeColor(internal.ColorProfile)).Render("cloudctl refresh [profile]")
+			fmt.Println(lipgloss.NewStyle().MarginTop(1).Foreground(themeColor(internal.ColorAccent)).Render(internal.Icon(internal.IconTip)+" "+i18n.T("status.tip")) +
+				lipgloss.NewStyle().Foreground(themeColor(internal.ColorRole)).Render(i18n.T("status.tip.refresh", command)))
 		}
 	},
 }
@@ -164,9 +252,80 @@ func printSessionGroup(displays []sessionDisplay, status sessionStatus, title st
 		return
 	}
 
-	var profileStyle lipgloss.Style
+	fmt.Printf("\n%s\n", titleStyle.Render(title))
+	fmt.Println(lipgloss.NewStyle().Foreground(themeColor(internal.ColorAccent)).Render(strings.Repeat(internal.Icon(internal.IconRule), 100)))
+
+	for _, d := range filtered {
+		printSessionRow(d)
+	}
+}
+
+// printAccountGroups prints sessions under one heading per account with its session
+// counts. displays keep their status order within each account.
+func printAccountGroups(displays []sessionDisplay, usage []internal.AccountUsage) {
+	for _, u := range usage {
+		title := i18n.T("status.title.account", u.AccountID)
+		if u.AccountID == "" {
+			title = i18n.T("status.title.mfa")
+		}
+		summary := i18n.T("status.account_summary", u.Active, u.Expired)
+		if len(u.Roles) > 0 {
+			summary += ", " + i18n.T("status.account_roles", len(u.Roles))
+		}
+
+		fmt.Printf("\n%s  %s\n", titleStyle.UnsetMarginBottom().Render(title), sourceStyle.Render(summary))
+		fmt.Println(lipgloss.NewStyle().Foreground(themeColor(internal.ColorAccent)).Render(strings.Repeat(internal.Icon(internal.IconRule), 100)))
 
-	switch status {
+		for _, d := range displays {
+			if internal.SessionAccountID(d.session) == u.AccountID {
+				printSessionRow(d)
+			}
+		}
+	}
+}
+
+// printLimitWarnings reports sessions approaching or over the org limits in config.
+func printLimitWarnings(warnings []internal.LimitWarning) {
+	if len(warnings) == 0 {
+		return
+	}
+	fmt.Println(lipgloss.NewStyle().MarginTop(1).Foreground(themeColor(internal.ColorExpiring)).Render(
+		internal.Icon(internal.IconWarning) + " " + i18n.T("status.limits")))
+	for _, w := range warnings {
+		key := "status.limit." + string(w.Kind)
+		if w.Exceeded && w.Kind != internal.LimitDuration {
+			key += "_exceeded"
+		}
+		fmt.Println("   " + i18n.T(key, w.Subject, w.Value, w.Limit))
+	}
+}
+
+func printCredentialsDrift(drift []internal.CredentialsDrift) {
+	if len(drift) == 0 {
+		return
+	}
+	fmt.Println(lipgloss.NewStyle().MarginTop(1).Foreground(themeColor(internal.ColorExpiring)).Render(
+		internal.Icon(internal.IconWarning) + " " + i18n.T("status.drift")))
+	resync := false
+	for _, d := range drift {
+		switch d.Kind {
+		case internal.DriftNewer:
+			fmt.Println("   " + i18n.T("status.drift.newer", d.Profile, internal.FormatTime(d.FileExpiration), internal.FormatTime(d.StoreExpiration)))
+		case internal.DriftOrphaned:
+			fmt.Println("   " + i18n.T("status.drift.orphaned", d.Profile))
+		default:
+			fmt.Println("   " + i18n.T("status.drift.stale", d.Profile))
+			resync = true
+		}
+	}
+	if resync {
+		fmt.Println("   " + internal.Icon(internal.IconTip) + " " + i18n.T("status.drift_hint"))
+	}
+}
+
+func printSessionRow(d sessionDisplay) {
+	var profileStyle lipgloss.Style
+	switch d.status {
 	case statusActive:
 		profileStyle = profileActiveStyle
 	case statusExpiring:
@@ -175,88 +334,151 @@ func printSessionGroup(displays []sessionDisplay, status sessionStatus, title st
 		profileStyle = profileExpiredStyle
 	}
 
-	fmt.Printf("\n%s\n", titleStyle.Render(title))
-	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("#4A90E2")).Render(strings.Repeat("─", 100)))
+	s := d.session
+	accountID := internal.SessionAccountID(s)
+	roleName := internal.RoleName(s.RoleArn)
 
-	for _, d := range filtered {
-		s := d.session
-		accountID := extractAccountID(s.RoleArn)
-		roleName := extractRoleName(s.RoleArn)
-
-		// Format profile name with current indicator
-		profileDisplay := profileStyle.Render(s.Profile)
-		if d.isCurrent {
-			profileDisplay += " " + currentStyle.Render("← current")
-		}
+	// Format profile name with current indicator
+	profileDisplay := profileStyle.Render(s.Profile)
+	if d.isCurrent {
+		profileDisplay += " " + currentStyle.Render(internal.Icon(internal.IconCurrent) + " " + i18n.T("status.current"))
+	}
 
-		// Format role display
-		roleDisplay := roleStyle.Render(s.RoleArn)
-		if roleName != "" && accountID != "" {
-			roleDisplay = roleStyle.Render(fmt.Sprintf("%s (%s)", roleName, accountID))
-		} else if s.RoleArn == "MFA-Session" || s.RoleArn == "" {
-			roleDisplay = sourceStyle.Render("MFA Session")
+	// Format role display
+	roleDisplay := roleStyle.Render(s.RoleArn)
+	if roleName != "" && accountID != "" {
+		roleDisplay = roleStyle.Render(fmt.Sprintf("%s (%s)", roleName, accountID))
+	} else if s.RoleArn == "MFA-Session" || s.RoleArn == "" {
+		roleDisplay = sourceStyle.Render(i18n.T("status.mfa_session"))
+		if accountID != "" {
+			roleDisplay = sourceStyle.Render(fmt.Sprintf("%s (%s)", i18n.T("status.mfa_session"), accountID))
 		}
+	}
+	if badge := accessBadge(s.Access); badge != "" {
+		roleDisplay += " " + badge
+	}
 
-		// Format remaining time
-		remainingStr := timeStyle.Render(formatDuration(d.remaining))
-		if d.status == statusExpired {
-			remainingStr = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")).Render("Expired")
+	// Format remaining time (or the expiry timestamp when display.expiry_format is absolute)
+	expiryFormat := internal.CurrentConfig().Display.ExpiryFormat
+	remainingStr := timeStyle.Render(formatDuration(d.remaining))
+	if expiryFormat == internal.ExpiryFormatAbsolute {
+		remainingStr = timeStyle.Render(internal.FormatTime(s.Expiration))
+	}
+	if d.status == statusExpired {
+		expiredText := i18n.T("status.expired")
+		if expiryFormat == internal.ExpiryFormatAbsolute {
+			expiredText = internal.FormatTime(s.Expiration)
 		}
+		remainingStr = expiredTagStyle.Render(expiredText)
+	}
 
-		// Use lipgloss to format exact widths while respecting ANSI sequences
-		profileCol := lipgloss.NewStyle().Width(25).Render(profileDisplay)
-		roleCol := lipgloss.NewStyle().Width(50).Render(roleDisplay)
-		timeCol := lipgloss.NewStyle().Width(20).Align(lipgloss.Right).Render(remainingStr)
+	// Use lipgloss to format exact widths while respecting ANSI sequences
+	profileCol := lipgloss.NewStyle().Width(25).Render(profileDisplay)
+	roleCol := lipgloss.NewStyle().Width(50).Render(roleDisplay)
+	timeCol := lipgloss.NewStyle().Width(20).Align(lipgloss.Right).Render(remainingStr)
 
-		// Line 1: Profile, Role, Remaining Time
-		fmt.Printf("%s %s %s %s\n", d.icon, profileCol, roleCol, timeCol)
+	// Line 1: Profile, Role, Remaining Time
+	fmt.Printf("%s %s %s %s\n", d.icon, profileCol, roleCol, timeCol)
 
-		// Line 2: Source Info and Expiration
-		sourceInfo := ""
-		if s.SourceProfile != "" && s.RoleArn != "MFA-Session" {
-			sourceInfo = fmt.Sprintf("Source: %-12s ", s.SourceProfile)
-		}
-		
-		fmt.Printf("   %s%s\n",
+	// Line 2: Source Info and Expiration
+	sourceInfo := ""
+	if s.SourceProfile != "" && s.RoleArn != "MFA-Session" {
+		sourceInfo = i18n.T("label.source", fmt.Sprintf("%-12s", s.SourceProfile)) + " "
+	}
+
+	// The timestamp is already in the first line unless both formats are requested
+	expiresInfo := ""
+	if expiryFormat == internal.ExpiryFormatBoth {
+		expiresInfo = i18n.T("label.expires", internal.FormatTime(s.Expiration))
+	}
+	if !s.SelfDestruct.IsZero() {
+		expiresInfo = strings.TrimSpace(expiresInfo + "  " + i18n.T("label.self_destruct", internal.FormatTime(s.SelfDestruct)))
+	}
+	reauthInfo := ""
+	if d.reauthRoot != "" {
+		reauthInfo = " " + expiredTagStyle.Render(internal.Icon(internal.IconWarning)+" "+i18n.T("status.needs_reauth", d.reauthRoot))
+	}
+	if sourceInfo != "" || expiresInfo != "" || reauthInfo != "" {
+		fmt.Printf("   %s%s%s\n",
 			sourceStyle.Render(sourceInfo),
-			sourceStyle.Render("Expires: "+internal.FormatBKK(s.Expiration)),
+			sourceStyle.Render(expiresInfo),
+			reauthInfo,
 		)
 	}
 }
 
-func extractAccountID(roleArn string) string {
-	re := regexp.MustCompile(`arn:aws:iam::(\d+):role/`)
-	matches := re.FindStringSubmatch(roleArn)
-	if len(matches) > 1 {
-		return matches[1]
+// accessBadge renders the access level from `login --check-access`, with admin in the
+// production color so it stands out.
+func accessBadge(access string) string {
+	color := internal.ColorMuted
+	switch access {
+	case "":
+		return ""
+	case internal.AccessAdmin:
+		color = internal.ColorProduction
+	case internal.AccessReadOnly:
+		color = internal.ColorActive
 	}
-	return ""
+	return lipgloss.NewStyle().Foreground(themeColor(color)).Render("[" + access + "]")
 }
 
-func extractRoleName(roleArn string) string {
-	re := regexp.MustCompile(`arn:aws:iam::\d+:role/(.+)`)
-	matches := re.FindStringSubmatch(roleArn)
-	if len(matches) > 1 {
-		return matches[1]
+// printRemoteStatus lists the active sessions recorded in the remote state by every
+// machine, with any refresh in progress.
+func printRemoteStatus(ctx context.Context, secret string) {
+	if !internal.RemoteStateEnabled() {
+		fmt.Println(internal.Icon(internal.IconError) + " " + i18n.T("status.remote_disabled"))
+		fmt.Println("\n" + internal.Icon(internal.IconTip) + " cloudctl config set remote.url s3://<bucket>/cloudctl/state.json")
+		return
+	}
+	res, err := ui.Spin(ctx, "Reading remote state...", func(ctx context.Context) (any, error) {
+		return internal.LoadRemoteState(ctx, secret)
+	})
+	if err != nil {
+		fmt.Printf("%s %v\n", internal.Icon(internal.IconError), err)
+		os.Exit(1)
+	}
+	state := res.(*internal.RemoteState)
+
+	loadStatusStyles()
+	fmt.Println(titleStyle.Render(i18n.T("status.title.remote", internal.CurrentConfig().Remote.URL)))
+	sessions := state.SortedSessions()
+	if len(sessions) == 0 {
+		fmt.Println(internal.Icon(internal.IconEmpty) + " " + i18n.T("status.remote_empty"))
+		return
+	}
+
+	thisHost := internal.RemoteHost()
+	for _, s := range sessions {
+		host := s.Host
+		if host == thisHost {
+			host += " (" + i18n.T("status.remote_this_host") + ")"
+		}
+		fmt.Printf("%s %-24s %-28s %s  %s\n", internal.Icon(internal.IconActive), s.Profile, host,
+			timeStyle.Render(i18n.T("status.remote_minted", internal.FormatTime(s.MintedAt))), formatDuration(time.Until(s.Expiration)))
+	}
+	renewing := make([]string, 0, len(state.Renewals))
+	for profile := range state.Renewals {
+		renewing = append(renewing, profile)
+	}
+	sort.Strings(renewing)
+	for _, profile := range renewing {
+		r := state.Renewals[profile]
+		fmt.Printf("%s %-24s %s\n", internal.Icon(internal.IconExpiring), profile, i18n.T("status.remote_renewing", r.Host, internal.FormatTime(r.Until)))
 	}
-	return ""
 }
 
 func formatDuration(d time.Duration) string {
 	if d <= 0 {
 		return "0s"
 	}
-
-	hours := int(d.Hours())
-	minutes := int(d.Minutes()) % 60
-
-	if hours > 0 {
-		return fmt.Sprintf("%dh%dm remaining", hours, minutes)
-	}
-	return fmt.Sprintf("%dm remaining", minutes)
+	return i18n.T("time.remaining", internal.FormatDurationShort(d))
 }
 
 func init() {
+	statusCmd.Flags().StringVar(&statusGroupBy, "group-by", "status", "Group sessions by 'status' or 'account' (with per-account session counts)")
+	statusCmd.Flags().BoolVar(&statusRemote, "remote", false, "Show the sessions of every machine from the remote state (remote.url)")
+	statusCmd.Flags().BoolVar(&statusICal, "ical", false, "Print an iCalendar file with the expiries of MFA and long-lived sessions")
+	statusCmd.Flags().DurationVar(&statusRemind, "remind", 30*time.Minute, "How long before an expiry the --ical reminders go off")
 	statusCmd.Flags().StringVar(&statusSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for session decryption (or set CLOUDCTL_SECRET env var)")
 	rootCmd.AddCommand(statusCmd)
 }
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	suggestSince string
+	suggestApply bool
+	suggestJSON  bool
+)
+
+// suggestionEntry is one suggestion in `suggest --json`.
+type suggestionEntry struct {
+	Kind     string    `json:"kind"`
+	Name     string    `json:"name"`
+	Role     string    `json:"role"`
+	Region   string    `json:"region,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	Logins   int       `json:"logins"`
+	LastUsed time.Time `json:"last_used"`
+	Command  string    `json:"command"`
+}
+
+var suggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "Suggest role aliases from the roles you log in to most",
+	Long: `Look at the role logins recorded in the local audit log (never sent anywhere) and
+suggest role aliases: one for each role you logged in to at least 3 times by ARN, named
+after the profile you store it as most often, and a default region for aliases without
+one when most logins used the same region. Each suggestion is the 'role add' command
+that creates it; --apply saves them all.`,
+	Example: `  cloudctl suggest
+  cloudctl suggest --since 30d
+  cloudctl suggest --apply`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		lookback, err := parseLookback(suggestSince)
+		if err != nil {
+			printer.Error("%v", err)
+			return
+		}
+		events, err := internal.ReadAuditLog()
+		if err != nil {
+			printer.Error("%v", err)
+			return
+		}
+		aliases, err := internal.ListRoleAliases()
+		if err != nil {
+			printer.Error("Failed to load role aliases: %v", err)
+			return
+		}
+		suggestions := internal.SuggestAliases(events, time.Now().Add(-lookback), aliases)
+
+		if useJSON(suggestJSON) && !suggestApply {
+			out := make([]suggestionEntry, 0, len(suggestions))
+			for _, s := range suggestions {
+				out = append(out, suggestionEntry{
+					Kind: s.Kind, Name: s.Name, Role: s.Alias.ARN, Region: s.Alias.Region, Source: s.Source,
+					Logins: s.Logins, LastUsed: s.LastUsed, Command: roleAddCommand(s.Name, s.Alias),
+				})
+			}
+			printer.Result(out)
+			return
+		}
+
+		if len(suggestions) == 0 {
+			printer.Info("No suggestions from your role logins in the last %s.", suggestSince)
+			return
+		}
+
+		for _, s := range suggestions {
+			usage := fmt.Sprintf("%d logins, last %s ago", s.Logins, internal.FormatDurationShort(time.Since(s.LastUsed)))
+			if s.Kind == internal.SuggestRegion {
+				printer.Print("  ~ %-20s default region %s (%s)", s.Name, s.Alias.Region, usage)
+			} else {
+				printer.Print("  + %-20s %s (%s)", s.Name, s.Alias.ARN, usage)
+			}
+			if !suggestApply {
+				printer.Detail("%s", roleAddCommand(s.Name, s.Alias))
+			}
+		}
+
+		if !suggestApply {
+			printer.Tip("Run these, or save them all with: cloudctl suggest --apply")
+			return
+		}
+		for _, s := range suggestions {
+			if err := internal.SaveRoleAlias(s.Name, s.Alias); err != nil {
+				printer.Error("Failed to save role alias '%s': %v", s.Name, err)
+				return
+			}
+		}
+		printer.Success("Saved %d role aliases", len(suggestions))
+		first := suggestions[0]
+		if first.Source != "" {
+			printer.Tip("Log in with an alias: cloudctl login --source %s --profile %s --role %s", first.Source, first.Name, first.Name)
+		}
+	},
+}
+
+// roleAddCommand is the `role add` command that saves alias as name, keeping the
+// metadata suggest doesn't change.
+func roleAddCommand(name string, alias internal.RoleAlias) string {
+	c := fmt.Sprintf("cloudctl role add %s %s", name, alias.ARN)
+	if alias.Region != "" {
+		c += " --region " + alias.Region
+	}
+	if alias.Duration != 0 {
+		c += fmt.Sprintf(" --duration %d", alias.Duration)
+	}
+	if alias.MFARequired {
+		c += " --mfa-required"
+	}
+	if alias.Group != "" {
+		c += fmt.Sprintf(" --group %q", alias.Group)
+	}
+	if alias.Description != "" {
+		c += fmt.Sprintf(" --description %q", alias.Description)
+	}
+	if alias.Color != "" {
+		c += fmt.Sprintf(" --color %q", alias.Color)
+	}
+	return c
+}
+
+func init() {
+	suggestCmd.Flags().StringVar(&suggestSince, "since", "90d", "How much login history to consider (e.g. 30d)")
+	suggestCmd.Flags().BoolVar(&suggestApply, "apply", false, "Save all suggested role aliases")
+	suggestCmd.Flags().BoolVar(&suggestJSON, "json", false, "Print the suggestions as JSON")
+	rootCmd.AddCommand(suggestCmd)
+}
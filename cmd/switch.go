@@ -7,11 +7,14 @@ import (
 	"time"
 
 	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
 	"github.com/chukul/cloudctl/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var switchSecret string
+var switchClipboard bool
+var switchYes bool
 
 var switchCmd = &cobra.Command{
 	Use:   "switch [profile]",
@@ -26,15 +29,29 @@ var switchCmd = &cobra.Command{
   
   # Or set CLOUDCTL_SECRET environment variable
   export CLOUDCTL_SECRET="your-secret"
-  eval $(cloudctl switch prod-admin)`,
+  eval $(cloudctl switch prod-admin)
+
+  # Copy the export commands to paste into another terminal (cleared after 45s)
+  cloudctl switch prod-admin --clipboard
+
+  # Point the AWS CLI and SDKs at LocalStack (an "endpoints" entry in the config)
+  eval $(cloudctl switch localstack)`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var profile string
 
+		// Endpoint profiles don't touch the store, so they need no secret
+		if len(args) == 1 {
+			if e, ok := internal.CurrentConfig().Endpoint(args[0]); ok {
+				emitSwitchExports(endpointExports(args[0], e), args[0])
+				return
+			}
+		}
+
 		// Get secret first to enable interactive listing with full details
 		secret, err := internal.GetSecret(switchSecret)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Encryption secret required\n")
-			fmt.Fprintf(os.Stderr, "\n💡 Set the secret or use macOS Keychain:\n")
+			fmt.Fprintln(os.Stderr, "❌ "+i18n.T("secret.required"))
+			fmt.Fprintln(os.Stderr, "\n💡 "+i18n.T("secret.set_hint_keychain"))
 			fmt.Fprintf(os.Stderr, "   export CLOUDCTL_SECRET=\"your-32-char-encryption-key\"\n")
 			return
 		}
@@ -48,63 +65,128 @@ var switchCmd = &cobra.Command{
 			}
 
 			now := time.Now()
-			var options []string
-			optionToProfile := make(map[string]string)
-
+			var active []*internal.AWSSession
 			for _, s := range allSessions {
 				// Only show active sessions
-				if s.Expiration.After(now) {
-					sessionType := "Role"
-					if s.RoleArn == "MFA-Session" {
-						sessionType = "MFA"
-					}
-					displayName := fmt.Sprintf("%-15s (%s)", s.Profile, sessionType)
-					options = append(options, displayName)
-					optionToProfile[displayName] = s.Profile
+				if s.Expiration.After(now) && !s.SelfDestructed(now) {
+					active = append(active, s)
 				}
 			}
+			var endpoints []ui.Row
+			for name, e := range internal.CurrentConfig().Endpoints {
+				endpoints = append(endpoints, ui.Row{Key: name, Columns: []string{name, "endpoint", e.URL}})
+			}
+			sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Key < endpoints[j].Key })
 
-			if len(options) == 0 {
-				fmt.Fprintln(os.Stderr, "📭 No active sessions found. Create one first.")
+			if len(active) == 0 && len(endpoints) == 0 {
+				fmt.Fprintln(os.Stderr, "📭 "+i18n.T("sessions.none_active"))
 				return
 			}
-			sort.Strings(options)
 
-			selected, err := ui.SelectProfile("Select Active Profile to Switch", options)
+			selected, err := selectSession("Select Active Profile to Switch", active, endpoints...)
 			if err != nil {
 				return
 			}
-			profile = optionToProfile[selected]
+			profile = selected
+			if e, ok := internal.CurrentConfig().Endpoint(profile); ok {
+				emitSwitchExports(endpointExports(profile, e), profile)
+				return
+			}
 		} else {
 			profile = args[0]
 		}
 
 		s, err := internal.LoadCredentials(profile, secret)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Profile '%s' not found\n", profile)
+			fmt.Fprintln(os.Stderr, "❌ "+i18n.T("profile.not_found", profile))
 
 			// List available profiles
 			if profiles, _ := internal.ListProfiles(); len(profiles) > 0 {
-				fmt.Fprintf(os.Stderr, "\n💡 Available profiles:\n")
+				fmt.Fprintln(os.Stderr, "\n💡 "+i18n.T("profile.available"))
 				for _, p := range profiles {
 					fmt.Fprintf(os.Stderr, "   • %s\n", p)
 				}
 			} else {
-				fmt.Fprintf(os.Stderr, "\n💡 No sessions found. Create one with:\n")
+				fmt.Fprintln(os.Stderr, "\n💡 "+i18n.T("profile.none_hint"))
 				fmt.Fprintf(os.Stderr, "   cloudctl login --source <profile> --profile <name> --role <role-arn>\n")
 			}
 			return
 		}
 
+		if refuseSelfDestructed(s) || !confirmProduction(s, switchYes) {
+			os.Exit(1)
+		}
+
 		// Output shell-compatible export commands
-		fmt.Printf("export AWS_ACCESS_KEY_ID=%s\n", s.AccessKey)
-		fmt.Printf("export AWS_SECRET_ACCESS_KEY=%s\n", s.SecretKey)
-		fmt.Printf("export AWS_SESSION_TOKEN=%s\n", s.SessionToken)
-		fmt.Printf("export CLOUDCTL_PROFILE=%s\n", profile)
+		exports := fmt.Sprintf("export AWS_ACCESS_KEY_ID=%s\n", s.AccessKey) +
+			fmt.Sprintf("export AWS_SECRET_ACCESS_KEY=%s\n", s.SecretKey) +
+			fmt.Sprintf("export AWS_SESSION_TOKEN=%s\n", s.SessionToken) +
+			fmt.Sprintf("export CLOUDCTL_PROFILE=%s\n", profile)
+		if region := sessionRegion(s); region != "" {
+			exports += fmt.Sprintf("export AWS_REGION=%s\n", region) +
+				fmt.Sprintf("export AWS_DEFAULT_REGION=%s\n", region)
+		}
+		if s.Access != "" {
+			exports += fmt.Sprintf("export CLOUDCTL_ACCESS=%s\n", s.Access)
+		} else if os.Getenv("CLOUDCTL_ACCESS") != "" {
+			exports += "export CLOUDCTL_ACCESS=\n"
+		}
+		// Leaving an endpoint profile: send requests to AWS again
+		if _, ok := internal.CurrentConfig().Endpoint(os.Getenv("CLOUDCTL_PROFILE")); ok && os.Getenv("AWS_ENDPOINT_URL") != "" {
+			exports += "export AWS_ENDPOINT_URL=\n"
+		}
+
+		if switchClipboard {
+			internal.RecordExport(profile, "clipboard")
+		} else {
+			internal.RecordExport(profile, "shell")
+		}
+		emitSwitchExports(exports, profile)
 	},
 }
 
+// endpointExports points the AWS SDKs and CLI at a custom endpoint with static test keys.
+// Exporting empty values rather than using unset keeps the output valid for fish.
+func endpointExports(name string, e internal.EndpointProfile) string {
+	exports := fmt.Sprintf("export AWS_ACCESS_KEY_ID=%s\n", e.AccessKey) +
+		fmt.Sprintf("export AWS_SECRET_ACCESS_KEY=%s\n", e.SecretKey)
+	if os.Getenv("AWS_SESSION_TOKEN") != "" {
+		exports += "export AWS_SESSION_TOKEN=\n"
+	}
+	if os.Getenv("CLOUDCTL_ACCESS") != "" {
+		exports += "export CLOUDCTL_ACCESS=\n"
+	}
+	return exports +
+		fmt.Sprintf("export AWS_ENDPOINT_URL=%s\n", e.URL) +
+		fmt.Sprintf("export CLOUDCTL_PROFILE=%s\n", name) +
+		fmt.Sprintf("export AWS_REGION=%s\n", e.Region) +
+		fmt.Sprintf("export AWS_DEFAULT_REGION=%s\n", e.Region)
+}
+
+// emitSwitchExports prints the export commands for eval, or copies them with --clipboard.
+func emitSwitchExports(exports, profile string) {
+	if switchClipboard {
+		if err := copyToClipboard(exports, fmt.Sprintf("export commands for '%s'", profile)); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Print(exports)
+}
+
+// sessionRegion is the region exported for a session: the one stored at login, else the
+// account's configured region.
+func sessionRegion(s *internal.AWSSession) string {
+	if s.Region != "" {
+		return s.Region
+	}
+	return internal.CurrentConfig().AccountRegion(internal.SessionAccountID(s))
+}
+
 func init() {
+	switchCmd.Flags().BoolVarP(&switchYes, "yes", "y", false, "Skip the confirmation for production sessions")
+	switchCmd.Flags().BoolVar(&switchClipboard, "clipboard", false, "Copy the export commands to the clipboard instead of printing them")
 	switchCmd.Flags().StringVar(&switchSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
 	rootCmd.AddCommand(switchCmd)
 }
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
+	"github.com/chukul/cloudctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncStoreSecret string
+	syncStoreDryRun bool
+)
+
+var syncStoreCmd = &cobra.Command{
+	Use:   "sync-store",
+	Short: "Sync sessions, role aliases and MFA devices with your other machines",
+	Long: `Sync the encrypted store, role aliases and MFA devices both ways with the copy at
+store_sync.url (an S3 object or SSM parameter), reached with store_sync.profile from
+~/.aws/config. Run it on each machine, or let the daemon run it at every check, and your
+desktop and laptop see the same sessions.
+
+An item changed on one machine since the last sync is copied to the other; when both
+changed it, the change made last wins and is reported as a conflict. Sessions stay
+encrypted with the cloudctl secret, so every machine needs the same secret, and the
+store must not use the age, KMS or TPM provider.`,
+	Example: `  cloudctl config set store_sync.url s3://my-bucket/cloudctl/store.json
+  cloudctl config set store_sync.profile bootstrap
+  cloudctl sync-store --dry-run
+  cloudctl sync-store`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !internal.StoreSyncEnabled() {
+			printer.Error("store_sync.url is not set.")
+			printer.Tip("Set it to an S3 object or SSM parameter: cloudctl config set store_sync.url s3://bucket/key")
+			os.Exit(1)
+		}
+		secret, err := internal.GetSecret(syncStoreSecret)
+		if err != nil {
+			printer.Error("%s", i18n.T("secret.required"))
+			os.Exit(1)
+		}
+
+		res, err := ui.Spin(cmd.Context(), "Syncing store...", func(ctx context.Context) (any, error) {
+			return internal.SyncStore(ctx, secret, syncStoreDryRun)
+		})
+		if err != nil {
+			printer.Error("Store sync failed: %v", err)
+			os.Exit(1)
+		}
+		result := res.(*internal.StoreSyncResult)
+
+		for _, c := range result.Changes {
+			direction, verb := "⬆️ ", "pushed"
+			if c.Pulled {
+				direction, verb = "⬇️ ", "pulled"
+			}
+			if c.Deleted {
+				verb = "deletion " + verb
+			}
+			note := ""
+			if c.Conflict {
+				note = " (changed on both sides; the later change won)"
+			}
+			printer.Print("%s %-8s %-30s %s%s", direction, c.Kind, c.Name, verb, note)
+		}
+		for _, name := range result.Rejected {
+			printer.Warn("Session '%s' in the sync copy failed its integrity check and was not pulled.", name)
+		}
+
+		switch {
+		case len(result.Changes) == 0:
+			printer.Success("Already in sync with %s", internal.CurrentConfig().StoreSync.URL)
+		case syncStoreDryRun:
+			printer.Info("\n%d change(s) would be synced; nothing was written.", len(result.Changes))
+		default:
+			printer.Success("Synced %d change(s) with %s", len(result.Changes), internal.CurrentConfig().StoreSync.URL)
+		}
+	},
+}
+
+func init() {
+	syncStoreCmd.Flags().StringVar(&syncStoreSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for encryption (or set CLOUDCTL_SECRET env var)")
+	syncStoreCmd.Flags().BoolVar(&syncStoreDryRun, "dry-run", false, "Show what would be synced without writing anything")
+	rootCmd.AddCommand(syncStoreCmd)
+}
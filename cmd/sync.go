@@ -4,12 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
-	"strings"
 	"time"
 
 	"github.com/chukul/cloudctl/internal"
-	"github.com/chukul/cloudctl/internal/ui"
+	"github.com/chukul/cloudctl/internal/i18n"
 	"github.com/spf13/cobra"
 )
 
@@ -21,12 +19,18 @@ var syncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Sync stored sessions to ~/.aws/credentials",
 	Long: `Export cloudctl managed sessions to the standard AWS credentials file (~/.aws/credentials).
-This allows external tools (Terraform, VS Code, etc.) to use your assumed roles directly.`,
+This allows external tools (Terraform, VS Code, etc.) to use your assumed roles directly.
+
+With sync.mode "files" in the config, each profile is written to its own file instead
+(sync.file_template, default ~/.aws/credentials.d/{profile}) and read through a
+credential_process profile that sync adds to ~/.aws/config. A refresh then rewrites only
+the refreshed profile's small file, not the whole credentials file. Profiles with a
+hand-written ~/.aws/config section stay in ~/.aws/credentials.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get secret from flag, env, or keychain
 		secret, err := internal.GetSecret(syncSecret)
 		if err != nil {
-			fmt.Println("❌ Encryption secret required")
+			fmt.Println("❌ " + i18n.T("secret.required"))
 			return
 		}
 
@@ -36,8 +40,7 @@ This allows external tools (Terraform, VS Code, etc.) to use your assumed roles
 				fmt.Printf("❌ Sync failed: %v\n", err)
 				return
 			}
-			credsPath := filepath.Join(os.Getenv("HOME"), ".aws", "credentials")
-			fmt.Printf("✅ Synced %d profiles to %s\n", count, credsPath)
+			fmt.Printf("✅ Synced %d profiles to %s\n", count, syncTarget())
 			return
 		}
 
@@ -62,7 +65,7 @@ This allows external tools (Terraform, VS Code, etc.) to use your assumed roles
 		now := time.Now()
 		var activeSessions []*internal.AWSSession
 		for _, s := range allSessions {
-			if s.Expiration.After(now) {
+			if s.Expiration.After(now) && !s.SelfDestructed(now) {
 				activeSessions = append(activeSessions, s)
 			}
 		}
@@ -87,27 +90,13 @@ This allows external tools (Terraform, VS Code, etc.) to use your assumed roles
 			}
 		} else {
 			// Interactive Selection
-			var options []string
-			optionToProfile := make(map[string]string)
-			for _, s := range activeSessions {
-				sessionType := "Role"
-				if s.RoleArn == "MFA-Session" {
-					sessionType = "MFA"
-				}
-				displayName := fmt.Sprintf("%-15s (%s)", s.Profile, sessionType)
-				options = append(options, displayName)
-				optionToProfile[displayName] = s.Profile
-			}
-			sort.Strings(options)
-
-			selected, err := ui.SelectProfile("Select Active Profile to Sync (MFA or Role)", options)
+			selected, err := selectSession("Select Active Profile to Sync (MFA or Role)", activeSessions)
 			if err != nil {
 				return
 			}
 
-			selectedProfile := optionToProfile[selected]
 			for _, s := range activeSessions {
-				if s.Profile == selectedProfile {
+				if s.Profile == selected {
 					sessionsToSync = append(sessionsToSync, s)
 					break
 				}
@@ -119,94 +108,23 @@ This allows external tools (Terraform, VS Code, etc.) to use your assumed roles
 			return
 		}
 
-		credsPath := filepath.Join(os.Getenv("HOME"), ".aws", "credentials")
-		// Read existing credentials file
-		content, err := os.ReadFile(credsPath)
-		var existingLines []string
-		if err == nil {
-			existingLines = strings.Split(string(content), "\n")
-		}
-
-		// Remove cloudctl managed sections
-		newLines := []string{}
-		skipSection := false
-		for i := 0; i < len(existingLines); i++ {
-			line := existingLines[i]
-			trimmed := strings.TrimSpace(line)
-
-			if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
-				pName := strings.Trim(trimmed, "[]")
-				skipSection = false
-				for _, s := range sessionsToSync {
-					if s.Profile == pName {
-						skipSection = true
-						break
-					}
-				}
-			}
-
-			if strings.HasPrefix(trimmed, "; Managed by cloudctl") {
-				foundH := ""
-				for j := i + 1; j < len(existingLines); j++ {
-					tj := strings.TrimSpace(existingLines[j])
-					if tj == "" || strings.HasPrefix(tj, ";") {
-						continue
-					}
-					if strings.HasPrefix(tj, "[") && strings.HasSuffix(tj, "]") {
-						foundH = strings.Trim(tj, "[]")
-					}
-					break
-				}
-				if foundH != "" {
-					isReplacing := false
-					for _, s := range sessionsToSync {
-						if s.Profile == foundH {
-							isReplacing = true
-							break
-						}
-					}
-					if isReplacing {
-						continue
-					}
-				}
-			}
-
-			if !skipSection {
-				newLines = append(newLines, line)
-			}
-		}
-
-		for len(newLines) > 0 && strings.TrimSpace(newLines[len(newLines)-1]) == "" {
-			newLines = newLines[:len(newLines)-1]
-		}
-		if len(newLines) > 0 && newLines[len(newLines)-1] != "" {
-			newLines = append(newLines, "")
-		}
-
-		syncedCount := 0
-		for _, s := range sessionsToSync {
-			sessionType := "Role Session"
-			if s.RoleArn == "MFA-Session" {
-				sessionType = "MFA Session"
-			}
-			newLines = append(newLines, fmt.Sprintf("; Managed by cloudctl (%s) - Expires: %s", sessionType, internal.FormatBKK(s.Expiration)))
-			newLines = append(newLines, fmt.Sprintf("[%s]", s.Profile))
-			newLines = append(newLines, fmt.Sprintf("aws_access_key_id = %s", s.AccessKey))
-			newLines = append(newLines, fmt.Sprintf("aws_secret_access_key = %s", s.SecretKey))
-			newLines = append(newLines, fmt.Sprintf("aws_session_token = %s", s.SessionToken))
-			newLines = append(newLines, "")
-			syncedCount++
-		}
-
-		if err := os.WriteFile(credsPath, []byte(strings.Join(newLines, "\n")), 0600); err != nil {
-			fmt.Printf("❌ Failed to write credentials file: %v\n", err)
+		syncedCount, err := internal.SyncToAWS(sessionsToSync)
+		if err != nil {
+			fmt.Printf("❌ Sync failed: %v\n", err)
 			return
 		}
-
-		fmt.Printf("✅ Synced %d profiles to %s\n", syncedCount, credsPath)
+		fmt.Printf("✅ Synced %d profiles to %s\n", syncedCount, syncTarget())
 	},
 }
 
+// syncTarget describes where sync writes sessions.
+func syncTarget() string {
+	if c := internal.CurrentConfig().Sync; c.FilesMode() {
+		return filepath.Dir(c.ProfileFile("{profile}")) + " (credential_process profiles in " + internal.AWSConfigPath() + ")"
+	}
+	return internal.AWSCredentialsPath()
+}
+
 func init() {
 	syncCmd.Flags().StringVar(&syncSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
 	syncCmd.Flags().BoolVar(&syncAll, "all", false, "Sync all active sessions")
@@ -0,0 +1,6 @@
+//go:build !windows
+
+package cmd
+
+// enableVirtualTerminal is a no-op: Unix terminals interpret ANSI escapes natively.
+func enableVirtualTerminal() {}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	timelineSince   string
+	timelineAccount string
+	timelineJSON    bool
+)
+
+// timelineEntry is one entry in `timeline --json`.
+type timelineEntry struct {
+	Time    time.Time `json:"time"`
+	Account string    `json:"account,omitempty"`
+	Kind    string    `json:"kind"`
+	Profile string    `json:"profile,omitempty"`
+	Role    string    `json:"role,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+	Failed  bool      `json:"failed,omitempty"`
+}
+
+var timelineCmd = &cobra.Command{
+	Use:   "timeline",
+	Short: "Show what you did with your sessions, per account, from the local audit log",
+	Long: `Show the logins, MFA logins, refreshes, console sign-ins and credential exports
+(switch, exec) recorded in the local audit log, oldest first and grouped by account, to
+reconstruct what you did during an incident. Break-glass and root logins and the
+dual-control approvals used are included; individual STS calls are not (see stats).`,
+	Example: `  cloudctl timeline
+  cloudctl timeline --since 24h --account 123456789012
+  cloudctl timeline --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		lookback, err := parseLookback(timelineSince)
+		if err != nil {
+			printer.Error("%v", err)
+			return
+		}
+		since := time.Now().Add(-lookback)
+		events, err := internal.ReadAuditLog()
+		if err != nil {
+			printer.Error("%v", err)
+			return
+		}
+		var groups []internal.TimelineGroup
+		for _, g := range internal.BuildTimeline(events, since) {
+			if timelineAccount == "" || g.AccountID == timelineAccount {
+				groups = append(groups, g)
+			}
+		}
+
+		if useJSON(timelineJSON) {
+			out := []timelineEntry{}
+			for _, g := range groups {
+				for _, e := range g.Entries {
+					out = append(out, timelineEntry{
+						Time: e.Time, Account: g.AccountID, Kind: e.Kind, Profile: e.Profile,
+						Role: e.Role, Detail: e.Detail, Failed: e.Failed,
+					})
+				}
+			}
+			printer.Result(out)
+			return
+		}
+
+		if len(groups) == 0 {
+			printer.Info("%s No activity recorded since %s.", internal.Icon(internal.IconEmpty), internal.FormatTime(since))
+			return
+		}
+		printer.Print("Activity since %s (%s)", internal.FormatTime(since), internal.AuditLogPath())
+		for _, g := range groups {
+			printer.Print("\n%s", timelineGroupTitle(g.AccountID))
+			printer.Print("%s", strings.Repeat("─", 100))
+			for _, e := range g.Entries {
+				line := fmt.Sprintf("%-20s %-12s %-24s %s", internal.FormatTime(e.Time), e.Kind, e.Profile, internal.RoleName(e.Role))
+				if e.Detail != "" {
+					line += " (" + e.Detail + ")"
+				}
+				if e.Failed {
+					line += " FAILED"
+				}
+				printer.Print("%s", strings.TrimRight(line, " "))
+			}
+		}
+	},
+}
+
+// timelineGroupTitle names an account with its env tag from the config.
+func timelineGroupTitle(accountID string) string {
+	if accountID == "" {
+		return "MFA sessions and other profiles"
+	}
+	if env := internal.CurrentConfig().Accounts[accountID].Env; env != "" {
+		return fmt.Sprintf("Account %s (%s)", accountID, env)
+	}
+	return "Account " + accountID
+}
+
+func init() {
+	timelineCmd.Flags().StringVar(&timelineSince, "since", "7d", "How far back to look (e.g. 24h, 7d)")
+	timelineCmd.Flags().StringVar(&timelineAccount, "account", "", "Only show this account ID")
+	timelineCmd.Flags().BoolVar(&timelineJSON, "json", false, "Print the entries as JSON")
+	rootCmd.AddCommand(timelineCmd)
+}
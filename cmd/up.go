@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
+	"github.com/chukul/cloudctl/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// upDaemonInterval is the check interval of a daemon started by `up`, the default of
+// `daemon start`.
+const upDaemonInterval = 5
+
+var (
+	upSecret  string
+	upForce   bool
+	upDryRun  bool
+	upReplace bool
+)
+
+var upCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Start the work session from the config: MFA login, roles, sync, kubeconfigs and daemon",
+	Long: `Start the work session described in the 'up' section of the config file with one
+MFA prompt: get an MFA session from the source profile, assume each configured role from
+it, sync the sessions to ~/.aws/credentials, update the kubeconfig entries of the
+configured EKS clusters and start the auto-refresh daemon.
+
+Sessions that are still valid for more than 30 minutes are kept, so running it again
+only logs in to what expired; --force logs in to everything again.`,
+	Example: `  # ~/.cloudctl/config.json
+  "up": {
+    "source": "default",
+    "mfa_device": "work-phone",
+    "roles": [
+      {"role": "prod-admin"},
+      {"role": "arn:aws:iam::210987654321:role/Developer", "profile": "dev"}
+    ],
+    "kubeconfig": [{"profile": "dev", "cluster": "dev-cluster"}]
+  }
+
+  cloudctl up
+  cloudctl up --dry-run`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := internal.CurrentConfig().Up
+		if c.Source == "" && len(c.Kubeconfig) == 0 {
+			printer.Error("No work session configured.")
+			printer.Tip("Add an 'up' section to %s (see cloudctl up --help)", internal.ConfigPath())
+			os.Exit(1)
+		}
+		logins, err := internal.ResolveUpRoles(c)
+		if err != nil {
+			printer.Error("%v", err)
+			os.Exit(1)
+		}
+
+		if upDryRun {
+			printUpPlan(c, logins)
+			return
+		}
+
+		secret, err := internal.GetSecret(upSecret)
+		if err != nil {
+			printer.Error("%s", i18n.T("secret.required"))
+			printer.Tip("\n%s", i18n.T("secret.set_hint"))
+			printer.Detail("export CLOUDCTL_SECRET=\"your-32-char-encryption-key\"")
+			os.Exit(1)
+		}
+
+		// Every name is checked before the MFA prompt, so a clash doesn't stop the run halfway
+		if c.MFADevice != "" {
+			profile := c.MFASessionProfile()
+			checkSessionName(&profile, "MFA-Session", upSecret, upReplace, false)
+		}
+		for i := range logins {
+			checkSessionName(&logins[i].Profile, logins[i].RoleArn, upSecret, upReplace, false)
+		}
+
+		ctx := cmd.Context()
+		failed := 0
+
+		source := c.Source
+		if c.MFADevice != "" {
+			if err := upMFALogin(ctx, c, secret); err != nil {
+				printer.Error("%v", err)
+				os.Exit(1)
+			}
+			source = c.MFASessionProfile()
+		}
+
+		for _, l := range logins {
+			if err := upRoleLogin(ctx, l, source, secret); err != nil {
+				printer.Error("%s: %v", l.Profile, err)
+				failed++
+			}
+		}
+
+		if c.Sync {
+			count, err := internal.SyncAllToAWS(secret)
+			if err != nil {
+				printer.Error("%v", err)
+				failed++
+			} else {
+				printer.Success("Synced %d sessions to ~/.aws/credentials", count)
+			}
+		}
+
+		for _, k := range c.Kubeconfig {
+			region := internal.DefaultRegion
+			if s, err := internal.LoadCredentials(k.Profile, secret); err == nil && s.Region != "" {
+				region = s.Region
+			}
+			_, err := ui.Spin(ctx, "Updating kubeconfig for "+k.Cluster+"...", func(ctx context.Context) (any, error) {
+				return nil, internal.UpdateKubeconfig(ctx, k, region)
+			})
+			if err != nil {
+				printer.Error("%s: %v", k.Cluster, err)
+				failed++
+				continue
+			}
+			printer.Success("Updated kubeconfig for %s (profile %s)", k.Cluster, k.Profile)
+		}
+
+		if c.Daemon {
+			if daemonRunning() {
+				printer.Info("Daemon is already running")
+			} else if pid, _, err := startDaemonBackground(upDaemonInterval); err != nil {
+				printer.Error("%v", err)
+				failed++
+			} else {
+				printer.Success("Daemon started (PID: %d)", pid)
+			}
+		}
+
+		if failed > 0 {
+			printer.Error("Work session started with %d failed steps", failed)
+			os.Exit(1)
+		}
+		printer.Success("Work session ready")
+	},
+}
+
+// upMFALogin gets the MFA session of the work session, unless the stored one is still
+// fresh.
+func upMFALogin(ctx context.Context, c internal.UpConfig, secret string) error {
+	profile := c.MFASessionProfile()
+	if existing, err := internal.LoadCredentials(profile, secret); err == nil && !upForce && internal.UpSessionFresh(existing, "MFA-Session", time.Now()) {
+		printer.Success("MFA session '%s' is valid until %s", profile, internal.FormatExpiry(existing.UsableUntil()))
+		return nil
+	}
+
+	device := c.MFADevice
+	if arn, found := internal.GetMFADevice(device); found {
+		device = arn
+	} else if !strings.HasPrefix(device, "arn:") {
+		return fmt.Errorf("unknown MFA device '%s' (see cloudctl mfa list)", device)
+	}
+	cfg, err := internal.LoadProfileConfig(ctx, c.Source, c.STSRegion())
+	if err != nil {
+		return errors.New(i18n.T("profile.not_found", c.Source))
+	}
+
+	printer.Info("%s MFA device: %s", internal.Icon(internal.IconMFA), device)
+	opts := internal.MFALoginOptions{
+		Profile:   profile,
+		Source:    c.Source,
+		Region:    c.STSRegion(),
+		MFASerial: device,
+		TokenCode: readMFACode(),
+		Warn:      printer.Warn,
+	}
+	res, err := ui.Spin(ctx, "Authenticating with MFA...", func(ctx context.Context) (any, error) {
+		return internal.LoginMFA(ctx, cfg, opts)
+	})
+	if err != nil {
+		return errors.New(mfaFailedMessage(err))
+	}
+	if err := internal.StoreSession(ctx, res.(*internal.AWSSession), secret, warnStderr); err != nil {
+		return err
+	}
+	printer.Success("%s", i18n.T("mfa.stored", profile))
+	return nil
+}
+
+// upRoleLogin assumes one role of the work session from source, unless its stored
+// session is still fresh.
+func upRoleLogin(ctx context.Context, l internal.UpLogin, source, secret string) error {
+	if existing, err := internal.LoadCredentials(l.Profile, secret); err == nil && !upForce && internal.UpSessionFresh(existing, l.RoleArn, time.Now()) {
+		printer.Success("'%s' is valid until %s", l.Profile, internal.FormatExpiry(existing.UsableUntil()))
+		return nil
+	}
+	src, err := internal.LoadLoginSource(ctx, source, secret, l.Region)
+	if err != nil {
+		return err
+	}
+	opts := internal.RoleLoginOptions{
+		Profile:       l.Profile,
+		RoleArn:       l.RoleArn,
+		Source:        source,
+		SourceSession: src.Session,
+		Region:        l.Region,
+		Duration:      l.Duration,
+		Warn:          printer.Warn,
+	}
+	res, err := ui.Spin(ctx, "Assuming role "+l.RoleArn+"...", func(ctx context.Context) (any, error) {
+		return internal.LoginRole(ctx, src.Config, opts)
+	})
+	if err != nil {
+		return errors.New(i18n.T("login.assume_failed", err))
+	}
+	if err := internal.StoreSession(ctx, res.(*internal.AWSSession), secret, warnStderr); err != nil {
+		return err
+	}
+	printer.Success("%s", i18n.T("login.stored_encrypted", l.Profile))
+	return nil
+}
+
+// printUpPlan shows what `up` would do.
+func printUpPlan(c internal.UpConfig, logins []internal.UpLogin) {
+	if c.MFADevice != "" {
+		printer.Print("  MFA login   %s from %s with %s", c.MFASessionProfile(), c.Source, c.MFADevice)
+	}
+	source := c.Source
+	if c.MFADevice != "" {
+		source = c.MFASessionProfile()
+	}
+	for _, l := range logins {
+		printer.Print("  Role login  %s: %s from %s (%s)", l.Profile, l.RoleArn, source, l.Region)
+	}
+	if c.Sync {
+		printer.Print("  Sync        ~/.aws/credentials")
+	}
+	for _, k := range c.Kubeconfig {
+		printer.Print("  Kubeconfig  %s as %s", k.Cluster, k.Profile)
+	}
+	if c.Daemon {
+		printer.Print("  Daemon      start if not running")
+	}
+}
+
+func init() {
+	upCmd.Flags().StringVar(&upSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret for encryption (or set CLOUDCTL_SECRET env var)")
+	upCmd.Flags().BoolVar(&upForce, "force", false, "Log in again even when stored sessions are still valid")
+	upCmd.Flags().BoolVar(&upReplace, "replace", false, "Replace active sessions of other roles stored under the configured names")
+	upCmd.Flags().BoolVar(&upDryRun, "dry-run", false, "Show the steps without running them")
+	rootCmd.AddCommand(upCmd)
+}
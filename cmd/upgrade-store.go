@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeStoreSecret string
+	upgradeStoreDryRun bool
+)
+
+var upgradeStoreCmd = &cobra.Command{
+	Use:   "upgrade-store",
+	Short: "Upgrade sessions stored by older versions to the current store format",
+	Long: `Rewrite every session stored by an older version of cloudctl in the current store format.
+Fields older versions didn't store are backfilled: Region from the account's region in the
+config file (else ap-southeast-1) and Duration from the session type (1 hour for roles,
+12 hours for MFA sessions). Sessions missing their credentials or expiration, or with an
+invalid region or duration, can't be upgraded and are listed for a new login.
+
+The store is backed up to credentials.json.bak first.`,
+	Example: `  cloudctl upgrade-store --dry-run
+  cloudctl upgrade-store`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		secret, err := internal.GetSecret(upgradeStoreSecret)
+		if err != nil && !internal.CurrentConfig().Encryption.UsesEnvelope() {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		provider, err := internal.StoreProvider(secret)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		var backups []string
+		if !upgradeStoreDryRun {
+			if backups, err = internal.BackupStoreFiles(); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+		}
+		result, err := internal.UpgradeStore(provider, upgradeStoreDryRun)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			if len(backups) > 0 {
+				fmt.Printf("💡 Restore the previous store from: %s\n", strings.Join(backups, ", "))
+			}
+			os.Exit(1)
+		}
+
+		verb := "Upgraded"
+		if upgradeStoreDryRun {
+			verb = "Would upgrade"
+		}
+		if len(result.Upgraded) > 0 {
+			fmt.Printf("✅ %s %d session(s) to store version %d: %s\n", verb, len(result.Upgraded), internal.StoreVersion, strings.Join(result.Upgraded, ", "))
+		}
+		if result.Current > 0 {
+			fmt.Printf("✅ %d session(s) already at store version %d.\n", result.Current, internal.StoreVersion)
+		}
+		if len(result.Stale) > 0 {
+			fmt.Printf("\n⚠️  %d session(s) can't be upgraded and need a new login:\n", len(result.Stale))
+			for _, s := range result.Stale {
+				fmt.Printf("   • %-30s %s\n", s.Profile, strings.Join(s.Problems, ", "))
+			}
+			fmt.Println("\n💡 Log in to them again, or remove them:")
+			for _, s := range result.Stale {
+				fmt.Printf("   cloudctl logout --profile %s\n", s.Profile)
+			}
+		}
+		if len(result.Upgraded) == 0 && result.Current == 0 && len(result.Stale) == 0 {
+			fmt.Println("📭 No stored sessions found.")
+		}
+		if len(backups) > 0 && len(result.Upgraded) > 0 {
+			fmt.Printf("💡 Backups: %s (delete them once everything works)\n", strings.Join(backups, ", "))
+		}
+	},
+}
+
+func init() {
+	upgradeStoreCmd.Flags().StringVar(&upgradeStoreSecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	upgradeStoreCmd.Flags().BoolVar(&upgradeStoreDryRun, "dry-run", false, "Show what would be upgraded without writing")
+	rootCmd.AddCommand(upgradeStoreCmd)
+}
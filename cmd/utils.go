@@ -1,46 +1,250 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"sort"
 	"strings"
-	"syscall"
+	"time"
 
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/internal/i18n"
+	"github.com/chukul/cloudctl/internal/ui"
 	"golang.org/x/term"
 )
 
+// readMFACode reads an MFA code without echoing it. It uses term.ReadPassword so it
+// works the same on Windows consoles, and falls back to a plain line read when stdin
+// is not a terminal (e.g. a code piped in from a script).
 func readMFACode() string {
-	fmt.Print("Enter MFA code: ")
-	var code string
-	var char byte
-	buf := make([]byte, 1)
+	fmt.Fprint(os.Stderr, "Enter MFA code: ")
 
-	oldState, err := term.MakeRaw(int(syscall.Stdin))
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Fatalf("❌ Failed to read input: %v", err)
+		}
+		fmt.Fprintln(os.Stderr)
+		return strings.TrimSpace(line)
+	}
+
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		log.Fatalf("❌ Failed to read input: %v", err)
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// confirmProduction guards production sessions: unless yes is set, the profile name has
+// to be typed before its credentials are used. Prompts go to stderr so it also works
+// inside eval $(cloudctl switch ...). It returns false if the user didn't confirm.
+func confirmProduction(s *internal.AWSSession, yes bool) bool {
+	if yes || !internal.CurrentConfig().IsProduction(s) {
+		return true
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintf(os.Stderr, "❌ '%s' is a production session; pass --yes to use it without a terminal.\n", s.Profile)
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "⚠️  '%s' is a PRODUCTION session (%s).\n", s.Profile, s.RoleArn)
+	fmt.Fprintf(os.Stderr, "   Type the profile name to continue: ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(line) != s.Profile {
+		fmt.Fprintln(os.Stderr, "❌ Confirmation did not match; aborted.")
+		return false
+	}
+	return true
+}
+
+// refuseSelfDestructed reports (on stderr) whether the session's `login --self-destruct`
+// deadline has passed, in which case its credentials must not be handed out.
+func refuseSelfDestructed(s *internal.AWSSession) bool {
+	if !s.SelfDestructed(time.Now()) {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "💣 '%s' self-destructed at %s and can no longer be used.\n", s.Profile, internal.FormatTime(s.SelfDestruct))
+	fmt.Fprintln(os.Stderr, "💡 Log in again if you still need access.")
+	return true
+}
+
+// checkSessionName makes sure storing a session of roleArn as *name doesn't silently
+// clobber an active session of another role or a hand-written AWS CLI profile. Every
+// command that stores a session calls it before minting one. In a terminal it offers a
+// free name instead when rename is set; otherwise replacing an active session needs
+// --replace. It returns false when the command is cancelled.
+func checkSessionName(name *string, roleArn, secretFlag string, replace, rename bool) bool {
+	secret, _ := internal.GetSecret(secretFlag)
+	sessions, _ := internal.ListAllSessions(secret)
+	managed, _ := internal.ReadManagedCredentials()
+	conflict := internal.CheckSessionName(*name, roleArn, sessions, internal.ListAWSProfiles(), managed, time.Now())
+	if !conflict.Found() || (replace && conflict.AWSProfile == nil) {
+		return true
+	}
+
+	if s := conflict.Session; s != nil {
+		printer.Warn("'%s' is an active session of %s, expiring %s.", *name, s.RoleArn, internal.FormatExpiry(s.Expiration))
+	}
+	if p := conflict.AWSProfile; p != nil {
+		printer.Warn("'%s' is also an AWS CLI profile (%s): a session with its name hides it as a login source, and sync would overwrite its credentials.", *name, p.Kind())
+	}
+
+	if !rename || !term.IsTerminal(int(os.Stdin.Fd())) {
+		if conflict.Session != nil && !replace {
+			printer.Error("Not replacing the active session '%s' of another role.", *name)
+			if rename {
+				printer.Tip("Choose another name with --profile %s, or pass --replace.", conflict.Suggestion)
+			} else {
+				printer.Tip("Store it under another name, or pass --replace.")
+			}
+			os.Exit(1)
+		}
+		return true
+	}
+
+	renameTo := fmt.Sprintf("Use '%s' instead", conflict.Suggestion)
+	keep := fmt.Sprintf("Keep '%s'", *name)
+	if conflict.Session != nil {
+		keep = fmt.Sprintf("Replace '%s'", *name)
+	}
+	choice, err := ui.SelectProfile("Session name in use", []string{renameTo, keep})
+	if err != nil || choice == "" {
+		printer.Info("%s", i18n.T("common.cancelled"))
+		return false
+	}
+	if choice == renameTo {
+		*name = conflict.Suggestion
+		printer.Info("Storing the session as '%s'", *name)
+	}
+	return true
+}
+
+// claimRenewal takes the remote renewal lease on a profile before it is re-authenticated.
+// It returns false (after saying why) while another machine is refreshing the profile;
+// an unreachable remote state only warns.
+func claimRenewal(ctx context.Context, profile, secret string) bool {
+	err := internal.AcquireRenewal(ctx, profile, secret)
+	var busy *internal.RenewalInProgressError
+	if errors.As(err, &busy) {
+		fmt.Fprintf(os.Stderr, "⏳ %v\n", busy)
+		fmt.Fprintln(os.Stderr, "💡 Try again once it is done, or check: cloudctl status --remote")
+		return false
+	}
 	if err != nil {
-		log.Fatalf("❌ Failed to set terminal mode: %v", err)
+		fmt.Fprintf(os.Stderr, "⚠️  Remote state unavailable: %v\n", err)
 	}
-	defer term.Restore(int(syscall.Stdin), oldState)
+	return true
+}
 
-	for {
-		_, err := syscall.Read(syscall.Stdin, buf)
+// warnStderr prints the warnings of internal flows, such as an unreachable remote state.
+func warnStderr(format string, args ...any) {
+	printer.Warn(format, args...)
+}
+
+// useJSON reports whether a command prints its JSON result, for its own --json flag
+// or --format json. With --json the printer becomes the JSON printer.
+func useJSON(flag bool) bool {
+	if flag && printer.Format() != internal.FormatJSON {
+		printer, _ = internal.NewPrinter(internal.FormatJSON, quietLevel, os.Stdout, os.Stderr)
+	}
+	return printer.Format() == internal.FormatJSON
+}
+
+// selectMFADevice picks a stored MFA device, or asks for an ARN when none are saved.
+func selectMFADevice() (string, error) {
+	devices, _ := internal.ListMFADevices()
+	if len(devices) == 1 {
+		for _, arn := range devices {
+			return arn, nil
+		}
+	}
+	if len(devices) > 1 {
+		var deviceNames []string
+		for name, arn := range devices {
+			deviceNames = append(deviceNames, fmt.Sprintf("%s (%s)", name, arn))
+		}
+		sort.Strings(deviceNames)
+
+		selected, err := ui.SelectProfile("Select MFA Device", deviceNames)
 		if err != nil {
-			log.Fatalf("❌ Failed to read input: %v", err)
+			return "", err
 		}
-		char = buf[0]
-
-		if char == 13 || char == 10 { // Enter
-			fmt.Print("\r\n")
-			break
-		} else if char == 127 || char == 8 { // Backspace
-			if len(code) > 0 {
-				code = code[:len(code)-1]
-				fmt.Print("\b \b")
-			}
-		} else if char >= 32 && char <= 126 { // Printable characters
-			code += string(char)
-			fmt.Print("*")
+		parts := strings.SplitN(selected, " (", 2)
+		return devices[parts[0]], nil
+	}
+	return ui.GetInput("Enter MFA Device ARN", "arn:aws:iam::123:mfa/user", false)
+}
+
+// readPassphrase reads a passphrase without echo. CLOUDCTL_EXPORT_PASSPHRASE is used
+// instead when set, for scripted exports and imports.
+func readPassphrase(prompt string, confirm bool) (string, error) {
+	if p := os.Getenv("CLOUDCTL_EXPORT_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	passphrase := string(b)
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+
+	if confirm {
+		fmt.Fprintf(os.Stderr, "Confirm %s: ", strings.ToLower(prompt[:1])+prompt[1:])
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if string(b) != passphrase {
+			return "", fmt.Errorf("passphrases do not match")
 		}
 	}
+	return passphrase, nil
+}
 
-	return strings.TrimSpace(code)
+// ambientSourceOption returns the source picker entry for credentials from the current
+// environment (AWS_PROFILE, environment keys, web identity or container credentials),
+// and the source it stands for; both are "" when there are none.
+func ambientSourceOption() (option, source string) {
+	source, label := internal.AmbientSourceProfile()
+	if source == "" {
+		return "", ""
+	}
+	return fmt.Sprintf("%-15s (current environment: %s)", source, label), source
+}
+
+// defaultAmbientSource picks the current environment's credentials as the source when
+// --source is omitted and there is no terminal to choose in, e.g. in CI.
+func defaultAmbientSource() string {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return ""
+	}
+	source, label := internal.AmbientSourceProfile()
+	if source != "" {
+		fmt.Fprintf(os.Stderr, "🌐 Using credentials from the current environment (%s)\n", label)
+	}
+	return source
+}
+
+// selectSession lets the user pick one of sessions in the session picker, followed by
+// extra rows (e.g. endpoints), and returns the picked profile.
+func selectSession(title string, sessions []*internal.AWSSession, extra ...ui.Row) (string, error) {
+	events, _ := internal.ReadAuditLog()
+	sessionRows := internal.SessionRows(sessions, internal.LastUsedByProfile(events), time.Now())
+	rows := make([]ui.Row, 0, len(sessionRows)+len(extra))
+	for _, r := range sessionRows {
+		rows = append(rows, ui.Row{Key: r.Profile, Columns: []string{r.Profile, r.Role, r.Account, r.Expires, r.LastUsed}})
+	}
+	rows = append(rows, extra...)
+	return ui.SelectRow(title, []string{"PROFILE", "ROLE", "ACCOUNT", "EXPIRES", "LAST USED"}, rows)
 }
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chukul/cloudctl/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifySecret string
+	verifySeal   bool
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the credential store for corruption and tampering",
+	Long: `Check every entry of the credential store against its HMAC, keyed by your secret (or
+encryption provider), and check that every field decrypts. Entries changed outside
+cloudctl, corrupted on disk, or moved between profiles are reported as tampered. The
+store seal, an HMAC over all profile names and entry HMACs, catches entries that were
+removed, added or rolled back to an older copy.
+
+Entries written by older versions have no HMAC yet and are reported as unsealed; --seal
+adds one after checking that they decrypt, and seals the store.`,
+	Example: `  cloudctl verify
+  cloudctl verify --seal`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		secret, err := internal.GetSecret(verifySecret)
+		if err != nil && !internal.CurrentConfig().Encryption.UsesEnvelope() {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		provider, err := internal.StoreProvider(secret)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		if verifySeal {
+			sealed, err := internal.SealStore(provider)
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+			if len(sealed) > 0 {
+				fmt.Printf("🔏 Sealed %d entr(y/ies): %s\n\n", len(sealed), strings.Join(sealed, ", "))
+			}
+		}
+
+		results, err := internal.VerifyStore(provider)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			fmt.Println("💡 Restore credentials.json from a backup, or remove it and log in again.")
+			os.Exit(1)
+		}
+		if len(results) == 0 {
+			fmt.Println("📭 No stored sessions found.")
+			return
+		}
+
+		counts := make(map[string]int)
+		fmt.Printf("Credential store %s\n", internal.StoreDir())
+		fmt.Println(strings.Repeat("─", 60))
+		for _, r := range results {
+			counts[r.Status]++
+			switch r.Status {
+			case internal.IntegrityOK:
+				fmt.Printf("✅ %-30s ok\n", r.Profile)
+			case internal.IntegrityUnsealed:
+				fmt.Printf("⚠️  %-30s no HMAC yet (written by an older version)\n", r.Profile)
+			case internal.IntegrityStale:
+				var stale *internal.StaleSessionsError
+				if errors.As(r.Err, &stale) {
+					fmt.Printf("⚠️  %-30s needs a new login (%s)\n", r.Profile, strings.Join(stale.Sessions[0].Problems, ", "))
+				}
+			case internal.IntegrityWrongKey:
+				fmt.Printf("🔑 %-30s can't be decrypted with this secret\n", r.Profile)
+			case internal.IntegrityTampered:
+				fmt.Printf("❌ %-30s modified outside cloudctl or corrupted\n", r.Profile)
+			}
+		}
+		fmt.Println(strings.Repeat("─", 60))
+		fmt.Printf("%d ok, %d unsealed, %d wrong key, %d tampered\n",
+			counts[internal.IntegrityOK], counts[internal.IntegrityUnsealed], counts[internal.IntegrityWrongKey], counts[internal.IntegrityTampered])
+		storeSealed, _ := internal.StoreSealed()
+		if !storeSealed {
+			fmt.Println("⚠️  The store has no seal yet (written by an older version)")
+		}
+
+		if counts[internal.IntegrityStale] > 0 {
+			fmt.Printf("\n💡 %d session(s) from an older version can't be upgraded; log in to them again.\n", counts[internal.IntegrityStale])
+		}
+		if counts[internal.IntegrityUnsealed] > 0 || !storeSealed {
+			fmt.Println("\n💡 Add HMACs to unsealed entries and seal the store with: cloudctl verify --seal")
+		}
+		if counts[internal.IntegrityWrongKey] == len(results) {
+			fmt.Println("\n💡 No entry decrypts: check CLOUDCTL_SECRET, --secret or the encryption provider.")
+		}
+		if counts[internal.IntegrityTampered] > 0 {
+			fmt.Println("\n💡 Don't use tampered entries. Remove them and log in again:")
+			for _, r := range results {
+				if r.Status == internal.IntegrityTampered {
+					fmt.Printf("   cloudctl logout --profile %s\n", r.Profile)
+				}
+			}
+		}
+		if counts[internal.IntegrityTampered] > 0 || counts[internal.IntegrityWrongKey] > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifySecret, "secret", os.Getenv("CLOUDCTL_SECRET"), "Secret key for decryption (or set CLOUDCTL_SECRET env var)")
+	verifyCmd.Flags().BoolVar(&verifySeal, "seal", false, "Add an HMAC to entries written by older versions")
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -13,8 +13,8 @@ var versionCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("cloudctl version %s\n", internal.CurrentVersion)
 
-		// Force check for updates
-		latest, url, err := internal.FetchLatestVersion()
+		// Always asks GitHub; an unchanged release is answered from the cache by ETag
+		latest, url, err := internal.FetchLatestVersion(cmd.Context())
 		if err != nil {
 			fmt.Printf("Unable to check for updates: %v\n", err)
 			return
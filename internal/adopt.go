@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Default lifetimes assumed for adopted credentials whose expiration isn't known: the
+// STS defaults of AssumeRole (and SSO portal credentials) and of GetSessionToken.
+const (
+	adoptRoleLifetime  = time.Hour
+	adoptTokenLifetime = 12 * time.Hour
+)
+
+// expirationEnvVars hold the expiration of credentials in the environment, as exported
+// by `aws configure export-credentials` and SDK credential processes, or by aws-vault.
+var expirationEnvVars = []string{"AWS_CREDENTIAL_EXPIRATION", "AWS_SESSION_EXPIRATION"}
+
+// AdoptOptions describes temporary credentials obtained outside cloudctl, such as those
+// pasted from the SSO portal, to be stored as a session.
+type AdoptOptions struct {
+	Profile      string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+	// Expiration is when the credentials expire; zero means unknown, and a default
+	// lifetime for the kind of principal is assumed.
+	Expiration time.Time
+}
+
+// EnvAdoptOptions reads credentials from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN, their expiration from AWS_CREDENTIAL_EXPIRATION or
+// AWS_SESSION_EXPIRATION when set, and the region from AWS_REGION or AWS_DEFAULT_REGION.
+func EnvAdoptOptions() (AdoptOptions, error) {
+	opts, err := adoptOptionsFrom(os.Getenv)
+	if err == nil && (opts.AccessKey == "" || opts.SecretKey == "") {
+		err = fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set")
+	}
+	return opts, err
+}
+
+// ParseCredentialBlock reads credentials from text copied from the SSO portal: the
+// "Set AWS environment variables" block for bash/zsh (export), Windows cmd (SET) or
+// PowerShell ($Env:), or the "Add a profile to your AWS credentials file" block. It
+// takes the same variables as EnvAdoptOptions; other lines are ignored.
+func ParseCredentialBlock(text string) (AdoptOptions, error) {
+	vars := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"export ", "set ", "$env:"} {
+			if len(line) >= len(prefix) && strings.EqualFold(line[:len(prefix)], prefix) {
+				line = strings.TrimSpace(line[len(prefix):])
+				break
+			}
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		// Credentials file keys are the lower-case variable names
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[name] = value
+	}
+	opts, err := adoptOptionsFrom(func(name string) string { return vars[name] })
+	if err == nil && (opts.AccessKey == "" || opts.SecretKey == "") {
+		err = fmt.Errorf("no AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY found in the pasted text")
+	}
+	return opts, err
+}
+
+// adoptOptionsFrom reads adopted credentials from variables looked up with get.
+func adoptOptionsFrom(get func(string) string) (AdoptOptions, error) {
+	opts := AdoptOptions{
+		AccessKey:    get("AWS_ACCESS_KEY_ID"),
+		SecretKey:    get("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: get("AWS_SESSION_TOKEN"),
+		Region:       get("AWS_REGION"),
+	}
+	if opts.Region == "" {
+		opts.Region = get("AWS_DEFAULT_REGION")
+	}
+	for _, name := range expirationEnvVars {
+		if value := get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s '%s': %w", name, value, err)
+			}
+			opts.Expiration = t
+			break
+		}
+	}
+	return opts, nil
+}
+
+// ParseExpiration parses an --expires value: an RFC 3339 time, or a duration from now
+// such as 45m.
+func ParseExpiration(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("expiration '%s' must be in the future", value)
+		}
+		return now.Add(d).Truncate(time.Second), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiration '%s' (use a time like 2025-01-02T15:04:05Z or a duration like 45m)", value)
+	}
+	return t, nil
+}
+
+// AdoptSession checks temporary credentials with sts:GetCallerIdentity and returns
+// them as a session of the principal they belong to, to be stored with StoreSession.
+// cfg supplies the region and endpoint; its credentials are not used. Adopted sessions
+// have no source, so they can't be refreshed, only adopted or logged in again.
+func AdoptSession(ctx context.Context, cfg aws.Config, opts AdoptOptions) (*AWSSession, error) {
+	if opts.SessionToken == "" {
+		return nil, fmt.Errorf("no session token: only temporary credentials can be adopted; use long-term keys as an AWS CLI profile with --source")
+	}
+	region := opts.Region
+	if region == "" {
+		region = DefaultRegion
+	}
+	s := &AWSSession{
+		Profile:      opts.Profile,
+		AccessKey:    opts.AccessKey,
+		SecretKey:    opts.SecretKey,
+		SessionToken: opts.SessionToken,
+		Region:       region,
+		Expiration:   opts.Expiration,
+	}
+	if err := ResolveCallerIdentity(ctx, cfg, s); err != nil {
+		return nil, fmt.Errorf("credentials were rejected by STS (expired or mistyped?): %w", err)
+	}
+
+	s.RoleArn = s.PrincipalArn
+	lifetime := adoptTokenLifetime
+	if principal, err := ParseARN(s.PrincipalArn); err == nil {
+		if role, _, ok := principal.AssumedRole(); ok {
+			s.RoleArn = fmt.Sprintf("arn:%s:iam::%s:role/%s", principal.Partition, principal.AccountID, role)
+			lifetime = adoptRoleLifetime
+		}
+	}
+	if s.Expiration.IsZero() {
+		s.Expiration = time.Now().Add(lifetime).Truncate(time.Second)
+	} else if !s.Expiration.After(time.Now()) {
+		return nil, fmt.Errorf("credentials expired at %s", FormatTime(s.Expiration))
+	}
+	return s, nil
+}
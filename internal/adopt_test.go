@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+func mockCallerIdentity(mock *MockSTSClient, arn string) {
+	mock.GetCallerIdentityFunc = func(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+		return &sts.GetCallerIdentityOutput{
+			Account: aws.String("123456789012"),
+			Arn:     aws.String(arn),
+			UserId:  aws.String("AROAEXAMPLE:someone@example.com"),
+		}, nil
+	}
+}
+
+func TestAdoptSession(t *testing.T) {
+	mock := setupMockSTS(t)
+	mockCallerIdentity(mock, "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Admin_abc/someone@example.com")
+	opts := AdoptOptions{Profile: "sso-dev", AccessKey: "ASIAEXAMPLE", SecretKey: "secret", SessionToken: "token"}
+
+	s, err := AdoptSession(context.Background(), aws.Config{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.RoleArn != "arn:aws:iam::123456789012:role/AWSReservedSSO_Admin_abc" {
+		t.Errorf("Unexpected role %s", s.RoleArn)
+	}
+	if s.AccountID != "123456789012" || s.Region != DefaultRegion || s.SourceProfile != "" {
+		t.Errorf("Unexpected session %+v", s)
+	}
+	if d := time.Until(s.Expiration); d < 59*time.Minute || d > adoptRoleLifetime {
+		t.Errorf("Expected the role lifetime, got %v", d)
+	}
+
+	// A known expiration is kept, unless it has passed
+	opts.Expiration = time.Now().Add(20 * time.Minute).Truncate(time.Second)
+	if s, err := AdoptSession(context.Background(), aws.Config{}, opts); err != nil || !s.Expiration.Equal(opts.Expiration) {
+		t.Errorf("Expected expiration %v, got %+v (%v)", opts.Expiration, s, err)
+	}
+	opts.Expiration = time.Now().Add(-time.Minute)
+	if _, err := AdoptSession(context.Background(), aws.Config{}, opts); err == nil {
+		t.Error("Expected expired credentials to fail")
+	}
+
+	opts.SessionToken = ""
+	if _, err := AdoptSession(context.Background(), aws.Config{}, opts); err == nil {
+		t.Error("Expected long-term keys to fail")
+	}
+}
+
+func TestAdoptSessionToken(t *testing.T) {
+	mock := setupMockSTS(t)
+	mockCallerIdentity(mock, "arn:aws:iam::123456789012:user/someone")
+
+	s, err := AdoptSession(context.Background(), aws.Config{}, AdoptOptions{Profile: "dev-mfa", AccessKey: "ASIAEXAMPLE", SecretKey: "secret", SessionToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.RoleArn != "arn:aws:iam::123456789012:user/someone" {
+		t.Errorf("Unexpected principal %s", s.RoleArn)
+	}
+	if d := time.Until(s.Expiration); d < 11*time.Hour {
+		t.Errorf("Expected the session token lifetime, got %v", d)
+	}
+}
+
+func TestEnvAdoptOptions(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if _, err := EnvAdoptOptions(); err == nil {
+		t.Error("Expected missing credentials to fail")
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "ASIAEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	t.Setenv("AWS_CREDENTIAL_EXPIRATION", "2030-01-02T15:04:05Z")
+	opts, err := EnvAdoptOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.SessionToken != "token" || opts.Region != "eu-west-1" || opts.Expiration.Format(time.RFC3339) != "2030-01-02T15:04:05Z" {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+
+	t.Setenv("AWS_CREDENTIAL_EXPIRATION", "tomorrow")
+	if _, err := EnvAdoptOptions(); err == nil {
+		t.Error("Expected an invalid expiration to fail")
+	}
+}
+
+func TestParseExpiration(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	if got, err := ParseExpiration("45m", now); err != nil || !got.Equal(now.Add(45*time.Minute)) {
+		t.Errorf("Unexpected duration result %v (%v)", got, err)
+	}
+	if got, err := ParseExpiration("2025-01-02T16:00:00Z", now); err != nil || !got.Equal(now.Add(time.Hour)) {
+		t.Errorf("Unexpected time result %v (%v)", got, err)
+	}
+	for _, value := range []string{"-5m", "soon"} {
+		if _, err := ParseExpiration(value, now); err == nil {
+			t.Errorf("Expected %q to fail", value)
+		}
+	}
+}
+
+func TestParseCredentialBlock(t *testing.T) {
+	blocks := map[string]string{
+		"bash": `export AWS_ACCESS_KEY_ID="ASIAEXAMPLE"
+export AWS_SECRET_ACCESS_KEY="se=cret"
+export AWS_SESSION_TOKEN="token"
+`,
+		"cmd": "SET AWS_ACCESS_KEY_ID=ASIAEXAMPLE\r\nSET AWS_SECRET_ACCESS_KEY=se=cret\r\nSET AWS_SESSION_TOKEN=token\r\n",
+		"powershell": `$Env:AWS_ACCESS_KEY_ID="ASIAEXAMPLE"
+$Env:AWS_SECRET_ACCESS_KEY="se=cret"
+$Env:AWS_SESSION_TOKEN="token"`,
+		"credentials file": `[123456789012_AdministratorAccess]
+aws_access_key_id=ASIAEXAMPLE
+aws_secret_access_key=se=cret
+aws_session_token=token`,
+	}
+	for name, block := range blocks {
+		opts, err := ParseCredentialBlock(block)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if opts.AccessKey != "ASIAEXAMPLE" || opts.SecretKey != "se=cret" || opts.SessionToken != "token" {
+			t.Errorf("%s: unexpected options %+v", name, opts)
+		}
+	}
+
+	opts, err := ParseCredentialBlock("export AWS_ACCESS_KEY_ID=A\nexport AWS_SECRET_ACCESS_KEY=B\nexport AWS_REGION='eu-west-1'\nexport AWS_CREDENTIAL_EXPIRATION=2030-01-02T15:04:05Z")
+	if err != nil || opts.Region != "eu-west-1" || opts.Expiration.IsZero() {
+		t.Errorf("Unexpected options %+v (%v)", opts, err)
+	}
+
+	if _, err := ParseCredentialBlock("https://portal.awsapps.com/start"); err == nil {
+		t.Error("Expected text without credentials to fail")
+	}
+}
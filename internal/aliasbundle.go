@@ -0,0 +1,278 @@
+package internal
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// AliasBundleVersion is the current export format version.
+const AliasBundleVersion = 1
+
+const (
+	encryptedBundleFormat = "cloudctl-aliases-encrypted"
+	bundleKDF             = "pbkdf2-sha256"
+	bundleKDFIterations   = 600000
+)
+
+// AliasBundle is the export format for role and MFA device aliases.
+type AliasBundle struct {
+	Version    int                  `json:"version"`
+	Roles      map[string]RoleAlias `json:"roles"`
+	MFADevices map[string]string    `json:"mfa_devices,omitempty"`
+}
+
+// encryptedBundle wraps an AliasBundle encrypted with a passphrase-derived key.
+type encryptedBundle struct {
+	Format     string `json:"format"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`
+	Data       string `json:"data"`
+}
+
+// ExportAliases collects all role and MFA device aliases into a bundle.
+func ExportAliases() (*AliasBundle, error) {
+	roles, err := ListRoleAliases()
+	if err != nil {
+		return nil, err
+	}
+	devices, err := ListMFADevices()
+	if err != nil {
+		return nil, err
+	}
+	return &AliasBundle{Version: AliasBundleVersion, Roles: roles, MFADevices: devices}, nil
+}
+
+// MarshalAliasBundle serializes a bundle, encrypting it when passphrase is non-empty.
+func MarshalAliasBundle(bundle *AliasBundle, passphrase string) ([]byte, error) {
+	plain, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal aliases: %w", err)
+	}
+	if passphrase == "" {
+		return plain, nil
+	}
+
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	defer Wipe(plain)
+	derived, err := pbkdf2.Key(sha256.New, passphrase, salt, bundleKDFIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	key := NewSecureBuffer(derived)
+	defer key.Destroy()
+	data, err := Encrypt(plain, key.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt aliases: %w", err)
+	}
+	return json.MarshalIndent(encryptedBundle{
+		Format:     encryptedBundleFormat,
+		KDF:        bundleKDF,
+		Iterations: bundleKDFIterations,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Data:       base64.StdEncoding.EncodeToString(data),
+	}, "", "  ")
+}
+
+// IsEncryptedAliasBundle reports whether b is a passphrase-encrypted export.
+func IsEncryptedAliasBundle(b []byte) bool {
+	var env encryptedBundle
+	return json.Unmarshal(b, &env) == nil && env.Format == encryptedBundleFormat
+}
+
+// ParseAliasBundle reads an export. It accepts encrypted bundles (passphrase required),
+// plain bundles, and the legacy `role export` map of name → ARN.
+func ParseAliasBundle(b []byte, passphrase string) (*AliasBundle, error) {
+	if IsEncryptedAliasBundle(b) {
+		var env encryptedBundle
+		if err := json.Unmarshal(b, &env); err != nil {
+			return nil, fmt.Errorf("failed to parse encrypted export: %w", err)
+		}
+		if env.KDF != bundleKDF {
+			return nil, fmt.Errorf("unsupported key derivation '%s'", env.KDF)
+		}
+		if passphrase == "" {
+			return nil, fmt.Errorf("export is encrypted; a passphrase is required")
+		}
+		salt, err := base64.StdEncoding.DecodeString(env.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid salt: %w", err)
+		}
+		data, err := base64.StdEncoding.DecodeString(env.Data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data: %w", err)
+		}
+		derived, err := pbkdf2.Key(sha256.New, passphrase, salt, env.Iterations, 32)
+		if err != nil {
+			return nil, err
+		}
+		key := NewSecureBuffer(derived)
+		defer key.Destroy()
+		plain, err := Decrypt(data, key.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt export (wrong passphrase?)")
+		}
+		defer Wipe(plain)
+		b = plain
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	bundle := &AliasBundle{Version: AliasBundleVersion}
+	if _, ok := probe["version"]; ok {
+		if err := json.Unmarshal(b, bundle); err != nil {
+			return nil, fmt.Errorf("failed to parse export: %w", err)
+		}
+		if bundle.Version > AliasBundleVersion {
+			return nil, fmt.Errorf("export version %d is newer than supported (%d)", bundle.Version, AliasBundleVersion)
+		}
+	} else if err := json.Unmarshal(b, &bundle.Roles); err != nil {
+		return nil, fmt.Errorf("failed to parse roles: %w", err)
+	}
+
+	if bundle.Roles == nil {
+		bundle.Roles = make(map[string]RoleAlias)
+	}
+	if bundle.MFADevices == nil {
+		bundle.MFADevices = make(map[string]string)
+	}
+	return bundle, nil
+}
+
+// Conflict strategies for importing aliases that already exist with a different value
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+)
+
+// ValidateConflictStrategy checks an --on-conflict value.
+func ValidateConflictStrategy(strategy string) error {
+	switch strategy {
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+		return nil
+	}
+	return fmt.Errorf("unknown conflict strategy '%s' (use skip, overwrite or rename)", strategy)
+}
+
+// MergeChange describes what an import does to a single alias.
+type MergeChange struct {
+	// Kind is "role" or "mfa".
+	Kind string
+	Name string
+	// Action is "add", "overwrite", "rename", "skip" or "unchanged".
+	Action string
+	// NewName is set for renames.
+	NewName string
+	Old     string
+	New     string
+}
+
+// MergeAliases applies incoming aliases to current using strategy and returns the
+// resulting bundle plus a per-alias change list. current is not modified.
+func MergeAliases(current, incoming *AliasBundle, strategy string) (*AliasBundle, []MergeChange) {
+	merged := &AliasBundle{
+		Version:    AliasBundleVersion,
+		Roles:      make(map[string]RoleAlias, len(current.Roles)),
+		MFADevices: make(map[string]string, len(current.MFADevices)),
+	}
+	for k, v := range current.Roles {
+		merged.Roles[k] = v
+	}
+	for k, v := range current.MFADevices {
+		merged.MFADevices[k] = v
+	}
+
+	var changes []MergeChange
+
+	for _, name := range sortedKeys(incoming.Roles) {
+		alias := incoming.Roles[name]
+		change := MergeChange{Kind: "role", Name: name, New: alias.ARN}
+		existing, exists := merged.Roles[name]
+		switch {
+		case !exists:
+			change.Action = "add"
+			merged.Roles[name] = alias
+		case existing == alias:
+			change.Action = "unchanged"
+		case strategy == ConflictOverwrite:
+			change.Action = "overwrite"
+			change.Old = existing.ARN
+			merged.Roles[name] = alias
+		case strategy == ConflictRename:
+			change.Action = "rename"
+			change.NewName = freeName(name, func(n string) bool { _, taken := merged.Roles[n]; return taken || incoming.Roles[n] != RoleAlias{} })
+			merged.Roles[change.NewName] = alias
+		default:
+			change.Action = "skip"
+			change.Old = existing.ARN
+		}
+		changes = append(changes, change)
+	}
+
+	for _, name := range sortedKeys(incoming.MFADevices) {
+		arn := incoming.MFADevices[name]
+		change := MergeChange{Kind: "mfa", Name: name, New: arn}
+		existing, exists := merged.MFADevices[name]
+		switch {
+		case !exists:
+			change.Action = "add"
+			merged.MFADevices[name] = arn
+		case existing == arn:
+			change.Action = "unchanged"
+		case strategy == ConflictOverwrite:
+			change.Action = "overwrite"
+			change.Old = existing
+			merged.MFADevices[name] = arn
+		case strategy == ConflictRename:
+			change.Action = "rename"
+			change.NewName = freeName(name, func(n string) bool { _, taken := merged.MFADevices[n]; return taken || incoming.MFADevices[n] != "" })
+			merged.MFADevices[change.NewName] = arn
+		default:
+			change.Action = "skip"
+			change.Old = existing
+		}
+		changes = append(changes, change)
+	}
+
+	return merged, changes
+}
+
+// freeName returns name-2, name-3, ... for the first candidate not taken.
+func freeName(name string, taken func(string) bool) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SaveAliasBundle writes the roles and MFA devices of a bundle to their stores.
+func SaveAliasBundle(bundle *AliasBundle) error {
+	if err := SaveAllRoleAliases(bundle.Roles); err != nil {
+		return err
+	}
+	return SaveAllMFADevices(bundle.MFADevices)
+}
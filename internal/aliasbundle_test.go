@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAliasBundleEncryptedRoundTrip(t *testing.T) {
+	bundle := &AliasBundle{
+		Version:    AliasBundleVersion,
+		Roles:      map[string]RoleAlias{"prod": {ARN: "arn:aws:iam::123456789012:role/Admin", Region: "us-east-1"}},
+		MFADevices: map[string]string{"phone": "arn:aws:iam::123456789012:mfa/me"},
+	}
+
+	b, err := MarshalAliasBundle(bundle, "correct horse")
+	if err != nil {
+		t.Fatalf("MarshalAliasBundle failed: %v", err)
+	}
+	if !IsEncryptedAliasBundle(b) || strings.Contains(string(b), "arn:aws") {
+		t.Fatalf("Expected encrypted output, got:\n%s", b)
+	}
+
+	if _, err := ParseAliasBundle(b, "wrong"); err == nil {
+		t.Error("Expected error with wrong passphrase")
+	}
+	if _, err := ParseAliasBundle(b, ""); err == nil {
+		t.Error("Expected error without passphrase")
+	}
+
+	got, err := ParseAliasBundle(b, "correct horse")
+	if err != nil {
+		t.Fatalf("ParseAliasBundle failed: %v", err)
+	}
+	if got.Roles["prod"] != bundle.Roles["prod"] || got.MFADevices["phone"] != bundle.MFADevices["phone"] {
+		t.Errorf("Round trip mismatch: %+v", got)
+	}
+}
+
+func TestParseAliasBundleLegacy(t *testing.T) {
+	got, err := ParseAliasBundle([]byte(`{"prod": "arn:aws:iam::123456789012:role/Admin"}`), "")
+	if err != nil {
+		t.Fatalf("ParseAliasBundle failed: %v", err)
+	}
+	if got.Roles["prod"].ARN != "arn:aws:iam::123456789012:role/Admin" || len(got.MFADevices) != 0 {
+		t.Errorf("Unexpected legacy parse: %+v", got)
+	}
+}
+
+func TestMergeAliasesStrategies(t *testing.T) {
+	current := &AliasBundle{
+		Roles:      map[string]RoleAlias{"prod": {ARN: "arn:aws:iam::111111111111:role/Admin"}, "dev": {ARN: "arn:aws:iam::222222222222:role/Dev"}},
+		MFADevices: map[string]string{"phone": "arn:aws:iam::111111111111:mfa/me"},
+	}
+	incoming := &AliasBundle{
+		Roles: map[string]RoleAlias{
+			"prod":    {ARN: "arn:aws:iam::999999999999:role/Admin"},
+			"dev":     {ARN: "arn:aws:iam::222222222222:role/Dev"},
+			"staging": {ARN: "arn:aws:iam::333333333333:role/Dev"},
+		},
+		MFADevices: map[string]string{"phone": "arn:aws:iam::999999999999:mfa/me"},
+	}
+
+	actions := func(changes []MergeChange) map[string]string {
+		m := make(map[string]string)
+		for _, c := range changes {
+			m[c.Kind+":"+c.Name] = c.Action
+		}
+		return m
+	}
+
+	merged, changes := MergeAliases(current, incoming, ConflictSkip)
+	a := actions(changes)
+	if a["role:prod"] != "skip" || a["role:dev"] != "unchanged" || a["role:staging"] != "add" || a["mfa:phone"] != "skip" {
+		t.Errorf("Unexpected skip actions: %v", a)
+	}
+	if merged.Roles["prod"].ARN != "arn:aws:iam::111111111111:role/Admin" {
+		t.Error("skip must keep the existing alias")
+	}
+	if current.Roles["staging"] != (RoleAlias{}) {
+		t.Error("MergeAliases must not modify current")
+	}
+
+	merged, _ = MergeAliases(current, incoming, ConflictOverwrite)
+	if merged.Roles["prod"].ARN != "arn:aws:iam::999999999999:role/Admin" || merged.MFADevices["phone"] != "arn:aws:iam::999999999999:mfa/me" {
+		t.Errorf("overwrite not applied: %+v", merged)
+	}
+
+	merged, changes = MergeAliases(current, incoming, ConflictRename)
+	if merged.Roles["prod"].ARN != "arn:aws:iam::111111111111:role/Admin" || merged.Roles["prod-2"].ARN != "arn:aws:iam::999999999999:role/Admin" {
+		t.Errorf("rename not applied: %+v", merged.Roles)
+	}
+	if merged.MFADevices["phone-2"] != "arn:aws:iam::999999999999:mfa/me" {
+		t.Errorf("MFA rename not applied: %+v", merged.MFADevices)
+	}
+	if actions(changes)["role:prod"] != "rename" {
+		t.Errorf("Expected rename action: %v", actions(changes))
+	}
+
+	if err := ValidateConflictStrategy("merge"); err == nil {
+		t.Error("Expected error for unknown strategy")
+	}
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+)
+
+// AmbientSource is the source name for credentials from the current environment
+// (environment variables, web identity or container credentials), as the AWS SDK
+// finds them without a profile.
+const AmbientSource = "@env"
+
+// InstanceSource is the source name for the role of the EC2 instance (through IMDSv2)
+// or ECS task cloudctl runs on, for hosts without ~/.aws files.
+const InstanceSource = "instance"
+
+// ecsCredentialsHost serves task credentials at AWS_CONTAINER_CREDENTIALS_RELATIVE_URI.
+const ecsCredentialsHost = "http://169.254.170.2"
+
+// IsBuiltinSource reports whether a source names credentials cloudctl finds itself,
+// rather than a cloudctl session or AWS CLI profile.
+func IsBuiltinSource(source string) bool {
+	return source == AmbientSource || source == InstanceSource
+}
+
+// LoadBuiltinSourceConfig loads the AWS config of AmbientSource or InstanceSource.
+func LoadBuiltinSourceConfig(ctx context.Context, source, region string) (aws.Config, error) {
+	switch source {
+	case AmbientSource:
+		cfg, err := LoadAmbientConfig(ctx, region)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to load credentials from the environment: %w", err)
+		}
+		return cfg, nil
+	case InstanceSource:
+		cfg, err := config.LoadDefaultConfig(ctx,
+			config.WithRegion(region),
+			config.WithCredentialsProvider(aws.NewCredentialsCache(instanceCredentials())),
+			WithCallTimeout)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to load instance credentials: %w", err)
+		}
+		return cfg, nil
+	}
+	return aws.Config{}, fmt.Errorf("unknown source '%s'", source)
+}
+
+// instanceCredentials returns the ECS task role provider when the container credential
+// variables are set, and otherwise the EC2 instance role from IMDSv2, without falling
+// back to IMDSv1.
+func instanceCredentials() aws.CredentialsProvider {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		endpoint = ecsCredentialsHost + rel
+	}
+	if endpoint != "" {
+		return endpointcreds.New(endpoint, func(o *endpointcreds.Options) {
+			o.AuthorizationTokenProvider = endpointcreds.TokenProviderFunc(func() (string, error) {
+				if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+					b, err := os.ReadFile(file)
+					return strings.TrimSpace(string(b)), err
+				}
+				return os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"), nil
+			})
+		})
+	}
+	return ec2rolecreds.New(func(o *ec2rolecreds.Options) {
+		o.Client = imds.New(imds.Options{EnableFallback: aws.FalseTernary})
+	})
+}
+
+// AmbientSourceProfile returns the source to offer when --source is omitted: AWS_PROFILE
+// by name, so later refreshes don't depend on the environment, or AmbientSource for
+// credentials only found in it. label describes them for the user; source is "" when
+// the environment has none. Precedence follows the AWS SDK: keys before AWS_PROFILE.
+func AmbientSourceProfile() (source, label string) {
+	switch {
+	case os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "":
+		return AmbientSource, "AWS_ACCESS_KEY_ID " + MaskAccessKey(os.Getenv("AWS_ACCESS_KEY_ID"))
+	case os.Getenv("AWS_PROFILE") != "":
+		return os.Getenv("AWS_PROFILE"), "AWS_PROFILE=" + os.Getenv("AWS_PROFILE")
+	case os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" && os.Getenv("AWS_ROLE_ARN") != "":
+		return AmbientSource, "web identity for " + os.Getenv("AWS_ROLE_ARN")
+	case os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "":
+		return AmbientSource, "container credentials"
+	}
+	return "", ""
+}
+
+// LoadAmbientConfig loads an AWS config with the default credential chain and no
+// profile, for AmbientSource.
+func LoadAmbientConfig(ctx context.Context, region string) (aws.Config, error) {
+	return config.LoadDefaultConfig(ctx, config.WithRegion(region), WithCallTimeout)
+}
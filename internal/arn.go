@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ARN is a parsed Amazon Resource Name: arn:<partition>:<service>:<region>:<account>:<resource>.
+type ARN struct {
+	Partition string
+	Service   string
+	Region    string
+	AccountID string
+	// Resource is everything after the account, e.g. "role/engineering/app/AdminRole".
+	Resource string
+}
+
+// ParseARN splits an ARN into its parts. Only the layout is checked; use ParseRoleARN
+// for role ARNs.
+func ParseARN(s string) (ARN, error) {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[1] == "" || parts[2] == "" || parts[5] == "" {
+		return ARN{}, fmt.Errorf("'%s' is not an ARN (arn:<partition>:<service>:<region>:<account>:<resource>)", s)
+	}
+	return ARN{Partition: parts[1], Service: parts[2], Region: parts[3], AccountID: parts[4], Resource: parts[5]}, nil
+}
+
+// ParseRoleARN parses an IAM role ARN, with or without a path.
+func ParseRoleARN(s string) (ARN, error) {
+	a, err := ParseARN(s)
+	if err != nil || !a.IsRole() {
+		return ARN{}, fmt.Errorf("'%s' is not an IAM role ARN (arn:aws:iam::<account-id>:role/<role-name>)", s)
+	}
+	return a, nil
+}
+
+// String joins the parts back into an ARN.
+func (a ARN) String() string {
+	return strings.Join([]string{"arn", a.Partition, a.Service, a.Region, a.AccountID, a.Resource}, ":")
+}
+
+// ResourceType is the part of the resource before the first "/" or ":", e.g. "role".
+func (a ARN) ResourceType() string {
+	if i := strings.IndexAny(a.Resource, "/:"); i >= 0 {
+		return a.Resource[:i]
+	}
+	return a.Resource
+}
+
+// ResourceID is the part of the resource after its type, e.g. "engineering/app/AdminRole".
+func (a ARN) ResourceID() string {
+	if i := strings.IndexAny(a.Resource, "/:"); i >= 0 {
+		return a.Resource[i+1:]
+	}
+	return ""
+}
+
+// IsRole reports whether a is an IAM role in an account.
+func (a ARN) IsRole() bool {
+	return a.Service == "iam" && a.ResourceType() == "role" && accountIDPattern.MatchString(a.AccountID) && a.Name() != ""
+}
+
+// IsMFADevice reports whether a is an IAM virtual MFA device.
+func (a ARN) IsMFADevice() bool {
+	return a.Service == "iam" && a.ResourceType() == "mfa" && accountIDPattern.MatchString(a.AccountID) && a.Name() != ""
+}
+
+// Name is the last segment of an IAM resource ID: the role, user or device name
+// without its path ("AdminRole" for role/engineering/app/AdminRole).
+func (a ARN) Name() string {
+	id := a.ResourceID()
+	return id[strings.LastIndex(id, "/")+1:]
+}
+
+// Path is the IAM path of the resource, "/engineering/app/" for
+// role/engineering/app/AdminRole and "/" when there is none.
+func (a ARN) Path() string {
+	id := a.ResourceID()
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		return "/" + id[:i+1]
+	}
+	return "/"
+}
+
+// AssumedRole returns the role and session name of an STS assumed-role ARN
+// (arn:aws:sts::<account>:assumed-role/<role>/<session>).
+func (a ARN) AssumedRole() (role, session string, ok bool) {
+	if a.Service != "sts" || a.ResourceType() != "assumed-role" {
+		return "", "", false
+	}
+	role, session, ok = strings.Cut(a.ResourceID(), "/")
+	return role, session, ok && role != ""
+}
+
+// RoleAccountID returns the account ID of an IAM role ARN, or "".
+func RoleAccountID(roleArn string) string {
+	a, err := ParseRoleARN(roleArn)
+	if err != nil {
+		return ""
+	}
+	return a.AccountID
+}
+
+// RoleName returns the name of an IAM role ARN without its path, or "".
+func RoleName(roleArn string) string {
+	a, err := ParseRoleARN(roleArn)
+	if err != nil {
+		return ""
+	}
+	return a.Name()
+}
@@ -0,0 +1,59 @@
+package internal
+
+import "testing"
+
+func TestParseRoleARN(t *testing.T) {
+	tests := []struct {
+		arn, account, name, path string
+	}{
+		{"arn:aws:iam::123456789012:role/Admin", "123456789012", "Admin", "/"},
+		{"arn:aws:iam::123456789012:role/engineering/app/AdminRole", "123456789012", "AdminRole", "/engineering/app/"},
+		{"arn:aws:iam::123456789012:role/aws-service-role/ecs.amazonaws.com/AWSServiceRoleForECS", "123456789012", "AWSServiceRoleForECS", "/aws-service-role/ecs.amazonaws.com/"},
+		{"arn:aws-us-gov:iam::123456789012:role/Deploy", "123456789012", "Deploy", "/"},
+		{"arn:aws-cn:iam::123456789012:role/ops/Deploy", "123456789012", "Deploy", "/ops/"},
+	}
+	for _, tt := range tests {
+		a, err := ParseRoleARN(tt.arn)
+		if err != nil {
+			t.Errorf("%s: %v", tt.arn, err)
+			continue
+		}
+		if a.AccountID != tt.account || a.Name() != tt.name || a.Path() != tt.path || a.String() != tt.arn {
+			t.Errorf("%s: got account %q name %q path %q", tt.arn, a.AccountID, a.Name(), a.Path())
+		}
+	}
+
+	for _, bad := range []string{
+		"",
+		"Admin",
+		"MFA-Session",
+		"arn:aws:iam::123456789012:user/alice",
+		"arn:aws:iam::123456789012:role/",
+		"arn:aws:iam::12345:role/Admin",
+		"arn:aws:sts::123456789012:assumed-role/Admin/alice",
+	} {
+		if _, err := ParseRoleARN(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+		if RoleAccountID(bad) != "" || RoleName(bad) != "" {
+			t.Errorf("Expected no account or name for %q", bad)
+		}
+	}
+}
+
+func TestARNKinds(t *testing.T) {
+	mfa, err := ParseARN("arn:aws:iam::123456789012:mfa/team/alice")
+	if err != nil || !mfa.IsMFADevice() || mfa.IsRole() || mfa.Name() != "alice" {
+		t.Errorf("Unexpected MFA device ARN: %+v, %v", mfa, err)
+	}
+
+	assumed, _ := ParseARN("arn:aws:sts::123456789012:assumed-role/Admin/alice@example.com")
+	if role, session, ok := assumed.AssumedRole(); !ok || role != "Admin" || session != "alice@example.com" {
+		t.Errorf("AssumedRole = %q, %q, %v", role, session, ok)
+	}
+
+	bucket, err := ParseARN("arn:aws:s3:::bucket/key:with:colons")
+	if err != nil || bucket.Resource != "bucket/key:with:colons" || bucket.AccountID != "" {
+		t.Errorf("Unexpected S3 ARN: %+v, %v", bucket, err)
+	}
+}
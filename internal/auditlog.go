@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+var auditLogPath = filepath.Join(storeDir, "audit.log")
+
+// Audit event types
+const (
+	AuditApprovalIssued        = "approval_issued"
+	AuditApprovalUsed          = "approval_used"
+	AuditDualControlRequested  = "dual_control_requested"
+	AuditDualControlDelayEnded = "dual_control_delay_elapsed"
+	AuditLogin                 = "login"
+	AuditBreakGlass            = "break_glass"
+	AuditRootLogin             = "root_login"
+	// AuditBrokerIssue records credentials `cloudctl serve` handed out; its User is the
+	// OIDC user the credentials were issued to.
+	AuditBrokerIssue = "broker_issue"
+	// Usage events, for `cloudctl stats`, `cloudctl suggest` and `cloudctl timeline`
+	AuditSTSCall           = "sts_call"
+	AuditRefresh           = "refresh"
+	AuditConsoleFederation = "console_federation"
+	AuditRoleUse           = "role_use"
+	AuditMFALogin          = "mfa_login"
+	AuditExport            = "export"
+)
+
+// AuditEvent is one line of the local audit log. It never holds credentials.
+type AuditEvent struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	// User is the OS user who ran cloudctl, or the OIDC user of broker_issue events.
+	User     string `json:"user"`
+	Role     string `json:"role,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+	Approver string `json:"approver,omitempty"`
+	// Nonce identifies an approval token, so each token can only be used once.
+	Nonce  string `json:"nonce,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	// Command, LatencyMS and Failed are set on usage events.
+	Command   string `json:"command,omitempty"`
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+	Failed    bool   `json:"failed,omitempty"`
+	// Source and Region are set on role_use and mfa_login events.
+	Source string `json:"source,omitempty"`
+	Region string `json:"region,omitempty"`
+}
+
+// AppendAudit adds an event to the audit log, filling in the time and user.
+func AppendAudit(e AuditEvent) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.User == "" {
+		e.User = CurrentUser()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(auditLogPath), 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	f, err := os.OpenFile(auditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// ReadAuditLog returns all events, oldest first. A missing log is empty; lines that
+// can't be parsed are skipped.
+func ReadAuditLog() ([]AuditEvent, error) {
+	f, err := os.Open(auditLogPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e AuditEvent
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			events = append(events, e)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// AuditLogPath returns the location of the audit log.
+func AuditLogPath() string {
+	return auditLogPath
+}
+
+// CurrentUser returns the OS user name, recorded as the requester and approver in
+// dual-control events.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
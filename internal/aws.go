@@ -2,7 +2,9 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,17 +14,21 @@ import (
 )
 
 // AssumeRole performs an AWS STS AssumeRole operation and returns a session.
-func AssumeRole(profile, roleArn, sessionName, region string) (*AWSSession, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
+func AssumeRole(ctx context.Context, profile, roleArn, sessionName, region string) (*AWSSession, error) {
+	if err := CheckAssumePolicy(AssumeRequest{RoleArn: roleArn, Source: profile}); err != nil {
+		return nil, err
+	}
+	cfg, err := config.LoadDefaultConfig(ctx,
 		config.WithRegion(region),
 		config.WithSharedConfigProfile(profile),
+		WithCallTimeout,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	svc := sts.NewFromConfig(cfg)
-	out, err := svc.AssumeRole(context.TODO(), &sts.AssumeRoleInput{
+	svc := NewSTSClient(cfg, profile)
+	out, err := svc.AssumeRole(ctx, &sts.AssumeRoleInput{
 		RoleArn:         &roleArn,
 		RoleSessionName: &sessionName,
 	})
@@ -40,25 +46,32 @@ func AssumeRole(profile, roleArn, sessionName, region string) (*AWSSession, erro
 	}, nil
 }
 
-// PerformRefresh silenty refreshes a single session if possible
-func PerformRefresh(s *AWSSession, secret, region string) (*AWSSession, error) {
-	if s.RoleArn == "MFA-Session" {
-		return nil, fmt.Errorf("MFA sessions cannot be silently refreshed")
-	}
-	if s.SourceProfile == "" {
-		return nil, fmt.Errorf("no source profile stored for this session")
-	}
+// ErrSourceExpired is returned by LoadSourceConfig for an expired cloudctl session.
+var ErrSourceExpired = errors.New("has expired")
 
-	ctx := context.TODO()
+// ErrSourceSelfDestructed is returned by LoadSourceConfig for a cloudctl session past its
+// `login --self-destruct` deadline, which must not mint new sessions.
+var ErrSourceSelfDestructed = errors.New("has self-destructed")
+
+// LoadSourceConfig builds an AWS config from a source, which is either a cloudctl
+// session (when the secret unlocks one) or an AWS CLI profile.
+func LoadSourceConfig(ctx context.Context, source, secret, region string) (aws.Config, error) {
 	var cfg aws.Config
 	var err error
 
+	if IsBuiltinSource(source) {
+		return LoadBuiltinSourceConfig(ctx, source, region)
+	}
+
 	// Load source credentials
-	sourceSession, sourceErr := LoadCredentials(s.SourceProfile, secret)
+	sourceSession, sourceErr := LoadCredentials(source, secret)
 	if sourceErr == nil {
 		// Source is a cloudctl session - Check if it's still active
 		if time.Now().After(sourceSession.Expiration) {
-			return nil, fmt.Errorf("source session '%s' has expired", s.SourceProfile)
+			return cfg, fmt.Errorf("source session '%s' %w", source, ErrSourceExpired)
+		}
+		if sourceSession.SelfDestructed(time.Now()) {
+			return cfg, fmt.Errorf("source session '%s' %w at %s; log in again", source, ErrSourceSelfDestructed, FormatTime(sourceSession.SelfDestruct))
 		}
 
 		cfg, err = config.LoadDefaultConfig(ctx,
@@ -68,29 +81,84 @@ func PerformRefresh(s *AWSSession, secret, region string) (*AWSSession, error) {
 				sourceSession.SecretKey,
 				sourceSession.SessionToken,
 			)),
+			WithCallTimeout,
 		)
 	} else {
 		// Source is standard AWS profile
 		cfg, err = config.LoadDefaultConfig(ctx,
 			config.WithRegion(region),
-			config.WithSharedConfigProfile(s.SourceProfile),
+			config.WithSharedConfigProfile(source),
+			WithCallTimeout,
 		)
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to load source: %w", err)
+		return cfg, fmt.Errorf("failed to load source: %w", err)
+	}
+	return cfg, nil
+}
+
+// PerformRefresh silenty refreshes a single session if possible
+func PerformRefresh(ctx context.Context, s *AWSSession, secret, region string) (*AWSSession, error) {
+	return RefreshSession(ctx, s, secret, region, func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, "⚠️  "+format+"\n", args...)
+	})
+}
+
+// RefreshSession is PerformRefresh with a context. Remote state problems don't stop the
+// refresh and are passed to warn instead.
+func RefreshSession(ctx context.Context, s *AWSSession, secret, region string, warn func(format string, args ...any)) (*AWSSession, error) {
+	if s.RoleArn == "MFA-Session" {
+		return nil, fmt.Errorf("MFA sessions cannot be silently refreshed")
+	}
+	if s.IsRoot() {
+		return nil, fmt.Errorf("root sessions cannot be refreshed; run root-login again")
+	}
+	if s.SourceProfile == "" {
+		return nil, fmt.Errorf("no source profile stored for this session")
+	}
+	if err := requireConfig(); err != nil {
+		return nil, err
+	}
+	if CurrentConfig().RequiresDualControl(s.RoleArn) {
+		return nil, fmt.Errorf("role %s requires dual control; log in again with an approval", s.RoleArn)
+	}
+	if s.SelfDestructed(time.Now()) {
+		return nil, fmt.Errorf("session self-destructed at %s; log in again", FormatTime(s.SelfDestruct))
+	}
+	if CurrentConfig().IsBreakGlass(s.RoleArn) {
+		return nil, fmt.Errorf("role %s is a break-glass role; log in again with a justification", s.RoleArn)
+	}
+
+	if err := CheckAssumePolicy(AssumeRequest{RoleArn: s.RoleArn, Source: s.SourceProfile, SourceSession: loadSourceSession(s.SourceProfile, secret)}); err != nil {
+		return nil, err
+	}
+
+	if err := AcquireRenewal(ctx, s.Profile, secret); err != nil {
+		var busy *RenewalInProgressError
+		if errors.As(err, &busy) {
+			return nil, err
+		}
+		warn("Remote state unavailable, refreshing anyway: %v", err)
+	}
+
+	cfg, err := LoadSourceConfig(ctx, s.SourceProfile, secret, region)
+	if err != nil {
+		return nil, err
 	}
 
-	stsClient := sts.NewFromConfig(cfg)
+	stsClient := NewSTSClient(cfg, s.Profile)
 	sessionName := s.Profile
 	duration := int32(3600)
 
+	start := time.Now()
 	res, err := stsClient.AssumeRole(ctx, &sts.AssumeRoleInput{
 		RoleArn:         &s.RoleArn,
 		RoleSessionName: &sessionName,
 		DurationSeconds: &duration,
 	})
 	if err != nil {
+		RecordRefresh(s.Profile, "silent", start, err)
 		return nil, err
 	}
 
@@ -105,11 +173,37 @@ func PerformRefresh(s *AWSSession, secret, region string) (*AWSSession, error) {
 		Region:        s.Region,
 		MfaArn:        s.MfaArn,
 		Duration:      s.Duration,
+		Access:        s.Access,
+		AccountID:     s.AccountID,
+		PrincipalArn:  s.PrincipalArn,
+		UserID:        s.UserID,
+		AccountAlias:  s.AccountAlias,
+		SelfDestruct:  s.SelfDestruct,
 	}
 
 	if err := SaveCredentials(s.Profile, newSession, secret); err != nil {
 		return nil, err
 	}
+	RecordRefresh(s.Profile, "silent", start, nil)
+	if err := PublishRemoteSession(ctx, newSession, secret); err != nil {
+		warn("Failed to update remote state: %v", err)
+	}
 
 	return newSession, nil
 }
+
+// ResolveCallerIdentity fills in the session's account ID, principal ARN and user ID
+// with sts:GetCallerIdentity, called with the session's own credentials. cfg supplies
+// the region and endpoint; its credentials are not used.
+func ResolveCallerIdentity(ctx context.Context, cfg aws.Config, s *AWSSession) error {
+	sessionCfg := cfg.Copy()
+	sessionCfg.Credentials = credentials.NewStaticCredentialsProvider(s.AccessKey, s.SecretKey, s.SessionToken)
+	out, err := NewSTSClient(sessionCfg, s.Profile).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return err
+	}
+	s.AccountID = aws.ToString(out.Account)
+	s.PrincipalArn = aws.ToString(out.Arn)
+	s.UserID = aws.ToString(out.UserId)
+	return nil
+}
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AWSConfigImport is what `import aws-config` makes of the AWS CLI's role profiles.
+type AWSConfigImport struct {
+	// Aliases holds a role alias per role profile and an MFA device alias per mfa_serial.
+	Aliases *AliasBundle
+	// Sources maps each role alias to the profile with the long-lived credentials its
+	// role is assumed from. Roles assumed from another role (chained source_profile) or
+	// with credential_source have none.
+	Sources map[string]string
+	// MFADevices maps each role alias with an mfa_serial to its MFA device alias.
+	MFADevices map[string]string
+	// Skipped explains the role profiles that can't become an alias.
+	Skipped []string
+}
+
+// ImportAWSConfigProfiles converts role profiles (those with role_arn) into role aliases
+// named after the profile. region, duration_seconds and mfa_serial become the alias
+// defaults login uses, and each mfa_serial is saved as an MFA device alias named after
+// the device.
+func ImportAWSConfigProfiles(profiles []AWSProfile) *AWSConfigImport {
+	byName := make(map[string]AWSProfile, len(profiles))
+	for _, p := range profiles {
+		byName[p.Name] = p
+	}
+	result := &AWSConfigImport{
+		Aliases:    &AliasBundle{Version: AliasBundleVersion, Roles: map[string]RoleAlias{}, MFADevices: map[string]string{}},
+		Sources:    map[string]string{},
+		MFADevices: map[string]string{},
+	}
+
+	for _, p := range profiles {
+		if p.RoleArn == "" {
+			continue
+		}
+		if _, err := ParseRoleARN(p.RoleArn); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", p.Name, err))
+			continue
+		}
+		alias := RoleAlias{ARN: p.RoleArn, Region: p.Region, Duration: p.DurationSeconds, MFARequired: p.MFASerial != ""}
+		switch source, ok := byName[p.SourceProfile]; {
+		case p.SourceProfile != "" && !ok:
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: source_profile '%s' doesn't exist", p.Name, p.SourceProfile))
+			continue
+		case p.SourceProfile != "":
+			alias.Description = "AWS profile " + p.Name + " (source " + p.SourceProfile + ")"
+			// A profile may name itself to use its own static keys for the role
+			if source.RoleArn == "" || source.Name == p.Name {
+				result.Sources[p.Name] = p.SourceProfile
+			}
+		case p.CredentialSource != "":
+			alias.Description = "AWS profile " + p.Name + " (credential_source " + p.CredentialSource + ")"
+		default:
+			alias.Description = "AWS profile " + p.Name
+		}
+		if err := alias.Validate(); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", p.Name, err))
+			continue
+		}
+		result.Aliases.Roles[p.Name] = alias
+		if p.MFASerial != "" {
+			device := mfaDeviceName(p.MFASerial)
+			result.Aliases.MFADevices[device] = p.MFASerial
+			result.MFADevices[p.Name] = device
+		}
+	}
+	sort.Strings(result.Skipped)
+	return result
+}
+
+// mfaDeviceName names an MFA device alias after the device in its ARN
+// (arn:aws:iam::123456789012:mfa/alice becomes "alice").
+func mfaDeviceName(serial string) string {
+	if i := strings.LastIndex(serial, "/"); i >= 0 && i < len(serial)-1 {
+		return serial[i+1:]
+	}
+	return serial
+}
+
+// UpTemplate returns the `up` section that logs in to the imported roles of the source
+// most of them are assumed from: an MFA login from that source when its roles have an
+// mfa_serial, then one login per role. It returns nil when no role has a source.
+func (r *AWSConfigImport) UpTemplate() *UpConfig {
+	counts := map[string]int{}
+	for _, source := range r.Sources {
+		counts[source]++
+	}
+	best := ""
+	for source, n := range counts {
+		if n > counts[best] || (n == counts[best] && source < best) {
+			best = source
+		}
+	}
+	if best == "" {
+		return nil
+	}
+
+	up := &UpConfig{Source: best}
+	for _, name := range sortedKeys(r.Aliases.Roles) {
+		if r.Sources[name] != best {
+			continue
+		}
+		up.Roles = append(up.Roles, UpRole{Role: name, Profile: name})
+		if up.MFADevice == "" {
+			up.MFADevice = r.MFADevices[name]
+		}
+	}
+	return up
+}
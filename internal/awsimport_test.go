@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImportAWSConfigProfiles(t *testing.T) {
+	profiles := []AWSProfile{
+		{Name: "base", StaticKeys: true},
+		{Name: "prod-admin", RoleArn: "arn:aws:iam::111111111111:role/Admin", SourceProfile: "base",
+			MFASerial: "arn:aws:iam::222222222222:mfa/alice", DurationSeconds: 7200, Region: "ap-southeast-1"},
+		{Name: "dev-admin", RoleArn: "arn:aws:iam::333333333333:role/Admin", SourceProfile: "base"},
+		{Name: "chained", RoleArn: "arn:aws:iam::444444444444:role/ReadOnly", SourceProfile: "prod-admin"},
+		{Name: "ec2", RoleArn: "arn:aws:iam::555555555555:role/Ops", CredentialSource: "Ec2InstanceMetadata"},
+		{Name: "broken", RoleArn: "arn:aws:iam::666666666666:role/X", SourceProfile: "missing"},
+		{Name: "short", RoleArn: "arn:aws:iam::777777777777:role/X", SourceProfile: "base", DurationSeconds: 60},
+	}
+	r := ImportAWSConfigProfiles(profiles)
+
+	want := RoleAlias{ARN: "arn:aws:iam::111111111111:role/Admin", Description: "AWS profile prod-admin (source base)",
+		Region: "ap-southeast-1", Duration: 7200, MFARequired: true}
+	if got := r.Aliases.Roles["prod-admin"]; got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if got := r.Aliases.MFADevices["alice"]; got != "arn:aws:iam::222222222222:mfa/alice" {
+		t.Errorf("Expected the mfa_serial as MFA device alice, got %q", got)
+	}
+	if len(r.Aliases.Roles) != 4 || len(r.Skipped) != 2 {
+		t.Errorf("Expected 4 aliases and 2 skipped profiles, got %v and %v", sortedKeys(r.Aliases.Roles), r.Skipped)
+	}
+	if _, ok := r.Sources["chained"]; ok {
+		t.Error("Expected a role assumed from another role to have no source")
+	}
+
+	up := r.UpTemplate()
+	wantUp := &UpConfig{Source: "base", MFADevice: "alice", Roles: []UpRole{
+		{Role: "dev-admin", Profile: "dev-admin"},
+		{Role: "prod-admin", Profile: "prod-admin"},
+	}}
+	if !reflect.DeepEqual(up, wantUp) {
+		t.Errorf("Expected up template %+v, got %+v", wantUp, up)
+	}
+}
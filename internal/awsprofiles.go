@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// awsFilePath returns the AWS CLI file named by env, or ~/.aws/name when it isn't set.
+// A leading ~ is expanded like the AWS CLI does.
+func awsFilePath(env, name string) string {
+	path := os.Getenv(env)
+	if path == "" {
+		return filepath.Join(os.Getenv("HOME"), ".aws", name)
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = filepath.Join(os.Getenv("HOME"), path[1:])
+	}
+	return path
+}
+
+// AWSProfile is a profile of the AWS CLI's credentials or config file with the settings
+// that say where its credentials come from.
+type AWSProfile struct {
+	Name string
+	// StaticKeys is true when the profile has an access key in either file.
+	StaticKeys        bool
+	RoleArn           string
+	SourceProfile     string
+	CredentialSource  string
+	MFASerial         string
+	DurationSeconds   int32
+	SSO               bool
+	CredentialProcess bool
+	Region            string
+}
+
+// Kind describes where the profile's credentials come from, for pickers.
+func (p AWSProfile) Kind() string {
+	switch {
+	case p.RoleArn != "" && p.SourceProfile != "":
+		return "role via " + p.SourceProfile
+	case p.RoleArn != "" && p.CredentialSource != "":
+		return "role via " + p.CredentialSource
+	case p.RoleArn != "":
+		return "role"
+	case p.SSO:
+		return "SSO"
+	case p.StaticKeys:
+		return "static keys"
+	case p.CredentialProcess:
+		return "credential_process"
+	}
+	return "no credentials"
+}
+
+// ListAWSProfiles reads the profiles of the AWS CLI's credentials and config files
+// (AWS_SHARED_CREDENTIALS_FILE and AWS_CONFIG_FILE when set), sorted by name. Files that
+// can't be read have no profiles.
+func ListAWSProfiles() []AWSProfile {
+	profiles := map[string]*AWSProfile{}
+	get := func(name string) *AWSProfile {
+		if p, ok := profiles[name]; ok {
+			return p
+		}
+		p := &AWSProfile{Name: name}
+		profiles[name] = p
+		return p
+	}
+
+	if content, err := os.ReadFile(AWSCredentialsPath()); err == nil {
+		for _, sec := range splitConfigSections(string(content)) {
+			if name := strings.TrimSpace(strings.Trim(sec.Header, "[]")); name != "" {
+				get(name).apply(sectionValues(sec))
+			}
+		}
+	}
+	if content, err := os.ReadFile(AWSConfigPath()); err == nil {
+		for _, sec := range splitConfigSections(string(content)) {
+			name := strings.TrimSpace(strings.Trim(sec.Header, "[]"))
+			// Other sections (sso-session, services) aren't profiles
+			if name != "default" && !strings.HasPrefix(name, "profile ") {
+				continue
+			}
+			get(configSectionProfile(sec.Header)).apply(sectionValues(sec))
+		}
+	}
+
+	result := make([]AWSProfile, 0, len(profiles))
+	for _, p := range profiles {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (p *AWSProfile) apply(values map[string]string) {
+	for key, value := range values {
+		switch key {
+		case "aws_access_key_id":
+			p.StaticKeys = true
+		case "role_arn":
+			p.RoleArn = value
+		case "source_profile":
+			p.SourceProfile = value
+		case "credential_source":
+			p.CredentialSource = value
+		case "mfa_serial":
+			p.MFASerial = value
+		case "duration_seconds":
+			if n, err := strconv.ParseInt(value, 10, 32); err == nil {
+				p.DurationSeconds = int32(n)
+			}
+		case "sso_session", "sso_start_url":
+			p.SSO = true
+		case "credential_process":
+			p.CredentialProcess = true
+		case "region":
+			p.Region = value
+		}
+	}
+}
+
+// sectionValues returns the top-level key = value settings of a section. Nested
+// settings (indented lines under e.g. "s3 =") are skipped.
+func sectionValues(sec configSection) map[string]string {
+	values := map[string]string{}
+	for _, line := range sec.Lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == sec.Header || strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "#") ||
+			strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			continue
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return values
+}
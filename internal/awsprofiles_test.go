@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListAWSProfilesHonorsEnvFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := t.TempDir()
+	credsPath := filepath.Join(dir, "creds")
+	configPath := filepath.Join(dir, "cfg")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsPath)
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+
+	// Files in ~/.aws are ignored while the env vars are set
+	os.MkdirAll(filepath.Join(home, ".aws"), 0700)
+	os.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte("[ignored]\naws_access_key_id = AKIAIGNORED\n"), 0600)
+
+	os.WriteFile(credsPath, []byte("[default]\naws_access_key_id = AKIAUSER\naws_secret_access_key = secret\n"), 0600)
+	os.WriteFile(configPath, []byte(`[default]
+region = eu-west-1
+
+[profile prod]
+role_arn = arn:aws:iam::111111111111:role/Admin
+source_profile = default
+mfa_serial = arn:aws:iam::222222222222:mfa/alice
+duration_seconds = 7200
+s3 =
+  max_concurrent_requests = 20
+
+[profile sso]
+sso_session = corp
+
+[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+`), 0600)
+
+	if got := AWSCredentialsPath(); got != credsPath {
+		t.Errorf("Expected AWSCredentialsPath %s, got %s", credsPath, got)
+	}
+	want := []AWSProfile{
+		{Name: "default", StaticKeys: true, Region: "eu-west-1"},
+		{Name: "prod", RoleArn: "arn:aws:iam::111111111111:role/Admin", SourceProfile: "default",
+			MFASerial: "arn:aws:iam::222222222222:mfa/alice", DurationSeconds: 7200},
+		{Name: "sso", SSO: true},
+	}
+	got := ListAWSProfiles()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %+v, got %+v", want, got)
+	}
+	kinds := []string{"static keys", "role via default", "SSO"}
+	for i, p := range got {
+		if p.Kind() != kinds[i] {
+			t.Errorf("Expected %s to be %q, got %q", p.Name, kinds[i], p.Kind())
+		}
+	}
+
+	t.Setenv("AWS_CONFIG_FILE", "~/aws-config")
+	if got := AWSConfigPath(); got != filepath.Join(home, "aws-config") {
+		t.Errorf("Expected ~ to be expanded, got %s", got)
+	}
+}
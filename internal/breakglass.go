@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// MinJustificationLength keeps break-glass justifications from being a single word.
+const MinJustificationLength = 10
+
+// maxSessionTagValue is the longest value STS accepts for a session tag.
+const maxSessionTagValue = 256
+
+// JustificationTagKey is the session tag that carries a break-glass justification, so
+// it shows in CloudTrail next to every call made with the session.
+const JustificationTagKey = "Justification"
+
+// IsBreakGlass reports whether logging in to roleArn needs a justification, because it
+// matches security.break_glass_roles.
+func (c *Config) IsBreakGlass(roleArn string) bool {
+	for _, pattern := range c.Security.BreakGlassRoles {
+		if matchGlob(pattern, roleArn) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateJustification trims a break-glass justification and checks its length.
+func ValidateJustification(justification string) (string, error) {
+	justification = strings.Join(strings.Fields(justification), " ")
+	if len([]rune(justification)) < MinJustificationLength {
+		return "", fmt.Errorf("a justification of at least %d characters is required (e.g. an incident or ticket reference)", MinJustificationLength)
+	}
+	if len([]rune(justification)) > maxSessionTagValue {
+		return "", fmt.Errorf("the justification must be at most %d characters", maxSessionTagValue)
+	}
+	return justification, nil
+}
+
+// BreakGlassTags returns the session tags for a break-glass login. Characters STS
+// doesn't allow in tag values are replaced by spaces; the audit log keeps the original.
+func BreakGlassTags(justification string) []types.Tag {
+	value := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsSpace(r) || strings.ContainsRune("_.:/=+-@", r) {
+			return r
+		}
+		return ' '
+	}, justification)
+	value = strings.Join(strings.Fields(value), " ")
+	return []types.Tag{{Key: aws.String(JustificationTagKey), Value: aws.String(value)}}
+}
+
+// SourceIdentityFor turns an OS user name into an STS source identity, which must be
+// 2-64 characters of letters, digits and _+=,.@- and is kept through role chaining.
+func SourceIdentityFor(user string) string {
+	id := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_+=,.@-", r)) {
+			return r
+		}
+		return '-'
+	}, user)
+	if len(id) > 64 {
+		id = id[:64]
+	}
+	for len(id) < 2 {
+		id += "-"
+	}
+	return id
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestIsBreakGlass(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Security.BreakGlassRoles = []string{"*:role/emergency/*"}
+	if !cfg.IsBreakGlass("arn:aws:iam::123456789012:role/emergency/DBAdmin") {
+		t.Error("Expected emergency/DBAdmin to be a break-glass role")
+	}
+	if cfg.IsBreakGlass("arn:aws:iam::123456789012:role/DBAdmin") {
+		t.Error("Expected DBAdmin not to be a break-glass role")
+	}
+}
+
+func TestValidateJustification(t *testing.T) {
+	got, err := ValidateJustification("  INC-1234:\trestore   orders DB \n")
+	if err != nil || got != "INC-1234: restore orders DB" {
+		t.Errorf("ValidateJustification = %q, %v", got, err)
+	}
+	for _, bad := range []string{"", "   ", "fix", strings.Repeat("x", 257)} {
+		if _, err := ValidateJustification(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestBreakGlassTags(t *testing.T) {
+	tags := BreakGlassTags(`INC-1234 "orders" #db, see https://wiki/x?a=1`)
+	if len(tags) != 1 || aws.ToString(tags[0].Key) != JustificationTagKey {
+		t.Fatalf("Unexpected tags: %+v", tags)
+	}
+	if got := aws.ToString(tags[0].Value); got != "INC-1234 orders db see https://wiki/x a=1" {
+		t.Errorf("Tag value = %q", got)
+	}
+}
+
+func TestSourceIdentityFor(t *testing.T) {
+	tests := map[string]string{
+		"alice":                 "alice",
+		`CORP\bob smith`:        "CORP-bob-smith",
+		"x":                     "x-",
+		"ใจดี":                  "----",
+		strings.Repeat("a", 70): strings.Repeat("a", 64),
+	}
+	for user, want := range tests {
+		if got := SourceIdentityFor(user); got != want {
+			t.Errorf("SourceIdentityFor(%q) = %q, want %q", user, got, want)
+		}
+	}
+}
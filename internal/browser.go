@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrBrowserPrintOnly is returned by OpenURL when launching a browser is turned off, so
+// callers print the URL instead.
+var ErrBrowserPrintOnly = errors.New("opening a browser is disabled (browser.print_only)")
+
+// browserStartTimeout is how long OpenURL waits for a browser command to fail before
+// assuming it launched. Openers like xdg-open exit quickly, browsers keep running.
+const browserStartTimeout = 2 * time.Second
+
+// browserCommand returns the command line that opens rawURL on goos ("wsl" inside WSL). A custom command
+// gets the URL in place of every "{url}", or appended when it has none.
+func browserCommand(custom, goos, rawURL string) ([]string, error) {
+	if custom != "" {
+		fields := strings.Fields(custom)
+		replaced := false
+		for i, f := range fields {
+			if strings.Contains(f, "{url}") {
+				fields[i] = strings.ReplaceAll(f, "{url}", rawURL)
+				replaced = true
+			}
+		}
+		if !replaced {
+			fields = append(fields, rawURL)
+		}
+		return fields, nil
+	}
+
+	switch goos {
+	case "wsl":
+		// Open in the Windows browser: wslview (wslu) when installed, else PowerShell
+		if _, err := exec.LookPath("wslview"); err == nil {
+			return []string{"wslview", rawURL}, nil
+		}
+		return powershellStartProcess(rawURL), nil
+	case "darwin":
+		return []string{"open", rawURL}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return []string{"xdg-open", rawURL}, nil
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler", rawURL}, nil
+	}
+	return nil, fmt.Errorf("no default browser command for %s (set browser.command in %s)", goos, ConfigPath())
+}
+
+// OpenURL opens rawURL with browser.command from the config, or the platform's default
+// opener. It returns ErrBrowserPrintOnly when browser.print_only is set.
+func OpenURL(rawURL string) error {
+	cfg := CurrentConfig().Browser
+	if cfg.PrintOnly {
+		return ErrBrowserPrintOnly
+	}
+
+	goos := runtime.GOOS
+	if IsWSL() {
+		goos = "wsl"
+	}
+	args, err := browserCommand(cfg.Command, goos, rawURL)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%s failed: %w", args[0], err)
+		}
+	case <-time.After(browserStartTimeout):
+		// Still running, e.g. a browser started in the foreground
+	}
+	return nil
+}
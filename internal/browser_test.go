@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBrowserCommand(t *testing.T) {
+	url := "https://signin.aws.amazon.com/federation?Action=login"
+
+	tests := []struct {
+		custom string
+		goos   string
+		want   []string
+	}{
+		{"", "darwin", []string{"open", url}},
+		{"", "linux", []string{"xdg-open", url}},
+		{"", "windows", []string{"rundll32", "url.dll,FileProtocolHandler", url}},
+		{"wslview", "linux", []string{"wslview", url}},
+		{"firefox --new-window {url}", "linux", []string{"firefox", "--new-window", url}},
+		{"open -a Safari {url}", "darwin", []string{"open", "-a", "Safari", url}},
+	}
+	for _, tt := range tests {
+		got, err := browserCommand(tt.custom, tt.goos, url)
+		if err != nil {
+			t.Errorf("browserCommand(%q, %q) failed: %v", tt.custom, tt.goos, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("browserCommand(%q, %q) = %q, want %q", tt.custom, tt.goos, got, tt.want)
+		}
+	}
+
+	if _, err := browserCommand("", "plan9", url); err == nil {
+		t.Error("Expected an error for a platform without a default opener")
+	}
+}
+
+func TestWSLBrowserCommand(t *testing.T) {
+	args := powershellStartProcess("https://example.com/?a=1&b='x'")
+	want := "Start-Process 'https://example.com/?a=1&b=''x'''"
+	if args[0] != "powershell.exe" || args[len(args)-1] != want {
+		t.Errorf("Expected PowerShell to get %q, got %q", want, args)
+	}
+}
+
+func TestIsWSLKernel(t *testing.T) {
+	if !isWSLKernel("5.15.153.1-microsoft-standard-WSL2") || !isWSLKernel("4.4.0-19041-Microsoft") {
+		t.Error("Expected WSL kernels to be detected")
+	}
+	if isWSLKernel("6.8.0-45-generic") {
+		t.Error("Expected a regular kernel not to be detected as WSL")
+	}
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"sort"
+	"time"
+)
+
+// SourceRoot walks a session's source chain through stored cloudctl sessions and returns
+// the top-most one (typically an MFA session) plus how many hops away it is. A session
+// whose source is an AWS CLI profile is its own root.
+func SourceRoot(profile string, sessions map[string]*AWSSession) (string, int) {
+	current := profile
+	depth := 0
+	seen := map[string]bool{profile: true}
+	for {
+		s, ok := sessions[current]
+		if !ok || s.RoleArn == "MFA-Session" || s.SourceProfile == "" {
+			return current, depth
+		}
+		next, ok := sessions[s.SourceProfile]
+		if !ok || seen[next.Profile] {
+			return current, depth
+		}
+		seen[next.Profile] = true
+		current = next.Profile
+		depth++
+	}
+}
+
+// SourceGroup is a root session and the sessions that depend on it, nearest first.
+type SourceGroup struct {
+	Root       string
+	Dependents []*AWSSession
+}
+
+// GroupBySourceRoot groups sessions by SourceRoot so each root (and its MFA prompt) is
+// handled once. Groups are sorted by root name; dependents by chain depth, then name.
+func GroupBySourceRoot(selected []*AWSSession, all []*AWSSession) []SourceGroup {
+	byProfile := make(map[string]*AWSSession, len(all))
+	for _, s := range all {
+		byProfile[s.Profile] = s
+	}
+
+	depths := make(map[string]int)
+	groups := make(map[string]*SourceGroup)
+	for _, s := range selected {
+		root, depth := SourceRoot(s.Profile, byProfile)
+		g, ok := groups[root]
+		if !ok {
+			g = &SourceGroup{Root: root}
+			groups[root] = g
+		}
+		if s.Profile != root {
+			depths[s.Profile] = depth
+			g.Dependents = append(g.Dependents, s)
+		}
+	}
+
+	result := make([]SourceGroup, 0, len(groups))
+	for _, g := range groups {
+		sort.Slice(g.Dependents, func(i, j int) bool {
+			di, dj := depths[g.Dependents[i].Profile], depths[g.Dependents[j].Profile]
+			if di != dj {
+				// Sessions closer to the root are sources for the ones further away
+				return di < dj
+			}
+			return g.Dependents[i].Profile < g.Dependents[j].Profile
+		})
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Root < result[j].Root })
+	return result
+}
+
+// NeedsReauth returns the sessions whose source root is an expired MFA session, mapped
+// to that root. They may still be active, but they can't be refreshed silently any more:
+// the next refresh needs a new MFA login first.
+func NeedsReauth(sessions []*AWSSession, now time.Time) map[string]string {
+	byProfile := make(map[string]*AWSSession, len(sessions))
+	for _, s := range sessions {
+		byProfile[s.Profile] = s
+	}
+	result := make(map[string]string)
+	for _, s := range sessions {
+		root, _ := SourceRoot(s.Profile, byProfile)
+		if root == s.Profile {
+			continue
+		}
+		if r := byProfile[root]; r.RoleArn == "MFA-Session" && !now.Before(r.UsableUntil()) {
+			result[s.Profile] = root
+		}
+	}
+	return result
+}
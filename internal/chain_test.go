@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGroupBySourceRoot(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	all := []*AWSSession{
+		{Profile: "mfa", RoleArn: "MFA-Session", SourceProfile: "default", Expiration: past},
+		{Profile: "admin", RoleArn: "arn:aws:iam::111111111111:role/Admin", SourceProfile: "mfa", Expiration: past},
+		{Profile: "chained", RoleArn: "arn:aws:iam::222222222222:role/Deploy", SourceProfile: "admin", Expiration: past},
+		{Profile: "readonly", RoleArn: "arn:aws:iam::111111111111:role/ReadOnly", SourceProfile: "mfa", Expiration: past},
+		{Profile: "ci", RoleArn: "arn:aws:iam::333333333333:role/CI", SourceProfile: "default", Expiration: past},
+	}
+	// Selection order must not matter
+	selected := []*AWSSession{all[2], all[4], all[1], all[3]}
+
+	groups := GroupBySourceRoot(selected, all)
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d: %+v", len(groups), groups)
+	}
+	if groups[0].Root != "ci" || len(groups[0].Dependents) != 0 {
+		t.Errorf("Unexpected standalone group: %+v", groups[0])
+	}
+
+	mfa := groups[1]
+	if mfa.Root != "mfa" {
+		t.Fatalf("Expected mfa root, got %s", mfa.Root)
+	}
+	var order []string
+	for _, d := range mfa.Dependents {
+		order = append(order, d.Profile)
+	}
+	want := []string{"admin", "readonly", "chained"}
+	if len(order) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, order)
+		}
+	}
+}
+
+func TestSourceRootCycle(t *testing.T) {
+	sessions := map[string]*AWSSession{
+		"a": {Profile: "a", RoleArn: "arn:aws:iam::111111111111:role/A", SourceProfile: "b"},
+		"b": {Profile: "b", RoleArn: "arn:aws:iam::111111111111:role/B", SourceProfile: "a"},
+	}
+	if root, _ := SourceRoot("a", sessions); root != "b" {
+		t.Errorf("Expected cycle to stop at b, got %s", root)
+	}
+}
+
+func TestNeedsReauth(t *testing.T) {
+	now := time.Now()
+	sessions := []*AWSSession{
+		{Profile: "mfa", RoleArn: "MFA-Session", SourceProfile: "default", Expiration: now.Add(-time.Minute)},
+		{Profile: "admin", RoleArn: "arn:aws:iam::111111111111:role/Admin", SourceProfile: "mfa", Expiration: now.Add(time.Hour)},
+		{Profile: "chained", RoleArn: "arn:aws:iam::222222222222:role/Deploy", SourceProfile: "admin", Expiration: now.Add(-time.Hour)},
+		{Profile: "mfa2", RoleArn: "MFA-Session", SourceProfile: "default", Expiration: now.Add(time.Hour)},
+		{Profile: "dev", RoleArn: "arn:aws:iam::333333333333:role/Dev", SourceProfile: "mfa2", Expiration: now.Add(-time.Hour)},
+		{Profile: "ci", RoleArn: "arn:aws:iam::444444444444:role/CI", SourceProfile: "default", Expiration: now.Add(-time.Hour)},
+	}
+	got := NeedsReauth(sessions, now)
+	want := map[string]string{"admin": "mfa", "chained": "mfa"}
+	if len(got) != len(want) || got["admin"] != "mfa" || got["chained"] != "mfa" {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
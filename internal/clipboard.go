@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// clipboardTool is a copy/paste command pair for one platform or display server.
+type clipboardTool struct {
+	copy  []string
+	paste []string
+}
+
+// clipboardTools lists the supported tools in order of preference for the current OS.
+func clipboardTools() []clipboardTool {
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardTool{{copy: []string{"pbcopy"}, paste: []string{"pbpaste"}}}
+	case "windows":
+		return []clipboardTool{{
+			copy:  []string{"powershell", "-NoProfile", "-Command", "$input | Set-Clipboard"},
+			paste: []string{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"},
+		}}
+	}
+	var tools []clipboardTool
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, clipboardTool{copy: []string{"wl-copy"}, paste: []string{"wl-paste", "--no-newline"}})
+	}
+	return append(tools,
+		clipboardTool{copy: []string{"xclip", "-selection", "clipboard"}, paste: []string{"xclip", "-selection", "clipboard", "-o"}},
+		clipboardTool{copy: []string{"xsel", "--clipboard", "--input"}, paste: []string{"xsel", "--clipboard", "--output"}},
+	)
+}
+
+func findClipboardTool() (clipboardTool, error) {
+	for _, t := range clipboardTools() {
+		if _, err := exec.LookPath(t.copy[0]); err == nil {
+			return t, nil
+		}
+	}
+	return clipboardTool{}, fmt.Errorf("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+}
+
+// CopyToClipboard replaces the clipboard contents with text.
+func CopyToClipboard(text string) error {
+	tool, err := findClipboardTool()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(tool.copy[0], tool.copy[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+	return nil
+}
+
+// ReadClipboard returns the current clipboard contents.
+func ReadClipboard() (string, error) {
+	tool, err := findClipboardTool()
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.Command(tool.paste[0], tool.paste[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read clipboard: %w", err)
+	}
+	return string(out), nil
+}
+
+// ClipboardFingerprint identifies copied text without keeping it, so a later clear can
+// check the clipboard still holds what cloudctl put there.
+func ClipboardFingerprint(text string) string {
+	sum := sha256.Sum256([]byte(strings.TrimRight(text, "\r\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ClearClipboardIfUnchanged empties the clipboard if it still matches fingerprint, so
+// anything the user copied in the meantime is left alone. It reports whether it cleared.
+func ClearClipboardIfUnchanged(fingerprint string) (bool, error) {
+	current, err := ReadClipboard()
+	if err != nil {
+		return false, err
+	}
+	if ClipboardFingerprint(current) != fingerprint {
+		return false, nil
+	}
+	return true, CopyToClipboard("")
+}
+
+// ClipboardClearTimeout returns security.clipboard_clear_seconds; 0 means never clear.
+func ClipboardClearTimeout() time.Duration {
+	return time.Duration(CurrentConfig().Security.ClipboardClearSeconds) * time.Second
+}
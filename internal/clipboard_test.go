@@ -0,0 +1,23 @@
+package internal
+
+import "testing"
+
+func TestClipboardFingerprint(t *testing.T) {
+	copied := "export AWS_ACCESS_KEY_ID=ASIAEXAMPLE\n"
+
+	// Paste tools may add or drop the trailing newline
+	for _, pasted := range []string{copied, "export AWS_ACCESS_KEY_ID=ASIAEXAMPLE", "export AWS_ACCESS_KEY_ID=ASIAEXAMPLE\r\n"} {
+		if ClipboardFingerprint(pasted) != ClipboardFingerprint(copied) {
+			t.Errorf("Expected %q to match the copied text", pasted)
+		}
+	}
+	if ClipboardFingerprint("something else") == ClipboardFingerprint(copied) {
+		t.Error("Expected different text to have a different fingerprint")
+	}
+}
+
+func TestDefaultClipboardClearTimeout(t *testing.T) {
+	if got := DefaultConfig().Security.ClipboardClearSeconds; got != DefaultClipboardClearSeconds {
+		t.Errorf("Expected default of %d seconds, got %d", DefaultClipboardClearSeconds, got)
+	}
+}
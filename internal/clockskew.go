@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// clockSkewThreshold is how far the local clock may be off before it is blamed for a
+// rejected MFA code. Codes change every 30 seconds and AWS accepts the neighbouring
+// ones, so a clock further off than that gets codes refused.
+const clockSkewThreshold = 30 * time.Second
+
+// ClockSkewFromError returns how far the local clock is ahead of AWS (negative when it
+// is behind), from the Date header of the response behind an AWS API error. ok is false
+// when err carries no response with a date. The header has one-second resolution.
+func ClockSkewFromError(err error) (skew time.Duration, ok bool) {
+	var respErr *smithyhttp.ResponseError
+	if !errors.As(err, &respErr) || respErr.Response == nil {
+		return 0, false
+	}
+	return clockSkewFromResponse(respErr.Response.Response, time.Now())
+}
+
+func clockSkewFromResponse(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, false
+	}
+	return now.Sub(date), true
+}
+
+// ClockSkewed reports whether skew is large enough to make MFA codes fail.
+func ClockSkewed(skew time.Duration) bool {
+	return skew.Abs() > clockSkewThreshold
+}
+
+// DescribeClockSkew says how far and which way the local clock is off.
+func DescribeClockSkew(skew time.Duration) string {
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	return fmt.Sprintf("this machine's clock is %s %s AWS", skew.Abs().Round(time.Second), direction)
+}
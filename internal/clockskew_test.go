@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// stsErrorAt is an STS error whose response is dated date, wrapped the way the SDK
+// returns it.
+func stsErrorAt(date time.Time) error {
+	resp := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	resp.Header.Set("Date", date.UTC().Format(http.TimeFormat))
+	return &smithy.OperationError{ServiceID: "STS", OperationName: "GetSessionToken", Err: &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{Response: &smithyhttp.Response{Response: resp}, Err: errors.New("AccessDenied: MultiFactorAuthentication failed")},
+	}}
+}
+
+func TestClockSkewFromError(t *testing.T) {
+	skew, ok := ClockSkewFromError(fmt.Errorf("wrapped: %w", stsErrorAt(time.Now().Add(-3*time.Minute))))
+	if !ok || skew < 179*time.Second || skew > 182*time.Second {
+		t.Errorf("Expected the clock to be about 3m ahead, got %v (%v)", skew, ok)
+	}
+	if _, ok := ClockSkewFromError(errors.New("dial tcp: no route to host")); ok {
+		t.Error("Expected no skew from an error without a response")
+	}
+
+	if ClockSkewed(10*time.Second) || !ClockSkewed(-45*time.Second) {
+		t.Error("Unexpected skew threshold")
+	}
+	if got := DescribeClockSkew(-95 * time.Second); got != "this machine's clock is 1m35s behind AWS" {
+		t.Errorf("Unexpected description %q", got)
+	}
+}
+
+func TestMFAErrorNamesClockSkew(t *testing.T) {
+	err := newMFAError(stsErrorAt(time.Now().Add(2 * time.Minute)))
+	if !strings.Contains(err.Error(), "behind AWS") {
+		t.Errorf("Expected the clock skew in the error, got %q", err)
+	}
+	if err := newMFAError(stsErrorAt(time.Now())); strings.Contains(err.Error(), "clock") {
+		t.Errorf("Expected no clock skew in the error, got %q", err)
+	}
+}
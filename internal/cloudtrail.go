@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+)
+
+// mintEventNames are the STS calls that create the credentials cloudctl stores.
+var mintEventNames = []string{"AssumeRole", "GetSessionToken"}
+
+// MintEvent is a CloudTrail record of STS issuing temporary credentials.
+type MintEvent struct {
+	Time      time.Time
+	EventName string
+	Region    string
+	SourceIP  string
+	// Principal is the ARN of the identity that called STS.
+	Principal string
+	// RoleArn and SessionName are empty for GetSessionToken.
+	RoleArn     string
+	SessionName string
+	// AccessKeyID is the key of the issued credentials, "" when the call failed.
+	AccessKeyID string
+	ErrorCode   string
+
+	// Profile is the stored session this event minted, set by CorrelateMintEvents.
+	Profile string
+	// Current is set when the stored session still holds these exact credentials rather
+	// than newer ones minted for the same role and session name.
+	Current bool
+}
+
+// cloudTrailRecord is the subset of a CloudTrail event document used here.
+type cloudTrailRecord struct {
+	EventTime       time.Time `json:"eventTime"`
+	EventName       string    `json:"eventName"`
+	AWSRegion       string    `json:"awsRegion"`
+	SourceIPAddress string    `json:"sourceIPAddress"`
+	ErrorCode       string    `json:"errorCode"`
+	UserIdentity    struct {
+		ARN string `json:"arn"`
+	} `json:"userIdentity"`
+	RequestParameters struct {
+		RoleArn         string `json:"roleArn"`
+		RoleSessionName string `json:"roleSessionName"`
+	} `json:"requestParameters"`
+	ResponseElements struct {
+		Credentials struct {
+			AccessKeyID string `json:"accessKeyId"`
+		} `json:"credentials"`
+	} `json:"responseElements"`
+}
+
+// ParseMintEvent decodes the CloudTrail JSON document of an STS event.
+func ParseMintEvent(document string) (*MintEvent, error) {
+	var r cloudTrailRecord
+	if err := json.Unmarshal([]byte(document), &r); err != nil {
+		return nil, fmt.Errorf("failed to parse CloudTrail event: %w", err)
+	}
+	return &MintEvent{
+		Time:        r.EventTime,
+		EventName:   r.EventName,
+		Region:      r.AWSRegion,
+		SourceIP:    r.SourceIPAddress,
+		Principal:   r.UserIdentity.ARN,
+		RoleArn:     r.RequestParameters.RoleArn,
+		SessionName: r.RequestParameters.RoleSessionName,
+		AccessKeyID: r.ResponseElements.Credentials.AccessKeyID,
+		ErrorCode:   r.ErrorCode,
+	}, nil
+}
+
+// LookupMintEvents returns AssumeRole and GetSessionToken events since the given time,
+// newest first. CloudTrail only returns events of the client's region; calls to the
+// global STS endpoint are recorded in us-east-1.
+func LookupMintEvents(ctx context.Context, cfg aws.Config, since time.Time) ([]*MintEvent, error) {
+	client := cloudtrail.NewFromConfig(cfg)
+
+	var events []*MintEvent
+	for _, name := range mintEventNames {
+		paginator := cloudtrail.NewLookupEventsPaginator(client, &cloudtrail.LookupEventsInput{
+			StartTime: aws.Time(since),
+			LookupAttributes: []types.LookupAttribute{{
+				AttributeKey:   types.LookupAttributeKeyEventName,
+				AttributeValue: aws.String(name),
+			}},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("CloudTrail lookup failed: %w", err)
+			}
+			for _, e := range page.Events {
+				if e.CloudTrailEvent == nil {
+					continue
+				}
+				event, err := ParseMintEvent(*e.CloudTrailEvent)
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, event)
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.After(events[j].Time) })
+	return events, nil
+}
+
+// CorrelateMintEvents links events to stored sessions: first by the exact access key,
+// then by role ARN and session name for credentials that have since been refreshed.
+func CorrelateMintEvents(events []*MintEvent, sessions []*AWSSession) {
+	byKey := make(map[string]*AWSSession)
+	for _, s := range sessions {
+		if s.AccessKey != "" {
+			byKey[s.AccessKey] = s
+		}
+	}
+
+	for _, e := range events {
+		if s, ok := byKey[e.AccessKeyID]; ok && e.AccessKeyID != "" {
+			e.Profile, e.Current = s.Profile, true
+			continue
+		}
+		if e.RoleArn == "" {
+			continue
+		}
+		for _, s := range sessions {
+			sessionName := s.SessionName
+			if sessionName == "" {
+				// Refreshes use the profile as the session name
+				sessionName = s.Profile
+			}
+			if s.RoleArn == e.RoleArn && sessionName == e.SessionName {
+				e.Profile = s.Profile
+				break
+			}
+		}
+	}
+}
+
+// FilterMintEvents keeps events that were correlated with a stored session or made by
+// one of the given principals.
+func FilterMintEvents(events []*MintEvent, principals ...string) []*MintEvent {
+	mine := make(map[string]bool)
+	for _, p := range principals {
+		if p != "" {
+			mine[p] = true
+		}
+	}
+
+	var filtered []*MintEvent
+	for _, e := range events {
+		if e.Profile != "" || mine[e.Principal] {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+const assumeRoleEvent = `{
+  "eventTime": "2026-10-14T08:45:12Z",
+  "eventName": "AssumeRole",
+  "awsRegion": "us-east-1",
+  "sourceIPAddress": "203.0.113.10",
+  "userIdentity": {"type": "AssumedRole", "arn": "arn:aws:sts::111111111111:assumed-role/Base/alice"},
+  "requestParameters": {"roleArn": "arn:aws:iam::222222222222:role/Admin", "roleSessionName": "prod-admin", "durationSeconds": 3600},
+  "responseElements": {"credentials": {"accessKeyId": "ASIAEXAMPLE1", "expiration": "Oct 14, 2026, 9:45:12 AM"}}
+}`
+
+func TestParseMintEvent(t *testing.T) {
+	e, err := ParseMintEvent(assumeRoleEvent)
+	if err != nil {
+		t.Fatalf("ParseMintEvent failed: %v", err)
+	}
+	if !e.Time.Equal(time.Date(2026, 10, 14, 8, 45, 12, 0, time.UTC)) {
+		t.Errorf("Unexpected time %v", e.Time)
+	}
+	if e.EventName != "AssumeRole" || e.RoleArn != "arn:aws:iam::222222222222:role/Admin" || e.SessionName != "prod-admin" {
+		t.Errorf("Unexpected request fields: %+v", e)
+	}
+	if e.AccessKeyID != "ASIAEXAMPLE1" || e.Principal != "arn:aws:sts::111111111111:assumed-role/Base/alice" || e.SourceIP != "203.0.113.10" {
+		t.Errorf("Unexpected identity fields: %+v", e)
+	}
+
+	failed, err := ParseMintEvent(`{"eventName": "GetSessionToken", "errorCode": "AccessDenied", "userIdentity": {"arn": "arn:aws:iam::111111111111:user/alice"}}`)
+	if err != nil {
+		t.Fatalf("ParseMintEvent failed: %v", err)
+	}
+	if failed.ErrorCode != "AccessDenied" || failed.AccessKeyID != "" {
+		t.Errorf("Unexpected failed event: %+v", failed)
+	}
+
+	if _, err := ParseMintEvent("not json"); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestCorrelateAndFilterMintEvents(t *testing.T) {
+	sessions := []*AWSSession{
+		{Profile: "prod-admin", AccessKey: "ASIACURRENT", RoleArn: "arn:aws:iam::222222222222:role/Admin"},
+		{Profile: "mfa", AccessKey: "ASIAMFA", RoleArn: "MFA-Session"},
+	}
+	events := []*MintEvent{
+		{EventName: "AssumeRole", AccessKeyID: "ASIACURRENT", RoleArn: "arn:aws:iam::222222222222:role/Admin", SessionName: "prod-admin"},
+		{EventName: "AssumeRole", AccessKeyID: "ASIAOLD", RoleArn: "arn:aws:iam::222222222222:role/Admin", SessionName: "prod-admin"},
+		{EventName: "GetSessionToken", AccessKeyID: "ASIAMFA", Principal: "arn:aws:iam::111111111111:user/alice"},
+		{EventName: "AssumeRole", AccessKeyID: "ASIAOTHER", RoleArn: "arn:aws:iam::222222222222:role/Admin", SessionName: "bob", Principal: "arn:aws:iam::111111111111:user/bob"},
+		{EventName: "GetSessionToken", Principal: "arn:aws:iam::111111111111:user/alice", ErrorCode: "AccessDenied"},
+	}
+
+	CorrelateMintEvents(events, sessions)
+
+	if events[0].Profile != "prod-admin" || !events[0].Current {
+		t.Errorf("Expected exact key match, got %+v", events[0])
+	}
+	if events[1].Profile != "prod-admin" || events[1].Current {
+		t.Errorf("Expected role and session name match, got %+v", events[1])
+	}
+	if events[2].Profile != "mfa" || !events[2].Current {
+		t.Errorf("Expected MFA key match, got %+v", events[2])
+	}
+	if events[3].Profile != "" {
+		t.Errorf("Expected no match for another user's session, got %+v", events[3])
+	}
+
+	mine := FilterMintEvents(events, "arn:aws:iam::111111111111:user/alice")
+	if len(mine) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(mine))
+	}
+	for _, e := range mine {
+		if e.SessionName == "bob" {
+			t.Error("Another user's event should be filtered out")
+		}
+	}
+}
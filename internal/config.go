@@ -0,0 +1,562 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/chukul/cloudctl/internal/i18n"
+)
+
+var configPath = filepath.Join(storeDir, "config.json")
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// Config holds user preferences stored in ~/.cloudctl/config.json.
+// Every field is optional; a missing file means all defaults.
+type Config struct {
+	Display    DisplayConfig    `json:"display"`
+	Theme      ThemeConfig      `json:"theme"`
+	Daemon     DaemonConfig     `json:"daemon"`
+	Security   SecurityConfig   `json:"security"`
+	Encryption EncryptionConfig `json:"encryption"`
+	Limits     LimitsConfig     `json:"limits"`
+	Browser    BrowserConfig    `json:"browser"`
+	Console    ConsoleConfig    `json:"console"`
+	Remote     RemoteConfig     `json:"remote"`
+	StoreSync  StoreSyncConfig  `json:"store_sync"`
+	Sync       SyncConfig       `json:"sync"`
+	Network    NetworkConfig    `json:"network"`
+	Up         UpConfig         `json:"up"`
+	Serve      ServeConfig      `json:"serve"`
+	// Accounts holds per-account defaults keyed by the 12-digit account ID.
+	Accounts map[string]AccountConfig `json:"accounts,omitempty"`
+	// Endpoints holds local profiles bound to an AWS-compatible emulator such as
+	// LocalStack, keyed by profile name.
+	Endpoints map[string]EndpointProfile `json:"endpoints,omitempty"`
+}
+
+// AccountConfig holds defaults for sessions in one account, used whenever no --region
+// flag or role alias region is given.
+type AccountConfig struct {
+	// Region is stored with new sessions and exported as AWS_REGION.
+	Region string `json:"region,omitempty"`
+	// ConsoleRegion is the console home region; it defaults to Region.
+	ConsoleRegion string `json:"console_region,omitempty"`
+	// Env tags the account's environment; "prod" or "production" asks for confirmation
+	// before switch or exec use its sessions.
+	Env string `json:"env,omitempty"`
+}
+
+// EndpointProfile is a profile for a custom endpoint that never calls STS: switch exports
+// AWS_ENDPOINT_URL with static test keys instead of a stored session.
+type EndpointProfile struct {
+	// URL is exported as AWS_ENDPOINT_URL, e.g. "http://localhost:4566".
+	URL string `json:"url"`
+	// Region defaults to us-east-1.
+	Region string `json:"region,omitempty"`
+	// AccessKey and SecretKey default to "test", which LocalStack accepts.
+	AccessKey string `json:"access_key,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+}
+
+// DisplayConfig controls how values are rendered in the terminal, logs and synced files.
+type DisplayConfig struct {
+	// Timezone is "local" (default), "UTC" or an IANA name such as "Asia/Bangkok".
+	Timezone string `json:"timezone,omitempty"`
+	// ExpiryFormat is "relative" (45m remaining), "absolute" (timestamp) or "both" (default).
+	ExpiryFormat string `json:"expiry_format,omitempty"`
+	// Locale selects the message language ("en", "th", "ja"); empty means detect from LANG.
+	Locale string `json:"locale,omitempty"`
+	// ResolveIdentity stores the account, principal ARN and user ID from
+	// sts:GetCallerIdentity and the account alias with each login (one extra STS and
+	// IAM call; default true).
+	ResolveIdentity bool `json:"resolve_identity"`
+}
+
+// ThemeConfig selects the icons and colors used by status, prompt and login output.
+type ThemeConfig struct {
+	// Name is the base theme: "emoji" (default) or "ascii" for terminals without emoji fonts.
+	Name string `json:"name,omitempty"`
+	// Icons overrides individual icons of the base theme (e.g. "success": "OK").
+	Icons map[string]string `json:"icons,omitempty"`
+	// Colors overrides individual colors as "#RRGGBB" or an ANSI number; "" disables the color.
+	Colors map[string]string `json:"colors,omitempty"`
+}
+
+// DaemonConfig controls the background auto-refresh daemon.
+type DaemonConfig struct {
+	// Schedules proactively refresh profiles at cron times, evaluated in display.timezone.
+	Schedules []RefreshSchedule `json:"schedules,omitempty"`
+	// IdlePauseMinutes pauses expiry-driven refreshes once there has been no keyboard or
+	// mouse input for this many minutes; 0 (default) never pauses.
+	IdlePauseMinutes int `json:"idle_pause_minutes,omitempty"`
+}
+
+// RefreshSchedule refreshes Profile whenever the 5-field Cron expression matches
+// (e.g. "45 8 * * 1-5" for 08:45 on weekdays).
+type RefreshSchedule struct {
+	Profile string `json:"profile"`
+	Cron    string `json:"cron"`
+}
+
+// SecurityConfig controls how long decrypted access stays available.
+type SecurityConfig struct {
+	// AutoLockMinutes locks the store after this many minutes without a cloudctl command;
+	// `cloudctl unlock` is then required. 0 (default) disables the auto-lock.
+	AutoLockMinutes int `json:"auto_lock_minutes,omitempty"`
+	// ClipboardClearSeconds clears credentials or URLs copied with --clipboard after this
+	// many seconds (default 45); 0 leaves the clipboard alone.
+	ClipboardClearSeconds int `json:"clipboard_clear_seconds"`
+	// AllowInsecureStorage silences the startup warning about credentials in cloud-synced
+	// folders or with loose permissions, for users who accept the risk.
+	AllowInsecureStorage bool `json:"allow_insecure_storage,omitempty"`
+	// ProductionPatterns mark sessions as production when their profile name, role ARN
+	// or account ID matches one of these globs (e.g. "prod-*" or "*:role/Admin*").
+	ProductionPatterns []string `json:"production_patterns,omitempty"`
+	// DualControlRoles are role ARN globs whose login needs a teammate's approval token
+	// or, with DualControlDelayMinutes, a request made that long beforehand.
+	DualControlRoles []string `json:"dual_control_roles,omitempty"`
+	// DualControlDelayMinutes allows a dual-control login this many minutes after it was
+	// requested, without an approval; 0 (default) always requires an approval.
+	DualControlDelayMinutes int `json:"dual_control_delay_minutes,omitempty"`
+	// Approvers maps teammate names to the public keys printed by `cloudctl approve --init`.
+	Approvers map[string]string `json:"approvers,omitempty"`
+	// BreakGlassRoles are role ARN globs whose login needs a written justification, sent
+	// as a session tag with the user as SourceIdentity and kept in the audit log.
+	BreakGlassRoles []string `json:"break_glass_roles,omitempty"`
+	// ScrubDirs are project directories `cloudctl scrub` searches for .env files, in
+	// addition to the current directory.
+	ScrubDirs []string `json:"scrub_dirs,omitempty"`
+}
+
+// EncryptionConfig selects how the credential store is encrypted. Changing it requires
+// `cloudctl secret migrate` to re-encrypt existing sessions.
+type EncryptionConfig struct {
+	// Provider is "secret" (default), "age", "kms" or "tpm".
+	Provider string `json:"provider,omitempty"`
+	// AgeRecipients are the age public keys ("age1...") the store key is wrapped for.
+	AgeRecipients []string `json:"age_recipients,omitempty"`
+	// AgeIdentityFile holds the age private key used to unwrap the store key.
+	AgeIdentityFile string `json:"age_identity_file,omitempty"`
+	// KMSKeyID is the KMS key ID, ARN or alias that wraps the store key.
+	KMSKeyID string `json:"kms_key_id,omitempty"`
+	// KMSProfile is the shared AWS config profile used to call KMS (default chain if empty).
+	KMSProfile string `json:"kms_profile,omitempty"`
+	// KMSRegion overrides the region of the KMS bootstrap profile.
+	KMSRegion string `json:"kms_region,omitempty"`
+	// TPMDevice overrides the TPM device on Linux (default /dev/tpmrm0, then /dev/tpm0).
+	TPMDevice string `json:"tpm_device,omitempty"`
+	// SecretsManagerID is the Secrets Manager secret (name or ARN) holding the cloudctl
+	// secret, read when neither --secret nor CLOUDCTL_SECRET is set.
+	SecretsManagerID string `json:"secrets_manager_id,omitempty"`
+	// SecretsManagerProfile is the shared AWS config profile used to read it (default
+	// chain if empty).
+	SecretsManagerProfile string `json:"secrets_manager_profile,omitempty"`
+	// SecretsManagerRegion defaults to the region of an ARN, then of the profile.
+	SecretsManagerRegion string `json:"secrets_manager_region,omitempty"`
+}
+
+// UsesEnvelope reports whether the store key is wrapped by age, KMS or the TPM rather
+// than derived from the cloudctl secret.
+func (c EncryptionConfig) UsesEnvelope() bool {
+	return c.Provider == ProviderAge || c.Provider == ProviderKMS || c.Provider == ProviderTPM
+}
+
+// BrowserConfig controls how console and sign-in URLs are opened.
+type BrowserConfig struct {
+	// Command opens URLs instead of the platform default (e.g. "wslview" or
+	// "firefox --new-window {url}"); the URL replaces {url} or is appended.
+	Command string `json:"command,omitempty"`
+	// PrintOnly never launches a browser and prints URLs instead, for SSH sessions.
+	PrintOnly bool `json:"print_only,omitempty"`
+	// Containers opens each console sign-in in a Firefox container named after the
+	// profile, so several accounts stay signed in side by side. It needs the "Open
+	// external links in a container" extension.
+	Containers bool `json:"containers,omitempty"`
+}
+
+// ConsoleConfig selects the AWS sign-in endpoint console federation uses, for orgs that
+// block the global one.
+type ConsoleConfig struct {
+	// Signin is "global" (default, signin.aws.amazon.com), "regional"
+	// (<region>.signin.aws.amazon.com) or an https:// federation URL in which {region}
+	// is replaced.
+	Signin string `json:"signin,omitempty"`
+	// SigninOverrides sets Signin per partition ("aws", "aws-cn", "aws-us-gov") or
+	// region; a region entry wins over its partition.
+	SigninOverrides map[string]string `json:"signin_overrides,omitempty"`
+}
+
+// RemoteConfig points to shared state that lets cloudctl on several machines (say a
+// laptop and a jump box) see each other's sessions and avoid refreshing a profile twice.
+type RemoteConfig struct {
+	// URL is "s3://bucket/key" or "ssm:/parameter/name"; empty (default) disables it.
+	URL string `json:"url,omitempty"`
+	// Profile is the shared AWS config profile used to reach it (default chain if empty).
+	Profile string `json:"profile,omitempty"`
+	// Region overrides the region of that profile.
+	Region string `json:"region,omitempty"`
+	// Host is the name this machine is recorded under (default: the hostname).
+	Host string `json:"host,omitempty"`
+}
+
+// StoreSyncConfig points to a copy of the encrypted store, role aliases and MFA devices
+// that several machines of one user sync with, so they all see the same sessions.
+type StoreSyncConfig struct {
+	// URL is "s3://bucket/key" or "ssm:/parameter/name"; empty (default) disables it.
+	URL string `json:"url,omitempty"`
+	// Profile is the shared AWS config profile used to reach it (default chain if empty).
+	Profile string `json:"profile,omitempty"`
+	// Region overrides the region of that profile.
+	Region string `json:"region,omitempty"`
+}
+
+// SyncConfig controls how sync writes sessions for the AWS CLI and SDKs.
+type SyncConfig struct {
+	// Mode is "credentials" (default) to write sections of ~/.aws/credentials, or
+	// "files" to write each profile to its own file, read through a credential_process
+	// profile in ~/.aws/config, so a refresh only rewrites that profile's file.
+	Mode string `json:"mode,omitempty"`
+	// FileTemplate is the path of a profile's file in "files" mode; {profile} is
+	// replaced by the profile name (default ~/.aws/credentials.d/{profile}).
+	FileTemplate string `json:"file_template,omitempty"`
+}
+
+// UpConfig is the work session `cloudctl up` starts: one MFA login, the roles assumed
+// from it, then sync, kubeconfigs and the daemon.
+type UpConfig struct {
+	// Source is the AWS CLI profile (or "env", "instance") with long-lived keys.
+	Source string `json:"source,omitempty"`
+	// MFADevice is the MFA device alias or ARN; empty assumes the roles from Source
+	// directly.
+	MFADevice string `json:"mfa_device,omitempty"`
+	// MFAProfile is the name the MFA session is stored as (default "mfa-session").
+	MFAProfile string `json:"mfa_profile,omitempty"`
+	// Region is the region of the STS endpoint for the MFA login (default ap-southeast-1).
+	Region string `json:"region,omitempty"`
+	// Roles are the role logins of the session.
+	Roles []UpRole `json:"roles,omitempty"`
+	// Sync writes the active sessions to ~/.aws/credentials afterwards (default true).
+	Sync bool `json:"sync"`
+	// Kubeconfig lists the EKS clusters whose kubeconfig entries are updated with
+	// `aws eks update-kubeconfig`.
+	Kubeconfig []UpCluster `json:"kubeconfig,omitempty"`
+	// Daemon starts the auto-refresh daemon if it isn't running (default true).
+	Daemon bool `json:"daemon"`
+}
+
+// UpRole is a role login of the work session. Region and Duration fall back to the
+// alias and account defaults like `cloudctl login`.
+type UpRole struct {
+	// Role is a role alias or ARN.
+	Role string `json:"role"`
+	// Profile is the name the session is stored as (default: derived like login).
+	Profile  string `json:"profile,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Duration int32  `json:"duration,omitempty"`
+}
+
+// UpCluster is an EKS cluster whose kubeconfig entry `cloudctl up` updates.
+type UpCluster struct {
+	// Profile is the session the kubeconfig authenticates with; it must be synced.
+	Profile string `json:"profile"`
+	Cluster string `json:"cluster"`
+	// Region defaults to the session's region.
+	Region string `json:"region,omitempty"`
+	// Alias names the kubeconfig context (default: the cluster ARN).
+	Alias string `json:"alias,omitempty"`
+}
+
+// ServeConfig configures `cloudctl serve`, the credential broker run on a team bastion:
+// which identity it assumes roles from and which OIDC users may assume which role.
+type ServeConfig struct {
+	// Source is the bastion's AWS identity: an AWS CLI profile, "env" or "instance"
+	// (default "instance").
+	Source string `json:"source,omitempty"`
+	// Region is the region of the STS endpoint (default ap-southeast-1).
+	Region string `json:"region,omitempty"`
+	// Audience is the OIDC client ID that ID tokens must be issued to.
+	Audience string `json:"audience,omitempty"`
+	// UserClaim is the token claim used as user name and source identity (default "email").
+	UserClaim string `json:"user_claim,omitempty"`
+	// GroupsClaim is the token claim listing the user's groups (default "groups").
+	GroupsClaim string `json:"groups_claim,omitempty"`
+	// MaxDurationMinutes caps the sessions handed out (default 60).
+	MaxDurationMinutes int `json:"max_duration_minutes,omitempty"`
+	// Grants list who may assume which role.
+	Grants []ServeGrant `json:"grants,omitempty"`
+}
+
+// ServeGrant lets the listed users and members of the listed groups assume a role.
+type ServeGrant struct {
+	// Role is a role alias or ARN.
+	Role   string   `json:"role"`
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// NetworkConfig bounds how long cloudctl waits on AWS.
+type NetworkConfig struct {
+	// CallTimeoutSeconds fails an AWS API call (STS, IAM, KMS, remote state...) that
+	// takes longer than this many seconds, retries included (default 30); 0 waits forever.
+	CallTimeoutSeconds int `json:"call_timeout_seconds"`
+}
+
+// CallTimeout returns the per-call limit, or 0 for none.
+func (c NetworkConfig) CallTimeout() time.Duration {
+	return time.Duration(c.CallTimeoutSeconds) * time.Second
+}
+
+// LimitsConfig holds org norms for concurrent sessions and session length. status warns
+// when active sessions approach them; 0 (default) disables a check.
+type LimitsConfig struct {
+	// MaxSessionsPerAccount is the number of concurrent sessions allowed in one account.
+	MaxSessionsPerAccount int `json:"max_sessions_per_account,omitempty"`
+	// MaxSessionsPerRole is the number of concurrent sessions allowed for one role.
+	MaxSessionsPerRole int `json:"max_sessions_per_role,omitempty"`
+	// MaxDurationMinutes is the longest session duration the org expects.
+	MaxDurationMinutes int `json:"max_duration_minutes,omitempty"`
+}
+
+// AccountRegion returns the configured default region of an account, or "".
+func (c *Config) AccountRegion(accountID string) string {
+	return c.Accounts[accountID].Region
+}
+
+// AccountConsoleRegion returns the configured console home region of an account,
+// falling back to its default region, or "".
+func (c *Config) AccountConsoleRegion(accountID string) string {
+	a := c.Accounts[accountID]
+	if a.ConsoleRegion != "" {
+		return a.ConsoleRegion
+	}
+	return a.Region
+}
+
+// Endpoint returns the endpoint profile with this name, with defaults filled in.
+func (c *Config) Endpoint(name string) (EndpointProfile, bool) {
+	e, ok := c.Endpoints[name]
+	if !ok {
+		return e, false
+	}
+	if e.Region == "" {
+		e.Region = "us-east-1"
+	}
+	if e.AccessKey == "" {
+		e.AccessKey = "test"
+	}
+	if e.SecretKey == "" {
+		e.SecretKey = "test"
+	}
+	return e, true
+}
+
+// DefaultClipboardClearSeconds mirrors the clipboard timeout of common password managers.
+const DefaultClipboardClearSeconds = 45
+
+// DefaultCallTimeoutSeconds is long enough for STS retries on a slow link, short enough
+// that a hung endpoint doesn't look like a hung cloudctl.
+const DefaultCallTimeoutSeconds = 30
+
+// DefaultConfig returns the configuration used when no config file exists.
+func DefaultConfig() *Config {
+	return &Config{
+		Display: DisplayConfig{
+			Timezone:        "local",
+			ExpiryFormat:    ExpiryFormatBoth,
+			ResolveIdentity: true,
+		},
+		Theme: ThemeConfig{
+			Name: ThemeEmoji,
+		},
+		Security: SecurityConfig{
+			ClipboardClearSeconds: DefaultClipboardClearSeconds,
+		},
+		Network: NetworkConfig{
+			CallTimeoutSeconds: DefaultCallTimeoutSeconds,
+		},
+		Up: UpConfig{
+			Sync:   true,
+			Daemon: true,
+		},
+	}
+}
+
+var (
+	loadedConfig     *Config
+	loadedConfigErr  error
+	loadedConfigOnce sync.Once
+)
+
+// LoadConfig reads the config file, filling in defaults for anything not set.
+func LoadConfig() (*Config, error) {
+	b, err := os.ReadFile(configPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		// CLOUDCTL_* overrides still apply without a config file
+		b = []byte("{}")
+	}
+	return ParseConfig(b, false)
+}
+
+// ParseConfig parses and validates config file contents. ${VAR} references in string
+// values are expanded from the environment first, then CLOUDCTL_<SECTION>_<KEY>
+// variables override keys. With strict, keys cloudctl doesn't know are errors instead
+// of being ignored.
+func ParseConfig(b []byte, strict bool) (*Config, error) {
+	cfg := DefaultConfig()
+	if err := decodeConfig(b, cfg, strict); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", configPath, err)
+	}
+	if cfg.Display.Timezone == "" {
+		cfg.Display.Timezone = "local"
+	}
+	if cfg.Display.ExpiryFormat == "" {
+		cfg.Display.ExpiryFormat = ExpiryFormatBoth
+	}
+	if _, err := ResolveTimezone(cfg.Display.Timezone); err != nil {
+		return nil, fmt.Errorf("invalid display.timezone in %s: %w", configPath, err)
+	}
+	if err := ValidateExpiryFormat(cfg.Display.ExpiryFormat); err != nil {
+		return nil, fmt.Errorf("invalid display.expiry_format in %s: %w", configPath, err)
+	}
+	if cfg.Theme.Name == "" {
+		cfg.Theme.Name = ThemeEmoji
+	}
+	if _, err := ResolveTheme(cfg.Theme); err != nil {
+		return nil, fmt.Errorf("invalid theme in %s: %w", configPath, err)
+	}
+	if _, err := ParseSchedules(cfg.Daemon.Schedules); err != nil {
+		return nil, fmt.Errorf("invalid daemon.schedules in %s: %w", configPath, err)
+	}
+	if cfg.Security.AutoLockMinutes < 0 {
+		return nil, fmt.Errorf("invalid security.auto_lock_minutes in %s: must not be negative", configPath)
+	}
+	if cfg.Security.ClipboardClearSeconds < 0 {
+		return nil, fmt.Errorf("invalid security.clipboard_clear_seconds in %s: must not be negative", configPath)
+	}
+	if cfg.Security.DualControlDelayMinutes < 0 {
+		return nil, fmt.Errorf("invalid security.dual_control_delay_minutes in %s: must not be negative", configPath)
+	}
+	if err := ValidateApprovers(cfg.Security.Approvers); err != nil {
+		return nil, fmt.Errorf("invalid security.approvers in %s: %w", configPath, err)
+	}
+	if err := ValidateEncryptionConfig(cfg.Encryption); err != nil {
+		return nil, fmt.Errorf("invalid encryption settings in %s: %w", configPath, err)
+	}
+	if cfg.Daemon.IdlePauseMinutes < 0 {
+		return nil, fmt.Errorf("invalid daemon.idle_pause_minutes in %s: must not be negative", configPath)
+	}
+	for id := range cfg.Accounts {
+		if !accountIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid accounts entry '%s' in %s: keys must be 12-digit account IDs", id, configPath)
+		}
+	}
+	for name, e := range cfg.Endpoints {
+		if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoints.%s.url in %s: must be an http:// or https:// URL", name, configPath)
+		}
+	}
+	if err := ValidateConsoleConfig(cfg.Console); err != nil {
+		return nil, fmt.Errorf("invalid console settings in %s: %w", configPath, err)
+	}
+	if cfg.Remote.URL != "" {
+		if err := ValidateRemoteURL(cfg.Remote.URL); err != nil {
+			return nil, fmt.Errorf("invalid remote.url in %s: %w", configPath, err)
+		}
+	}
+	if cfg.StoreSync.URL != "" {
+		if err := ValidateRemoteURL(cfg.StoreSync.URL); err != nil {
+			return nil, fmt.Errorf("invalid store_sync.url in %s: %w", configPath, err)
+		}
+		if cfg.StoreSync.URL == cfg.Remote.URL {
+			return nil, fmt.Errorf("invalid store_sync.url in %s: must not be the same as remote.url", configPath)
+		}
+	}
+	if err := ValidateSyncConfig(cfg.Sync); err != nil {
+		return nil, fmt.Errorf("invalid sync settings in %s: %w", configPath, err)
+	}
+	if err := ValidateServeConfig(cfg.Serve); err != nil {
+		return nil, fmt.Errorf("invalid serve settings in %s: %w", configPath, err)
+	}
+	if err := ValidateUpConfig(cfg.Up); err != nil {
+		return nil, fmt.Errorf("invalid up settings in %s: %w", configPath, err)
+	}
+	if cfg.Network.CallTimeoutSeconds < 0 {
+		return nil, fmt.Errorf("invalid network.call_timeout_seconds in %s: must not be negative", configPath)
+	}
+	if cfg.Limits.MaxSessionsPerAccount < 0 || cfg.Limits.MaxSessionsPerRole < 0 || cfg.Limits.MaxDurationMinutes < 0 {
+		return nil, fmt.Errorf("invalid limits in %s: values must not be negative", configPath)
+	}
+	return cfg, nil
+}
+
+// SaveConfig writes the config file.
+func SaveConfig(cfg *Config) error {
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return os.WriteFile(configPath, b, 0600)
+}
+
+// CurrentConfig returns the config loaded once per process. A broken config file
+// falls back to defaults (with a warning) so display settings never block a command;
+// see ConfigError for what must not run on those defaults.
+func CurrentConfig() *Config {
+	loadedConfigOnce.Do(func() {
+		cfg, err := LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v (using defaults)\n", err)
+			cfg = DefaultConfig()
+		}
+		loadedConfig, loadedConfigErr = cfg, err
+	})
+	return loadedConfig
+}
+
+// ConfigError returns why the config file couldn't be loaded, when CurrentConfig fell
+// back to defaults. The defaults turn off dual control, break-glass roles and the
+// auto-lock, so logins and refreshes refuse to run on them.
+func ConfigError() error {
+	CurrentConfig()
+	return loadedConfigErr
+}
+
+// requireConfig fails with the config error, if any, before security settings are used.
+func requireConfig() error {
+	if err := ConfigError(); err != nil {
+		return fmt.Errorf("%w; fix it before logging in or refreshing, as its security settings can't be checked", err)
+	}
+	return nil
+}
+
+// ConfigPath returns the location of the config file.
+func ConfigPath() string {
+	return configPath
+}
+
+// LocalesDir is where packagers and users can drop <locale>.json message overrides.
+func LocalesDir() string {
+	return filepath.Join(filepath.Dir(configPath), "locales")
+}
+
+// ApplyLocale loads message overrides and selects the display language from
+// display.locale, falling back to the environment (CLOUDCTL_LANG, LC_ALL, LANG).
+func ApplyLocale() {
+	if err := i18n.LoadOverrides(LocalesDir()); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Ignoring message overrides: %v\n", err)
+	}
+	i18n.SetLocale(i18n.DetectLocale(CurrentConfig().Display.Locale))
+}
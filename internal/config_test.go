@@ -0,0 +1,360 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper to point the config file at a temp directory
+func setupTestConfig(t *testing.T, content string) {
+	dir := t.TempDir()
+	originalPath := configPath
+	configPath = filepath.Join(dir, "config.json")
+	t.Cleanup(func() {
+		configPath = originalPath
+	})
+
+	if content != "" {
+		if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+}
+
+// setTestConfig replaces the process-wide config with defaults adjusted by
+// mutate, restoring the previous config when the test finishes.
+func setTestConfig(t *testing.T, mutate func(*Config)) *Config {
+	t.Helper()
+	loadedConfigOnce.Do(func() {})
+	original := loadedConfig
+	if original == nil {
+		// The once is spent now, so later tests must not find a nil config
+		original = DefaultConfig()
+	}
+	cfg := DefaultConfig()
+	if mutate != nil {
+		mutate(cfg)
+	}
+	originalErr := loadedConfigErr
+	loadedConfig, loadedConfigErr = cfg, nil
+	t.Cleanup(func() { loadedConfig, loadedConfigErr = original, originalErr })
+	return cfg
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	setupTestConfig(t, "")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Display.Timezone != "local" {
+		t.Errorf("Expected default timezone 'local', got %q", cfg.Display.Timezone)
+	}
+}
+
+func TestLoadConfigTimezone(t *testing.T) {
+	setupTestConfig(t, `{"display": {"timezone": "Asia/Bangkok"}}`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Display.Timezone != "Asia/Bangkok" {
+		t.Errorf("Expected Asia/Bangkok, got %q", cfg.Display.Timezone)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	setupTestConfig(t, `{"display": {"timezone": "Mars/Olympus"}}`)
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected error for unknown timezone, got nil")
+	}
+
+	setupTestConfig(t, `{ invalid json`)
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected error for corrupt config, got nil")
+	}
+}
+
+func TestSaveConfigRoundTrip(t *testing.T) {
+	setupTestConfig(t, "")
+
+	cfg := DefaultConfig()
+	cfg.Display.Timezone = "UTC"
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if loaded.Display.Timezone != "UTC" {
+		t.Errorf("Expected UTC, got %q", loaded.Display.Timezone)
+	}
+}
+
+func TestResolveTimezone(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", "Local", false},
+		{"local", "Local", false},
+		{"UTC", "UTC", false},
+		{"Asia/Bangkok", "Asia/Bangkok", false},
+		{"Not/AZone", "", true},
+	}
+
+	for _, tt := range tests {
+		loc, err := ResolveTimezone(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ResolveTimezone(%q): expected error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ResolveTimezone(%q) failed: %v", tt.name, err)
+			continue
+		}
+		if loc.String() != tt.want {
+			t.Errorf("ResolveTimezone(%q) = %s, want %s", tt.name, loc, tt.want)
+		}
+	}
+
+	bkk, _ := ResolveTimezone("Asia/Bangkok")
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := ts.In(bkk).Format(DisplayTimeFormatZone); got != "2025-01-01 07:00:00 +07:00" {
+		t.Errorf("Unexpected Bangkok formatting: %s", got)
+	}
+}
+
+func TestFormatExpiry(t *testing.T) {
+	exp := time.Now().Add(90*time.Minute + 30*time.Second)
+
+	tests := []struct {
+		format string
+		want   []string
+		absent []string
+	}{
+		{ExpiryFormatRelative, []string{"1h30m remaining"}, []string{exp.Format("2006")}},
+		{ExpiryFormatAbsolute, []string{FormatTime(exp)}, []string{"remaining"}},
+		{ExpiryFormatBoth, []string{FormatTime(exp), "1h30m remaining"}, nil},
+	}
+
+	for _, tt := range tests {
+		setTestConfig(t, func(c *Config) { c.Display.ExpiryFormat = tt.format })
+
+		got := FormatExpiry(exp)
+		for _, w := range tt.want {
+			if !strings.Contains(got, w) {
+				t.Errorf("%s: expected %q in %q", tt.format, w, got)
+			}
+		}
+		for _, a := range tt.absent {
+			if strings.Contains(got, a) {
+				t.Errorf("%s: did not expect %q in %q", tt.format, a, got)
+			}
+		}
+	}
+
+	if got := FormatRelative(time.Now().Add(-5 * time.Minute)); !strings.HasPrefix(got, "expired") {
+		t.Errorf("Expected expired relative text, got %q", got)
+	}
+	if got := FormatISO(time.Date(2025, 1, 1, 7, 0, 0, 0, time.FixedZone("ICT", 7*3600))); got != "2025-01-01T00:00:00Z" {
+		t.Errorf("Unexpected ISO output: %s", got)
+	}
+}
+
+func TestLoadConfigAccounts(t *testing.T) {
+	setupTestConfig(t, `{"accounts": {
+		"222222222222": {"region": "eu-west-1"},
+		"333333333333": {"region": "us-west-2", "console_region": "us-east-1"}
+	}}`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := cfg.AccountRegion("222222222222"); got != "eu-west-1" {
+		t.Errorf("Expected eu-west-1, got %q", got)
+	}
+	if got := cfg.AccountConsoleRegion("222222222222"); got != "eu-west-1" {
+		t.Errorf("Expected console region to fall back to eu-west-1, got %q", got)
+	}
+	if got := cfg.AccountConsoleRegion("333333333333"); got != "us-east-1" {
+		t.Errorf("Expected us-east-1, got %q", got)
+	}
+	if got := cfg.AccountRegion("444444444444"); got != "" {
+		t.Errorf("Expected no region for an unmapped account, got %q", got)
+	}
+
+	setupTestConfig(t, `{"accounts": {"prod": {"region": "eu-west-1"}}}`)
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected error for a non-numeric account key")
+	}
+}
+
+func TestLoadConfigEndpoints(t *testing.T) {
+	setupTestConfig(t, `{"endpoints": {
+		"localstack": {"url": "http://localhost:4566"},
+		"minio": {"url": "https://minio.test:9000", "region": "eu-west-1", "access_key": "minio", "secret_key": "minio123"}
+	}}`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	e, ok := cfg.Endpoint("localstack")
+	if !ok || e.Region != "us-east-1" || e.AccessKey != "test" || e.SecretKey != "test" {
+		t.Errorf("Expected LocalStack defaults, got %+v", e)
+	}
+	if e, _ := cfg.Endpoint("minio"); e.Region != "eu-west-1" || e.AccessKey != "minio" {
+		t.Errorf("Expected configured values to be kept, got %+v", e)
+	}
+	if _, ok := cfg.Endpoint("prod"); ok {
+		t.Error("Expected no endpoint profile for an unknown name")
+	}
+
+	setupTestConfig(t, `{"endpoints": {"localstack": {"url": "localhost:4566"}}}`)
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "endpoints.localstack.url") {
+		t.Errorf("Expected error for a URL without scheme, got %v", err)
+	}
+}
+
+func TestLoadConfigConsole(t *testing.T) {
+	setupTestConfig(t, `{"console": {"signin": "regional", "signin_overrides": {"aws-cn": "global"}}}`)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Console.Signin != SigninRegional || cfg.Console.SigninOverrides["aws-cn"] != SigninGlobal {
+		t.Errorf("Unexpected console settings %+v", cfg.Console)
+	}
+
+	setupTestConfig(t, `{"console": {"signin_overrides": {"eu-west-1": "signin.example.com"}}}`)
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "console.signin_overrides.eu-west-1") {
+		t.Errorf("Expected error for an endpoint without https://, got %v", err)
+	}
+}
+
+func TestConfigEnvInterpolation(t *testing.T) {
+	t.Setenv("TEST_CLOUDCTL_TZ", "Asia/Tokyo")
+	setupTestConfig(t, `{"display": {"timezone": "${TEST_CLOUDCTL_TZ}", "locale": "${TEST_CLOUDCTL_UNSET:-ja}"}}`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Display.Timezone != "Asia/Tokyo" || cfg.Display.Locale != "ja" {
+		t.Errorf("Expected expanded values, got %q and %q", cfg.Display.Timezone, cfg.Display.Locale)
+	}
+
+	setupTestConfig(t, `{"encryption": {"kms_key_id": "${TEST_CLOUDCTL_UNSET}"}}`)
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "encryption.kms_key_id") {
+		t.Errorf("Expected error naming the key, got %v", err)
+	}
+}
+
+func TestParseConfigStrict(t *testing.T) {
+	setupTestConfig(t, "")
+
+	data := []byte(`{"display": {"timezon": "UTC"}}`)
+	if _, err := ParseConfig(data, false); err != nil {
+		t.Errorf("Expected unknown keys to be ignored when loading, got %v", err)
+	}
+	_, err := ParseConfig(data, true)
+	if err == nil || !strings.Contains(err.Error(), `"display.timezone"`) {
+		t.Errorf("Expected a suggestion for the misspelled key, got %v", err)
+	}
+
+	_, err = ParseConfig([]byte("{\n  \"limits\": {\"max_sessions_per_role\": \"two\"}\n}"), true)
+	if err == nil || !strings.Contains(err.Error(), "limits.max_sessions_per_role must be a whole number") {
+		t.Errorf("Expected a type error naming the key, got %v", err)
+	}
+
+	_, err = ParseConfig([]byte("{\n  \"display\": {,}\n}"), true)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected the line of the syntax error, got %v", err)
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	setupTestConfig(t, `{"display": {"locale": "th"}}`)
+
+	if err := SetConfigValue("security.auto_lock_minutes", "30"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetConfigValue("accounts.123456789012.region", "eu-west-1"); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Security.AutoLockMinutes != 30 || cfg.AccountRegion("123456789012") != "eu-west-1" || cfg.Display.Locale != "th" {
+		t.Errorf("Expected values to be set and others kept, got %+v", cfg)
+	}
+	if v, _ := ConfigValue(cfg, "security.auto_lock_minutes"); v != float64(30) {
+		t.Errorf("Expected ConfigValue to return 30, got %v", v)
+	}
+
+	// Invalid values must not be written
+	if err := SetConfigValue("display.timezone", "Mars/Olympus"); err == nil {
+		t.Error("Expected an invalid timezone to be rejected")
+	}
+	if err := SetConfigValue("security.auto_lock_minutes", "soon"); err == nil {
+		t.Error("Expected a non-number to be rejected")
+	}
+	if err := SetConfigValue("display.colour", "red"); err == nil {
+		t.Error("Expected an unknown key to be rejected")
+	}
+
+	if err := SetConfigValue("display.locale", ""); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := LoadConfig(); cfg.Display.Locale != "" || cfg.Display.Timezone != "local" {
+		t.Errorf("Expected locale to be removed, got %+v", cfg.Display)
+	}
+}
+
+func TestConfigEnvOverrides(t *testing.T) {
+	setupTestConfig(t, `{"display": {"timezone": "Asia/Bangkok", "locale": "th"}}`)
+	t.Setenv("CLOUDCTL_DISPLAY_TIMEZONE", "UTC")
+	t.Setenv("CLOUDCTL_SECURITY_AUTO_LOCK_MINUTES", "15")
+	t.Setenv("CLOUDCTL_SECURITY_ALLOW_INSECURE_STORAGE", "true")
+	t.Setenv("CLOUDCTL_ENCRYPTION_PROVIDER", "age")
+	t.Setenv("CLOUDCTL_ENCRYPTION_AGE_RECIPIENTS", "age1qyqszqgpqyqszqgpqyqszqgpqyqszqgpqyqszqgpqyqszqgpqyqs3290gq, ")
+	t.Setenv("CLOUDCTL_ENCRYPTION_AGE_IDENTITY_FILE", "/keys/age.txt")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Display.Timezone != "UTC" || cfg.Display.Locale != "th" {
+		t.Errorf("Expected env to override only the timezone, got %+v", cfg.Display)
+	}
+	if cfg.Security.AutoLockMinutes != 15 || !cfg.Security.AllowInsecureStorage {
+		t.Errorf("Expected numeric and boolean overrides, got %+v", cfg.Security)
+	}
+	if cfg.Encryption.Provider != ProviderAge || len(cfg.Encryption.AgeRecipients) != 1 {
+		t.Errorf("Expected provider and comma-separated recipients, got %+v", cfg.Encryption)
+	}
+
+	// Overrides apply without a config file too
+	setupTestConfig(t, "")
+	if cfg, err := LoadConfig(); err != nil || cfg.Security.AutoLockMinutes != 15 {
+		t.Errorf("Expected overrides without a file, got %+v, %v", cfg, err)
+	}
+
+	t.Setenv("CLOUDCTL_SECURITY_AUTO_LOCK_MINUTES", "soon")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "CLOUDCTL_SECURITY_AUTO_LOCK_MINUTES") {
+		t.Errorf("Expected an error naming the variable, got %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigExportVersion is the current config export format version.
+const ConfigExportVersion = 1
+
+// machineConfigKeys are settings that only make sense on the machine they were set on, or
+// hold credentials. --sanitized leaves them out, and import never applies them.
+var machineConfigKeys = []string{
+	"encryption.age_identity_file",
+	"encryption.tpm_device",
+	"remote.host",
+	"security.allow_insecure_storage",
+	"endpoints.<key>.access_key",
+	"endpoints.<key>.secret_key",
+}
+
+// ConfigExport is the YAML document written by `config export`: config file settings as
+// written (${VAR} references are kept), role aliases and MFA device aliases.
+type ConfigExport struct {
+	Version    int                  `json:"version"`
+	Settings   map[string]any       `json:"settings,omitempty"`
+	Roles      map[string]RoleAlias `json:"roles,omitempty"`
+	MFADevices map[string]string    `json:"mfa_devices,omitempty"`
+}
+
+// ExportConfig collects the config file, role aliases and MFA devices. With sanitized,
+// machine-specific settings and credentials are left out, so the export can be committed
+// to a shared dotfiles repo; stored sessions are never part of it.
+func ExportConfig(sanitized bool) (*ConfigExport, error) {
+	b, err := ReadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	var settings map[string]any
+	if err := json.Unmarshal(b, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", configPath, describeJSONError(b, err))
+	}
+	if sanitized {
+		removeMachineConfigKeys(settings)
+	}
+	aliases, err := ExportAliases()
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigExport{Version: ConfigExportVersion, Settings: settings, Roles: aliases.Roles, MFADevices: aliases.MFADevices}, nil
+}
+
+// removeMachineConfigKeys deletes machineConfigKeys, and the sections they leave empty,
+// from a decoded config file and returns the keys it removed.
+func removeMachineConfigKeys(settings map[string]any) []string {
+	var removed []string
+	var walk func(v any, pattern []string, key string)
+	walk = func(v any, pattern []string, key string) {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		part := pattern[0]
+		for k, child := range m {
+			if part != "<key>" && k != part {
+				continue
+			}
+			if len(pattern) == 1 {
+				delete(m, k)
+				removed = append(removed, joinConfigKey(key, k))
+				continue
+			}
+			walk(child, pattern[1:], joinConfigKey(key, k))
+			if cm, ok := child.(map[string]any); ok && len(cm) == 0 {
+				delete(m, k)
+			}
+		}
+	}
+	for _, pattern := range machineConfigKeys {
+		walk(settings, strings.Split(pattern, "."), "")
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// MarshalConfigExport writes an export as YAML.
+func MarshalConfigExport(export *ConfigExport, sanitized bool) ([]byte, error) {
+	// Go through JSON so the document uses the config file's key names
+	b, err := json.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write YAML: %w", err)
+	}
+	header := "# cloudctl config export; apply with: cloudctl config import <file>\n"
+	if sanitized {
+		header = "# cloudctl config export (sanitized: no secrets or machine-specific settings)\n# Apply with: cloudctl config import <file>\n"
+	}
+	return append([]byte(header), out...), nil
+}
+
+// ParseConfigExport reads a YAML (or JSON) export.
+func ParseConfigExport(b []byte) (*ConfigExport, error) {
+	var raw any
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	normalized, err := normalizeYAML(raw)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := normalized.(map[string]any); !ok {
+		return nil, fmt.Errorf("the export must be a YAML mapping with version, settings, roles and mfa_devices")
+	}
+	jb, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	export := &ConfigExport{}
+	if err := json.Unmarshal(jb, export); err != nil {
+		return nil, fmt.Errorf("failed to parse export: %w", err)
+	}
+	if export.Version == 0 {
+		return nil, fmt.Errorf("not a cloudctl config export (no version)")
+	}
+	if export.Version > ConfigExportVersion {
+		return nil, fmt.Errorf("export version %d is newer than supported (%d)", export.Version, ConfigExportVersion)
+	}
+	if export.Settings == nil {
+		export.Settings = map[string]any{}
+	}
+	if export.Roles == nil {
+		export.Roles = map[string]RoleAlias{}
+	}
+	if export.MFADevices == nil {
+		export.MFADevices = map[string]string{}
+	}
+	return export, nil
+}
+
+// normalizeYAML turns the map[interface{}]interface{} values of yaml.v2 into the
+// map[string]any that encoding/json handles. Keys such as account IDs may come back as
+// numbers when they were not quoted.
+func normalizeYAML(v any) (any, error) {
+	switch t := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for k, child := range t {
+			n, err := normalizeYAML(child)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(k)] = n
+		}
+		return m, nil
+	case []any:
+		for i, child := range t {
+			n, err := normalizeYAML(child)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = n
+		}
+		return t, nil
+	}
+	return v, nil
+}
+
+// ConfigSettingChange is a config key that an import adds or changes.
+type ConfigSettingChange struct {
+	Key string
+	// Old is nil for keys the config file doesn't have yet.
+	Old any
+	New any
+}
+
+// MergeConfigSettings applies the settings of an import to the config file contents b:
+// imported values replace local ones, and keys only set locally are kept. Machine
+// specific keys in the import are ignored and returned as skipped. The merged file is
+// validated but not written.
+func MergeConfigSettings(b []byte, incoming map[string]any) (merged []byte, changes []ConfigSettingChange, skipped []string, err error) {
+	var root map[string]any
+	if err := json.Unmarshal(b, &root); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse config %s: %w", configPath, describeJSONError(b, err))
+	}
+	if root == nil {
+		root = map[string]any{}
+	}
+	skipped = removeMachineConfigKeys(incoming)
+	if unknown := unknownConfigKeys(incoming, ""); len(unknown) > 0 {
+		return nil, nil, nil, unknownKeyError(unknown[0])
+	}
+
+	var merge func(dst, src map[string]any, prefix string)
+	merge = func(dst, src map[string]any, prefix string) {
+		for _, k := range sortedKeys(src) {
+			key := joinConfigKey(prefix, k)
+			srcMap, srcIsMap := src[k].(map[string]any)
+			dstMap, dstIsMap := dst[k].(map[string]any)
+			if srcIsMap {
+				if len(srcMap) == 0 {
+					continue
+				}
+				// Report and replace single keys rather than whole sections
+				if !dstIsMap {
+					dstMap = map[string]any{}
+					dst[k] = dstMap
+				}
+				merge(dstMap, srcMap, key)
+				continue
+			}
+			if old, ok := dst[k]; ok && reflect.DeepEqual(old, src[k]) {
+				continue
+			}
+			changes = append(changes, ConfigSettingChange{Key: key, Old: dst[k], New: src[k]})
+			dst[k] = src[k]
+		}
+	}
+	merge(root, incoming, "")
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	out = append(out, '\n')
+	if _, err := ParseConfig(out, true); err != nil {
+		return nil, nil, nil, err
+	}
+	return out, changes, skipped, nil
+}
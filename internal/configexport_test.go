@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigExportSanitizedRoundTrip(t *testing.T) {
+	setupTestConfig(t, `{
+  "display": {"timezone": "Asia/Bangkok"},
+  "accounts": {"123456789012": {"region": "eu-west-1"}},
+  "remote": {"host": "laptop"},
+  "endpoints": {"local": {"url": "http://localhost:4566", "secret_key": "abc"}},
+  "security": {"production_patterns": ["prod-*", "on"], "scrub_dirs": ["${HOME}/src"]}
+}`)
+	setupTestRoles(t, `{"prod-admin": "arn:aws:iam::123456789012:role/Admin"}`)
+	originalMFAPath := mfaStorePath
+	mfaStorePath = filepath.Join(t.TempDir(), "mfa.json")
+	t.Cleanup(func() { mfaStorePath = originalMFAPath })
+	SaveMFADevice("work", "arn:aws:iam::123456789012:mfa/me")
+
+	export, err := ExportConfig(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MarshalConfigExport(export, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, leaked := range []string{"secret_key", "laptop", "remote"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("Sanitized export contains %q:\n%s", leaked, out)
+		}
+	}
+	// References stay unexpanded, and account IDs and "on" stay strings
+	for _, want := range []string{"${HOME}/src", `"123456789012":`, `- "on"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the export:\n%s", want, out)
+		}
+	}
+
+	parsed, err := ParseConfigExport(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Roles["prod-admin"].ARN != "arn:aws:iam::123456789012:role/Admin" || parsed.MFADevices["work"] == "" {
+		t.Errorf("Aliases didn't round-trip: %+v", parsed)
+	}
+
+	// Imported settings replace local ones, local-only keys stay
+	merged, changes, skipped, err := MergeConfigSettings([]byte(`{"display": {"timezone": "UTC", "locale": "th"}}`), parsed.Settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("Nothing should be skipped from a sanitized export, got %v", skipped)
+	}
+	cfg, err := ParseConfig(merged, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Display.Timezone != "Asia/Bangkok" || cfg.Display.Locale != "th" || cfg.Accounts["123456789012"].Region != "eu-west-1" {
+		t.Errorf("Unexpected merged config %+v", cfg.Display)
+	}
+	if len(changes) != 5 || changes[0].Key != "accounts.123456789012.region" {
+		t.Errorf("Unexpected changes %+v", changes)
+	}
+}
+
+func TestMergeConfigSettingsSkipsMachineKeys(t *testing.T) {
+	setupTestConfig(t, "")
+	parsed, err := ParseConfigExport([]byte("version: 1\nsettings:\n  remote:\n    host: laptop\n  daemon:\n    idle_pause_minutes: 10\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, changes, skipped, err := MergeConfigSettings([]byte(`{"remote": {"host": "jump"}}`), parsed.Settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || skipped[0] != "remote.host" || len(changes) != 1 {
+		t.Errorf("Expected remote.host to be skipped, got %v and %+v", skipped, changes)
+	}
+	if !strings.Contains(string(merged), `"jump"`) {
+		t.Errorf("The local remote.host was changed:\n%s", merged)
+	}
+
+	bad, _ := ParseConfigExport([]byte("version: 1\nsettings:\n  display:\n    timezon: UTC\n"))
+	if _, _, _, err := MergeConfigSettings([]byte("{}"), bad.Settings); err == nil || !strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("Expected an unknown key error, got %v", err)
+	}
+	invalid, _ := ParseConfigExport([]byte("version: 1\nsettings:\n  display:\n    timezone: Mars/Base\n"))
+	if _, _, _, err := MergeConfigSettings([]byte("{}"), invalid.Settings); err == nil {
+		t.Error("Expected an invalid timezone to be refused")
+	}
+}
+
+func TestParseConfigExportVersion(t *testing.T) {
+	if _, err := ParseConfigExport([]byte("roles: {}\n")); err == nil {
+		t.Error("Expected a file without version to be refused")
+	}
+	if _, err := ParseConfigExport([]byte("version: 99\n")); err == nil {
+		t.Error("Expected a newer version to be refused")
+	}
+	// Unquoted account IDs come back as numbers from YAML
+	parsed, err := ParseConfigExport([]byte("version: 1\nsettings:\n  accounts:\n    123456789012:\n      region: eu-west-1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := parsed.Settings["accounts"].(map[string]any)["123456789012"]; !ok {
+		t.Errorf("Expected the account ID as a key, got %v", parsed.Settings)
+	}
+}
@@ -0,0 +1,436 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// envRefPattern matches ${VAR} and ${VAR:-default} in config string values.
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// decodeConfig expands environment references and decodes b into cfg, turning JSON
+// errors into messages that name the key and position.
+func decodeConfig(b []byte, cfg *Config, strict bool) error {
+	var raw any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return describeJSONError(b, err)
+	}
+	if _, ok := raw.(map[string]any); !ok {
+		return fmt.Errorf("the config must be a JSON object")
+	}
+	if strict {
+		if unknown := unknownConfigKeys(raw, ""); len(unknown) > 0 {
+			return unknownKeyError(unknown[0])
+		}
+	}
+
+	expanded, err := interpolateEnv(raw, "")
+	if err != nil {
+		return err
+	}
+	if err := applyEnvOverrides(expanded.(map[string]any)); err != nil {
+		return err
+	}
+	eb, err := json.Marshal(expanded)
+	if err != nil {
+		return err
+	}
+	if err := json.NewDecoder(bytes.NewReader(eb)).Decode(cfg); err != nil {
+		return describeJSONError(eb, err)
+	}
+	return nil
+}
+
+// ConfigEnvName returns the environment variable that overrides a key, e.g.
+// CLOUDCTL_SECURITY_AUTO_LOCK_MINUTES for security.auto_lock_minutes.
+func ConfigEnvName(key string) string {
+	return "CLOUDCTL_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applyEnvOverrides sets every key that has a CLOUDCTL_<SECTION>_<KEY> variable in the
+// environment. Keys under maps (accounts, endpoints, approvers, theme icons and colors) have no variable.
+func applyEnvOverrides(root map[string]any) error {
+	for _, key := range ConfigKeys() {
+		if strings.Contains(key, "<key>") {
+			continue
+		}
+		name := ConfigEnvName(key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		t, _ := configFieldType(key)
+		parsed, err := parseEnvValue(value, t)
+		if err != nil {
+			return fmt.Errorf("%s must be %s", name, describeConfigType(t))
+		}
+		setConfigPath(root, key, parsed)
+	}
+	return nil
+}
+
+// ConfigEnvOverrides lists the CLOUDCTL_<SECTION>_<KEY> variables that are set.
+func ConfigEnvOverrides() []string {
+	var names []string
+	for _, key := range ConfigKeys() {
+		if name := ConfigEnvName(key); !strings.Contains(key, "<key>") && os.Getenv(name) != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseEnvValue converts an environment variable to the JSON value of a key. Lists
+// of strings may be comma-separated; anything else that isn't a string is JSON.
+func parseEnvValue(value string, t reflect.Type) (any, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return strconv.Atoi(strings.TrimSpace(value))
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+			var items []any
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			return items, nil
+		}
+	}
+	var parsed any
+	err := json.Unmarshal([]byte(value), &parsed)
+	return parsed, err
+}
+
+// setConfigPath sets a dotted key in a decoded config, creating parent objects.
+func setConfigPath(root map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	m := root
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := m[part].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			m[part] = child
+		}
+		m = child
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// deleteConfigPath removes a dotted key from a decoded config.
+func deleteConfigPath(root map[string]any, key string) {
+	parts := strings.Split(key, ".")
+	m := root
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := m[part].(map[string]any)
+		if !ok {
+			return
+		}
+		m = child
+	}
+	delete(m, parts[len(parts)-1])
+}
+
+// interpolateEnv replaces environment references in every string of a decoded JSON
+// value. A reference to an unset variable without a default is an error.
+func interpolateEnv(v any, path string) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			expanded, err := interpolateEnv(child, joinConfigKey(path, k))
+			if err != nil {
+				return nil, err
+			}
+			v[k] = expanded
+		}
+	case []any:
+		for i, child := range v {
+			expanded, err := interpolateEnv(child, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	case string:
+		var missing string
+		out := envRefPattern.ReplaceAllStringFunc(v, func(ref string) string {
+			m := envRefPattern.FindStringSubmatch(ref)
+			if value, ok := os.LookupEnv(m[1]); ok {
+				return value
+			}
+			if strings.Contains(ref, ":-") {
+				return m[2]
+			}
+			missing = m[1]
+			return ref
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("%s refers to ${%s}, which is not set (use ${%s:-default} for a fallback)", path, missing, missing)
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// describeJSONError adds the line and column, or the key and expected type, to a JSON
+// decoding error.
+func describeJSONError(b []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := lineAndColumn(b, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %v", line, col, syntaxErr)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("%s must be %s, not a JSON %s", typeErr.Field, describeConfigType(typeErr.Type), typeErr.Value)
+	}
+	return err
+}
+
+func lineAndColumn(b []byte, offset int64) (int, int) {
+	if offset > int64(len(b)) {
+		offset = int64(len(b))
+	}
+	before := b[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+func describeConfigType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "a whole number"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return t.String()
+}
+
+func joinConfigKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// configFieldType returns the Go type stored under a dotted key such as
+// "display.timezone" or "accounts.123456789012.region".
+func configFieldType(key string) (reflect.Type, bool) {
+	t := reflect.TypeOf(Config{})
+	for _, part := range strings.Split(key, ".") {
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := jsonField(t, part)
+			if !ok {
+				return nil, false
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, false
+		}
+	}
+	return t, true
+}
+
+// jsonField finds the struct field with the given JSON name.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// ConfigKeys lists every settable key, with <key> standing in for map keys.
+func ConfigKeys() []string {
+	var keys []string
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		switch t.Kind() {
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+				walk(t.Field(i).Type, joinConfigKey(prefix, tag))
+			}
+		case reflect.Map:
+			if t.Elem().Kind() == reflect.Struct {
+				walk(t.Elem(), joinConfigKey(prefix, "<key>"))
+				return
+			}
+			keys = append(keys, joinConfigKey(prefix, "<key>"))
+		default:
+			keys = append(keys, prefix)
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	sort.Strings(keys)
+	return keys
+}
+
+// unknownConfigKeys returns the keys of a decoded config file that cloudctl doesn't use.
+func unknownConfigKeys(raw any, prefix string) []string {
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil
+	}
+	var unknown []string
+	for k, v := range m {
+		key := joinConfigKey(prefix, k)
+		t, ok := configFieldType(key)
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		if t.Kind() == reflect.Struct || (t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Struct) {
+			unknown = append(unknown, unknownConfigKeys(v, key)...)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// unknownKeyError names an unknown key and suggests the closest known one.
+func unknownKeyError(key string) error {
+	best, bestDist := "", len(key)/2+1
+	for _, known := range ConfigKeys() {
+		if d := levenshtein(key, known); d < bestDist {
+			best, bestDist = known, d
+		}
+	}
+	if best != "" {
+		return fmt.Errorf("unknown key %q (did you mean %q?)", key, best)
+	}
+	return fmt.Errorf("unknown key %q", key)
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// ConfigValue returns the effective value of a dotted key, after defaults and
+// environment references are applied.
+func ConfigValue(cfg *Config, key string) (any, error) {
+	if _, ok := configFieldType(key); !ok {
+		return nil, unknownKeyError(key)
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	for _, part := range strings.Split(key, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		v = m[part]
+	}
+	return v, nil
+}
+
+// ReadConfigFile returns the raw config file contents, or "{}" when there is none.
+func ReadConfigFile() ([]byte, error) {
+	b, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return []byte("{}\n"), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return b, nil
+}
+
+// WriteConfigFile validates contents strictly and replaces the config file with them.
+func WriteConfigFile(b []byte) error {
+	if _, err := ParseConfig(b, true); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return os.WriteFile(configPath, b, 0600)
+}
+
+// SetConfigValue sets a dotted key in the config file, keeping everything else as
+// written. Strings are stored as given; other types are parsed as JSON
+// (e.g. 30, true, ["a","b"]). An empty value removes the key.
+func SetConfigValue(key, value string) error {
+	t, ok := configFieldType(key)
+	if !ok {
+		return unknownKeyError(key)
+	}
+
+	b, err := ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	var root map[string]any
+	if err := json.Unmarshal(b, &root); err != nil {
+		return fmt.Errorf("failed to parse config %s: %w", configPath, describeJSONError(b, err))
+	}
+	if root == nil {
+		root = map[string]any{}
+	}
+
+	var parsed any = value
+	if t.Kind() != reflect.String && value != "" {
+		if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+			return fmt.Errorf("%s must be %s", key, describeConfigType(t))
+		}
+	}
+
+	if value == "" {
+		deleteConfigPath(root, key)
+	} else {
+		setConfigPath(root, key, parsed)
+	}
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteConfigFile(append(out, '\n'))
+}
@@ -0,0 +1,308 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// federationEndpoint replaces the configured sign-in endpoint when set; tests point it
+// at a local server.
+var federationEndpoint = ""
+
+// Sign-in endpoint modes for console.signin
+const (
+	SigninGlobal   = "global"
+	SigninRegional = "regional"
+)
+
+// defaultSigninRegion is used by regional sign-in when neither a console region nor a
+// session region is known.
+const defaultSigninRegion = "us-east-1"
+
+// partitionDomains are the sign-in and console domains of each AWS partition.
+var partitionDomains = map[string]struct{ signin, console string }{
+	"aws":        {"signin.aws.amazon.com", "console.aws.amazon.com"},
+	"aws-cn":     {"signin.amazonaws.cn", "console.amazonaws.cn"},
+	"aws-us-gov": {"signin.amazonaws-us-gov.com", "console.amazonaws-us-gov.com"},
+}
+
+// RegionPartition returns the partition a region belongs to.
+func RegionPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	}
+	return "aws"
+}
+
+// ValidateSigninEndpoint checks a console.signin value: global, regional or an https://
+// URL.
+func ValidateSigninEndpoint(value string) error {
+	if value == "" || value == SigninGlobal || value == SigninRegional {
+		return nil
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme == "https" && u.Host != "" {
+		return nil
+	}
+	return fmt.Errorf("'%s' must be global, regional or an https:// URL", value)
+}
+
+// ValidateConsoleConfig checks the sign-in endpoint and its per-partition and
+// per-region overrides.
+func ValidateConsoleConfig(c ConsoleConfig) error {
+	if err := ValidateSigninEndpoint(c.Signin); err != nil {
+		return fmt.Errorf("console.signin: %w", err)
+	}
+	for key, value := range c.SigninOverrides {
+		if _, ok := partitionDomains[key]; !ok && !regionPattern.MatchString(key) {
+			return fmt.Errorf("console.signin_overrides: '%s' is neither a partition (aws, aws-cn, aws-us-gov) nor a region", key)
+		}
+		if err := ValidateSigninEndpoint(value); err != nil {
+			return fmt.Errorf("console.signin_overrides.%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// SigninEndpoints returns the federation endpoint to get a sign-in token from and the
+// console URL to land on. region is the console home region ("" for the default); a
+// regional endpoint falls back to fallbackRegion and then us-east-1, and always lands
+// in the console of its region.
+func (c ConsoleConfig) SigninEndpoints(region, fallbackRegion string) (string, string) {
+	signinRegion := region
+	if signinRegion == "" {
+		signinRegion = fallbackRegion
+	}
+	if signinRegion == "" {
+		signinRegion = defaultSigninRegion
+	}
+	partition := RegionPartition(signinRegion)
+	domains := partitionDomains[partition]
+
+	mode := c.Signin
+	if v, ok := c.SigninOverrides[partition]; ok {
+		mode = v
+	}
+	if v, ok := c.SigninOverrides[signinRegion]; ok {
+		mode = v
+	}
+
+	regionalConsole := fmt.Sprintf("https://%s.%s/console/home?region=%s", signinRegion, domains.console, signinRegion)
+	switch mode {
+	case "", SigninGlobal:
+		if region == "" {
+			return "https://" + domains.signin + "/federation", "https://" + domains.console + "/"
+		}
+		return "https://" + domains.signin + "/federation", fmt.Sprintf("https://%s.%s/console/home?region=%s", region, domains.console, region)
+	case SigninRegional:
+		return fmt.Sprintf("https://%s.%s/federation", signinRegion, domains.signin), regionalConsole
+	}
+	// A custom endpoint, e.g. a host the org's proxy allows
+	return strings.ReplaceAll(mode, "{region}", signinRegion), regionalConsole
+}
+
+// FederatedConsoleURL exchanges a role session for a sign-in token and returns the
+// console sign-in URL, landing in region's console home ("" for the default). The
+// sign-in endpoint comes from the console section of the config.
+func FederatedConsoleURL(s *AWSSession, region string) (string, error) {
+	endpoint, destination := consoleEndpoints(s, region)
+	signinToken, err := getSigninToken(s, endpoint)
+	if err != nil {
+		return "", err
+	}
+	return consoleLoginURL(endpoint, destination, signinToken), nil
+}
+
+// consoleEndpoints returns the federation endpoint and console destination for a
+// session.
+func consoleEndpoints(s *AWSSession, region string) (string, string) {
+	endpoint, destination := CurrentConfig().Console.SigninEndpoints(region, s.Region)
+	if federationEndpoint != "" {
+		endpoint = federationEndpoint
+	}
+	return endpoint, destination
+}
+
+// federationRetryDelay is the wait before the first retry of a failed getSigninToken
+// call; it doubles with each retry. Tests shorten it.
+var federationRetryDelay = 500 * time.Millisecond
+
+// federationAttempts bounds the getSigninToken calls made for one token.
+const federationAttempts = 3
+
+// federationMaxSkew is the difference from the endpoint's clock above which a refused
+// session is blamed on the local clock.
+const federationMaxSkew = 5 * time.Minute
+
+// FederationError is a getSigninToken call the federation endpoint refused or answered
+// with something other than a token. Hint names the likely cause when one is known.
+type FederationError struct {
+	Endpoint string
+	// Status is the HTTP status, 0 for an unparsable 200 response.
+	Status int
+	Hint   string
+	Err    error
+}
+
+func (e *FederationError) Error() string {
+	msg := fmt.Sprintf("failed to get sign-in token from %s", e.Endpoint)
+	if e.Status != 0 {
+		msg += fmt.Sprintf(": HTTP %d", e.Status)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Hint != "" {
+		msg += " (" + e.Hint + ")"
+	}
+	return msg
+}
+
+func (e *FederationError) Unwrap() error {
+	return e.Err
+}
+
+// getSigninToken calls getSigninToken on the federation endpoint. Network errors,
+// throttling and server errors are retried with backoff; refusals are not, and come
+// back as a FederationError naming the likely cause.
+func getSigninToken(s *AWSSession, endpoint string) (string, error) {
+	sessionData, _ := json.Marshal(map[string]string{
+		"sessionId":    s.AccessKey,
+		"sessionKey":   s.SecretKey,
+		"sessionToken": s.SessionToken,
+	})
+	params := url.Values{}
+	params.Add("Action", "getSigninToken")
+	params.Add("Session", string(sessionData))
+	client := &http.Client{Timeout: CurrentConfig().Network.CallTimeout()}
+
+	start := time.Now()
+	var token string
+	var err error
+	delay := federationRetryDelay
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		token, retry, err = requestSigninToken(client, fmt.Sprintf("%s?%s", endpoint, params.Encode()), endpoint, s)
+		if err == nil || !retry || attempt == federationAttempts {
+			break
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+	RecordConsoleFederation(s.Profile, start, err)
+	return token, err
+}
+
+// requestSigninToken makes one getSigninToken call and reports whether a failure is
+// worth retrying.
+func requestSigninToken(client *http.Client, requestURL, endpoint string, s *AWSSession) (string, bool, error) {
+	resp, err := client.Get(requestURL)
+	if err != nil {
+		// The URL holds the session's credentials
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", true, &FederationError{Endpoint: endpoint, Err: err}
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode != http.StatusOK {
+		ferr := &FederationError{Endpoint: endpoint, Status: resp.StatusCode, Hint: federationHint(resp, body, s)}
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return "", retry, ferr
+	}
+	var tokenResp map[string]string
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", false, &FederationError{Endpoint: endpoint, Err: fmt.Errorf("unexpected response (not JSON)"),
+			Hint: "a proxy or captive portal may be answering for the sign-in endpoint; see console.signin to use another one"}
+	}
+	signinToken := tokenResp["SigninToken"]
+	if signinToken == "" {
+		return "", false, &FederationError{Endpoint: endpoint, Err: fmt.Errorf("no sign-in token in the response")}
+	}
+	return signinToken, false, nil
+}
+
+// federationHint names the likely cause of a refused getSigninToken call from its
+// status, body and the endpoint's clock.
+func federationHint(resp *http.Response, body []byte, s *AWSSession) string {
+	if skew, ok := clockSkewFromResponse(resp, time.Now()); ok && skew.Abs() > federationMaxSkew {
+		return DescribeClockSkew(skew) + "; fix the clock and try again"
+	}
+	text := strings.ToLower(string(body))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return "the sign-in endpoint is throttling requests; wait a moment, or reuse a cached token without --fresh"
+	case resp.StatusCode >= 500:
+		return "the sign-in endpoint is having problems; try again later, or another endpoint with console.signin"
+	case !s.Expiration.IsZero() && time.Now().After(s.Expiration), strings.Contains(text, "expired"):
+		return fmt.Sprintf("the session has expired; refresh it with: cloudctl refresh --profile %s", s.Profile)
+	case strings.Contains(text, "signature") || strings.Contains(text, "not yet valid") || strings.Contains(text, "clock"):
+		return "the request looked like it was signed at the wrong time; check this machine's clock"
+	case resp.StatusCode == http.StatusForbidden || strings.Contains(text, "denied") || strings.Contains(text, "not authorized"):
+		return "the session isn't allowed to federate to the console; an SCP or permissions boundary may deny it, or the region's sign-in endpoint may be disabled"
+	case resp.StatusCode == http.StatusBadRequest:
+		return "the sign-in endpoint didn't accept the session's credentials; log in again to get new ones"
+	}
+	return ""
+}
+
+func consoleLoginURL(endpoint, destination, signinToken string) string {
+	return fmt.Sprintf("%s?Action=login&Issuer=cloudctl&Destination=%s&SigninToken=%s",
+		endpoint, url.QueryEscape(destination), signinToken)
+}
+
+// ContainerURL wraps a URL so that Firefox opens it in the container called name, using
+// the "Open external links in a container" extension, which creates missing containers.
+func ContainerURL(name, rawURL string) string {
+	return "ext+container:name=" + url.QueryEscape(name) + "&url=" + url.QueryEscape(rawURL)
+}
+
+// selectorKeys are the session attributes a SessionSelector can match.
+var selectorKeys = map[string]bool{"env": true, "account": true, "profile": true, "role": true}
+
+// SessionSelector picks sessions by attributes, e.g. "env=prod,role=*ReadOnly*".
+type SessionSelector map[string]string
+
+// ParseSessionSelector parses comma-separated key=value terms; every term must match.
+// Keys are env (the account's env in the config), account, profile and role (the role
+// ARN); values may contain * and ? wildcards.
+func ParseSessionSelector(value string) (SessionSelector, error) {
+	sel := SessionSelector{}
+	for _, term := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(term), "=")
+		key, val = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(val)
+		if !ok || val == "" || !selectorKeys[key] {
+			return nil, fmt.Errorf("invalid selector '%s' (use key=value with env, account, profile or role, e.g. env=prod)", term)
+		}
+		sel[key] = val
+	}
+	return sel, nil
+}
+
+// Matches reports whether the session has every attribute of the selector.
+func (sel SessionSelector) Matches(c *Config, s *AWSSession) bool {
+	account := SessionAccountID(s)
+	values := map[string]string{
+		"env":     c.Accounts[account].Env,
+		"account": account,
+		"profile": s.Profile,
+		"role":    s.RoleArn,
+	}
+	for key, pattern := range sel {
+		if values[key] == "" || !matchGlob(pattern, values[key]) {
+			return false
+		}
+	}
+	return true
+}
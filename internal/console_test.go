@@ -0,0 +1,287 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFederatedConsoleURL(t *testing.T) {
+	originalLog := auditLogPath
+	auditLogPath = filepath.Join(t.TempDir(), "audit.log")
+	t.Cleanup(func() { auditLogPath = originalLog })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var session map[string]string
+		if r.URL.Query().Get("Action") != "getSigninToken" || json.Unmarshal([]byte(r.URL.Query().Get("Session")), &session) != nil ||
+			session["sessionId"] != "ASIAEXAMPLE" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"SigninToken": "tok"})
+	}))
+	defer server.Close()
+	setTestConfig(t, nil)
+	original := federationEndpoint
+	federationEndpoint = server.URL
+	t.Cleanup(func() { federationEndpoint = original })
+
+	s := &AWSSession{Profile: "prod", AccessKey: "ASIAEXAMPLE", SecretKey: "secret", SessionToken: "token"}
+	got, err := FederatedConsoleURL(s, "eu-west-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(got)
+	if q := u.Query(); q.Get("SigninToken") != "tok" || q.Get("Destination") != "https://eu-west-1.console.aws.amazon.com/console/home?region=eu-west-1" {
+		t.Errorf("Unexpected console URL: %s", got)
+	}
+
+	s.AccessKey = "ASIAOTHER"
+	if _, err := FederatedConsoleURL(s, ""); err == nil {
+		t.Error("Expected an error without a sign-in token")
+	}
+}
+
+func TestSigninEndpoints(t *testing.T) {
+	console := ConsoleConfig{
+		SigninOverrides: map[string]string{
+			"aws-us-gov":   SigninRegional,
+			"eu-central-1": "https://{region}.signin.example.com/federation",
+		},
+	}
+	tests := []struct {
+		config                ConsoleConfig
+		region, fallback      string
+		endpoint, destination string
+	}{
+		{ConsoleConfig{}, "", "", "https://signin.aws.amazon.com/federation", "https://console.aws.amazon.com/"},
+		{ConsoleConfig{}, "eu-west-1", "", "https://signin.aws.amazon.com/federation", "https://eu-west-1.console.aws.amazon.com/console/home?region=eu-west-1"},
+		{ConsoleConfig{}, "cn-north-1", "", "https://signin.amazonaws.cn/federation", "https://cn-north-1.console.amazonaws.cn/console/home?region=cn-north-1"},
+		{ConsoleConfig{Signin: SigninRegional}, "eu-west-1", "", "https://eu-west-1.signin.aws.amazon.com/federation", "https://eu-west-1.console.aws.amazon.com/console/home?region=eu-west-1"},
+		// Regional sign-in needs a region: the session's, then us-east-1
+		{ConsoleConfig{Signin: SigninRegional}, "", "ap-southeast-1", "https://ap-southeast-1.signin.aws.amazon.com/federation", "https://ap-southeast-1.console.aws.amazon.com/console/home?region=ap-southeast-1"},
+		{ConsoleConfig{Signin: SigninRegional}, "", "", "https://us-east-1.signin.aws.amazon.com/federation", "https://us-east-1.console.aws.amazon.com/console/home?region=us-east-1"},
+		{console, "us-gov-west-1", "", "https://us-gov-west-1.signin.amazonaws-us-gov.com/federation", "https://us-gov-west-1.console.amazonaws-us-gov.com/console/home?region=us-gov-west-1"},
+		{console, "eu-central-1", "", "https://eu-central-1.signin.example.com/federation", "https://eu-central-1.console.aws.amazon.com/console/home?region=eu-central-1"},
+		{console, "eu-west-1", "", "https://signin.aws.amazon.com/federation", "https://eu-west-1.console.aws.amazon.com/console/home?region=eu-west-1"},
+	}
+	for _, tt := range tests {
+		endpoint, destination := tt.config.SigninEndpoints(tt.region, tt.fallback)
+		if endpoint != tt.endpoint || destination != tt.destination {
+			t.Errorf("%+v %q %q: got %s %s, want %s %s", tt.config, tt.region, tt.fallback, endpoint, destination, tt.endpoint, tt.destination)
+		}
+	}
+}
+
+func TestValidateConsoleConfig(t *testing.T) {
+	valid := ConsoleConfig{Signin: SigninRegional, SigninOverrides: map[string]string{"aws-cn": SigninGlobal, "eu-west-1": "https://signin.example.com/federation"}}
+	if err := ValidateConsoleConfig(valid); err != nil {
+		t.Errorf("Expected %+v to be valid, got %v", valid, err)
+	}
+	for _, c := range []ConsoleConfig{
+		{Signin: "legacy"},
+		{Signin: "http://signin.example.com/federation"},
+		{SigninOverrides: map[string]string{"moon-1": SigninRegional}},
+		{SigninOverrides: map[string]string{"aws": "nearest"}},
+	} {
+		if err := ValidateConsoleConfig(c); err == nil {
+			t.Errorf("Expected %+v to be rejected", c)
+		}
+	}
+}
+
+func TestContainerURL(t *testing.T) {
+	got := ContainerURL("prod admin", "https://signin.aws.amazon.com/federation?Action=login&SigninToken=a+b")
+	want := "ext+container:name=prod+admin&url=https%3A%2F%2Fsignin.aws.amazon.com%2Ffederation%3FAction%3Dlogin%26SigninToken%3Da%2Bb"
+	if got != want {
+		t.Errorf("ContainerURL = %s, want %s", got, want)
+	}
+}
+
+func TestSessionSelector(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Accounts = map[string]AccountConfig{
+		"111111111111": {Env: "prod"},
+		"222222222222": {Env: "staging"},
+	}
+	prodAdmin := &AWSSession{Profile: "prod-admin", RoleArn: "arn:aws:iam::111111111111:role/Admin"}
+	prodRead := &AWSSession{Profile: "prod-read", RoleArn: "arn:aws:iam::111111111111:role/ReadOnly"}
+	staging := &AWSSession{Profile: "staging-admin", RoleArn: "arn:aws:iam::222222222222:role/Admin"}
+	untagged := &AWSSession{Profile: "sandbox", RoleArn: "arn:aws:iam::333333333333:role/Admin"}
+
+	tests := []struct {
+		selector string
+		want     []*AWSSession
+	}{
+		{"env=prod", []*AWSSession{prodAdmin, prodRead}},
+		{"env=PROD, role=*Admin", []*AWSSession{prodAdmin}},
+		{"account=222222222222", []*AWSSession{staging}},
+		{"profile=*-admin", []*AWSSession{prodAdmin, staging}},
+		{"env=*", []*AWSSession{prodAdmin, prodRead, staging}},
+	}
+	for _, tt := range tests {
+		sel, err := ParseSessionSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.selector, err)
+		}
+		var got []*AWSSession
+		for _, s := range []*AWSSession{prodAdmin, prodRead, staging, untagged} {
+			if sel.Matches(cfg, s) {
+				got = append(got, s)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s matched %d sessions, want %d", tt.selector, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s matched %s, want %s", tt.selector, got[i].Profile, tt.want[i].Profile)
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "env", "env=", "region=us-east-1", "env=prod,,"} {
+		if _, err := ParseSessionSelector(bad); err == nil || !strings.Contains(err.Error(), "invalid selector") {
+			t.Errorf("ParseSessionSelector(%q) = %v, want an error", bad, err)
+		}
+	}
+}
+
+func TestCachedConsoleURL(t *testing.T) {
+	originalLog := auditLogPath
+	auditLogPath = filepath.Join(t.TempDir(), "audit.log")
+	originalCache := consoleCachePath
+	consoleCachePath = filepath.Join(t.TempDir(), "console-cache.json")
+	t.Cleanup(func() { auditLogPath, consoleCachePath = originalLog, originalCache })
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(map[string]string{"SigninToken": fmt.Sprintf("tok%d", calls)})
+	}))
+	defer server.Close()
+	setTestConfig(t, nil)
+	original := federationEndpoint
+	federationEndpoint = server.URL
+	t.Cleanup(func() { federationEndpoint = original })
+
+	provider := NewSecretProvider("abcdefabcdefabcdefabcdefabcdef12")
+	s := &AWSSession{Profile: "prod", AccessKey: "ASIAEXAMPLE", SecretKey: "secret", SessionToken: "token", Expiration: time.Now().Add(time.Hour)}
+	token := func(consoleURL string) string {
+		u, _ := url.Parse(consoleURL)
+		return u.Query().Get("SigninToken")
+	}
+
+	first, cached, err := CachedConsoleURL(s, "", provider, false)
+	if err != nil || cached || token(first) != "tok1" {
+		t.Fatalf("Expected a new token, got %s (cached %v, %v)", first, cached, err)
+	}
+	if b, _ := os.ReadFile(consoleCachePath); strings.Contains(string(b), "tok1") {
+		t.Error("Expected the cached token to be encrypted")
+	}
+	if again, cached, _ := CachedConsoleURL(s, "eu-west-1", provider, false); !cached || token(again) != "tok1" || calls != 1 {
+		t.Errorf("Expected the cached token to be reused, got %s after %d calls", again, calls)
+	}
+	if fresh, cached, _ := CachedConsoleURL(s, "", provider, true); cached || token(fresh) != "tok2" {
+		t.Errorf("Expected --fresh to get a new token, got %s", fresh)
+	}
+
+	// A refreshed session has new keys
+	s.AccessKey = "ASIAREFRESHED"
+	if _, cached, _ := CachedConsoleURL(s, "", provider, false); cached {
+		t.Error("Expected a refreshed session not to reuse the cached token")
+	}
+
+	// A session about to expire isn't cached
+	expiring := &AWSSession{Profile: "dev", AccessKey: "ASIADEV", Expiration: time.Now().Add(time.Minute)}
+	CachedConsoleURL(expiring, "", provider, false)
+	if _, cached, _ := CachedConsoleURL(expiring, "", provider, false); cached {
+		t.Error("Expected no cached token for a session about to expire")
+	}
+
+	if err := forgetConsoleTokens("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(consoleCachePath); !os.IsNotExist(err) {
+		t.Error("Expected the cache to be removed with its last token")
+	}
+}
+
+func TestFederatedConsoleURLRetriesAndExplainsFailures(t *testing.T) {
+	originalLog := auditLogPath
+	auditLogPath = filepath.Join(t.TempDir(), "audit.log")
+	originalDelay := federationRetryDelay
+	federationRetryDelay = time.Millisecond
+	t.Cleanup(func() { auditLogPath, federationRetryDelay = originalLog, originalDelay })
+	setTestConfig(t, nil)
+	original := federationEndpoint
+	t.Cleanup(func() { federationEndpoint = original })
+
+	s := &AWSSession{Profile: "prod", AccessKey: "ASIAEXAMPLE", SecretKey: "secret", SessionToken: "token", Expiration: time.Now().Add(time.Hour)}
+	serve := func(handler func(w http.ResponseWriter, calls int)) *int {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			handler(w, calls)
+		}))
+		t.Cleanup(server.Close)
+		federationEndpoint = server.URL
+		return &calls
+	}
+
+	calls := serve(func(w http.ResponseWriter, calls int) {
+		if calls < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"SigninToken": "tok"})
+	})
+	if _, err := FederatedConsoleURL(s, ""); err != nil || *calls != 3 {
+		t.Errorf("Expected a token after two retried server errors, got %v after %d calls", err, *calls)
+	}
+
+	calls = serve(func(w http.ResponseWriter, calls int) { http.Error(w, "throttled", http.StatusTooManyRequests) })
+	if _, err := FederatedConsoleURL(s, ""); err == nil || *calls != federationAttempts || !strings.Contains(err.Error(), "throttling") {
+		t.Errorf("Expected throttling to be retried %d times, got %v after %d calls", federationAttempts, err, *calls)
+	}
+
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter, calls int)
+		want    string
+	}{
+		{"expired", func(w http.ResponseWriter, _ int) {
+			http.Error(w, "The security token included in the request is expired", http.StatusBadRequest)
+		}, "cloudctl refresh --profile prod"},
+		{"denied", func(w http.ResponseWriter, _ int) { http.Error(w, "Forbidden", http.StatusForbidden) }, "SCP"},
+		// Date headers have whole seconds, so the skew is 1h0m0s or 1h0m1s
+		{"clock skew", func(w http.ResponseWriter, _ int) {
+			w.Header().Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+			http.Error(w, "bad request", http.StatusBadRequest)
+		}, "clock is 1h0m"},
+		{"captive portal", func(w http.ResponseWriter, _ int) { w.Write([]byte("<html>Log in to the Wi-Fi</html>")) }, "proxy"},
+	}
+	for _, tt := range tests {
+		calls := serve(tt.handler)
+		_, err := FederatedConsoleURL(s, "")
+		var ferr *FederationError
+		if !errors.As(err, &ferr) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected a federation error mentioning %q, got %v", tt.name, tt.want, err)
+		}
+		if *calls != 1 {
+			t.Errorf("%s: expected no retry, got %d calls", tt.name, *calls)
+		}
+		if strings.Contains(fmt.Sprint(err), "secret") {
+			t.Errorf("%s: expected no credentials in the error, got %v", tt.name, err)
+		}
+	}
+}
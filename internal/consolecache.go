@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// consoleCachePath holds the sign-in tokens console got from the federation endpoint,
+// so opening the console again for the same session doesn't call it again. Tokens are
+// encrypted like the credential store; profiles, endpoints and expiry times are plain
+// text.
+var consoleCachePath = filepath.Join(storeDir, "console-cache.json")
+
+// signinTokenLifetime is how long the federation endpoint's sign-in tokens are valid.
+const signinTokenLifetime = 15 * time.Minute
+
+// consoleCacheMargin leaves time to open a URL built from a cached token before the
+// token or its session expires.
+const consoleCacheMargin = 2 * time.Minute
+
+// cachedSigninToken is a sign-in token in the console cache.
+type cachedSigninToken struct {
+	Profile  string    `json:"profile"`
+	Endpoint string    `json:"endpoint"`
+	Expires  time.Time `json:"expires"`
+	// Token is the encrypted sign-in token, base64 encoded.
+	Token string `json:"token"`
+}
+
+// consoleCacheKey identifies the session and endpoint a token was issued for; a
+// refreshed session has new keys and so misses the cache.
+func consoleCacheKey(s *AWSSession, endpoint string) string {
+	sum := sha256.Sum256([]byte(endpoint + "\n" + s.AccessKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// CachedConsoleURL is FederatedConsoleURL reusing a cached sign-in token for the same
+// session and endpoint until shortly before the token or the session expires. fresh
+// skips the cache and caches a new token. It reports whether the token came from the
+// cache. A cache that can't be read or written only costs a federation call.
+func CachedConsoleURL(s *AWSSession, region string, provider CryptoProvider, fresh bool) (string, bool, error) {
+	endpoint, destination := consoleEndpoints(s, region)
+	key := consoleCacheKey(s, endpoint)
+	now := time.Now()
+	cache := loadConsoleCache()
+	if entry, ok := cache[key]; ok && !fresh && now.Before(entry.Expires) {
+		if enc, err := base64.StdEncoding.DecodeString(entry.Token); err == nil {
+			if token, err := provider.Decrypt(enc); err == nil {
+				return consoleLoginURL(endpoint, destination, string(token)), true, nil
+			}
+		}
+	}
+
+	token, err := getSigninToken(s, endpoint)
+	if err != nil {
+		return "", false, err
+	}
+	expires := now.Add(signinTokenLifetime)
+	if s.Expiration.Before(expires) {
+		expires = s.Expiration
+	}
+	expires = expires.Add(-consoleCacheMargin)
+	if expires.After(now) {
+		if enc, err := provider.Encrypt([]byte(token)); err == nil {
+			for k, e := range cache {
+				if !now.Before(e.Expires) {
+					delete(cache, k)
+				}
+			}
+			cache[key] = cachedSigninToken{Profile: s.Profile, Endpoint: endpoint, Expires: expires.UTC().Truncate(time.Second), Token: base64.StdEncoding.EncodeToString(enc)}
+			saveConsoleCache(cache)
+		}
+	}
+	return consoleLoginURL(endpoint, destination, token), false, nil
+}
+
+func loadConsoleCache() map[string]cachedSigninToken {
+	cache := make(map[string]cachedSigninToken)
+	if b, err := os.ReadFile(consoleCachePath); err == nil {
+		json.Unmarshal(b, &cache)
+	}
+	return cache
+}
+
+func saveConsoleCache(cache map[string]cachedSigninToken) error {
+	if len(cache) == 0 {
+		if err := os.Remove(consoleCachePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(consoleCachePath), 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	b, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(consoleCachePath, b, 0600)
+}
+
+// forgetConsoleTokens drops the cached sign-in tokens of a profile.
+func forgetConsoleTokens(profile string) error {
+	cache := loadConsoleCache()
+	n := len(cache)
+	for k, e := range cache {
+		if e.Profile == profile {
+			delete(cache, k)
+		}
+	}
+	if len(cache) == n {
+		return nil
+	}
+	return saveConsoleCache(cache)
+}
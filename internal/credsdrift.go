@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// managedCommentPrefix starts the comment sync writes above each section it manages.
+const managedCommentPrefix = "; Managed by cloudctl"
+
+// AWSCredentialsPath is the shared credentials file sync writes to,
+// AWS_SHARED_CREDENTIALS_FILE when it is set.
+func AWSCredentialsPath() string {
+	return awsFilePath("AWS_SHARED_CREDENTIALS_FILE", "credentials")
+}
+
+// ManagedCredential is a section of ~/.aws/credentials written by sync.
+type ManagedCredential struct {
+	Profile   string
+	AccessKey string
+	// Expiration is read from the "; Managed by cloudctl" comment; zero when it
+	// can't be parsed.
+	Expiration time.Time
+}
+
+// ReadManagedCredentials returns the sections of ~/.aws/credentials that sync manages,
+// in file order, followed by the profiles it wrote in files mode. A missing file has
+// none.
+func ReadManagedCredentials() ([]ManagedCredential, error) {
+	content, err := os.ReadFile(AWSCredentialsPath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	creds := parseManagedCredentials(string(content))
+	synced, err := readSyncedCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return append(creds, synced...), nil
+}
+
+// parseManagedCredentials picks the sections preceded by a "; Managed by cloudctl"
+// comment out of credentials file content.
+func parseManagedCredentials(content string) []ManagedCredential {
+	var creds []ManagedCredential
+	var current *ManagedCredential
+	managed := false
+	var expires time.Time
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, managedCommentPrefix):
+			managed = true
+			expires = time.Time{}
+			if _, ts, ok := strings.Cut(trimmed, "Expires:"); ok {
+				if t, err := time.Parse(DisplayTimeFormatZone, strings.TrimSpace(ts)); err == nil {
+					expires = t
+				}
+			}
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			current = nil
+			if managed {
+				creds = append(creds, ManagedCredential{Profile: strings.Trim(trimmed, "[]"), Expiration: expires})
+				current = &creds[len(creds)-1]
+			}
+			managed = false
+		case current != nil:
+			if key, value, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(key) == "aws_access_key_id" {
+				current.AccessKey = strings.TrimSpace(value)
+			}
+		}
+	}
+	return creds
+}
+
+// DriftKind describes how a synced section differs from the store.
+type DriftKind string
+
+const (
+	// DriftStale is a section holding older keys than the stored session; sync
+	// hasn't run since the session was refreshed.
+	DriftStale DriftKind = "stale"
+	// DriftNewer is a section holding keys that outlive the stored session, e.g.
+	// synced from another store.
+	DriftNewer DriftKind = "newer"
+	// DriftOrphaned is a section for a profile that is no longer stored.
+	DriftOrphaned DriftKind = "orphaned"
+)
+
+// CredentialsDrift is a synced ~/.aws/credentials section that doesn't match the store.
+type CredentialsDrift struct {
+	Kind    DriftKind
+	Profile string
+	// FileExpiration is when the file's keys expire (zero when unknown), and
+	// StoreExpiration when the stored session does.
+	FileExpiration  time.Time
+	StoreExpiration time.Time
+}
+
+// DetectCredentialsDrift compares the synced sections with the stored sessions. A
+// section with the stored access key is in sync; one with different keys is stale
+// unless its keys expire after the stored session's. Sections sync didn't write are
+// left alone, as are stored sessions that were never synced.
+func DetectCredentialsDrift(sessions []*AWSSession, managed []ManagedCredential) []CredentialsDrift {
+	stored := make(map[string]*AWSSession, len(sessions))
+	for _, s := range sessions {
+		stored[s.Profile] = s
+	}
+
+	var drift []CredentialsDrift
+	for _, m := range managed {
+		s, ok := stored[m.Profile]
+		if !ok {
+			drift = append(drift, CredentialsDrift{Kind: DriftOrphaned, Profile: m.Profile, FileExpiration: m.Expiration})
+			continue
+		}
+		if m.AccessKey == s.AccessKey {
+			continue
+		}
+		kind := DriftStale
+		if m.Expiration.After(s.Expiration) {
+			kind = DriftNewer
+		}
+		drift = append(drift, CredentialsDrift{Kind: kind, Profile: m.Profile, FileExpiration: m.Expiration, StoreExpiration: s.Expiration})
+	}
+	sort.SliceStable(drift, func(i, j int) bool { return drift[i].Profile < drift[j].Profile })
+	return drift
+}
+
+// RemoveManagedCredentials deletes every section sync manages from ~/.aws/credentials,
+// leaving the user's own profiles, and returns the removed profiles.
+func RemoveManagedCredentials() ([]string, error) {
+	managed, err := ReadManagedCredentials()
+	if err != nil || len(managed) == 0 {
+		return nil, err
+	}
+	profiles := make([]string, 0, len(managed))
+	for _, m := range managed {
+		profiles = append(profiles, m.Profile)
+	}
+	if err := RemoveFromAWSCredentials(profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
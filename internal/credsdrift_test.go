@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectCredentialsDrift(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".aws"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	section := func(profile, key string, expires time.Time) string {
+		return "; Managed by cloudctl (Role Session) - Expires: " + FormatTimeZone(expires) + "\n" +
+			"[" + profile + "]\naws_access_key_id = " + key + "\naws_secret_access_key = secret\naws_session_token = token\n\n"
+	}
+	content := "[default]\naws_access_key_id = AKIAUSER\naws_secret_access_key = secret\n\n" +
+		section("in-sync", "ASIASAME", now.Add(time.Hour)) +
+		section("stale", "ASIAOLD", now.Add(-time.Hour)) +
+		section("newer", "ASIANEW", now.Add(3*time.Hour)) +
+		section("gone", "ASIAGONE", now.Add(time.Hour))
+	if err := os.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	managed, err := ReadManagedCredentials()
+	if err != nil {
+		t.Fatalf("ReadManagedCredentials failed: %v", err)
+	}
+	if len(managed) != 4 {
+		t.Fatalf("Expected 4 managed sections, got %+v", managed)
+	}
+	if managed[0].Profile != "in-sync" || managed[0].AccessKey != "ASIASAME" || !managed[0].Expiration.Equal(now.Add(time.Hour)) {
+		t.Errorf("Unexpected first section %+v", managed[0])
+	}
+
+	sessions := []*AWSSession{
+		{Profile: "default", AccessKey: "ASIAOTHER", Expiration: now.Add(time.Hour)},
+		{Profile: "in-sync", AccessKey: "ASIASAME", Expiration: now.Add(time.Hour)},
+		{Profile: "stale", AccessKey: "ASIAFRESH", Expiration: now.Add(time.Hour)},
+		{Profile: "newer", AccessKey: "ASIAPREV", Expiration: now.Add(time.Hour)},
+		{Profile: "never-synced", AccessKey: "ASIAX", Expiration: now.Add(time.Hour)},
+	}
+	drift := DetectCredentialsDrift(sessions, managed)
+	got := map[string]DriftKind{}
+	for _, d := range drift {
+		got[d.Profile] = d.Kind
+	}
+	want := map[string]DriftKind{"stale": DriftStale, "newer": DriftNewer, "gone": DriftOrphaned}
+	if len(got) != len(want) {
+		t.Fatalf("Expected drift %v, got %+v", want, drift)
+	}
+	for profile, kind := range want {
+		if got[profile] != kind {
+			t.Errorf("Expected %s to be %s, got %q", profile, kind, got[profile])
+		}
+	}
+
+	// No file, no drift
+	os.Remove(filepath.Join(home, ".aws", "credentials"))
+	if managed, err := ReadManagedCredentials(); err != nil || len(managed) != 0 {
+		t.Errorf("Expected nothing without a file, got %+v, %v", managed, err)
+	}
+}
+
+func TestRemoveManagedCredentials(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".aws"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(home, ".aws", "credentials")
+	content := "[default]\naws_access_key_id = AKIAUSER\naws_secret_access_key = secret\n\n" +
+		"; Managed by cloudctl (Role Session) - Expires: " + FormatTimeZone(time.Now()) + "\n" +
+		"[prod]\naws_access_key_id = ASIAPROD\naws_secret_access_key = secret\naws_session_token = token\n\n" +
+		"[personal]\naws_access_key_id = AKIAME\naws_secret_access_key = secret\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := RemoveManagedCredentials()
+	if err != nil {
+		t.Fatalf("RemoveManagedCredentials failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "prod" {
+		t.Errorf("Expected prod to be removed, got %v", removed)
+	}
+	b, _ := os.ReadFile(path)
+	got := string(b)
+	if strings.Contains(got, "prod") || strings.Contains(got, "Managed by cloudctl") {
+		t.Errorf("Expected the managed section to be gone:\n%s", got)
+	}
+	if !strings.Contains(got, "[default]") || !strings.Contains(got, "[personal]") {
+		t.Errorf("Expected the user's profiles to stay:\n%s", got)
+	}
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"errors"
@@ -13,6 +14,7 @@ func Encrypt(plainText []byte, key []byte) ([]byte, error) {
 	// Hash the key to ensure it is exactly 32 bytes (AES-256)
 	// This allows users to use any length secret (passphrase or hex key)
 	key32 := sha256.Sum256(key)
+	defer Wipe(key32[:])
 
 	block, err := aes.NewCipher(key32[:])
 	if err != nil {
@@ -32,9 +34,22 @@ func Encrypt(plainText []byte, key []byte) ([]byte, error) {
 	return aesGCM.Seal(nonce, nonce, plainText, nil), nil
 }
 
+// MAC returns the HMAC-SHA256 of data, with a key derived from key separately from the
+// encryption key.
+func MAC(data []byte, key []byte) []byte {
+	material := append([]byte("cloudctl-mac\x00"), key...)
+	macKey := sha256.Sum256(material)
+	Wipe(material)
+	defer Wipe(macKey[:])
+	h := hmac.New(sha256.New, macKey[:])
+	h.Write(data)
+	return h.Sum(nil)
+}
+
 func Decrypt(cipherText []byte, key []byte) ([]byte, error) {
 	// Hash the key to ensure it is exactly 32 bytes
 	key32 := sha256.Sum256(key)
+	defer Wipe(key32[:])
 
 	block, err := aes.NewCipher(key32[:])
 	if err != nil {
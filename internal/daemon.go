@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Daemon files, kept in the user's DaemonDir
+const (
+	DaemonPIDFile    = "daemon.pid"
+	DaemonLogFile    = "daemon.log"
+	DaemonStdoutFile = "daemon.stdout.log"
+	DaemonStderrFile = "daemon.stderr.log"
+	daemonSocketFile = "daemon.sock"
+)
+
+// Requests a daemon answers on its socket, as JSON-RPC methods (see daemonrpc.go) or,
+// for status and stop, as a bare line
+const (
+	DaemonRequestStatus         = "status"
+	DaemonRequestStop           = "stop"
+	DaemonRequestListProfiles   = "list-profiles"
+	DaemonRequestGetCredentials = "get-credentials"
+)
+
+// daemonRequestTimeout bounds one request on the daemon socket, for both sides.
+const daemonRequestTimeout = 2 * time.Second
+
+// DaemonStatus is what a running daemon reports on its socket.
+type DaemonStatus struct {
+	PID      int       `json:"pid"`
+	User     string    `json:"user"`
+	Started  time.Time `json:"started"`
+	Interval int       `json:"interval_minutes"`
+}
+
+// daemonUser names the current user's daemon files: the uid, or the SID on Windows.
+func daemonUser() string {
+	if uid := os.Getuid(); uid >= 0 {
+		return strconv.Itoa(uid)
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Uid
+	}
+	return "default"
+}
+
+// DaemonDir returns the directory holding the current user's daemon PID file, logs and
+// socket: daemon-<uid> in the store directory. Users sharing a CLOUDCTL_HOME each get
+// their own, so their daemons can run side by side.
+func DaemonDir() string {
+	return filepath.Join(StoreDir(), "daemon-"+daemonUser())
+}
+
+// DaemonPath returns the path of one of the current user's daemon files.
+func DaemonPath(name string) string {
+	return filepath.Join(DaemonDir(), name)
+}
+
+// LegacyDaemonPath returns where versions before per-user daemon directories kept a
+// daemon file, so daemons they started can still be found and stopped.
+func LegacyDaemonPath(name string) string {
+	return filepath.Join(StoreDir(), name)
+}
+
+// EnsureDaemonDir creates the current user's daemon directory, or checks that the
+// existing one is a directory of this user, and makes sure only this user can open it.
+func EnsureDaemonDir() (string, error) {
+	dir := DaemonDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create daemon directory: %w", err)
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	if uid, ok := ownerUID(info); ok && uid != os.Getuid() {
+		return "", fmt.Errorf("%s belongs to uid %d, not to you", dir, uid)
+	}
+	if info.Mode().Perm() != 0700 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return "", fmt.Errorf("failed to restrict %s: %w", dir, err)
+		}
+	}
+	return dir, nil
+}
+
+// errPeerCredUnsupported is returned where the uid of a socket peer can't be read. The
+// daemon then doesn't listen at all rather than accept connections it can't check.
+var errPeerCredUnsupported = errors.New("socket peer credentials are not supported on this platform")
+
+// socketPeerUID returns the uid of the process on the other end of conn. Tests replace it.
+var socketPeerUID = peerUID
+
+// DaemonHandlers are what the daemon does for requests beyond reporting its status.
+type DaemonHandlers struct {
+	// Stop is called when a stop request comes in.
+	Stop func()
+	// Refused (if set) is called with the uid of every refused connection.
+	Refused func(uid int)
+	// Credentials returns a profile's session, refreshed if needed, for get-credentials.
+	// It is only called once the caller has authenticated; without it, get-credentials
+	// is not offered.
+	Credentials func(ctx context.Context, profile, secret string) (*AWSSession, error)
+}
+
+// DaemonServer answers requests on the daemon socket. Connections from other users are
+// refused before anything is read from them.
+type DaemonServer struct {
+	ctx      context.Context
+	listener net.Listener
+	status   DaemonStatus
+	handlers DaemonHandlers
+
+	once sync.Once
+}
+
+// ListenDaemon starts the current user's daemon socket, answering requests with status
+// (its User is filled in) and handlers. ctx bounds the work done for requests.
+func ListenDaemon(ctx context.Context, status DaemonStatus, handlers DaemonHandlers) (*DaemonServer, error) {
+	if _, err := EnsureDaemonDir(); err != nil {
+		return nil, err
+	}
+	if !peerCredSupported {
+		return nil, errPeerCredUnsupported
+	}
+
+	path := DaemonPath(daemonSocketFile)
+	// A socket left behind by a daemon that didn't exit cleanly; the PID file already
+	// keeps a second daemon from starting
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	// The directory already keeps other users out; the socket mode is a second fence
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict %s: %w", path, err)
+	}
+
+	status.User = daemonUser()
+	s := &DaemonServer{ctx: ctx, listener: listener, status: status, handlers: handlers}
+	go s.serve()
+	return s, nil
+}
+
+func (s *DaemonServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn.(*net.UnixConn))
+	}
+}
+
+func (s *DaemonServer) handle(conn *net.UnixConn) {
+	defer conn.Close()
+	uid, err := socketPeerUID(conn)
+	if err != nil || uid != os.Getuid() {
+		if s.handlers.Refused != nil {
+			s.handlers.Refused(uid)
+		}
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(daemonRequestTimeout))
+	request, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+	request = strings.TrimSpace(request)
+	if strings.HasPrefix(request, "{") {
+		// A get-credentials call may refresh the session, so the answer gets more time
+		conn.SetWriteDeadline(time.Now().Add(daemonCallTimeout))
+		s.serveRPC(conn, request)
+		return
+	}
+
+	// Bare status and stop lines, as sent by versions before JSON-RPC
+	if request != DaemonRequestStatus && request != DaemonRequestStop {
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(daemonRequestTimeout))
+	b, _ := json.Marshal(s.status)
+	fmt.Fprintln(conn, string(b))
+	if request == DaemonRequestStop {
+		s.handlers.Stop()
+	}
+}
+
+// Close stops listening and removes the socket.
+func (s *DaemonServer) Close() error {
+	var err error
+	s.once.Do(func() {
+		err = s.listener.Close()
+		os.Remove(DaemonPath(daemonSocketFile))
+	})
+	return err
+}
+
+// RequestDaemon sends a status or stop request to the current user's daemon and returns
+// its status. It fails when no daemon of this user is listening, e.g. one started by an
+// older version.
+func RequestDaemon(request string) (DaemonStatus, error) {
+	var status DaemonStatus
+	err := CallDaemon(request, nil, &status)
+	return status, err
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package internal
+
+import (
+	"net"
+	"os"
+)
+
+const peerCredSupported = false
+
+func peerUID(conn *net.UnixConn) (int, error) {
+	return -1, errPeerCredUnsupported
+}
+
+// ownerUID can't tell the owner here; the directory mode is relied on instead.
+func ownerUID(info os.FileInfo) (int, bool) {
+	return -1, false
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func setupDaemonDir(t *testing.T) {
+	t.Helper()
+	original := storeDir
+	storeDir = t.TempDir()
+	t.Cleanup(func() { storeDir = original })
+}
+
+func TestEnsureDaemonDir(t *testing.T) {
+	setupDaemonDir(t)
+
+	dir, err := EnsureDaemonDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(dir) != storeDir || filepath.Base(dir) != "daemon-"+daemonUser() {
+		t.Errorf("Unexpected daemon directory %s", dir)
+	}
+	if DaemonPath(DaemonPIDFile) != filepath.Join(dir, DaemonPIDFile) {
+		t.Errorf("Unexpected PID file path %s", DaemonPath(DaemonPIDFile))
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// Loosened permissions are tightened again
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnsureDaemonDir(); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0700 {
+		t.Errorf("Expected 0700, got %v", info.Mode().Perm())
+	}
+
+	// A file in the directory's place is not used
+	os.RemoveAll(dir)
+	os.WriteFile(dir, nil, 0600)
+	if _, err := EnsureDaemonDir(); err == nil {
+		t.Error("Expected a file in place of the directory to fail")
+	}
+}
+
+func TestDaemonSocket(t *testing.T) {
+	if !peerCredSupported {
+		t.Skip("No peer credentials on this platform")
+	}
+	setupDaemonDir(t)
+
+	stopped := make(chan struct{})
+	started := time.Now().Truncate(time.Second)
+	server, err := ListenDaemon(context.Background(), DaemonStatus{PID: 42, Started: started, Interval: 5}, DaemonHandlers{Stop: func() { close(stopped) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	info, err := os.Stat(DaemonPath(daemonSocketFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected socket mode 0600, got %v", info.Mode().Perm())
+	}
+
+	status, err := RequestDaemon(DaemonRequestStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.PID != 42 || status.User != daemonUser() || !status.Started.Equal(started) || status.Interval != 5 {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	if _, err := RequestDaemon(DaemonRequestStop); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop request did not stop the daemon")
+	}
+
+	server.Close()
+	if _, err := os.Stat(DaemonPath(daemonSocketFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket to be removed, got %v", err)
+	}
+	if _, err := RequestDaemon(DaemonRequestStatus); err == nil {
+		t.Error("Expected a request to a closed socket to fail")
+	}
+}
+
+func TestDaemonSocketRefusesOtherUsers(t *testing.T) {
+	if !peerCredSupported {
+		t.Skip("No peer credentials on this platform")
+	}
+	setupDaemonDir(t)
+
+	original := socketPeerUID
+	socketPeerUID = func(*net.UnixConn) (int, error) { return os.Getuid() + 1, nil }
+	t.Cleanup(func() { socketPeerUID = original })
+
+	refused := make(chan int, 1)
+	server, err := ListenDaemon(context.Background(), DaemonStatus{PID: 42}, DaemonHandlers{
+		Stop:    func() { t.Error("Stopped by another user") },
+		Refused: func(uid int) { refused <- uid },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	if _, err := RequestDaemon(DaemonRequestStop); err == nil {
+		t.Error("Expected the request of another user to fail")
+	}
+	select {
+	case uid := <-refused:
+		if uid != os.Getuid()+1 {
+			t.Errorf("Expected uid %d to be reported, got %d", os.Getuid()+1, uid)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Refused connection was not reported")
+	}
+}
@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// daemonCallTimeout bounds a JSON-RPC call on the daemon socket; get-credentials may
+// refresh the session first, which takes an STS call or two.
+const daemonCallTimeout = 60 * time.Second
+
+// JSON-RPC 2.0 error codes. The ones from -32000 down are cloudctl's.
+const (
+	DaemonErrParse          = -32700
+	DaemonErrInvalidRequest = -32600
+	DaemonErrMethodNotFound = -32601
+	DaemonErrInvalidParams  = -32602
+	DaemonErrFailed         = -32000
+	DaemonErrUnauthorized   = -32001
+)
+
+// DaemonError is a JSON-RPC error answered by the daemon.
+type DaemonError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *DaemonError) Error() string {
+	return "daemon: " + e.Message
+}
+
+// DaemonProfile is a stored profile as listed by list-profiles: metadata from the
+// session index only, so listing needs neither the secret nor an unlocked store.
+type DaemonProfile struct {
+	Profile string `json:"profile"`
+	// Expiration is missing when the profile is not in the index yet.
+	Expiration *time.Time `json:"expiration,omitempty"`
+	MFA        bool       `json:"mfa"`
+}
+
+// DaemonProfiles is the result of list-profiles.
+type DaemonProfiles struct {
+	Profiles []DaemonProfile `json:"profiles"`
+}
+
+// DaemonCredentialsParams are the params of get-credentials. Secret is the encryption
+// secret, as for --secret; it may be left out when the store uses an envelope provider.
+type DaemonCredentialsParams struct {
+	Profile string `json:"profile"`
+	Secret  string `json:"secret,omitempty"`
+}
+
+// DaemonCredentials is the result of get-credentials: the credential_process output,
+// plus the session's region.
+type DaemonCredentials struct {
+	ProcessCredentials
+	Region string `json:"Region,omitempty"`
+}
+
+type daemonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type daemonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *DaemonError    `json:"error,omitempty"`
+}
+
+// serveRPC answers one JSON-RPC request line.
+func (s *DaemonServer) serveRPC(conn net.Conn, line string) {
+	var req daemonRPCRequest
+	resp := daemonRPCResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+	after := func() {}
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		resp.Error = &DaemonError{DaemonErrParse, "parse error"}
+	} else {
+		if len(req.ID) > 0 {
+			resp.ID = req.ID
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			resp.Error = &DaemonError{DaemonErrInvalidRequest, "invalid request"}
+		} else {
+			resp.Result, resp.Error, after = s.call(req.Method, req.Params)
+		}
+	}
+	b, _ := json.Marshal(resp)
+	fmt.Fprintln(conn, string(b))
+	after()
+}
+
+// call runs a method. after is run once the answer is sent.
+func (s *DaemonServer) call(method string, params json.RawMessage) (result any, rpcErr *DaemonError, after func()) {
+	after = func() {}
+	switch method {
+	case DaemonRequestStatus:
+		return s.status, nil, after
+	case DaemonRequestStop:
+		return s.status, nil, s.handlers.Stop
+	case DaemonRequestListProfiles:
+		sessions, err := ListIndexedSessions()
+		if err != nil {
+			return nil, &DaemonError{DaemonErrFailed, err.Error()}, after
+		}
+		profiles := DaemonProfiles{Profiles: []DaemonProfile{}}
+		for _, is := range sessions {
+			p := DaemonProfile{Profile: is.Profile, MFA: is.MFA}
+			if is.Known() {
+				p.Expiration = &is.Expiration
+			}
+			profiles.Profiles = append(profiles.Profiles, p)
+		}
+		return profiles, nil, after
+	case DaemonRequestGetCredentials:
+		if s.handlers.Credentials == nil {
+			break
+		}
+		var p DaemonCredentialsParams
+		if len(params) == 0 || json.Unmarshal(params, &p) != nil || p.Profile == "" {
+			return nil, &DaemonError{DaemonErrInvalidParams, "params must be {\"profile\": ..., \"secret\": ...}"}, after
+		}
+		secret, err := authorizeDaemonCaller(p.Secret)
+		if err != nil {
+			return nil, &DaemonError{DaemonErrUnauthorized, err.Error()}, after
+		}
+		session, err := s.handlers.Credentials(s.ctx, p.Profile, secret)
+		if err != nil {
+			return nil, &DaemonError{DaemonErrFailed, err.Error()}, after
+		}
+		return DaemonCredentials{ProcessCredentials: NewProcessCredentials(session), Region: session.Region}, nil, after
+	}
+	return nil, &DaemonError{DaemonErrMethodNotFound, fmt.Sprintf("unknown method '%s'", method)}, after
+}
+
+// authorizeDaemonCaller checks the secret a caller sent for get-credentials against the
+// daemon's own, so the socket never hands out more than the caller could decrypt itself.
+// The store must be unlocked. With an envelope provider and no secret, the provider's
+// own access control applies, as it does for commands.
+func authorizeDaemonCaller(secret string) (string, error) {
+	own, err := GetSecret("")
+	if err != nil {
+		return "", err
+	}
+	if own == "" {
+		return "", nil
+	}
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(own)) != 1 {
+		return "", errors.New("wrong or missing secret")
+	}
+	return own, nil
+}
+
+// CallDaemon calls a JSON-RPC method on the current user's daemon, decoding its result
+// into result (if not nil). Errors answered by the daemon are *DaemonError.
+func CallDaemon(method string, params, result any) error {
+	conn, err := net.DialTimeout("unix", DaemonPath(daemonSocketFile), daemonRequestTimeout)
+	if err != nil {
+		return fmt.Errorf("daemon is not listening: %w", err)
+	}
+	defer conn.Close()
+
+	req := map[string]any{"jsonrpc": "2.0", "id": 1, "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+	b, _ := json.Marshal(req)
+	conn.SetDeadline(time.Now().Add(daemonCallTimeout))
+	if _, err := fmt.Fprintln(conn, string(b)); err != nil {
+		return err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("no answer from the daemon: %w", err)
+	}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *DaemonError    `json:"error"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("unexpected answer from the daemon: %w", err)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result != nil {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("unexpected answer from the daemon: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startRPCDaemon serves the daemon socket with a stored dev-admin session, returned as is
+// by the Credentials handler.
+func startRPCDaemon(t *testing.T, key string) {
+	t.Helper()
+	if !peerCredSupported {
+		t.Skip("No peer credentials on this platform")
+	}
+	setupTestDir(t)
+	setupDaemonDir(t)
+	setTestConfig(t, nil)
+	t.Setenv("CLOUDCTL_SECRET", key)
+
+	s := testSession("dev-admin")
+	s.Region = "eu-west-1"
+	if err := SaveCredentials(s.Profile, s, key); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := ListenDaemon(context.Background(), DaemonStatus{PID: 42}, DaemonHandlers{
+		Stop: func() {},
+		Credentials: func(ctx context.Context, profile, secret string) (*AWSSession, error) {
+			return LoadCredentials(profile, secret)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+}
+
+func TestDaemonListProfiles(t *testing.T) {
+	startRPCDaemon(t, "1234567890ABCDEF1234567890ABCDEF")
+	// Listing needs no secret
+	t.Setenv("CLOUDCTL_SECRET", "")
+
+	var result DaemonProfiles
+	if err := CallDaemon(DaemonRequestListProfiles, nil, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Profiles) != 1 || result.Profiles[0].Profile != "dev-admin" || result.Profiles[0].Expiration == nil {
+		t.Errorf("Unexpected profiles %+v", result.Profiles)
+	}
+}
+
+func TestDaemonGetCredentials(t *testing.T) {
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	startRPCDaemon(t, key)
+
+	var creds DaemonCredentials
+	err := CallDaemon(DaemonRequestGetCredentials, DaemonCredentialsParams{Profile: "dev-admin", Secret: key}, &creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Version != 1 || creds.AccessKeyID == "" || creds.SessionToken == "" || creds.Region != "eu-west-1" {
+		t.Errorf("Unexpected credentials %+v", creds)
+	}
+
+	for name, params := range map[string]any{
+		"wrong secret":   DaemonCredentialsParams{Profile: "dev-admin", Secret: "FEDCBA0987654321FEDCBA0987654321"},
+		"missing secret": DaemonCredentialsParams{Profile: "dev-admin"},
+	} {
+		var rpcErr *DaemonError
+		if err := CallDaemon(DaemonRequestGetCredentials, params, nil); !errors.As(err, &rpcErr) || rpcErr.Code != DaemonErrUnauthorized {
+			t.Errorf("%s: expected an unauthorized error, got %v", name, err)
+		}
+	}
+
+	var rpcErr *DaemonError
+	if err := CallDaemon(DaemonRequestGetCredentials, nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != DaemonErrInvalidParams {
+		t.Errorf("Expected an invalid params error, got %v", err)
+	}
+	if err := CallDaemon(DaemonRequestGetCredentials, DaemonCredentialsParams{Profile: "nope", Secret: key}, nil); !errors.As(err, &rpcErr) || rpcErr.Code != DaemonErrFailed {
+		t.Errorf("Expected a failed call for an unknown profile, got %v", err)
+	}
+	if err := CallDaemon("get-secrets", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != DaemonErrMethodNotFound {
+		t.Errorf("Expected an unknown method error, got %v", err)
+	}
+}
+
+func TestDaemonBareRequests(t *testing.T) {
+	startRPCDaemon(t, "1234567890ABCDEF1234567890ABCDEF")
+
+	send := func(line string) string {
+		conn, err := net.Dial("unix", DaemonPath(daemonSocketFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		conn.Write([]byte(line + "\n"))
+		answer, _ := bufio.NewReader(conn).ReadString('\n')
+		return answer
+	}
+	// The line protocol of earlier versions is still answered
+	if answer := send("status"); !strings.Contains(answer, `"pid":42`) {
+		t.Errorf("Unexpected answer to a bare status: %q", answer)
+	}
+	if answer := send("{not json"); !strings.Contains(answer, `"code":-32700`) {
+		t.Errorf("Expected a parse error, got %q", answer)
+	}
+}
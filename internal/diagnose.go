@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DiagnoseFiles returns the files of a diagnose bundle taken from cloudctl's own state:
+// the last logLines lines of the daemon logs, the alias files and the config, each
+// passed through RedactSecrets. Files that don't exist are left out.
+func DiagnoseFiles(logLines int) map[string][]byte {
+	files := make(map[string][]byte)
+	for _, name := range []string{DaemonLogFile, DaemonStdoutFile, DaemonStderrFile} {
+		if tail, err := tailFile(DaemonPath(name), logLines); err == nil {
+			files["logs/"+name] = []byte(RedactSecrets(tail))
+		}
+	}
+	// Alias files only hold names and ARNs, but still pass them through redaction
+	for _, path := range []string{roleStorePath, mfaStorePath} {
+		if data, err := os.ReadFile(path); err == nil {
+			files["aliases/"+filepath.Base(path)] = []byte(RedactSecrets(string(data)))
+		}
+	}
+	// The config can hold endpoint secret keys and Secrets Manager settings
+	if data, err := os.ReadFile(configPath); err == nil {
+		files["config.json"] = []byte(RedactSecrets(string(data)))
+	}
+	return files
+}
+
+// tailFile returns the last n lines of a file.
+func tailFile(path string, n int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var approverKeyPath = filepath.Join(storeDir, "approver.key")
+
+// DualControlWindow is how long a time-delayed request stays usable once its delay has
+// passed. After that a new request starts the delay again.
+const DualControlWindow = time.Hour
+
+// ErrNoApproverKey is returned by ApproverPublicKey before `cloudctl approve --init`.
+var ErrNoApproverKey = errors.New("no approver key; run 'cloudctl approve --init' first")
+
+// Approval is the signed content of an approval token: Approver allows Requester to log
+// in to Role once before Expires.
+type Approval struct {
+	Role      string    `json:"role"`
+	Requester string    `json:"requester"`
+	Approver  string    `json:"approver"`
+	Nonce     string    `json:"nonce"`
+	Expires   time.Time `json:"expires"`
+}
+
+// RequiresDualControl reports whether logging in to roleArn needs an approval or a
+// time-delayed request, because it matches security.dual_control_roles.
+func (c *Config) RequiresDualControl(roleArn string) bool {
+	for _, pattern := range c.Security.DualControlRoles {
+		if matchGlob(pattern, roleArn) {
+			return true
+		}
+	}
+	return false
+}
+
+// InitApproverKey creates this machine's approver signing key if there is none yet and
+// returns its public key, which teammates add to security.approvers.
+func InitApproverKey() (string, error) {
+	if pub, err := ApproverPublicKey(); err == nil {
+		return pub, nil
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(approverKeyPath), 0700); err != nil {
+		return "", fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	seed := base64.StdEncoding.EncodeToString(priv.Seed())
+	if err := os.WriteFile(approverKeyPath, []byte(seed+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write approver key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(pub), nil
+}
+
+func loadApproverKey() (ed25519.PrivateKey, error) {
+	b, err := os.ReadFile(approverKeyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoApproverKey
+		}
+		return nil, fmt.Errorf("failed to read approver key: %w", err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid approver key in %s", approverKeyPath)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// ApproverPublicKey returns this machine's approver public key.
+func ApproverPublicKey() (string, error) {
+	priv, err := loadApproverKey()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)), nil
+}
+
+// IssueApproval signs an approval with this machine's approver key. The token is the
+// JSON approval and its signature, each base64url-encoded and joined by a dot.
+func IssueApproval(role, requester, approver string, validFor time.Duration) (string, *Approval, error) {
+	priv, err := loadApproverKey()
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	a := &Approval{
+		Role:      role,
+		Requester: requester,
+		Approver:  approver,
+		Nonce:     hex.EncodeToString(nonce),
+		Expires:   time.Now().Add(validFor).UTC().Truncate(time.Second),
+	}
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return "", nil, err
+	}
+	sig := ed25519.Sign(priv, payload)
+	token := base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sig)
+	return token, a, nil
+}
+
+// VerifyApproval checks that token was signed by one of approvers (name to public key)
+// for role and requester, has not expired and is not signed with this machine's own
+// approver key. Whether it was used before is checked separately with ApprovalUsed.
+func VerifyApproval(token, role, requester string, approvers map[string]string, now time.Time) (*Approval, error) {
+	encPayload, encSig, ok := strings.Cut(strings.TrimSpace(token), ".")
+	payload, err1 := base64.RawURLEncoding.DecodeString(encPayload)
+	sig, err2 := base64.RawURLEncoding.DecodeString(encSig)
+	if !ok || err1 != nil || err2 != nil {
+		return nil, errors.New("malformed approval token")
+	}
+	var a Approval
+	if err := json.Unmarshal(payload, &a); err != nil {
+		return nil, errors.New("malformed approval token")
+	}
+
+	encKey, ok := approvers[a.Approver]
+	if !ok {
+		return nil, fmt.Errorf("approval is from '%s', who is not in security.approvers", a.Approver)
+	}
+	pub, err := base64.StdEncoding.DecodeString(encKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key for approver '%s' in security.approvers", a.Approver)
+	}
+	if !ed25519.Verify(pub, payload, sig) {
+		return nil, fmt.Errorf("approval signature does not match approver '%s'", a.Approver)
+	}
+	if own, err := ApproverPublicKey(); err == nil && own == encKey {
+		return nil, errors.New("you can't approve your own login; ask a teammate")
+	}
+	if a.Approver == requester {
+		return nil, errors.New("you can't approve your own login; ask a teammate")
+	}
+	if a.Role != role {
+		return nil, fmt.Errorf("approval is for %s, not %s", a.Role, role)
+	}
+	if a.Requester != requester {
+		return nil, fmt.Errorf("approval is for user '%s', not '%s'", a.Requester, requester)
+	}
+	if now.After(a.Expires) {
+		return nil, fmt.Errorf("approval expired at %s", FormatTime(a.Expires))
+	}
+	return &a, nil
+}
+
+// ValidateApprovers checks that every security.approvers entry is an ed25519 public key.
+func ValidateApprovers(approvers map[string]string) error {
+	for name, key := range approvers {
+		if pub, err := base64.StdEncoding.DecodeString(key); err != nil || len(pub) != ed25519.PublicKeySize {
+			return fmt.Errorf("approver '%s' must be a public key printed by 'cloudctl approve --init'", name)
+		}
+	}
+	return nil
+}
+
+// ApprovalUsed reports whether an approval token with this nonce was already used.
+func ApprovalUsed(events []AuditEvent, nonce string) bool {
+	for _, e := range events {
+		if e.Event == AuditApprovalUsed && e.Nonce == nonce {
+			return true
+		}
+	}
+	return false
+}
+
+// PendingDualControl returns when user last requested a time-delayed login to role, if
+// that request is still usable: its delay hasn't passed yet, or passed less than
+// DualControlWindow ago and no login used it.
+func PendingDualControl(events []AuditEvent, role, user string, delay time.Duration, now time.Time) (time.Time, bool) {
+	var requested time.Time
+	for _, e := range events {
+		if e.Role != role || e.User != user {
+			continue
+		}
+		switch e.Event {
+		case AuditDualControlRequested:
+			requested = e.Time
+		case AuditDualControlDelayEnded:
+			// Each request allows one login
+			requested = time.Time{}
+		}
+	}
+	if requested.IsZero() || now.After(requested.Add(delay+DualControlWindow)) {
+		return time.Time{}, false
+	}
+	return requested, true
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupApproverKey points the approver key and audit log at a temp dir, creates a key
+// and returns its public key.
+func setupApproverKey(t *testing.T) string {
+	dir := t.TempDir()
+	originalKey, originalLog := approverKeyPath, auditLogPath
+	approverKeyPath = filepath.Join(dir, "approver.key")
+	auditLogPath = filepath.Join(dir, "audit.log")
+	t.Cleanup(func() {
+		approverKeyPath, auditLogPath = originalKey, originalLog
+	})
+	pub, err := InitApproverKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub
+}
+
+func TestApprovalRoundTrip(t *testing.T) {
+	pub := setupApproverKey(t)
+	role := "arn:aws:iam::123456789012:role/BreakGlass"
+
+	token, issued, err := IssueApproval(role, "alice", "bob", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The requester's machine has a different approver key of its own
+	approverKeyPath = filepath.Join(t.TempDir(), "approver.key")
+	approvers := map[string]string{"bob": pub}
+
+	a, err := VerifyApproval(token, role, "alice", approvers, time.Now())
+	if err != nil {
+		t.Fatalf("VerifyApproval failed: %v", err)
+	}
+	if a.Approver != "bob" || a.Nonce != issued.Nonce {
+		t.Errorf("Unexpected approval: %+v", a)
+	}
+
+	checks := map[string]func() error{
+		"wrong role": func() error {
+			_, err := VerifyApproval(token, "arn:aws:iam::123456789012:role/Other", "alice", approvers, time.Now())
+			return err
+		},
+		"wrong requester": func() error {
+			_, err := VerifyApproval(token, role, "mallory", approvers, time.Now())
+			return err
+		},
+		"expired": func() error {
+			_, err := VerifyApproval(token, role, "alice", approvers, time.Now().Add(2*time.Hour))
+			return err
+		},
+		"unknown approver": func() error {
+			_, err := VerifyApproval(token, role, "alice", map[string]string{}, time.Now())
+			return err
+		},
+		"forged key": func() error {
+			other, _, _ := ed25519.GenerateKey(nil)
+			forged := map[string]string{"bob": base64.StdEncoding.EncodeToString(other)}
+			_, err := VerifyApproval(token, role, "alice", forged, time.Now())
+			return err
+		},
+		"tampered": func() error {
+			encPayload, sig, _ := strings.Cut(token, ".")
+			payload, _ := base64.RawURLEncoding.DecodeString(encPayload)
+			payload = []byte(strings.Replace(string(payload), "BreakGlass", "Admin", 1))
+			tampered := base64.RawURLEncoding.EncodeToString(payload) + "." + sig
+			_, err := VerifyApproval(tampered, "arn:aws:iam::123456789012:role/Admin", "alice", approvers, time.Now())
+			return err
+		},
+	}
+	for name, check := range checks {
+		if check() == nil {
+			t.Errorf("Expected %s approval to be rejected", name)
+		}
+	}
+}
+
+func TestApprovalRejectsOwnKey(t *testing.T) {
+	pub := setupApproverKey(t)
+	role := "arn:aws:iam::123456789012:role/BreakGlass"
+	token, _, err := IssueApproval(role, "alice", "alice-laptop", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = VerifyApproval(token, role, "alice", map[string]string{"alice-laptop": pub}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "your own login") {
+		t.Errorf("Expected self-approval to be rejected, got %v", err)
+	}
+}
+
+func TestPendingDualControl(t *testing.T) {
+	setupApproverKey(t)
+	role := "arn:aws:iam::123456789012:role/BreakGlass"
+	delay := 30 * time.Minute
+	start := time.Now().Add(-time.Hour)
+
+	if _, ok := PendingDualControl(nil, role, "alice", delay, start); ok {
+		t.Error("Expected no pending request in an empty log")
+	}
+
+	if err := AppendAudit(AuditEvent{Time: start, Event: AuditDualControlRequested, User: "alice", Role: role}); err != nil {
+		t.Fatal(err)
+	}
+	events, err := ReadAuditLog()
+	if err != nil || len(events) != 1 {
+		t.Fatalf("ReadAuditLog = %v, %v", events, err)
+	}
+
+	if requested, ok := PendingDualControl(events, role, "alice", delay, start.Add(time.Minute)); !ok || !requested.Equal(start) {
+		t.Errorf("Expected a pending request from %v, got %v %v", start, requested, ok)
+	}
+	if _, ok := PendingDualControl(events, role, "bob", delay, start.Add(time.Minute)); ok {
+		t.Error("Another user's request must not count")
+	}
+	if _, ok := PendingDualControl(events, role, "alice", delay, start.Add(delay+DualControlWindow+time.Minute)); ok {
+		t.Error("Expected the request to lapse after the window")
+	}
+
+	events = append(events, AuditEvent{Time: start.Add(delay), Event: AuditDualControlDelayEnded, User: "alice", Role: role})
+	if _, ok := PendingDualControl(events, role, "alice", delay, start.Add(delay+time.Minute)); ok {
+		t.Error("Expected a request to allow only one login")
+	}
+}
+
+func TestRequiresDualControl(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Security.DualControlRoles = []string{"*:role/BreakGlass*"}
+	if !cfg.RequiresDualControl("arn:aws:iam::123456789012:role/BreakGlassAdmin") {
+		t.Error("Expected BreakGlassAdmin to require dual control")
+	}
+	if cfg.RequiresDualControl("arn:aws:iam::123456789012:role/ReadOnly") {
+		t.Error("Expected ReadOnly not to require dual control")
+	}
+}
@@ -0,0 +1,41 @@
+package internal
+
+import "strings"
+
+// expiredTokenMarkers are printed by the AWS CLI, SDKs and tools like terraform when a
+// request was signed with expired session credentials.
+var expiredTokenMarkers = []string{
+	"ExpiredToken",
+	"security token included in the request is expired",
+	"The provided token has expired",
+}
+
+// IsExpiredTokenError reports whether command output mentions expired credentials.
+func IsExpiredTokenError(output string) bool {
+	for _, m := range expiredTokenMarkers {
+		if strings.Contains(output, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// TailBuffer is an io.Writer that keeps only the last Max bytes written, for scanning
+// the end of a long-running command's output.
+type TailBuffer struct {
+	Max int
+	buf []byte
+}
+
+func (t *TailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.Max; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the retained output.
+func (t *TailBuffer) String() string {
+	return string(t.buf)
+}
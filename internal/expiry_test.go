@@ -0,0 +1,41 @@
+package internal
+
+import "testing"
+
+func TestIsExpiredTokenError(t *testing.T) {
+	expired := []string{
+		"An error occurred (ExpiredToken) when calling the ListBuckets operation: The security token included in the request is expired",
+		"Error: error configuring Terraform AWS Provider: ExpiredTokenException: The security token included in the request is expired",
+		"operation error S3: ListBuckets, https response error StatusCode: 400, api error ExpiredToken",
+		"The provided token has expired.",
+	}
+	for _, out := range expired {
+		if !IsExpiredTokenError(out) {
+			t.Errorf("Expected expiry to be detected in %q", out)
+		}
+	}
+
+	other := []string{
+		"An error occurred (AccessDenied) when calling the ListBuckets operation",
+		"An error occurred (InvalidClientTokenId): The security token included in the request is invalid",
+		"",
+	}
+	for _, out := range other {
+		if IsExpiredTokenError(out) {
+			t.Errorf("Did not expect expiry in %q", out)
+		}
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	tail := &TailBuffer{Max: 10}
+	tail.Write([]byte("hello "))
+	tail.Write([]byte("world, "))
+	n, err := tail.Write([]byte("ExpiredToken"))
+	if n != len("ExpiredToken") || err != nil {
+		t.Fatalf("Unexpected write result %d, %v", n, err)
+	}
+	if got := tail.String(); got != "piredToken" {
+		t.Errorf("Expected the last 10 bytes, got %q", got)
+	}
+}
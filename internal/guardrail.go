@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// syncedFolderMarkers maps path fragments of common sync clients to their names. Paths
+// are compared lower-case with forward slashes.
+var syncedFolderMarkers = []struct {
+	fragment string
+	provider string
+}{
+	{"/library/mobile documents/", "iCloud Drive"},
+	{"/iclouddrive/", "iCloud Drive"},
+	{"/library/cloudstorage/onedrive", "OneDrive"},
+	{"/library/cloudstorage/googledrive", "Google Drive"},
+	{"/library/cloudstorage/dropbox", "Dropbox"},
+	{"/library/cloudstorage/box", "Box"},
+	{"/dropbox/", "Dropbox"},
+	{"/onedrive/", "OneDrive"},
+	{"/onedrive - ", "OneDrive"},
+	{"/google drive/", "Google Drive"},
+	{"/box sync/", "Box"},
+}
+
+// SyncedFolderProvider returns the sync client a path lives under, or "".
+func SyncedFolderProvider(path string) string {
+	p := strings.ToLower(strings.ReplaceAll(path, `\`, "/")) + "/"
+	for _, m := range syncedFolderMarkers {
+		if strings.Contains(p, m.fragment) {
+			return m.provider
+		}
+	}
+	return ""
+}
+
+// StorageWarning is a reason credentials on disk may be exposed.
+type StorageWarning struct {
+	Path   string
+	Reason string
+	// Synced is true for cloud-synced folders, false for loose permissions.
+	Synced bool
+}
+
+// CheckStorageExposure looks for credential directories inside cloud-synced folders
+// (following symlinks) and for files or directories readable by other users.
+func CheckStorageExposure(home string) []StorageWarning {
+	var warnings []StorageWarning
+
+	store := filepath.Join(home, ".cloudctl")
+	if os.Getenv("CLOUDCTL_HOME") != "" {
+		store = storeDir
+	}
+	for _, dir := range []string{store, filepath.Join(home, ".aws")} {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+		if provider := SyncedFolderProvider(resolved); provider != "" {
+			warnings = append(warnings, StorageWarning{
+				Path:   dir,
+				Reason: fmt.Sprintf("is synced by %s (%s)", provider, resolved),
+				Synced: true,
+			})
+		}
+	}
+
+	// Windows ACLs don't map to Unix permission bits
+	if runtime.GOOS == "windows" {
+		return warnings
+	}
+
+	checks := []struct {
+		path string
+		want os.FileMode
+	}{
+		{store, 0700},
+		{filepath.Join(store, "credentials.json"), 0600},
+		{filepath.Join(store, "roles.json"), 0600},
+		{filepath.Join(store, "mfa.json"), 0600},
+		{filepath.Join(home, ".aws", "credentials"), 0600},
+	}
+	for _, c := range checks {
+		info, err := os.Stat(c.path)
+		if err != nil {
+			continue
+		}
+		mode := info.Mode().Perm()
+		if mode&0077 != 0 {
+			warnings = append(warnings, StorageWarning{
+				Path:   c.path,
+				Reason: fmt.Sprintf("is accessible by other users (%04o, expected %04o)", mode, c.want),
+			})
+		}
+	}
+	return warnings
+}
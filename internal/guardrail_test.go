@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyncedFolderProvider(t *testing.T) {
+	tests := map[string]string{
+		"/Users/me/Library/Mobile Documents/com~apple~CloudDocs/dotfiles/.aws": "iCloud Drive",
+		"/Users/me/Library/CloudStorage/OneDrive-Contoso/.cloudctl":            "OneDrive",
+		"/Users/me/Library/CloudStorage/GoogleDrive-me@example.com/My Drive":   "Google Drive",
+		"/home/me/Dropbox/config/.cloudctl":                                    "Dropbox",
+		`C:\Users\me\OneDrive - Contoso\.aws`:                                  "OneDrive",
+		"/home/me/.cloudctl":                                                   "",
+		"/home/me/dropboxes/.aws":                                              "",
+	}
+	for path, want := range tests {
+		if got := SyncedFolderProvider(path); got != want {
+			t.Errorf("SyncedFolderProvider(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestCheckStorageExposure(t *testing.T) {
+	home := t.TempDir()
+
+	// ~/.aws is a symlink into a Dropbox folder
+	synced := filepath.Join(home, "Dropbox", "aws")
+	if err := os.MkdirAll(synced, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(synced, filepath.Join(home, ".aws")); err != nil {
+		t.Fatal(err)
+	}
+
+	// ~/.cloudctl is world-readable
+	store := filepath.Join(home, ".cloudctl")
+	if err := os.Mkdir(store, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(store, "credentials.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(store, 0755)
+
+	warnings := CheckStorageExposure(home)
+	var reasons []string
+	for _, w := range warnings {
+		reasons = append(reasons, w.Path+" "+w.Reason)
+	}
+	joined := strings.Join(reasons, "\n")
+
+	if !strings.Contains(joined, ".aws is synced by Dropbox") {
+		t.Errorf("Expected Dropbox warning, got:\n%s", joined)
+	}
+	if !strings.Contains(joined, ".cloudctl is accessible by other users (0755") {
+		t.Errorf("Expected permission warning, got:\n%s", joined)
+	}
+	if strings.Contains(joined, "credentials.json") {
+		t.Errorf("Did not expect a warning for a 0600 file, got:\n%s", joined)
+	}
+}
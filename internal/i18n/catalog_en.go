@@ -0,0 +1,94 @@
+package i18n
+
+// catalogEN is the reference catalog; every key must exist here.
+var catalogEN = map[string]string{
+	// Shared labels
+	"label.role":          "Role: %s",
+	"label.account":       "Account: %s",
+	"label.source":        "Source: %s",
+	"label.region":        "Region: %s",
+	"label.expires":       "Expires: %s",
+	"label.mfa_device":    "MFA Device: %s",
+	"label.console":       "Console: %s",
+	"label.access":        "Access: %s",
+	"label.self_destruct": "Self-destructs: %s",
+	"common.issues":       "Common issues:",
+	"common.example":      "Example:",
+	"common.cancelled":    "Operation cancelled.",
+
+	// Relative time
+	"time.remaining":   "%s remaining",
+	"time.expired_ago": "expired %s ago",
+
+	// Secret handling
+	"secret.required":          "Encryption secret required",
+	"secret.required_status":   "Encryption secret required to view session status",
+	"secret.set_hint":          "Set the secret:",
+	"secret.set_hint_keychain": "Set the secret or use macOS Keychain:",
+
+	// Sessions
+	"sessions.load_failed": "Failed to load sessions: %v",
+	"sessions.none_active": "No active sessions found. Create one first.",
+	"profile.not_found":    "Profile '%s' not found",
+	"profile.available":    "Available profiles:",
+	"profile.none_hint":    "No sessions found. Create one with:",
+	"profile.none_stored":  "No stored profiles found.",
+
+	// status
+	"status.title.active":           "Active Sessions",
+	"status.title.expiring":         "Expiring Soon",
+	"status.title.expired":          "Expired Sessions",
+	"status.empty":                  "No stored sessions found.",
+	"status.get_started":            "Get started:",
+	"status.current":                "current",
+	"status.mfa_session":            "MFA Session",
+	"status.expired":                "Expired",
+	"status.tip":                    "Tip: ",
+	"status.tip.refresh":            "Use %s to quickly restore expired sessions.",
+	"status.tip.reauth":             "Use %s to log in to expired MFA sessions once and rebuild the sessions that depend on them.",
+	"status.needs_reauth":           "needs re-auth: MFA session '%s' expired",
+	"status.locked":                 "Store locked since %s.",
+	"status.unlock_hint":            "Run 'cloudctl unlock' to continue.",
+	"status.auto_lock":              "Store unlocked, auto-lock in %s.",
+	"status.title.account":          "Account %s",
+	"status.title.mfa":              "MFA Sessions",
+	"status.account_summary":        "%d active, %d expired",
+	"status.account_roles":          "%d role(s)",
+	"status.limits":                 "Session limits:",
+	"status.limit.account":          "Account %s: %d of %d allowed concurrent sessions",
+	"status.limit.account_exceeded": "Account %s: %d concurrent sessions, over the limit of %d",
+	"status.limit.role":             "%s: %d of %d allowed concurrent sessions",
+	"status.limit.role_exceeded":    "%s: %d concurrent sessions, over the limit of %d",
+	"status.limit.duration":         "'%s' lasts %d minutes, longer than the %d-minute norm",
+	"status.drift":                  "~/.aws/credentials is out of sync with the store:",
+	"status.drift.stale":            "'%s' has older keys than the stored session",
+	"status.drift.newer":            "'%s' has newer keys than the stored session (expire %s, stored session %s)",
+	"status.drift.orphaned":         "'%s' is synced but no longer stored",
+	"status.drift_hint":             "Run 'cloudctl sync --all' to rewrite it from the store.",
+	"status.title.remote":           "Sessions across machines (%s)",
+	"status.remote_empty":           "No active sessions in the remote state.",
+	"status.remote_disabled":        "Remote state is not configured.",
+	"status.remote_this_host":       "this machine",
+	"status.remote_minted":          "minted %s",
+	"status.remote_renewing":        "being refreshed on %s until %s",
+
+	// login / mfa-login
+	"login.missing_params":   "Missing required parameters",
+	"login.stored":           "Session stored as '%s'",
+	"login.stored_encrypted": "Encrypted session stored as '%s'",
+	"login.assume_failed":    "Failed to assume role: %v",
+	"mfa.stored":             "MFA session stored as '%s'",
+	"mfa.auth_failed":        "MFA authentication failed: %v",
+	"mfa.verified":           "MFA verification successful.",
+	"mfa.next_steps":         "Now you can assume roles without MFA:",
+
+	// refresh
+	"refresh.silent_attempt": "Attempting silent refresh for '%s'...",
+	"refresh.silent_success": "Session '%s' refreshed silently.",
+	"refresh.success":        "Session '%s' refreshed/restored successfully!",
+
+	// logout
+	"logout.confirm_all": "This will remove all stored credentials. Type 'yes' to confirm: ",
+	"logout.removed":     "Profile '%s' removed successfully.",
+	"logout.all_removed": "All profiles removed successfully.",
+}
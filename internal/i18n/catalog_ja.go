@@ -0,0 +1,85 @@
+package i18n
+
+var catalogJA = map[string]string{
+	"label.role":          "ロール: %s",
+	"label.account":       "アカウント: %s",
+	"label.source":        "ソース: %s",
+	"label.region":        "リージョン: %s",
+	"label.expires":       "有効期限: %s",
+	"label.mfa_device":    "MFA デバイス: %s",
+	"label.console":       "コンソール: %s",
+	"label.access":        "アクセス: %s",
+	"label.self_destruct": "自動削除: %s",
+	"common.issues":       "よくある原因:",
+	"common.example":      "例:",
+	"common.cancelled":    "操作をキャンセルしました。",
+
+	"time.remaining":   "残り %s",
+	"time.expired_ago": "%s 前に期限切れ",
+
+	"secret.required":          "暗号化シークレットが必要です",
+	"secret.required_status":   "セッション状態を表示するには暗号化シークレットが必要です",
+	"secret.set_hint":          "シークレットを設定してください:",
+	"secret.set_hint_keychain": "シークレットを設定するか、macOS キーチェーンを使用してください:",
+
+	"sessions.load_failed": "セッションの読み込みに失敗しました: %v",
+	"sessions.none_active": "有効なセッションがありません。先に作成してください。",
+	"profile.not_found":    "プロファイル '%s' が見つかりません",
+	"profile.available":    "利用可能なプロファイル:",
+	"profile.none_hint":    "セッションがありません。次のコマンドで作成できます:",
+	"profile.none_stored":  "保存されたプロファイルはありません。",
+
+	"status.title.active":           "有効なセッション",
+	"status.title.expiring":         "まもなく期限切れ",
+	"status.title.expired":          "期限切れのセッション",
+	"status.empty":                  "保存されたセッションはありません。",
+	"status.get_started":            "はじめに:",
+	"status.current":                "現在",
+	"status.mfa_session":            "MFA セッション",
+	"status.expired":                "期限切れ",
+	"status.tip":                    "ヒント: ",
+	"status.tip.refresh":            "%s で期限切れのセッションをすばやく復元できます。",
+	"status.tip.reauth":             "%s で期限切れの MFA セッションに一度だけログインし、それに依存するセッションをまとめて再作成できます。",
+	"status.needs_reauth":           "再認証が必要: MFA セッション '%s' が期限切れです",
+	"status.locked":                 "ストアは %s からロックされています。",
+	"status.unlock_hint":            "続行するには 'cloudctl unlock' を実行してください。",
+	"status.auto_lock":              "ストアはロック解除中です。%s 後に自動ロックされます。",
+	"status.title.account":          "アカウント %s",
+	"status.title.mfa":              "MFA セッション",
+	"status.account_summary":        "有効 %d 件、期限切れ %d 件",
+	"status.account_roles":          "ロール %d 件",
+	"status.limits":                 "セッション上限:",
+	"status.limit.account":          "アカウント %s: 同時セッション %d 件 (上限 %d 件)",
+	"status.limit.account_exceeded": "アカウント %s: 同時セッション %d 件が上限 %d 件を超えています",
+	"status.limit.role":             "%s: 同時セッション %d 件 (上限 %d 件)",
+	"status.limit.role_exceeded":    "%s: 同時セッション %d 件が上限 %d 件を超えています",
+	"status.limit.duration":         "'%s' の有効期間は %d 分で、基準の %d 分を超えています",
+	"status.drift":                  "~/.aws/credentials がストアと一致していません:",
+	"status.drift.stale":            "'%s' のキーは保存済みセッションより古いです",
+	"status.drift.newer":            "'%s' のキーは保存済みセッションより新しいです (有効期限 %s、保存済みセッション %s)",
+	"status.drift.orphaned":         "'%s' は同期済みですが、もう保存されていません",
+	"status.drift_hint":             "'cloudctl sync --all' を実行してストアから書き直してください。",
+	"status.title.remote":           "マシン間のセッション (%s)",
+	"status.remote_empty":           "リモート状態に有効なセッションはありません。",
+	"status.remote_disabled":        "リモート状態が設定されていません。",
+	"status.remote_this_host":       "このマシン",
+	"status.remote_minted":          "発行: %s",
+	"status.remote_renewing":        "%s で更新中 (%s まで)",
+
+	"login.missing_params":   "必須パラメータが不足しています",
+	"login.stored":           "セッションを '%s' として保存しました",
+	"login.stored_encrypted": "暗号化したセッションを '%s' として保存しました",
+	"login.assume_failed":    "ロールの引き受けに失敗しました: %v",
+	"mfa.stored":             "MFA セッションを '%s' として保存しました",
+	"mfa.auth_failed":        "MFA 認証に失敗しました: %v",
+	"mfa.verified":           "MFA 認証に成功しました。",
+	"mfa.next_steps":         "これで MFA なしでロールを引き受けられます:",
+
+	"refresh.silent_attempt": "'%s' をバックグラウンドで更新しています...",
+	"refresh.silent_success": "セッション '%s' を更新しました。",
+	"refresh.success":        "セッション '%s' の更新/復元に成功しました!",
+
+	"logout.confirm_all": "保存されたすべての認証情報を削除します。確認のため 'yes' と入力してください: ",
+	"logout.removed":     "プロファイル '%s' を削除しました。",
+	"logout.all_removed": "すべてのプロファイルを削除しました。",
+}
@@ -0,0 +1,85 @@
+package i18n
+
+var catalogTH = map[string]string{
+	"label.role":          "Role: %s",
+	"label.account":       "บัญชี: %s",
+	"label.source":        "ต้นทาง: %s",
+	"label.region":        "Region: %s",
+	"label.expires":       "หมดอายุ: %s",
+	"label.mfa_device":    "อุปกรณ์ MFA: %s",
+	"label.console":       "คอนโซล: %s",
+	"label.access":        "สิทธิ์การเข้าถึง: %s",
+	"label.self_destruct": "ทำลายตัวเอง: %s",
+	"common.issues":       "ปัญหาที่พบบ่อย:",
+	"common.example":      "ตัวอย่าง:",
+	"common.cancelled":    "ยกเลิกการทำงานแล้ว",
+
+	"time.remaining":   "เหลืออีก %s",
+	"time.expired_ago": "หมดอายุเมื่อ %s ที่แล้ว",
+
+	"secret.required":          "ต้องระบุรหัสลับสำหรับการเข้ารหัส",
+	"secret.required_status":   "ต้องระบุรหัสลับสำหรับการเข้ารหัสเพื่อดูสถานะเซสชัน",
+	"secret.set_hint":          "ตั้งค่ารหัสลับ:",
+	"secret.set_hint_keychain": "ตั้งค่ารหัสลับ หรือใช้ macOS Keychain:",
+
+	"sessions.load_failed": "โหลดเซสชันไม่สำเร็จ: %v",
+	"sessions.none_active": "ไม่พบเซสชันที่ใช้งานได้ กรุณาสร้างเซสชันก่อน",
+	"profile.not_found":    "ไม่พบโปรไฟล์ '%s'",
+	"profile.available":    "โปรไฟล์ที่มีอยู่:",
+	"profile.none_hint":    "ไม่พบเซสชัน สร้างใหม่ได้ด้วย:",
+	"profile.none_stored":  "ไม่พบโปรไฟล์ที่บันทึกไว้",
+
+	"status.title.active":           "เซสชันที่ใช้งานอยู่",
+	"status.title.expiring":         "ใกล้หมดอายุ",
+	"status.title.expired":          "เซสชันที่หมดอายุแล้ว",
+	"status.empty":                  "ไม่พบเซสชันที่บันทึกไว้",
+	"status.get_started":            "เริ่มต้นใช้งาน:",
+	"status.current":                "ปัจจุบัน",
+	"status.mfa_session":            "เซสชัน MFA",
+	"status.expired":                "หมดอายุ",
+	"status.tip":                    "เคล็ดลับ: ",
+	"status.tip.refresh":            "ใช้ %s เพื่อกู้คืนเซสชันที่หมดอายุได้อย่างรวดเร็ว",
+	"status.tip.reauth":             "ใช้ %s เพื่อเข้าสู่ระบบเซสชัน MFA ที่หมดอายุเพียงครั้งเดียว แล้วสร้างเซสชันที่ขึ้นกับเซสชันนั้นใหม่ทั้งหมด",
+	"status.needs_reauth":           "ต้องยืนยันตัวตนใหม่: เซสชัน MFA '%s' หมดอายุแล้ว",
+	"status.locked":                 "ที่เก็บข้อมูลถูกล็อกตั้งแต่ %s",
+	"status.unlock_hint":            "รัน 'cloudctl unlock' เพื่อใช้งานต่อ",
+	"status.auto_lock":              "ที่เก็บข้อมูลปลดล็อกอยู่ จะล็อกอัตโนมัติใน %s",
+	"status.title.account":          "บัญชี %s",
+	"status.title.mfa":              "เซสชัน MFA",
+	"status.account_summary":        "ใช้งานอยู่ %d, หมดอายุ %d",
+	"status.account_roles":          "%d role",
+	"status.limits":                 "ขีดจำกัดเซสชัน:",
+	"status.limit.account":          "บัญชี %s: เซสชันพร้อมกัน %d จากที่อนุญาต %d",
+	"status.limit.account_exceeded": "บัญชี %s: เซสชันพร้อมกัน %d เกินขีดจำกัด %d",
+	"status.limit.role":             "%s: เซสชันพร้อมกัน %d จากที่อนุญาต %d",
+	"status.limit.role_exceeded":    "%s: เซสชันพร้อมกัน %d เกินขีดจำกัด %d",
+	"status.limit.duration":         "'%s' มีอายุ %d นาที นานกว่าเกณฑ์ %d นาที",
+	"status.drift":                  "~/.aws/credentials ไม่ตรงกับที่เก็บ:",
+	"status.drift.stale":            "'%s' มีคีย์ที่เก่ากว่าเซสชันที่เก็บไว้",
+	"status.drift.newer":            "'%s' มีคีย์ที่ใหม่กว่าเซสชันที่เก็บไว้ (หมดอายุ %s, เซสชันที่เก็บไว้ %s)",
+	"status.drift.orphaned":         "'%s' ถูกซิงค์ไว้แต่ไม่ได้เก็บอยู่แล้ว",
+	"status.drift_hint":             "รัน 'cloudctl sync --all' เพื่อเขียนใหม่จากที่เก็บ",
+	"status.title.remote":           "เซสชันในทุกเครื่อง (%s)",
+	"status.remote_empty":           "ไม่มีเซสชันที่ใช้งานอยู่ในสถานะระยะไกล",
+	"status.remote_disabled":        "ยังไม่ได้ตั้งค่าสถานะระยะไกล",
+	"status.remote_this_host":       "เครื่องนี้",
+	"status.remote_minted":          "ออกเมื่อ %s",
+	"status.remote_renewing":        "กำลังรีเฟรชบน %s จนถึง %s",
+
+	"login.missing_params":   "ขาดพารามิเตอร์ที่จำเป็น",
+	"login.stored":           "บันทึกเซสชันเป็น '%s' แล้ว",
+	"login.stored_encrypted": "บันทึกเซสชันแบบเข้ารหัสเป็น '%s' แล้ว",
+	"login.assume_failed":    "Assume role ไม่สำเร็จ: %v",
+	"mfa.stored":             "บันทึกเซสชัน MFA เป็น '%s' แล้ว",
+	"mfa.auth_failed":        "ยืนยันตัวตนด้วย MFA ไม่สำเร็จ: %v",
+	"mfa.verified":           "ยืนยัน MFA สำเร็จ",
+	"mfa.next_steps":         "ตอนนี้คุณสามารถ assume role ได้โดยไม่ต้องใช้ MFA:",
+
+	"refresh.silent_attempt": "กำลังรีเฟรช '%s' แบบเงียบ...",
+	"refresh.silent_success": "รีเฟรชเซสชัน '%s' แบบเงียบสำเร็จ",
+	"refresh.success":        "รีเฟรช/กู้คืนเซสชัน '%s' สำเร็จ!",
+
+	"logout.confirm_all": "การดำเนินการนี้จะลบข้อมูลรับรองทั้งหมด พิมพ์ 'yes' เพื่อยืนยัน: ",
+	"logout.removed":     "ลบโปรไฟล์ '%s' เรียบร้อยแล้ว",
+	"logout.all_removed": "ลบโปรไฟล์ทั้งหมดเรียบร้อยแล้ว",
+}
@@ -0,0 +1,138 @@
+// Package i18n holds the translated message catalog for user-facing CLI output.
+//
+// Messages never contain the leading status emoji; callers add icons themselves so
+// the same text works with any output theme.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is used when no supported locale is configured or detected.
+const DefaultLocale = "en"
+
+var (
+	mu       sync.RWMutex
+	locale   = DefaultLocale
+	catalogs = map[string]map[string]string{
+		"en": catalogEN,
+		"th": catalogTH,
+		"ja": catalogJA,
+	}
+)
+
+// SupportedLocales returns the locales with a catalog, built-in or loaded from overrides.
+func SupportedLocales() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	locales := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// DetectLocale picks the locale to use. An explicitly configured value wins; otherwise
+// CLOUDCTL_LANG, LC_ALL, LC_MESSAGES and LANG are checked in that order.
+func DetectLocale(configured string) string {
+	candidates := []string{configured, os.Getenv("CLOUDCTL_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")}
+	for _, c := range candidates {
+		if l := normalize(c); l != "" {
+			mu.RLock()
+			_, ok := catalogs[l]
+			mu.RUnlock()
+			if ok {
+				return l
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// normalize turns values like "th_TH.UTF-8" or "ja-JP" into "th" / "ja".
+func normalize(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "c" || value == "posix" || value == "auto" {
+		return ""
+	}
+	if i := strings.IndexAny(value, "_-.@"); i > 0 {
+		value = value[:i]
+	}
+	return value
+}
+
+// SetLocale switches the active locale. Unknown locales fall back to English.
+func SetLocale(l string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := catalogs[l]; ok {
+		locale = l
+		return
+	}
+	locale = DefaultLocale
+}
+
+// Locale returns the active locale.
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return locale
+}
+
+// LoadOverrides merges <dir>/<locale>.json files over the built-in catalogs. This lets
+// packagers customize wording or ship additional locales without rebuilding.
+func LoadOverrides(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f, err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(b, &messages); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", f, err)
+		}
+
+		l := strings.TrimSuffix(filepath.Base(f), ".json")
+		merged := make(map[string]string)
+		for k, v := range catalogs[l] {
+			merged[k] = v
+		}
+		for k, v := range messages {
+			merged[k] = v
+		}
+		catalogs[l] = merged
+	}
+	return nil
+}
+
+// T returns the message for key in the active locale, formatted with args.
+// Missing translations fall back to English, then to the key itself.
+func T(key string, args ...any) string {
+	mu.RLock()
+	msg, ok := catalogs[locale][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLocale][key]
+	}
+	mu.RUnlock()
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
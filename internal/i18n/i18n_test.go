@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCatalogsComplete(t *testing.T) {
+	for name, catalog := range map[string]map[string]string{"th": catalogTH, "ja": catalogJA} {
+		for key, en := range catalogEN {
+			msg, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing key %q", name, key)
+				continue
+			}
+			if strings.Count(msg, "%") != strings.Count(en, "%") {
+				t.Errorf("%s: placeholder mismatch for %q", name, key)
+			}
+		}
+	}
+}
+
+func TestDetectLocale(t *testing.T) {
+	t.Setenv("CLOUDCTL_LANG", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "th_TH.UTF-8")
+
+	if got := DetectLocale(""); got != "th" {
+		t.Errorf("Expected th from LANG, got %s", got)
+	}
+	if got := DetectLocale("ja-JP"); got != "ja" {
+		t.Errorf("Expected configured ja, got %s", got)
+	}
+
+	t.Setenv("LANG", "de_DE.UTF-8")
+	if got := DetectLocale(""); got != DefaultLocale {
+		t.Errorf("Expected fallback to %s, got %s", DefaultLocale, got)
+	}
+}
+
+func TestTranslateAndFallback(t *testing.T) {
+	t.Cleanup(func() { SetLocale(DefaultLocale) })
+
+	SetLocale("ja")
+	if got := T("logout.removed", "prod"); got != "プロファイル 'prod' を削除しました。" {
+		t.Errorf("Unexpected translation: %s", got)
+	}
+	if got := T("no.such.key"); got != "no.such.key" {
+		t.Errorf("Expected key fallback, got %s", got)
+	}
+
+	SetLocale("xx")
+	if Locale() != DefaultLocale {
+		t.Errorf("Unknown locale should fall back to %s", DefaultLocale)
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"status.empty": "Nothing here yet."}`), 0600)
+	os.WriteFile(filepath.Join(dir, "de.json"), []byte(`{"status.empty": "Keine Sitzungen gespeichert."}`), 0600)
+
+	original := catalogs
+	t.Cleanup(func() {
+		catalogs = original
+		SetLocale(DefaultLocale)
+	})
+	catalogs = map[string]map[string]string{"en": catalogEN, "th": catalogTH, "ja": catalogJA}
+
+	if err := LoadOverrides(dir); err != nil {
+		t.Fatalf("LoadOverrides failed: %v", err)
+	}
+
+	SetLocale("en")
+	if got := T("status.empty"); got != "Nothing here yet." {
+		t.Errorf("Override not applied: %s", got)
+	}
+	if got := T("status.current"); got != "current" {
+		t.Errorf("Non-overridden key changed: %s", got)
+	}
+
+	SetLocale("de")
+	if got := T("status.empty"); got != "Keine Sitzungen gespeichert." {
+		t.Errorf("Packager locale not loaded: %s", got)
+	}
+	if got := T("status.current"); got != "current" {
+		t.Errorf("Missing key should fall back to English: %s", got)
+	}
+}
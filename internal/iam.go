@@ -0,0 +1,403 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Role check results for `role list --resolve`
+const (
+	RoleStatusOK           = "ok"
+	RoleStatusStale        = "stale"
+	RoleStatusOtherAccount = "other-account"
+	RoleStatusError        = "error"
+)
+
+// RoleCheck is the live IAM state of a role alias.
+type RoleCheck struct {
+	Name  string
+	Alias RoleAlias
+	// Status is one of the RoleStatus* values.
+	Status string
+	// MaxSessionDuration in seconds, when the role was found.
+	MaxSessionDuration int32
+	// TrustRequiresMFA is true when the trust policy conditions on MFA.
+	TrustRequiresMFA bool
+	Err              error
+}
+
+// ResolveRoles looks up each alias with iam:GetRole using cfg. GetRole only sees the
+// caller's own account, so aliases for other accounts are reported, not checked.
+func ResolveRoles(ctx context.Context, cfg aws.Config, aliases map[string]RoleAlias) ([]RoleCheck, string, error) {
+	identity, err := newSTSClient(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, "", err
+	}
+	account := aws.ToString(identity.Account)
+	client := iam.NewFromConfig(cfg)
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	checks := make([]RoleCheck, 0, len(names))
+	for _, name := range names {
+		alias := aliases[name]
+		check := RoleCheck{Name: name, Alias: alias}
+
+		roleName := RoleName(alias.ARN)
+		if alias.AccountID() != account || roleName == "" {
+			check.Status = RoleStatusOtherAccount
+			checks = append(checks, check)
+			continue
+		}
+
+		out, err := client.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(roleName)})
+		var notFound *types.NoSuchEntityException
+		switch {
+		case errors.As(err, &notFound):
+			check.Status = RoleStatusStale
+		case err != nil:
+			check.Status = RoleStatusError
+			check.Err = err
+		default:
+			check.Status = RoleStatusOK
+			check.MaxSessionDuration = aws.ToInt32(out.Role.MaxSessionDuration)
+			check.TrustRequiresMFA = TrustPolicyRequiresMFA(aws.ToString(out.Role.AssumeRolePolicyDocument))
+		}
+		checks = append(checks, check)
+	}
+	return checks, account, nil
+}
+
+// TrustPolicyRequiresMFA reports whether any Allow statement in a (URL-encoded) trust
+// policy has an aws:MultiFactorAuthPresent or aws:MultiFactorAuthAge condition.
+func TrustPolicyRequiresMFA(document string) bool {
+	if decoded, err := url.QueryUnescape(document); err == nil {
+		document = decoded
+	}
+
+	var policy struct {
+		Statement json.RawMessage
+	}
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return false
+	}
+
+	type statement struct {
+		Effect    string
+		Condition map[string]map[string]any
+	}
+	var statements []statement
+	if err := json.Unmarshal(policy.Statement, &statements); err != nil {
+		var single statement
+		if err := json.Unmarshal(policy.Statement, &single); err != nil {
+			return false
+		}
+		statements = []statement{single}
+	}
+
+	for _, st := range statements {
+		if !strings.EqualFold(st.Effect, "Allow") {
+			continue
+		}
+		for _, conditions := range st.Condition {
+			for key := range conditions {
+				k := strings.ToLower(key)
+				if k == "aws:multifactorauthpresent" || k == "aws:multifactorauthage" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// PermissionCheck is the simulated IAM decision for one action.
+type PermissionCheck struct {
+	Action   string
+	Resource string
+	// Decision is "allowed", "explicitDeny" or "implicitDeny".
+	Decision string
+	// MatchedPolicies are the policies whose statements decided the result.
+	MatchedPolicies []string
+	// MissingContext lists condition keys the simulation could not evaluate.
+	MissingContext []string
+	// DeniedByOrganizations and DeniedByBoundary report an SCP or permissions boundary
+	// that blocks the action regardless of the role's own policies.
+	DeniedByOrganizations bool
+	DeniedByBoundary      bool
+}
+
+// Allowed reports whether the action would be allowed.
+func (c PermissionCheck) Allowed() bool {
+	return c.Decision == string(types.PolicyEvaluationDecisionTypeAllowed)
+}
+
+// SimulateRolePermissions evaluates actions against the policies attached to roleArn
+// with iam:SimulatePrincipalPolicy. resource is optional; "" simulates against "*".
+func SimulateRolePermissions(ctx context.Context, cfg aws.Config, roleArn string, actions []string, resource string) ([]PermissionCheck, error) {
+	input := &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(roleArn),
+		ActionNames:     actions,
+	}
+	if resource != "" {
+		input.ResourceArns = []string{resource}
+	}
+
+	var checks []PermissionCheck
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(iam.NewFromConfig(cfg), input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page.EvaluationResults {
+			checks = append(checks, permissionCheckFromResult(r))
+		}
+	}
+	return checks, nil
+}
+
+func permissionCheckFromResult(r types.EvaluationResult) PermissionCheck {
+	check := PermissionCheck{
+		Action:         aws.ToString(r.EvalActionName),
+		Resource:       aws.ToString(r.EvalResourceName),
+		Decision:       string(r.EvalDecision),
+		MissingContext: r.MissingContextValues,
+	}
+
+	seen := make(map[string]bool)
+	for _, st := range r.MatchedStatements {
+		id := aws.ToString(st.SourcePolicyId)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			check.MatchedPolicies = append(check.MatchedPolicies, id)
+		}
+	}
+	if r.OrganizationsDecisionDetail != nil {
+		check.DeniedByOrganizations = !r.OrganizationsDecisionDetail.AllowedByOrganizations
+	}
+	if r.PermissionsBoundaryDecisionDetail != nil {
+		check.DeniedByBoundary = !r.PermissionsBoundaryDecisionDetail.AllowedByPermissionsBoundary
+	}
+	return check
+}
+
+// listAccountAliases calls iam:ListAccountAliases with cfg. Tests replace it.
+var listAccountAliases = func(ctx context.Context, cfg aws.Config) ([]string, error) {
+	out, err := iam.NewFromConfig(cfg).ListAccountAliases(ctx, &iam.ListAccountAliasesInput{})
+	if err != nil {
+		return nil, err
+	}
+	return out.AccountAliases, nil
+}
+
+// ResolveAccountAlias fills in the session's account alias with iam:ListAccountAliases,
+// called with the session's own credentials like ResolveCallerIdentity. An account
+// without an alias leaves it empty.
+func ResolveAccountAlias(ctx context.Context, cfg aws.Config, s *AWSSession) error {
+	sessionCfg := cfg.Copy()
+	sessionCfg.Credentials = credentials.NewStaticCredentialsProvider(s.AccessKey, s.SecretKey, s.SessionToken)
+	aliases, err := listAccountAliases(ctx, sessionCfg)
+	if err != nil {
+		return err
+	}
+	s.AccountAlias = ""
+	if len(aliases) > 0 {
+		s.AccountAlias = aliases[0]
+	}
+	return nil
+}
+
+// SessionPeek describes who a session is and what it can do, for `cloudctl peek`.
+type SessionPeek struct {
+	Account string
+	ARN     string
+	UserID  string
+	// AccountAlias is "" when the account has none or it could not be read (AliasErr).
+	AccountAlias string
+	AliasErr     error
+	// Principal is the role or user name the policies belong to.
+	Principal        string
+	AttachedPolicies []string
+	InlinePolicies   []string
+	PoliciesErr      error
+}
+
+// PeekSession looks up the caller identity of cfg, the account alias and the policies of
+// the underlying role or user. Alias and policy lookups need IAM read permissions; their
+// errors are recorded rather than returned.
+func PeekSession(ctx context.Context, cfg aws.Config) (*SessionPeek, error) {
+	identity, err := newSTSClient(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, err
+	}
+	peek := &SessionPeek{
+		Account: aws.ToString(identity.Account),
+		ARN:     aws.ToString(identity.Arn),
+		UserID:  aws.ToString(identity.UserId),
+	}
+	client := iam.NewFromConfig(cfg)
+
+	if aliases, err := listAccountAliases(ctx, cfg); err != nil {
+		peek.AliasErr = err
+	} else if len(aliases) > 0 {
+		peek.AccountAlias = aliases[0]
+	}
+
+	kind, name := principalFromCallerARN(peek.ARN)
+	peek.Principal = name
+	switch kind {
+	case "role":
+		peek.AttachedPolicies, peek.InlinePolicies, peek.PoliciesErr = rolePolicies(ctx, client, name)
+	case "user":
+		peek.AttachedPolicies, peek.InlinePolicies, peek.PoliciesErr = userPolicies(ctx, client, name)
+	default:
+		peek.PoliciesErr = errors.New("policies can only be listed for roles and users")
+	}
+	return peek, nil
+}
+
+// principalFromCallerARN returns ("role", name) for an assumed-role ARN and ("user", name)
+// for an IAM user ARN.
+func principalFromCallerARN(arn string) (string, string) {
+	a, err := ParseARN(arn)
+	if err != nil {
+		return "", ""
+	}
+	if role, _, ok := a.AssumedRole(); ok {
+		return "role", role
+	}
+	if a.Service == "iam" && a.ResourceType() == "user" {
+		return "user", a.Name()
+	}
+	return "", ""
+}
+
+func rolePolicies(ctx context.Context, client *iam.Client, roleName string) ([]string, []string, error) {
+	var attached, inline []string
+	attachedPages := iam.NewListAttachedRolePoliciesPaginator(client, &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)})
+	for attachedPages.HasMorePages() {
+		page, err := attachedPages.NextPage(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, p := range page.AttachedPolicies {
+			attached = append(attached, aws.ToString(p.PolicyName))
+		}
+	}
+	inlinePages := iam.NewListRolePoliciesPaginator(client, &iam.ListRolePoliciesInput{RoleName: aws.String(roleName)})
+	for inlinePages.HasMorePages() {
+		page, err := inlinePages.NextPage(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		inline = append(inline, page.PolicyNames...)
+	}
+	return attached, inline, nil
+}
+
+func userPolicies(ctx context.Context, client *iam.Client, userName string) ([]string, []string, error) {
+	var attached, inline []string
+	attachedPages := iam.NewListAttachedUserPoliciesPaginator(client, &iam.ListAttachedUserPoliciesInput{UserName: aws.String(userName)})
+	for attachedPages.HasMorePages() {
+		page, err := attachedPages.NextPage(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, p := range page.AttachedPolicies {
+			attached = append(attached, aws.ToString(p.PolicyName))
+		}
+	}
+	inlinePages := iam.NewListUserPoliciesPaginator(client, &iam.ListUserPoliciesInput{UserName: aws.String(userName)})
+	for inlinePages.HasMorePages() {
+		page, err := inlinePages.NextPage(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		inline = append(inline, page.PolicyNames...)
+	}
+	return attached, inline, nil
+}
+
+// Access levels of a session, derived from its role's policies at login
+const (
+	AccessReadOnly = "read-only"
+	AccessAdmin    = "admin"
+	AccessCustom   = "custom"
+)
+
+// adminPolicies are AWS managed policies that grant admin, or the means to become admin.
+var adminPolicies = map[string]bool{
+	"AdministratorAccess": true,
+	"PowerUserAccess":     true,
+	"IAMFullAccess":       true,
+}
+
+// readOnlyPolicies are AWS managed policies that only read, besides the many
+// *ReadOnlyAccess service policies.
+var readOnlyPolicies = map[string]bool{
+	"ViewOnlyAccess": true,
+	"SecurityAudit":  true,
+}
+
+// ClassifyPolicies derives the access level from a role's attached policy ARNs and its
+// number of inline policies. Only AWS managed policies are trusted by name; anything
+// else, including inline policies, makes a role that isn't admin "custom".
+func ClassifyPolicies(attachedARNs []string, inline int) string {
+	readOnly := len(attachedARNs) > 0 && inline == 0
+	for _, arn := range attachedARNs {
+		awsManaged := strings.Contains(arn, ":iam::aws:policy/")
+		name := arn[strings.LastIndex(arn, "/")+1:]
+		if awsManaged && adminPolicies[name] {
+			return AccessAdmin
+		}
+		if !awsManaged || !(readOnlyPolicies[name] || strings.HasSuffix(name, "ReadOnlyAccess")) {
+			readOnly = false
+		}
+	}
+	if readOnly {
+		return AccessReadOnly
+	}
+	return AccessCustom
+}
+
+// DetectRoleAccess classifies the role behind an assumed-role session, using the
+// session's own credentials (cfg) to list the role's policies.
+func DetectRoleAccess(ctx context.Context, cfg aws.Config, roleArn string) (string, error) {
+	roleName := RoleName(roleArn)
+	if roleName == "" {
+		return "", errors.New("not a role ARN")
+	}
+	client := iam.NewFromConfig(cfg)
+
+	var attached []string
+	attachedPages := iam.NewListAttachedRolePoliciesPaginator(client, &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)})
+	for attachedPages.HasMorePages() {
+		page, err := attachedPages.NextPage(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, p := range page.AttachedPolicies {
+			attached = append(attached, aws.ToString(p.PolicyArn))
+		}
+	}
+	inline, err := client.ListRolePolicies(ctx, &iam.ListRolePoliciesInput{RoleName: aws.String(roleName), MaxItems: aws.Int32(1)})
+	if err != nil {
+		return "", err
+	}
+	return ClassifyPolicies(attached, len(inline.PolicyNames)), nil
+}
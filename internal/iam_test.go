@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+func TestTrustPolicyRequiresMFA(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     bool
+	}{
+		{
+			name:     "MFA present condition",
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"sts:AssumeRole","Condition":{"Bool":{"aws:MultiFactorAuthPresent":"true"}}}]}`,
+			want:     true,
+		},
+		{
+			name:     "MFA age condition, single statement object",
+			document: `{"Statement":{"Effect":"Allow","Action":"sts:AssumeRole","Condition":{"NumericLessThan":{"aws:MultiFactorAuthAge":"3600"}}}}`,
+			want:     true,
+		},
+		{
+			name:     "No condition",
+			document: `{"Statement":[{"Effect":"Allow","Action":"sts:AssumeRole"}]}`,
+			want:     false,
+		},
+		{
+			name:     "Deny statement only",
+			document: `{"Statement":[{"Effect":"Deny","Action":"sts:AssumeRole","Condition":{"BoolIfExists":{"aws:MultiFactorAuthPresent":"false"}}}]}`,
+			want:     false,
+		},
+		{
+			name:     "Invalid JSON",
+			document: `not json`,
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TrustPolicyRequiresMFA(tt.document); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			// IAM returns the document URL-encoded
+			if got := TrustPolicyRequiresMFA(url.QueryEscape(tt.document)); got != tt.want {
+				t.Errorf("encoded: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleName(t *testing.T) {
+	if got := RoleName("arn:aws:iam::123456789012:role/service-role/Deploy"); got != "Deploy" {
+		t.Errorf("Expected Deploy, got %q", got)
+	}
+	if got := RoleName("arn:aws:iam::123456789012:user/bob"); got != "" {
+		t.Errorf("Expected empty for non-role ARN, got %q", got)
+	}
+}
+
+func TestPermissionCheckFromResult(t *testing.T) {
+	allowed := permissionCheckFromResult(types.EvaluationResult{
+		EvalActionName:   aws.String("s3:GetObject"),
+		EvalResourceName: aws.String("arn:aws:s3:::bucket/key"),
+		EvalDecision:     types.PolicyEvaluationDecisionTypeAllowed,
+		MatchedStatements: []types.Statement{
+			{SourcePolicyId: aws.String("AmazonS3ReadOnlyAccess")},
+			{SourcePolicyId: aws.String("AmazonS3ReadOnlyAccess")},
+			{SourcePolicyId: aws.String("inline-data")},
+		},
+	})
+	if !allowed.Allowed() || allowed.Action != "s3:GetObject" || allowed.Resource != "arn:aws:s3:::bucket/key" {
+		t.Errorf("Unexpected check: %+v", allowed)
+	}
+	if len(allowed.MatchedPolicies) != 2 {
+		t.Errorf("Expected deduplicated policies, got %v", allowed.MatchedPolicies)
+	}
+	if allowed.DeniedByOrganizations || allowed.DeniedByBoundary {
+		t.Error("Missing decision details should not count as denials")
+	}
+
+	denied := permissionCheckFromResult(types.EvaluationResult{
+		EvalActionName:                    aws.String("s3:PutObject"),
+		EvalDecision:                      types.PolicyEvaluationDecisionTypeImplicitDeny,
+		MissingContextValues:              []string{"aws:SourceIp"},
+		OrganizationsDecisionDetail:       &types.OrganizationsDecisionDetail{AllowedByOrganizations: false},
+		PermissionsBoundaryDecisionDetail: &types.PermissionsBoundaryDecisionDetail{AllowedByPermissionsBoundary: true},
+	})
+	if denied.Allowed() || !denied.DeniedByOrganizations || denied.DeniedByBoundary {
+		t.Errorf("Unexpected check: %+v", denied)
+	}
+	if len(denied.MissingContext) != 1 {
+		t.Errorf("Expected missing context, got %v", denied.MissingContext)
+	}
+}
+
+func TestPrincipalFromCallerARN(t *testing.T) {
+	tests := []struct {
+		arn, kind, name string
+	}{
+		{"arn:aws:sts::123456789012:assumed-role/Admin/prod-admin", "role", "Admin"},
+		{"arn:aws:iam::123456789012:user/alice", "user", "alice"},
+		{"arn:aws:iam::123456789012:user/engineering/bob", "user", "bob"},
+		{"arn:aws:sts::123456789012:federated-user/carol", "", ""},
+	}
+	for _, tt := range tests {
+		kind, name := principalFromCallerARN(tt.arn)
+		if kind != tt.kind || name != tt.name {
+			t.Errorf("%s: expected (%q, %q), got (%q, %q)", tt.arn, tt.kind, tt.name, kind, name)
+		}
+	}
+}
+
+func TestClassifyPolicies(t *testing.T) {
+	tests := []struct {
+		attached []string
+		inline   int
+		want     string
+	}{
+		{[]string{"arn:aws:iam::aws:policy/AdministratorAccess"}, 0, AccessAdmin},
+		{[]string{"arn:aws:iam::aws:policy/ReadOnlyAccess", "arn:aws:iam::aws:policy/PowerUserAccess"}, 0, AccessAdmin},
+		{[]string{"arn:aws:iam::aws:policy/ReadOnlyAccess"}, 0, AccessReadOnly},
+		{[]string{"arn:aws:iam::aws:policy/job-function/ViewOnlyAccess", "arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess"}, 0, AccessReadOnly},
+		{[]string{"arn:aws:iam::aws:policy/ReadOnlyAccess"}, 1, AccessCustom},
+		// A customer managed policy can't be trusted by its name
+		{[]string{"arn:aws:iam::123456789012:policy/ReadOnlyAccess"}, 0, AccessCustom},
+		{[]string{"arn:aws:iam::123456789012:policy/AdministratorAccess"}, 0, AccessCustom},
+		{nil, 1, AccessCustom},
+	}
+	for _, tt := range tests {
+		if got := ClassifyPolicies(tt.attached, tt.inline); got != tt.want {
+			t.Errorf("ClassifyPolicies(%v, %d) = %s, want %s", tt.attached, tt.inline, got, tt.want)
+		}
+	}
+}
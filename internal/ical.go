@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// icalMinDuration is how long a role session must last to get a calendar entry;
+	// MFA sessions always get one, since only a new MFA code renews them.
+	icalMinDuration = 4 * time.Hour
+	// icalEventLength is how long an expiry entry blocks before the expiry.
+	icalEventLength = 15 * time.Minute
+	icalTimeFormat  = "20060102T150405Z"
+)
+
+// ExpiryCalendar returns an iCalendar (RFC 5545) document with an entry for the expiry
+// of each active long-lived session, ending at the expiry, and an alarm remind before
+// it. The UID depends on the profile and expiry, so importing the calendar again after
+// a refresh adds the new expiry and keeps the old one.
+func ExpiryCalendar(sessions []*AWSSession, now time.Time, remind time.Duration) string {
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		b.WriteString(foldICalLine(fmt.Sprintf(format, args...)))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//cloudctl//Session expiries//EN")
+	line("CALSCALE:GREGORIAN")
+	for _, s := range sessions {
+		expires := s.UsableUntil()
+		mfa := s.RoleArn == "MFA-Session"
+		if !expires.After(now) || (!mfa && time.Duration(s.Duration)*time.Second < icalMinDuration) {
+			continue
+		}
+		kind, renew := "Session", "cloudctl refresh "+s.Profile
+		if mfa {
+			kind = "MFA session"
+			renew = fmt.Sprintf("cloudctl mfa-login --source %s --profile %s", s.SourceProfile, s.Profile)
+		}
+		summary := fmt.Sprintf("cloudctl: %s '%s' expires", kind, s.Profile)
+
+		line("BEGIN:VEVENT")
+		line("UID:%s-%d@cloudctl", s.Profile, expires.Unix())
+		line("DTSTAMP:%s", now.UTC().Format(icalTimeFormat))
+		line("DTSTART:%s", expires.Add(-icalEventLength).UTC().Format(icalTimeFormat))
+		line("DTEND:%s", expires.UTC().Format(icalTimeFormat))
+		line("SUMMARY:%s", escapeICalText(summary))
+		line("DESCRIPTION:%s", escapeICalText("Renew it with: "+renew))
+		line("TRANSP:TRANSPARENT")
+		line("BEGIN:VALARM")
+		line("ACTION:DISPLAY")
+		line("DESCRIPTION:%s", escapeICalText(summary))
+		line("TRIGGER;RELATED=END:-PT%dM", int(remind.Minutes()))
+		line("END:VALARM")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// escapeICalText escapes a TEXT property value.
+func escapeICalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// foldICalLine folds a content line longer than 75 octets into continuation lines,
+// without splitting a UTF-8 character.
+func foldICalLine(s string) string {
+	const limit = 75
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpiryCalendar(t *testing.T) {
+	now := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	sessions := []*AWSSession{
+		{Profile: "mfa-session", RoleArn: "MFA-Session", SourceProfile: "default", Expiration: now.Add(12 * time.Hour)},
+		{Profile: "deploy", RoleArn: "arn:aws:iam::111111111111:role/Deploy", Expiration: now.Add(8 * time.Hour), Duration: 8 * 3600},
+		{Profile: "short", RoleArn: "arn:aws:iam::111111111111:role/Dev", Expiration: now.Add(time.Hour), Duration: 3600},
+		{Profile: "old", RoleArn: "MFA-Session", Expiration: now.Add(-time.Hour)},
+	}
+
+	got := ExpiryCalendar(sessions, now, 45*time.Minute)
+	if !strings.HasPrefix(got, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(got, "END:VCALENDAR\r\n") {
+		t.Fatalf("Expected a calendar, got:\n%s", got)
+	}
+	if n := strings.Count(got, "BEGIN:VEVENT"); n != 2 {
+		t.Errorf("Expected 2 events, got %d:\n%s", n, got)
+	}
+	for _, want := range []string{
+		"UID:mfa-session-1740859200@cloudctl\r\n",
+		"DTSTART:20250301T194500Z\r\n",
+		"DTEND:20250301T200000Z\r\n",
+		"SUMMARY:cloudctl: MFA session 'mfa-session' expires\r\n",
+		`DESCRIPTION:Renew it with: cloudctl mfa-login --source default --profile mfa-session`,
+		"SUMMARY:cloudctl: Session 'deploy' expires\r\n",
+		"TRIGGER;RELATED=END:-PT45M\r\n",
+	} {
+		if !strings.Contains(strings.ReplaceAll(got, "\r\n ", ""), want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "'short'") || strings.Contains(got, "'old'") {
+		t.Errorf("Expected no entries for short or expired sessions:\n%s", got)
+	}
+	for _, l := range strings.Split(got, "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("Line longer than 75 octets: %q", l)
+		}
+	}
+}
+
+func TestEscapeICalText(t *testing.T) {
+	if got := escapeICalText("a,b;c\\d\ne"); got != `a\,b\;c\\d\ne` {
+		t.Errorf("Unexpected escaping: %s", got)
+	}
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultIDEProfilePrefix is put before session names in the profiles ide-setup writes,
+// so they don't clash with the AWS CLI profiles sessions are logged in from.
+const DefaultIDEProfilePrefix = "cloudctl-"
+
+// ideManagedMarker is the comment written above every block generated by ide-setup.
+const ideManagedMarker = "; Managed by cloudctl (ide-setup)"
+
+// commandSafeNamePattern matches profile names that can be put in a credential_process
+// command as they are. The SDKs run the command with sh -c (cmd.exe /C on Windows), and
+// names may come from other machines through sync-store.
+var commandSafeNamePattern = regexp.MustCompile(`^[A-Za-z0-9._@+=,:-]+$`)
+
+// IsCommandSafeName reports whether a profile name can be used in a credential_process
+// command.
+func IsCommandSafeName(name string) bool {
+	return commandSafeNamePattern.MatchString(name)
+}
+
+// shellQuoteEscaper escapes what sh still expands inside double quotes.
+var shellQuoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+// quoteCommandArg double-quotes a path in a credential_process command, escaped for sh;
+// Windows paths can't contain the characters cmd.exe would need escaped.
+func quoteCommandArg(arg string) string {
+	if runtime.GOOS != "windows" {
+		arg = shellQuoteEscaper.Replace(arg)
+	}
+	return `"` + arg + `"`
+}
+
+// shellUnquoteReplacer undoes shellQuoteEscaper.
+var shellUnquoteReplacer = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\$`, "$", "\\`", "`")
+
+// unquoteCommandArg returns the path quoteCommandArg quoted, without its quotes.
+func unquoteCommandArg(quoted string) string {
+	if runtime.GOOS != "windows" {
+		return shellUnquoteReplacer.Replace(quoted)
+	}
+	return quoted
+}
+
+// ProcessCredentials is the output of `cloudctl credential-process`, in the format the
+// AWS SDKs expect from a credential_process command.
+type ProcessCredentials struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken,omitempty"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+// NewProcessCredentials returns the credential_process output for a session.
+func NewProcessCredentials(s *AWSSession) ProcessCredentials {
+	c := ProcessCredentials{
+		Version:         1,
+		AccessKeyID:     s.AccessKey,
+		SecretAccessKey: s.SecretKey,
+		SessionToken:    s.SessionToken,
+	}
+	if !s.Expiration.IsZero() {
+		c.Expiration = s.Expiration.UTC().Format(time.RFC3339)
+	}
+	return c
+}
+
+// IDEProfile is an AWS config profile that gets its credentials from a stored session.
+type IDEProfile struct {
+	Name    string
+	Session string
+	Region  string
+}
+
+// RenderIDEConfig returns the ~/.aws/config blocks for profiles, each running
+// `<executable> credential-process <session>`. IDE toolkits (VS Code, JetBrains) list
+// these profiles and run the command again whenever the credentials expire.
+func RenderIDEConfig(profiles []IDEProfile, executable string) string {
+	var b strings.Builder
+	for i, p := range profiles {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s\n%s\n", ideManagedMarker, configSectionHeader(p.Name))
+		// Quoted for the shells the SDKs run it with; paths may contain spaces. Session
+		// names are checked with IsCommandSafeName.
+		fmt.Fprintf(&b, "credential_process = %s credential-process %s\n", quoteCommandArg(executable), p.Session)
+		if p.Region != "" {
+			fmt.Fprintf(&b, "region = %s\n", p.Region)
+		}
+	}
+	return b.String()
+}
+
+// MergeIDEConfig replaces the blocks ide-setup generated before in an AWS config file
+// with ones for profiles; other sections are kept. Hand-written sections with the same
+// names are returned as conflicts and left alone unless force is set.
+func MergeIDEConfig(content string, profiles []IDEProfile, executable string, force bool) (string, []string) {
+	generated := map[string]bool{}
+	for _, p := range profiles {
+		generated[configSectionHeader(p.Name)] = true
+	}
+	return mergeManagedConfig(content, ideManagedMarker, generated, RenderIDEConfig(profiles, executable), force)
+}
+
+// WriteIDEConfig merges profiles into ~/.aws/config. It returns the conflicting
+// hand-written sections and writes nothing when there are any and force is false.
+func WriteIDEConfig(profiles []IDEProfile, executable string, force bool) ([]string, error) {
+	return writeAWSConfig(func(content string) (string, []string) {
+		return MergeIDEConfig(content, profiles, executable, force)
+	})
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewProcessCredentials(t *testing.T) {
+	s := testSession("dev-admin")
+	s.Expiration = time.Date(2025, 1, 2, 22, 4, 5, 0, time.FixedZone("ICT", 7*3600))
+
+	b, err := json.Marshal(NewProcessCredentials(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	json.Unmarshal(b, &got)
+	// The field names and the UTC RFC 3339 expiration are what the SDKs parse
+	if got["Version"] != float64(1) || got["AccessKeyId"] != s.AccessKey || got["SecretAccessKey"] != s.SecretKey ||
+		got["SessionToken"] != s.SessionToken || got["Expiration"] != "2025-01-02T15:04:05Z" {
+		t.Errorf("Unexpected credential_process output %s", b)
+	}
+}
+
+func TestMergeIDEConfig(t *testing.T) {
+	existing := "[default]\nregion = us-east-1\n\n[profile dev]\nregion = eu-central-1\n"
+	profiles := []IDEProfile{
+		{Name: "cloudctl-dev-admin", Session: "dev-admin", Region: "ap-southeast-1"},
+		{Name: "cloudctl-prod", Session: "prod"},
+	}
+	executable := "/Applications/My Tools/cloudctl"
+
+	merged, conflicts := MergeIDEConfig(existing, profiles, executable, false)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	for _, want := range []string{
+		"[profile dev]\nregion = eu-central-1",
+		"[profile cloudctl-dev-admin]\ncredential_process = \"/Applications/My Tools/cloudctl\" credential-process dev-admin\nregion = ap-southeast-1",
+		"[profile cloudctl-prod]\ncredential_process = \"/Applications/My Tools/cloudctl\" credential-process prod\n",
+	} {
+		if !strings.Contains(merged, want) {
+			t.Errorf("merged config is missing %q:\n%s", want, merged)
+		}
+	}
+
+	// Regenerating replaces the profiles written before
+	again, _ := MergeIDEConfig(merged, profiles[:1], executable, false)
+	if strings.Count(again, "[profile cloudctl-dev-admin]") != 1 || strings.Contains(again, "cloudctl-prod") {
+		t.Errorf("managed blocks were not replaced:\n%s", again)
+	}
+
+	// Hand-written profiles are kept unless forced
+	clash := []IDEProfile{{Name: "dev", Session: "dev"}}
+	if _, conflicts := MergeIDEConfig(existing, clash, executable, false); len(conflicts) != 1 || conflicts[0] != "[profile dev]" {
+		t.Errorf("conflicts = %v, want [profile dev]", conflicts)
+	}
+	if forced, _ := MergeIDEConfig(existing, clash, executable, true); strings.Contains(forced, "eu-central-1") {
+		t.Errorf("forced merge did not replace the section:\n%s", forced)
+	}
+}
+
+func TestIsCommandSafeName(t *testing.T) {
+	for _, name := range []string{"dev-admin", "team.prod", "me@acme", "ci_deploy:eu"} {
+		if !IsCommandSafeName(name) {
+			t.Errorf("Expected %q to be safe", name)
+		}
+	}
+	for _, name := range []string{"", "dev admin", `dev"`, "dev$(id)", "dev`id`", "dev;id", "a/b", `a\b`} {
+		if IsCommandSafeName(name) {
+			t.Errorf("Expected %q to be refused", name)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var hidIdlePattern = regexp.MustCompile(`"HIDIdleTime"\s*=\s*(\d+)`)
+
+// parseHIDIdleTime extracts the idle time from `ioreg -c IOHIDSystem` output (nanoseconds).
+func parseHIDIdleTime(out string) (time.Duration, error) {
+	m := hidIdlePattern.FindStringSubmatch(out)
+	if m == nil {
+		return 0, fmt.Errorf("HIDIdleTime not found in ioreg output")
+	}
+	ns, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid HIDIdleTime '%s'", m[1])
+	}
+	return time.Duration(ns), nil
+}
+
+// parseXprintidle parses `xprintidle` output (milliseconds).
+func parseXprintidle(out string) (time.Duration, error) {
+	ms, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected xprintidle output '%s'", strings.TrimSpace(out))
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
@@ -0,0 +1,18 @@
+//go:build darwin
+
+package internal
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// UserIdleTime returns how long it has been since the last keyboard or mouse input.
+func UserIdleTime() (time.Duration, error) {
+	out, err := exec.Command("ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run ioreg: %w", err)
+	}
+	return parseHIDIdleTime(string(out))
+}
@@ -0,0 +1,23 @@
+//go:build !darwin
+
+package internal
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// UserIdleTime returns how long it has been since the last keyboard or mouse input.
+// On Linux this needs xprintidle and an X11 (or XWayland) session.
+func UserIdleTime() (time.Duration, error) {
+	if runtime.GOOS != "linux" {
+		return 0, fmt.Errorf("idle detection is not supported on %s", runtime.GOOS)
+	}
+	out, err := exec.Command("xprintidle").Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run xprintidle (is it installed?): %w", err)
+	}
+	return parseXprintidle(string(out))
+}
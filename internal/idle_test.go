@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHIDIdleTime(t *testing.T) {
+	out := `+-o IOHIDSystem  <class IOHIDSystem, id 0x100000465>
+    {
+      "HIDIdleTime" = 125000000000
+      "HIDParameters" = {"HIDMouseAcceleration"=45056}
+    }`
+	d, err := parseHIDIdleTime(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 125*time.Second {
+		t.Errorf("Expected 125s, got %v", d)
+	}
+	if _, err := parseHIDIdleTime("no idle here"); err == nil {
+		t.Error("Expected error for missing HIDIdleTime")
+	}
+}
+
+func TestParseXprintidle(t *testing.T) {
+	d, err := parseXprintidle("90500\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 90500*time.Millisecond {
+		t.Errorf("Expected 90.5s, got %v", d)
+	}
+	if _, err := parseXprintidle("Couldn't open display"); err == nil {
+		t.Error("Expected error for invalid output")
+	}
+}
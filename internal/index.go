@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// indexPath holds unencrypted session metadata (expiry and type only, never credentials)
+// so commands like `list` can run without the encryption secret.
+var indexPath = filepath.Join(storeDir, "index.json")
+
+// IndexEntry is the metadata kept for one stored profile.
+type IndexEntry struct {
+	Expiration time.Time `json:"expiration"`
+	MFA        bool      `json:"mfa,omitempty"`
+}
+
+// LoadSessionIndex reads the metadata index. A missing index is empty.
+func LoadSessionIndex() (map[string]IndexEntry, error) {
+	index := make(map[string]IndexEntry)
+	b, err := os.ReadFile(indexPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, fmt.Errorf("failed to read session index: %w", err)
+	}
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse session index: %w", err)
+	}
+	return index, nil
+}
+
+func saveSessionIndex(index map[string]IndexEntry) error {
+	if len(index) == 0 {
+		if err := os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session index: %w", err)
+	}
+	return os.WriteFile(indexPath, b, 0600)
+}
+
+func indexEntryFor(s *AWSSession) IndexEntry {
+	return IndexEntry{Expiration: s.Expiration.UTC(), MFA: s.RoleArn == "MFA-Session" || s.RoleArn == ""}
+}
+
+// updateSessionIndex applies change to the index and writes it back.
+func updateSessionIndex(change func(index map[string]IndexEntry)) error {
+	index, err := LoadSessionIndex()
+	if err != nil {
+		// A corrupt index is only a cache; start over
+		index = make(map[string]IndexEntry)
+	}
+	change(index)
+	return saveSessionIndex(index)
+}
+
+// syncSessionIndex rewrites the index from fully decrypted sessions when it is out of
+// date, e.g. for sessions stored before the index existed.
+func syncSessionIndex(sessions []*AWSSession) {
+	index, err := LoadSessionIndex()
+	if err == nil && len(index) == len(sessions) {
+		current := true
+		for _, s := range sessions {
+			if e, ok := index[s.Profile]; !ok || e != indexEntryFor(s) {
+				current = false
+				break
+			}
+		}
+		if current {
+			return
+		}
+	}
+
+	fresh := make(map[string]IndexEntry, len(sessions))
+	for _, s := range sessions {
+		fresh[s.Profile] = indexEntryFor(s)
+	}
+	saveSessionIndex(fresh)
+}
+
+// IndexedSession is a stored profile with the metadata available without decryption.
+type IndexedSession struct {
+	Profile string
+	// Expiration is zero when the profile is not in the index yet.
+	Expiration time.Time
+	MFA        bool
+}
+
+// Known reports whether the index had metadata for the profile.
+func (s IndexedSession) Known() bool {
+	return !s.Expiration.IsZero()
+}
+
+// ListIndexedSessions returns every profile in the store, sorted by name, with the
+// metadata from the index. It never needs the encryption secret.
+func ListIndexedSessions() ([]IndexedSession, error) {
+	profiles, err := ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+	index, err := LoadSessionIndex()
+	if err != nil {
+		index = make(map[string]IndexEntry)
+	}
+
+	sessions := make([]IndexedSession, 0, len(profiles))
+	for _, p := range profiles {
+		e := index[p]
+		sessions = append(sessions, IndexedSession{Profile: p, Expiration: e.Expiration, MFA: e.MFA})
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Profile < sessions[j].Profile })
+	return sessions, nil
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSessionIndexTracksStore(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	if err := SaveCredentials("prod", &AWSSession{AccessKey: "AK", RoleArn: "arn:aws:iam::123456789012:role/Admin", Expiration: exp}, key); err != nil {
+		t.Fatalf("SaveCredentials failed: %v", err)
+	}
+	if err := SaveCredentials("mfa", &AWSSession{AccessKey: "AK", RoleArn: "MFA-Session", Expiration: exp}, key); err != nil {
+		t.Fatalf("SaveCredentials failed: %v", err)
+	}
+
+	listed, err := ListIndexedSessions()
+	if err != nil {
+		t.Fatalf("ListIndexedSessions failed: %v", err)
+	}
+	if len(listed) != 2 || listed[0].Profile != "mfa" || listed[1].Profile != "prod" {
+		t.Fatalf("Unexpected sessions: %+v", listed)
+	}
+	if !listed[0].MFA || listed[1].MFA {
+		t.Error("Expected only 'mfa' to be flagged as an MFA session")
+	}
+	if !listed[1].Expiration.Equal(exp) {
+		t.Errorf("Expected expiration %v, got %v", exp, listed[1].Expiration)
+	}
+
+	if err := RemoveProfile("prod"); err != nil {
+		t.Fatalf("RemoveProfile failed: %v", err)
+	}
+	index, _ := LoadSessionIndex()
+	if _, ok := index["prod"]; ok || len(index) != 1 {
+		t.Errorf("Expected 'prod' to be removed from the index, got %v", index)
+	}
+
+	if err := ClearAllCredentials(); err != nil {
+		t.Fatalf("ClearAllCredentials failed: %v", err)
+	}
+	if _, err := os.Stat(indexPath); !os.IsNotExist(err) {
+		t.Error("Expected the index to be removed with the store")
+	}
+}
+
+func TestSessionIndexRebuiltOnDecrypt(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+
+	if err := SaveCredentials("prod", &AWSSession{AccessKey: "AK", RoleArn: "arn:aws:iam::123456789012:role/Admin", Expiration: time.Now()}, key); err != nil {
+		t.Fatalf("SaveCredentials failed: %v", err)
+	}
+	// Simulate a store written before the index existed
+	os.Remove(indexPath)
+
+	listed, _ := ListIndexedSessions()
+	if len(listed) != 1 || listed[0].Known() {
+		t.Fatalf("Expected one profile without metadata, got %+v", listed)
+	}
+
+	if _, err := ListAllSessions(key); err != nil {
+		t.Fatalf("ListAllSessions failed: %v", err)
+	}
+	listed, _ = ListIndexedSessions()
+	if len(listed) != 1 || !listed[0].Known() {
+		t.Errorf("Expected the index to be rebuilt, got %+v", listed)
+	}
+}
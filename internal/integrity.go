@@ -0,0 +1,374 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// macField is the store field holding an entry's HMAC. It is not encrypted.
+const macField = "MAC"
+
+// storeSealKey is the top-level key of credentials.json holding the store seal: an HMAC
+// over every profile name and entry MAC, so removed, added or rolled back entries are
+// caught, not just changed ones.
+const storeSealKey = "$store"
+
+// sealMarkerName is the file next to credentials.json recording that the store has been
+// sealed. It lives outside the store, so stripping the seal can't pass a sealed store off
+// as one written before seals existed.
+const sealMarkerName = "store.sealed"
+
+// ErrStoreTampered is returned when a store entry doesn't match its HMAC: it was changed
+// outside cloudctl or corrupted on disk.
+var ErrStoreTampered = errors.New("credential store failed its integrity check")
+
+// entryMACInput is the data an entry's HMAC covers: the profile name and every field
+// except the MAC itself, so fields can't be changed or moved between profiles.
+func entryMACInput(profile string, enc map[string]string) []byte {
+	fields := make([]string, 0, len(enc))
+	for field := range enc {
+		if field != macField {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var b strings.Builder
+	b.WriteString("cloudctl-store-v1\x00")
+	b.WriteString(profile)
+	for _, field := range fields {
+		b.WriteString("\x00" + field + "=" + enc[field])
+	}
+	return []byte(b.String())
+}
+
+// sealEntry sets the entry's MAC field.
+func sealEntry(profile string, enc map[string]string, provider CryptoProvider) error {
+	mac, err := provider.MAC(entryMACInput(profile, enc))
+	if err != nil {
+		return fmt.Errorf("failed to compute store MAC: %w", err)
+	}
+	enc[macField] = base64.StdEncoding.EncodeToString(mac)
+	return nil
+}
+
+// verifyEntry checks the entry's MAC. Entries without one, written by older versions,
+// are accepted here; once the store is sealed, verifySeal rejects them. A mismatch
+// caused by a wrong key is returned as the decryption error it is, not as tampering.
+func verifyEntry(profile string, enc map[string]string, provider CryptoProvider) error {
+	stored, ok := enc[macField]
+	if !ok {
+		return nil
+	}
+	want, err := base64.StdEncoding.DecodeString(stored)
+	if err != nil {
+		return fmt.Errorf("%w: profile '%s' has an invalid MAC; run 'cloudctl verify'", ErrStoreTampered, profile)
+	}
+	got, err := provider.MAC(entryMACInput(profile, enc))
+	if err != nil {
+		return fmt.Errorf("failed to compute store MAC: %w", err)
+	}
+	if hmac.Equal(got, want) {
+		return nil
+	}
+	if err := checkEntryKey(enc, provider); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: profile '%s' was modified outside cloudctl or is corrupted; run 'cloudctl verify'", ErrStoreTampered, profile)
+}
+
+// checkEntryKey tells a wrong key from tampering: with the right key, AES-GCM still
+// opens the (untouched) Expiration field.
+func checkEntryKey(enc map[string]string, provider CryptoProvider) error {
+	raw, err := base64.StdEncoding.DecodeString(enc["Expiration"])
+	if err != nil {
+		return nil
+	}
+	if _, err := provider.Decrypt(raw); err != nil {
+		return fmt.Errorf("failed to decrypt Expiration: %w", err)
+	}
+	return nil
+}
+
+// storeMACInput is the data the store seal covers: each profile name with its entry MAC.
+func storeMACInput(data map[string]map[string]string) []byte {
+	profiles := make([]string, 0, len(data))
+	for profile := range data {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+
+	var b strings.Builder
+	b.WriteString("cloudctl-store-seal-v1")
+	for _, profile := range profiles {
+		b.WriteString("\x00" + profile + "=" + data[profile][macField])
+	}
+	return []byte(b.String())
+}
+
+// verifySeal checks the store seal with provider and remembers provider to seal the
+// store again on the next write. A store without a seal was written before seals
+// existed and is accepted until the seal marker exists; a sealed store must not contain
+// unsealed entries.
+func (st *Store) verifySeal(provider CryptoProvider) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.loadLocked(); err != nil {
+		return err
+	}
+	if st.sealer == provider {
+		return nil
+	}
+	switch {
+	case st.seal != "":
+		if err := st.checkSealLocked(provider); err != nil {
+			return err
+		}
+	case len(st.data) > 0 && st.markedLocked():
+		return fmt.Errorf("%w: the store seal was removed; run 'cloudctl verify'", ErrStoreTampered)
+	}
+	st.sealer = provider
+	return nil
+}
+
+// markedLocked reports whether the seal marker exists, i.e. the store has been sealed
+// before and must not be accepted without a seal.
+func (st *Store) markedLocked() bool {
+	_, err := os.Stat(filepath.Join(filepath.Dir(st.path), sealMarkerName))
+	return err == nil
+}
+
+// markLocked creates the seal marker after the store was written with a seal.
+func (st *Store) markLocked() error {
+	if st.markedLocked() {
+		return nil
+	}
+	marker := filepath.Join(filepath.Dir(st.path), sealMarkerName)
+	if err := os.WriteFile(marker, nil, 0600); err != nil {
+		return fmt.Errorf("failed to write store seal marker: %w", err)
+	}
+	return nil
+}
+
+func (st *Store) checkSealLocked(provider CryptoProvider) error {
+	var anyEntry map[string]string
+	for profile, enc := range st.data {
+		if enc[macField] == "" {
+			return fmt.Errorf("%w: profile '%s' has no MAC in a sealed store; run 'cloudctl verify'", ErrStoreTampered, profile)
+		}
+		anyEntry = enc
+	}
+	want, err := base64.StdEncoding.DecodeString(st.seal)
+	if err != nil {
+		return fmt.Errorf("%w: the store seal is invalid; run 'cloudctl verify'", ErrStoreTampered)
+	}
+	got, err := provider.MAC(storeMACInput(st.data))
+	if err != nil {
+		return fmt.Errorf("failed to compute store MAC: %w", err)
+	}
+	if hmac.Equal(got, want) {
+		return nil
+	}
+	if anyEntry != nil {
+		if err := checkEntryKey(anyEntry, provider); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: profiles were added, removed or replaced outside cloudctl; run 'cloudctl verify'", ErrStoreTampered)
+}
+
+// rekeySeal makes to seal the store from now on, if it was last verified with from.
+// Writes fail instead when the file changed since, as nothing verified it.
+func (st *Store) rekeySeal(from, to CryptoProvider) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.sealer == from {
+		st.sealer = to
+	}
+}
+
+// sealLocked updates the store seal before a write. A store is sealed once every entry
+// has a MAC; a sealed store is never written unsealed, so the seal can't be dropped by
+// a write that lacks the key.
+func (st *Store) sealLocked() error {
+	unsealed := ""
+	for profile, enc := range st.data {
+		if enc[macField] == "" {
+			unsealed = profile
+			break
+		}
+	}
+	sealed := st.seal != "" || st.markedLocked()
+	switch {
+	case st.sealer == nil && sealed:
+		return fmt.Errorf("the credential store is sealed; writing it needs the secret")
+	case st.sealer == nil:
+		return nil
+	case unsealed != "" && sealed:
+		return fmt.Errorf("profile '%s' has no MAC and can't be written to the sealed store", unsealed)
+	case unsealed != "":
+		// Left for 'cloudctl verify --seal', which checks the entry decrypts first
+		return nil
+	}
+	mac, err := st.sealer.MAC(storeMACInput(st.data))
+	if err != nil {
+		return fmt.Errorf("failed to compute store MAC: %w", err)
+	}
+	st.seal = base64.StdEncoding.EncodeToString(mac)
+	return nil
+}
+
+// touch marks a non-empty store as changed, so the next flush writes its seal.
+func (st *Store) touch() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.loadLocked(); err != nil {
+		return err
+	}
+	if len(st.data) == 0 {
+		return nil
+	}
+	return st.changedLocked(nil)
+}
+
+// sealed reports whether the store carries a seal.
+func (st *Store) sealed() (bool, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.loadLocked(); err != nil {
+		return false, err
+	}
+	return st.seal != "", nil
+}
+
+// StoreSealed reports whether the credential store carries a store seal. Stores written
+// by older versions have none until their next write or 'cloudctl verify --seal'.
+func StoreSealed() (bool, error) {
+	return processStore.sealed()
+}
+
+// removalSealer prepares the store for removing entries: a sealed store is sealed again
+// afterwards, which needs the key, so it is resolved without prompting.
+func removalSealer() error {
+	processStore.mu.Lock()
+	err := processStore.loadLocked()
+	ready := (processStore.seal == "" && !processStore.markedLocked()) || processStore.sealer != nil
+	processStore.mu.Unlock()
+	if err != nil || ready {
+		return err
+	}
+	secret, err := GetSecret("")
+	if err != nil {
+		return fmt.Errorf("the credential store is sealed, so removing a profile needs the secret: %w", err)
+	}
+	provider, err := StoreProvider(secret)
+	if err != nil {
+		return err
+	}
+	return processStore.verifySeal(provider)
+}
+
+// readStore returns a copy of the store's entries; a missing file is an empty store.
+func readStore() (map[string]map[string]string, error) {
+	return processStore.snapshot()
+}
+
+// Integrity states reported by VerifyStore
+const (
+	IntegrityOK       = "ok"
+	IntegrityUnsealed = "unsealed"
+	IntegrityTampered = "tampered"
+	IntegrityWrongKey = "wrong-key"
+	// IntegrityStale is an intact entry from an older version that needs a new login.
+	IntegrityStale = "stale"
+)
+
+// EntryIntegrity is the result of checking one profile in the store.
+type EntryIntegrity struct {
+	Profile string
+	Status  string
+	// Err is the decryption error for wrong-key and tampered entries, and lists the
+	// problems of stale ones.
+	Err error
+}
+
+// VerifyStore checks every entry of the store: its MAC and that each field decrypts.
+// Entries are sorted by profile name.
+func VerifyStore(provider CryptoProvider) ([]EntryIntegrity, error) {
+	// A wrong key shows up on every entry below; only tampering stops here
+	if err := processStore.verifySeal(provider); errors.Is(err, ErrStoreTampered) {
+		return nil, err
+	}
+	data, err := readStore()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]EntryIntegrity, 0, len(data))
+	for profile, enc := range data {
+		r := EntryIntegrity{Profile: profile, Status: IntegrityOK}
+		err := verifyEntry(profile, enc, provider)
+		if err == nil {
+			_, err = decryptSession(profile, enc, provider)
+		}
+		switch {
+		case errors.Is(err, ErrStoreTampered):
+			r.Status, r.Err = IntegrityTampered, err
+		case errors.Is(err, ErrNeedsRelogin):
+			r.Status, r.Err = IntegrityStale, err
+		case err != nil && checkEntryKey(enc, provider) != nil:
+			r.Status, r.Err = IntegrityWrongKey, err
+		case err != nil:
+			// The MAC matched (or is missing) but a field doesn't decrypt
+			r.Status, r.Err = IntegrityTampered, err
+		case enc[macField] == "":
+			r.Status = IntegrityUnsealed
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Profile < results[j].Profile })
+	return results, nil
+}
+
+// SealStore adds a MAC to entries written by older versions, after checking that all of
+// their fields decrypt, seals the store and returns the sealed profiles.
+func SealStore(provider CryptoProvider) ([]string, error) {
+	if err := processStore.verifySeal(provider); err != nil {
+		return nil, err
+	}
+	data, err := readStore()
+	if err != nil {
+		return nil, err
+	}
+	var sealed []string
+	for profile, enc := range data {
+		if _, ok := enc[macField]; ok {
+			continue
+		}
+		if _, err := decryptSession(profile, enc, provider); err != nil && !errors.Is(err, ErrNeedsRelogin) {
+			return nil, fmt.Errorf("profile '%s' can't be sealed: %w", profile, err)
+		}
+		if err := sealEntry(profile, enc, provider); err != nil {
+			return nil, err
+		}
+		sealed = append(sealed, profile)
+	}
+	err = processStore.Batch(func() error {
+		for _, profile := range sealed {
+			if err := processStore.put(profile, data[profile], nil); err != nil {
+				return err
+			}
+		}
+		return processStore.touch()
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(sealed)
+	return sealed, nil
+}
@@ -0,0 +1,270 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readTestStore(t *testing.T) map[string]map[string]string {
+	t.Helper()
+	data, err := readStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func writeTestStore(t *testing.T, data map[string]map[string]string) {
+	t.Helper()
+	b, _ := json.Marshal(data)
+	if err := os.WriteFile(storePath, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreIntegrity(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	provider := NewSecretProvider(key)
+
+	for _, profile := range []string{"dev", "prod"} {
+		s := &AWSSession{Profile: profile, AccessKey: "AKIA" + profile, SecretKey: "s", SessionToken: "t",
+			Expiration: time.Now().Add(time.Hour), RoleArn: "arn:aws:iam::123456789012:role/" + profile}
+		if err := SaveCredentials(profile, s, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if readTestStore(t)["dev"][macField] == "" {
+		t.Fatal("Expected saved entries to carry a MAC")
+	}
+	results, err := VerifyStore(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Status != IntegrityOK {
+			t.Errorf("%s: expected ok, got %s (%v)", r.Profile, r.Status, r.Err)
+		}
+	}
+
+	// A wrong key is a decryption error, not tampering
+	if _, err := LoadCredentials("dev", "wrong-secret"); err == nil || errors.Is(err, ErrStoreTampered) {
+		t.Errorf("Expected a decryption error for a wrong key, got %v", err)
+	}
+	results, _ = VerifyStore(NewSecretProvider("wrong-secret"))
+	if results[0].Status != IntegrityWrongKey {
+		t.Errorf("Expected wrong-key, got %s", results[0].Status)
+	}
+
+	// Moving a field between profiles is tampering, even though it decrypts
+	editTestStore(t, func(data map[string]map[string]string) { data["dev"]["AccessKey"] = data["prod"]["AccessKey"] })
+	if _, err := LoadCredentials("dev", key); !errors.Is(err, ErrStoreTampered) {
+		t.Errorf("Expected ErrStoreTampered for a swapped field, got %v", err)
+	}
+	results, _ = VerifyStore(provider)
+	if results[0].Profile != "dev" || results[0].Status != IntegrityTampered || results[1].Status != IntegrityOK {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	if _, err := ListAllSessionsWith(provider); !errors.Is(err, ErrStoreTampered) {
+		t.Errorf("Expected ListAllSessions to report tampering, got %v", err)
+	}
+
+	// Invalid JSON is reported as tampering rather than a parse error
+	os.WriteFile(storePath, []byte("{ invalid"), 0600)
+	if _, err := VerifyStore(provider); !errors.Is(err, ErrStoreTampered) {
+		t.Errorf("Expected ErrStoreTampered for invalid JSON, got %v", err)
+	}
+}
+
+func TestSealStore(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	provider := NewSecretProvider(key)
+
+	s := &AWSSession{Profile: "legacy", AccessKey: "AKIA", SecretKey: "s", SessionToken: "t",
+		Expiration: time.Now().Add(time.Hour), RoleArn: "arn:aws:iam::123456789012:role/legacy"}
+	if err := SaveCredentials("legacy", s, key); err != nil {
+		t.Fatal(err)
+	}
+	// Entries written by older versions have no MAC and still load
+	data := readTestStore(t)
+	delete(data["legacy"], macField)
+	writeTestStore(t, data)
+	forgetTestSeal(t)
+	if _, err := LoadCredentials("legacy", key); err != nil {
+		t.Fatalf("Expected an unsealed entry to load, got %v", err)
+	}
+	results, _ := VerifyStore(provider)
+	if results[0].Status != IntegrityUnsealed {
+		t.Errorf("Expected unsealed, got %s", results[0].Status)
+	}
+
+	if _, err := SealStore(NewSecretProvider("wrong-secret")); err == nil {
+		t.Error("Expected sealing with a wrong key to fail")
+	}
+	sealed, err := SealStore(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sealed) != 1 || sealed[0] != "legacy" {
+		t.Errorf("Unexpected sealed profiles: %v", sealed)
+	}
+	results, _ = VerifyStore(provider)
+	if results[0].Status != IntegrityOK {
+		t.Errorf("Expected ok after sealing, got %s", results[0].Status)
+	}
+}
+
+// forgetTestSeal removes the seal marker, so the store looks like one written before
+// store seals existed.
+func forgetTestSeal(t *testing.T) {
+	t.Helper()
+	if err := os.Remove(filepath.Join(filepath.Dir(storePath), sealMarkerName)); err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+}
+
+// editTestStore changes credentials.json as it is on disk, store seal included.
+func editTestStore(t *testing.T, edit func(data map[string]map[string]string)) {
+	t.Helper()
+	b, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data map[string]map[string]string
+	if err := json.Unmarshal(b, &data); err != nil {
+		t.Fatal(err)
+	}
+	edit(data)
+	writeTestStore(t, data)
+}
+
+func TestStoreSeal(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	t.Setenv("CLOUDCTL_SECRET", key)
+	provider := NewSecretProvider(key)
+
+	save := func(profile, accessKey string) {
+		t.Helper()
+		s := &AWSSession{Profile: profile, AccessKey: accessKey, SecretKey: "s", SessionToken: "t",
+			Expiration: time.Now().Add(time.Hour), RoleArn: "arn:aws:iam::123456789012:role/" + profile}
+		if err := SaveCredentials(profile, s, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("dev", "AKIAOLD")
+	old := readTestStore(t)["dev"]
+	save("dev", "AKIANEW")
+	save("prod", "AKIAPROD")
+	if sealed, err := StoreSealed(); err != nil || !sealed {
+		t.Fatalf("Expected saving to seal the store, got %v, %v", sealed, err)
+	}
+	if _, ok := readTestStore(t)[storeSealKey]; ok {
+		t.Error("Expected the store seal to be hidden from the entries")
+	}
+	if _, err := ListAllSessionsWith(provider); err != nil {
+		t.Fatalf("Expected a sealed store to load, got %v", err)
+	}
+	intact, _ := os.ReadFile(storePath)
+	restore := func() {
+		t.Helper()
+		if err := os.WriteFile(storePath, intact, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tampering := map[string]func(data map[string]map[string]string){
+		"deleted entry":      func(data map[string]map[string]string) { delete(data, "prod") },
+		"rolled back entry":  func(data map[string]map[string]string) { data["dev"] = old },
+		"stripped entry MAC": func(data map[string]map[string]string) { delete(data["dev"], macField) },
+		"stripped seal":      func(data map[string]map[string]string) { delete(data, storeSealKey) },
+		"stripped seal and deleted entry": func(data map[string]map[string]string) {
+			delete(data, storeSealKey)
+			delete(data, "prod")
+		},
+		"stripped seal and rolled back entry": func(data map[string]map[string]string) {
+			delete(data, storeSealKey)
+			data["dev"] = old
+		},
+		"stripped seal and entry MAC": func(data map[string]map[string]string) {
+			delete(data, storeSealKey)
+			delete(data["dev"], macField)
+		},
+	}
+	for name, edit := range tampering {
+		editTestStore(t, edit)
+		if _, err := LoadCredentials("dev", key); !errors.Is(err, ErrStoreTampered) {
+			t.Errorf("%s: expected ErrStoreTampered, got %v", name, err)
+		}
+		if _, err := VerifyStore(provider); !errors.Is(err, ErrStoreTampered) {
+			t.Errorf("%s: expected verify to report tampering, got %v", name, err)
+		}
+		if err := SaveCredentials("other", testSession("other"), key); !errors.Is(err, ErrStoreTampered) {
+			t.Errorf("%s: expected a write not to seal over tampering, got %v", name, err)
+		}
+		restore()
+	}
+
+	// A wrong key is still a decryption error, not tampering
+	if _, err := LoadCredentials("dev", "wrong-secret"); err == nil || errors.Is(err, ErrStoreTampered) {
+		t.Errorf("Expected a decryption error for a wrong key, got %v", err)
+	}
+
+	// Removing a profile seals the store again
+	if err := RemoveProfile("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCredentials("dev", key); err != nil {
+		t.Errorf("Expected the store to stay valid after a removal, got %v", err)
+	}
+	// A new process without the secret can't seal the store again
+	processStore.clear()
+	t.Setenv("CLOUDCTL_SECRET", "")
+	if err := RemoveProfile("dev"); err == nil {
+		t.Error("Expected removing from a sealed store without the secret to fail")
+	}
+
+	// logout --all starts over with a new store, which is sealed again
+	if err := ClearAllCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	save("dev", "AKIAFRESH")
+	if _, err := LoadCredentials("dev", key); err != nil {
+		t.Errorf("Expected a new store to load after clearing, got %v", err)
+	}
+}
+
+func TestSealStoreSealsOlderStores(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	provider := NewSecretProvider(key)
+	if err := SaveCredentials("dev", testSession("dev"), key); err != nil {
+		t.Fatal(err)
+	}
+
+	// A store written before store seals still loads
+	editTestStore(t, func(data map[string]map[string]string) { delete(data, storeSealKey) })
+	forgetTestSeal(t)
+	if sealed, _ := StoreSealed(); sealed {
+		t.Fatal("Expected the store to be unsealed")
+	}
+	if _, err := LoadCredentials("dev", key); err != nil {
+		t.Fatalf("Expected an unsealed store to load, got %v", err)
+	}
+
+	if _, err := SealStore(provider); err != nil {
+		t.Fatal(err)
+	}
+	if sealed, _ := StoreSealed(); !sealed {
+		t.Error("Expected verify --seal to seal the store")
+	}
+	editTestStore(t, func(data map[string]map[string]string) { delete(data["dev"], macField) })
+	if _, err := VerifyStore(provider); !errors.Is(err, ErrStoreTampered) {
+		t.Errorf("Expected an unsealed entry in a sealed store to be tampering, got %v", err)
+	}
+}
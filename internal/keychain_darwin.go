@@ -7,7 +7,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"os/exec"
 	"runtime"
+	"strings"
 
 	"github.com/keybase/go-keychain"
 )
@@ -17,11 +19,11 @@ const (
 	KeychainAccount = "master-key"
 )
 
-// GetSecret retrieves a secret from one of three sources (in priority order):
+// resolveSecret retrieves a secret from one of three sources (in priority order):
 // 1. Explicit flag/argument (passed in)
 // 2. Environment variable (CLOUDCTL_SECRET)
 // 3. System Keychain (macOS only)
-func GetSecret(explicitSecret string) (string, error) {
+func resolveSecret(explicitSecret string) (string, error) {
 	// 1. Explicit flag
 	if explicitSecret != "" {
 		return explicitSecret, nil
@@ -33,7 +35,12 @@ func GetSecret(explicitSecret string) (string, error) {
 		return envSecret, nil
 	}
 
-	// 3. System Keychain (macOS only)
+	// 3. AWS Secrets Manager, when configured
+	if secret, ok, err := secretsManagerSecret(); ok {
+		return secret, err
+	}
+
+	// 4. System Keychain (macOS only)
 	if runtime.GOOS == "darwin" {
 		secret, err := getKeychainSecret()
 		if err == nil && secret != "" {
@@ -56,6 +63,7 @@ func SetupKeychain() (string, error) {
 		return "", err
 	}
 	secret := hex.EncodeToString(key) // 64 chars hex string
+	Wipe(key)
 
 	// Store in keychain
 	item := keychain.NewItem()
@@ -122,5 +130,18 @@ func getKeychainSecret() (string, error) {
 		return "", fmt.Errorf("secret not found in keychain")
 	}
 
-	return string(results[0].Data), nil
+	secret := string(results[0].Data)
+	Wipe(results[0].Data)
+	return secret, nil
+}
+
+// ReadKeychainSecretWithPrompt reads the secret through the `security` tool. The item's
+// access list only trusts cloudctl, so macOS asks the user to authenticate (password or
+// Touch ID) before releasing it.
+func ReadKeychainSecretWithPrompt() (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", KeychainService, "-a", KeychainAccount, "-w").Output()
+	if err != nil {
+		return "", fmt.Errorf("keychain access was denied or the secret is missing")
+	}
+	return strings.TrimSpace(string(out)), nil
 }
@@ -3,12 +3,15 @@
 package internal
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"os"
 )
 
-// GetSecret stub for non-macOS
-func GetSecret(explicitSecret string) (string, error) {
+// resolveSecret retrieves the secret from the explicit flag, CLOUDCTL_SECRET or, inside
+// WSL, the Windows Credential Manager.
+func resolveSecret(explicitSecret string) (string, error) {
 	if explicitSecret != "" {
 		return explicitSecret, nil
 	}
@@ -16,19 +19,54 @@ func GetSecret(explicitSecret string) (string, error) {
 	if envSecret != "" {
 		return envSecret, nil
 	}
-	return "", fmt.Errorf("no secret found and keychain is only supported on macOS")
+	if secret, ok, err := secretsManagerSecret(); ok {
+		return secret, err
+	}
+	if IsWSL() {
+		if secret, err := getWindowsCredential(); err == nil && secret != "" {
+			return secret, nil
+		}
+	}
+	return "", fmt.Errorf("no secret found and keychain is only supported on macOS and WSL")
 }
 
-// SetupKeychain stub for non-macOS
+// SetupKeychain generates a new secret and stores it in the Windows Credential Manager
+// when running inside WSL.
 func SetupKeychain() (string, error) {
-	return "", fmt.Errorf("keychain integration is only supported on macOS")
+	if !IsWSL() {
+		return "", fmt.Errorf("keychain integration is only supported on macOS and WSL")
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	secret := hex.EncodeToString(key)
+	Wipe(key)
+
+	if err := storeWindowsCredential(secret); err != nil {
+		return "", err
+	}
+	return secret, nil
 }
 
-// StoreKeychainSecret stub for non-macOS
+// StoreKeychainSecret stores a specific secret in the Windows Credential Manager when
+// running inside WSL.
 func StoreKeychainSecret(secret string) error {
-	return fmt.Errorf("keychain integration is only supported on macOS")
+	if !IsWSL() {
+		return fmt.Errorf("keychain integration is only supported on macOS and WSL")
+	}
+	return storeWindowsCredential(secret)
 }
 
 func getKeychainSecret() (string, error) {
+	if !IsWSL() {
+		return "", fmt.Errorf("keychain integration is only supported on macOS and WSL")
+	}
+	return getWindowsCredential()
+}
+
+// ReadKeychainSecretWithPrompt stub for non-macOS
+func ReadKeychainSecretWithPrompt() (string, error) {
 	return "", fmt.Errorf("keychain integration is only supported on macOS")
 }
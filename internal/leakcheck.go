@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Leak statuses reported by CheckLeakedKeys.
+const (
+	// LeakActive means the key belongs to a stored session that hasn't expired.
+	LeakActive = "active"
+	// LeakExpired means the key belongs to a stored session that has expired.
+	LeakExpired = "expired"
+	// LeakUnknown means no stored session uses the key.
+	LeakUnknown = "unknown"
+)
+
+// LeakResult is what leak-check knows about one access key.
+type LeakResult struct {
+	Key    string
+	Status string
+	// Session is the stored session using Key; nil when Status is LeakUnknown.
+	Session *AWSSession
+}
+
+// IsAccessKeyID reports whether s is exactly an AKIA or ASIA access key ID.
+func IsAccessKeyID(s string) bool {
+	m := accessKeyPattern.FindString(s)
+	return m != "" && m == s
+}
+
+// FindAccessKeys returns the distinct access key IDs in text, in order of appearance,
+// e.g. from a log file or a git diff.
+func FindAccessKeys(text string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, key := range accessKeyPattern.FindAllString(text, -1) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// CheckLeakedKeys matches keys against the access keys of stored sessions.
+func CheckLeakedKeys(keys []string, sessions []*AWSSession, now time.Time) []LeakResult {
+	byKey := make(map[string]*AWSSession, len(sessions))
+	for _, s := range sessions {
+		byKey[s.AccessKey] = s
+	}
+	results := make([]LeakResult, 0, len(keys))
+	for _, key := range keys {
+		r := LeakResult{Key: key, Status: LeakUnknown}
+		if s, ok := byKey[key]; ok {
+			r.Session = s
+			r.Status = LeakActive
+			if !s.Expiration.After(now) {
+				r.Status = LeakExpired
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// MaskAccessKey shortens an access key ID to its prefix and last four characters, so
+// output about a leak doesn't repeat the whole key.
+func MaskAccessKey(key string) string {
+	if len(key) < 12 {
+		return key
+	}
+	return key[:4] + "…" + key[len(key)-4:]
+}
+
+// AccessKeyAccount looks up the account that owns an access key with
+// sts:GetAccessKeyInfo, which works for keys of any account.
+func AccessKeyAccount(ctx context.Context, cfg aws.Config, key string) (string, error) {
+	out, err := newSTSClient(cfg).GetAccessKeyInfo(ctx, &sts.GetAccessKeyInfoInput{
+		AccessKeyId: aws.String(key),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.Account), nil
+}
+
+// RevokeSessionsHint returns how to cut off a leaked session before it expires.
+// Temporary credentials can't be deleted; instead a deny policy on the role (or the
+// IAM user, for MFA sessions) rejects credentials issued before now.
+func RevokeSessionsHint(s *AWSSession, now time.Time) string {
+	policy := `{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Action":"*","Resource":"*",` +
+		`"Condition":{"DateLessThan":{"aws:TokenIssueTime":"` + now.UTC().Format(time.RFC3339) + `"}}}]}`
+	if role := RoleName(s.RoleArn); role != "" {
+		return "aws iam put-role-policy --role-name " + role +
+			" --policy-name AWSRevokeOlderSessions --policy-document '" + policy + "'"
+	}
+	user := "<your-user>"
+	if p, err := ParseARN(s.PrincipalArn); err == nil && p.Service == "iam" && p.ResourceType() == "user" {
+		user = p.Name()
+	}
+	return "aws iam put-user-policy --user-name " + user + " --policy-name AWSRevokeOlderSessions --policy-document '" + policy + "'"
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"sort"
+	"time"
+)
+
+// LimitKind identifies which org norm a LimitWarning is about.
+type LimitKind string
+
+const (
+	LimitAccountSessions LimitKind = "account"
+	LimitRoleSessions    LimitKind = "role"
+	LimitDuration        LimitKind = "duration"
+)
+
+// limitWarnPercent is how close to a limit a count has to get before it is reported.
+const limitWarnPercent = 80
+
+// SessionAccountID returns the account ID resolved at login, else the one in the role
+// ARN; "" for MFA sessions stored without a resolved identity.
+func SessionAccountID(s *AWSSession) string {
+	if s.AccountID != "" {
+		return s.AccountID
+	}
+	return RoleAccountID(s.RoleArn)
+}
+
+// AccountUsage counts the stored sessions of one account.
+type AccountUsage struct {
+	// AccountID is "" for MFA sessions, which don't belong to a role.
+	AccountID string
+	Active    int
+	Expired   int
+	// Roles counts active sessions per role ARN.
+	Roles map[string]int
+}
+
+// SessionUsageByAccount groups sessions by account, sorted by account ID with MFA
+// sessions last.
+func SessionUsageByAccount(sessions []*AWSSession, now time.Time) []AccountUsage {
+	byAccount := make(map[string]*AccountUsage)
+	for _, s := range sessions {
+		id := SessionAccountID(s)
+		u, ok := byAccount[id]
+		if !ok {
+			u = &AccountUsage{AccountID: id, Roles: make(map[string]int)}
+			byAccount[id] = u
+		}
+		if s.Expiration.After(now) {
+			u.Active++
+			if id != "" {
+				u.Roles[s.RoleArn]++
+			}
+		} else {
+			u.Expired++
+		}
+	}
+
+	usage := make([]AccountUsage, 0, len(byAccount))
+	for _, u := range byAccount {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if (usage[i].AccountID == "") != (usage[j].AccountID == "") {
+			return usage[j].AccountID == ""
+		}
+		return usage[i].AccountID < usage[j].AccountID
+	})
+	return usage
+}
+
+// LimitWarning reports a count that is close to or over an org limit.
+type LimitWarning struct {
+	Kind LimitKind
+	// Subject is the account ID, role ARN or profile the warning is about.
+	Subject string
+	Value   int
+	Limit   int
+	// Exceeded is set when Value is over Limit rather than just approaching it.
+	Exceeded bool
+}
+
+// CheckSessionLimits compares active sessions with the configured limits. Concurrent
+// session counts warn from 80% of a limit; durations only warn when over it.
+func CheckSessionLimits(sessions []*AWSSession, limits LimitsConfig, now time.Time) []LimitWarning {
+	var warnings []LimitWarning
+	check := func(kind LimitKind, subject string, value, limit int) {
+		if limit <= 0 || value*100 < limit*limitWarnPercent {
+			return
+		}
+		warnings = append(warnings, LimitWarning{Kind: kind, Subject: subject, Value: value, Limit: limit, Exceeded: value > limit})
+	}
+
+	for _, u := range SessionUsageByAccount(sessions, now) {
+		if u.AccountID == "" {
+			continue
+		}
+		check(LimitAccountSessions, u.AccountID, u.Active, limits.MaxSessionsPerAccount)
+
+		roles := make([]string, 0, len(u.Roles))
+		for arn := range u.Roles {
+			roles = append(roles, arn)
+		}
+		sort.Strings(roles)
+		for _, arn := range roles {
+			check(LimitRoleSessions, arn, u.Roles[arn], limits.MaxSessionsPerRole)
+		}
+	}
+
+	if limits.MaxDurationMinutes > 0 {
+		active := make([]*AWSSession, 0, len(sessions))
+		for _, s := range sessions {
+			if s.Expiration.After(now) && s.Duration > 0 {
+				active = append(active, s)
+			}
+		}
+		sort.Slice(active, func(i, j int) bool { return active[i].Profile < active[j].Profile })
+		for _, s := range active {
+			minutes := int(s.Duration) / 60
+			if minutes > limits.MaxDurationMinutes {
+				warnings = append(warnings, LimitWarning{Kind: LimitDuration, Subject: s.Profile, Value: minutes, Limit: limits.MaxDurationMinutes, Exceeded: true})
+			}
+		}
+	}
+	return warnings
+}
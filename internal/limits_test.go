@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func limitTestSessions(now time.Time) []*AWSSession {
+	active := now.Add(time.Hour)
+	return []*AWSSession{
+		{Profile: "prod-admin", RoleArn: "arn:aws:iam::111111111111:role/Admin", Expiration: active, Duration: 3600},
+		{Profile: "prod-admin-2", RoleArn: "arn:aws:iam::111111111111:role/Admin", Expiration: active, Duration: 3600},
+		{Profile: "prod-ro", RoleArn: "arn:aws:iam::111111111111:role/ReadOnly", Expiration: active, Duration: 43200},
+		{Profile: "prod-old", RoleArn: "arn:aws:iam::111111111111:role/Admin", Expiration: now.Add(-time.Hour), Duration: 43200},
+		{Profile: "dev", RoleArn: "arn:aws:iam::222222222222:role/Dev", Expiration: active, Duration: 3600},
+		{Profile: "mfa", RoleArn: "MFA-Session", Expiration: active},
+	}
+}
+
+func TestSessionUsageByAccount(t *testing.T) {
+	now := time.Now()
+	usage := SessionUsageByAccount(limitTestSessions(now), now)
+
+	if len(usage) != 3 {
+		t.Fatalf("Expected 3 groups, got %+v", usage)
+	}
+	if usage[0].AccountID != "111111111111" || usage[1].AccountID != "222222222222" || usage[2].AccountID != "" {
+		t.Errorf("Unexpected order: %s, %s, %s", usage[0].AccountID, usage[1].AccountID, usage[2].AccountID)
+	}
+	prod := usage[0]
+	if prod.Active != 3 || prod.Expired != 1 {
+		t.Errorf("Expected 3 active and 1 expired, got %d and %d", prod.Active, prod.Expired)
+	}
+	if prod.Roles["arn:aws:iam::111111111111:role/Admin"] != 2 {
+		t.Errorf("Expected 2 active Admin sessions, got %v", prod.Roles)
+	}
+	if len(usage[2].Roles) != 0 {
+		t.Errorf("MFA sessions should not count as roles, got %v", usage[2].Roles)
+	}
+}
+
+func TestCheckSessionLimits(t *testing.T) {
+	now := time.Now()
+	sessions := limitTestSessions(now)
+
+	if w := CheckSessionLimits(sessions, LimitsConfig{}, now); len(w) != 0 {
+		t.Errorf("Expected no warnings without limits, got %+v", w)
+	}
+
+	warnings := CheckSessionLimits(sessions, LimitsConfig{MaxSessionsPerAccount: 2, MaxSessionsPerRole: 2, MaxDurationMinutes: 60}, now)
+	want := []LimitWarning{
+		{Kind: LimitAccountSessions, Subject: "111111111111", Value: 3, Limit: 2, Exceeded: true},
+		{Kind: LimitRoleSessions, Subject: "arn:aws:iam::111111111111:role/Admin", Value: 2, Limit: 2},
+		{Kind: LimitDuration, Subject: "prod-ro", Value: 720, Limit: 60, Exceeded: true},
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("Expected %d warnings, got %+v", len(want), warnings)
+	}
+	for i := range want {
+		if warnings[i] != want[i] {
+			t.Errorf("Warning %d: expected %+v, got %+v", i, want[i], warnings[i])
+		}
+	}
+
+	// 3 of 5 is below the 80% threshold
+	if w := CheckSessionLimits(sessions, LimitsConfig{MaxSessionsPerAccount: 5}, now); len(w) != 0 {
+		t.Errorf("Expected no warnings below threshold, got %+v", w)
+	}
+	if w := CheckSessionLimits(sessions, LimitsConfig{MaxSessionsPerAccount: 3}, now); len(w) != 1 || w[0].Exceeded {
+		t.Errorf("Expected one approaching warning at the limit, got %+v", w)
+	}
+}
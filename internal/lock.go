@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var lockStatePath = filepath.Join(storeDir, "lock.json")
+
+// ErrStoreLocked is returned by GetSecret while the store is auto-locked.
+var ErrStoreLocked = errors.New("store is locked after inactivity; run 'cloudctl unlock'")
+
+// LockState is the persisted auto-lock state. It never holds secret material.
+type LockState struct {
+	// LastActivity is when a cloudctl command was last run by the user.
+	LastActivity time.Time `json:"last_activity"`
+	// Locked is set by `cloudctl lock` or once the inactivity timeout has passed.
+	Locked   bool      `json:"locked"`
+	LockedAt time.Time `json:"locked_at,omitempty"`
+}
+
+// AutoLockTimeout returns security.auto_lock_minutes as a duration; 0 means disabled.
+func AutoLockTimeout() time.Duration {
+	return time.Duration(CurrentConfig().Security.AutoLockMinutes) * time.Minute
+}
+
+// IsLocked reports whether the store is locked at now for the given timeout.
+func (s LockState) IsLocked(now time.Time, timeout time.Duration) bool {
+	if s.Locked {
+		return true
+	}
+	return timeout > 0 && !s.LastActivity.IsZero() && now.Sub(s.LastActivity) > timeout
+}
+
+// LockedSince returns when the store became locked.
+func (s LockState) LockedSince(timeout time.Duration) time.Time {
+	if s.Locked {
+		return s.LockedAt
+	}
+	return s.LastActivity.Add(timeout)
+}
+
+// LoadLockState reads the lock state; a missing file means unlocked with no activity yet.
+func LoadLockState() (LockState, error) {
+	var s LockState
+	b, err := os.ReadFile(lockStatePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return s, fmt.Errorf("failed to read lock state: %w", err)
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return s, fmt.Errorf("failed to parse lock state: %w", err)
+	}
+	return s, nil
+}
+
+func saveLockState(s LockState) error {
+	if err := os.MkdirAll(filepath.Dir(lockStatePath), 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock state: %w", err)
+	}
+	return os.WriteFile(lockStatePath, b, 0600)
+}
+
+// RecordActivity marks user activity, postponing the auto-lock. A store that is already
+// locked (including one whose timeout has just passed) stays locked until Unlock.
+func RecordActivity() error {
+	timeout := AutoLockTimeout()
+	if timeout <= 0 {
+		return nil
+	}
+	s, err := LoadLockState()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if s.IsLocked(now, timeout) {
+		if !s.Locked {
+			s.Locked = true
+			s.LockedAt = s.LockedSince(timeout)
+			return saveLockState(s)
+		}
+		return nil
+	}
+	s.LastActivity = now
+	return saveLockState(s)
+}
+
+// CheckLock returns ErrStoreLocked when the store is locked.
+func CheckLock() error {
+	s, err := LoadLockState()
+	if err != nil {
+		return err
+	}
+	if s.IsLocked(time.Now(), AutoLockTimeout()) {
+		return ErrStoreLocked
+	}
+	return nil
+}
+
+// Lock locks the store immediately.
+func Lock() error {
+	s, err := LoadLockState()
+	if err != nil {
+		return err
+	}
+	if !s.Locked {
+		s.Locked = true
+		s.LockedAt = time.Now()
+	}
+	return saveLockState(s)
+}
+
+// Unlock clears the lock and restarts the inactivity timer. Callers must have
+// re-authenticated the user first.
+func Unlock() error {
+	return saveLockState(LockState{LastActivity: time.Now()})
+}
+
+// GetSecret returns the encryption secret from the flag, CLOUDCTL_SECRET or the keychain,
+// unless the store is locked. With an age or KMS provider the secret is optional, and an
+// empty secret is returned when none is configured.
+func GetSecret(explicitSecret string) (string, error) {
+	if err := CheckLock(); err != nil {
+		return "", err
+	}
+	secret, err := resolveSecret(explicitSecret)
+	if err != nil && CurrentConfig().Encryption.UsesEnvelope() {
+		return "", nil
+	}
+	return secret, err
+}
+
+// ResolveSecretForUnlock returns the configured secret without checking the lock, so
+// `cloudctl unlock` can verify what the user entered against it.
+func ResolveSecretForUnlock() (string, error) {
+	return resolveSecret("")
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupTestLock(t *testing.T) {
+	t.Helper()
+	originalPath := lockStatePath
+	lockStatePath = filepath.Join(t.TempDir(), "lock.json")
+	t.Cleanup(func() {
+		lockStatePath = originalPath
+	})
+}
+
+func TestLockStateIsLocked(t *testing.T) {
+	now := time.Now()
+	timeout := 15 * time.Minute
+
+	tests := []struct {
+		name  string
+		state LockState
+		want  bool
+	}{
+		{"no activity yet", LockState{}, false},
+		{"recent activity", LockState{LastActivity: now.Add(-5 * time.Minute)}, false},
+		{"inactive too long", LockState{LastActivity: now.Add(-20 * time.Minute)}, true},
+		{"locked manually", LockState{LastActivity: now, Locked: true}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.state.IsLocked(now, timeout); got != tt.want {
+			t.Errorf("%s: IsLocked = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Auto-lock disabled: only a manual lock counts
+	if (LockState{LastActivity: now.Add(-24 * time.Hour)}).IsLocked(now, 0) {
+		t.Error("Expected no auto-lock when the timeout is 0")
+	}
+
+	since := LockState{LastActivity: now.Add(-20 * time.Minute)}.LockedSince(timeout)
+	if !since.Equal(now.Add(-5 * time.Minute)) {
+		t.Errorf("Unexpected LockedSince %v", since)
+	}
+}
+
+func TestLockUnlock(t *testing.T) {
+	setupTestLock(t)
+
+	if err := CheckLock(); err != nil {
+		t.Fatalf("Expected unlocked store, got %v", err)
+	}
+
+	if err := Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckLock(); !errors.Is(err, ErrStoreLocked) {
+		t.Fatalf("Expected ErrStoreLocked, got %v", err)
+	}
+	if _, err := GetSecret("explicit"); !errors.Is(err, ErrStoreLocked) {
+		t.Errorf("Expected GetSecret to refuse while locked, got %v", err)
+	}
+
+	if err := Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckLock(); err != nil {
+		t.Errorf("Expected unlocked store after Unlock, got %v", err)
+	}
+	if secret, err := GetSecret("explicit"); err != nil || secret != "explicit" {
+		t.Errorf("Expected explicit secret after Unlock, got %q, %v", secret, err)
+	}
+}
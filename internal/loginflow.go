@@ -0,0 +1,366 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// The login, MFA login and restore flows below are shared by the commands, the Go
+// package and tests. They never print or prompt: MFA codes are passed in, failures
+// are returned and problems that don't stop the flow go to Warn.
+
+// MFAError is returned when the MFA code was rejected, as opposed to the role.
+type MFAError struct {
+	Err error
+	// ClockSkew is how far the local clock was ahead of AWS when the code was rejected,
+	// from the date of the STS response; 0 when unknown.
+	ClockSkew time.Duration
+}
+
+func newMFAError(err error) *MFAError {
+	skew, _ := ClockSkewFromError(err)
+	return &MFAError{Err: err, ClockSkew: skew}
+}
+
+func (e *MFAError) Error() string {
+	if ClockSkewed(e.ClockSkew) {
+		return fmt.Sprintf("MFA authentication failed: %v (%s)", e.Err, DescribeClockSkew(e.ClockSkew))
+	}
+	return fmt.Sprintf("MFA authentication failed: %v", e.Err)
+}
+
+func (e *MFAError) Unwrap() error {
+	return e.Err
+}
+
+// LoginSource is the AWS config of a login's source.
+type LoginSource struct {
+	Config aws.Config
+	// MFA is set when the source is a cloudctl MFA session, which needs no further MFA.
+	MFA bool
+	// Session is the cloudctl session the source names, if any.
+	Session *AWSSession
+}
+
+// LoadLoginSource loads the source of a role login: a cloudctl session when secret
+// unlocks one, the environment or instance role, or an AWS CLI profile.
+func LoadLoginSource(ctx context.Context, source, secret, region string) (*LoginSource, error) {
+	session := loadSourceSession(source, secret)
+	cfg, err := LoadSourceConfig(ctx, source, secret, region)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginSource{Config: cfg, MFA: session != nil && session.RoleArn == "MFA-Session", Session: session}, nil
+}
+
+// LoadProfileConfig loads the environment, instance role or AWS CLI profile source of
+// an MFA login. cloudctl sessions can't be used: GetSessionToken needs long-lived keys.
+func LoadProfileConfig(ctx context.Context, source, region string) (aws.Config, error) {
+	if IsBuiltinSource(source) {
+		return LoadBuiltinSourceConfig(ctx, source, region)
+	}
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithSharedConfigProfile(source),
+		config.WithRegion(region),
+		WithCallTimeout)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load source: %w", err)
+	}
+	return cfg, nil
+}
+
+// RoleLoginOptions describes a role login.
+type RoleLoginOptions struct {
+	// Profile is the name the session is stored under, also used as session name.
+	Profile  string
+	RoleArn  string
+	Source   string
+	Region   string
+	Duration int32
+	// SourceSession is the cloudctl session Source names (LoginSource.Session), for the
+	// policy file's source conditions.
+	SourceSession *AWSSession
+	// MFASerial and TokenCode authenticate with GetSessionToken before the role is
+	// assumed.
+	MFASerial string
+	TokenCode string
+	// Justification is required for break-glass roles; it is sent as a session tag
+	// with the current user as SourceIdentity.
+	Justification string
+	// SelfDestruct sets a local deadline this long after login.
+	SelfDestruct time.Duration
+	// CheckAccess classifies the role from its attached policies.
+	CheckAccess bool
+	// Warn receives problems that don't fail the login.
+	Warn func(format string, args ...any)
+}
+
+// AssumeRequest returns the login as the policy file sees it.
+func (o RoleLoginOptions) AssumeRequest() AssumeRequest {
+	return AssumeRequest{RoleArn: o.RoleArn, Source: o.Source, SourceSession: o.SourceSession, MFA: o.MFASerial != ""}
+}
+
+// LoginRole assumes a role from the source config and returns the new session. It is
+// not stored; see StoreSession.
+func LoginRole(ctx context.Context, src aws.Config, opts RoleLoginOptions) (*AWSSession, error) {
+	warn := warnFunc(opts.Warn)
+	if err := requireConfig(); err != nil {
+		return nil, err
+	}
+	if CurrentConfig().IsBreakGlass(opts.RoleArn) && opts.Justification == "" {
+		return nil, fmt.Errorf("%s is a break-glass role and needs a justification", opts.RoleArn)
+	}
+	if err := CheckAssumePolicy(opts.AssumeRequest()); err != nil {
+		return nil, err
+	}
+	duration := opts.Duration
+	if duration == 0 {
+		duration = defaultRoleDuration
+	}
+
+	cfg := src.Copy()
+	if opts.MFASerial != "" {
+		out, err := NewSTSClient(cfg, opts.Profile).GetSessionToken(ctx, &sts.GetSessionTokenInput{
+			DurationSeconds: aws.Int32(3600),
+			SerialNumber:    aws.String(opts.MFASerial),
+			TokenCode:       aws.String(opts.TokenCode),
+		})
+		if err != nil {
+			return nil, newMFAError(err)
+		}
+		cfg.Credentials = aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(
+			aws.ToString(out.Credentials.AccessKeyId),
+			aws.ToString(out.Credentials.SecretAccessKey),
+			aws.ToString(out.Credentials.SessionToken)))
+	}
+
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(opts.RoleArn),
+		RoleSessionName: aws.String(opts.Profile),
+		DurationSeconds: aws.Int32(duration),
+	}
+	if opts.Justification != "" {
+		input.Tags = BreakGlassTags(opts.Justification)
+		input.SourceIdentity = aws.String(SourceIdentityFor(CurrentUser()))
+	}
+	out, err := NewSTSClient(cfg, opts.Profile).AssumeRole(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &AWSSession{
+		Profile:       opts.Profile,
+		RoleArn:       opts.RoleArn,
+		SourceProfile: opts.Source,
+		Region:        opts.Region,
+		MfaArn:        opts.MFASerial,
+		Duration:      duration,
+	}
+	setCredentials(s, out.Credentials)
+	if opts.SelfDestruct > 0 {
+		s.SelfDestruct = time.Now().Add(opts.SelfDestruct).Truncate(time.Second)
+	}
+
+	if opts.CheckAccess {
+		sessionCfg := cfg.Copy()
+		sessionCfg.Credentials = credentials.NewStaticCredentialsProvider(s.AccessKey, s.SecretKey, s.SessionToken)
+		if access, err := DetectRoleAccess(ctx, sessionCfg, opts.RoleArn); err != nil {
+			warn("Could not check access (needs iam:ListAttachedRolePolicies and iam:ListRolePolicies): %v", err)
+		} else {
+			s.Access = access
+		}
+	}
+	resolveIdentity(ctx, cfg, s, warn)
+	recordRoleUse(s)
+	return s, nil
+}
+
+// MFALoginOptions describes an MFA login.
+type MFALoginOptions struct {
+	Profile   string
+	Source    string
+	Region    string
+	Duration  int32
+	MFASerial string
+	TokenCode string
+	// Warn receives problems that don't fail the login.
+	Warn func(format string, args ...any)
+}
+
+// LoginMFA gets an MFA session with GetSessionToken from the source config and returns
+// it, to be stored with StoreSession.
+func LoginMFA(ctx context.Context, src aws.Config, opts MFALoginOptions) (*AWSSession, error) {
+	duration := opts.Duration
+	if duration == 0 {
+		duration = defaultMFADuration
+	}
+	out, err := NewSTSClient(src, opts.Profile).GetSessionToken(ctx, &sts.GetSessionTokenInput{
+		DurationSeconds: aws.Int32(duration),
+		SerialNumber:    aws.String(opts.MFASerial),
+		TokenCode:       aws.String(opts.TokenCode),
+	})
+	if err != nil {
+		return nil, newMFAError(err)
+	}
+	s := &AWSSession{
+		Profile:       opts.Profile,
+		RoleArn:       "MFA-Session",
+		SourceProfile: opts.Source,
+		Region:        opts.Region,
+		MfaArn:        opts.MFASerial,
+		Duration:      duration,
+	}
+	setCredentials(s, out.Credentials)
+	resolveIdentity(ctx, src, s, warnFunc(opts.Warn))
+	recordMFALogin(s)
+	return s, nil
+}
+
+// StoreSession saves a new session and publishes it to shared remote state.
+func StoreSession(ctx context.Context, s *AWSSession, secret string, warn func(format string, args ...any)) error {
+	if err := SaveCredentials(s.Profile, s, secret); err != nil {
+		return err
+	}
+	if err := PublishRemoteSession(ctx, s, secret); err != nil {
+		warnFunc(warn)("Failed to update remote state: %v", err)
+	}
+	return nil
+}
+
+// RestoreSession logs a stored session in again from its source, the way `cloudctl
+// refresh` does once a silent refresh isn't possible: MFA sessions get a new MFA session
+// and roles are assumed again, with tokenCode when the session used MFA. The new session
+// keeps the stored identity and settings, is saved and published.
+func RestoreSession(ctx context.Context, s *AWSSession, secret, tokenCode string, warn func(format string, args ...any)) (*AWSSession, error) {
+	if s.IsRoot() {
+		return nil, fmt.Errorf("root sessions cannot be restored; run root-login again")
+	}
+	if cfg := CurrentConfig(); cfg.RequiresDualControl(s.RoleArn) || cfg.IsBreakGlass(s.RoleArn) {
+		return nil, fmt.Errorf("role %s needs a new approval or justification; log in again", s.RoleArn)
+	}
+	region := s.Region
+	if region == "" {
+		region = DefaultRegion
+	}
+	duration := s.Duration
+	if duration < 900 {
+		duration = defaultRoleDuration
+	}
+
+	if s.RoleArn != "MFA-Session" {
+		req := AssumeRequest{RoleArn: s.RoleArn, Source: s.SourceProfile, SourceSession: loadSourceSession(s.SourceProfile, secret), MFA: s.MfaArn != ""}
+		if err := CheckAssumePolicy(req); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := LoadSourceConfig(ctx, s.SourceProfile, secret, region)
+	if err != nil {
+		return nil, err
+	}
+	client := NewSTSClient(cfg, s.Profile)
+	restored := &AWSSession{
+		Profile:       s.Profile,
+		RoleArn:       s.RoleArn,
+		SourceProfile: s.SourceProfile,
+		Region:        region,
+		MfaArn:        s.MfaArn,
+		Duration:      duration,
+		Access:        s.Access,
+		AccountID:     s.AccountID,
+		PrincipalArn:  s.PrincipalArn,
+		UserID:        s.UserID,
+		AccountAlias:  s.AccountAlias,
+		SelfDestruct:  s.SelfDestruct,
+	}
+
+	start := time.Now()
+	if s.RoleArn == "MFA-Session" {
+		restored.Access = ""
+		var out *sts.GetSessionTokenOutput
+		out, err = client.GetSessionToken(ctx, &sts.GetSessionTokenInput{
+			DurationSeconds: aws.Int32(duration),
+			SerialNumber:    aws.String(s.MfaArn),
+			TokenCode:       aws.String(tokenCode),
+		})
+		if err != nil {
+			err = newMFAError(err)
+		} else {
+			setCredentials(restored, out.Credentials)
+		}
+	} else {
+		input := &sts.AssumeRoleInput{
+			RoleArn:         aws.String(s.RoleArn),
+			RoleSessionName: aws.String(s.Profile),
+			DurationSeconds: aws.Int32(duration),
+		}
+		if s.MfaArn != "" {
+			input.SerialNumber = aws.String(s.MfaArn)
+			input.TokenCode = aws.String(tokenCode)
+		}
+		var out *sts.AssumeRoleOutput
+		if out, err = client.AssumeRole(ctx, input); err == nil {
+			setCredentials(restored, out.Credentials)
+		}
+	}
+	if err != nil {
+		RecordRefresh(s.Profile, "interactive", start, err)
+		return nil, err
+	}
+
+	if err := SaveCredentials(s.Profile, restored, secret); err != nil {
+		return nil, fmt.Errorf("failed to save refreshed session: %w", err)
+	}
+	RecordRefresh(s.Profile, "interactive", start, nil)
+	if err := PublishRemoteSession(ctx, restored, secret); err != nil {
+		warnFunc(warn)("Failed to update remote state: %v", err)
+	}
+	return restored, nil
+}
+
+// NeedsMFACode reports whether restoring s asks for an MFA code.
+func NeedsMFACode(s *AWSSession) bool {
+	return s.RoleArn == "MFA-Session" || s.MfaArn != ""
+}
+
+func setCredentials(s *AWSSession, c *types.Credentials) {
+	s.AccessKey = aws.ToString(c.AccessKeyId)
+	s.SecretKey = aws.ToString(c.SecretAccessKey)
+	s.SessionToken = aws.ToString(c.SessionToken)
+	s.Expiration = aws.ToTime(c.Expiration)
+}
+
+// resolveIdentity stores the session's identity and account alias when
+// display.resolve_identity is set.
+func resolveIdentity(ctx context.Context, cfg aws.Config, s *AWSSession, warn func(format string, args ...any)) {
+	if !CurrentConfig().Display.ResolveIdentity {
+		return
+	}
+	if err := ResolveCallerIdentity(ctx, cfg, s); err != nil {
+		warn("Could not resolve the session identity: %v", err)
+		return
+	}
+	// Many roles may not list the aliases, so a failure is not worth a warning
+	_ = ResolveAccountAlias(ctx, cfg, s)
+}
+
+func warnFunc(warn func(format string, args ...any)) func(format string, args ...any) {
+	if warn == nil {
+		return func(string, ...any) {}
+	}
+	return warn
+}
+
+// IsMFAError reports whether err is a rejected MFA code.
+func IsMFAError(err error) bool {
+	var mfaErr *MFAError
+	return errors.As(err, &mfaErr)
+}
@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+func TestLoginRole(t *testing.T) {
+	mock := setupMockSTS(t)
+	loadedConfig.Security.BreakGlassRoles = []string{"*:role/BreakGlass"}
+
+	role := "arn:aws:iam::123456789012:role/Admin"
+	s, err := LoginRole(context.Background(), aws.Config{Region: "eu-west-1"}, RoleLoginOptions{
+		Profile: "prod-admin", RoleArn: role, Source: "default", Region: "eu-west-1",
+		MFASerial: "arn:aws:iam::123456789012:mfa/me", TokenCode: "123456", SelfDestruct: 30 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := mock.Calls()
+	if len(calls) != 3 || calls[0].Operation != "GetSessionToken" || calls[1].Operation != "AssumeRole" || calls[2].Operation != "GetCallerIdentity" {
+		t.Fatalf("Unexpected calls: %+v", calls)
+	}
+	if in := calls[0].Input.(*sts.GetSessionTokenInput); aws.ToString(in.TokenCode) != "123456" {
+		t.Errorf("Unexpected GetSessionToken input: %+v", in)
+	}
+	if in := calls[1].Input.(*sts.AssumeRoleInput); aws.ToInt32(in.DurationSeconds) != 3600 || in.Tags != nil {
+		t.Errorf("Unexpected AssumeRole input: %+v", in)
+	}
+	if s.AccessKey != "ASIAMOCK00000002" || s.RoleArn != role || s.SourceProfile != "default" || s.Duration != 3600 ||
+		s.MfaArn == "" || s.AccountID != "123456789012" || s.SelfDestruct.IsZero() {
+		t.Errorf("Unexpected session: %+v", s)
+	}
+
+	// Break-glass roles need a justification, which is sent as a tag
+	breakGlass := "arn:aws:iam::123456789012:role/BreakGlass"
+	if _, err := LoginRole(context.Background(), aws.Config{}, RoleLoginOptions{Profile: "bg", RoleArn: breakGlass}); err == nil {
+		t.Error("Expected a break-glass login without justification to fail")
+	}
+	if n := len(mock.Calls()); n != 3 {
+		t.Errorf("Expected no STS call for a refused login, got %d calls", n)
+	}
+	if _, err := LoginRole(context.Background(), aws.Config{}, RoleLoginOptions{Profile: "bg", RoleArn: breakGlass, Justification: "INC-1"}); err != nil {
+		t.Fatal(err)
+	}
+	in := mock.Calls()[3].Input.(*sts.AssumeRoleInput)
+	if len(in.Tags) == 0 || in.SourceIdentity == nil {
+		t.Errorf("Expected break-glass tags and a source identity: %+v", in)
+	}
+
+	mock.GetSessionTokenFunc = func(*sts.GetSessionTokenInput) (*sts.GetSessionTokenOutput, error) {
+		return nil, errors.New("MultiFactorAuthentication failed")
+	}
+	_, err = LoginRole(context.Background(), aws.Config{}, RoleLoginOptions{Profile: "p", RoleArn: role, MFASerial: "arn:aws:iam::123456789012:mfa/me"})
+	if !IsMFAError(err) {
+		t.Errorf("Expected an MFA error, got %v", err)
+	}
+}
+
+func TestLoginRoleAccountAlias(t *testing.T) {
+	setupMockSTS(t)
+	role := "arn:aws:iam::123456789012:role/Admin"
+
+	// The alias is looked up with the new session's credentials
+	listAccountAliases = func(_ context.Context, cfg aws.Config) ([]string, error) {
+		if creds, err := cfg.Credentials.Retrieve(context.Background()); err != nil || creds.AccessKeyID != "ASIAMOCK00000001" {
+			t.Errorf("Unexpected alias lookup credentials: %+v (%v)", creds, err)
+		}
+		return []string{"acme-prod"}, nil
+	}
+	s, err := LoginRole(context.Background(), aws.Config{}, RoleLoginOptions{Profile: "prod-admin", RoleArn: role})
+	if err != nil || s.AccountAlias != "acme-prod" {
+		t.Errorf("Expected the account alias, got %+v (%v)", s, err)
+	}
+
+	listAccountAliases = func(context.Context, aws.Config) ([]string, error) { return nil, errors.New("AccessDenied") }
+	s, err = LoginRole(context.Background(), aws.Config{}, RoleLoginOptions{Profile: "prod-admin", RoleArn: role})
+	if err != nil || s.AccountAlias != "" || s.AccountID != "123456789012" {
+		t.Errorf("Expected a login without alias, got %+v (%v)", s, err)
+	}
+}
+
+func TestBrokenConfigFailsClosed(t *testing.T) {
+	mock := setupMockSTS(t)
+	loadedConfigErr = errors.New("failed to parse config: unexpected end of JSON input")
+
+	role := "arn:aws:iam::123456789012:role/Admin"
+	if _, err := LoginRole(context.Background(), aws.Config{}, RoleLoginOptions{Profile: "prod-admin", RoleArn: role}); err == nil {
+		t.Error("Expected a login with a broken config file to fail")
+	}
+	s := testSession("prod-admin")
+	s.SourceProfile = "default"
+	if _, err := RefreshSession(context.Background(), s, "", "eu-west-1", func(string, ...any) {}); err == nil {
+		t.Error("Expected a refresh with a broken config file to fail")
+	}
+	if len(mock.Calls()) != 0 {
+		t.Errorf("Expected no STS calls on default settings, got %+v", mock.Calls())
+	}
+}
+
+func TestLoginMFAAndStore(t *testing.T) {
+	mock := setupMockSTS(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+
+	s, err := LoginMFA(context.Background(), aws.Config{}, MFALoginOptions{
+		Profile: "mfa", Source: "default", Region: "eu-west-1", MFASerial: "arn:aws:iam::123456789012:mfa/me", TokenCode: "654321",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.RoleArn != "MFA-Session" || s.Duration != defaultMFADuration || time.Until(s.Expiration) < 11*time.Hour {
+		t.Errorf("Unexpected MFA session: %+v", s)
+	}
+	if in := mock.Calls()[0].Input.(*sts.GetSessionTokenInput); aws.ToInt32(in.DurationSeconds) != defaultMFADuration {
+		t.Errorf("Unexpected GetSessionToken input: %+v", in)
+	}
+
+	if err := StoreSession(context.Background(), s, key, nil); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := LoadCredentials("mfa", key)
+	if err != nil || stored.AccessKey != s.AccessKey || stored.PrincipalArn != s.PrincipalArn {
+		t.Errorf("Unexpected stored session: %+v (%v)", stored, err)
+	}
+}
+
+func TestRestoreSession(t *testing.T) {
+	mock := setupMockSTS(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+
+	source := testSession("dev-mfa")
+	source.RoleArn, source.MfaArn, source.Duration = "MFA-Session", "arn:aws:iam::123456789012:mfa/me", 43200
+	source.SourceProfile, source.AccountID = "@env", "123456789012"
+	source.Expiration = time.Now().Add(-time.Hour)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	if err := SaveCredentials(source.Profile, source, key); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := RestoreSession(context.Background(), source, key, "111111", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := mock.Calls()[0].Input.(*sts.GetSessionTokenInput)
+	if aws.ToString(in.TokenCode) != "111111" || aws.ToString(in.SerialNumber) != source.MfaArn || aws.ToInt32(in.DurationSeconds) != 43200 {
+		t.Errorf("Unexpected GetSessionToken input: %+v", in)
+	}
+	if restored.AccountID != "123456789012" || time.Until(restored.Expiration) < 11*time.Hour {
+		t.Errorf("Expected the identity to be kept: %+v", restored)
+	}
+
+	// A role that used MFA is assumed again from its now valid source, with the code
+	role := testSession("dev-admin")
+	role.SourceProfile, role.MfaArn = "dev-mfa", source.MfaArn
+	if _, err := RestoreSession(context.Background(), role, key, "222222", nil); err != nil {
+		t.Fatal(err)
+	}
+	assume := mock.Calls()[1].Input.(*sts.AssumeRoleInput)
+	if aws.ToString(assume.TokenCode) != "222222" || aws.ToString(assume.RoleSessionName) != "dev-admin" {
+		t.Errorf("Unexpected AssumeRole input: %+v", assume)
+	}
+	if stored, _ := LoadCredentials("dev-admin", key); stored == nil || stored.AccessKey != "ASIAMOCK00000002" {
+		t.Errorf("Restored role was not stored: %+v", stored)
+	}
+
+	mock.GetSessionTokenFunc = func(*sts.GetSessionTokenInput) (*sts.GetSessionTokenOutput, error) {
+		return nil, errors.New("invalid code")
+	}
+	if _, err := RestoreSession(context.Background(), source, key, "000000", nil); !IsMFAError(err) {
+		t.Errorf("Expected an MFA error, got %v", err)
+	}
+}
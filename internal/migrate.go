@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StoreVersion is the format version written with every store entry. Entries without a
+// version field were written before it existed and count as version 1: they may lack
+// fields added since, which are backfilled or reported when they load.
+const StoreVersion = 2
+
+// versionField is the store field holding an entry's format version. It is not
+// encrypted, so the store can be described without the secret.
+const versionField = "Version"
+
+// DefaultRegion is the region login uses without --region.
+const DefaultRegion = "ap-southeast-1"
+
+// Default durations of sessions that were stored without one, matching the login flags.
+const (
+	defaultRoleDuration = 3600
+	defaultMFADuration  = 43200
+)
+
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// ErrNeedsRelogin is matched by errors for stored sessions that are incomplete or
+// invalid and can only be fixed by logging in again.
+var ErrNeedsRelogin = errors.New("stored session needs a new login")
+
+// StaleSession is a stored session that can't be upgraded, and why.
+type StaleSession struct {
+	Profile  string
+	Problems []string
+}
+
+// StaleSessionsError lists the stored sessions that need a new login.
+type StaleSessionsError struct {
+	Sessions []StaleSession
+}
+
+func (e *StaleSessionsError) Error() string {
+	parts := make([]string, 0, len(e.Sessions))
+	for _, s := range e.Sessions {
+		parts = append(parts, fmt.Sprintf("%s (%s)", s.Profile, strings.Join(s.Problems, ", ")))
+	}
+	return fmt.Sprintf("%d stored session(s) written by an older version need a new login: %s; log in again or remove them with 'cloudctl logout --profile <name>'",
+		len(e.Sessions), strings.Join(parts, "; "))
+}
+
+func (e *StaleSessionsError) Is(target error) bool { return target == ErrNeedsRelogin }
+
+// entryVersion returns the format version of a store entry.
+func entryVersion(enc map[string]string) int {
+	v, err := strconv.Atoi(enc[versionField])
+	if err != nil {
+		return 1
+	}
+	return v
+}
+
+// upgradeSession backfills the fields a version 1 entry may lack and returns what is
+// missing or invalid in it. Credentials and the expiration can't be backfilled.
+func upgradeSession(s *AWSSession, enc map[string]string) []string {
+	var problems []string
+	for _, field := range []string{"AccessKey", "SecretKey", "SessionToken", "RoleArn"} {
+		if _, ok := enc[field]; !ok {
+			problems = append(problems, "missing "+field)
+		}
+	}
+	if _, ok := enc["Expiration"]; !ok {
+		problems = append(problems, "missing Expiration")
+	} else if s.Expiration.IsZero() {
+		problems = append(problems, "invalid Expiration")
+	}
+
+	if s.Region == "" {
+		s.Region = CurrentConfig().AccountRegion(SessionAccountID(s))
+		if s.Region == "" {
+			s.Region = DefaultRegion
+		}
+	}
+	if !regionPattern.MatchString(s.Region) {
+		problems = append(problems, fmt.Sprintf("invalid Region %q", s.Region))
+	}
+
+	if s.Duration == 0 {
+		switch {
+		case s.IsRoot():
+			s.Duration = MaxRootSessionSeconds
+		case s.RoleArn == "MFA-Session":
+			s.Duration = defaultMFADuration
+		default:
+			s.Duration = defaultRoleDuration
+		}
+	}
+	if s.Duration < 900 || s.Duration > 129600 {
+		problems = append(problems, fmt.Sprintf("invalid Duration %d", s.Duration))
+	}
+	return problems
+}
+
+// StoreUpgrade is the result of UpgradeStore.
+type StoreUpgrade struct {
+	// Upgraded lists the profiles rewritten at StoreVersion (or that would be, on a dry run).
+	Upgraded []string
+	// Current counts the entries that were already at StoreVersion.
+	Current int
+	// Stale lists the sessions that can't be upgraded and need a new login.
+	Stale []StaleSession
+}
+
+// UpgradeStore rewrites every entry older than StoreVersion with its backfilled fields.
+// Sessions that can't be upgraded are reported in Stale and left as they are. With
+// dryRun nothing is written.
+func UpgradeStore(provider CryptoProvider, dryRun bool) (*StoreUpgrade, error) {
+	data, err := readStore()
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]string, 0, len(data))
+	for profile := range data {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+
+	result := &StoreUpgrade{}
+	err = processStore.Batch(func() error {
+		for _, profile := range profiles {
+			enc := data[profile]
+			if entryVersion(enc) >= StoreVersion {
+				result.Current++
+				continue
+			}
+			if err := verifyEntry(profile, enc, provider); err != nil {
+				return err
+			}
+			s, err := decryptSession(profile, enc, provider)
+			var stale *StaleSessionsError
+			if errors.As(err, &stale) {
+				result.Stale = append(result.Stale, stale.Sessions...)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decrypt session '%s': %w", profile, err)
+			}
+			if !dryRun {
+				if err := SaveCredentialsWith(profile, s, provider); err != nil {
+					return fmt.Errorf("failed to upgrade '%s': %w", profile, err)
+				}
+			}
+			result.Upgraded = append(result.Upgraded, profile)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// describeStoreVersion summarizes the entry versions for diagnostics.
+func describeStoreVersion(data map[string]map[string]string) string {
+	legacy := 0
+	for _, enc := range data {
+		if entryVersion(enc) < StoreVersion {
+			legacy++
+		}
+	}
+	if legacy == 0 {
+		return fmt.Sprintf("%d (per-field AES-256-GCM, HMAC per entry)", StoreVersion)
+	}
+	return fmt.Sprintf("%d, with %d of %d entries at an older version (run 'cloudctl upgrade-store')", StoreVersion, legacy, len(data))
+}
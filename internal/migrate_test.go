@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// saveLegacySession stores a session as a version 1 entry without the given fields.
+func saveLegacySession(t *testing.T, key string, s *AWSSession, drop ...string) {
+	t.Helper()
+	if err := SaveCredentials(s.Profile, s, key); err != nil {
+		t.Fatal(err)
+	}
+	data := readTestStore(t)
+	for _, field := range append(drop, versionField, macField) {
+		delete(data[s.Profile], field)
+	}
+	writeTestStore(t, data)
+	forgetTestSeal(t)
+}
+
+func TestUpgradeStore(t *testing.T) {
+	setupTestDir(t)
+	setTestConfig(t, func(c *Config) { c.Accounts = map[string]AccountConfig{"123456789012": {Region: "eu-west-1"}} })
+
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	provider := NewSecretProvider(key)
+	expiration := time.Now().Add(time.Hour).Truncate(time.Second)
+	saveLegacySession(t, key, &AWSSession{Profile: "mfa", AccessKey: "AKIA", SecretKey: "s", SessionToken: "t",
+		Expiration: expiration, RoleArn: "MFA-Session"}, "Duration", "Region")
+	saveLegacySession(t, key, &AWSSession{Profile: "dev", AccessKey: "AKIA", SecretKey: "s", SessionToken: "t",
+		Expiration: expiration, RoleArn: "arn:aws:iam::123456789012:role/dev"}, "Duration", "Region")
+	saveLegacySession(t, key, &AWSSession{Profile: "broken", AccessKey: "AKIA", SecretKey: "s",
+		Expiration: expiration, RoleArn: "arn:aws:iam::123456789012:role/broken"}, "SessionToken")
+
+	summary, err := DescribeStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(summary.SchemaVersion, "3 of 3 entries at an older version") {
+		t.Errorf("Unexpected schema version: %s", summary.SchemaVersion)
+	}
+
+	// Legacy entries load with backfilled defaults
+	s, err := LoadCredentials("dev", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Region != "eu-west-1" || s.Duration != defaultRoleDuration {
+		t.Errorf("Expected backfilled region and duration, got %s and %d", s.Region, s.Duration)
+	}
+	s, _ = LoadCredentials("mfa", key)
+	if s.Region != DefaultRegion || s.Duration != defaultMFADuration {
+		t.Errorf("Expected MFA defaults, got %s and %d", s.Region, s.Duration)
+	}
+
+	// Incomplete entries fail with the profile and what is missing
+	_, err = LoadCredentials("broken", key)
+	if !errors.Is(err, ErrNeedsRelogin) || !strings.Contains(err.Error(), "broken (missing SessionToken)") {
+		t.Errorf("Expected a re-login error for broken, got %v", err)
+	}
+	if _, err := ListAllSessions(key); !errors.Is(err, ErrNeedsRelogin) {
+		t.Errorf("Expected ListAllSessions to report stale sessions, got %v", err)
+	}
+
+	result, err := UpgradeStore(provider, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entryVersion(readTestStore(t)["dev"]) != 1 {
+		t.Error("Dry run must not rewrite entries")
+	}
+	if strings.Join(result.Upgraded, ",") != "dev,mfa" || len(result.Stale) != 1 || result.Stale[0].Profile != "broken" {
+		t.Errorf("Unexpected dry run result: %+v", result)
+	}
+
+	result, err = UpgradeStore(provider, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := readTestStore(t)
+	if entryVersion(data["dev"]) != StoreVersion || data["dev"][macField] == "" || entryVersion(data["broken"]) != 1 {
+		t.Errorf("Unexpected versions after upgrade: dev %d, broken %d", entryVersion(data["dev"]), entryVersion(data["broken"]))
+	}
+	s, err = LoadCredentials("dev", key)
+	if err != nil || s.Region != "eu-west-1" || !s.Expiration.Equal(expiration) {
+		t.Errorf("Unexpected upgraded session: %+v (%v)", s, err)
+	}
+
+	result, _ = UpgradeStore(provider, false)
+	if len(result.Upgraded) != 0 || result.Current != 2 {
+		t.Errorf("Expected nothing left to upgrade, got %+v", result)
+	}
+}
+
+func TestUpgradeSessionValidation(t *testing.T) {
+	enc := map[string]string{"AccessKey": "", "SecretKey": "", "SessionToken": "", "RoleArn": "", "Expiration": ""}
+	s := &AWSSession{Expiration: time.Now(), Region: "Tokyo", Duration: 60}
+	problems := upgradeSession(s, enc)
+	if strings.Join(problems, ", ") != `invalid Region "Tokyo", invalid Duration 60` {
+		t.Errorf("Unexpected problems: %v", problems)
+	}
+
+	s = &AWSSession{RoleArn: RootSessionArn("123456789012"), RootTask: "arn:aws:iam::aws:policy/root-task/IAMAuditRootUserCredentials"}
+	problems = upgradeSession(s, enc)
+	if s.Duration != MaxRootSessionSeconds || len(problems) != 1 || problems[0] != "invalid Expiration" {
+		t.Errorf("Unexpected root upgrade: %d, %v", s.Duration, problems)
+	}
+}
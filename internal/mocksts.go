@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const stsXMLNamespace = "https://sts.amazonaws.com/doc/2011-06-15/"
+
+// MockSTS answers STS query API requests (AssumeRole, GetSessionToken and
+// GetCallerIdentity) with the credentials of a stored session, so SDKs and tools
+// pointed at a custom STS endpoint run against a cloudctl-managed session. Requests
+// are not signature-checked and AWS is never contacted; whatever role or duration the
+// caller asks for, it gets the stored credentials.
+type MockSTS struct {
+	// Load returns the session to hand out. It is called on every request so a
+	// session refreshed in the meantime is picked up.
+	Load func() (*AWSSession, error)
+}
+
+type stsCredentials struct {
+	AccessKeyID     string `xml:"AccessKeyId"`
+	SecretAccessKey string `xml:"SecretAccessKey"`
+	SessionToken    string `xml:"SessionToken"`
+	Expiration      string `xml:"Expiration"`
+}
+
+type stsAssumedRoleUser struct {
+	AssumedRoleID string `xml:"AssumedRoleId"`
+	Arn           string `xml:"Arn"`
+}
+
+type stsResponseMetadata struct {
+	RequestID string `xml:"RequestId"`
+}
+
+type assumeRoleResponse struct {
+	XMLName         xml.Name            `xml:"AssumeRoleResponse"`
+	Xmlns           string              `xml:"xmlns,attr"`
+	Credentials     stsCredentials      `xml:"AssumeRoleResult>Credentials"`
+	AssumedRoleUser stsAssumedRoleUser  `xml:"AssumeRoleResult>AssumedRoleUser"`
+	Metadata        stsResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type getSessionTokenResponse struct {
+	XMLName     xml.Name            `xml:"GetSessionTokenResponse"`
+	Xmlns       string              `xml:"xmlns,attr"`
+	Credentials stsCredentials      `xml:"GetSessionTokenResult>Credentials"`
+	Metadata    stsResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type getCallerIdentityResponse struct {
+	XMLName  xml.Name            `xml:"GetCallerIdentityResponse"`
+	Xmlns    string              `xml:"xmlns,attr"`
+	Arn      string              `xml:"GetCallerIdentityResult>Arn"`
+	UserID   string              `xml:"GetCallerIdentityResult>UserId"`
+	Account  string              `xml:"GetCallerIdentityResult>Account"`
+	Metadata stsResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type stsErrorResponse struct {
+	XMLName   xml.Name `xml:"ErrorResponse"`
+	Xmlns     string   `xml:"xmlns,attr"`
+	Type      string   `xml:"Error>Type"`
+	Code      string   `xml:"Error>Code"`
+	Message   string   `xml:"Error>Message"`
+	RequestID string   `xml:"RequestId"`
+}
+
+func (m *MockSTS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := newRequestID()
+	if err := r.ParseForm(); err != nil {
+		writeSTSError(w, http.StatusBadRequest, requestID, "MalformedQueryString", err.Error())
+		return
+	}
+
+	action := r.Form.Get("Action")
+	switch action {
+	case "AssumeRole", "GetSessionToken", "GetCallerIdentity":
+	case "":
+		writeSTSError(w, http.StatusBadRequest, requestID, "MissingAction", "Action is required")
+		return
+	default:
+		writeSTSError(w, http.StatusBadRequest, requestID, "InvalidAction",
+			fmt.Sprintf("%s is not supported by cloudctl mock-sts", action))
+		return
+	}
+
+	s, err := m.Load()
+	if err != nil {
+		writeSTSError(w, http.StatusInternalServerError, requestID, "ServiceFailure", err.Error())
+		return
+	}
+	if time.Now().After(s.Expiration) {
+		writeSTSError(w, http.StatusBadRequest, requestID, "ExpiredToken",
+			fmt.Sprintf("the session for profile '%s' has expired; run cloudctl refresh --profile %s", s.Profile, s.Profile))
+		return
+	}
+	if s.SelfDestructed(time.Now()) {
+		writeSTSError(w, http.StatusBadRequest, requestID, "ExpiredToken",
+			fmt.Sprintf("the session for profile '%s' self-destructed at %s; log in again", s.Profile, FormatTime(s.SelfDestruct)))
+		return
+	}
+
+	creds := stsCredentials{
+		AccessKeyID:     s.AccessKey,
+		SecretAccessKey: s.SecretKey,
+		SessionToken:    s.SessionToken,
+		Expiration:      s.Expiration.UTC().Format(time.RFC3339),
+	}
+	meta := stsResponseMetadata{RequestID: requestID}
+	arn, userID, account := mockIdentity(s)
+
+	var resp any
+	switch action {
+	case "AssumeRole":
+		resp = assumeRoleResponse{Xmlns: stsXMLNamespace, Credentials: creds,
+			AssumedRoleUser: stsAssumedRoleUser{AssumedRoleID: userID, Arn: arn}, Metadata: meta}
+	case "GetSessionToken":
+		resp = getSessionTokenResponse{Xmlns: stsXMLNamespace, Credentials: creds, Metadata: meta}
+	case "GetCallerIdentity":
+		resp = getCallerIdentityResponse{Xmlns: stsXMLNamespace, Arn: arn, UserID: userID, Account: account, Metadata: meta}
+	}
+	writeSTSXML(w, http.StatusOK, resp)
+}
+
+// mockIdentity derives the caller ARN, user ID and account of a stored session without
+// calling AWS: the assumed-role ARN for role sessions, the IAM user for MFA sessions.
+func mockIdentity(s *AWSSession) (arn, userID, account string) {
+	source := s.RoleArn
+	if source == "" {
+		source = s.MfaArn
+	}
+	a, err := ParseARN(source)
+	if err != nil {
+		return "", s.AccessKey, ""
+	}
+	partition, account := a.Partition, a.AccountID
+
+	if a.IsRole() {
+		// Roles may have a path; the assumed-role ARN only keeps the name
+		name := a.Name()
+		session := s.SessionName
+		if session == "" {
+			session = s.Profile
+		}
+		return fmt.Sprintf("arn:%s:sts::%s:assumed-role/%s/%s", partition, account, name, session),
+			s.AccessKey + ":" + session, account
+	}
+	if a.IsMFADevice() {
+		return fmt.Sprintf("arn:%s:iam::%s:user/%s", partition, account, a.Name()), s.AccessKey, account
+	}
+	return "", s.AccessKey, account
+}
+
+func writeSTSError(w http.ResponseWriter, status int, requestID, code, message string) {
+	errType := "Sender"
+	if status >= 500 {
+		errType = "Receiver"
+	}
+	writeSTSXML(w, status, stsErrorResponse{Xmlns: stsXMLNamespace, Type: errType, Code: code, Message: message, RequestID: requestID})
+}
+
+func writeSTSXML(w http.ResponseWriter, status int, v any) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(b)
+}
+
+// newRequestID returns a random ID in the UUID format AWS uses for x-amzn-RequestId.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+)
+
+func TestMockSTS(t *testing.T) {
+	session := &AWSSession{
+		Profile:      "dev",
+		AccessKey:    "ASIAMOCK",
+		SecretKey:    "secret",
+		SessionToken: "token",
+		Expiration:   time.Now().Add(time.Hour).Truncate(time.Second),
+		RoleArn:      "arn:aws:iam::123456789012:role/team/Admin",
+		SessionName:  "alice",
+	}
+	server := httptest.NewServer(&MockSTS{Load: func() (*AWSSession, error) { return session, nil }})
+	defer server.Close()
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKIAANY", "any", ""),
+	})
+	ctx := context.Background()
+
+	out, err := client.AssumeRole(ctx, &sts.AssumeRoleInput{
+		RoleArn:         aws.String("arn:aws:iam::123456789012:role/Ignored"),
+		RoleSessionName: aws.String("test"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *out.Credentials.AccessKeyId != "ASIAMOCK" || *out.Credentials.SessionToken != "token" {
+		t.Errorf("Unexpected credentials: %+v", out.Credentials)
+	}
+	if !out.Credentials.Expiration.Equal(session.Expiration) {
+		t.Errorf("Expiration = %v, want %v", out.Credentials.Expiration, session.Expiration)
+	}
+	if got := *out.AssumedRoleUser.Arn; got != "arn:aws:sts::123456789012:assumed-role/Admin/alice" {
+		t.Errorf("AssumedRoleUser.Arn = %s", got)
+	}
+
+	id, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *id.Account != "123456789012" || *id.UserId != "ASIAMOCK:alice" {
+		t.Errorf("Unexpected identity: %s %s", *id.Account, *id.UserId)
+	}
+
+	// An expired session is reported the way STS reports expired tokens
+	session.Expiration = time.Now().Add(-time.Minute)
+	_, err = client.GetSessionToken(ctx, &sts.GetSessionTokenInput{})
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ExpiredToken" {
+		t.Errorf("Expected ExpiredToken, got %v", err)
+	}
+
+	// So is one past its self-destruct deadline
+	session.Expiration = time.Now().Add(time.Hour)
+	session.SelfDestruct = time.Now().Add(-time.Minute)
+	_, err = client.GetSessionToken(ctx, &sts.GetSessionTokenInput{})
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ExpiredToken" {
+		t.Errorf("Expected ExpiredToken for a self-destructed session, got %v", err)
+	}
+}
+
+func TestMockIdentityMFASession(t *testing.T) {
+	arn, _, account := mockIdentity(&AWSSession{AccessKey: "ASIA", MfaArn: "arn:aws:iam::111111111111:mfa/bob"})
+	if arn != "arn:aws:iam::111111111111:user/bob" || account != "111111111111" {
+		t.Errorf("mockIdentity = %s, %s", arn, account)
+	}
+}
+
+func TestResolveCallerIdentity(t *testing.T) {
+	stored := &AWSSession{AccessKey: "ASIAMFA", SecretKey: "secret", SessionToken: "token",
+		Expiration: time.Now().Add(time.Hour), RoleArn: "MFA-Session", MfaArn: "arn:aws:iam::111111111111:mfa/bob"}
+	server := httptest.NewServer(&MockSTS{Load: func() (*AWSSession, error) {
+		// MFA sessions are recognized by their device, as RoleArn holds a marker
+		s := *stored
+		s.RoleArn = ""
+		return &s, nil
+	}})
+	defer server.Close()
+
+	cfg := aws.Config{Region: "us-east-1", BaseEndpoint: aws.String(server.URL)}
+	session := *stored
+	if err := ResolveCallerIdentity(context.Background(), cfg, &session); err != nil {
+		t.Fatal(err)
+	}
+	if session.AccountID != "111111111111" || session.PrincipalArn != "arn:aws:iam::111111111111:user/bob" || session.UserID != "ASIAMFA" {
+		t.Errorf("Unexpected identity: %q %q %q", session.AccountID, session.PrincipalArn, session.UserID)
+	}
+	if got := SessionAccountID(&session); got != "111111111111" {
+		t.Errorf("SessionAccountID = %q, want the resolved account", got)
+	}
+}
@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// notesPath holds free-form notes such as account IDs, SSO start URLs or VPN
+// requirements. Note names and timestamps are plain text; the text is encrypted like
+// the credential store.
+var notesPath = filepath.Join(storeDir, "notes.json")
+
+// Note is one stored note.
+type Note struct {
+	Name    string
+	Text    string
+	Updated time.Time
+}
+
+// storedNote is the on-disk form of a note.
+type storedNote struct {
+	Text    string    `json:"text"`
+	Updated time.Time `json:"updated"`
+}
+
+func loadNoteFile() (map[string]storedNote, error) {
+	notes := make(map[string]storedNote)
+	b, err := os.ReadFile(notesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return notes, nil
+		}
+		return nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+	if err := json.Unmarshal(b, &notes); err != nil {
+		return nil, fmt.Errorf("failed to parse notes: %w", err)
+	}
+	return notes, nil
+}
+
+func saveNoteFile(notes map[string]storedNote) error {
+	if err := os.MkdirAll(filepath.Dir(notesPath), 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	b, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal notes: %w", err)
+	}
+	return os.WriteFile(notesPath, b, 0600)
+}
+
+// SaveNote encrypts and stores a note, replacing one with the same name.
+func SaveNote(name, text, key string) error {
+	provider, err := StoreProvider(key)
+	if err != nil {
+		return err
+	}
+	return SaveNoteWith(&Note{Name: name, Text: text, Updated: time.Now()}, provider)
+}
+
+// SaveNoteWith stores a note encrypted with an explicit provider.
+func SaveNoteWith(note *Note, provider CryptoProvider) error {
+	notes, err := loadNoteFile()
+	if err != nil {
+		return err
+	}
+	plain := []byte(note.Text)
+	enc, err := provider.Encrypt(plain)
+	Wipe(plain)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt note '%s': %w", note.Name, err)
+	}
+	notes[note.Name] = storedNote{Text: base64.StdEncoding.EncodeToString(enc), Updated: note.Updated.UTC().Truncate(time.Second)}
+	return saveNoteFile(notes)
+}
+
+// LoadNote decrypts the note called name.
+func LoadNote(name, key string) (*Note, error) {
+	provider, err := StoreProvider(key)
+	if err != nil {
+		return nil, err
+	}
+	return LoadNoteWith(name, provider)
+}
+
+// LoadNoteWith decrypts a note with an explicit provider.
+func LoadNoteWith(name string, provider CryptoProvider) (*Note, error) {
+	notes, err := loadNoteFile()
+	if err != nil {
+		return nil, err
+	}
+	stored, ok := notes[name]
+	if !ok {
+		return nil, fmt.Errorf("note '%s' not found", name)
+	}
+	enc, err := base64.StdEncoding.DecodeString(stored.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode note '%s': %w", name, err)
+	}
+	text, err := provider.Decrypt(enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt note '%s' (wrong secret?): %w", name, err)
+	}
+	defer Wipe(text)
+	return &Note{Name: name, Text: string(text), Updated: stored.Updated}, nil
+}
+
+// ListNotes returns the stored notes without their text, sorted by name. It does not
+// need the secret.
+func ListNotes() ([]Note, error) {
+	notes, err := loadNoteFile()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Note, 0, len(notes))
+	for name, stored := range notes {
+		list = append(list, Note{Name: name, Updated: stored.Updated})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// NoteExists reports whether a note called name is stored.
+func NoteExists(name string) bool {
+	notes, err := loadNoteFile()
+	if err != nil {
+		return false
+	}
+	_, ok := notes[name]
+	return ok
+}
+
+// RemoveNote deletes a note.
+func RemoveNote(name string) error {
+	notes, err := loadNoteFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := notes[name]; !ok {
+		return fmt.Errorf("note '%s' not found", name)
+	}
+	delete(notes, name)
+	return saveNoteFile(notes)
+}
+
+// loadAllNotesWith decrypts every note, for re-encrypting them with another provider.
+func loadAllNotesWith(provider CryptoProvider) ([]*Note, error) {
+	list, err := ListNotes()
+	if err != nil {
+		return nil, err
+	}
+	notes := make([]*Note, 0, len(list))
+	for _, n := range list {
+		note, err := LoadNoteWith(n.Name, provider)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotesRoundTrip(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	text := "Account: 123456789012\nSSO: https://example.awsapps.com/start\nVPN required"
+
+	if err := SaveNote("prod", text, key); err != nil {
+		t.Fatalf("SaveNote failed: %v", err)
+	}
+	if err := SaveNote("dev", "Account: 210987654321", key); err != nil {
+		t.Fatalf("SaveNote failed: %v", err)
+	}
+
+	b, _ := os.ReadFile(notesPath)
+	if strings.Contains(string(b), "123456789012") {
+		t.Error("note text is stored in plain text")
+	}
+
+	note, err := LoadNote("prod", key)
+	if err != nil {
+		t.Fatalf("LoadNote failed: %v", err)
+	}
+	if note.Text != text {
+		t.Errorf("Text = %q, want %q", note.Text, text)
+	}
+	if time.Since(note.Updated) > time.Minute {
+		t.Errorf("Updated = %v, want about now", note.Updated)
+	}
+
+	if _, err := LoadNote("prod", "FEDCBA0987654321FEDCBA0987654321"); err == nil {
+		t.Error("expected an error with the wrong secret")
+	}
+
+	list, err := ListNotes()
+	if err != nil || len(list) != 2 || list[0].Name != "dev" || list[1].Name != "prod" || list[0].Text != "" {
+		t.Fatalf("ListNotes = %+v, %v", list, err)
+	}
+
+	if err := RemoveNote("dev"); err != nil {
+		t.Fatalf("RemoveNote failed: %v", err)
+	}
+	if NoteExists("dev") || !NoteExists("prod") {
+		t.Error("RemoveNote removed the wrong note")
+	}
+	if err := RemoveNote("dev"); err == nil {
+		t.Error("expected an error removing a missing note")
+	}
+}
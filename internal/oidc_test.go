@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testIssuer is an OIDC issuer serving a discovery document and the JWKS of its keys.
+type testIssuer struct {
+	server *httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+	// jwksFetches counts the requests for the keys.
+	jwksFetches int
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+	b64 := base64.RawURLEncoding.EncodeToString
+	iss.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"issuer": iss.server.URL, "jwks_uri": iss.server.URL + "/keys"})
+		case "/keys":
+			iss.jwksFetches++
+			json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+				{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+				{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+				{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(iss.server.Close)
+	return iss
+}
+
+// token signs claims with the issuer's RSA key (alg RS256) or EC key (alg ES256).
+func (iss *testIssuer) token(t *testing.T, alg, kid string, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch alg {
+	case "RS256":
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, iss.rsaKey, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, iss.ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (iss *testIssuer) claims(overrides map[string]any) map[string]any {
+	c := map[string]any{
+		"iss":   iss.server.URL,
+		"aud":   "cloudctl",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"email": "alice@example.com",
+	}
+	for k, v := range overrides {
+		c[k] = v
+	}
+	return c
+}
+
+func TestOIDCVerifierAcceptsValidTokens(t *testing.T) {
+	iss := newTestIssuer(t)
+	v, err := NewOIDCVerifier(context.Background(), iss.server.URL+"/", "cloudctl")
+	if err != nil {
+		t.Fatalf("NewOIDCVerifier failed: %v", err)
+	}
+
+	for _, alg := range []struct{ alg, kid string }{{"RS256", "rsa1"}, {"ES256", "ec1"}} {
+		claims, err := v.Verify(context.Background(), iss.token(t, alg.alg, alg.kid, iss.claims(nil)))
+		if err != nil {
+			t.Errorf("%s: expected the token to verify, got %v", alg.alg, err)
+			continue
+		}
+		if claims["email"] != "alice@example.com" {
+			t.Errorf("%s: unexpected claims %v", alg.alg, claims)
+		}
+	}
+
+	// aud may be a list
+	token := iss.token(t, "RS256", "rsa1", iss.claims(map[string]any{"aud": []string{"other", "cloudctl"}}))
+	if _, err := v.Verify(context.Background(), token); err != nil {
+		t.Errorf("Expected a token with a list audience to verify, got %v", err)
+	}
+}
+
+func TestOIDCVerifierRejectsInvalidTokens(t *testing.T) {
+	iss := newTestIssuer(t)
+	v, err := NewOIDCVerifier(context.Background(), iss.server.URL, "cloudctl")
+	if err != nil {
+		t.Fatalf("NewOIDCVerifier failed: %v", err)
+	}
+	valid := iss.token(t, "RS256", "rsa1", iss.claims(nil))
+	parts := strings.Split(valid, ".")
+
+	tampered, _ := json.Marshal(iss.claims(map[string]any{"email": "mallory@example.com"}))
+	none, _ := json.Marshal(map[string]string{"alg": "none", "kid": "rsa1"})
+
+	for name, token := range map[string]string{
+		"expired":        iss.token(t, "RS256", "rsa1", iss.claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"not yet valid":  iss.token(t, "RS256", "rsa1", iss.claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})),
+		"no expiry":      iss.token(t, "RS256", "rsa1", iss.claims(map[string]any{"exp": nil})),
+		"wrong audience": iss.token(t, "RS256", "rsa1", iss.claims(map[string]any{"aud": "other"})),
+		"wrong issuer":   iss.token(t, "RS256", "rsa1", iss.claims(map[string]any{"iss": "https://evil.example.com"})),
+		"unknown key":    iss.token(t, "RS256", "rsa2", iss.claims(nil)),
+		"wrong key type": iss.token(t, "ES256", "rsa1", iss.claims(nil)),
+		"tampered":       parts[0] + "." + base64.RawURLEncoding.EncodeToString(tampered) + "." + parts[2],
+		"alg none":       base64.RawURLEncoding.EncodeToString(none) + "." + parts[1] + ".",
+		"malformed":      "not-a-token",
+	} {
+		if _, err := v.Verify(context.Background(), token); err == nil {
+			t.Errorf("%s: expected the token to be rejected", name)
+		}
+	}
+}
+
+func TestOIDCVerifierRefetchesKeysForUnknownKeyIDs(t *testing.T) {
+	iss := newTestIssuer(t)
+	v, err := NewOIDCVerifier(context.Background(), iss.server.URL, "cloudctl")
+	if err != nil {
+		t.Fatalf("NewOIDCVerifier failed: %v", err)
+	}
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	token := iss.token(t, "RS256", "rotated", iss.claims(nil))
+	v.Verify(context.Background(), token)
+	v.Verify(context.Background(), token)
+	if iss.jwksFetches != 1 {
+		t.Errorf("Expected no refetch within a minute of the last one, got %d fetches", iss.jwksFetches)
+	}
+
+	now = now.Add(2 * time.Minute)
+	v.Verify(context.Background(), token)
+	if iss.jwksFetches != 2 {
+		t.Errorf("Expected a refetch for an unknown key, got %d fetches", iss.jwksFetches)
+	}
+}
+
+func TestNewOIDCVerifierChecksTheIssuer(t *testing.T) {
+	iss := newTestIssuer(t)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": iss.server.URL, "jwks_uri": iss.server.URL + "/keys"})
+	}))
+	defer mirror.Close()
+
+	if _, err := NewOIDCVerifier(context.Background(), mirror.URL, "cloudctl"); err == nil {
+		t.Error("Expected a discovery document for another issuer to be rejected")
+	}
+}
@@ -6,3 +6,9 @@ import "runtime"
 func IsMacOS() bool {
 	return runtime.GOOS == "darwin"
 }
+
+// HasKeychain reports whether a system secret store is available: the macOS Keychain,
+// or the Windows Credential Manager from inside WSL.
+func HasKeychain() bool {
+	return IsMacOS() || IsWSL()
+}
@@ -0,0 +1,259 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// Output formats accepted by --format
+const (
+	FormatAuto  = "auto"
+	FormatPlain = "plain"
+	FormatColor = "color"
+	FormatJSON  = "json"
+)
+
+// Quiet levels set by repeating --quiet
+const (
+	QuietNone = iota
+	// QuietInfo drops info, detail and tip lines.
+	QuietInfo
+	// QuietAll also drops success lines and warnings, leaving errors and results.
+	QuietAll
+)
+
+// Message levels, as written by the JSON printer
+const (
+	LevelOutput  = "output"
+	LevelSuccess = "success"
+	LevelInfo    = "info"
+	LevelDetail  = "detail"
+	LevelTip     = "tip"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// Printer writes command output. Commands print through it rather than fmt, so the
+// same code can write plain, colored or JSON output, be quieted with --quiet, and be
+// captured in tests. Messages take a format and arguments like fmt.Printf; a leading
+// "\n" starts a new paragraph.
+type Printer interface {
+	// Print is a line of the command's output proper, such as a table row. It is
+	// printed at every quiet level.
+	Print(format string, args ...any)
+	// Success reports that the command did what was asked.
+	Success(format string, args ...any)
+	// Info is a plain line, such as progress.
+	Info(format string, args ...any)
+	// Detail is an indented line belonging to the message before it.
+	Detail(format string, args ...any)
+	// Tip suggests what to do next.
+	Tip(format string, args ...any)
+	// Warn reports a problem that didn't stop the command.
+	Warn(format string, args ...any)
+	// Error reports why the command failed.
+	Error(format string, args ...any)
+	// Result is the command's machine-readable result. Only the JSON printer writes
+	// it; text output says the same in messages.
+	Result(v any)
+	// Format returns FormatPlain, FormatColor or FormatJSON.
+	Format() string
+}
+
+// NewPrinter returns a printer for format writing results and messages to out and
+// warnings and errors to errOut. FormatAuto is color when out is a terminal and
+// NO_COLOR isn't set, and plain otherwise.
+func NewPrinter(format string, quiet int, out, errOut io.Writer) (Printer, error) {
+	switch strings.ToLower(format) {
+	case "", FormatAuto:
+		if isTerminal(out) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" {
+			return &textPrinter{out: out, errOut: errOut, quiet: quiet, color: true}, nil
+		}
+		return &textPrinter{out: out, errOut: errOut, quiet: quiet}, nil
+	case FormatPlain:
+		return &textPrinter{out: out, errOut: errOut, quiet: quiet}, nil
+	case FormatColor:
+		return &textPrinter{out: out, errOut: errOut, quiet: quiet, color: true}, nil
+	case FormatJSON:
+		return &jsonPrinter{out: out, errOut: errOut, quiet: quiet}, nil
+	}
+	return nil, fmt.Errorf("unknown format '%s' (use auto, plain, color or json)", format)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// shown reports whether a message of level is printed at quiet.
+func shown(level string, quiet int) bool {
+	switch level {
+	case LevelInfo, LevelDetail, LevelTip:
+		return quiet < QuietInfo
+	case LevelSuccess, LevelWarning:
+		return quiet < QuietAll
+	}
+	return true
+}
+
+// splitParagraph separates the leading newlines of a message from its text.
+func splitParagraph(msg string) (string, string) {
+	text := strings.TrimLeft(msg, "\n")
+	return msg[:len(msg)-len(text)], text
+}
+
+// textPrinter writes the icon-prefixed lines cloudctl has always printed, colored when
+// color is set.
+type textPrinter struct {
+	out, errOut io.Writer
+	quiet       int
+	color       bool
+}
+
+var textStyles = map[string]struct{ icon, color string }{
+	LevelOutput:  {"", ""},
+	LevelSuccess: {IconSuccess, ColorActive},
+	LevelInfo:    {"", ""},
+	LevelDetail:  {"", ColorMuted},
+	LevelTip:     {IconTip, ColorMuted},
+	LevelWarning: {IconWarning, ColorExpiring},
+	LevelError:   {IconError, ColorExpired},
+}
+
+func (p *textPrinter) print(level, format string, args []any) {
+	if !shown(level, p.quiet) {
+		return
+	}
+	w := p.out
+	if level == LevelWarning || level == LevelError {
+		w = p.errOut
+	}
+	lead, text := splitParagraph(fmt.Sprintf(format, args...))
+	style := textStyles[level]
+	if level == LevelDetail {
+		text = "   " + text
+	}
+	if p.color && style.color != "" {
+		if c := ANSIColor(style.color); c != "" {
+			text = c + text + ANSIReset
+		}
+	}
+	if style.icon != "" {
+		text = Icon(style.icon) + " " + text
+	}
+	fmt.Fprintln(w, lead+text)
+}
+
+func (p *textPrinter) Print(format string, args ...any)   { p.print(LevelOutput, format, args) }
+func (p *textPrinter) Success(format string, args ...any) { p.print(LevelSuccess, format, args) }
+func (p *textPrinter) Info(format string, args ...any)    { p.print(LevelInfo, format, args) }
+func (p *textPrinter) Detail(format string, args ...any)  { p.print(LevelDetail, format, args) }
+func (p *textPrinter) Tip(format string, args ...any)     { p.print(LevelTip, format, args) }
+func (p *textPrinter) Warn(format string, args ...any)    { p.print(LevelWarning, format, args) }
+func (p *textPrinter) Error(format string, args ...any)   { p.print(LevelError, format, args) }
+func (p *textPrinter) Result(any)                         {}
+
+func (p *textPrinter) Format() string {
+	if p.color {
+		return FormatColor
+	}
+	return FormatPlain
+}
+
+// jsonPrinter keeps stdout for the result and writes messages to stderr as one JSON
+// object per line, so scripts can parse both.
+type jsonPrinter struct {
+	out, errOut io.Writer
+	quiet       int
+}
+
+// Message is a line of JSON printer output on stderr.
+type Message struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+func (p *jsonPrinter) print(level, format string, args []any) {
+	if !shown(level, p.quiet) {
+		return
+	}
+	_, text := splitParagraph(fmt.Sprintf(format, args...))
+	b, _ := json.Marshal(Message{Level: level, Message: text})
+	fmt.Fprintln(p.errOut, string(b))
+}
+
+func (p *jsonPrinter) Print(format string, args ...any)   { p.print(LevelOutput, format, args) }
+func (p *jsonPrinter) Success(format string, args ...any) { p.print(LevelSuccess, format, args) }
+func (p *jsonPrinter) Info(format string, args ...any)    { p.print(LevelInfo, format, args) }
+func (p *jsonPrinter) Detail(format string, args ...any)  { p.print(LevelDetail, format, args) }
+func (p *jsonPrinter) Tip(format string, args ...any)     { p.print(LevelTip, format, args) }
+func (p *jsonPrinter) Warn(format string, args ...any)    { p.print(LevelWarning, format, args) }
+func (p *jsonPrinter) Error(format string, args ...any)   { p.print(LevelError, format, args) }
+func (p *jsonPrinter) Format() string                     { return FormatJSON }
+
+func (p *jsonPrinter) Result(v any) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		p.Error("failed to encode result: %v", err)
+		return
+	}
+	fmt.Fprintln(p.out, string(b))
+}
+
+// CapturePrinter records output instead of writing it, for tests. It prints at any
+// quiet level and reports format as FormatJSON when JSON is set.
+type CapturePrinter struct {
+	JSON bool
+
+	mu       sync.Mutex
+	messages []Message
+	results  []any
+}
+
+func (p *CapturePrinter) print(level, format string, args []any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, text := splitParagraph(fmt.Sprintf(format, args...))
+	p.messages = append(p.messages, Message{Level: level, Message: text})
+}
+
+func (p *CapturePrinter) Print(format string, args ...any)   { p.print(LevelOutput, format, args) }
+func (p *CapturePrinter) Success(format string, args ...any) { p.print(LevelSuccess, format, args) }
+func (p *CapturePrinter) Info(format string, args ...any)    { p.print(LevelInfo, format, args) }
+func (p *CapturePrinter) Detail(format string, args ...any)  { p.print(LevelDetail, format, args) }
+func (p *CapturePrinter) Tip(format string, args ...any)     { p.print(LevelTip, format, args) }
+func (p *CapturePrinter) Warn(format string, args ...any)    { p.print(LevelWarning, format, args) }
+func (p *CapturePrinter) Error(format string, args ...any)   { p.print(LevelError, format, args) }
+
+func (p *CapturePrinter) Result(v any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results = append(p.results, v)
+}
+
+func (p *CapturePrinter) Format() string {
+	if p.JSON {
+		return FormatJSON
+	}
+	return FormatPlain
+}
+
+// Messages returns the messages printed so far, oldest first.
+func (p *CapturePrinter) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.messages...)
+}
+
+// Results returns the results printed so far, oldest first.
+func (p *CapturePrinter) Results() []any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]any(nil), p.results...)
+}
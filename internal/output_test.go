@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTextPrinter(t *testing.T) {
+	setTestConfig(t, func(c *Config) { c.Theme.Name = ThemeASCII })
+
+	var out, errOut bytes.Buffer
+	p, err := NewPrinter(FormatPlain, QuietNone, &out, &errOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Success("Stored %s", "prod")
+	p.Detail("Expires: %s", "1h")
+	p.Tip("\nNext: %s", "login")
+	p.Warn("remote state unreachable")
+	p.Error("failed: %v", "denied")
+	p.Result(map[string]string{"profile": "prod"})
+
+	want := "[ok] Stored prod\n   Expires: 1h\n\n[tip] Next: login\n"
+	if out.String() != want {
+		t.Errorf("Unexpected stdout:\n%q\nwant\n%q", out.String(), want)
+	}
+	if errOut.String() != "[warn] remote state unreachable\n[error] failed: denied\n" {
+		t.Errorf("Unexpected stderr: %q", errOut.String())
+	}
+
+	out.Reset()
+	p, _ = NewPrinter(FormatColor, QuietNone, &out, &errOut)
+	p.Success("Stored")
+	if !strings.Contains(out.String(), ANSIColor(ColorActive)) || !strings.HasSuffix(out.String(), ANSIReset+"\n") {
+		t.Errorf("Expected colored output, got %q", out.String())
+	}
+
+	if _, err := NewPrinter("yaml", QuietNone, &out, &errOut); err == nil {
+		t.Error("Expected an unknown format to fail")
+	}
+}
+
+func TestPrinterQuietLevels(t *testing.T) {
+	setTestConfig(t, nil)
+
+	tests := []struct {
+		quiet int
+		want  []string
+	}{
+		{QuietNone, []string{"row", "done", "info", "detail", "tip", "warn", "fail"}},
+		{QuietInfo, []string{"row", "done", "warn", "fail"}},
+		{QuietAll, []string{"row", "fail"}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		p, _ := NewPrinter(FormatPlain, tt.quiet, &out, &out)
+		p.Print("row")
+		p.Success("done")
+		p.Info("info")
+		p.Detail("detail")
+		p.Tip("tip")
+		p.Warn("warn")
+		p.Error("fail")
+
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			fields := strings.Fields(line)
+			got = append(got, fields[len(fields)-1])
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("quiet %d: got %v, want %v", tt.quiet, got, tt.want)
+		}
+	}
+}
+
+func TestJSONPrinter(t *testing.T) {
+	var out, errOut bytes.Buffer
+	p, _ := NewPrinter(FormatJSON, QuietInfo, &out, &errOut)
+	p.Info("hidden at -q")
+	p.Success("\nStored %s", "prod")
+	p.Result([]string{"prod"})
+	if p.Format() != FormatJSON {
+		t.Errorf("Unexpected format %s", p.Format())
+	}
+
+	var result []string
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil || len(result) != 1 || result[0] != "prod" {
+		t.Errorf("Expected only the result on stdout, got %q (%v)", out.String(), err)
+	}
+	var msg Message
+	if err := json.Unmarshal(errOut.Bytes(), &msg); err != nil || msg != (Message{Level: LevelSuccess, Message: "Stored prod"}) {
+		t.Errorf("Unexpected messages on stderr: %q (%v)", errOut.String(), err)
+	}
+}
+
+func TestCapturePrinter(t *testing.T) {
+	var p Printer = &CapturePrinter{JSON: true}
+	p.Warn("slow %s", "network")
+	p.Result(42)
+
+	c := p.(*CapturePrinter)
+	if msgs := c.Messages(); len(msgs) != 1 || msgs[0] != (Message{Level: LevelWarning, Message: "slow network"}) {
+		t.Errorf("Unexpected messages: %+v", msgs)
+	}
+	if results := c.Results(); len(results) != 1 || results[0] != 42 {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	if p.Format() != FormatJSON {
+		t.Errorf("Unexpected format %s", p.Format())
+	}
+}
@@ -0,0 +1,22 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// storeDir holds the config, credential store and state files: $CLOUDCTL_HOME, or
+// ~/.cloudctl by default.
+var storeDir = defaultStoreDir()
+
+func defaultStoreDir() string {
+	if dir := os.Getenv("CLOUDCTL_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.Getenv("HOME"), ".cloudctl")
+}
+
+// StoreDir returns the directory cloudctl keeps its files in.
+func StoreDir() string {
+	return storeDir
+}
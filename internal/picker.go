@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SessionRow is a stored session as the session pickers show it.
+type SessionRow struct {
+	Profile string
+	// Role is the alias of the session's role, else the role name; "MFA session" and
+	// "root: <task>" for the other kinds of session.
+	Role string
+	// Account is the account ID, followed by its env from the config when it has one.
+	Account  string
+	Expires  string
+	LastUsed string
+}
+
+// LastUsedByProfile returns when each profile was last used, going by the audit log:
+// logins, exports, console sign-ins and API calls, but not the daemon's refreshes.
+func LastUsedByProfile(events []AuditEvent) map[string]time.Time {
+	last := make(map[string]time.Time)
+	for _, e := range events {
+		if e.Profile == "" || e.Command == "daemon" {
+			continue
+		}
+		if e.Time.After(last[e.Profile]) {
+			last[e.Profile] = e.Time
+		}
+	}
+	return last
+}
+
+// SessionRows returns the picker rows of sessions, sorted by profile.
+func SessionRows(sessions []*AWSSession, lastUsed map[string]time.Time, now time.Time) []SessionRow {
+	aliases := make(map[string]string)
+	if roles, err := ListRoleAliases(); err == nil {
+		for _, name := range sortedKeys(roles) {
+			if _, ok := aliases[roles[name].ARN]; !ok {
+				aliases[roles[name].ARN] = name
+			}
+		}
+	}
+	cfg := CurrentConfig()
+
+	rows := make([]SessionRow, 0, len(sessions))
+	for _, s := range sessions {
+		row := SessionRow{Profile: s.Profile, Expires: FormatRelative(s.Expiration), LastUsed: "never"}
+		switch {
+		case s.RoleArn == "MFA-Session":
+			row.Role = "MFA session"
+		case s.IsRoot():
+			row.Role = "root: " + RootTaskName(s.RootTask)
+		case aliases[s.RoleArn] != "":
+			row.Role = aliases[s.RoleArn]
+		default:
+			row.Role = RoleName(s.RoleArn)
+		}
+		row.Account = policySourceAccount(s)
+		if env := cfg.Accounts[row.Account].Env; env != "" {
+			row.Account += " (" + env + ")"
+		}
+		if t, ok := lastUsed[s.Profile]; ok {
+			row.LastUsed = formatAgo(now.Sub(t))
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Profile < rows[j].Profile })
+	return rows
+}
+
+// formatAgo renders how long ago something happened, e.g. "5m ago" or "3d ago".
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
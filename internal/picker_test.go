@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionRows(t *testing.T) {
+	setupTestRoles(t, `{"prod-admin": "arn:aws:iam::111111111111:role/Admin"}`)
+	setTestConfig(t, func(c *Config) { c.Accounts = map[string]AccountConfig{"111111111111": {Env: "prod"}} })
+
+	now := time.Now()
+	sessions := []*AWSSession{
+		{Profile: "prod", RoleArn: "arn:aws:iam::111111111111:role/Admin", Expiration: now.Add(time.Hour)},
+		{Profile: "dev", RoleArn: "arn:aws:iam::222222222222:role/team/Developer", Expiration: now.Add(time.Hour)},
+		{Profile: "mfa", RoleArn: "MFA-Session", MfaArn: "arn:aws:iam::222222222222:mfa/alice", Expiration: now.Add(time.Hour)},
+	}
+	lastUsed := LastUsedByProfile([]AuditEvent{
+		{Time: now.Add(-3 * time.Hour), Event: AuditExport, Profile: "prod"},
+		{Time: now.Add(-5 * time.Minute), Event: AuditRefresh, Profile: "prod", Command: "daemon"},
+		{Time: now.Add(-72 * time.Hour), Event: AuditLogin, Profile: "dev"},
+	})
+
+	rows := SessionRows(sessions, lastUsed, now)
+	want := []SessionRow{
+		{Profile: "dev", Role: "Developer", Account: "222222222222", LastUsed: "3d ago"},
+		{Profile: "mfa", Role: "MFA session", Account: "222222222222", LastUsed: "never"},
+		{Profile: "prod", Role: "prod-admin", Account: "111111111111 (prod)", LastUsed: "3h ago"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("Expected %d rows, got %+v", len(want), rows)
+	}
+	for i, w := range want {
+		got := rows[i]
+		got.Expires = ""
+		if got != w {
+			t.Errorf("Row %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+}
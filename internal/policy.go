@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var policyPath = filepath.Join(storeDir, "policy.json")
+
+// Policy rule effects
+const (
+	// PolicyDeny refuses every matching AssumeRole call.
+	PolicyDeny = "deny"
+	// PolicyRequireMFA refuses matching calls that aren't MFA-authenticated: made from
+	// an MFA session or with an MFA code.
+	PolicyRequireMFA = "require_mfa"
+)
+
+// Policy is the local policy file: guardrails checked before every AssumeRole call
+// cloudctl makes, on login, restore, silent refresh and in the broker.
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule applies its effect to the AssumeRole calls it matches. A call matches when
+// every condition the rule sets does, and a condition matches when any of its values
+// does; a rule without conditions matches every call. Roles and sources are glob
+// patterns (* and ?), envs are accounts.<id>.env values.
+type PolicyRule struct {
+	Name string `json:"name"`
+	// Effect is "deny" (default) or "require_mfa".
+	Effect string `json:"effect,omitempty"`
+	// Roles and Envs match the role being assumed.
+	Roles []string `json:"roles,omitempty"`
+	Envs  []string `json:"envs,omitempty"`
+	// Sources match the source profile name; SourceRoles and SourceEnvs match the role
+	// and account of a cloudctl session source, so AWS CLI profiles never match them.
+	Sources     []string `json:"sources,omitempty"`
+	SourceRoles []string `json:"source_roles,omitempty"`
+	SourceEnvs  []string `json:"source_envs,omitempty"`
+	// Message is shown with the denial, e.g. who to ask or what to do instead.
+	Message string `json:"message,omitempty"`
+}
+
+// AssumeRequest is an AssumeRole call as the policy sees it.
+type AssumeRequest struct {
+	RoleArn string
+	// Source is the source profile name.
+	Source string
+	// SourceSession is the cloudctl session Source names, nil for AWS CLI profiles and
+	// the built-in sources.
+	SourceSession *AWSSession
+	// MFA is set when an MFA code is sent with the call.
+	MFA bool
+}
+
+// mfaAuthenticated reports whether the call is MFA-authenticated.
+func (r AssumeRequest) mfaAuthenticated() bool {
+	return r.MFA || (r.SourceSession != nil && r.SourceSession.RoleArn == "MFA-Session")
+}
+
+// PolicyDenial is returned when a policy rule refuses an AssumeRole call.
+type PolicyDenial struct {
+	Rule    PolicyRule
+	Request AssumeRequest
+}
+
+func (e *PolicyDenial) Error() string {
+	var msg string
+	if e.Rule.Effect == PolicyRequireMFA {
+		msg = fmt.Sprintf("policy '%s' requires MFA to assume %s: use an MFA session as source or log in with --mfa", e.Rule.Name, e.Request.RoleArn)
+	} else {
+		msg = fmt.Sprintf("policy '%s' denies assuming %s", e.Rule.Name, e.Request.RoleArn)
+		if e.Request.Source != "" {
+			msg += fmt.Sprintf(" from '%s'", e.Request.Source)
+		}
+	}
+	if e.Rule.Message != "" {
+		msg += " (" + e.Rule.Message + ")"
+	}
+	return msg
+}
+
+// LoadPolicy reads the policy file. A missing file is an empty policy.
+func LoadPolicy() (*Policy, error) {
+	b, err := os.ReadFile(policyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Policy{}, nil
+		}
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", policyPath, err)
+	}
+	if err := ValidatePolicy(&p); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", policyPath, err)
+	}
+	return &p, nil
+}
+
+// ValidatePolicy checks that rules are named, uniquely, and have a known effect.
+func ValidatePolicy(p *Policy) error {
+	names := map[string]bool{}
+	for i, r := range p.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rules[%d]: name is required", i)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("rules[%d]: name '%s' is used twice", i, r.Name)
+		}
+		names[r.Name] = true
+		switch r.Effect {
+		case "", PolicyDeny, PolicyRequireMFA:
+		default:
+			return fmt.Errorf("rule '%s': unknown effect '%s' (use deny or require_mfa)", r.Name, r.Effect)
+		}
+	}
+	return nil
+}
+
+// Evaluate returns a *PolicyDenial from the first rule that refuses the call, or nil.
+func (p *Policy) Evaluate(req AssumeRequest) error {
+	cfg := CurrentConfig()
+	for _, r := range p.Rules {
+		if !r.matches(cfg, req) {
+			continue
+		}
+		if r.Effect == PolicyRequireMFA && req.mfaAuthenticated() {
+			continue
+		}
+		return &PolicyDenial{Rule: r, Request: req}
+	}
+	return nil
+}
+
+func (r PolicyRule) matches(cfg *Config, req AssumeRequest) bool {
+	if len(r.Roles) > 0 && !matchAnyGlob(r.Roles, req.RoleArn) {
+		return false
+	}
+	if len(r.Envs) > 0 && !matchAnyEnv(r.Envs, cfg.Accounts[RoleAccountID(req.RoleArn)].Env) {
+		return false
+	}
+	if len(r.Sources) > 0 && !matchAnyGlob(r.Sources, req.Source) {
+		return false
+	}
+	if len(r.SourceRoles) > 0 {
+		if req.SourceSession == nil || req.SourceSession.RoleArn == "MFA-Session" || !matchAnyGlob(r.SourceRoles, req.SourceSession.RoleArn) {
+			return false
+		}
+	}
+	if len(r.SourceEnvs) > 0 {
+		if req.SourceSession == nil || !matchAnyEnv(r.SourceEnvs, cfg.Accounts[policySourceAccount(req.SourceSession)].Env) {
+			return false
+		}
+	}
+	return true
+}
+
+// policySourceAccount returns the account of a source session, taking an MFA session's
+// from its device when its identity wasn't resolved.
+func policySourceAccount(s *AWSSession) string {
+	if account := SessionAccountID(s); account != "" {
+		return account
+	}
+	if a, err := ParseARN(s.MfaArn); err == nil {
+		return a.AccountID
+	}
+	return ""
+}
+
+func matchAnyGlob(patterns []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, p := range patterns {
+		if matchGlob(p, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyEnv(envs []string, env string) bool {
+	if env == "" {
+		return false
+	}
+	for _, e := range envs {
+		if strings.EqualFold(e, env) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckAssumePolicy evaluates the policy file for an AssumeRole call. A policy file that
+// can't be read or is invalid refuses every call, so a typo never switches the
+// guardrails off.
+func CheckAssumePolicy(req AssumeRequest) error {
+	p, err := LoadPolicy()
+	if err != nil {
+		return fmt.Errorf("%w; fix it before assuming roles", err)
+	}
+	return p.Evaluate(req)
+}
+
+// loadSourceSession loads the cloudctl session a source names; nil when it isn't one or
+// can't be decrypted. Envelope providers need no secret, so an empty one only rules out
+// the secret provider.
+func loadSourceSession(source, secret string) *AWSSession {
+	if source == "" || IsBuiltinSource(source) {
+		return nil
+	}
+	if secret == "" && !CurrentConfig().Encryption.UsesEnvelope() {
+		return nil
+	}
+	s, err := LoadCredentials(source, secret)
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
+// PolicyPath returns the location of the policy file.
+func PolicyPath() string {
+	return policyPath
+}
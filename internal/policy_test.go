@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func setupTestPolicy(t *testing.T, content string) {
+	originalPath := policyPath
+	policyPath = filepath.Join(t.TempDir(), "policy.json")
+	t.Cleanup(func() { policyPath = originalPath })
+	if content != "" {
+		if err := os.WriteFile(policyPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCheckAssumePolicy(t *testing.T) {
+	setTestConfig(t, func(c *Config) {
+		c.Accounts = map[string]AccountConfig{
+			"111111111111": {Env: "prod"},
+			"222222222222": {Env: "dev"},
+		}
+	})
+	setupTestPolicy(t, `{"rules": [
+		{"name": "no-dev-to-prod", "envs": ["prod"], "source_envs": ["dev"], "message": "use your MFA session"},
+		{"name": "admin-needs-mfa", "effect": "require_mfa", "roles": ["arn:aws:iam::*:role/*Admin*"]},
+		{"name": "no-ci", "sources": ["ci-*"], "envs": ["PROD"]}
+	]}`)
+
+	devSession := &AWSSession{Profile: "dev", RoleArn: "arn:aws:iam::222222222222:role/Developer"}
+	mfaSession := &AWSSession{Profile: "mfa-session", RoleArn: "MFA-Session", MfaArn: "arn:aws:iam::222222222222:mfa/alice"}
+	prodReadOnly := "arn:aws:iam::111111111111:role/ReadOnly"
+	prodAdmin := "arn:aws:iam::111111111111:role/Admin"
+
+	tests := []struct {
+		name string
+		req  AssumeRequest
+		rule string
+	}{
+		{"dev to prod", AssumeRequest{RoleArn: prodReadOnly, Source: "dev", SourceSession: devSession}, "no-dev-to-prod"},
+		{"dev MFA session to prod", AssumeRequest{RoleArn: prodReadOnly, Source: "mfa-session", SourceSession: mfaSession}, "no-dev-to-prod"},
+		{"CLI profile to prod", AssumeRequest{RoleArn: prodReadOnly, Source: "default"}, ""},
+		{"dev to dev", AssumeRequest{RoleArn: "arn:aws:iam::222222222222:role/ReadOnly", Source: "dev", SourceSession: devSession}, ""},
+		{"admin without MFA", AssumeRequest{RoleArn: "arn:aws:iam::333333333333:role/Admin", Source: "default"}, "admin-needs-mfa"},
+		{"admin with MFA code", AssumeRequest{RoleArn: "arn:aws:iam::333333333333:role/Admin", Source: "default", MFA: true}, ""},
+		{"admin from MFA session", AssumeRequest{RoleArn: "arn:aws:iam::333333333333:role/Admin", Source: "mfa", SourceSession: &AWSSession{RoleArn: "MFA-Session"}}, ""},
+		{"prod admin from CI", AssumeRequest{RoleArn: prodAdmin, Source: "ci-runner", MFA: true}, "no-ci"},
+	}
+	for _, tt := range tests {
+		err := CheckAssumePolicy(tt.req)
+		var denial *PolicyDenial
+		switch {
+		case tt.rule == "" && err != nil:
+			t.Errorf("%s: expected the call to be allowed, got %v", tt.name, err)
+		case tt.rule != "" && !errors.As(err, &denial):
+			t.Errorf("%s: expected a denial by %s, got %v", tt.name, tt.rule, err)
+		case tt.rule != "" && denial.Rule.Name != tt.rule:
+			t.Errorf("%s: expected a denial by %s, got one by %s", tt.name, tt.rule, denial.Rule.Name)
+		}
+	}
+
+	err := CheckAssumePolicy(tests[0].req)
+	if !strings.Contains(err.Error(), "no-dev-to-prod") || !strings.Contains(err.Error(), "use your MFA session") {
+		t.Errorf("Expected the rule and its message in the denial, got %q", err)
+	}
+}
+
+func TestCheckAssumePolicyWithoutOrWithBrokenFile(t *testing.T) {
+	req := AssumeRequest{RoleArn: "arn:aws:iam::111111111111:role/Admin", Source: "default"}
+
+	setupTestPolicy(t, "")
+	if err := CheckAssumePolicy(req); err != nil {
+		t.Errorf("Expected no policy file to allow everything, got %v", err)
+	}
+
+	for name, content := range map[string]string{
+		"syntax":         `{"rules": [`,
+		"unnamed rule":   `{"rules": [{"roles": ["*"]}]}`,
+		"unknown effect": `{"rules": [{"name": "a", "effect": "allow"}]}`,
+		"duplicate name": `{"rules": [{"name": "a", "roles": ["x"]}, {"name": "a", "roles": ["y"]}]}`,
+	} {
+		setupTestPolicy(t, content)
+		if err := CheckAssumePolicy(req); err == nil {
+			t.Errorf("%s: expected a broken policy file to refuse the call", name)
+		}
+	}
+}
+
+func TestLoginRoleChecksPolicy(t *testing.T) {
+	setupTestPolicy(t, `{"rules": [{"name": "no-admin", "roles": ["*:role/Admin"]}]}`)
+	mock := &MockSTSClient{}
+	t.Cleanup(UseSTSClient(mock))
+
+	_, err := LoginRole(context.Background(), aws.Config{}, RoleLoginOptions{
+		Profile: "admin", RoleArn: "arn:aws:iam::111111111111:role/Admin", Source: "default",
+	})
+	var denial *PolicyDenial
+	if !errors.As(err, &denial) {
+		t.Fatalf("Expected a policy denial, got %v", err)
+	}
+	if mock.CallCount("AssumeRole") != 0 {
+		t.Error("Expected a denied login not to call STS")
+	}
+}
+
+func TestCheckAssumePolicyWithEnvelopeProvider(t *testing.T) {
+	setupTestKeyring(t)
+	setTestConfig(t, func(c *Config) {
+		c.Encryption = EncryptionConfig{Provider: ProviderKMS, KMSKeyID: "alias/cloudctl"}
+		c.Accounts = map[string]AccountConfig{
+			"111111111111": {Env: "prod"},
+			"222222222222": {Env: "dev"},
+		}
+	})
+	envelopeMu.Lock()
+	originalProvider := envelopeCached
+	envelopeCached = &envelopeProvider{name: ProviderKMS, wrapper: &xorWrapper{}}
+	envelopeMu.Unlock()
+	t.Cleanup(func() { envelopeCached = originalProvider })
+	setupTestPolicy(t, `{"rules": [
+		{"name": "no-dev-to-prod", "envs": ["prod"], "source_envs": ["dev"]},
+		{"name": "admin-needs-mfa", "effect": "require_mfa", "roles": ["arn:aws:iam::*:role/*Admin*"]}
+	]}`)
+
+	dev := testSession("dev")
+	dev.RoleArn = "arn:aws:iam::222222222222:role/Developer"
+	mfa := testSession("mfa")
+	mfa.RoleArn = "MFA-Session"
+	for _, s := range []*AWSSession{dev, mfa} {
+		if err := SaveCredentials(s.Profile, s, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// GetSecret returns no secret for envelope providers; the sources must still load
+	req := AssumeRequest{RoleArn: "arn:aws:iam::111111111111:role/ReadOnly", Source: "dev", SourceSession: loadSourceSession("dev", "")}
+	var denial *PolicyDenial
+	if err := CheckAssumePolicy(req); !errors.As(err, &denial) || denial.Rule.Name != "no-dev-to-prod" {
+		t.Errorf("Expected source_envs to match a dev session, got %v", err)
+	}
+
+	req = AssumeRequest{RoleArn: "arn:aws:iam::333333333333:role/Admin", Source: "mfa", SourceSession: loadSourceSession("mfa", "")}
+	if err := CheckAssumePolicy(req); err != nil {
+		t.Errorf("Expected an MFA session source to satisfy require_mfa, got %v", err)
+	}
+
+	source, err := LoadLoginSource(context.Background(), "mfa", "", "eu-west-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !source.MFA || source.Session == nil {
+		t.Errorf("Expected the MFA session source to be detected, got MFA=%v session=%v", source.MFA, source.Session != nil)
+	}
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// MaxPresignExpiry is the longest validity SigV4 allows for a presigned URL.
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// s3RegionEndpoint answers HEAD requests for any bucket with its region, even without
+// credentials or access to the bucket.
+var s3RegionEndpoint = "https://s3.amazonaws.com"
+
+// S3Object is a bucket and key parsed from an s3:// URI.
+type S3Object struct {
+	Bucket string
+	Key    string
+}
+
+// ParseS3URI parses s3://bucket/key. The key may contain slashes but must not be empty.
+func ParseS3URI(uri string) (S3Object, error) {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	if !ok {
+		return S3Object{}, fmt.Errorf("invalid S3 URI '%s': must start with s3://", uri)
+	}
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" || key == "" {
+		return S3Object{}, fmt.Errorf("invalid S3 URI '%s': expected s3://bucket/key", uri)
+	}
+	return S3Object{Bucket: bucket, Key: key}, nil
+}
+
+// PresignS3 returns a URL that downloads obj (or uploads to it, with put) until expires
+// has passed. Signing happens locally; the URL stops working early if the signing
+// credentials expire first.
+func PresignS3(ctx context.Context, cfg aws.Config, obj S3Object, expires time.Duration, put bool) (string, error) {
+	if expires <= 0 || expires > MaxPresignExpiry {
+		return "", fmt.Errorf("expiry must be between 1s and %v", MaxPresignExpiry)
+	}
+	client := s3.NewPresignClient(s3.NewFromConfig(cfg))
+	opt := s3.WithPresignExpires(expires)
+
+	var req *v4.PresignedHTTPRequest
+	var err error
+	if put {
+		req, err = client.PresignPutObject(ctx, &s3.PutObjectInput{Bucket: aws.String(obj.Bucket), Key: aws.String(obj.Key)}, opt)
+	} else {
+		req, err = client.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(obj.Bucket), Key: aws.String(obj.Key)}, opt)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to presign s3://%s/%s: %w", obj.Bucket, obj.Key, err)
+	}
+	return req.URL, nil
+}
+
+// BucketRegion looks up the region of a bucket from the x-amz-bucket-region header S3
+// returns to an anonymous HEAD request. A URL signed for the wrong region is rejected.
+func BucketRegion(ctx context.Context, bucket string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s3RegionEndpoint+"/"+bucket, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up region of bucket '%s': %w", bucket, err)
+	}
+	resp.Body.Close()
+	region := resp.Header.Get("X-Amz-Bucket-Region")
+	if region == "" {
+		if resp.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("bucket '%s' does not exist", bucket)
+		}
+		return "", fmt.Errorf("failed to look up region of bucket '%s': %s", bucket, resp.Status)
+	}
+	return region, nil
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestParseS3URI(t *testing.T) {
+	obj, err := ParseS3URI("s3://my-bucket/reports/2025/q1.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Bucket != "my-bucket" || obj.Key != "reports/2025/q1.csv" {
+		t.Errorf("Unexpected object: %+v", obj)
+	}
+	for _, uri := range []string{"my-bucket/key", "s3://my-bucket", "s3://my-bucket/", "s3:///key"} {
+		if _, err := ParseS3URI(uri); err == nil {
+			t.Errorf("Expected error for %q", uri)
+		}
+	}
+}
+
+func TestPresignS3(t *testing.T) {
+	cfg := aws.Config{
+		Region:      "eu-west-1",
+		Credentials: credentials.NewStaticCredentialsProvider("ASIAEXAMPLE", "secret", "token"),
+	}
+	obj := S3Object{Bucket: "my-bucket", Key: "dir/file name.txt"}
+
+	raw, err := PresignS3(context.Background(), cfg, obj, 15*time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Host != "my-bucket.s3.eu-west-1.amazonaws.com" || u.Path != "/dir/file name.txt" {
+		t.Errorf("Unexpected URL: %s", raw)
+	}
+	if q.Get("X-Amz-Expires") != "900" || q.Get("X-Amz-Security-Token") != "token" {
+		t.Errorf("Expected a 900s URL with the session token, got %s", raw)
+	}
+
+	if _, err := PresignS3(context.Background(), cfg, obj, 8*24*time.Hour, false); err == nil {
+		t.Error("Expected error for an expiry over 7 days")
+	}
+}
+
+func TestBucketRegion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// S3 answers 403 for buckets you can't access, but still names the region
+		w.Header().Set("X-Amz-Bucket-Region", "ap-southeast-1")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	old := s3RegionEndpoint
+	s3RegionEndpoint = server.URL
+	defer func() { s3RegionEndpoint = old }()
+
+	region, err := BucketRegion(context.Background(), "private")
+	if err != nil || region != "ap-southeast-1" {
+		t.Errorf("BucketRegion = %q, %v", region, err)
+	}
+	if _, err := BucketRegion(context.Background(), "missing"); err == nil {
+		t.Error("Expected error for a missing bucket")
+	}
+}
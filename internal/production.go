@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// productionEnvs are the accounts.<id>.env values that mark an account as production.
+var productionEnvs = map[string]bool{"prod": true, "production": true}
+
+// IsProduction reports whether a session belongs to production: its account is tagged
+// env "prod" in the config, or its profile name, role ARN or account ID matches one of
+// security.production_patterns.
+func (c *Config) IsProduction(s *AWSSession) bool {
+	account := SessionAccountID(s)
+	if productionEnvs[strings.ToLower(c.Accounts[account].Env)] {
+		return true
+	}
+	for _, pattern := range c.Security.ProductionPatterns {
+		for _, value := range []string{s.Profile, s.RoleArn, account} {
+			if value != "" && matchGlob(pattern, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGlob matches value against a pattern where * matches any run of characters,
+// including the slashes of role paths, and ? matches one character.
+func matchGlob(pattern, value string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	matched, _ := regexp.MatchString("^(?i:"+expr+")$", value)
+	return matched
+}
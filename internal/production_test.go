@@ -0,0 +1,29 @@
+package internal
+
+import "testing"
+
+func TestIsProduction(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Accounts = map[string]AccountConfig{
+		"111111111111": {Env: "Prod"},
+		"222222222222": {Env: "staging"},
+	}
+	cfg.Security.ProductionPatterns = []string{"live-*", "*:role/ops/Breakglass*", "333333333333"}
+
+	tests := []struct {
+		session AWSSession
+		want    bool
+	}{
+		{AWSSession{Profile: "a", RoleArn: "arn:aws:iam::111111111111:role/ReadOnly"}, true},
+		{AWSSession{Profile: "b", RoleArn: "arn:aws:iam::222222222222:role/Admin"}, false},
+		{AWSSession{Profile: "live-admin", RoleArn: "arn:aws:iam::222222222222:role/Admin"}, true},
+		{AWSSession{Profile: "c", RoleArn: "arn:aws:iam::444444444444:role/ops/BreakglassAdmin"}, true},
+		{AWSSession{Profile: "d", RoleArn: "arn:aws:iam::333333333333:role/Dev"}, true},
+		{AWSSession{Profile: "alive", RoleArn: "MFA-Session"}, false},
+	}
+	for _, tt := range tests {
+		if got := cfg.IsProduction(&tt.session); got != tt.want {
+			t.Errorf("IsProduction(%s, %s) = %v, want %v", tt.session.Profile, tt.session.RoleArn, got, tt.want)
+		}
+	}
+}
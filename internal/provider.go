@@ -0,0 +1,336 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Encryption providers accepted by encryption.provider
+const (
+	// ProviderSecret encrypts with a key derived from the cloudctl secret (Keychain or
+	// CLOUDCTL_SECRET). It is the default and the only format older versions can read.
+	ProviderSecret = "secret"
+	// ProviderAge wraps a store data key for age recipients.
+	ProviderAge = "age"
+	// ProviderKMS wraps a store data key with an AWS KMS key (envelope encryption).
+	ProviderKMS = "kms"
+	// ProviderTPM seals a store data key to this machine's TPM (Linux and Windows).
+	ProviderTPM = "tpm"
+)
+
+// CryptoProvider encrypts and decrypts individual store fields.
+type CryptoProvider interface {
+	Name() string
+	Encrypt(plain []byte) ([]byte, error)
+	Decrypt(data []byte) ([]byte, error)
+	// MAC authenticates data with the same key material, for store integrity checks.
+	MAC(data []byte) ([]byte, error)
+}
+
+// secretProvider is the original scheme: AES-256-GCM keyed by sha256(secret).
+type secretProvider struct {
+	key *SecureBuffer
+}
+
+// NewSecretProvider returns the provider for a cloudctl secret.
+func NewSecretProvider(secret string) CryptoProvider {
+	return &secretProvider{key: SecureBufferFromString(secret)}
+}
+
+func (p *secretProvider) Name() string { return ProviderSecret }
+
+func (p *secretProvider) Encrypt(plain []byte) ([]byte, error) {
+	return Encrypt(plain, p.key.Bytes())
+}
+
+func (p *secretProvider) Decrypt(data []byte) ([]byte, error) {
+	return Decrypt(data, p.key.Bytes())
+}
+
+func (p *secretProvider) MAC(data []byte) ([]byte, error) {
+	return MAC(data, p.key.Bytes()), nil
+}
+
+// keyWrapper protects a store data key with an external key.
+type keyWrapper interface {
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(wrapped []byte) ([]byte, error)
+	// KeyID identifies the wrapping key in keyring.json (recipients or KMS key).
+	KeyID() string
+}
+
+var keyringPath = filepath.Join(storeDir, "keyring.json")
+
+// keyring is the on-disk form of a wrapped data key. It holds no plaintext key material.
+type keyring struct {
+	Provider   string `json:"provider"`
+	KeyID      string `json:"key_id"`
+	WrappedKey string `json:"wrapped_key"`
+}
+
+// envelopeProvider encrypts fields with a random data key that is wrapped by an external
+// key (age recipients or KMS) and stored in keyring.json. The data key is unwrapped once
+// per process.
+type envelopeProvider struct {
+	name    string
+	wrapper keyWrapper
+
+	mu      sync.Mutex
+	dataKey *SecureBuffer
+}
+
+func (p *envelopeProvider) Name() string { return p.name }
+
+// key returns the data key, unwrapping it from the keyring or creating a new one.
+func (p *envelopeProvider) key() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dataKey != nil {
+		return p.dataKey.Bytes(), nil
+	}
+
+	kr, err := loadKeyring()
+	if err != nil {
+		return nil, err
+	}
+	if kr != nil {
+		if kr.Provider != p.name {
+			return nil, fmt.Errorf("keyring was created by the '%s' provider, not '%s'; run 'cloudctl secret migrate'", kr.Provider, p.name)
+		}
+		wrapped, err := base64.StdEncoding.DecodeString(kr.WrappedKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wrapped key in %s: %w", keyringPath, err)
+		}
+		plain, err := p.wrapper.Unwrap(wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unwrap store key with %s: %w", p.name, err)
+		}
+		p.dataKey = NewSecureBuffer(plain)
+		return p.dataKey.Bytes(), nil
+	}
+
+	fresh := make([]byte, 32)
+	if _, err := rand.Read(fresh); err != nil {
+		return nil, err
+	}
+	wrapped, err := p.wrapper.Wrap(fresh)
+	if err != nil {
+		Wipe(fresh)
+		return nil, fmt.Errorf("failed to wrap store key with %s: %w", p.name, err)
+	}
+	if err := saveKeyring(&keyring{
+		Provider:   p.name,
+		KeyID:      p.wrapper.KeyID(),
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+	}); err != nil {
+		Wipe(fresh)
+		return nil, err
+	}
+	p.dataKey = NewSecureBuffer(fresh)
+	return p.dataKey.Bytes(), nil
+}
+
+func (p *envelopeProvider) Encrypt(plain []byte) ([]byte, error) {
+	key, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(plain, key)
+}
+
+func (p *envelopeProvider) Decrypt(data []byte) ([]byte, error) {
+	key, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(data, key)
+}
+
+func (p *envelopeProvider) MAC(data []byte) ([]byte, error) {
+	key, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	return MAC(data, key), nil
+}
+
+func loadKeyring() (*keyring, error) {
+	b, err := os.ReadFile(keyringPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+	var kr keyring
+	if err := json.Unmarshal(b, &kr); err != nil {
+		return nil, fmt.Errorf("failed to parse keyring %s: %w", keyringPath, err)
+	}
+	return &kr, nil
+}
+
+func saveKeyring(kr *keyring) error {
+	if err := os.MkdirAll(filepath.Dir(keyringPath), 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	b, err := json.MarshalIndent(kr, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal keyring: %w", err)
+	}
+	return os.WriteFile(keyringPath, b, 0600)
+}
+
+// RemoveKeyring deletes the wrapped data key, e.g. before migrating to another provider.
+func RemoveKeyring() error {
+	if err := os.Remove(keyringPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove keyring: %w", err)
+	}
+	return nil
+}
+
+var (
+	envelopeMu     sync.Mutex
+	envelopeCached CryptoProvider
+)
+
+// NewCryptoProvider builds a provider from encryption settings. secret is only used by
+// the secret provider.
+func NewCryptoProvider(cfg EncryptionConfig, secret string) (CryptoProvider, error) {
+	switch cfg.Provider {
+	case "", ProviderSecret:
+		if secret == "" {
+			return nil, fmt.Errorf("no secret found")
+		}
+		return NewSecretProvider(secret), nil
+	case ProviderAge:
+		wrapper, err := newAgeWrapper(cfg.AgeRecipients, cfg.AgeIdentityFile)
+		if err != nil {
+			return nil, err
+		}
+		return &envelopeProvider{name: ProviderAge, wrapper: wrapper}, nil
+	case ProviderKMS:
+		wrapper, err := newKMSWrapper(cfg.KMSKeyID, cfg.KMSProfile, cfg.KMSRegion)
+		if err != nil {
+			return nil, err
+		}
+		return &envelopeProvider{name: ProviderKMS, wrapper: wrapper}, nil
+	case ProviderTPM:
+		wrapper, err := newTPMWrapper(cfg.TPMDevice)
+		if err != nil {
+			return nil, err
+		}
+		return &envelopeProvider{name: ProviderTPM, wrapper: wrapper}, nil
+	}
+	return nil, fmt.Errorf("unknown encryption provider '%s' (use secret, age, kms or tpm)", cfg.Provider)
+}
+
+// StoreProvider returns the configured provider for the credential store. Envelope
+// providers are cached so the data key is unwrapped (one KMS call) once per process.
+func StoreProvider(secret string) (CryptoProvider, error) {
+	cfg := CurrentConfig().Encryption
+	if !cfg.UsesEnvelope() {
+		return NewCryptoProvider(cfg, secret)
+	}
+
+	envelopeMu.Lock()
+	defer envelopeMu.Unlock()
+	if envelopeCached == nil {
+		p, err := NewCryptoProvider(cfg, secret)
+		if err != nil {
+			return nil, err
+		}
+		envelopeCached = p
+	}
+	return envelopeCached, nil
+}
+
+// ValidateEncryptionConfig checks the settings without contacting KMS or reading keys.
+func ValidateEncryptionConfig(cfg EncryptionConfig) error {
+	if cfg.SecretsManagerID != "" && cfg.UsesEnvelope() {
+		return fmt.Errorf("secrets_manager_id only applies to the secret provider")
+	}
+	switch cfg.Provider {
+	case "", ProviderSecret:
+		return nil
+	case ProviderAge:
+		if len(cfg.AgeRecipients) == 0 {
+			return fmt.Errorf("age_recipients is required for the age provider")
+		}
+		if cfg.AgeIdentityFile == "" {
+			return fmt.Errorf("age_identity_file is required for the age provider")
+		}
+		_, err := parseAgeRecipients(cfg.AgeRecipients)
+		return err
+	case ProviderKMS:
+		if cfg.KMSKeyID == "" {
+			return fmt.Errorf("kms_key_id is required for the kms provider")
+		}
+		return nil
+	case ProviderTPM:
+		return tpmSupported()
+	}
+	return fmt.Errorf("unknown encryption provider '%s' (use secret, age, kms or tpm)", cfg.Provider)
+}
+
+// BackupStoreFiles copies credentials.json, notes.json and keyring.json (when present) to
+// .bak files before a migration, and returns the backup paths.
+func BackupStoreFiles() ([]string, error) {
+	var backups []string
+	for _, path := range []string{storePath, notesPath, keyringPath} {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+		if err := os.WriteFile(path+".bak", b, 0600); err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+		backups = append(backups, path+".bak")
+	}
+	return backups, nil
+}
+
+// MigrateStore re-encrypts every stored session and note from one provider to another,
+// and returns the number of sessions. Envelope providers share keyring.json, so the old
+// data key is unwrapped before the keyring is replaced.
+func MigrateStore(from, to CryptoProvider) (int, error) {
+	sessions, err := ListAllSessionsWith(from)
+	if err != nil {
+		return 0, err
+	}
+	notes, err := loadAllNotesWith(from)
+	if err != nil {
+		return 0, err
+	}
+	if err := RemoveKeyring(); err != nil {
+		return 0, err
+	}
+	// The store was verified with the old provider; seal it with the new one from now on
+	processStore.rekeySeal(from, to)
+	saved := 0
+	err = processStore.Batch(func() error {
+		for _, s := range sessions {
+			if err := SaveCredentialsWith(s.Profile, s, to); err != nil {
+				return fmt.Errorf("failed to re-encrypt '%s': %w", s.Profile, err)
+			}
+			saved++
+		}
+		return nil
+	})
+	if err != nil {
+		return saved, err
+	}
+	for _, note := range notes {
+		if err := SaveNoteWith(note, to); err != nil {
+			return len(sessions), fmt.Errorf("failed to re-encrypt note '%s': %w", note.Name, err)
+		}
+	}
+	return len(sessions), nil
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// ageWrapper wraps the store data key for one or more age recipients and unwraps it
+// with the identities in an age key file.
+type ageWrapper struct {
+	recipients   []age.Recipient
+	recipientIDs string
+	identityFile string
+}
+
+func parseAgeRecipients(values []string) ([]age.Recipient, error) {
+	recipients := make([]age.Recipient, 0, len(values))
+	for _, v := range values {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient '%s': %w", v, err)
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+func newAgeWrapper(recipients []string, identityFile string) (*ageWrapper, error) {
+	parsed, err := parseAgeRecipients(recipients)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("age_recipients is required for the age provider")
+	}
+	return &ageWrapper{
+		recipients:   parsed,
+		recipientIDs: strings.Join(recipients, ","),
+		identityFile: expandHome(identityFile),
+	}, nil
+}
+
+func (w *ageWrapper) KeyID() string { return w.recipientIDs }
+
+func (w *ageWrapper) Wrap(dataKey []byte) ([]byte, error) {
+	var out bytes.Buffer
+	enc, err := age.Encrypt(&out, w.recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := enc.Write(dataKey); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (w *ageWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	f, err := os.Open(w.identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open age identity file: %w", err)
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age identity file: %w", err)
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(wrapped), identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// expandHome replaces a leading ~/ with the home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// kmsEncryptionContext binds wrapped keys to cloudctl so they can't be decrypted as
+// something else, and shows up in CloudTrail.
+var kmsEncryptionContext = map[string]string{"application": "cloudctl", "purpose": "store-key"}
+
+// kmsWrapper wraps the store data key with a KMS key, calling KMS with credentials from
+// a bootstrap profile in the shared AWS config (not a cloudctl session, which would need
+// the store to be decrypted first).
+type kmsWrapper struct {
+	keyID   string
+	profile string
+	region  string
+}
+
+func newKMSWrapper(keyID, profile, region string) (*kmsWrapper, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("kms_key_id is required for the kms provider")
+	}
+	return &kmsWrapper{keyID: keyID, profile: profile, region: region}, nil
+}
+
+func (w *kmsWrapper) KeyID() string { return w.keyID }
+
+func (w *kmsWrapper) client(ctx context.Context) (*kms.Client, error) {
+	var opts []func(*config.LoadOptions) error
+	if w.profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(w.profile))
+	}
+	if w.region != "" {
+		opts = append(opts, config.WithRegion(w.region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, append(opts, WithCallTimeout)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load KMS bootstrap profile: %w", err)
+	}
+	return kms.NewFromConfig(cfg), nil
+}
+
+func (w *kmsWrapper) Wrap(dataKey []byte) ([]byte, error) {
+	// CryptoProvider has no context; network.call_timeout_seconds bounds the call
+	ctx := context.Background()
+	client, err := w.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(w.keyID),
+		Plaintext:         dataKey,
+		EncryptionContext: kmsEncryptionContext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (w *kmsWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	// CryptoProvider has no context; network.call_timeout_seconds bounds the call
+	ctx := context.Background()
+	client, err := w.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(w.keyID),
+		CiphertextBlob:    wrapped,
+		EncryptionContext: kmsEncryptionContext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+)
+
+// xorWrapper is a stand-in for age/KMS that records how often the key is unwrapped.
+type xorWrapper struct {
+	unwraps int
+}
+
+func (w *xorWrapper) KeyID() string { return "test-key" }
+
+func (w *xorWrapper) Wrap(dataKey []byte) ([]byte, error) {
+	out := make([]byte, len(dataKey))
+	for i, b := range dataKey {
+		out[i] = b ^ 0x5a
+	}
+	return out, nil
+}
+
+func (w *xorWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	w.unwraps++
+	return w.Wrap(wrapped)
+}
+
+func setupTestKeyring(t *testing.T) {
+	t.Helper()
+	dir := setupTestDir(t)
+	originalPath := keyringPath
+	keyringPath = filepath.Join(dir, "keyring.json")
+	t.Cleanup(func() {
+		keyringPath = originalPath
+	})
+}
+
+func TestSecretProviderMatchesLegacyFormat(t *testing.T) {
+	secret := "1234567890ABCDEF1234567890ABCDEF"
+	legacy, err := Encrypt([]byte("AKIATEST"), []byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := NewSecretProvider(secret).Decrypt(legacy)
+	if err != nil || string(plain) != "AKIATEST" {
+		t.Fatalf("Expected legacy ciphertext to decrypt, got %q, %v", plain, err)
+	}
+}
+
+func TestEnvelopeProviderKeyring(t *testing.T) {
+	setupTestKeyring(t)
+
+	wrapper := &xorWrapper{}
+	first := &envelopeProvider{name: ProviderKMS, wrapper: wrapper}
+	data, err := first.Encrypt([]byte("secret value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(keyringPath); err != nil {
+		t.Fatalf("Expected keyring to be written: %v", err)
+	}
+
+	// A new process unwraps the stored key once and can decrypt
+	second := &envelopeProvider{name: ProviderKMS, wrapper: wrapper}
+	for i := 0; i < 3; i++ {
+		plain, err := second.Decrypt(data)
+		if err != nil || string(plain) != "secret value" {
+			t.Fatalf("Expected round trip, got %q, %v", plain, err)
+		}
+	}
+	if wrapper.unwraps != 1 {
+		t.Errorf("Expected one unwrap per provider, got %d", wrapper.unwraps)
+	}
+
+	// A different provider must not silently use the keyring
+	other := &envelopeProvider{name: ProviderAge, wrapper: wrapper}
+	if _, err := other.Decrypt(data); err == nil {
+		t.Error("Expected provider mismatch error")
+	}
+}
+
+func TestAgeWrapper(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityFile := filepath.Join(t.TempDir(), "keys.txt")
+	if err := os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := newAgeWrapper([]string{identity.Recipient().String()}, identityFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := bytes.Repeat([]byte{7}, 32)
+	wrapped, err := w.Wrap(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unwrapped, err := w.Unwrap(wrapped)
+	if err != nil || !bytes.Equal(unwrapped, key) {
+		t.Fatalf("Expected age round trip, got %v, %v", unwrapped, err)
+	}
+
+	if _, err := newAgeWrapper([]string{"not-a-recipient"}, identityFile); err == nil {
+		t.Error("Expected invalid recipient error")
+	}
+}
+
+func TestMigrateStore(t *testing.T) {
+	setupTestKeyring(t)
+
+	secret := "1234567890ABCDEF1234567890ABCDEF"
+	from := NewSecretProvider(secret)
+	session := &AWSSession{Profile: "dev", AccessKey: "AKIATEST", SecretKey: "sk", Expiration: time.Now().Add(time.Hour).Truncate(time.Second)}
+	if err := SaveCredentialsWith("dev", session, from); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveNoteWith(&Note{Name: "dev", Text: "account 123456789012", Updated: time.Now()}, from); err != nil {
+		t.Fatal(err)
+	}
+
+	to := &envelopeProvider{name: ProviderKMS, wrapper: &xorWrapper{}}
+	count, err := MigrateStore(from, to)
+	if err != nil || count != 1 {
+		t.Fatalf("Expected 1 migrated session, got %d, %v", count, err)
+	}
+
+	if _, err := ListAllSessionsWith(from); err == nil {
+		t.Error("Expected the old provider to fail after migration")
+	}
+	sessions, err := ListAllSessionsWith(to)
+	if err != nil || len(sessions) != 1 || sessions[0].AccessKey != "AKIATEST" {
+		t.Fatalf("Expected migrated session, got %v, %v", sessions, err)
+	}
+	if note, err := LoadNoteWith("dev", to); err != nil || note.Text != "account 123456789012" {
+		t.Fatalf("Expected migrated note, got %v, %v", note, err)
+	}
+}
+
+func TestValidateEncryptionConfig(t *testing.T) {
+	valid := []EncryptionConfig{
+		{},
+		{Provider: ProviderSecret},
+		{Provider: ProviderKMS, KMSKeyID: "alias/cloudctl"},
+	}
+	for _, cfg := range valid {
+		if err := ValidateEncryptionConfig(cfg); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", cfg, err)
+		}
+	}
+
+	invalid := []EncryptionConfig{
+		{Provider: "rot13"},
+		{Provider: ProviderKMS},
+		{Provider: ProviderAge, AgeIdentityFile: "~/.age/key.txt"},
+		{Provider: ProviderAge, AgeRecipients: []string{"bad"}, AgeIdentityFile: "~/.age/key.txt"},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateEncryptionConfig(cfg); err == nil {
+			t.Errorf("Expected %+v to be invalid", cfg)
+		}
+	}
+}
+
+func TestSealedKeyEncoding(t *testing.T) {
+	pub := []byte("public-area")
+	priv := bytes.Repeat([]byte{0xab}, 300)
+
+	encoded := encodeSealedKey(pub, priv)
+	gotPub, gotPriv, err := decodeSealedKey(encoded)
+	if err != nil {
+		t.Fatalf("decodeSealedKey failed: %v", err)
+	}
+	if !bytes.Equal(gotPub, pub) || !bytes.Equal(gotPriv, priv) {
+		t.Error("Expected blobs to round-trip")
+	}
+
+	for _, bad := range [][]byte{nil, encoded[:1], encoded[:len(encoded)-1], append(encoded, 0)} {
+		if _, _, err := decodeSealedKey(bad); err == nil {
+			t.Errorf("Expected error decoding %d bytes", len(bad))
+		}
+	}
+}
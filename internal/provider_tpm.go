@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/go-tpm/legacy/tpm2"
+)
+
+// tpmSRKTemplate is the standard ECC storage root key template. The SRK is derived
+// deterministically from the owner hierarchy seed, so recreating it on each run yields
+// the same key without persisting a handle.
+var tpmSRKTemplate = tpm2.Public{
+	Type:       tpm2.AlgECC,
+	NameAlg:    tpm2.AlgSHA256,
+	Attributes: tpm2.FlagStorageDefault | tpm2.FlagNoDA,
+	ECCParameters: &tpm2.ECCParams{
+		Symmetric: &tpm2.SymScheme{Alg: tpm2.AlgAES, KeyBits: 128, Mode: tpm2.AlgCFB},
+		CurveID:   tpm2.CurveNISTP256,
+	},
+}
+
+// tpmSealTemplate describes the sealed data object holding the store key. FixedTPM and
+// FixedParent stop it from being duplicated to another TPM.
+var tpmSealTemplate = tpm2.Public{
+	Type:       tpm2.AlgKeyedHash,
+	NameAlg:    tpm2.AlgSHA256,
+	Attributes: tpm2.FlagFixedTPM | tpm2.FlagFixedParent | tpm2.FlagUserWithAuth | tpm2.FlagNoDA,
+}
+
+// tpmWrapper seals the store data key to this machine's TPM. The wrapped key is the
+// public and private blobs of the sealed object, which only the same TPM can load, so a
+// copied keyring.json and credentials.json are useless elsewhere.
+type tpmWrapper struct {
+	device string
+}
+
+func newTPMWrapper(device string) (*tpmWrapper, error) {
+	if err := tpmSupported(); err != nil {
+		return nil, err
+	}
+	return &tpmWrapper{device: device}, nil
+}
+
+func (w *tpmWrapper) KeyID() string {
+	if w.device != "" {
+		return "tpm:" + w.device
+	}
+	return "tpm"
+}
+
+func (w *tpmWrapper) Wrap(dataKey []byte) ([]byte, error) {
+	rw, err := openTPM(w.device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open TPM: %w", err)
+	}
+	defer rw.Close()
+
+	srk, _, err := tpm2.CreatePrimary(rw, tpm2.HandleOwner, tpm2.PCRSelection{}, "", "", tpmSRKTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TPM storage key: %w", err)
+	}
+	defer tpm2.FlushContext(rw, srk)
+
+	priv, pub, _, _, _, err := tpm2.CreateKeyWithSensitive(rw, srk, tpm2.PCRSelection{}, "", "", tpmSealTemplate, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal store key: %w", err)
+	}
+	return encodeSealedKey(pub, priv), nil
+}
+
+func (w *tpmWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	pub, priv, err := decodeSealedKey(wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	rw, err := openTPM(w.device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open TPM: %w", err)
+	}
+	defer rw.Close()
+
+	srk, _, err := tpm2.CreatePrimary(rw, tpm2.HandleOwner, tpm2.PCRSelection{}, "", "", tpmSRKTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TPM storage key: %w", err)
+	}
+	defer tpm2.FlushContext(rw, srk)
+
+	handle, _, err := tpm2.Load(rw, srk, "", pub, priv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sealed store key (was it sealed on another machine?): %w", err)
+	}
+	defer tpm2.FlushContext(rw, handle)
+
+	dataKey, err := tpm2.Unseal(rw, handle, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to unseal store key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// encodeSealedKey packs the sealed object's blobs as two length-prefixed fields.
+func encodeSealedKey(pub, priv []byte) []byte {
+	out := make([]byte, 0, 4+len(pub)+len(priv))
+	out = binary.BigEndian.AppendUint16(out, uint16(len(pub)))
+	out = append(out, pub...)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(priv)))
+	return append(out, priv...)
+}
+
+func decodeSealedKey(data []byte) (pub, priv []byte, err error) {
+	next := func() ([]byte, error) {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("sealed store key is truncated")
+		}
+		n := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+n {
+			return nil, fmt.Errorf("sealed store key is truncated")
+		}
+		field := data[2 : 2+n]
+		data = data[2+n:]
+		return field, nil
+	}
+	if pub, err = next(); err != nil {
+		return nil, nil, err
+	}
+	if priv, err = next(); err != nil {
+		return nil, nil, err
+	}
+	if len(data) != 0 {
+		return nil, nil, fmt.Errorf("sealed store key has trailing data")
+	}
+	return pub, priv, nil
+}
@@ -0,0 +1,18 @@
+package internal
+
+import (
+	"io"
+
+	"github.com/google/go-tpm/legacy/tpm2"
+)
+
+func tpmSupported() error { return nil }
+
+// openTPM opens the given device, or the kernel resource manager (/dev/tpmrm0, falling
+// back to /dev/tpm0) when device is empty.
+func openTPM(device string) (io.ReadWriteCloser, error) {
+	if device != "" {
+		return tpm2.OpenTPM(device)
+	}
+	return tpm2.OpenTPM()
+}
@@ -0,0 +1,23 @@
+//go:build !linux && !windows
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// tpmSupported reports that hardware-backed encryption is unavailable. On macOS this
+// would use the Secure Enclave, but Secure Enclave keys can only be created by a
+// code-signed binary with keychain entitlements, which cloudctl builds don't have yet.
+func tpmSupported() error {
+	if runtime.GOOS == "darwin" {
+		return fmt.Errorf("the tpm provider is not available on macOS: Secure Enclave keys require a signed build with keychain entitlements")
+	}
+	return fmt.Errorf("the tpm provider is not supported on %s", runtime.GOOS)
+}
+
+func openTPM(device string) (io.ReadWriteCloser, error) {
+	return nil, tpmSupported()
+}
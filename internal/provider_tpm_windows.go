@@ -0,0 +1,15 @@
+package internal
+
+import (
+	"io"
+
+	"github.com/google/go-tpm/legacy/tpm2"
+)
+
+func tpmSupported() error { return nil }
+
+// openTPM opens the TPM through TPM Base Services. Windows has no device path, so
+// device is ignored.
+func openTPM(device string) (io.ReadWriteCloser, error) {
+	return tpm2.OpenTPM()
+}
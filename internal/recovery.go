@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// RecoveryPhraseWords is the length of a recovery phrase for a 256-bit key.
+const RecoveryPhraseWords = 24
+
+// RecoveryPhrase encodes a generated 64-char hex secret as a 24-word BIP39 phrase, which
+// is easier to write down and has a checksum that catches transcription mistakes.
+func RecoveryPhrase(secret string) (string, error) {
+	key, err := hex.DecodeString(secret)
+	if err != nil || len(key) != 32 {
+		return "", fmt.Errorf("only generated 64-character keys can be turned into a recovery phrase")
+	}
+	defer Wipe(key)
+	return bip39.NewMnemonic(key)
+}
+
+// SecretFromRecoveryPhrase reconstructs the hex secret from a recovery phrase. Case and
+// whitespace are ignored.
+func SecretFromRecoveryPhrase(phrase string) (string, error) {
+	words := strings.Fields(strings.ToLower(phrase))
+	if len(words) != RecoveryPhraseWords {
+		return "", fmt.Errorf("a recovery phrase has %d words, got %d", RecoveryPhraseWords, len(words))
+	}
+	key, err := bip39.EntropyFromMnemonic(strings.Join(words, " "))
+	if err != nil {
+		return "", fmt.Errorf("invalid recovery phrase (check for typos): %w", err)
+	}
+	defer Wipe(key)
+	return hex.EncodeToString(key), nil
+}
+
+// RecoveryCheckPositions picks count distinct random word positions (1-based, sorted)
+// the user is asked to repeat to prove the phrase was recorded.
+func RecoveryCheckPositions(count int) ([]int, error) {
+	count = min(count, RecoveryPhraseWords)
+	picked := make(map[int]bool)
+	for len(picked) < count {
+		n, err := rand.Int(rand.Reader, big.NewInt(RecoveryPhraseWords))
+		if err != nil {
+			return nil, err
+		}
+		picked[int(n.Int64())+1] = true
+	}
+	positions := make([]int, 0, count)
+	for p := range picked {
+		positions = append(positions, p)
+	}
+	sort.Ints(positions)
+	return positions, nil
+}
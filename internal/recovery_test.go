@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecoveryPhraseRoundTrip(t *testing.T) {
+	secret := strings.Repeat("0123456789abcdef", 4)
+
+	phrase, err := RecoveryPhrase(secret)
+	if err != nil {
+		t.Fatalf("RecoveryPhrase failed: %v", err)
+	}
+	if n := len(strings.Fields(phrase)); n != RecoveryPhraseWords {
+		t.Fatalf("Expected %d words, got %d", RecoveryPhraseWords, n)
+	}
+
+	// Users retype phrases with arbitrary spacing and case
+	retyped := "  " + strings.ToUpper(strings.ReplaceAll(phrase, " ", "\n ")) + " "
+	got, err := SecretFromRecoveryPhrase(retyped)
+	if err != nil {
+		t.Fatalf("SecretFromRecoveryPhrase failed: %v", err)
+	}
+	if got != secret {
+		t.Errorf("Expected %s, got %s", secret, got)
+	}
+}
+
+func TestRecoveryPhraseRejectsCustomSecrets(t *testing.T) {
+	for _, secret := range []string{"my-32-char-encryption-key-123456", "abcd", strings.Repeat("zz", 32)} {
+		if _, err := RecoveryPhrase(secret); err == nil {
+			t.Errorf("Expected error for %q", secret)
+		}
+	}
+}
+
+func TestSecretFromRecoveryPhraseValidation(t *testing.T) {
+	phrase, _ := RecoveryPhrase(strings.Repeat("ab", 32))
+	words := strings.Fields(phrase)
+
+	if _, err := SecretFromRecoveryPhrase(strings.Join(words[:12], " ")); err == nil {
+		t.Error("Expected error for a short phrase")
+	}
+
+	// Swapping two different words breaks the checksum
+	for i := 1; i < len(words); i++ {
+		if words[i] != words[0] {
+			words[0], words[i] = words[i], words[0]
+			break
+		}
+	}
+	if _, err := SecretFromRecoveryPhrase(strings.Join(words, " ")); err == nil {
+		t.Error("Expected checksum error for swapped words")
+	}
+
+	words[0] = "notaword"
+	if _, err := SecretFromRecoveryPhrase(strings.Join(words, " ")); err == nil {
+		t.Error("Expected error for an unknown word")
+	}
+}
+
+func TestRecoveryCheckPositions(t *testing.T) {
+	positions, err := RecoveryCheckPositions(3)
+	if err != nil {
+		t.Fatalf("RecoveryCheckPositions failed: %v", err)
+	}
+	if len(positions) != 3 {
+		t.Fatalf("Expected 3 positions, got %v", positions)
+	}
+	for i, p := range positions {
+		if p < 1 || p > RecoveryPhraseWords {
+			t.Errorf("Position %d out of range", p)
+		}
+		if i > 0 && p <= positions[i-1] {
+			t.Errorf("Expected sorted, distinct positions, got %v", positions)
+		}
+	}
+}
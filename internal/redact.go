@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RedactedPlaceholder replaces any value that looks like a credential.
+const RedactedPlaceholder = "[REDACTED]"
+
+var (
+	// AWS access key IDs (long-term AKIA and temporary ASIA keys)
+	accessKeyPattern = regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{16}\b`)
+	// key = value / key: value pairs whose key name suggests a secret, also as JSON
+	// ("key": "value"); quoted values keep their quotes so JSON stays valid
+	secretQuotedPattern = regexp.MustCompile(`(?i)\b([a-z_]*(secret|token|password|session_key|sessionkey)[a-z_]*)("?\s*[=:]\s*)"[^"]*"`)
+	secretAssignPattern = regexp.MustCompile(`(?i)\b([a-z_]*(secret|token|password|session_key|sessionkey)[a-z_]*)("?\s*[=:]\s*)([^\s&"]+)`)
+	// Federation sign-in tokens embedded in console URLs
+	signinTokenPattern = regexp.MustCompile(`(?i)(SigninToken=)[^&\s]+`)
+)
+
+// RedactSecrets masks access keys, secret keys, session tokens and sign-in tokens
+// so the text can be shared (e.g. attached to a GitHub issue) without leaking credentials.
+func RedactSecrets(s string) string {
+	s = signinTokenPattern.ReplaceAllString(s, "${1}"+RedactedPlaceholder)
+	s = secretQuotedPattern.ReplaceAllString(s, "${1}${3}\""+RedactedPlaceholder+"\"")
+	s = secretAssignPattern.ReplaceAllString(s, "${1}${3}"+RedactedPlaceholder)
+	s = accessKeyPattern.ReplaceAllStringFunc(s, func(key string) string {
+		// Keep the prefix so it's still clear whether it was a temporary key
+		return key[:4] + RedactedPlaceholder
+	})
+	return s
+}
+
+// IsSensitiveEnv reports whether an environment variable name is likely to hold a secret.
+func IsSensitiveEnv(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range []string{"SECRET", "TOKEN", "PASSWORD", "ACCESS_KEY"} {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
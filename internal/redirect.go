@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shortCodeBytes gives headless links a 40-bit code: 8 characters that are easy to type
+// from a phone, yet can't be guessed within the link's lifetime.
+const shortCodeBytes = 5
+
+// OneTimeRedirect serves a single redirect to a sensitive URL (such as a console sign-in
+// link) from a random localhost path, so the URL itself never has to be printed. The first
+// request is redirected; every later request gets 410 Gone.
+type OneTimeRedirect struct {
+	target   string
+	path     string
+	host     string
+	listener net.Listener
+	server   *http.Server
+
+	once sync.Once
+	used chan struct{}
+}
+
+// NewOneTimeRedirect starts listening on a random port on 127.0.0.1.
+func NewOneTimeRedirect(target string) (*OneTimeRedirect, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return newOneTimeRedirect(target, "127.0.0.1:0", "/"+hex.EncodeToString(nonce))
+}
+
+// NewShortRedirect serves the redirect on listenAddr under a short code, for opening it
+// from another device. When listenAddr has no host (e.g. ":0" or "0.0.0.0:8080"), the
+// URL uses this machine's outbound IP address.
+func NewShortRedirect(target, listenAddr string) (*OneTimeRedirect, error) {
+	code := make([]byte, shortCodeBytes)
+	if _, err := rand.Read(code); err != nil {
+		return nil, err
+	}
+	return newOneTimeRedirect(target, listenAddr, "/"+strings.ToLower(base32.StdEncoding.EncodeToString(code)))
+}
+
+func newOneTimeRedirect(target, listenAddr, path string) (*OneTimeRedirect, error) {
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start local redirect server: %w", err)
+	}
+
+	host := listener.Addr().String()
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok && addr.IP.IsUnspecified() {
+		ip, err := outboundIP()
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		host = net.JoinHostPort(ip.String(), fmt.Sprint(addr.Port))
+	}
+
+	r := &OneTimeRedirect{
+		target:   target,
+		path:     path,
+		host:     host,
+		listener: listener,
+		used:     make(chan struct{}),
+	}
+	r.server = &http.Server{Handler: http.HandlerFunc(r.serve), ReadHeaderTimeout: 10 * time.Second}
+	go r.server.Serve(listener)
+	return r, nil
+}
+
+// Loopback reports whether the redirect is only reachable from this machine. Otherwise
+// the link, and the sign-in token it redirects to, travel over the network in cleartext.
+func (r *OneTimeRedirect) Loopback() bool {
+	addr, ok := r.listener.Addr().(*net.TCPAddr)
+	return ok && addr.IP.IsLoopback()
+}
+
+// URL is the address to open in the browser.
+func (r *OneTimeRedirect) URL() string {
+	return fmt.Sprintf("http://%s%s", r.host, r.path)
+}
+
+// outboundIP returns the local address used to reach other networks. Dialing UDP sends
+// no packets; it only selects a route.
+func outboundIP() (net.IP, error) {
+	conn, err := net.Dial("udp", "192.0.2.1:9")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find this machine's IP address (use --listen <ip>:<port>): %w", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+func (r *OneTimeRedirect) serve(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	if req.URL.Path != r.path {
+		http.NotFound(w, req)
+		return
+	}
+
+	served := false
+	r.once.Do(func() {
+		served = true
+		http.Redirect(w, req, r.target, http.StatusFound)
+		close(r.used)
+	})
+	if !served {
+		http.Error(w, "This sign-in link has already been used.", http.StatusGone)
+	}
+}
+
+// Wait blocks until the redirect has been used or ctx is done.
+func (r *OneTimeRedirect) Wait(ctx context.Context) error {
+	select {
+	case <-r.used:
+		return nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("sign-in link was not opened in time")
+		}
+		return ctx.Err()
+	}
+}
+
+// Close stops the server. Call it after Wait so the redirect response can finish.
+func (r *OneTimeRedirect) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return r.server.Shutdown(ctx)
+}
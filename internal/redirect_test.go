@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOneTimeRedirect(t *testing.T) {
+	target := "https://signin.aws.amazon.com/federation?Action=login&SigninToken=secret"
+	r, err := NewOneTimeRedirect(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if !strings.HasPrefix(r.URL(), "http://127.0.0.1:") {
+		t.Fatalf("Expected a localhost URL, got %s", r.URL())
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	// Wrong path must not reveal anything
+	resp, err := client.Get(r.URL() + "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown path, got %d", resp.StatusCode)
+	}
+
+	resp, err = client.Get(r.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != target {
+		t.Fatalf("Expected redirect to target, got %d %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.Wait(ctx); err != nil {
+		t.Fatalf("Expected Wait to return after use, got %v", err)
+	}
+
+	resp, err = client.Get(r.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusGone {
+		t.Errorf("Expected 410 on reuse, got %d", resp.StatusCode)
+	}
+}
+
+func TestOneTimeRedirectTimeout(t *testing.T) {
+	r, err := NewOneTimeRedirect("https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.Wait(ctx); err == nil {
+		t.Error("Expected timeout error")
+	}
+}
+
+func TestShortRedirect(t *testing.T) {
+	r, err := NewShortRedirect("https://example.com", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// http://127.0.0.1:<port>/ followed by an 8-character code
+	code := r.URL()[strings.LastIndex(r.URL(), "/")+1:]
+	if !strings.HasPrefix(r.URL(), "http://127.0.0.1:") || len(code) != 8 || strings.ToLower(code) != code {
+		t.Fatalf("Expected a short lowercase code, got %s", r.URL())
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(r.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected redirect, got %d", resp.StatusCode)
+	}
+}
+
+func TestRedirectLoopback(t *testing.T) {
+	local, err := NewShortRedirect("https://example.com", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+	if !local.Loopback() {
+		t.Errorf("Expected %s to be loopback-only", local.URL())
+	}
+
+	network, err := NewShortRedirect("https://example.com", "0.0.0.0:0")
+	if err != nil {
+		t.Skipf("No outbound address in this environment: %v", err)
+	}
+	defer network.Close()
+	if network.Loopback() {
+		t.Errorf("Expected %s not to be loopback-only", network.URL())
+	}
+}
@@ -0,0 +1,41 @@
+package internal
+
+import "fmt"
+
+// Policies for `refresh --all --fail-on`, deciding when a batch refresh exits non-zero
+const (
+	// FailOnAny fails when any session failed to refresh.
+	FailOnAny = "any"
+	// FailOnAll fails when sessions failed and none was refreshed.
+	FailOnAll = "all"
+	// FailOnNone never fails because of individual sessions.
+	FailOnNone = "none"
+)
+
+// RefreshSummary counts the outcomes of a batch refresh. Skipped sessions, such as
+// expired ones that need a manual login, are not failures.
+type RefreshSummary struct {
+	Refreshed int
+	Skipped   int
+	Failed    int
+}
+
+// ValidateFailOn checks a --fail-on policy.
+func ValidateFailOn(policy string) error {
+	switch policy {
+	case FailOnAny, FailOnAll, FailOnNone:
+		return nil
+	}
+	return fmt.Errorf("invalid --fail-on '%s' (use any, all or none)", policy)
+}
+
+// FailsOn reports whether the summary counts as a failure under policy.
+func (s RefreshSummary) FailsOn(policy string) bool {
+	switch policy {
+	case FailOnAny:
+		return s.Failed > 0
+	case FailOnAll:
+		return s.Failed > 0 && s.Refreshed == 0
+	}
+	return false
+}
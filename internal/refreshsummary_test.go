@@ -0,0 +1,33 @@
+package internal
+
+import "testing"
+
+func TestRefreshSummaryFailsOn(t *testing.T) {
+	tests := []struct {
+		summary        RefreshSummary
+		any, all, none bool
+	}{
+		{RefreshSummary{}, false, false, false},
+		{RefreshSummary{Refreshed: 3, Skipped: 2}, false, false, false},
+		{RefreshSummary{Refreshed: 2, Failed: 1}, true, false, false},
+		{RefreshSummary{Skipped: 1, Failed: 2}, true, true, false},
+	}
+	for _, tt := range tests {
+		for policy, want := range map[string]bool{FailOnAny: tt.any, FailOnAll: tt.all, FailOnNone: tt.none} {
+			if got := tt.summary.FailsOn(policy); got != want {
+				t.Errorf("%+v with %s: got %v, want %v", tt.summary, policy, got, want)
+			}
+		}
+	}
+}
+
+func TestValidateFailOn(t *testing.T) {
+	for _, policy := range []string{FailOnAny, FailOnAll, FailOnNone} {
+		if err := ValidateFailOn(policy); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", policy, err)
+		}
+	}
+	if err := ValidateFailOn("some"); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+}
@@ -0,0 +1,408 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// RenewalLease is how long a machine may hold a profile while refreshing it before
+// another machine may take over.
+const RenewalLease = 2 * time.Minute
+
+// remoteStateFormat identifies the encrypted remote state document.
+const remoteStateFormat = "cloudctl-remote-state-v1"
+
+// RemoteSession is what the remote state records about a session minted on one
+// machine. It never holds credentials.
+type RemoteSession struct {
+	Profile    string    `json:"profile"`
+	Host       string    `json:"host"`
+	RoleArn    string    `json:"role_arn,omitempty"`
+	AccountID  string    `json:"account_id,omitempty"`
+	MintedAt   time.Time `json:"minted_at"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// RemoteRenewal is a lease on refreshing a profile, held by one machine.
+type RemoteRenewal struct {
+	Host  string    `json:"host"`
+	Until time.Time `json:"until"`
+}
+
+// RemoteState is the decrypted remote state document.
+type RemoteState struct {
+	// Sessions are keyed by "host/profile".
+	Sessions map[string]RemoteSession `json:"sessions"`
+	// Renewals are keyed by profile.
+	Renewals map[string]RemoteRenewal `json:"renewals"`
+}
+
+// RenewalInProgressError is returned when another machine is refreshing a profile.
+type RenewalInProgressError struct {
+	Profile string
+	Host    string
+	Until   time.Time
+}
+
+func (e *RenewalInProgressError) Error() string {
+	return fmt.Sprintf("'%s' is being refreshed on %s (lease until %s)", e.Profile, e.Host, FormatTime(e.Until))
+}
+
+// remoteBackend reads and writes the encrypted remote state document.
+type remoteBackend interface {
+	// Get returns nil without an error when there is no document yet.
+	Get(ctx context.Context) ([]byte, error)
+	Put(ctx context.Context, data []byte) error
+}
+
+// RemoteStateEnabled reports whether remote.url is configured.
+func RemoteStateEnabled() bool {
+	return CurrentConfig().Remote.URL != ""
+}
+
+// RemoteHost is the name this machine is recorded under: remote.host, or the hostname.
+func RemoteHost() string {
+	if host := CurrentConfig().Remote.Host; host != "" {
+		return host
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "unknown"
+	}
+	return host
+}
+
+// ValidateRemoteURL checks a remote.url value: s3://bucket/key or ssm:<parameter-name>.
+func ValidateRemoteURL(u string) error {
+	if strings.HasPrefix(u, "s3://") {
+		_, err := ParseS3URI(u)
+		return err
+	}
+	if name, ok := strings.CutPrefix(u, "ssm:"); ok {
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("invalid SSM parameter in '%s': expected ssm:/path/to/parameter", u)
+		}
+		return nil
+	}
+	return fmt.Errorf("'%s' is not supported: use s3://bucket/key or ssm:/parameter/name", u)
+}
+
+// newRemoteBackend returns the backend for the configured remote.url, calling AWS with
+// credentials from remote.profile in the shared AWS config (not a cloudctl session).
+var newRemoteBackend = func(ctx context.Context) (remoteBackend, error) {
+	rc := CurrentConfig().Remote
+	return openRemoteBackend(ctx, rc.URL, rc.Profile, rc.Region)
+}
+
+// openRemoteBackend returns the backend for an s3:// or ssm: URL, reached with profile
+// from the shared AWS config (the default chain if empty) and region.
+func openRemoteBackend(ctx context.Context, u, profile, region string) (remoteBackend, error) {
+	if err := ValidateRemoteURL(u); err != nil {
+		return nil, err
+	}
+	var opts []func(*config.LoadOptions) error
+	if profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, append(opts, WithCallTimeout)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile '%s': %w", profile, err)
+	}
+	if name, ok := strings.CutPrefix(u, "ssm:"); ok {
+		return &ssmBackend{client: ssm.NewFromConfig(cfg), name: name}, nil
+	}
+	obj, _ := ParseS3URI(u)
+	return &s3Backend{client: s3.NewFromConfig(cfg), obj: obj}, nil
+}
+
+type s3Backend struct {
+	client *s3.Client
+	obj    S3Object
+}
+
+func (b *s3Backend) Get(ctx context.Context) ([]byte, error) {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(b.obj.Bucket), Key: aws.String(b.obj.Key)})
+	var noKey *s3types.NoSuchKey
+	if errors.As(err, &noKey) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (b *s3Backend) Put(ctx context.Context, data []byte) error {
+	_, err := b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(b.obj.Bucket),
+		Key:                  aws.String(b.obj.Key),
+		Body:                 bytes.NewReader(data),
+		ContentType:          aws.String("application/json"),
+		ServerSideEncryption: s3types.ServerSideEncryptionAes256,
+	})
+	return err
+}
+
+// ssmMaxValueSize is the size limit of an advanced-tier SSM parameter value.
+const ssmMaxValueSize = 8 * 1024
+
+type ssmBackend struct {
+	client *ssm.Client
+	name   string
+}
+
+func (b *ssmBackend) Get(ctx context.Context) ([]byte, error) {
+	out, err := b.client.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(b.name), WithDecryption: aws.Bool(true)})
+	var notFound *ssmtypes.ParameterNotFound
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(aws.ToString(out.Parameter.Value)), nil
+}
+
+func (b *ssmBackend) Put(ctx context.Context, data []byte) error {
+	if len(data) > ssmMaxValueSize {
+		return fmt.Errorf("%d bytes don't fit in an SSM parameter (at most %d); use an s3:// URL", len(data), ssmMaxValueSize)
+	}
+	_, err := b.client.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(b.name),
+		Value:     aws.String(string(data)),
+		Type:      ssmtypes.ParameterTypeSecureString,
+		Tier:      ssmtypes.ParameterTierIntelligentTiering,
+		Overwrite: aws.Bool(true),
+	})
+	return err
+}
+
+// remoteEnvelope is the stored document: the state JSON, encrypted with the cloudctl
+// secret, so every machine needs the same secret to read it.
+type remoteEnvelope struct {
+	Format string `json:"format"`
+	Data   string `json:"data"`
+}
+
+// remoteProvider encrypts the remote state with the cloudctl secret even when the store
+// uses age, KMS or the TPM, whose data keys are kept per machine in keyring.json.
+func remoteProvider(secret string) (CryptoProvider, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("the remote state is encrypted with the cloudctl secret, and no secret was found")
+	}
+	return NewSecretProvider(secret), nil
+}
+
+func decodeRemoteState(b []byte, provider CryptoProvider) (*RemoteState, error) {
+	state := &RemoteState{Sessions: map[string]RemoteSession{}, Renewals: map[string]RemoteRenewal{}}
+	if len(b) == 0 {
+		return state, nil
+	}
+	var env remoteEnvelope
+	if err := json.Unmarshal(b, &env); err != nil || env.Format != remoteStateFormat {
+		return nil, fmt.Errorf("the remote state is not a cloudctl remote state document")
+	}
+	enc, err := base64.StdEncoding.DecodeString(env.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode remote state: %w", err)
+	}
+	plain, err := provider.Decrypt(enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt remote state (is the secret the same on every machine?): %w", err)
+	}
+	if err := json.Unmarshal(plain, state); err != nil {
+		return nil, fmt.Errorf("failed to parse remote state: %w", err)
+	}
+	if state.Sessions == nil {
+		state.Sessions = map[string]RemoteSession{}
+	}
+	if state.Renewals == nil {
+		state.Renewals = map[string]RemoteRenewal{}
+	}
+	return state, nil
+}
+
+func encodeRemoteState(state *RemoteState, provider CryptoProvider) ([]byte, error) {
+	plain, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := provider.Encrypt(plain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt remote state: %w", err)
+	}
+	return json.Marshal(remoteEnvelope{Format: remoteStateFormat, Data: base64.StdEncoding.EncodeToString(enc)})
+}
+
+// prune drops expired sessions and leases.
+func (st *RemoteState) prune(now time.Time) {
+	for key, s := range st.Sessions {
+		if !s.Expiration.After(now) {
+			delete(st.Sessions, key)
+		}
+	}
+	for profile, r := range st.Renewals {
+		if !r.Until.After(now) {
+			delete(st.Renewals, profile)
+		}
+	}
+}
+
+// SortedSessions returns the recorded sessions ordered by profile, then host.
+func (st *RemoteState) SortedSessions() []RemoteSession {
+	list := make([]RemoteSession, 0, len(st.Sessions))
+	for _, s := range st.Sessions {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Profile != list[j].Profile {
+			return list[i].Profile < list[j].Profile
+		}
+		return list[i].Host < list[j].Host
+	})
+	return list
+}
+
+// updateRemoteState reads the remote state, applies change and writes it back.
+// Expired entries are dropped on every write.
+func updateRemoteState(ctx context.Context, secret string, change func(st *RemoteState) error) (*RemoteState, error) {
+	provider, err := remoteProvider(secret)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := newRemoteBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := backend.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote state: %w", err)
+	}
+	state, err := decodeRemoteState(b, provider)
+	if err != nil {
+		return nil, err
+	}
+	state.prune(time.Now())
+	if err := change(state); err != nil {
+		return state, err
+	}
+	out, err := encodeRemoteState(state, provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Put(ctx, out); err != nil {
+		return nil, fmt.Errorf("failed to write remote state: %w", err)
+	}
+	return state, nil
+}
+
+// LoadRemoteState reads and decrypts the remote state, without expired entries.
+func LoadRemoteState(ctx context.Context, secret string) (*RemoteState, error) {
+	provider, err := remoteProvider(secret)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := newRemoteBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := backend.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote state: %w", err)
+	}
+	state, err := decodeRemoteState(b, provider)
+	if err != nil {
+		return nil, err
+	}
+	state.prune(time.Now())
+	return state, nil
+}
+
+// PublishRemoteSession records a freshly minted session for this machine and releases
+// this machine's renewal lease on the profile. It does nothing without remote.url.
+func PublishRemoteSession(ctx context.Context, s *AWSSession, secret string) error {
+	if !RemoteStateEnabled() {
+		return nil
+	}
+	host := RemoteHost()
+	_, err := updateRemoteState(ctx, secret, func(st *RemoteState) error {
+		st.Sessions[host+"/"+s.Profile] = RemoteSession{
+			Profile:    s.Profile,
+			Host:       host,
+			RoleArn:    s.RoleArn,
+			AccountID:  SessionAccountID(s),
+			MintedAt:   time.Now().UTC().Truncate(time.Second),
+			Expiration: s.Expiration.UTC(),
+		}
+		if r, ok := st.Renewals[s.Profile]; ok && r.Host == host {
+			delete(st.Renewals, s.Profile)
+		}
+		return nil
+	})
+	return err
+}
+
+// UnpublishRemoteSessions removes this machine's entries for profiles, e.g. on logout.
+func UnpublishRemoteSessions(ctx context.Context, profiles []string, secret string) error {
+	if !RemoteStateEnabled() || len(profiles) == 0 {
+		return nil
+	}
+	host := RemoteHost()
+	_, err := updateRemoteState(ctx, secret, func(st *RemoteState) error {
+		for _, profile := range profiles {
+			delete(st.Sessions, host+"/"+profile)
+		}
+		return nil
+	})
+	return err
+}
+
+// AcquireRenewal takes the renewal lease on a profile before refreshing it, so two
+// machines don't renew the same profile at once. It returns a *RenewalInProgressError
+// while another machine holds the lease. Remote storage offers no locking, so a
+// re-read after the write catches most (not all) simultaneous claims.
+func AcquireRenewal(ctx context.Context, profile, secret string) error {
+	if !RemoteStateEnabled() {
+		return nil
+	}
+	host := RemoteHost()
+	now := time.Now()
+	_, err := updateRemoteState(ctx, secret, func(st *RemoteState) error {
+		if r, ok := st.Renewals[profile]; ok && r.Host != host && r.Until.After(now) {
+			return &RenewalInProgressError{Profile: profile, Host: r.Host, Until: r.Until}
+		}
+		st.Renewals[profile] = RemoteRenewal{Host: host, Until: now.Add(RenewalLease).UTC().Truncate(time.Second)}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	state, err := LoadRemoteState(ctx, secret)
+	if err != nil {
+		return err
+	}
+	if r, ok := state.Renewals[profile]; ok && r.Host != host {
+		return &RenewalInProgressError{Profile: profile, Host: r.Host, Until: r.Until}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryBackend stands in for S3 or SSM.
+type memoryBackend struct {
+	data []byte
+}
+
+func (b *memoryBackend) Get(ctx context.Context) ([]byte, error) { return b.data, nil }
+
+func (b *memoryBackend) Put(ctx context.Context, data []byte) error {
+	b.data = data
+	return nil
+}
+
+func setupRemoteState(t *testing.T, host string) *memoryBackend {
+	t.Helper()
+	setTestConfig(t, func(c *Config) {
+		c.Remote = RemoteConfig{URL: "s3://team-bucket/cloudctl/state.json", Host: host}
+	})
+
+	backend := &memoryBackend{}
+	originalBackend := newRemoteBackend
+	newRemoteBackend = func(ctx context.Context) (remoteBackend, error) { return backend, nil }
+	t.Cleanup(func() { newRemoteBackend = originalBackend })
+	return backend
+}
+
+func TestRemoteStatePublishAndRenewal(t *testing.T) {
+	backend := setupRemoteState(t, "laptop")
+	ctx := context.Background()
+	secret := "1234567890ABCDEF1234567890ABCDEF"
+	s := &AWSSession{Profile: "prod", RoleArn: "arn:aws:iam::123456789012:role/Admin", Expiration: time.Now().Add(time.Hour)}
+
+	if err := AcquireRenewal(ctx, "prod", secret); err != nil {
+		t.Fatalf("AcquireRenewal failed: %v", err)
+	}
+	if err := PublishRemoteSession(ctx, s, secret); err != nil {
+		t.Fatalf("PublishRemoteSession failed: %v", err)
+	}
+	if strings.Contains(string(backend.data), "123456789012") {
+		t.Error("remote state is stored in plain text")
+	}
+
+	state, err := LoadRemoteState(ctx, secret)
+	if err != nil {
+		t.Fatalf("LoadRemoteState failed: %v", err)
+	}
+	got, ok := state.Sessions["laptop/prod"]
+	if !ok || got.AccountID != "123456789012" || got.Host != "laptop" {
+		t.Fatalf("Sessions = %+v", state.Sessions)
+	}
+	if len(state.Renewals) != 0 {
+		t.Errorf("publishing should release the lease, got %+v", state.Renewals)
+	}
+
+	// Another machine can't refresh while the laptop holds the lease
+	if err := AcquireRenewal(ctx, "prod", secret); err != nil {
+		t.Fatal(err)
+	}
+	loadedConfig.Remote.Host = "jumpbox"
+	err = AcquireRenewal(ctx, "prod", secret)
+	var busy *RenewalInProgressError
+	if !errors.As(err, &busy) || busy.Host != "laptop" {
+		t.Fatalf("expected the laptop's lease to block, got %v", err)
+	}
+	if err := AcquireRenewal(ctx, "dev", secret); err != nil {
+		t.Errorf("other profiles should not be blocked: %v", err)
+	}
+
+	if _, err := LoadRemoteState(ctx, "FEDCBA0987654321FEDCBA0987654321"); err == nil {
+		t.Error("expected an error with a different secret")
+	}
+}
+
+func TestRemoteStatePrune(t *testing.T) {
+	now := time.Now()
+	st := &RemoteState{
+		Sessions: map[string]RemoteSession{
+			"a/old": {Profile: "old", Host: "a", Expiration: now.Add(-time.Minute)},
+			"a/new": {Profile: "new", Host: "a", Expiration: now.Add(time.Hour)},
+		},
+		Renewals: map[string]RemoteRenewal{"old": {Host: "a", Until: now.Add(-time.Second)}},
+	}
+	st.prune(now)
+	if len(st.Sessions) != 1 || len(st.Renewals) != 0 {
+		t.Errorf("prune left %+v", st)
+	}
+}
+
+func TestValidateRemoteURL(t *testing.T) {
+	for _, u := range []string{"s3://bucket/cloudctl/state.json", "ssm:/cloudctl/state"} {
+		if err := ValidateRemoteURL(u); err != nil {
+			t.Errorf("%s: unexpected error %v", u, err)
+		}
+	}
+	for _, u := range []string{"s3://bucket", "ssm:", "https://example.com/state"} {
+		if err := ValidateRemoteURL(u); err == nil {
+			t.Errorf("%s: expected an error", u)
+		}
+	}
+}
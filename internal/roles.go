@@ -0,0 +1,248 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var roleStorePath = filepath.Join(storeDir, "roles.json")
+
+// RoleAlias is a named IAM role plus the defaults used when logging in through it.
+type RoleAlias struct {
+	ARN string `json:"arn"`
+	// Description is free text shown in `role list`.
+	Description string `json:"description,omitempty"`
+	// Region is used by login when --region is not given.
+	Region string `json:"region,omitempty"`
+	// Duration is the session duration in seconds used when --duration is not given.
+	Duration int32 `json:"duration,omitempty"`
+	// MFARequired makes login ask for an MFA device when the source is not already an MFA session.
+	MFARequired bool `json:"mfa_required,omitempty"`
+	// Color highlights the alias in listings ("#RRGGBB" or an ANSI color number).
+	Color string `json:"color,omitempty"`
+	// Group organizes aliases (e.g. by client or environment) in listings and the login picker.
+	Group string `json:"group,omitempty"`
+}
+
+// hasMetadata reports whether anything beyond the ARN is set.
+func (r RoleAlias) hasMetadata() bool {
+	return r.Description != "" || r.Region != "" || r.Duration != 0 || r.MFARequired || r.Color != "" || r.Group != ""
+}
+
+// MarshalJSON keeps aliases without metadata in the original "name": "arn" form so
+// roles.json stays readable by older versions.
+func (r RoleAlias) MarshalJSON() ([]byte, error) {
+	if !r.hasMetadata() {
+		return json.Marshal(r.ARN)
+	}
+	type plain RoleAlias
+	return json.Marshal(plain(r))
+}
+
+// UnmarshalJSON accepts both the legacy string form and the object form.
+func (r *RoleAlias) UnmarshalJSON(b []byte) error {
+	var arn string
+	if err := json.Unmarshal(b, &arn); err == nil {
+		*r = RoleAlias{ARN: arn}
+		return nil
+	}
+	type plain RoleAlias
+	var p plain
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	*r = RoleAlias(p)
+	return nil
+}
+
+// AccountID returns the account ID embedded in the role ARN, or "".
+func (r RoleAlias) AccountID() string {
+	return RoleAccountID(r.ARN)
+}
+
+// Validate checks the metadata values.
+func (r RoleAlias) Validate() error {
+	if r.ARN == "" {
+		return fmt.Errorf("role ARN is required")
+	}
+	if r.Duration != 0 && (r.Duration < 900 || r.Duration > 43200) {
+		return fmt.Errorf("duration must be between 900 and 43200 seconds")
+	}
+	if err := validateColor(r.Color); err != nil {
+		return fmt.Errorf("color: %w", err)
+	}
+	return nil
+}
+
+// SaveRoleAlias persists a role alias, replacing any existing one with the same name.
+func SaveRoleAlias(name string, alias RoleAlias) error {
+	if err := alias.Validate(); err != nil {
+		return err
+	}
+	roles, err := ListRoleAliases()
+	if err != nil {
+		return err
+	}
+	roles[name] = alias
+	return SaveAllRoleAliases(roles)
+}
+
+// SaveRole persists an IAM Role ARN with an alias, keeping any existing metadata.
+func SaveRole(name, arn string) error {
+	roles, err := ListRoleAliases()
+	if err != nil {
+		roles = make(map[string]RoleAlias)
+	}
+	alias := roles[name]
+	alias.ARN = arn
+	roles[name] = alias
+	return SaveAllRoleAliases(roles)
+}
+
+// SaveAllRoleAliases overwrites the entire role alias store.
+func SaveAllRoleAliases(roles map[string]RoleAlias) error {
+	if err := os.MkdirAll(filepath.Dir(roleStorePath), 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	b, err := json.MarshalIndent(roles, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal roles: %w", err)
+	}
+	before, _ := ListRoleAliases()
+	if err := os.WriteFile(roleStorePath, b, 0600); err != nil {
+		return err
+	}
+	touchSyncItems(SyncRole, changedSyncNames(before, roles)...)
+	return nil
+}
+
+// ListRoleAliases returns all stored IAM role aliases with their metadata.
+func ListRoleAliases() (map[string]RoleAlias, error) {
+	roles := make(map[string]RoleAlias)
+	b, err := os.ReadFile(roleStorePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return roles, nil
+		}
+		return nil, fmt.Errorf("failed to read roles store: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &roles); err != nil {
+		return nil, fmt.Errorf("failed to parse roles store: %w", err)
+	}
+	return roles, nil
+}
+
+// ListRoles returns all stored IAM role aliases as name → ARN.
+func ListRoles() (map[string]string, error) {
+	aliases, err := ListRoleAliases()
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string]string, len(aliases))
+	for name, alias := range aliases {
+		roles[name] = alias.ARN
+	}
+	return roles, nil
+}
+
+// RemoveRole deletes an IAM role alias.
+func RemoveRole(name string) error {
+	roles, err := ListRoleAliases()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := roles[name]; !ok {
+		return fmt.Errorf("role '%s' not found", name)
+	}
+
+	delete(roles, name)
+
+	if len(roles) == 0 {
+		return ClearAllRoles()
+	}
+
+	return SaveAllRoleAliases(roles)
+}
+
+// ClearAllRoles removes the entire role alias file.
+func ClearAllRoles() error {
+	before, _ := ListRoleAliases()
+	if err := os.Remove(roleStorePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear roles: %w", err)
+	}
+	touchSyncItems(SyncRole, changedSyncNames(before, nil)...)
+	return nil
+}
+
+// GetRoleAlias retrieves a role alias with its metadata.
+func GetRoleAlias(name string) (RoleAlias, bool) {
+	roles, _ := ListRoleAliases()
+	alias, ok := roles[name]
+	return alias, ok
+}
+
+// GetRole retrieves an IAM Role ARN by its alias.
+func GetRole(name string) (string, bool) {
+	alias, ok := GetRoleAlias(name)
+	return alias.ARN, ok
+}
+
+// RoleGroups returns the distinct group names in sorted order. Ungrouped aliases are not
+// represented; check for an empty Group separately.
+func RoleGroups(roles map[string]RoleAlias) []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, r := range roles {
+		if r.Group != "" && !seen[r.Group] {
+			seen[r.Group] = true
+			groups = append(groups, r.Group)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// FilterRolesByGroup returns the aliases in group. An empty group matches ungrouped aliases.
+func FilterRolesByGroup(roles map[string]RoleAlias, group string) map[string]RoleAlias {
+	filtered := make(map[string]RoleAlias)
+	for name, r := range roles {
+		if strings.EqualFold(r.Group, group) {
+			filtered[name] = r
+		}
+	}
+	return filtered
+}
+
+// switchRoleDisplayMax is the longest display name the console's role switcher shows.
+const switchRoleDisplayMax = 64
+
+// SwitchRoleURL returns the console link that adds roleArn to the console's own role
+// switcher, labelled displayName. A "#RRGGBB" color is passed on; ANSI color numbers
+// have no console equivalent and are left out.
+func SwitchRoleURL(roleArn, displayName, color string) (string, error) {
+	role, err := ParseRoleARN(roleArn)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	params.Set("account", role.AccountID)
+	// Roles with a path are switched to by path and name
+	params.Set("roleName", strings.TrimPrefix(role.Path(), "/")+role.Name())
+	if displayName != "" {
+		if r := []rune(displayName); len(r) > switchRoleDisplayMax {
+			displayName = string(r[:switchRoleDisplayMax])
+		}
+		params.Set("displayName", displayName)
+	}
+	if hexColorPattern.MatchString(color) {
+		params.Set("color", strings.ToUpper(strings.TrimPrefix(color, "#")))
+	}
+	return "https://signin.aws.amazon.com/switchrole?" + params.Encode(), nil
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Helper to point the role alias store at a temp directory
+func setupTestRoles(t *testing.T, content string) {
+	dir := t.TempDir()
+	originalPath := roleStorePath
+	roleStorePath = filepath.Join(dir, "roles.json")
+	t.Cleanup(func() {
+		roleStorePath = originalPath
+	})
+
+	if content != "" {
+		if err := os.WriteFile(roleStorePath, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write roles: %v", err)
+		}
+	}
+}
+
+func TestListRoleAliasesLegacyFormat(t *testing.T) {
+	setupTestRoles(t, `{"prod": "arn:aws:iam::123456789012:role/Admin"}`)
+
+	roles, err := ListRoleAliases()
+	if err != nil {
+		t.Fatalf("ListRoleAliases failed: %v", err)
+	}
+	if roles["prod"].ARN != "arn:aws:iam::123456789012:role/Admin" {
+		t.Errorf("Unexpected ARN: %q", roles["prod"].ARN)
+	}
+	if roles["prod"].AccountID() != "123456789012" {
+		t.Errorf("Unexpected account: %q", roles["prod"].AccountID())
+	}
+}
+
+func TestSaveRoleAliasMetadata(t *testing.T) {
+	setupTestRoles(t, "")
+
+	if err := SaveRole("plain", "arn:aws:iam::111111111111:role/ReadOnly"); err != nil {
+		t.Fatalf("SaveRole failed: %v", err)
+	}
+	alias := RoleAlias{
+		ARN:         "arn:aws:iam::123456789012:role/Admin",
+		Description: "Production admin",
+		Region:      "us-east-1",
+		Duration:    1800,
+		MFARequired: true,
+		Color:       "#D0021B",
+	}
+	if err := SaveRoleAlias("prod", alias); err != nil {
+		t.Fatalf("SaveRoleAlias failed: %v", err)
+	}
+
+	got, ok := GetRoleAlias("prod")
+	if !ok || got != alias {
+		t.Errorf("Round trip mismatch: %+v", got)
+	}
+
+	// Aliases without metadata stay in the legacy string form
+	b, _ := os.ReadFile(roleStorePath)
+	if !strings.Contains(string(b), `"plain": "arn:aws:iam::111111111111:role/ReadOnly"`) {
+		t.Errorf("Expected legacy form for plain alias, got:\n%s", b)
+	}
+
+	// Updating only the ARN keeps metadata
+	if err := SaveRole("prod", "arn:aws:iam::123456789012:role/Admin2"); err != nil {
+		t.Fatalf("SaveRole failed: %v", err)
+	}
+	got, _ = GetRoleAlias("prod")
+	if got.Region != "us-east-1" || got.ARN != "arn:aws:iam::123456789012:role/Admin2" {
+		t.Errorf("Metadata lost on ARN update: %+v", got)
+	}
+}
+
+func TestRoleAliasValidate(t *testing.T) {
+	arn := "arn:aws:iam::123456789012:role/Admin"
+	invalid := []RoleAlias{
+		{},
+		{ARN: arn, Duration: 60},
+		{ARN: arn, Color: "red"},
+	}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("Expected validation error for %+v", r)
+		}
+	}
+	if err := (RoleAlias{ARN: arn, Duration: 3600, Color: "9"}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRoleGroups(t *testing.T) {
+	roles := map[string]RoleAlias{
+		"pay-prod": {ARN: "arn:aws:iam::111111111111:role/Admin", Group: "payments"},
+		"pay-dev":  {ARN: "arn:aws:iam::222222222222:role/Admin", Group: "payments"},
+		"web-prod": {ARN: "arn:aws:iam::333333333333:role/Admin", Group: "web"},
+		"sandbox":  {ARN: "arn:aws:iam::444444444444:role/Admin"},
+	}
+
+	groups := RoleGroups(roles)
+	if strings.Join(groups, ",") != "payments,web" {
+		t.Errorf("Unexpected groups: %v", groups)
+	}
+	if n := len(FilterRolesByGroup(roles, "Payments")); n != 2 {
+		t.Errorf("Expected 2 payments roles (case-insensitive), got %d", n)
+	}
+	ungrouped := FilterRolesByGroup(roles, "")
+	if _, ok := ungrouped["sandbox"]; !ok || len(ungrouped) != 1 {
+		t.Errorf("Unexpected ungrouped roles: %v", ungrouped)
+	}
+}
+
+func TestSwitchRoleURL(t *testing.T) {
+	got, err := SwitchRoleURL("arn:aws:iam::123456789012:role/ops/Admin", "prod admin", "#f2b0a9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://signin.aws.amazon.com/switchrole?account=123456789012&color=F2B0A9&displayName=prod+admin&roleName=ops%2FAdmin"
+	if got != want {
+		t.Errorf("SwitchRoleURL =\n%s\nwant\n%s", got, want)
+	}
+
+	got, _ = SwitchRoleURL("arn:aws:iam::123456789012:role/Admin", strings.Repeat("x", 70), "208")
+	if strings.Contains(got, "color=") || strings.Contains(got, strings.Repeat("x", 65)) {
+		t.Errorf("Expected ANSI colors to be dropped and the name cut to 64 characters: %s", got)
+	}
+	if _, err := SwitchRoleURL("arn:aws:iam::123456789012:user/alice", "", ""); err == nil {
+		t.Error("Expected a user ARN to be rejected")
+	}
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// MaxRootSessionSeconds is the longest session sts:AssumeRoot issues.
+const MaxRootSessionSeconds = 900
+
+// RootTasks are the AWS managed task policies a root session can be scoped to.
+var RootTasks = []string{
+	"IAMAuditRootUserCredentials",
+	"IAMCreateRootUserPassword",
+	"IAMDeleteRootUserCredentials",
+	"S3UnlockBucketPolicy",
+	"SQSUnlockQueuePolicy",
+}
+
+// RootTaskPolicyArn returns the task policy ARN for a task name from RootTasks
+// (case-insensitive) or a full root-task policy ARN.
+func RootTaskPolicyArn(task string) (string, error) {
+	if strings.HasPrefix(task, "arn:") {
+		if !strings.Contains(task, ":policy/root-task/") {
+			return "", fmt.Errorf("'%s' is not a root-task policy ARN", task)
+		}
+		return task, nil
+	}
+	for _, t := range RootTasks {
+		if strings.EqualFold(t, task) {
+			return "arn:aws:iam::aws:policy/root-task/" + t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown task '%s' (one of %s, or a task policy ARN)", task, strings.Join(RootTasks, ", "))
+}
+
+// RootTaskName returns the name of a task policy ARN, e.g. "S3UnlockBucketPolicy".
+func RootTaskName(taskArn string) string {
+	return taskArn[strings.LastIndex(taskArn, "/")+1:]
+}
+
+// RootSessionArn is the principal stored as the RoleArn of a root session.
+func RootSessionArn(accountID string) string {
+	return "arn:aws:iam::" + accountID + ":root"
+}
+
+// IsRoot reports whether the session came from sts:AssumeRoot.
+func (s *AWSSession) IsRoot() bool {
+	return s.RootTask != ""
+}
+
+// AssumeRoot starts a privileged root session in a member account, scoped to one task
+// policy. cfg holds the credentials of the management account or the delegated
+// administrator for centralized root access, and must use a regional STS endpoint.
+func AssumeRoot(ctx context.Context, cfg aws.Config, profile, accountID, taskArn string, duration int32) (*AWSSession, error) {
+	if !accountIDPattern.MatchString(accountID) {
+		return nil, fmt.Errorf("account must be a 12-digit account ID")
+	}
+	if duration <= 0 || duration > MaxRootSessionSeconds {
+		return nil, fmt.Errorf("duration must be between 1 and %d seconds", MaxRootSessionSeconds)
+	}
+	res, err := NewSTSClient(cfg, profile).AssumeRoot(ctx, &sts.AssumeRootInput{
+		TargetPrincipal: aws.String(accountID),
+		TaskPolicyArn:   &types.PolicyDescriptorType{Arn: aws.String(taskArn)},
+		DurationSeconds: aws.Int32(duration),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AWSSession{
+		Profile:      profile,
+		AccessKey:    aws.ToString(res.Credentials.AccessKeyId),
+		SecretKey:    aws.ToString(res.Credentials.SecretAccessKey),
+		SessionToken: aws.ToString(res.Credentials.SessionToken),
+		Expiration:   aws.ToTime(res.Credentials.Expiration),
+		RoleArn:      RootSessionArn(accountID),
+		Region:       cfg.Region,
+		Duration:     duration,
+		AccountID:    accountID,
+		RootTask:     taskArn,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestRootTaskPolicyArn(t *testing.T) {
+	tests := []struct {
+		task    string
+		want    string
+		wantErr bool
+	}{
+		{"S3UnlockBucketPolicy", "arn:aws:iam::aws:policy/root-task/S3UnlockBucketPolicy", false},
+		{"iamauditrootusercredentials", "arn:aws:iam::aws:policy/root-task/IAMAuditRootUserCredentials", false},
+		{"arn:aws-us-gov:iam::aws:policy/root-task/SQSUnlockQueuePolicy", "arn:aws-us-gov:iam::aws:policy/root-task/SQSUnlockQueuePolicy", false},
+		{"arn:aws:iam::aws:policy/AdministratorAccess", "", true},
+		{"DeleteEverything", "", true},
+	}
+	for _, tt := range tests {
+		got, err := RootTaskPolicyArn(tt.task)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("RootTaskPolicyArn(%s) = %q, %v; want %q", tt.task, got, err, tt.want)
+		}
+	}
+	if got := RootTaskName("arn:aws:iam::aws:policy/root-task/S3UnlockBucketPolicy"); got != "S3UnlockBucketPolicy" {
+		t.Errorf("RootTaskName = %s", got)
+	}
+}
+
+func TestAssumeRoot(t *testing.T) {
+	originalLog := auditLogPath
+	auditLogPath = filepath.Join(t.TempDir(), "audit.log")
+	t.Cleanup(func() { auditLogPath = originalLog })
+
+	expiration := time.Now().Add(15 * time.Minute).UTC().Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("Action") != "AssumeRoot" || r.Form.Get("TargetPrincipal") != "123456789012" ||
+			r.Form.Get("TaskPolicyArn.arn") != "arn:aws:iam::aws:policy/root-task/S3UnlockBucketPolicy" || r.Form.Get("DurationSeconds") != "600" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<AssumeRootResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRootResult>
+<Credentials><AccessKeyId>ASIAROOT</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken><Expiration>%s</Expiration></Credentials>
+</AssumeRootResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></AssumeRootResponse>`, expiration.Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	cfg := aws.Config{
+		Region:       "eu-west-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKIAORG", "any", ""),
+	}
+	taskArn := "arn:aws:iam::aws:policy/root-task/S3UnlockBucketPolicy"
+	s, err := AssumeRoot(context.Background(), cfg, "root-prod", "123456789012", taskArn, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.AccessKey != "ASIAROOT" || !s.Expiration.Equal(expiration) || s.RoleArn != "arn:aws:iam::123456789012:root" ||
+		SessionAccountID(s) != "123456789012" || !s.IsRoot() || s.Region != "eu-west-1" {
+		t.Errorf("Unexpected session: %+v", s)
+	}
+
+	if _, err := AssumeRoot(context.Background(), cfg, "root-prod", "123456789012", taskArn, 3600); err == nil {
+		t.Error("Expected an error for a duration over 15 minutes")
+	}
+	if _, err := AssumeRoot(context.Background(), cfg, "root-prod", "prod", taskArn, 600); err == nil {
+		t.Error("Expected an error for an invalid account ID")
+	}
+
+	s.SourceProfile = "org-admin"
+	if _, err := PerformRefresh(context.Background(), s, "", "eu-west-1"); err == nil {
+		t.Error("Expected root sessions to refuse a silent refresh")
+	}
+}
@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a standard 5-field cron expression: minute hour day-of-month month day-of-week.
+// Fields accept *, lists (1,15), ranges (1-5) and steps (*/10, 0-30/5). Day-of-week is 0-6
+// with 0 (or 7) as Sunday. As in cron, when both day fields are restricted either may match.
+type CronSchedule struct {
+	expr    string
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+// ParseCron parses a 5-field cron expression.
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression '%s' must have 5 fields (minute hour day month weekday)", expr)
+	}
+
+	c := &CronSchedule{expr: expr}
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// 7 is an alias for Sunday
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = fields[2] == "*"
+	c.dowStar = fields[4] == "*"
+	return c, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step in '%s'", part)
+			}
+			step = s
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value '%s'", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value '%s'", part)
+				}
+			} else if step > 1 {
+				// "5/15" means from 5 to the end of the range
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("'%s' is out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// String returns the original expression.
+func (c *CronSchedule) String() string {
+	return c.expr
+}
+
+// Matches reports whether t (to the minute) is a scheduled time.
+func (c *CronSchedule) Matches(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	return c.dayMatches(t)
+}
+
+// Next returns the first scheduled time strictly after t, in t's location. It gives up
+// after five years, which only happens for impossible dates like "0 0 31 2 *".
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		if c.month&(1<<uint(next.Month())) == 0 {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !c.dayMatches(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if c.hour&(1<<uint(next.Hour())) == 0 {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+		if c.minute&(1<<uint(next.Minute())) == 0 {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
+
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// NextClockTime returns the next occurrence of a "15:04" wall-clock time after now, in loc.
+func NextClockTime(clock string, now time.Time, loc *time.Location) (time.Time, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s' (use HH:MM, e.g. 08:45)", clock)
+	}
+	now = now.In(loc)
+	next := time.Date(now.Year(), now.Month(), now.Day(), parsed.Hour(), parsed.Minute(), 0, 0, loc)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
+
+// ScheduledRefresh is a parsed daemon.schedules entry.
+type ScheduledRefresh struct {
+	Profile string
+	Cron    *CronSchedule
+}
+
+// ParseSchedules parses and validates daemon.schedules entries.
+func ParseSchedules(entries []RefreshSchedule) ([]ScheduledRefresh, error) {
+	schedules := make([]ScheduledRefresh, 0, len(entries))
+	for i, e := range entries {
+		if strings.TrimSpace(e.Profile) == "" {
+			return nil, fmt.Errorf("entry %d: profile is required", i+1)
+		}
+		c, err := ParseCron(e.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d (%s): %w", i+1, e.Profile, err)
+		}
+		schedules = append(schedules, ScheduledRefresh{Profile: e.Profile, Cron: c})
+	}
+	return schedules, nil
+}
+
+// DueProfiles returns the profiles with a scheduled time in (since, now], evaluated in loc.
+// Each profile appears once even if several of its schedules fired.
+func DueProfiles(schedules []ScheduledRefresh, since, now time.Time, loc *time.Location) []string {
+	seen := make(map[string]bool)
+	var due []string
+	for _, s := range schedules {
+		next := s.Cron.Next(since.In(loc))
+		if next.IsZero() || next.After(now) || seen[s.Profile] {
+			continue
+		}
+		seen[s.Profile] = true
+		due = append(due, s.Profile)
+	}
+	return due
+}
+
+// NextScheduledRefresh returns the earliest scheduled time after t in loc, or the zero
+// time when there are no schedules.
+func NextScheduledRefresh(schedules []ScheduledRefresh, t time.Time, loc *time.Location) time.Time {
+	var earliest time.Time
+	for _, s := range schedules {
+		next := s.Cron.Next(t.In(loc))
+		if !next.IsZero() && (earliest.IsZero() || next.Before(earliest)) {
+			earliest = next
+		}
+	}
+	return earliest
+}
+
+// SleepUntil blocks until the wall clock reaches t. It re-checks the wall clock every
+// 30 seconds because a plain sleep on the monotonic clock overshoots after the machine
+// has been suspended.
+func SleepUntil(t time.Time) {
+	for {
+		remaining := t.Sub(time.Now().Round(0))
+		if remaining <= 0 {
+			return
+		}
+		time.Sleep(min(remaining, 30*time.Second))
+	}
+}
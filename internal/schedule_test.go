@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	loc := time.UTC
+	// Thursday 2026-10-15 09:00
+	from := time.Date(2026, 10, 15, 9, 0, 0, 0, loc)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"45 8 * * *", time.Date(2026, 10, 16, 8, 45, 0, 0, loc)},
+		{"45 8 * * 1-5", time.Date(2026, 10, 16, 8, 45, 0, 0, loc)},
+		{"0 2 * * 0", time.Date(2026, 10, 18, 2, 0, 0, 0, loc)},
+		{"0 2 * * 7", time.Date(2026, 10, 18, 2, 0, 0, 0, loc)},
+		{"*/20 * * * *", time.Date(2026, 10, 15, 9, 20, 0, 0, loc)},
+		{"30 22 1 * *", time.Date(2026, 11, 1, 22, 30, 0, 0, loc)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, loc)},
+		// Both day fields restricted: either matches (the 20th or the next Monday)
+		{"0 6 20 * 1", time.Date(2026, 10, 19, 6, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextImpossible(t *testing.T) {
+	c, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Expected no next time, got %v", got)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
+
+func TestNextClockTime(t *testing.T) {
+	loc := time.FixedZone("ICT", 7*3600)
+	now := time.Date(2026, 10, 15, 7, 0, 0, 0, loc)
+
+	got, err := NextClockTime("08:45", now, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 15, 8, 45, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got, _ = NextClockTime("06:00", now, loc)
+	if want := time.Date(2026, 10, 16, 6, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("Expected tomorrow %v, got %v", want, got)
+	}
+
+	if _, err := NextClockTime("8.45", now, loc); err == nil {
+		t.Error("Expected error for invalid time")
+	}
+}
+
+func TestDueProfiles(t *testing.T) {
+	schedules, err := ParseSchedules([]RefreshSchedule{
+		{Profile: "prod", Cron: "45 8 * * 1-5"},
+		{Profile: "prod", Cron: "50 8 * * *"},
+		{Profile: "nightly", Cron: "0 1 * * *"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc := time.UTC
+	since := time.Date(2026, 10, 15, 8, 40, 0, 0, loc)
+	now := time.Date(2026, 10, 15, 8, 55, 0, 0, loc)
+	due := DueProfiles(schedules, since, now, loc)
+	if len(due) != 1 || due[0] != "prod" {
+		t.Errorf("Expected [prod], got %v", due)
+	}
+
+	if next := NextScheduledRefresh(schedules, now, loc); !next.Equal(time.Date(2026, 10, 16, 1, 0, 0, 0, loc)) {
+		t.Errorf("Unexpected next scheduled refresh %v", next)
+	}
+
+	if _, err := ParseSchedules([]RefreshSchedule{{Cron: "0 1 * * *"}}); err == nil {
+		t.Error("Expected error for missing profile")
+	}
+}
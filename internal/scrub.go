@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scrubMaxDepth bounds how deep project directories are searched for .env files.
+const scrubMaxDepth = 4
+
+// scrubSkipDirs are never descended into when looking for .env files.
+var scrubSkipDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, ".terraform": true,
+	".venv": true, "venv": true, "__pycache__": true, "dist": true, "build": true,
+}
+
+// ScrubSecret is a credential value of an expired session to look for.
+type ScrubSecret struct {
+	Profile string
+	// Kind is "access key", "secret key" or "session token".
+	Kind  string
+	Value string
+}
+
+// ScrubFinding is one line of a file that contains an expired session's credential.
+type ScrubFinding struct {
+	Path    string
+	Line    int
+	Profile string
+	Kinds   []string
+}
+
+// ExpiredSessionSecrets returns the credentials of sessions that expired before now.
+// Active sessions are left alone: redacting them would not make them any less valid.
+func ExpiredSessionSecrets(sessions []*AWSSession, now time.Time) []ScrubSecret {
+	var secrets []ScrubSecret
+	for _, s := range sessions {
+		if s.Expiration.After(now) {
+			continue
+		}
+		for _, c := range []ScrubSecret{
+			{Profile: s.Profile, Kind: "access key", Value: s.AccessKey},
+			{Profile: s.Profile, Kind: "secret key", Value: s.SecretKey},
+			{Profile: s.Profile, Kind: "session token", Value: s.SessionToken},
+		} {
+			// Very short values would match unrelated text
+			if len(c.Value) >= 16 {
+				secrets = append(secrets, c)
+			}
+		}
+	}
+	return secrets
+}
+
+// ScrubCandidates lists the files scrub looks at: shell histories, .env files in the
+// project directories, and backups of the AWS CLI files next to ~/.aws/credentials.
+// Files that don't exist are left out.
+func ScrubCandidates(home string, projectDirs []string) []string {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, name := range []string{
+		".bash_history", ".zsh_history", ".zhistory", ".history", ".sh_history",
+		".local/share/fish/fish_history",
+		"AppData/Roaming/Microsoft/Windows/PowerShell/PSReadLine/ConsoleHost_history.txt",
+		".local/share/powershell/PSReadLine/ConsoleHost_history.txt",
+	} {
+		add(filepath.Join(home, filepath.FromSlash(name)))
+	}
+	if histfile := os.Getenv("HISTFILE"); histfile != "" {
+		add(histfile)
+	}
+
+	// The live config and credentials files are managed by `cloudctl sync`
+	if entries, err := os.ReadDir(filepath.Join(home, ".aws")); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() && e.Name() != "config" && e.Name() != "credentials" {
+				add(filepath.Join(home, ".aws", e.Name()))
+			}
+		}
+	}
+
+	for _, dir := range projectDirs {
+		root := filepath.Clean(dir)
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				depth := strings.Count(strings.TrimPrefix(path, root), string(filepath.Separator))
+				if path != root && (scrubSkipDirs[d.Name()] || depth > scrubMaxDepth) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if isEnvFile(d.Name()) {
+				add(path)
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// isEnvFile matches .env, .env.local, .envrc and production.env style names.
+func isEnvFile(name string) bool {
+	return name == ".env" || name == ".envrc" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env")
+}
+
+// ScanForSecrets reports each line of path that contains one of secrets.
+func ScanForSecrets(path string, secrets []ScrubSecret) ([]ScrubFinding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var findings []ScrubFinding
+	scanner := bufio.NewScanner(f)
+	// History lines with pasted tokens can be long
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		var finding *ScrubFinding
+		for _, s := range secrets {
+			if !bytes.Contains(line, []byte(s.Value)) {
+				continue
+			}
+			if finding == nil {
+				finding = &ScrubFinding{Path: path, Line: n, Profile: s.Profile}
+			}
+			finding.Kinds = append(finding.Kinds, s.Kind)
+		}
+		if finding != nil {
+			findings = append(findings, *finding)
+		}
+	}
+	return findings, scanner.Err()
+}
+
+// RedactSecretsInFile replaces every occurrence of secrets in path, keeping its
+// permissions, and returns the number of replacements. Access keys keep their prefix,
+// as RedactSecrets does. The file is replaced atomically.
+func RedactSecretsInFile(path string, secrets []ScrubSecret) (int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, s := range secrets {
+		n := bytes.Count(b, []byte(s.Value))
+		if n == 0 {
+			continue
+		}
+		replacement := RedactedPlaceholder
+		if s.Kind == "access key" {
+			replacement = s.Value[:4] + RedactedPlaceholder
+		}
+		b = bytes.ReplaceAll(b, []byte(s.Value), []byte(replacement))
+		count += n
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".scrub-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpiredSessionSecrets(t *testing.T) {
+	now := time.Now()
+	sessions := []*AWSSession{
+		{Profile: "old", AccessKey: "ASIAOLDOLDOLDOLDOLD1", SecretKey: "oldsecretoldsecretoldsecret", SessionToken: "short", Expiration: now.Add(-time.Hour)},
+		{Profile: "live", AccessKey: "ASIALIVELIVELIVELIV1", SecretKey: "livesecretlivesecret", Expiration: now.Add(time.Hour)},
+	}
+	secrets := ExpiredSessionSecrets(sessions, now)
+	if len(secrets) != 2 {
+		t.Fatalf("Expected the access and secret key of 'old' only, got %+v", secrets)
+	}
+	for _, s := range secrets {
+		if s.Profile != "old" {
+			t.Errorf("Unexpected secret of %s", s.Profile)
+		}
+	}
+}
+
+func TestScrubCandidates(t *testing.T) {
+	home := t.TempDir()
+	project := filepath.Join(home, "src", "app")
+	files := map[string]bool{
+		".bash_history":                 true,
+		".aws/credentials":              false,
+		".aws/credentials.bak":          true,
+		"src/app/.env":                  true,
+		"src/app/.env.local":            true,
+		"src/app/config/production.env": true,
+		"src/app/README.md":             false,
+		"src/app/node_modules/pkg/.env": false,
+		"src/app/.git/.env":             false,
+	}
+	for name := range files {
+		path := filepath.Join(home, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("HISTFILE", "")
+
+	found := make(map[string]bool)
+	for _, path := range ScrubCandidates(home, []string{project}) {
+		rel, _ := filepath.Rel(home, path)
+		found[filepath.ToSlash(rel)] = true
+	}
+	for name, want := range files {
+		if found[name] != want {
+			t.Errorf("%s: found = %v, want %v", name, found[name], want)
+		}
+	}
+}
+
+func TestScanAndRedactSecrets(t *testing.T) {
+	secrets := []ScrubSecret{
+		{Profile: "old", Kind: "access key", Value: "ASIAOLDOLDOLDOLDOLD1"},
+		{Profile: "old", Kind: "secret key", Value: "oldsecretoldsecretoldsecret"},
+	}
+	path := filepath.Join(t.TempDir(), ".zsh_history")
+	content := "ls\nexport AWS_ACCESS_KEY_ID=ASIAOLDOLDOLDOLDOLD1\nexport AWS_SECRET_ACCESS_KEY=oldsecretoldsecretoldsecret\nexport AWS_ACCESS_KEY_ID=ASIAOTHEROTHEROTHER1\n"
+	if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := ScanForSecrets(path, secrets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 || findings[0].Line != 2 || findings[1].Kinds[0] != "secret key" {
+		t.Fatalf("Unexpected findings: %+v", findings)
+	}
+
+	n, err := RedactSecretsInFile(path, secrets)
+	if err != nil || n != 2 {
+		t.Fatalf("RedactSecretsInFile = %d, %v", n, err)
+	}
+	b, _ := os.ReadFile(path)
+	got := string(b)
+	if strings.Contains(got, "ASIAOLDOLDOLDOLDOLD1") || strings.Contains(got, "oldsecret") {
+		t.Errorf("Secrets left in file:\n%s", got)
+	}
+	if !strings.Contains(got, "AWS_ACCESS_KEY_ID=ASIA"+RedactedPlaceholder) || !strings.Contains(got, "ASIAOTHEROTHEROTHER1") {
+		t.Errorf("Unexpected redaction:\n%s", got)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("Expected permissions to be kept, got %v", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// smSecretCache keeps the secret fetched from Secrets Manager for the rest of the
+// process, so commands that resolve the secret more than once make one call.
+var smSecretCache struct {
+	sync.Mutex
+	id, secret string
+}
+
+// secretsManagerSecret fetches the cloudctl secret from the Secrets Manager secret in
+// encryption.secrets_manager_id. ok is false when none is configured.
+func secretsManagerSecret() (secret string, ok bool, err error) {
+	enc := CurrentConfig().Encryption
+	if enc.SecretsManagerID == "" {
+		return "", false, nil
+	}
+	smSecretCache.Lock()
+	defer smSecretCache.Unlock()
+	if smSecretCache.id == enc.SecretsManagerID {
+		return smSecretCache.secret, true, nil
+	}
+
+	secret, err = fetchSecretsManagerSecret(context.Background(), enc)
+	if err != nil {
+		// Commands only report that no secret was found, so say why here
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to read the secret from Secrets Manager: %v\n", err)
+		return "", true, err
+	}
+	smSecretCache.id, smSecretCache.secret = enc.SecretsManagerID, secret
+	return secret, true, nil
+}
+
+// fetchSecretsManagerSecret calls secretsmanager:GetSecretValue with the bootstrap
+// profile. The secret must be stored as a string, the way `cloudctl secret show`
+// prints it.
+func fetchSecretsManagerSecret(ctx context.Context, enc EncryptionConfig) (string, error) {
+	var opts []func(*config.LoadOptions) error
+	if enc.SecretsManagerProfile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(enc.SecretsManagerProfile))
+	}
+	region := enc.SecretsManagerRegion
+	if a, err := ParseARN(enc.SecretsManagerID); err == nil && region == "" {
+		region = a.Region
+	}
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, append(opts, WithCallTimeout)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to load Secrets Manager bootstrap profile: %w", err)
+	}
+	if cfg.Region == "" {
+		return "", fmt.Errorf("no region for Secrets Manager (set encryption.secrets_manager_region)")
+	}
+
+	out, err := secretsmanager.NewFromConfig(cfg).GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(enc.SecretsManagerID),
+	})
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(aws.ToString(out.SecretString))
+	if value == "" {
+		return "", fmt.Errorf("secret %s has no string value", enc.SecretsManagerID)
+	}
+	return value, nil
+}
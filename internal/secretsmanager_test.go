@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecretsManagerSecret(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			t.Errorf("Unexpected target %q", r.Header.Get("X-Amz-Target"))
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request") {
+			t.Errorf("Expected a SigV4 signature for eu-west-1, got %q", r.Header.Get("Authorization"))
+		}
+		var in struct{ SecretId string }
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		if in.SecretId != "cloudctl/build-host" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","Message":"Secrets Manager can't find the specified secret."}`))
+			return
+		}
+		w.Write([]byte(`{"Name":"cloudctl/build-host","SecretString":"abcdefabcdefabcdefabcdefabcdef12\n"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	t.Setenv("AWS_PROFILE", "")
+
+	setTestConfig(t, func(c *Config) {
+		c.Encryption.SecretsManagerID = "cloudctl/build-host"
+		c.Encryption.SecretsManagerRegion = "eu-west-1"
+	})
+	t.Cleanup(func() { smSecretCache.id, smSecretCache.secret = "", "" })
+
+	secret, ok, err := secretsManagerSecret()
+	if !ok || err != nil || secret != "abcdefabcdefabcdefabcdefabcdef12" {
+		t.Fatalf("Expected the secret, got %q, %v, %v", secret, ok, err)
+	}
+	if _, _, _ = secretsManagerSecret(); calls != 1 {
+		t.Errorf("Expected the secret to be cached, got %d calls", calls)
+	}
+
+	loadedConfig.Encryption.SecretsManagerID = "missing"
+	if _, ok, err := secretsManagerSecret(); !ok || err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("Expected ResourceNotFoundException, got %v, %v", ok, err)
+	}
+
+	loadedConfig.Encryption.SecretsManagerID = ""
+	if _, ok, _ := secretsManagerSecret(); ok {
+		t.Error("Expected nothing without secrets_manager_id")
+	}
+}
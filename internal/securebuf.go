@@ -0,0 +1,53 @@
+package internal
+
+// SecureBuffer holds secret bytes such as encryption keys in memory that is locked
+// against swapping where the OS allows it and zeroed by Destroy. Go strings can't be
+// wiped, so code handling secrets should keep them in a SecureBuffer for as long as
+// possible and Destroy it as soon as it is no longer needed.
+type SecureBuffer struct {
+	b      []byte
+	locked bool
+}
+
+// NewSecureBuffer takes ownership of b; the caller must not keep other references to it.
+func NewSecureBuffer(b []byte) *SecureBuffer {
+	s := &SecureBuffer{b: b}
+	if len(b) > 0 {
+		s.locked = lockMemory(b) == nil
+	}
+	return s
+}
+
+// SecureBufferFromString copies s into a new SecureBuffer. The string itself stays in
+// ordinary memory, so call this as close to where the secret enters the process as possible.
+func SecureBufferFromString(s string) *SecureBuffer {
+	return NewSecureBuffer([]byte(s))
+}
+
+// Bytes returns the buffer contents. The slice is only valid until Destroy.
+func (s *SecureBuffer) Bytes() []byte {
+	return s.b
+}
+
+// Locked reports whether the memory is locked against swapping.
+func (s *SecureBuffer) Locked() bool {
+	return s.locked
+}
+
+// Destroy zeroes and unlocks the buffer. It is safe to call more than once.
+func (s *SecureBuffer) Destroy() {
+	if s == nil || s.b == nil {
+		return
+	}
+	Wipe(s.b)
+	if s.locked {
+		unlockMemory(s.b)
+		s.locked = false
+	}
+	s.b = nil
+}
+
+// Wipe zeroes b in place.
+func Wipe(b []byte) {
+	clear(b)
+}
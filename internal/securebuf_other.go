@@ -0,0 +1,16 @@
+//go:build !unix
+
+package internal
+
+import "errors"
+
+func lockMemory(b []byte) error {
+	return errors.New("memory locking is not supported on this platform")
+}
+
+func unlockMemory(b []byte) {}
+
+// DisableCoreDumps is a no-op where core dumps can't be limited per process.
+func DisableCoreDumps() error {
+	return nil
+}
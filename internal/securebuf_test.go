@@ -0,0 +1,34 @@
+package internal
+
+import "testing"
+
+func TestSecureBufferDestroy(t *testing.T) {
+	raw := []byte("super-secret-key")
+	buf := NewSecureBuffer(raw)
+	if string(buf.Bytes()) != "super-secret-key" {
+		t.Fatalf("Unexpected contents %q", buf.Bytes())
+	}
+
+	buf.Destroy()
+	for i, c := range raw {
+		if c != 0 {
+			t.Fatalf("Byte %d not wiped: %q", i, raw)
+		}
+	}
+	if buf.Bytes() != nil || buf.Locked() {
+		t.Error("Expected destroyed buffer to be empty and unlocked")
+	}
+
+	// Destroying twice (or a nil buffer) must be safe
+	buf.Destroy()
+	var nilBuf *SecureBuffer
+	nilBuf.Destroy()
+}
+
+func TestSecureBufferFromString(t *testing.T) {
+	buf := SecureBufferFromString("abc")
+	defer buf.Destroy()
+	if string(buf.Bytes()) != "abc" {
+		t.Errorf("Unexpected contents %q", buf.Bytes())
+	}
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package internal
+
+import "golang.org/x/sys/unix"
+
+func lockMemory(b []byte) error {
+	return unix.Mlock(b)
+}
+
+func unlockMemory(b []byte) {
+	unix.Munlock(b)
+}
+
+// DisableCoreDumps sets the soft core file size limit to zero so a crash can't write
+// decrypted credentials or the master secret to disk. The hard limit is kept, so the
+// programs cloudctl starts (exec targets, the browser, $EDITOR) can raise it again.
+func DisableCoreDumps() error {
+	var limit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_CORE, &limit); err != nil {
+		return err
+	}
+	limit.Cur = 0
+	return unix.Setrlimit(unix.RLIMIT_CORE, &limit)
+}
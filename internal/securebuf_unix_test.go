@@ -0,0 +1,28 @@
+//go:build unix
+
+package internal
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestDisableCoreDumpsKeepsHardLimit(t *testing.T) {
+	var before unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_CORE, &before); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { unix.Setrlimit(unix.RLIMIT_CORE, &before) })
+
+	if err := DisableCoreDumps(); err != nil {
+		t.Fatal(err)
+	}
+	var after unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_CORE, &after); err != nil {
+		t.Fatal(err)
+	}
+	if after.Cur != 0 || after.Max != before.Max {
+		t.Errorf("Expected soft limit 0 and hard limit %d, got %d and %d", before.Max, after.Cur, after.Max)
+	}
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+// SelfDestructed reports whether the session's `login --self-destruct` deadline has passed.
+func (s *AWSSession) SelfDestructed(now time.Time) bool {
+	return !s.SelfDestruct.IsZero() && !now.Before(s.SelfDestruct)
+}
+
+// UsableUntil is when cloudctl stops handing out the session: its self-destruct
+// deadline when one is set, else its STS expiry.
+func (s *AWSSession) UsableUntil() time.Time {
+	if !s.SelfDestruct.IsZero() && s.SelfDestruct.Before(s.Expiration) {
+		return s.SelfDestruct
+	}
+	return s.Expiration
+}
+
+// PurgeSelfDestructed deletes the sessions whose self-destruct deadline has passed,
+// from the store and from ~/.aws/credentials, and returns their profile names.
+func PurgeSelfDestructed(sessions []*AWSSession, now time.Time) ([]string, error) {
+	var purged []string
+	err := processStore.Batch(func() error {
+		for _, s := range sessions {
+			if !s.SelfDestructed(now) {
+				continue
+			}
+			if err := RemoveProfile(s.Profile); err != nil {
+				return fmt.Errorf("failed to delete '%s': %w", s.Profile, err)
+			}
+			purged = append(purged, s.Profile)
+		}
+		return nil
+	})
+	if err != nil {
+		return purged, err
+	}
+	if len(purged) > 0 {
+		if err := RemoveFromAWSCredentials(purged); err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
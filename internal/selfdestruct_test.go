@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfDestructed(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &AWSSession{Expiration: now.Add(4 * time.Hour)}
+	if s.SelfDestructed(now) || !s.UsableUntil().Equal(s.Expiration) {
+		t.Error("a session without a deadline should be usable until it expires")
+	}
+
+	s.SelfDestruct = now.Add(2 * time.Hour)
+	if s.SelfDestructed(now) {
+		t.Error("deadline has not passed yet")
+	}
+	if !s.SelfDestructed(s.SelfDestruct) {
+		t.Error("session should self-destruct at its deadline")
+	}
+	if !s.UsableUntil().Equal(s.SelfDestruct) {
+		t.Errorf("UsableUntil = %v, want %v", s.UsableUntil(), s.SelfDestruct)
+	}
+}
+
+func TestPurgeSelfDestructed(t *testing.T) {
+	dir := setupTestDir(t)
+	t.Setenv("HOME", dir)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	now := time.Now()
+
+	gone := &AWSSession{Profile: "gone", AccessKey: "k1", Expiration: now.Add(time.Hour), SelfDestruct: now.Add(-time.Minute)}
+	kept := &AWSSession{Profile: "kept", AccessKey: "k2", Expiration: now.Add(time.Hour), SelfDestruct: now.Add(time.Minute)}
+	SaveCredentials("gone", gone, key)
+	SaveCredentials("kept", kept, key)
+
+	os.MkdirAll(filepath.Join(dir, ".aws"), 0700)
+	credsPath := filepath.Join(dir, ".aws", "credentials")
+	os.WriteFile(credsPath, []byte(strings.Join([]string{
+		"[default]",
+		"aws_access_key_id = AKIAUSER",
+		"",
+		"; Managed by cloudctl (ROLE) - Expires: soon",
+		"[gone]",
+		"aws_access_key_id = k1",
+		"",
+		"; Managed by cloudctl (ROLE) - Expires: soon",
+		"[kept]",
+		"aws_access_key_id = k2",
+	}, "\n")), 0600)
+
+	purged, err := PurgeSelfDestructed([]*AWSSession{gone, kept}, now)
+	if err != nil {
+		t.Fatalf("PurgeSelfDestructed failed: %v", err)
+	}
+	if len(purged) != 1 || purged[0] != "gone" {
+		t.Fatalf("purged = %v, want [gone]", purged)
+	}
+
+	if _, err := LoadCredentials("kept", key); err != nil {
+		t.Errorf("kept session should still be stored: %v", err)
+	}
+	profiles, _ := ListProfiles()
+	for _, p := range profiles {
+		if p == "gone" {
+			t.Error("self-destructed session is still stored")
+		}
+	}
+
+	b, _ := os.ReadFile(credsPath)
+	creds := string(b)
+	if strings.Contains(creds, "[gone]") || strings.Contains(creds, "k1") {
+		t.Errorf("self-destructed section left in credentials file:\n%s", creds)
+	}
+	if !strings.Contains(creds, "[default]") || !strings.Contains(creds, "[kept]") {
+		t.Errorf("other sections should be kept:\n%s", creds)
+	}
+}
+
+func TestLoadSourceConfigRefusesSelfDestructed(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	now := time.Now()
+
+	source := &AWSSession{Profile: "boxed", AccessKey: "k1", Expiration: now.Add(time.Hour), SelfDestruct: now.Add(-time.Minute)}
+	if err := SaveCredentials("boxed", source, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSourceConfig(context.Background(), "boxed", key, "eu-west-1"); !errors.Is(err, ErrSourceSelfDestructed) {
+		t.Errorf("Expected a self-destructed source to be refused, got %v", err)
+	}
+
+	source.SelfDestruct = now.Add(time.Minute)
+	if err := SaveCredentials("boxed", source, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSourceConfig(context.Background(), "boxed", key, "eu-west-1"); err != nil {
+		t.Errorf("Expected a source before its deadline to load, got %v", err)
+	}
+}
@@ -0,0 +1,356 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+const (
+	defaultServeUserClaim   = "email"
+	defaultServeGroupsClaim = "groups"
+	defaultServeMaxDuration = 60
+	// serveProfile is the profile the broker's STS calls are recorded under.
+	serveProfile = "serve"
+)
+
+// SourceName returns the bastion identity roles are assumed from.
+func (c ServeConfig) SourceName() string {
+	if c.Source != "" {
+		return c.Source
+	}
+	return InstanceSource
+}
+
+// STSRegion returns the region of the STS endpoint.
+func (c ServeConfig) STSRegion() string {
+	if c.Region != "" {
+		return c.Region
+	}
+	return DefaultRegion
+}
+
+// UserClaimName returns the claim used as user name.
+func (c ServeConfig) UserClaimName() string {
+	if c.UserClaim != "" {
+		return c.UserClaim
+	}
+	return defaultServeUserClaim
+}
+
+// GroupsClaimName returns the claim listing the user's groups.
+func (c ServeConfig) GroupsClaimName() string {
+	if c.GroupsClaim != "" {
+		return c.GroupsClaim
+	}
+	return defaultServeGroupsClaim
+}
+
+// MaxDuration returns the longest session the broker hands out, in seconds.
+func (c ServeConfig) MaxDuration() int32 {
+	if c.MaxDurationMinutes > 0 {
+		return int32(c.MaxDurationMinutes * 60)
+	}
+	return defaultServeMaxDuration * 60
+}
+
+// ValidateServeConfig checks the shape of the serve section; aliases are resolved when
+// the broker starts.
+func ValidateServeConfig(c ServeConfig) error {
+	if c.MaxDurationMinutes < 0 {
+		return fmt.Errorf("max_duration_minutes must not be negative")
+	}
+	if c.MaxDurationMinutes > 0 && c.MaxDurationMinutes < 15 {
+		return fmt.Errorf("max_duration_minutes must be at least 15, the shortest STS session")
+	}
+	for i, g := range c.Grants {
+		if g.Role == "" {
+			return fmt.Errorf("grants[%d]: role is required", i)
+		}
+		if len(g.Users) == 0 && len(g.Groups) == 0 {
+			return fmt.Errorf("grants[%d]: users or groups are required", i)
+		}
+	}
+	return nil
+}
+
+// ServeRole is a role the broker hands out, with the users and groups allowed to assume it.
+type ServeRole struct {
+	// Name is the alias or ARN the grant names it by.
+	Name   string `json:"role"`
+	Arn    string `json:"arn"`
+	users  map[string]bool
+	groups map[string]bool
+}
+
+// ResolveServeGrants resolves the roles of the grants. Break-glass and dual-control
+// roles can't be granted: they need a justification or approval from the person
+// logging in, which the broker can't collect.
+func ResolveServeGrants(c ServeConfig) ([]*ServeRole, error) {
+	cfg := CurrentConfig()
+	byArn := map[string]*ServeRole{}
+	var roles []*ServeRole
+	for _, g := range c.Grants {
+		arn := g.Role
+		if a, found := GetRoleAlias(g.Role); found {
+			arn = a.ARN
+		}
+		if _, err := ParseRoleARN(arn); err != nil {
+			return nil, fmt.Errorf("role '%s': %w", g.Role, err)
+		}
+		if cfg.IsBreakGlass(arn) {
+			return nil, fmt.Errorf("role '%s' is a break-glass role and can't be handed out by the broker", g.Role)
+		}
+		if cfg.RequiresDualControl(arn) {
+			return nil, fmt.Errorf("role '%s' requires dual control and can't be handed out by the broker", g.Role)
+		}
+		role := byArn[arn]
+		if role == nil {
+			role = &ServeRole{Name: g.Role, Arn: arn, users: map[string]bool{}, groups: map[string]bool{}}
+			byArn[arn] = role
+			roles = append(roles, role)
+		}
+		for _, u := range g.Users {
+			role.users[strings.ToLower(u)] = true
+		}
+		for _, group := range g.Groups {
+			role.groups[group] = true
+		}
+	}
+	return roles, nil
+}
+
+// Allows reports whether the user, or one of their groups, is granted the role. User
+// names are compared case-insensitively, as identity providers don't agree on the case
+// of email addresses.
+func (r *ServeRole) Allows(user string, groups []string) bool {
+	if r.users[strings.ToLower(user)] {
+		return true
+	}
+	for _, g := range groups {
+		if r.groups[g] {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenVerifier checks a bearer token and returns its claims; *OIDCVerifier is the one
+// `cloudctl serve` uses.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (map[string]any, error)
+}
+
+// CredentialBroker is the HTTP API of `cloudctl serve`. Callers authenticate with an
+// OIDC ID token as bearer token and get short-lived credentials for the roles they are
+// granted, assumed from the bastion's own identity with their user name as
+// SourceIdentity, so CloudTrail shows who was behind every session.
+//
+//	GET  /healthz         liveness, unauthenticated
+//	GET  /v1/roles        the roles the caller may assume
+//	POST /v1/credentials  {"role": "<alias or ARN>", "duration_seconds": 3600}
+//
+// Credentials are returned in the credential_process format.
+type CredentialBroker struct {
+	Config   ServeConfig
+	Roles    []*ServeRole
+	Verifier TokenVerifier
+	// Source is the AWS config of the bastion's identity.
+	Source aws.Config
+	// Log receives one line per request; nil discards them.
+	Log func(format string, args ...any)
+}
+
+type brokerCaller struct {
+	User   string
+	Groups []string
+}
+
+type brokerCredentialsRequest struct {
+	Role            string `json:"role"`
+	DurationSeconds int32  `json:"duration_seconds,omitempty"`
+}
+
+func (b *CredentialBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/healthz":
+		writeBrokerJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	case "/v1/roles":
+		if r.Method != http.MethodGet {
+			writeBrokerError(w, http.StatusMethodNotAllowed, "use GET")
+			return
+		}
+		caller, ok := b.authenticate(w, r)
+		if !ok {
+			return
+		}
+		roles := []*ServeRole{}
+		for _, role := range b.Roles {
+			if role.Allows(caller.User, caller.Groups) {
+				roles = append(roles, role)
+			}
+		}
+		sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+		writeBrokerJSON(w, http.StatusOK, map[string]any{"user": caller.User, "roles": roles})
+	case "/v1/credentials":
+		if r.Method != http.MethodPost {
+			writeBrokerError(w, http.StatusMethodNotAllowed, "use POST")
+			return
+		}
+		caller, ok := b.authenticate(w, r)
+		if !ok {
+			return
+		}
+		b.issue(w, r, caller)
+	default:
+		writeBrokerError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// authenticate verifies the bearer token and answers 401 when it isn't valid.
+func (b *CredentialBroker) authenticate(w http.ResponseWriter, r *http.Request) (*brokerCaller, bool) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="cloudctl"`)
+		writeBrokerError(w, http.StatusUnauthorized, "an OIDC ID token is required as bearer token")
+		return nil, false
+	}
+	claims, err := b.Verifier.Verify(r.Context(), strings.TrimSpace(token))
+	if err != nil {
+		b.logf("%s  rejected token from %s: %v", FormatLogTime(time.Now()), clientAddr(r), err)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="cloudctl", error="invalid_token"`)
+		writeBrokerError(w, http.StatusUnauthorized, err.Error())
+		return nil, false
+	}
+	user, _ := claims[b.Config.UserClaimName()].(string)
+	if user == "" {
+		writeBrokerError(w, http.StatusUnauthorized, fmt.Sprintf("token has no '%s' claim", b.Config.UserClaimName()))
+		return nil, false
+	}
+	// An unverified address may belong to anyone who signed up with it at the IdP
+	if b.Config.UserClaimName() == "email" {
+		if verified, _ := claims["email_verified"].(bool); !verified {
+			b.logf("%s  rejected unverified email %s from %s", FormatLogTime(time.Now()), user, clientAddr(r))
+			writeBrokerError(w, http.StatusUnauthorized, "token's email is not verified (email_verified)")
+			return nil, false
+		}
+	}
+	return &brokerCaller{User: user, Groups: claimStrings(claims[b.Config.GroupsClaimName()])}, true
+}
+
+func (b *CredentialBroker) issue(w http.ResponseWriter, r *http.Request, caller *brokerCaller) {
+	var req brokerCredentialsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&req); err != nil {
+		writeBrokerError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	role := b.findRole(req.Role)
+	if role == nil || !role.Allows(caller.User, caller.Groups) {
+		b.logf("%s  denied %s for %s", FormatLogTime(time.Now()), req.Role, caller.User)
+		writeBrokerError(w, http.StatusForbidden, fmt.Sprintf("%s may not assume '%s'", caller.User, req.Role))
+		return
+	}
+	duration := req.DurationSeconds
+	if duration == 0 {
+		duration = b.Config.MaxDuration()
+	}
+	if duration < 900 || duration > b.Config.MaxDuration() {
+		writeBrokerError(w, http.StatusBadRequest, fmt.Sprintf("duration_seconds must be between 900 and %d", b.Config.MaxDuration()))
+		return
+	}
+
+	if err := CheckAssumePolicy(AssumeRequest{RoleArn: role.Arn, Source: b.Config.SourceName()}); err != nil {
+		b.logf("%s  denied %s for %s: %v", FormatLogTime(time.Now()), role.Arn, caller.User, err)
+		writeBrokerError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	identity := SourceIdentityFor(caller.User)
+	out, err := NewSTSClient(b.Source, serveProfile).AssumeRole(r.Context(), &sts.AssumeRoleInput{
+		RoleArn:         aws.String(role.Arn),
+		RoleSessionName: aws.String(identity),
+		SourceIdentity:  aws.String(identity),
+		DurationSeconds: aws.Int32(duration),
+	})
+	_ = AppendAudit(AuditEvent{
+		Event:  AuditBrokerIssue,
+		User:   caller.User,
+		Role:   role.Arn,
+		Detail: clientAddr(r),
+		Failed: err != nil,
+	})
+	if err != nil {
+		b.logf("%s  %s for %s failed: %v", FormatLogTime(time.Now()), role.Arn, caller.User, err)
+		writeBrokerError(w, http.StatusBadGateway, fmt.Sprintf("failed to assume '%s': %v", req.Role, err))
+		return
+	}
+	b.logf("%s  issued %s to %s (%s)", FormatLogTime(time.Now()), role.Arn, caller.User, FormatDurationShort(time.Duration(duration)*time.Second))
+
+	s := &AWSSession{RoleArn: role.Arn}
+	setCredentials(s, out.Credentials)
+	writeBrokerJSON(w, http.StatusOK, NewProcessCredentials(s))
+}
+
+// findRole returns the granted role with this alias or ARN.
+func (b *CredentialBroker) findRole(name string) *ServeRole {
+	for _, role := range b.Roles {
+		if role.Name == name || role.Arn == name {
+			return role
+		}
+	}
+	return nil
+}
+
+func (b *CredentialBroker) logf(format string, args ...any) {
+	if b.Log != nil {
+		b.Log(format, args...)
+	}
+}
+
+// claimStrings returns a claim holding a string or a list of them as a list.
+func claimStrings(claim any) []string {
+	switch c := claim.(type) {
+	case string:
+		return []string{c}
+	case []any:
+		var out []string
+		for _, item := range c {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func clientAddr(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func writeBrokerJSON(w http.ResponseWriter, status int, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(append(b, '\n'))
+}
+
+func writeBrokerError(w http.ResponseWriter, status int, message string) {
+	writeBrokerJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// fakeVerifier accepts the tokens it has claims for.
+type fakeVerifier map[string]map[string]any
+
+func (f fakeVerifier) Verify(_ context.Context, token string) (map[string]any, error) {
+	if claims, ok := f[token]; ok {
+		return claims, nil
+	}
+	return nil, errors.New("invalid token signature")
+}
+
+func TestValidateServeConfig(t *testing.T) {
+	valid := ServeConfig{Grants: []ServeGrant{{Role: "prod-readonly", Groups: []string{"sre"}}}}
+	if err := ValidateServeConfig(valid); err != nil {
+		t.Errorf("Expected a valid config, got %v", err)
+	}
+	for name, c := range map[string]ServeConfig{
+		"no role":         {Grants: []ServeGrant{{Users: []string{"alice@example.com"}}}},
+		"nobody":          {Grants: []ServeGrant{{Role: "prod-readonly"}}},
+		"short duration":  {MaxDurationMinutes: 5},
+		"negative length": {MaxDurationMinutes: -1},
+	} {
+		if err := ValidateServeConfig(c); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestResolveServeGrants(t *testing.T) {
+	setTestConfig(t, func(c *Config) { c.Security.BreakGlassRoles = []string{"arn:aws:iam::*:role/BreakGlass"} })
+	setupTestRoles(t, "")
+	if err := SaveRoleAlias("prod-readonly", RoleAlias{ARN: "arn:aws:iam::111111111111:role/ReadOnly"}); err != nil {
+		t.Fatal(err)
+	}
+
+	roles, err := ResolveServeGrants(ServeConfig{Grants: []ServeGrant{
+		{Role: "prod-readonly", Groups: []string{"sre"}},
+		{Role: "arn:aws:iam::111111111111:role/ReadOnly", Users: []string{"Alice@Example.com"}},
+	}})
+	if err != nil {
+		t.Fatalf("ResolveServeGrants failed: %v", err)
+	}
+	if len(roles) != 1 || roles[0].Arn != "arn:aws:iam::111111111111:role/ReadOnly" {
+		t.Fatalf("Expected grants of one role to be merged, got %+v", roles)
+	}
+	if !roles[0].Allows("alice@example.com", nil) || !roles[0].Allows("bob@example.com", []string{"dev", "sre"}) {
+		t.Error("Expected the user and the group to be allowed")
+	}
+	if roles[0].Allows("bob@example.com", []string{"dev"}) {
+		t.Error("Expected a user without a grant to be denied")
+	}
+
+	for _, role := range []string{"missing-alias", "arn:aws:iam::111111111111:role/BreakGlass"} {
+		if _, err := ResolveServeGrants(ServeConfig{Grants: []ServeGrant{{Role: role, Users: []string{"a"}}}}); err == nil {
+			t.Errorf("%s: expected an error", role)
+		}
+	}
+}
+
+func TestCredentialBroker(t *testing.T) {
+	originalLog := auditLogPath
+	auditLogPath = filepath.Join(t.TempDir(), "audit.log")
+	t.Cleanup(func() { auditLogPath = originalLog })
+	mock := &MockSTSClient{}
+	t.Cleanup(UseSTSClient(mock))
+
+	c := ServeConfig{MaxDurationMinutes: 120}
+	broker := &CredentialBroker{
+		Config: c,
+		Roles: []*ServeRole{
+			{Name: "prod-readonly", Arn: "arn:aws:iam::111111111111:role/ReadOnly", users: map[string]bool{}, groups: map[string]bool{"sre": true}},
+			{Name: "prod-admin", Arn: "arn:aws:iam::111111111111:role/Admin", users: map[string]bool{"bob@example.com": true}, groups: map[string]bool{}},
+		},
+		Verifier: fakeVerifier{
+			"alice":      {"email": "alice@example.com", "email_verified": true, "groups": []any{"sre"}},
+			"nomail":     {"sub": "1234"},
+			"unverified": {"email": "bob@example.com", "email_verified": false},
+			"noverified": {"email": "bob@example.com"},
+		},
+	}
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		broker.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request("GET", "/healthz", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected /healthz to be open, got %d", rec.Code)
+	}
+	for name, token := range map[string]string{"no token": "", "invalid token": "forged", "no user claim": "nomail", "unverified email": "unverified", "no email_verified": "noverified"} {
+		if rec := request("POST", "/v1/credentials", token, `{"role":"prod-readonly"}`); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", name, rec.Code)
+		}
+	}
+
+	rec := request("GET", "/v1/roles", "alice", "")
+	var listed struct {
+		User  string       `json:"user"`
+		Roles []*ServeRole `json:"roles"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected /v1/roles answer %d: %s", rec.Code, rec.Body)
+	}
+	if listed.User != "alice@example.com" || len(listed.Roles) != 1 || listed.Roles[0].Name != "prod-readonly" {
+		t.Errorf("Expected only the granted role to be listed, got %+v", listed)
+	}
+
+	if rec := request("POST", "/v1/credentials", "alice", `{"role":"prod-admin"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a role without a grant to be refused, got %d", rec.Code)
+	}
+	if rec := request("POST", "/v1/credentials", "alice", `{"role":"prod-readonly","duration_seconds":43200}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a duration over the maximum to be refused, got %d", rec.Code)
+	}
+	if mock.CallCount("AssumeRole") != 0 {
+		t.Fatalf("Expected refused requests not to call STS")
+	}
+
+	rec = request("POST", "/v1/credentials", "alice", `{"role":"prod-readonly"}`)
+	var creds ProcessCredentials
+	if err := json.Unmarshal(rec.Body.Bytes(), &creds); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected /v1/credentials answer %d: %s", rec.Code, rec.Body)
+	}
+	if creds.Version != 1 || creds.AccessKeyID != "ASIAMOCK00000001" || creds.Expiration == "" {
+		t.Errorf("Unexpected credentials %+v", creds)
+	}
+	in := mock.Calls()[0].Input.(*sts.AssumeRoleInput)
+	if aws.ToString(in.SourceIdentity) != "alice@example.com" || aws.ToString(in.RoleSessionName) != "alice@example.com" {
+		t.Errorf("Expected the user as source identity and session name, got %q and %q",
+			aws.ToString(in.SourceIdentity), aws.ToString(in.RoleSessionName))
+	}
+	if aws.ToInt32(in.DurationSeconds) != 7200 {
+		t.Errorf("Expected the maximum duration by default, got %d", aws.ToInt32(in.DurationSeconds))
+	}
+
+	events, _ := ReadAuditLog()
+	var issued []AuditEvent
+	for _, e := range events {
+		if e.Event == AuditBrokerIssue {
+			issued = append(issued, e)
+		}
+	}
+	if len(issued) != 1 || issued[0].User != "alice@example.com" || issued[0].Role != "arn:aws:iam::111111111111:role/ReadOnly" {
+		t.Errorf("Expected the issue to be audited for the OIDC user, got %+v", issued)
+	}
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxSessionNameLength is the longest RoleSessionName STS accepts; the profile name is
+// used as session name.
+const maxSessionNameLength = 64
+
+var sessionNameInvalid = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// DefaultSessionName derives a session name for a role login without --profile: the role
+// alias, prefixed with its group ("payments-prod-admin"), or for a plain role ARN the
+// role name after the account's env tag ("prod-admin") or before the account ID
+// ("admin-123456789012"). A name taken by a session of another role gets a -2, -3...
+// suffix; taken reports those.
+func DefaultSessionName(roleArn, aliasName string, alias *RoleAlias, taken func(name string) bool) string {
+	var parts []string
+	if aliasName != "" {
+		if alias != nil && alias.Group != "" && !strings.HasPrefix(strings.ToLower(aliasName), strings.ToLower(alias.Group)) {
+			parts = append(parts, alias.Group)
+		}
+		parts = append(parts, aliasName)
+	} else {
+		account := RoleAccountID(roleArn)
+		if env := CurrentConfig().Accounts[account].Env; env != "" {
+			parts = append(parts, env, RoleName(roleArn))
+		} else {
+			parts = append(parts, RoleName(roleArn), account)
+		}
+	}
+
+	name := sessionNameInvalid.ReplaceAllString(strings.ToLower(strings.Join(parts, "-")), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > maxSessionNameLength-3 {
+		// Room for a suffix
+		name = strings.TrimRight(name[:maxSessionNameLength-3], "-")
+	}
+	if name == "" {
+		name = "session"
+	}
+	if taken(name) {
+		name = freeName(name, taken)
+	}
+	return name
+}
+
+// SessionNameConflict is what a login would clobber by storing its session under a name.
+type SessionNameConflict struct {
+	// Session is the active stored session of another role with the name, which the
+	// login would replace.
+	Session *AWSSession
+	// AWSProfile is the hand-written AWS CLI profile with the name. A session with its
+	// name hides it as a login source, and sync would overwrite its credentials.
+	AWSProfile *AWSProfile
+	// Suggestion is a free name with a -2, -3... suffix.
+	Suggestion string
+}
+
+// Found reports whether the name clashes with anything.
+func (c SessionNameConflict) Found() bool {
+	return c.Session != nil || c.AWSProfile != nil
+}
+
+// CheckSessionName looks for what a login to roleArn stored as name would clobber: an
+// active session of another role, or an AWS CLI profile that sync doesn't manage.
+// Expired sessions and sessions of the same role are replaced as usual.
+func CheckSessionName(name, roleArn string, sessions []*AWSSession, profiles []AWSProfile, managed []ManagedCredential, now time.Time) SessionNameConflict {
+	var conflict SessionNameConflict
+	taken := map[string]bool{}
+	for _, s := range sessions {
+		taken[s.Profile] = true
+		if s.Profile == name && s.RoleArn != roleArn && s.Expiration.After(now) {
+			conflict.Session = s
+		}
+	}
+	synced := make(map[string]bool, len(managed))
+	for _, m := range managed {
+		synced[m.Profile] = true
+	}
+	for i, p := range profiles {
+		taken[p.Name] = true
+		if p.Name == name && !synced[p.Name] {
+			conflict.AWSProfile = &profiles[i]
+		}
+	}
+	if conflict.Found() {
+		conflict.Suggestion = freeName(name, func(n string) bool { return taken[n] })
+	}
+	return conflict
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultSessionName(t *testing.T) {
+	setTestConfig(t, func(c *Config) { c.Accounts = map[string]AccountConfig{"111111111111": {Env: "prod"}} })
+
+	none := func(string) bool { return false }
+	tests := []struct {
+		name      string
+		roleArn   string
+		aliasName string
+		alias     *RoleAlias
+		want      string
+	}{
+		{"alias with group", "arn:aws:iam::111111111111:role/Admin", "prod-admin", &RoleAlias{Group: "payments"}, "payments-prod-admin"},
+		{"alias already prefixed", "arn:aws:iam::111111111111:role/Admin", "payments-admin", &RoleAlias{Group: "Payments"}, "payments-admin"},
+		{"alias without group", "arn:aws:iam::111111111111:role/Admin", "Prod Admin", &RoleAlias{}, "prod-admin"},
+		{"account env", "arn:aws:iam::111111111111:role/ops/DeployRole", "", nil, "prod-deployrole"},
+		{"account ID", "arn:aws:iam::222222222222:role/ReadOnly", "", nil, "readonly-222222222222"},
+	}
+	for _, tt := range tests {
+		if got := DefaultSessionName(tt.roleArn, tt.aliasName, tt.alias, none); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	taken := map[string]bool{"prod-admin": true, "prod-admin-2": true}
+	if got := DefaultSessionName("arn:aws:iam::111111111111:role/Admin", "prod-admin", &RoleAlias{}, func(n string) bool { return taken[n] }); got != "prod-admin-3" {
+		t.Errorf("Expected a free suffix, got %q", got)
+	}
+
+	long := "arn:aws:iam::222222222222:role/" + strings.Repeat("x", 64)
+	if got := DefaultSessionName(long, "", nil, none); len(got) > maxSessionNameLength-3 {
+		t.Errorf("Name too long for a suffix: %q", got)
+	}
+}
+
+func TestCheckSessionName(t *testing.T) {
+	now := time.Now()
+	admin := "arn:aws:iam::111111111111:role/Admin"
+	sessions := []*AWSSession{
+		{Profile: "prod", RoleArn: "arn:aws:iam::111111111111:role/ReadOnly", Expiration: now.Add(time.Hour)},
+		{Profile: "prod-2", RoleArn: admin, Expiration: now.Add(time.Hour)},
+		{Profile: "old", RoleArn: "arn:aws:iam::111111111111:role/ReadOnly", Expiration: now.Add(-time.Hour)},
+		{Profile: "mine", RoleArn: admin, Expiration: now.Add(time.Hour)},
+	}
+	profiles := []AWSProfile{{Name: "default", StaticKeys: true}, {Name: "synced", StaticKeys: true}}
+	managed := []ManagedCredential{{Profile: "synced"}}
+
+	tests := []struct {
+		name       string
+		session    bool
+		awsProfile bool
+		suggestion string
+	}{
+		{"prod", true, false, "prod-3"},
+		{"default", false, true, "default-2"},
+		{"old", false, false, ""},
+		{"mine", false, false, ""},
+		{"synced", false, false, ""},
+		{"new", false, false, ""},
+	}
+	for _, tt := range tests {
+		c := CheckSessionName(tt.name, admin, sessions, profiles, managed, now)
+		if (c.Session != nil) != tt.session || (c.AWSProfile != nil) != tt.awsProfile || c.Suggestion != tt.suggestion || c.Found() != (tt.session || tt.awsProfile) {
+			t.Errorf("%s: unexpected conflict %+v", tt.name, c)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultSSOProfileName is the profile name template used when a spec has none.
+const DefaultSSOProfileName = "{account}-{permission_set}"
+
+// SSOSpec describes an IAM Identity Center sso-session and the accounts and permission
+// sets to generate AWS CLI profiles for.
+type SSOSpec struct {
+	Session  string `json:"sso_session"`
+	StartURL string `json:"start_url"`
+	// Region is the Identity Center region (sso_region).
+	Region string `json:"sso_region"`
+	Scopes string `json:"registration_scopes,omitempty"`
+	// ProfileName is a template with {account}, {account_id} and {permission_set}.
+	ProfileName string `json:"profile_name,omitempty"`
+	// DefaultRegion is the region of generated profiles without their own.
+	DefaultRegion string       `json:"default_region,omitempty"`
+	Accounts      []SSOAccount `json:"accounts"`
+}
+
+// SSOAccount is one account and the permission sets to create profiles for.
+type SSOAccount struct {
+	Name           string   `json:"name"`
+	AccountID      string   `json:"account_id"`
+	PermissionSets []string `json:"permission_sets"`
+	Region         string   `json:"region,omitempty"`
+}
+
+// SSOProfile is one generated [profile] block.
+type SSOProfile struct {
+	Name          string
+	AccountID     string
+	PermissionSet string
+	Region        string
+}
+
+// LoadSSOSpec reads and validates a JSON spec file.
+func LoadSSOSpec(path string) (*SSOSpec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	var spec SSOSpec
+	if err := json.Unmarshal(b, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate checks the spec and the profile names it expands to.
+func (s *SSOSpec) Validate() error {
+	if s.Session == "" || strings.ContainsAny(s.Session, " []\t") {
+		return fmt.Errorf("sso_session must be a name without spaces or brackets")
+	}
+	if u, err := url.Parse(s.StartURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("start_url must be an https URL, e.g. https://my-org.awsapps.com/start")
+	}
+	if s.Region == "" {
+		return fmt.Errorf("sso_region is required")
+	}
+	if len(s.Accounts) == 0 {
+		return fmt.Errorf("no accounts given")
+	}
+	for _, a := range s.Accounts {
+		if !accountIDPattern.MatchString(a.AccountID) {
+			return fmt.Errorf("account '%s': account_id must be 12 digits", a.Name)
+		}
+		if len(a.PermissionSets) == 0 {
+			return fmt.Errorf("account '%s': no permission_sets given", a.Name)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, p := range s.Profiles() {
+		if p.Name == "" || strings.ContainsAny(p.Name, " []\t") {
+			return fmt.Errorf("invalid profile name '%s' (check profile_name and account names)", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("profile '%s' would be generated twice (check profile_name)", p.Name)
+		}
+		seen[p.Name] = true
+	}
+	return nil
+}
+
+// Profiles expands the accounts and permission sets into profiles, in spec order.
+func (s *SSOSpec) Profiles() []SSOProfile {
+	template := s.ProfileName
+	if template == "" {
+		template = DefaultSSOProfileName
+	}
+	var profiles []SSOProfile
+	for _, a := range s.Accounts {
+		name := a.Name
+		if name == "" {
+			name = a.AccountID
+		}
+		region := a.Region
+		if region == "" {
+			region = s.DefaultRegion
+		}
+		for _, ps := range a.PermissionSets {
+			profile := strings.NewReplacer("{account}", name, "{account_id}", a.AccountID, "{permission_set}", ps).Replace(template)
+			profiles = append(profiles, SSOProfile{Name: profile, AccountID: a.AccountID, PermissionSet: ps, Region: region})
+		}
+	}
+	return profiles
+}
+
+// ssoManagedMarker is the comment written above every block generated for a session.
+func ssoManagedMarker(session string) string {
+	return "; Managed by cloudctl (sso-session " + session + ")"
+}
+
+// RenderSSOConfig returns the ~/.aws/config blocks for the spec.
+func RenderSSOConfig(s *SSOSpec) string {
+	marker := ssoManagedMarker(s.Session)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n[sso-session %s]\n", marker, s.Session)
+	fmt.Fprintf(&b, "sso_start_url = %s\nsso_region = %s\n", s.StartURL, s.Region)
+	scopes := s.Scopes
+	if scopes == "" {
+		scopes = "sso:account:access"
+	}
+	fmt.Fprintf(&b, "sso_registration_scopes = %s\n", scopes)
+	for _, p := range s.Profiles() {
+		fmt.Fprintf(&b, "\n%s\n%s\n", marker, configSectionHeader(p.Name))
+		fmt.Fprintf(&b, "sso_session = %s\nsso_account_id = %s\nsso_role_name = %s\n", s.Session, p.AccountID, p.PermissionSet)
+		if p.Region != "" {
+			fmt.Fprintf(&b, "region = %s\n", p.Region)
+		}
+	}
+	return b.String()
+}
+
+// configSectionHeader is the ~/.aws/config header of a profile; only the default
+// profile has no "profile " prefix.
+func configSectionHeader(profile string) string {
+	if profile == "default" {
+		return "[default]"
+	}
+	return "[profile " + profile + "]"
+}
+
+// configSection is one section of an AWS config file with the comments above it.
+type configSection struct {
+	// Header is the trimmed section header, "" for lines before the first section.
+	Header string
+	Lines  []string
+}
+
+func splitConfigSections(content string) []configSection {
+	sections := []configSection{{}}
+	var pending []string // comments and blank lines, attached to the next section
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			sections = append(sections, configSection{Header: trimmed, Lines: append(pending, line)})
+			pending = nil
+		case trimmed == "" || strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "#"):
+			pending = append(pending, line)
+		default:
+			last := &sections[len(sections)-1]
+			last.Lines = append(last.Lines, pending...)
+			last.Lines = append(last.Lines, line)
+			pending = nil
+		}
+	}
+	last := &sections[len(sections)-1]
+	last.Lines = append(last.Lines, pending...)
+	return sections
+}
+
+func (c configSection) managedBy(marker string) bool {
+	for _, line := range c.Lines {
+		if strings.TrimSpace(line) == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeSSOConfig replaces the blocks previously generated for the spec's sso-session in
+// an AWS config file with freshly rendered ones; other sections are kept. Sections the
+// spec would generate that were written by hand are returned as conflicts and left
+// alone unless force is set.
+func MergeSSOConfig(content string, s *SSOSpec, force bool) (string, []string) {
+	generated := map[string]bool{"[sso-session " + s.Session + "]": true}
+	for _, p := range s.Profiles() {
+		generated[configSectionHeader(p.Name)] = true
+	}
+	return mergeManagedConfig(content, ssoManagedMarker(s.Session), generated, RenderSSOConfig(s), force)
+}
+
+// mergeManagedConfig replaces the sections marked with marker in an AWS config file by
+// rendered, which holds the generated sections. Hand-written sections with a generated
+// header are returned as conflicts, and content is returned unchanged, unless force is set.
+func mergeManagedConfig(content, marker string, generated map[string]bool, rendered string, force bool) (string, []string) {
+	var kept []string
+	var conflicts []string
+	for _, sec := range splitConfigSections(content) {
+		if sec.managedBy(marker) {
+			continue
+		}
+		if generated[sec.Header] {
+			if !force {
+				conflicts = append(conflicts, sec.Header)
+			}
+			continue
+		}
+		kept = append(kept, sec.Lines...)
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return content, conflicts
+	}
+
+	out := strings.TrimRight(strings.Join(kept, "\n"), "\n")
+	if out != "" {
+		out += "\n\n"
+	}
+	return out + rendered, nil
+}
+
+// AWSConfigPath is the AWS CLI config file, AWS_CONFIG_FILE when it is set.
+func AWSConfigPath() string {
+	return awsFilePath("AWS_CONFIG_FILE", "config")
+}
+
+// WriteSSOConfig merges the spec into ~/.aws/config. It returns the conflicting
+// hand-written sections and writes nothing when there are any and force is false.
+func WriteSSOConfig(s *SSOSpec, force bool) ([]string, error) {
+	return writeAWSConfig(func(content string) (string, []string) {
+		return MergeSSOConfig(content, s, force)
+	})
+}
+
+// writeAWSConfig rewrites ~/.aws/config with merge, keeping its mode. Nothing is written
+// when merge returns conflicts.
+func writeAWSConfig(merge func(content string) (string, []string)) ([]string, error) {
+	path := AWSConfigPath()
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read AWS config: %w", err)
+	}
+	merged, conflicts := merge(string(content))
+	if len(conflicts) > 0 {
+		return conflicts, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create AWS config directory: %w", err)
+	}
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, []byte(merged), mode); err != nil {
+		return nil, fmt.Errorf("failed to write AWS config: %w", err)
+	}
+	return nil, nil
+}
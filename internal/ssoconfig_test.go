@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func testSSOSpec() *SSOSpec {
+	return &SSOSpec{
+		Session:       "my-org",
+		StartURL:      "https://my-org.awsapps.com/start",
+		Region:        "us-east-1",
+		DefaultRegion: "ap-southeast-1",
+		Accounts: []SSOAccount{
+			{Name: "prod", AccountID: "123456789012", PermissionSets: []string{"ReadOnlyAccess", "AdministratorAccess"}},
+			{Name: "dev", AccountID: "210987654321", PermissionSets: []string{"PowerUserAccess"}, Region: "eu-west-1"},
+		},
+	}
+}
+
+func TestSSOSpecProfiles(t *testing.T) {
+	spec := testSSOSpec()
+	if err := spec.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	profiles := spec.Profiles()
+	if len(profiles) != 3 || profiles[0].Name != "prod-ReadOnlyAccess" || profiles[2].Region != "eu-west-1" || profiles[1].Region != "ap-southeast-1" {
+		t.Fatalf("Profiles = %+v", profiles)
+	}
+
+	spec.ProfileName = "{account}"
+	if err := spec.Validate(); err == nil {
+		t.Error("expected duplicate profile names to be rejected")
+	}
+
+	invalid := []func(s *SSOSpec){
+		func(s *SSOSpec) { s.StartURL = "http://my-org.awsapps.com/start" },
+		func(s *SSOSpec) { s.Session = "my org" },
+		func(s *SSOSpec) { s.Region = "" },
+		func(s *SSOSpec) { s.Accounts[0].AccountID = "1234" },
+		func(s *SSOSpec) { s.Accounts[1].PermissionSets = nil },
+	}
+	for i, change := range invalid {
+		s := testSSOSpec()
+		change(s)
+		if err := s.Validate(); err == nil {
+			t.Errorf("case %d: expected a validation error", i)
+		}
+	}
+}
+
+func TestMergeSSOConfig(t *testing.T) {
+	spec := testSSOSpec()
+	existing := "[default]\nregion = us-east-1\n\n# my own profile\n[profile personal]\nregion = eu-central-1\n"
+
+	merged, conflicts := MergeSSOConfig(existing, spec, false)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	for _, want := range []string{
+		"[default]\nregion = us-east-1",
+		"# my own profile\n[profile personal]",
+		"[sso-session my-org]\nsso_start_url = https://my-org.awsapps.com/start\nsso_region = us-east-1",
+		"[profile prod-AdministratorAccess]\nsso_session = my-org\nsso_account_id = 123456789012\nsso_role_name = AdministratorAccess\nregion = ap-southeast-1",
+		"[profile dev-PowerUserAccess]",
+	} {
+		if !strings.Contains(merged, want) {
+			t.Errorf("merged config is missing %q:\n%s", want, merged)
+		}
+	}
+
+	// Regenerating replaces the managed blocks and drops profiles removed from the spec
+	spec.Accounts = spec.Accounts[:1]
+	again, conflicts := MergeSSOConfig(merged, spec, false)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	if strings.Count(again, "[sso-session my-org]") != 1 || strings.Contains(again, "dev-PowerUserAccess") {
+		t.Errorf("managed blocks were not replaced:\n%s", again)
+	}
+	if !strings.Contains(again, "[profile personal]") {
+		t.Errorf("hand-written profile was removed:\n%s", again)
+	}
+
+	// Hand-written sections with a generated name are conflicts unless forced
+	spec.ProfileName = "personal"
+	spec.Accounts[0].PermissionSets = spec.Accounts[0].PermissionSets[:1]
+	if _, conflicts := MergeSSOConfig(existing, spec, false); len(conflicts) != 1 || conflicts[0] != "[profile personal]" {
+		t.Errorf("conflicts = %v, want [profile personal]", conflicts)
+	}
+	forced, _ := MergeSSOConfig(existing, spec, true)
+	if strings.Contains(forced, "eu-central-1") || !strings.Contains(forced, "[profile personal]\nsso_session = my-org") {
+		t.Errorf("forced merge did not replace the section:\n%s", forced)
+	}
+}
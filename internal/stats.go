@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// auditCommand is the top-level command recorded with usage events, so `stats` can
+// tell the daemon's calls from interactive ones.
+var auditCommand string
+
+// SetAuditCommand sets the command name recorded with usage events.
+func SetAuditCommand(name string) {
+	auditCommand = name
+}
+
+// IsUsageEvent reports whether e only feeds `cloudctl stats` (an STS call, refresh or
+// console federation), `cloudctl suggest` (a role login) or `cloudctl timeline` (an MFA
+// login or credential export) rather than being a security event.
+func (e AuditEvent) IsUsageEvent() bool {
+	switch e.Event {
+	case AuditSTSCall, AuditRefresh, AuditConsoleFederation, AuditRoleUse, AuditMFALogin, AuditExport:
+		return true
+	}
+	return false
+}
+
+// recordUsage appends a usage event. Statistics are best-effort, so a failed write is
+// ignored rather than failing the command that made the call.
+func recordUsage(event, profile, detail string, start time.Time, err error) {
+	_ = AppendAudit(AuditEvent{
+		Event:     event,
+		Profile:   profile,
+		Detail:    detail,
+		Command:   auditCommand,
+		LatencyMS: time.Since(start).Milliseconds(),
+		Failed:    err != nil,
+	})
+}
+
+// RecordRefresh records a session refresh started at start; how is "silent" or
+// "interactive".
+func RecordRefresh(profile, how string, start time.Time, err error) {
+	recordUsage(AuditRefresh, profile, how, start, err)
+}
+
+// RecordConsoleFederation records a getSigninToken call made for profile.
+func RecordConsoleFederation(profile string, start time.Time, err error) {
+	recordUsage(AuditConsoleFederation, profile, "", start, err)
+}
+
+// NewSTSClient returns an STS client that records every call it makes, with its
+// latency including retries, as a usage event for profile.
+func NewSTSClient(cfg aws.Config, profile string) STSAPI {
+	return newSTSClient(cfg, func(o *sts.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CloudctlUsageStats",
+				func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+					start := time.Now()
+					out, md, err := next.HandleInitialize(ctx, in)
+					recordUsage(AuditSTSCall, profile, awsmiddleware.GetOperationName(ctx), start, err)
+					return out, md, err
+				}), middleware.After)
+		})
+	})
+}
+
+// ProfileStats sums up the usage events of one profile.
+type ProfileStats struct {
+	Profile     string
+	STSCalls    int
+	Refreshes   int
+	Federations int
+	Failures    int
+	// Average latencies; zero when there were no such events.
+	STSLatency        time.Duration
+	RefreshLatency    time.Duration
+	FederationLatency time.Duration
+	// RefreshInterval is the average time between refreshes; zero with fewer than two.
+	RefreshInterval time.Duration
+	// Commands counts events by the command that caused them (e.g. "daemon", "exec").
+	Commands map[string]int
+	// Operations counts STS calls by API name.
+	Operations map[string]int
+}
+
+// DailyUsage counts the usage events of one day, in display.timezone.
+type DailyUsage struct {
+	Day         string
+	STSCalls    int
+	Refreshes   int
+	Federations int
+}
+
+// UsageStats aggregates the usage events recorded since since, optionally for one
+// profile. Profiles are sorted by the number of STS calls, busiest first.
+func UsageStats(events []AuditEvent, since time.Time, profile string) ([]ProfileStats, []DailyUsage) {
+	type totals struct {
+		stats                  *ProfileStats
+		sts, refresh, federate time.Duration
+		firstRefresh, lastRef  time.Time
+	}
+	byProfile := make(map[string]*totals)
+	byDay := make(map[string]*DailyUsage)
+	loc := DisplayLocation()
+
+	for _, e := range events {
+		if (e.Event != AuditSTSCall && e.Event != AuditRefresh && e.Event != AuditConsoleFederation) ||
+			e.Time.Before(since) || (profile != "" && e.Profile != profile) {
+			continue
+		}
+		t, ok := byProfile[e.Profile]
+		if !ok {
+			t = &totals{stats: &ProfileStats{Profile: e.Profile, Commands: map[string]int{}, Operations: map[string]int{}}}
+			byProfile[e.Profile] = t
+		}
+		day := e.Time.In(loc).Format("2006-01-02")
+		d, ok := byDay[day]
+		if !ok {
+			d = &DailyUsage{Day: day}
+			byDay[day] = d
+		}
+
+		s := t.stats
+		latency := time.Duration(e.LatencyMS) * time.Millisecond
+		switch e.Event {
+		case AuditSTSCall:
+			s.STSCalls++
+			d.STSCalls++
+			t.sts += latency
+			s.Operations[e.Detail]++
+		case AuditRefresh:
+			s.Refreshes++
+			d.Refreshes++
+			t.refresh += latency
+			if t.firstRefresh.IsZero() || e.Time.Before(t.firstRefresh) {
+				t.firstRefresh = e.Time
+			}
+			if e.Time.After(t.lastRef) {
+				t.lastRef = e.Time
+			}
+		case AuditConsoleFederation:
+			s.Federations++
+			d.Federations++
+			t.federate += latency
+		}
+		if e.Failed {
+			s.Failures++
+		}
+		command := e.Command
+		if command == "" {
+			command = "unknown"
+		}
+		s.Commands[command]++
+	}
+
+	profiles := make([]ProfileStats, 0, len(byProfile))
+	for _, t := range byProfile {
+		s := t.stats
+		if s.STSCalls > 0 {
+			s.STSLatency = t.sts / time.Duration(s.STSCalls)
+		}
+		if s.Refreshes > 0 {
+			s.RefreshLatency = t.refresh / time.Duration(s.Refreshes)
+		}
+		if s.Refreshes > 1 {
+			s.RefreshInterval = t.lastRef.Sub(t.firstRefresh) / time.Duration(s.Refreshes-1)
+		}
+		if s.Federations > 0 {
+			s.FederationLatency = t.federate / time.Duration(s.Federations)
+		}
+		profiles = append(profiles, *s)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].STSCalls != profiles[j].STSCalls {
+			return profiles[i].STSCalls > profiles[j].STSCalls
+		}
+		return profiles[i].Profile < profiles[j].Profile
+	})
+
+	days := make([]DailyUsage, 0, len(byDay))
+	for _, d := range byDay {
+		days = append(days, *d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day < days[j].Day })
+	return profiles, days
+}
@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+func TestUsageStats(t *testing.T) {
+	setTestConfig(t, func(c *Config) { c.Display.Timezone = "UTC" })
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	events := []AuditEvent{
+		{Time: base.Add(-48 * time.Hour), Event: AuditSTSCall, Profile: "prod", Detail: "AssumeRole"}, // before since
+		{Time: base, Event: AuditSTSCall, Profile: "prod", Detail: "AssumeRole", Command: "daemon", LatencyMS: 100},
+		{Time: base.Add(time.Minute), Event: AuditSTSCall, Profile: "prod", Detail: "GetCallerIdentity", Command: "daemon", LatencyMS: 300},
+		{Time: base, Event: AuditRefresh, Profile: "prod", Detail: "silent", Command: "daemon", LatencyMS: 400},
+		{Time: base.Add(30 * time.Minute), Event: AuditRefresh, Profile: "prod", Detail: "silent", Command: "daemon", LatencyMS: 200},
+		{Time: base.Add(60 * time.Minute), Event: AuditRefresh, Profile: "prod", Detail: "silent", Command: "daemon", Failed: true},
+		{Time: base.Add(24 * time.Hour), Event: AuditSTSCall, Profile: "dev", Detail: "AssumeRole", Command: "login", LatencyMS: 50},
+		{Time: base.Add(24 * time.Hour), Event: AuditConsoleFederation, Profile: "dev", Command: "console", LatencyMS: 80},
+		{Time: base, Event: AuditLogin, Profile: "prod"}, // not a usage event
+	}
+
+	profiles, days := UsageStats(events, base.Add(-time.Hour), "")
+	if len(profiles) != 2 || profiles[0].Profile != "prod" || profiles[1].Profile != "dev" {
+		t.Fatalf("Unexpected profiles: %+v", profiles)
+	}
+	prod := profiles[0]
+	if prod.STSCalls != 2 || prod.Refreshes != 3 || prod.Federations != 0 || prod.Failures != 1 {
+		t.Errorf("Unexpected prod counts: %+v", prod)
+	}
+	if prod.STSLatency != 200*time.Millisecond || prod.RefreshLatency != 200*time.Millisecond {
+		t.Errorf("Unexpected prod latencies: sts %v, refresh %v", prod.STSLatency, prod.RefreshLatency)
+	}
+	if prod.RefreshInterval != 30*time.Minute {
+		t.Errorf("RefreshInterval = %v, want 30m", prod.RefreshInterval)
+	}
+	if prod.Commands["daemon"] != 5 || prod.Operations["AssumeRole"] != 1 || prod.Operations["GetCallerIdentity"] != 1 {
+		t.Errorf("Unexpected prod breakdown: %v %v", prod.Commands, prod.Operations)
+	}
+	dev := profiles[1]
+	if dev.Federations != 1 || dev.FederationLatency != 80*time.Millisecond || dev.RefreshInterval != 0 {
+		t.Errorf("Unexpected dev stats: %+v", dev)
+	}
+
+	if len(days) != 2 || days[0].Day != "2026-03-01" || days[0].STSCalls != 2 || days[0].Refreshes != 3 ||
+		days[1].Day != "2026-03-02" || days[1].Federations != 1 {
+		t.Errorf("Unexpected days: %+v", days)
+	}
+
+	profiles, _ = UsageStats(events, base.Add(-time.Hour), "dev")
+	if len(profiles) != 1 || profiles[0].Profile != "dev" {
+		t.Errorf("Profile filter: %+v", profiles)
+	}
+}
+
+func TestNewSTSClientRecordsCalls(t *testing.T) {
+	dir := t.TempDir()
+	originalLog := auditLogPath
+	auditLogPath = filepath.Join(dir, "audit.log")
+	t.Cleanup(func() { auditLogPath = originalLog })
+	SetAuditCommand("exec")
+	t.Cleanup(func() { SetAuditCommand("") })
+
+	session := &AWSSession{
+		Profile:      "dev",
+		AccessKey:    "ASIAMOCK",
+		SecretKey:    "secret",
+		SessionToken: "token",
+		Expiration:   time.Now().Add(time.Hour),
+		RoleArn:      "arn:aws:iam::123456789012:role/Admin",
+		SessionName:  "alice",
+	}
+	server := httptest.NewServer(&MockSTS{Load: func() (*AWSSession, error) { return session, nil }})
+	defer server.Close()
+
+	cfg := aws.Config{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKIAANY", "any", ""),
+	}
+	if _, err := NewSTSClient(cfg, "dev").GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatal(err)
+	}
+	RecordRefresh("dev", "silent", time.Now(), errors.New("expired"))
+
+	events, err := ReadAuditLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", events)
+	}
+	call := events[0]
+	if call.Event != AuditSTSCall || call.Profile != "dev" || call.Detail != "GetCallerIdentity" || call.Command != "exec" || call.Failed {
+		t.Errorf("Unexpected STS call event: %+v", call)
+	}
+	if refresh := events[1]; refresh.Event != AuditRefresh || refresh.Detail != "silent" || !refresh.Failed {
+		t.Errorf("Unexpected refresh event: %+v", refresh)
+	}
+}
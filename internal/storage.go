@@ -3,22 +3,32 @@ package internal
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"time"
 )
 
-var storePath = filepath.Join(os.Getenv("HOME"), ".cloudctl", "credentials.json")
-var mfaStorePath = filepath.Join(os.Getenv("HOME"), ".cloudctl", "mfa.json")
-var roleStorePath = filepath.Join(os.Getenv("HOME"), ".cloudctl", "roles.json")
+var storePath = filepath.Join(storeDir, "credentials.json")
+
+// ErrProfileNotFound is returned when a profile has no stored session.
+var ErrProfileNotFound = errors.New("not found in store")
+var mfaStorePath = filepath.Join(storeDir, "mfa.json")
 
 // SaveCredentials encrypts and stores AWS session for a specific profile.
 func SaveCredentials(profile string, creds *AWSSession, key string) error {
-	if err := os.MkdirAll(filepath.Dir(storePath), 0700); err != nil {
-		return fmt.Errorf("failed to create storage directory: %w", err)
+	provider, err := StoreProvider(key)
+	if err != nil {
+		return err
 	}
+	return SaveCredentialsWith(profile, creds, provider)
+}
 
+// SaveCredentialsWith stores a session encrypted with an explicit provider.
+func SaveCredentialsWith(profile string, creds *AWSSession, provider CryptoProvider) error {
 	encryptionMap := map[string]string{
 		"AccessKey":     creds.AccessKey,
 		"SecretKey":     creds.SecretKey,
@@ -30,56 +40,70 @@ func SaveCredentials(profile string, creds *AWSSession, key string) error {
 		"Region":        creds.Region,
 		"MfaArn":        creds.MfaArn,
 		"Duration":      fmt.Sprintf("%d", creds.Duration),
+		"Access":        creds.Access,
+		"AccountID":     creds.AccountID,
+		"PrincipalArn":  creds.PrincipalArn,
+		"UserID":        creds.UserID,
+		"AccountAlias":  creds.AccountAlias,
+		"SelfDestruct":  "",
+		"RootTask":      creds.RootTask,
+	}
+	if !creds.SelfDestruct.IsZero() {
+		encryptionMap["SelfDestruct"] = creds.SelfDestruct.Format(time.RFC3339)
 	}
 
 	encrypted := make(map[string]string)
 	for field, value := range encryptionMap {
-		enc, err := Encrypt([]byte(value), []byte(key))
+		plain := []byte(value)
+		enc, err := provider.Encrypt(plain)
+		Wipe(plain)
 		if err != nil {
 			return fmt.Errorf("failed to encrypt %s: %w", field, err)
 		}
 		encrypted[field] = base64.StdEncoding.EncodeToString(enc)
 	}
-
-	// load existing data
-	data := make(map[string]map[string]string)
-	if b, err := os.ReadFile(storePath); err == nil && len(b) > 0 {
-		if err := json.Unmarshal(b, &data); err != nil {
-			return fmt.Errorf("failed to parse existing credentials file: %w", err)
-		}
+	encrypted[versionField] = strconv.Itoa(StoreVersion)
+	if creds.Revoked {
+		encrypted["Revoked"] = "true"
 	}
-
-	data[profile] = encrypted
-
-	b, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal credentials: %w", err)
+	if err := sealEntry(profile, encrypted, provider); err != nil {
+		return err
+	}
+	if err := processStore.verifySeal(provider); err != nil {
+		return err
 	}
-	return os.WriteFile(storePath, b, 0600)
+
+	entry := indexEntryFor(creds)
+	return processStore.put(profile, encrypted, func(index map[string]IndexEntry) {
+		index[profile] = entry
+	})
 }
 
 // LoadCredentials decrypts AWS session for a profile.
 func LoadCredentials(profile, key string) (*AWSSession, error) {
-	b, err := os.ReadFile(storePath)
+	enc, ok, err := processStore.entry(profile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read credentials file: %w", err)
-	}
-
-	var data map[string]map[string]string
-	if err := json.Unmarshal(b, &data); err != nil {
-		return nil, fmt.Errorf("failed to decode credentials: %w", err)
+		return nil, err
 	}
-
-	enc, ok := data[profile]
 	if !ok {
-		return nil, fmt.Errorf("profile '%s' not found in store", profile)
+		return nil, fmt.Errorf("profile '%s' %w", profile, ErrProfileNotFound)
 	}
 
-	return decryptSession(profile, enc, key)
+	provider, err := StoreProvider(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := processStore.verifySeal(provider); err != nil {
+		return nil, err
+	}
+	if err := verifyEntry(profile, enc, provider); err != nil {
+		return nil, err
+	}
+	return decryptSession(profile, enc, provider)
 }
 
 // decryptSession is a helper to decrypt the fields of a session map.
-func decryptSession(profile string, enc map[string]string, key string) (*AWSSession, error) {
+func decryptSession(profile string, enc map[string]string, provider CryptoProvider) (*AWSSession, error) {
 	getField := func(field string) (string, error) {
 		val, ok := enc[field]
 		if !ok {
@@ -89,10 +113,12 @@ func decryptSession(profile string, enc map[string]string, key string) (*AWSSess
 		if err != nil {
 			return "", fmt.Errorf("failed to decode base64 for %s: %w", field, err)
 		}
-		decrypted, err := Decrypt(bytes, []byte(key))
+		decrypted, err := provider.Decrypt(bytes)
 		if err != nil {
 			return "", fmt.Errorf("failed to decrypt %s: %w", field, err)
 		}
+		// Session fields are strings the AWS SDK consumes, so only this copy can be wiped
+		defer Wipe(decrypted)
 		return string(decrypted), nil
 	}
 
@@ -141,13 +167,45 @@ func decryptSession(profile string, enc map[string]string, key string) (*AWSSess
 	if err != nil {
 		return nil, err
 	}
+	access, err := getField("Access")
+	if err != nil {
+		return nil, err
+	}
+	accountID, err := getField("AccountID")
+	if err != nil {
+		return nil, err
+	}
+	principalArn, err := getField("PrincipalArn")
+	if err != nil {
+		return nil, err
+	}
+	userID, err := getField("UserID")
+	if err != nil {
+		return nil, err
+	}
+	accountAlias, err := getField("AccountAlias")
+	if err != nil {
+		return nil, err
+	}
+	selfDestructStr, err := getField("SelfDestruct")
+	if err != nil {
+		return nil, err
+	}
+	var selfDestruct time.Time
+	if selfDestructStr != "" {
+		selfDestruct, _ = time.Parse(time.RFC3339, selfDestructStr)
+	}
+	rootTask, err := getField("RootTask")
+	if err != nil {
+		return nil, err
+	}
 
 	revoked := false
 	if val, ok := enc["Revoked"]; ok && val == "true" {
 		revoked = true
 	}
 
-	return &AWSSession{
+	s := &AWSSession{
 		Profile:       profile,
 		AccessKey:     accessKey,
 		SecretKey:     secretKey,
@@ -160,64 +218,86 @@ func decryptSession(profile string, enc map[string]string, key string) (*AWSSess
 		MfaArn:        mfaArn,
 		Duration:      duration,
 		Revoked:       revoked,
-	}, nil
+		Access:        access,
+		AccountID:     accountID,
+		PrincipalArn:  principalArn,
+		UserID:        userID,
+		AccountAlias:  accountAlias,
+		SelfDestruct:  selfDestruct,
+		RootTask:      rootTask,
+	}
+	if entryVersion(enc) < StoreVersion {
+		if problems := upgradeSession(s, enc); len(problems) > 0 {
+			return nil, &StaleSessionsError{Sessions: []StaleSession{{Profile: profile, Problems: problems}}}
+		}
+	}
+	return s, nil
 }
 
 // RemoveProfile deletes a stored profile.
 func RemoveProfile(profile string) error {
-	b, err := os.ReadFile(storePath)
-	if err != nil {
-		return fmt.Errorf("failed to read store: %w", err)
-	}
-
-	var data map[string]map[string]string
-	if err := json.Unmarshal(b, &data); err != nil {
-		return fmt.Errorf("failed to parse credentials: %w", err)
-	}
-
-	if _, ok := data[profile]; !ok {
-		return fmt.Errorf("profile '%s' not found", profile)
-	}
-
-	delete(data, profile)
-
-	if len(data) == 0 {
-		return os.Remove(storePath)
+	if err := removalSealer(); err != nil {
+		return err
 	}
-
-	out, err := json.MarshalIndent(data, "", "  ")
+	found, err := processStore.remove(profile)
 	if err != nil {
-		return fmt.Errorf("failed to marshal data: %w", err)
+		return err
+	}
+	if !found {
+		return fmt.Errorf("profile '%s' %w", profile, ErrProfileNotFound)
 	}
-	return os.WriteFile(storePath, out, 0600)
+	return forgetConsoleTokens(profile)
 }
 
 // ClearAllCredentials removes all stored sessions.
 func ClearAllCredentials() error {
+	defer processStore.clear()
+	profiles, _ := ListProfiles()
 	if err := os.Remove(storePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove credentials file: %w", err)
 	}
+	touchSyncItems(SyncSession, profiles...)
+	if err := os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove session index: %w", err)
+	}
+	if err := os.Remove(consoleCachePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove console cache: %w", err)
+	}
 	return nil
 }
 
 // ListAllSessions returns all stored AWS sessions.
 func ListAllSessions(key string) ([]*AWSSession, error) {
-	b, err := os.ReadFile(storePath)
+	provider, err := StoreProvider(key)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []*AWSSession{}, nil
-		}
-		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+		return nil, err
 	}
+	return ListAllSessionsWith(provider)
+}
 
-	var data map[string]map[string]string
-	if err := json.Unmarshal(b, &data); err != nil {
-		return nil, fmt.Errorf("failed to decode credentials: %w", err)
+// ListAllSessionsWith decrypts all stored sessions with an explicit provider.
+func ListAllSessionsWith(provider CryptoProvider) ([]*AWSSession, error) {
+	if err := processStore.verifySeal(provider); err != nil {
+		return nil, err
+	}
+	data, err := readStore()
+	if err != nil {
+		return nil, err
 	}
 
 	sessions := make([]*AWSSession, 0, len(data))
+	stale := &StaleSessionsError{}
 	for profile, enc := range data {
-		s, err := decryptSession(profile, enc, key)
+		if err := verifyEntry(profile, enc, provider); err != nil {
+			return nil, err
+		}
+		s, err := decryptSession(profile, enc, provider)
+		var staleErr *StaleSessionsError
+		if errors.As(err, &staleErr) {
+			// Keep going so the error lists every profile that needs a new login
+			stale.Sessions = append(stale.Sessions, staleErr.Sessions...)
+			continue
+		}
 		if err != nil {
 			// If one profile fails (e.g. wrong key for some reason), we might want to log it and continue
 			// but for now, we'll stop to be safe.
@@ -225,50 +305,92 @@ func ListAllSessions(key string) ([]*AWSSession, error) {
 		}
 		sessions = append(sessions, s)
 	}
+	if len(stale.Sessions) > 0 {
+		sort.Slice(stale.Sessions, func(i, j int) bool { return stale.Sessions[i].Profile < stale.Sessions[j].Profile })
+		return nil, stale
+	}
 
+	syncSessionIndex(sessions)
 	return sessions, nil
 }
 
-// ListProfiles returns just the names of stored profiles.
-func ListProfiles() ([]string, error) {
-	b, err := os.ReadFile(storePath)
+// StoreSummary describes the shape of the credential store without decrypting anything.
+type StoreSummary struct {
+	Path          string
+	Exists        bool
+	Mode          os.FileMode
+	SchemaVersion string
+	ProfileCount  int
+	Fields        []string
+}
+
+// DescribeStore inspects the credential store layout (profile count, stored field names)
+// for diagnostics. It never reads or returns any decrypted values.
+func DescribeStore() (*StoreSummary, error) {
+	summary := &StoreSummary{Path: storePath}
+
+	info, err := os.Stat(storePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return []string{}, nil
+			return summary, nil
 		}
-		return nil, fmt.Errorf("failed to read store: %w", err)
+		return nil, fmt.Errorf("failed to stat store: %w", err)
 	}
+	summary.Exists = true
+	summary.Mode = info.Mode().Perm()
 
-	var data map[string]interface{}
-	if err := json.Unmarshal(b, &data); err != nil {
-		return nil, fmt.Errorf("failed to parse credentials: %w", err)
+	data, err := readStore()
+	if err != nil {
+		return nil, err
 	}
 
-	keys := make([]string, 0, len(data))
-	for k := range data {
-		keys = append(keys, k)
+	fieldSet := make(map[string]bool)
+	for _, enc := range data {
+		for field := range enc {
+			fieldSet[field] = true
+		}
+	}
+	for field := range fieldSet {
+		summary.Fields = append(summary.Fields, field)
 	}
-	return keys, nil
+	sort.Strings(summary.Fields)
+
+	summary.ProfileCount = len(data)
+	summary.SchemaVersion = describeStoreVersion(data)
+	return summary, nil
+}
+
+// ListProfiles returns just the names of stored profiles.
+func ListProfiles() ([]string, error) {
+	return processStore.profiles()
 }
 
 // SaveMFADevice persists an MFA device ARN with an alias.
 func SaveMFADevice(name, arn string) error {
-	if err := os.MkdirAll(filepath.Dir(mfaStorePath), 0700); err != nil {
-		return fmt.Errorf("failed to create storage directory: %w", err)
-	}
-
 	devices, err := ListMFADevices()
 	if err != nil {
 		devices = make(map[string]string)
 	}
 
 	devices[name] = arn
+	return SaveAllMFADevices(devices)
+}
 
+// SaveAllMFADevices overwrites the entire MFA device alias store.
+func SaveAllMFADevices(devices map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(mfaStorePath), 0700); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
 	b, err := json.MarshalIndent(devices, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal MFA devices: %w", err)
 	}
-	return os.WriteFile(mfaStorePath, b, 0600)
+	before, _ := ListMFADevices()
+	if err := os.WriteFile(mfaStorePath, b, 0600); err != nil {
+		return err
+	}
+	touchSyncItems(SyncMFA, changedSyncNames(before, devices)...)
+	return nil
 }
 
 // ListMFADevices returns all stored MFA device aliases.
@@ -300,12 +422,7 @@ func RemoveMFADevice(name string) error {
 	}
 
 	delete(devices, name)
-
-	b, err := json.MarshalIndent(devices, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal MFA devices: %w", err)
-	}
-	return os.WriteFile(mfaStorePath, b, 0600)
+	return SaveAllMFADevices(devices)
 }
 
 // GetMFADevice retrieves an MFA ARN by its alias.
@@ -314,74 +431,3 @@ func GetMFADevice(name string) (string, bool) {
 	arn, ok := devices[name]
 	return arn, ok
 }
-
-// SaveRole persists an IAM Role ARN with an alias.
-func SaveRole(name, arn string) error {
-	roles, _ := ListRoles()
-	roles[name] = arn
-	return SaveAllRoles(roles)
-}
-
-// SaveAllRoles overwrites the entire role alias store.
-func SaveAllRoles(roles map[string]string) error {
-	if err := os.MkdirAll(filepath.Dir(roleStorePath), 0700); err != nil {
-		return fmt.Errorf("failed to create storage directory: %w", err)
-	}
-	b, err := json.MarshalIndent(roles, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal roles: %w", err)
-	}
-	return os.WriteFile(roleStorePath, b, 0600)
-}
-
-// ListRoles returns all stored IAM role aliases.
-func ListRoles() (map[string]string, error) {
-	roles := make(map[string]string)
-	b, err := os.ReadFile(roleStorePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return roles, nil
-		}
-		return nil, fmt.Errorf("failed to read roles store: %w", err)
-	}
-
-	if err := json.Unmarshal(b, &roles); err != nil {
-		return nil, fmt.Errorf("failed to parse roles store: %w", err)
-	}
-	return roles, nil
-}
-
-// RemoveRole deletes an IAM role alias.
-func RemoveRole(name string) error {
-	roles, err := ListRoles()
-	if err != nil {
-		return err
-	}
-
-	if _, ok := roles[name]; !ok {
-		return fmt.Errorf("role '%s' not found", name)
-	}
-
-	delete(roles, name)
-
-	if len(roles) == 0 {
-		return ClearAllRoles()
-	}
-
-	return SaveAllRoles(roles)
-}
-
-// ClearAllRoles removes the entire role alias file.
-func ClearAllRoles() error {
-	if err := os.Remove(roleStorePath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to clear roles: %w", err)
-	}
-	return nil
-}
-
-// GetRole retrieves an IAM Role ARN by its alias.
-func GetRole(name string) (string, bool) {
-	roles, _ := ListRoles()
-	arn, ok := roles[name]
-	return arn, ok
-}
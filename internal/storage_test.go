@@ -2,6 +2,7 @@ package internal
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -18,11 +19,20 @@ func setupTestDir(t *testing.T) string {
 	// Override the storePath variable for testing
 	// ensure we set it back after test
 	originalPath := storePath
+	originalIndexPath := indexPath
+	originalNotesPath := notesPath
+	originalConsoleCachePath := consoleCachePath
 	storePath = filepath.Join(dir, "credentials.json")
+	indexPath = filepath.Join(dir, "index.json")
+	notesPath = filepath.Join(dir, "notes.json")
+	consoleCachePath = filepath.Join(dir, "console-cache.json")
 
 	t.Cleanup(func() {
 		os.RemoveAll(dir)
 		storePath = originalPath
+		indexPath = originalIndexPath
+		notesPath = originalNotesPath
+		consoleCachePath = originalConsoleCachePath
 	})
 
 	return dir
@@ -43,6 +53,12 @@ func TestSaveAndLoadCredentials(t *testing.T) {
 		RoleArn:       "arn:aws:iam::123:role/TestRole",
 		SessionName:   profile,
 		SourceProfile: "default",
+		Access:        AccessReadOnly,
+		AccountID:     "123456789012",
+		PrincipalArn:  "arn:aws:sts::123456789012:assumed-role/ReadOnly/test",
+		UserID:        "AROATEST:test",
+		SelfDestruct:  time.Now().Add(30 * time.Minute),
+		RootTask:      "arn:aws:iam::aws:policy/root-task/S3UnlockBucketPolicy",
 	}
 
 	// 1. Save
@@ -69,6 +85,19 @@ func TestSaveAndLoadCredentials(t *testing.T) {
 	if loaded.SecretKey != session.SecretKey {
 		t.Errorf("SecretKey mismatch")
 	}
+	if loaded.Access != AccessReadOnly {
+		t.Errorf("Access mismatch. Got %q, want %q", loaded.Access, AccessReadOnly)
+	}
+	if loaded.AccountID != session.AccountID || loaded.PrincipalArn != session.PrincipalArn || loaded.UserID != session.UserID {
+		t.Errorf("Identity mismatch. Got %q %q %q", loaded.AccountID, loaded.PrincipalArn, loaded.UserID)
+	}
+
+	if loaded.SelfDestruct.Format(time.RFC3339) != session.SelfDestruct.Format(time.RFC3339) {
+		t.Errorf("SelfDestruct mismatch. Got %v, want %v", loaded.SelfDestruct, session.SelfDestruct)
+	}
+	if loaded.RootTask != session.RootTask {
+		t.Errorf("RootTask mismatch. Got %s, want %s", loaded.RootTask, session.RootTask)
+	}
 
 	// Compare times allowing for small serialization diff (RFC3339 loses some precision)
 	if !loaded.Expiration.Equal(session.Expiration) && loaded.Expiration.Format(time.RFC3339) != session.Expiration.Format(time.RFC3339) {
@@ -144,3 +173,15 @@ func TestRemoveProfile(t *testing.T) {
 		}
 	}
 }
+
+func TestProfileNotFound(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+
+	if _, err := LoadCredentials("missing", key); !errors.Is(err, ErrProfileNotFound) || err.Error() != "profile 'missing' not found in store" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := RemoveProfile("missing"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
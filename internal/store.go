@@ -0,0 +1,289 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// racyWindow is how long after a change a file's modification time can't be trusted to
+// change again: filesystems store it with coarse granularity, so a second write in the
+// same tick that keeps the size would otherwise go unnoticed.
+const racyWindow = time.Second
+
+// Store is the process-wide cache of credentials.json. The file is read and parsed once
+// and again only when it changes on disk (another cloudctl process or the daemon wrote
+// it). Writes go to the cache and are flushed right away, or once at the end of Batch.
+// All methods are safe for concurrent use.
+type Store struct {
+	mu sync.Mutex
+	// path is the file the cache was loaded from; tests move storePath.
+	path     string
+	data     map[string]map[string]string
+	raw      []byte
+	modTime  time.Time
+	size     int64
+	loadedAt time.Time
+
+	// seal is the store-level MAC over the profile names and their entry MACs; empty
+	// for a store written before seals existed.
+	seal string
+	// sealer is the provider the store was verified with since it was loaded, which
+	// seals it again when it is written.
+	sealer CryptoProvider
+
+	batch int
+	dirty bool
+	// index holds the session index changes of pending writes.
+	index []func(index map[string]IndexEntry)
+	// touched names the profiles of pending writes, for the store sync times.
+	touched []string
+}
+
+var processStore = &Store{}
+
+// CredentialStore returns the store of this process.
+func CredentialStore() *Store {
+	return processStore
+}
+
+// Batch runs fn with writes held in memory and flushes them once when it returns, also
+// when fn fails. Batches nest; writes from other goroutines during a batch are flushed
+// with it.
+func (st *Store) Batch(fn func() error) error {
+	st.mu.Lock()
+	st.batch++
+	st.mu.Unlock()
+
+	err := fn()
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.batch--
+	if st.batch > 0 {
+		return err
+	}
+	if flushErr := st.flushLocked(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// Flush writes pending changes to disk.
+func (st *Store) Flush() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.flushLocked()
+}
+
+// loadLocked brings the cache up to date with the file, unless it holds pending writes.
+func (st *Store) loadLocked() error {
+	if st.dirty && st.path == storePath {
+		return nil
+	}
+	info, err := os.Stat(storePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read credentials file: %w", err)
+		}
+		// Still missing: keep the provider that verified the empty store to seal it
+		if st.data == nil || st.path != storePath || st.raw != nil {
+			st.reset(make(map[string]map[string]string), nil, time.Time{}, 0)
+		}
+		return nil
+	}
+	if st.data != nil && st.path == storePath && info.ModTime().Equal(st.modTime) && info.Size() == st.size &&
+		st.loadedAt.Sub(st.modTime) > racyWindow {
+		return nil
+	}
+
+	b, err := os.ReadFile(storePath)
+	if err != nil {
+		return fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if st.data != nil && st.path == storePath && bytes.Equal(b, st.raw) {
+		st.modTime, st.size, st.loadedAt = info.ModTime(), info.Size(), time.Now()
+		return nil
+	}
+	data := make(map[string]map[string]string)
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &data); err != nil {
+			return fmt.Errorf("%w: %s is not valid JSON (%v); run 'cloudctl verify'", ErrStoreTampered, storePath, err)
+		}
+	}
+	st.reset(data, b, info.ModTime(), info.Size())
+	return nil
+}
+
+func (st *Store) reset(data map[string]map[string]string, raw []byte, modTime time.Time, size int64) {
+	st.path = storePath
+	st.seal, st.sealer = data[storeSealKey][macField], nil
+	delete(data, storeSealKey)
+	st.data, st.raw = data, raw
+	st.modTime, st.size, st.loadedAt = modTime, size, time.Now()
+	st.dirty, st.index = false, nil
+}
+
+// snapshot returns a copy of all entries, which the caller may change.
+func (st *Store) snapshot() (map[string]map[string]string, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.loadLocked(); err != nil {
+		return nil, err
+	}
+	data := make(map[string]map[string]string, len(st.data))
+	for profile, enc := range st.data {
+		data[profile] = copyEntry(enc)
+	}
+	return data, nil
+}
+
+// entry returns a copy of one profile's entry.
+func (st *Store) entry(profile string) (map[string]string, bool, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.loadLocked(); err != nil {
+		return nil, false, err
+	}
+	enc, ok := st.data[profile]
+	return copyEntry(enc), ok, nil
+}
+
+// profiles returns the stored profile names.
+func (st *Store) profiles() ([]string, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.loadLocked(); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(st.data))
+	for profile := range st.data {
+		names = append(names, profile)
+	}
+	return names, nil
+}
+
+// put stores an entry and the matching session index change.
+func (st *Store) put(profile string, enc map[string]string, index func(map[string]IndexEntry)) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if profile == storeSealKey {
+		return fmt.Errorf("'%s' is reserved and can't be used as a profile name", profile)
+	}
+	if err := st.loadLocked(); err != nil {
+		return err
+	}
+	st.data[profile] = copyEntry(enc)
+	st.touched = append(st.touched, profile)
+	return st.changedLocked(index)
+}
+
+// remove deletes an entry and reports whether it existed.
+func (st *Store) remove(profile string) (bool, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.loadLocked(); err != nil {
+		return false, err
+	}
+	if _, ok := st.data[profile]; !ok {
+		return false, nil
+	}
+	delete(st.data, profile)
+	st.touched = append(st.touched, profile)
+	return true, st.changedLocked(func(index map[string]IndexEntry) {
+		delete(index, profile)
+	})
+}
+
+// clear forgets the cache after the files were removed.
+func (st *Store) clear() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.reset(nil, nil, time.Time{}, 0)
+}
+
+func (st *Store) changedLocked(index func(map[string]IndexEntry)) error {
+	st.dirty = true
+	if index != nil {
+		st.index = append(st.index, index)
+	}
+	if st.batch > 0 {
+		return nil
+	}
+	return st.flushLocked()
+}
+
+// flushLocked writes the entries, or removes the file once the last one is gone, and
+// applies the pending index changes in one write.
+func (st *Store) flushLocked() error {
+	if !st.dirty {
+		return nil
+	}
+	if len(st.data) == 0 {
+		if err := os.Remove(st.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		st.raw, st.modTime, st.size, st.seal = nil, time.Time{}, 0, ""
+	} else {
+		if err := st.sealLocked(); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(st.path), 0700); err != nil {
+			return fmt.Errorf("failed to create storage directory: %w", err)
+		}
+		out := st.data
+		if st.seal != "" {
+			out = make(map[string]map[string]string, len(st.data)+1)
+			for profile, enc := range st.data {
+				out[profile] = enc
+			}
+			out[storeSealKey] = map[string]string{macField: st.seal}
+		}
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal credentials: %w", err)
+		}
+		if err := os.WriteFile(st.path, b, 0600); err != nil {
+			return err
+		}
+		if st.seal != "" {
+			if err := st.markLocked(); err != nil {
+				return err
+			}
+		}
+		st.raw = b
+		if info, err := os.Stat(st.path); err == nil {
+			st.modTime, st.size = info.ModTime(), info.Size()
+		}
+	}
+	st.loadedAt = time.Now()
+	st.dirty = false
+	touchSyncItems(SyncSession, st.touched...)
+	st.touched = nil
+
+	changes := st.index
+	st.index = nil
+	if len(changes) == 0 {
+		return nil
+	}
+	return updateSessionIndex(func(index map[string]IndexEntry) {
+		for _, change := range changes {
+			change(index)
+		}
+	})
+}
+
+func copyEntry(enc map[string]string) map[string]string {
+	if enc == nil {
+		return nil
+	}
+	out := make(map[string]string, len(enc))
+	for field, value := range enc {
+		out[field] = value
+	}
+	return out
+}
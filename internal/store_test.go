@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testSession(profile string) *AWSSession {
+	return &AWSSession{Profile: profile, AccessKey: "AKIA" + strings.ToUpper(profile), SecretKey: "s", SessionToken: "t",
+		Expiration: time.Now().Add(time.Hour), RoleArn: "arn:aws:iam::123456789012:role/" + profile}
+}
+
+func TestStoreNoticesExternalWrites(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	if err := SaveCredentials("dev", testSession("dev"), key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCredentials("dev", key); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process replaces the file right away, keeping its size
+	data := readTestStore(t)
+	data["prd"] = data["dev"]
+	delete(data, "dev")
+	writeTestStore(t, data)
+
+	if _, err := LoadCredentials("dev", key); err == nil {
+		t.Error("Expected the cached entry to be dropped after an external write")
+	}
+	profiles, _ := ListProfiles()
+	if len(profiles) != 1 || profiles[0] != "prd" {
+		t.Errorf("Unexpected profiles: %v", profiles)
+	}
+
+	os.Remove(storePath)
+	if profiles, _ := ListProfiles(); len(profiles) != 0 {
+		t.Errorf("Expected an empty store after the file was removed, got %v", profiles)
+	}
+}
+
+func TestStoreBatch(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+
+	err := CredentialStore().Batch(func() error {
+		for _, profile := range []string{"dev", "stg", "prd"} {
+			if err := SaveCredentials(profile, testSession(profile), key); err != nil {
+				return err
+			}
+		}
+		if _, err := os.Stat(storePath); !os.IsNotExist(err) {
+			t.Error("Expected writes to be held until the batch ends")
+		}
+		// Reads in the batch see its writes
+		s, err := LoadCredentials("stg", key)
+		if err != nil || s.AccessKey != "AKIASTG" {
+			t.Errorf("Unexpected session in batch: %+v (%v)", s, err)
+		}
+		return RemoveProfile("prd")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := readTestStore(t)
+	if len(data) != 2 || data["dev"] == nil || data["stg"] == nil {
+		t.Errorf("Unexpected store after batch: %d entries", len(data))
+	}
+	index, _ := LoadSessionIndex()
+	if _, ok := index["stg"]; !ok || len(index) != 2 {
+		t.Errorf("Unexpected index after batch: %v", index)
+	}
+}
+
+func TestStoreConcurrentSaves(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			profile := fmt.Sprintf("p%d", i)
+			if err := SaveCredentials(profile, testSession(profile), key); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	sessions, err := ListAllSessions(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 8 {
+		t.Errorf("Expected 8 sessions, got %d", len(sessions))
+	}
+}
@@ -0,0 +1,526 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// storeSyncFormat identifies the encrypted store sync document.
+const storeSyncFormat = "cloudctl-store-sync-v1"
+
+// storeSyncTombstoneTTL is how long deletions stay in the document, so machines that
+// sync less often still learn about them.
+const storeSyncTombstoneTTL = 90 * 24 * time.Hour
+
+// storeSyncStatePath records what this machine had after its last sync.
+var storeSyncStatePath = filepath.Join(storeDir, "store-sync.json")
+
+// syncTimesName is the file next to credentials.json recording when this machine last
+// changed or removed each synced item, keyed like the sync document. Conflicts are decided
+// with these times rather than those of whole files, which change with every item in them.
+const syncTimesName = "store-sync-times.json"
+
+// Kinds of synced items
+const (
+	SyncSession = "session"
+	SyncRole    = "role"
+	SyncMFA     = "mfa"
+)
+
+// syncedItem is one store entry (still encrypted), role alias or MFA device in the
+// store sync document.
+type syncedItem struct {
+	Value   json.RawMessage `json:"value,omitempty"`
+	Updated time.Time       `json:"updated"`
+	Host    string          `json:"host"`
+	Deleted bool            `json:"deleted,omitempty"`
+}
+
+// storeSyncDocument is the decrypted store sync document.
+type storeSyncDocument struct {
+	// Items are keyed by "kind/name".
+	Items map[string]syncedItem `json:"items"`
+}
+
+// storeSyncState is the hash of every item as this machine last synced it, the common
+// base that tells which side changed an item.
+type storeSyncState struct {
+	URL    string            `json:"url"`
+	Hashes map[string]string `json:"hashes"`
+}
+
+// StoreSyncChange is one item copied by a sync.
+type StoreSyncChange struct {
+	Kind string
+	Name string
+	// Pulled is set for changes taken from the remote copy; others were pushed to it.
+	Pulled  bool
+	Deleted bool
+	// Conflict is set when both sides changed the item since the last sync; the side
+	// that changed it last won.
+	Conflict bool
+}
+
+// StoreSyncResult is what a sync did.
+type StoreSyncResult struct {
+	Changes []StoreSyncChange
+	// Rejected names remote sessions that failed their integrity check and were kept out
+	// of the store.
+	Rejected []string
+}
+
+// newStoreSyncBackend returns the backend for store_sync.url. Tests replace it.
+var newStoreSyncBackend = func(ctx context.Context) (remoteBackend, error) {
+	sc := CurrentConfig().StoreSync
+	return openRemoteBackend(ctx, sc.URL, sc.Profile, sc.Region)
+}
+
+// StoreSyncEnabled reports whether store_sync.url is configured.
+func StoreSyncEnabled() bool {
+	return CurrentConfig().StoreSync.URL != ""
+}
+
+func syncKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+func hashSyncValue(value []byte) string {
+	if value == nil {
+		return ""
+	}
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
+
+// localSyncItems returns this machine's store entries, role aliases and MFA devices by
+// sync key, and when each of them was last changed or removed.
+func localSyncItems() (map[string][]byte, map[string]time.Time, error) {
+	items := make(map[string][]byte)
+	entries, err := readStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	for profile, enc := range entries {
+		b, _ := json.Marshal(enc)
+		items[syncKey(SyncSession, profile)] = b
+	}
+	roles, err := ListRoleAliases()
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, alias := range roles {
+		b, _ := json.Marshal(alias)
+		items[syncKey(SyncRole, name)] = b
+	}
+	devices, err := ListMFADevices()
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, arn := range devices {
+		b, _ := json.Marshal(arn)
+		items[syncKey(SyncMFA, name)] = b
+	}
+
+	// Items changed before store sync was enabled have no time of their own; the
+	// modification time of their file is the best guess
+	modified := make(map[string]time.Time)
+	for kind, path := range map[string]string{SyncSession: storePath, SyncRole: roleStorePath, SyncMFA: mfaStorePath} {
+		if info, err := os.Stat(path); err == nil {
+			modified[kind] = info.ModTime().UTC().Truncate(time.Second)
+		}
+	}
+	updated := loadSyncTimes()
+	for key := range items {
+		if updated[key].IsZero() {
+			kind, _, _ := strings.Cut(key, "/")
+			updated[key] = modified[kind]
+		}
+	}
+	return items, updated, nil
+}
+
+func syncTimesPath() string {
+	return filepath.Join(filepath.Dir(storePath), syncTimesName)
+}
+
+func loadSyncTimes() map[string]time.Time {
+	times := make(map[string]time.Time)
+	if b, err := os.ReadFile(syncTimesPath()); err == nil {
+		// A corrupt record only makes sync fall back to the file times
+		_ = json.Unmarshal(b, &times)
+	}
+	return times
+}
+
+func saveSyncTimes(times map[string]time.Time) {
+	if len(times) == 0 {
+		_ = os.Remove(syncTimesPath())
+		return
+	}
+	b, err := json.MarshalIndent(times, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(syncTimesPath(), b, 0600)
+}
+
+// recordSyncTimes merges the times of changed items by sync key into the record. It is
+// best effort and does nothing while store sync is off.
+func recordSyncTimes(times map[string]time.Time) {
+	if len(times) == 0 || !StoreSyncEnabled() {
+		return
+	}
+	record := loadSyncTimes()
+	for key, t := range times {
+		record[key] = t.UTC().Truncate(time.Second)
+	}
+	saveSyncTimes(record)
+}
+
+// touchSyncItems records that the named items of a kind changed now.
+func touchSyncItems(kind string, names ...string) {
+	now := time.Now()
+	times := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		times[syncKey(kind, name)] = now
+	}
+	recordSyncTimes(times)
+}
+
+// changedSyncNames returns the names added, changed or removed between two versions of
+// a file of items.
+func changedSyncNames[V comparable](before, after map[string]V) []string {
+	var names []string
+	for name, v := range after {
+		if old, ok := before[name]; !ok || old != v {
+			names = append(names, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func loadStoreSyncState(url string) storeSyncState {
+	state := storeSyncState{URL: url, Hashes: map[string]string{}}
+	b, err := os.ReadFile(storeSyncStatePath)
+	if err != nil {
+		return state
+	}
+	var saved storeSyncState
+	// The base only holds for the copy it was synced with
+	if json.Unmarshal(b, &saved) != nil || saved.URL != url || saved.Hashes == nil {
+		return state
+	}
+	return saved
+}
+
+func saveStoreSyncState(state storeSyncState) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(storeSyncStatePath, b, 0600)
+}
+
+func decodeStoreSyncDocument(b []byte, provider CryptoProvider) (*storeSyncDocument, error) {
+	doc := &storeSyncDocument{Items: map[string]syncedItem{}}
+	if len(b) == 0 {
+		return doc, nil
+	}
+	var env remoteEnvelope
+	if err := json.Unmarshal(b, &env); err != nil || env.Format != storeSyncFormat {
+		return nil, fmt.Errorf("the store sync copy is not a cloudctl store sync document")
+	}
+	enc, err := base64.StdEncoding.DecodeString(env.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the store sync copy: %w", err)
+	}
+	plain, err := provider.Decrypt(enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the store sync copy (is the secret the same on every machine?): %w", err)
+	}
+	if err := json.Unmarshal(plain, doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the store sync copy: %w", err)
+	}
+	if doc.Items == nil {
+		doc.Items = map[string]syncedItem{}
+	}
+	return doc, nil
+}
+
+func encodeStoreSyncDocument(doc *storeSyncDocument, provider CryptoProvider) ([]byte, error) {
+	plain, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := provider.Encrypt(plain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt the store sync copy: %w", err)
+	}
+	return json.Marshal(remoteEnvelope{Format: storeSyncFormat, Data: base64.StdEncoding.EncodeToString(enc)})
+}
+
+// SyncStore syncs the store, role aliases and MFA devices both ways with the copy at
+// store_sync.url. An item changed on one side since the last sync is copied to the
+// other; when both changed it, the later change wins. Store entries travel as they are,
+// encrypted with the secret, inside a document encrypted with it again, so every machine
+// needs the same secret. With dryRun, nothing is written on either side.
+func SyncStore(ctx context.Context, secret string, dryRun bool) (*StoreSyncResult, error) {
+	if CurrentConfig().Encryption.UsesEnvelope() {
+		return nil, fmt.Errorf("store sync needs sessions encrypted with the cloudctl secret, not the %s provider, whose keys stay on each machine", CurrentConfig().Encryption.Provider)
+	}
+	docProvider, err := remoteProvider(secret)
+	if err != nil {
+		return nil, err
+	}
+	storeProvider, err := StoreProvider(secret)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := newStoreSyncBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := backend.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the store sync copy: %w", err)
+	}
+	doc, err := decodeStoreSyncDocument(raw, docProvider)
+	if err != nil {
+		return nil, err
+	}
+	local, updated, err := localSyncItems()
+	if err != nil {
+		return nil, err
+	}
+	state := loadStoreSyncState(CurrentConfig().StoreSync.URL)
+
+	keys := make(map[string]bool)
+	for key := range local {
+		keys[key] = true
+	}
+	for key := range doc.Items {
+		keys[key] = true
+	}
+	for key := range state.Hashes {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	host := RemoteHost()
+	result := &StoreSyncResult{}
+	pulls := make(map[string]*syncedItem)
+	pushed := false
+	for _, key := range sorted {
+		kind, name, _ := strings.Cut(key, "/")
+		localValue, hasLocal := local[key]
+		localHash := hashSyncValue(localValue)
+		remote, hasRemote := doc.Items[key]
+		remoteHash := ""
+		if hasRemote && !remote.Deleted {
+			remoteHash = hashSyncValue(remote.Value)
+		}
+		if localHash == remoteHash {
+			state.setHash(key, localHash)
+			continue
+		}
+
+		base := state.Hashes[key]
+		localChanged, remoteChanged := localHash != base, remoteHash != base
+		change := StoreSyncChange{Kind: kind, Name: name, Conflict: localChanged && remoteChanged}
+		localUpdated := updated[key]
+		if localUpdated.IsZero() {
+			localUpdated = now
+		}
+		if localChanged && (!remoteChanged || !remote.Updated.After(localUpdated)) {
+			// Push
+			if hasLocal {
+				doc.Items[key] = syncedItem{Value: localValue, Updated: localUpdated, Host: host}
+			} else {
+				doc.Items[key] = syncedItem{Updated: localUpdated, Host: host, Deleted: true}
+				change.Deleted = true
+			}
+			pushed = true
+			state.setHash(key, localHash)
+		} else {
+			change.Pulled = true
+			if remoteHash == "" {
+				change.Deleted = true
+				pulls[key] = nil
+			} else {
+				if kind == SyncSession {
+					if _, err := decodeSyncedSession(name, remote.Value, storeProvider); err != nil {
+						result.Rejected = append(result.Rejected, name)
+						continue
+					}
+				}
+				item := remote
+				pulls[key] = &item
+			}
+			state.setHash(key, remoteHash)
+		}
+		result.Changes = append(result.Changes, change)
+	}
+	for key, item := range doc.Items {
+		if item.Deleted && now.Sub(item.Updated) > storeSyncTombstoneTTL {
+			delete(doc.Items, key)
+			pushed = true
+		}
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	if err := applyStorePulls(pulls, storeProvider); err != nil {
+		return nil, err
+	}
+	// Both sides agree now, so only later changes need a time, besides those of sessions
+	// whose remote copy was rejected
+	times := loadSyncTimes()
+	for key := range times {
+		kind, name, _ := strings.Cut(key, "/")
+		if kind != SyncSession || !slices.Contains(result.Rejected, name) {
+			delete(times, key)
+		}
+	}
+	saveSyncTimes(times)
+	if pushed || len(raw) == 0 {
+		out, err := encodeStoreSyncDocument(doc, docProvider)
+		if err != nil {
+			return nil, err
+		}
+		if err := backend.Put(ctx, out); err != nil {
+			return nil, fmt.Errorf("failed to write the store sync copy: %w", err)
+		}
+	}
+	if err := saveStoreSyncState(state); err != nil {
+		return nil, fmt.Errorf("failed to save store sync state: %w", err)
+	}
+	return result, nil
+}
+
+func (st *storeSyncState) setHash(key, hash string) {
+	if hash == "" {
+		delete(st.Hashes, key)
+		return
+	}
+	st.Hashes[key] = hash
+}
+
+// decodeSyncedSession checks a store entry from the sync copy and returns it with the
+// session it holds.
+func decodeSyncedSession(profile string, value []byte, provider CryptoProvider) (map[string]string, error) {
+	var enc map[string]string
+	if err := json.Unmarshal(value, &enc); err != nil {
+		return nil, err
+	}
+	if err := verifyEntry(profile, enc, provider); err != nil {
+		return nil, err
+	}
+	if _, err := decryptSession(profile, enc, provider); err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+// applyStorePulls writes the items taken from the sync copy (nil for deletions) to the
+// store, roles.json and mfa.json, each file once.
+func applyStorePulls(pulls map[string]*syncedItem, provider CryptoProvider) error {
+	if len(pulls) == 0 {
+		return nil
+	}
+	if err := processStore.verifySeal(provider); err != nil {
+		return err
+	}
+	var roles map[string]RoleAlias
+	var devices map[string]string
+	err := processStore.Batch(func() error {
+		for key, item := range pulls {
+			kind, name, _ := strings.Cut(key, "/")
+			switch kind {
+			case SyncSession:
+				if item == nil {
+					if _, err := processStore.remove(name); err != nil {
+						return err
+					}
+					continue
+				}
+				enc, err := decodeSyncedSession(name, item.Value, provider)
+				if err != nil {
+					return err
+				}
+				s, _ := decryptSession(name, enc, provider)
+				entry := indexEntryFor(s)
+				if err := processStore.put(name, enc, func(index map[string]IndexEntry) { index[name] = entry }); err != nil {
+					return err
+				}
+			case SyncRole:
+				if roles == nil {
+					var err error
+					if roles, err = ListRoleAliases(); err != nil {
+						return err
+					}
+				}
+				if item == nil {
+					delete(roles, name)
+					continue
+				}
+				var alias RoleAlias
+				if err := json.Unmarshal(item.Value, &alias); err != nil {
+					return fmt.Errorf("invalid role alias '%s' in the store sync copy: %w", name, err)
+				}
+				roles[name] = alias
+			case SyncMFA:
+				if devices == nil {
+					var err error
+					if devices, err = ListMFADevices(); err != nil {
+						return err
+					}
+				}
+				if item == nil {
+					delete(devices, name)
+					continue
+				}
+				var arn string
+				if err := json.Unmarshal(item.Value, &arn); err != nil {
+					return fmt.Errorf("invalid MFA device '%s' in the store sync copy: %w", name, err)
+				}
+				devices[name] = arn
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if roles != nil {
+		if err := SaveAllRoleAliases(roles); err != nil {
+			return err
+		}
+	}
+	if devices != nil {
+		if err := SaveAllMFADevices(devices); err != nil {
+			return err
+		}
+	}
+	return nil
+}
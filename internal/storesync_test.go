@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// setupStoreSync configures store_sync.url with an in-memory copy and returns a function
+// that switches between the stores of two machines, "desktop" and "laptop".
+func setupStoreSync(t *testing.T) (*memoryBackend, func(machine string)) {
+	t.Helper()
+	setTestConfig(t, func(c *Config) {
+		c.StoreSync = StoreSyncConfig{URL: "s3://my-bucket/cloudctl/store.json"}
+	})
+
+	backend := &memoryBackend{}
+	originalBackend := newStoreSyncBackend
+	newStoreSyncBackend = func(ctx context.Context) (remoteBackend, error) { return backend, nil }
+
+	dir := t.TempDir()
+	paths := []*string{&storePath, &indexPath, &notesPath, &roleStorePath, &mfaStorePath, &storeSyncStatePath}
+	originals := make([]string, len(paths))
+	for i, p := range paths {
+		originals[i] = *p
+	}
+	t.Cleanup(func() {
+		newStoreSyncBackend = originalBackend
+		for i, p := range paths {
+			*p = originals[i]
+		}
+	})
+
+	use := func(machine string) {
+		d := filepath.Join(dir, machine)
+		os.MkdirAll(d, 0700)
+		storePath = filepath.Join(d, "credentials.json")
+		indexPath = filepath.Join(d, "index.json")
+		notesPath = filepath.Join(d, "notes.json")
+		roleStorePath = filepath.Join(d, "roles.json")
+		mfaStorePath = filepath.Join(d, "mfa.json")
+		storeSyncStatePath = filepath.Join(d, "store-sync.json")
+	}
+	use("desktop")
+	return backend, use
+}
+
+func syncStore(t *testing.T, secret string) *StoreSyncResult {
+	t.Helper()
+	result, err := SyncStore(context.Background(), secret, false)
+	if err != nil {
+		t.Fatalf("SyncStore failed: %v", err)
+	}
+	return result
+}
+
+func TestSyncStorePushAndPull(t *testing.T) {
+	backend, use := setupStoreSync(t)
+	secret := "1234567890ABCDEF1234567890ABCDEF"
+
+	if err := SaveCredentials("dev-admin", testSession("dev-admin"), secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveRoleAlias("prod-admin", RoleAlias{ARN: "arn:aws:iam::123456789012:role/Admin"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveMFADevice("work", "arn:aws:iam::123456789012:mfa/me"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run writes nothing
+	if result, err := SyncStore(context.Background(), secret, true); err != nil || len(result.Changes) != 3 {
+		t.Fatalf("Expected 3 changes in the dry run, got %+v, %v", result, err)
+	}
+	if backend.data != nil {
+		t.Fatal("The dry run wrote the sync copy")
+	}
+
+	result := syncStore(t, secret)
+	if len(result.Changes) != 3 || result.Changes[0].Pulled {
+		t.Fatalf("Expected 3 pushed changes, got %+v", result.Changes)
+	}
+	if result := syncStore(t, secret); len(result.Changes) != 0 {
+		t.Errorf("Expected nothing to sync again, got %+v", result.Changes)
+	}
+
+	use("laptop")
+	result = syncStore(t, secret)
+	if len(result.Changes) != 3 || !result.Changes[0].Pulled {
+		t.Fatalf("Expected 3 pulled changes, got %+v", result.Changes)
+	}
+	s, err := LoadCredentials("dev-admin", secret)
+	if err != nil || s.AccessKey != testSession("dev-admin").AccessKey {
+		t.Errorf("The pulled session doesn't load: %+v, %v", s, err)
+	}
+	if alias, ok := GetRoleAlias("prod-admin"); !ok || alias.ARN != "arn:aws:iam::123456789012:role/Admin" {
+		t.Errorf("Role alias not pulled: %+v", alias)
+	}
+	if devices, _ := ListMFADevices(); devices["work"] != "arn:aws:iam::123456789012:mfa/me" {
+		t.Errorf("MFA device not pulled: %v", devices)
+	}
+
+	// A deletion travels back as a tombstone
+	if err := RemoveProfile("dev-admin"); err != nil {
+		t.Fatal(err)
+	}
+	if result := syncStore(t, secret); len(result.Changes) != 1 || !result.Changes[0].Deleted || result.Changes[0].Pulled {
+		t.Fatalf("Expected a pushed deletion, got %+v", result.Changes)
+	}
+	use("desktop")
+	if result := syncStore(t, secret); len(result.Changes) != 1 || !result.Changes[0].Deleted || !result.Changes[0].Pulled {
+		t.Fatalf("Expected a pulled deletion, got %+v", result.Changes)
+	}
+	if _, err := LoadCredentials("dev-admin", secret); err == nil {
+		t.Error("The deleted session is still stored")
+	}
+}
+
+func TestSyncStoreConflict(t *testing.T) {
+	_, use := setupStoreSync(t)
+	secret := "1234567890ABCDEF1234567890ABCDEF"
+	SaveRoleAlias("prod-admin", RoleAlias{ARN: "arn:aws:iam::123456789012:role/Admin"})
+	syncStore(t, secret)
+	use("laptop")
+	syncStore(t, secret)
+
+	// Both change the alias; the laptop syncs first, but the desktop changed it later
+	SaveRoleAlias("prod-admin", RoleAlias{ARN: "arn:aws:iam::123456789012:role/Laptop"})
+	recordSyncTimes(map[string]time.Time{syncKey(SyncRole, "prod-admin"): time.Now().Add(-time.Hour)})
+	syncStore(t, secret)
+
+	use("desktop")
+	SaveRoleAlias("prod-admin", RoleAlias{ARN: "arn:aws:iam::123456789012:role/Desktop"})
+	result := syncStore(t, secret)
+	if len(result.Changes) != 1 || !result.Changes[0].Conflict || result.Changes[0].Pulled {
+		t.Fatalf("Expected the desktop's change to win a conflict, got %+v", result.Changes)
+	}
+
+	use("laptop")
+	syncStore(t, secret)
+	if alias, _ := GetRoleAlias("prod-admin"); alias.ARN != "arn:aws:iam::123456789012:role/Desktop" {
+		t.Errorf("Expected the later change on the laptop, got %s", alias.ARN)
+	}
+}
+
+func TestSyncStoreConflictUsesItemTimes(t *testing.T) {
+	_, use := setupStoreSync(t)
+	secret := "1234567890ABCDEF1234567890ABCDEF"
+	SaveRoleAlias("prod-admin", RoleAlias{ARN: "arn:aws:iam::123456789012:role/Admin"})
+	syncStore(t, secret)
+	use("laptop")
+	syncStore(t, secret)
+
+	// The laptop changes the alias an hour ago and another one just now, which makes
+	// roles.json newer than the desktop's change
+	SaveRoleAlias("prod-admin", RoleAlias{ARN: "arn:aws:iam::123456789012:role/Laptop"})
+	recordSyncTimes(map[string]time.Time{syncKey(SyncRole, "prod-admin"): time.Now().Add(-time.Hour)})
+	use("desktop")
+	SaveRoleAlias("prod-admin", RoleAlias{ARN: "arn:aws:iam::123456789012:role/Desktop"})
+	recordSyncTimes(map[string]time.Time{syncKey(SyncRole, "prod-admin"): time.Now().Add(-30 * time.Minute)})
+	syncStore(t, secret)
+	use("laptop")
+	later := time.Now().Add(time.Minute)
+	SaveRoleAlias("dev-admin", RoleAlias{ARN: "arn:aws:iam::123456789012:role/Dev"})
+	os.Chtimes(roleStorePath, later, later)
+
+	result := syncStore(t, secret)
+	var conflict *StoreSyncChange
+	for i, c := range result.Changes {
+		if c.Name == "prod-admin" {
+			conflict = &result.Changes[i]
+		}
+	}
+	if conflict == nil || !conflict.Conflict || !conflict.Pulled {
+		t.Fatalf("Expected the desktop's later change to win the conflict, got %+v", result.Changes)
+	}
+	if alias, _ := GetRoleAlias("prod-admin"); alias.ARN != "arn:aws:iam::123456789012:role/Desktop" {
+		t.Errorf("Expected the desktop's change on the laptop, got %s", alias.ARN)
+	}
+	if times := loadSyncTimes(); len(times) != 0 {
+		t.Errorf("Expected no item times left after the sync, got %v", times)
+	}
+}
+
+func TestSyncStoreRejectsTamperedSession(t *testing.T) {
+	backend, use := setupStoreSync(t)
+	secret := "1234567890ABCDEF1234567890ABCDEF"
+	SaveCredentials("dev-admin", testSession("dev-admin"), secret)
+	syncStore(t, secret)
+
+	// Someone with the secret but not the store rewrites the entry's role in the copy
+	provider := NewSecretProvider(secret)
+	doc, err := decodeStoreSyncDocument(backend.data, provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := doc.Items[syncKey(SyncSession, "dev-admin")]
+	var enc map[string]string
+	json.Unmarshal(item.Value, &enc)
+	enc["RoleArn"] = base64.StdEncoding.EncodeToString([]byte("tampered"))
+	item.Value, _ = json.Marshal(enc)
+	item.Updated = time.Now().Add(time.Hour)
+	doc.Items[syncKey(SyncSession, "dev-admin")] = item
+	backend.data, _ = encodeStoreSyncDocument(doc, provider)
+
+	use("laptop")
+	result := syncStore(t, secret)
+	if len(result.Rejected) != 1 || result.Rejected[0] != "dev-admin" {
+		t.Fatalf("Expected dev-admin to be rejected, got %+v", result)
+	}
+	if _, err := LoadCredentials("dev-admin", secret); err == nil {
+		t.Error("The tampered session was stored")
+	}
+}
+
+func TestSyncStoreRefusesEnvelopeProvider(t *testing.T) {
+	setupStoreSync(t)
+	loadedConfig.Encryption.Provider = ProviderKMS
+	if _, err := SyncStore(context.Background(), "1234567890ABCDEF1234567890ABCDEF", false); err == nil {
+		t.Error("Expected store sync to refuse the KMS provider")
+	}
+}
@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// STSAPI is the part of the STS client cloudctl calls. Every STS client is made by
+// NewSTSClient, so login, refresh and the daemon can run against MockSTSClient.
+type STSAPI interface {
+	AssumeRole(ctx context.Context, in *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error)
+	AssumeRoot(ctx context.Context, in *sts.AssumeRootInput, optFns ...func(*sts.Options)) (*sts.AssumeRootOutput, error)
+	GetSessionToken(ctx context.Context, in *sts.GetSessionTokenInput, optFns ...func(*sts.Options)) (*sts.GetSessionTokenOutput, error)
+	GetCallerIdentity(ctx context.Context, in *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+	GetAccessKeyInfo(ctx context.Context, in *sts.GetAccessKeyInfoInput, optFns ...func(*sts.Options)) (*sts.GetAccessKeyInfoOutput, error)
+}
+
+var _ STSAPI = (*sts.Client)(nil)
+
+// newSTSClient makes the STS clients of this process; tests replace it with
+// UseSTSClient.
+var newSTSClient = func(cfg aws.Config, optFns ...func(*sts.Options)) STSAPI {
+	return sts.NewFromConfig(cfg, optFns...)
+}
+
+// UseSTSClient makes every STS client of this process the given one, until the returned
+// function restores the real client. It is meant for tests.
+func UseSTSClient(client STSAPI) (restore func()) {
+	original := newSTSClient
+	newSTSClient = func(aws.Config, ...func(*sts.Options)) STSAPI { return client }
+	return func() { newSTSClient = original }
+}
+
+// STSCall is a call recorded by MockSTSClient.
+type STSCall struct {
+	Operation string
+	// Input is the *sts.<Operation>Input the call was made with.
+	Input any
+}
+
+// MockSTSClient is an in-memory STSAPI for tests. It records every call and answers
+// with the matching hook, or by default with made-up credentials that are valid for
+// the requested duration and predictable: the nth call returns ASIAMOCK0000000n.
+// It is safe for concurrent use.
+type MockSTSClient struct {
+	AssumeRoleFunc        func(*sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error)
+	AssumeRootFunc        func(*sts.AssumeRootInput) (*sts.AssumeRootOutput, error)
+	GetSessionTokenFunc   func(*sts.GetSessionTokenInput) (*sts.GetSessionTokenOutput, error)
+	GetCallerIdentityFunc func(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error)
+	GetAccessKeyInfoFunc  func(*sts.GetAccessKeyInfoInput) (*sts.GetAccessKeyInfoOutput, error)
+
+	// Account is the account of the default answers (default 123456789012).
+	Account string
+	// Now is the clock for default expirations (default time.Now).
+	Now func() time.Time
+
+	mu    sync.Mutex
+	calls []STSCall
+}
+
+// Calls returns the calls made so far, oldest first.
+func (m *MockSTSClient) Calls() []STSCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]STSCall(nil), m.calls...)
+}
+
+// CallCount returns how many calls of an operation were made.
+func (m *MockSTSClient) CallCount(operation string) int {
+	n := 0
+	for _, c := range m.Calls() {
+		if c.Operation == operation {
+			n++
+		}
+	}
+	return n
+}
+
+// record stores a call and returns its number, starting at 1.
+func (m *MockSTSClient) record(ctx context.Context, operation string, in any) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, STSCall{Operation: operation, Input: in})
+	return len(m.calls), nil
+}
+
+func (m *MockSTSClient) account() string {
+	if m.Account != "" {
+		return m.Account
+	}
+	return "123456789012"
+}
+
+func (m *MockSTSClient) credentials(n int, duration *int32, fallback int32) *types.Credentials {
+	now := time.Now
+	if m.Now != nil {
+		now = m.Now
+	}
+	seconds := aws.ToInt32(duration)
+	if seconds == 0 {
+		seconds = fallback
+	}
+	return &types.Credentials{
+		AccessKeyId:     aws.String(fmt.Sprintf("ASIAMOCK%08d", n)),
+		SecretAccessKey: aws.String(fmt.Sprintf("mock-secret-%d", n)),
+		SessionToken:    aws.String(fmt.Sprintf("mock-token-%d", n)),
+		Expiration:      aws.Time(now().Add(time.Duration(seconds) * time.Second).UTC().Truncate(time.Second)),
+	}
+}
+
+func (m *MockSTSClient) AssumeRole(ctx context.Context, in *sts.AssumeRoleInput, _ ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	n, err := m.record(ctx, "AssumeRole", in)
+	if err != nil {
+		return nil, err
+	}
+	if m.AssumeRoleFunc != nil {
+		return m.AssumeRoleFunc(in)
+	}
+	roleArn, sessionName := aws.ToString(in.RoleArn), aws.ToString(in.RoleSessionName)
+	assumed := strings.Replace(strings.Replace(roleArn, ":iam:", ":sts:", 1), ":role/", ":assumed-role/", 1) + "/" + sessionName
+	return &sts.AssumeRoleOutput{
+		Credentials: m.credentials(n, in.DurationSeconds, 3600),
+		AssumedRoleUser: &types.AssumedRoleUser{
+			Arn:           aws.String(assumed),
+			AssumedRoleId: aws.String(fmt.Sprintf("AROAMOCK%08d:%s", n, sessionName)),
+		},
+	}, nil
+}
+
+func (m *MockSTSClient) AssumeRoot(ctx context.Context, in *sts.AssumeRootInput, _ ...func(*sts.Options)) (*sts.AssumeRootOutput, error) {
+	n, err := m.record(ctx, "AssumeRoot", in)
+	if err != nil {
+		return nil, err
+	}
+	if m.AssumeRootFunc != nil {
+		return m.AssumeRootFunc(in)
+	}
+	return &sts.AssumeRootOutput{Credentials: m.credentials(n, in.DurationSeconds, MaxRootSessionSeconds)}, nil
+}
+
+func (m *MockSTSClient) GetSessionToken(ctx context.Context, in *sts.GetSessionTokenInput, _ ...func(*sts.Options)) (*sts.GetSessionTokenOutput, error) {
+	n, err := m.record(ctx, "GetSessionToken", in)
+	if err != nil {
+		return nil, err
+	}
+	if m.GetSessionTokenFunc != nil {
+		return m.GetSessionTokenFunc(in)
+	}
+	return &sts.GetSessionTokenOutput{Credentials: m.credentials(n, in.DurationSeconds, 43200)}, nil
+}
+
+func (m *MockSTSClient) GetCallerIdentity(ctx context.Context, in *sts.GetCallerIdentityInput, _ ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	if _, err := m.record(ctx, "GetCallerIdentity", in); err != nil {
+		return nil, err
+	}
+	if m.GetCallerIdentityFunc != nil {
+		return m.GetCallerIdentityFunc(in)
+	}
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String(m.account()),
+		Arn:     aws.String("arn:aws:iam::" + m.account() + ":user/mock"),
+		UserId:  aws.String("AIDAMOCK"),
+	}, nil
+}
+
+func (m *MockSTSClient) GetAccessKeyInfo(ctx context.Context, in *sts.GetAccessKeyInfoInput, _ ...func(*sts.Options)) (*sts.GetAccessKeyInfoOutput, error) {
+	if _, err := m.record(ctx, "GetAccessKeyInfo", in); err != nil {
+		return nil, err
+	}
+	if m.GetAccessKeyInfoFunc != nil {
+		return m.GetAccessKeyInfoFunc(in)
+	}
+	return &sts.GetAccessKeyInfoOutput{Account: aws.String(m.account())}, nil
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// setupMockSTS points every STS client at a new MockSTSClient, uses the default config
+// and keeps usage events out of the real audit log.
+func setupMockSTS(t *testing.T) *MockSTSClient {
+	setupTestDir(t)
+	originalLog := auditLogPath
+	auditLogPath = filepath.Join(t.TempDir(), "audit.log")
+	setTestConfig(t, nil)
+	mock := &MockSTSClient{}
+	restore := UseSTSClient(mock)
+	originalAliases := listAccountAliases
+	listAccountAliases = func(context.Context, aws.Config) ([]string, error) { return nil, nil }
+	t.Cleanup(func() {
+		restore()
+		listAccountAliases = originalAliases
+		auditLogPath = originalLog
+	})
+	return mock
+}
+
+func TestRefreshSessionWithMockSTS(t *testing.T) {
+	mock := setupMockSTS(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+
+	source := testSession("dev-mfa")
+	source.RoleArn = "MFA-Session"
+	role := testSession("dev-admin")
+	role.SourceProfile = "dev-mfa"
+	role.Region = "eu-west-1"
+	role.PrincipalArn = "arn:aws:sts::123456789012:assumed-role/dev-admin/dev-admin"
+	role.Expiration = time.Now().Add(-time.Minute)
+	for _, s := range []*AWSSession{source, role} {
+		if err := SaveCredentials(s.Profile, s, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refreshed, err := RefreshSession(context.Background(), role, key, "eu-west-1", func(format string, args ...any) {
+		t.Errorf("Unexpected warning: "+format, args...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := mock.Calls()
+	if len(calls) != 1 || calls[0].Operation != "AssumeRole" {
+		t.Fatalf("Expected one AssumeRole call, got %+v", calls)
+	}
+	in := calls[0].Input.(*sts.AssumeRoleInput)
+	if aws.ToString(in.RoleArn) != role.RoleArn || aws.ToString(in.RoleSessionName) != "dev-admin" || aws.ToInt32(in.DurationSeconds) != 3600 {
+		t.Errorf("Unexpected AssumeRole input: %+v", in)
+	}
+	if refreshed.AccessKey != "ASIAMOCK00000001" || refreshed.PrincipalArn != role.PrincipalArn || time.Until(refreshed.Expiration) < 59*time.Minute {
+		t.Errorf("Unexpected refreshed session: %+v", refreshed)
+	}
+	stored, err := LoadCredentials("dev-admin", key)
+	if err != nil || stored.AccessKey != "ASIAMOCK00000001" || stored.SourceProfile != "dev-mfa" {
+		t.Errorf("Refreshed session was not stored: %+v (%v)", stored, err)
+	}
+}
+
+func TestRefreshSessionWithMockSTSFailure(t *testing.T) {
+	mock := setupMockSTS(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	mock.AssumeRoleFunc = func(*sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+		return nil, errors.New("AccessDenied")
+	}
+
+	source := testSession("dev-mfa")
+	source.RoleArn = "MFA-Session"
+	role := testSession("dev-admin")
+	role.SourceProfile = "dev-mfa"
+	for _, s := range []*AWSSession{source, role} {
+		if err := SaveCredentials(s.Profile, s, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := RefreshSession(context.Background(), role, key, "eu-west-1", func(string, ...any) {}); err == nil {
+		t.Fatal("Expected the STS error to be returned")
+	}
+	if stored, _ := LoadCredentials("dev-admin", key); stored == nil || stored.AccessKey != role.AccessKey {
+		t.Errorf("Expected the stored session to be kept, got %+v", stored)
+	}
+	events, _ := ReadAuditLog()
+	if len(events) != 1 || events[0].Event != AuditRefresh || !events[0].Failed {
+		t.Errorf("Expected a failed refresh event, got %+v", events)
+	}
+
+	// Sessions that can't be silently refreshed never reach STS
+	mfa := testSession("mfa")
+	mfa.RoleArn, mfa.SourceProfile = "MFA-Session", "default"
+	root := testSession("root")
+	root.RoleArn, root.RootTask, root.SourceProfile = "arn:aws:iam::123456789012:root", "S3UnlockBucketPolicy", "org"
+	for _, s := range []*AWSSession{mfa, root} {
+		if _, err := RefreshSession(context.Background(), s, key, "eu-west-1", func(string, ...any) {}); err == nil {
+			t.Errorf("Expected %s not to be refreshed", s.Profile)
+		}
+	}
+	if n := mock.CallCount("AssumeRole"); n != 1 {
+		t.Errorf("Expected no further STS calls, got %d AssumeRole calls", n)
+	}
+}
+
+func TestMockSTSIdentityAndRoot(t *testing.T) {
+	mock := setupMockSTS(t)
+	mock.Account = "210987654321"
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.Now = func() time.Time { return now }
+
+	s := testSession("dev")
+	if err := ResolveCallerIdentity(context.Background(), aws.Config{Region: "eu-west-1"}, s); err != nil {
+		t.Fatal(err)
+	}
+	if s.AccountID != "210987654321" || s.PrincipalArn != "arn:aws:iam::210987654321:user/mock" {
+		t.Errorf("Unexpected identity: %s %s", s.AccountID, s.PrincipalArn)
+	}
+
+	taskArn := "arn:aws:iam::aws:policy/root-task/S3UnlockBucketPolicy"
+	root, err := AssumeRoot(context.Background(), aws.Config{Region: "eu-west-1"}, "root-prod", "123456789012", taskArn, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.AccessKey != "ASIAMOCK00000002" || !root.Expiration.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("Unexpected root session: %+v", root)
+	}
+	in := mock.Calls()[1].Input.(*sts.AssumeRootInput)
+	if aws.ToString(in.TargetPrincipal) != "123456789012" || aws.ToString(in.TaskPolicyArn.Arn) != taskArn {
+		t.Errorf("Unexpected AssumeRoot input: %+v", in)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := AccessKeyAccount(ctx, aws.Config{}, "AKIAEXAMPLE"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled call to fail, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// suggestMinLogins is how many logins to a role make it worth an alias.
+const suggestMinLogins = 3
+
+// Kinds of suggestions
+const (
+	// SuggestAlias is a new role alias for a role logged in to by ARN.
+	SuggestAlias = "alias"
+	// SuggestRegion is a region default for an existing alias that has none.
+	SuggestRegion = "region"
+)
+
+// Suggestion is a role alias `cloudctl suggest` proposes from the local login history.
+type Suggestion struct {
+	Kind string
+	// Name is the alias to add or update, and Alias what it would be.
+	Name  string
+	Alias RoleAlias
+	// Source is the source most logins to the role came from.
+	Source   string
+	Logins   int
+	LastUsed time.Time
+}
+
+// recordRoleUse records a role login for `suggest`. Like other usage events it is
+// best-effort and stays in the local audit log.
+func recordRoleUse(s *AWSSession) {
+	_ = AppendAudit(AuditEvent{
+		Event:   AuditRoleUse,
+		Profile: s.Profile,
+		Role:    s.RoleArn,
+		Source:  s.SourceProfile,
+		Region:  s.Region,
+		Command: auditCommand,
+	})
+}
+
+// roleUsage sums up the logins to one role.
+type roleUsage struct {
+	logins   int
+	last     time.Time
+	sources  map[string]int
+	regions  map[string]int
+	profiles map[string]int
+}
+
+// mostUsed returns the most frequent value and its count, the first by name on a tie.
+func mostUsed(counts map[string]int) (string, int) {
+	best, bestCount := "", 0
+	for _, value := range sortedKeys(counts) {
+		if value != "" && counts[value] > bestCount {
+			best, bestCount = value, counts[value]
+		}
+	}
+	return best, bestCount
+}
+
+// SuggestAliases proposes role aliases from the role logins recorded since since: one
+// for each role logged in to at least suggestMinLogins times without an alias, named
+// after the profile it is most often stored as, and a region default for aliases that
+// have none when most logins used the same region. Busiest roles come first.
+func SuggestAliases(events []AuditEvent, since time.Time, aliases map[string]RoleAlias) []Suggestion {
+	byRole := make(map[string]*roleUsage)
+	for _, e := range events {
+		if e.Event != AuditRoleUse || e.Role == "" || e.Time.Before(since) {
+			continue
+		}
+		u, ok := byRole[e.Role]
+		if !ok {
+			u = &roleUsage{sources: map[string]int{}, regions: map[string]int{}, profiles: map[string]int{}}
+			byRole[e.Role] = u
+		}
+		u.logins++
+		if e.Time.After(u.last) {
+			u.last = e.Time
+		}
+		u.sources[e.Source]++
+		u.regions[e.Region]++
+		u.profiles[e.Profile]++
+	}
+
+	aliasByARN := make(map[string]string)
+	for _, name := range sortedKeys(aliases) {
+		if _, ok := aliasByARN[aliases[name].ARN]; !ok {
+			aliasByARN[aliases[name].ARN] = name
+		}
+	}
+	taken := func(name string) bool {
+		_, ok := aliases[name]
+		return ok
+	}
+
+	var suggestions []Suggestion
+	proposed := make(map[string]bool)
+	for _, arn := range sortedKeys(byRole) {
+		u := byRole[arn]
+		if u.logins < suggestMinLogins {
+			continue
+		}
+		source, _ := mostUsed(u.sources)
+		// A region is only a default when most logins used it, and not already the
+		// account's default
+		region, count := mostUsed(u.regions)
+		if count*2 <= u.logins || region == CurrentConfig().AccountRegion(RoleAccountID(arn)) {
+			region = ""
+		}
+		suggestion := Suggestion{Source: source, Logins: u.logins, LastUsed: u.last}
+
+		if name, ok := aliasByARN[arn]; ok {
+			alias := aliases[name]
+			if alias.Region != "" || region == "" {
+				continue
+			}
+			alias.Region = region
+			suggestion.Kind, suggestion.Name, suggestion.Alias = SuggestRegion, name, alias
+			suggestions = append(suggestions, suggestion)
+			continue
+		}
+
+		isTaken := func(name string) bool { return name == "" || taken(name) || proposed[name] }
+		name, _ := mostUsed(u.profiles)
+		if isTaken(name) {
+			name = strings.ToLower(RoleName(arn))
+		}
+		if isTaken(name) {
+			name = freeName(name, isTaken)
+		}
+		proposed[name] = true
+		suggestion.Kind, suggestion.Name, suggestion.Alias = SuggestAlias, name, RoleAlias{ARN: arn, Region: region}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Logins > suggestions[j].Logins
+	})
+	return suggestions
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuggestAliases(t *testing.T) {
+	setTestConfig(t, func(c *Config) { c.Accounts = map[string]AccountConfig{"333333333333": {Region: "us-west-2"}} })
+
+	admin := "arn:aws:iam::111111111111:role/Admin"
+	dev := "arn:aws:iam::222222222222:role/Developer"
+	ops := "arn:aws:iam::333333333333:role/Ops"
+	rare := "arn:aws:iam::444444444444:role/Rare"
+	now := time.Now()
+	use := func(role, profile, region string, ago time.Duration) AuditEvent {
+		return AuditEvent{Time: now.Add(-ago), Event: AuditRoleUse, Role: role, Profile: profile, Source: "default", Region: region}
+	}
+	events := []AuditEvent{
+		use(admin, "prod-admin", "eu-west-1", time.Hour),
+		use(admin, "prod-admin", "eu-west-1", 2*time.Hour),
+		use(admin, "admin", "eu-west-1", 3*time.Hour),
+		use(admin, "prod-admin", "us-east-1", 4*time.Hour),
+		// Already an alias without a region
+		use(dev, "dev", "eu-central-1", time.Hour),
+		use(dev, "dev", "eu-central-1", time.Hour),
+		use(dev, "dev", "eu-central-1", time.Hour),
+		// The region is the account's default; the profile name is taken by an alias
+		use(ops, "dev", "us-west-2", time.Hour),
+		use(ops, "dev", "us-west-2", time.Hour),
+		use(ops, "dev", "us-west-2", time.Hour),
+		// Too few logins, and too old
+		use(rare, "rare", "eu-west-1", time.Hour),
+		use(rare, "rare", "eu-west-1", 200*24*time.Hour),
+		use(rare, "rare", "eu-west-1", 200*24*time.Hour),
+		{Time: now, Event: AuditSTSCall, Profile: "prod-admin", Detail: "AssumeRole"},
+	}
+	aliases := map[string]RoleAlias{"dev": {ARN: dev}}
+
+	got := SuggestAliases(events, now.Add(-90*24*time.Hour), aliases)
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 suggestions, got %+v", got)
+	}
+	if got[0].Kind != SuggestAlias || got[0].Name != "prod-admin" || got[0].Alias.Region != "eu-west-1" || got[0].Logins != 4 || got[0].Source != "default" {
+		t.Errorf("Unexpected first suggestion %+v", got[0])
+	}
+	byName := map[string]Suggestion{}
+	for _, s := range got {
+		byName[s.Name] = s
+	}
+	if s := byName["dev"]; s.Kind != SuggestRegion || s.Alias.ARN != dev || s.Alias.Region != "eu-central-1" {
+		t.Errorf("Expected a region for the dev alias, got %+v", s)
+	}
+	if s := byName["ops"]; s.Kind != SuggestAlias || s.Alias.ARN != ops || s.Alias.Region != "" {
+		t.Errorf("Expected an ops alias without region, got %+v", s)
+	}
+
+	// Nothing left once the aliases exist
+	for _, s := range got {
+		aliases[s.Name] = s.Alias
+	}
+	if got := SuggestAliases(events, now.Add(-90*24*time.Hour), aliases); len(got) != 0 {
+		t.Errorf("Expected no suggestions, got %+v", got)
+	}
+}
@@ -1,9 +1,9 @@
 package internal
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 )
@@ -11,8 +11,6 @@ import (
 // SyncAllToAWS loads all active sessions and syncs them to ~/.aws/credentials.
 // This is used by both the 'sync' command and automatically by 'refresh' and the daemon.
 func SyncAllToAWS(secret string) (int, error) {
-	credsPath := filepath.Join(os.Getenv("HOME"), ".aws", "credentials")
-
 	// 1. Load all sessions
 	allSessions, err := ListAllSessions(secret)
 	if err != nil {
@@ -27,7 +25,7 @@ func SyncAllToAWS(secret string) (int, error) {
 	now := time.Now()
 	var activeSessions []*AWSSession
 	for _, s := range allSessions {
-		if s.Expiration.After(now) {
+		if s.Expiration.After(now) && !s.SelfDestructed(now) {
 			activeSessions = append(activeSessions, s)
 		}
 	}
@@ -36,14 +34,167 @@ func SyncAllToAWS(secret string) (int, error) {
 		return 0, nil
 	}
 
-	// 3. Read existing credentials file
+	return SyncToAWS(activeSessions)
+}
+
+// SyncToAWS writes sessions where the AWS CLI and SDKs find them: sections of
+// ~/.aws/credentials, or with sync.mode "files" their profile files. It returns how many
+// were synced.
+func SyncToAWS(sessions []*AWSSession) (int, error) {
+	if len(sessions) == 0 {
+		return 0, nil
+	}
+	replacing := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		replacing[s.Profile] = true
+	}
+	sections := sessions
+	if c := CurrentConfig().Sync; c.FilesMode() {
+		// Profiles that can't get a file (a hand-written ~/.aws/config section of the
+		// same name) stay in the credentials file
+		fallback, err := syncProfileFiles(c, sessions)
+		if err != nil {
+			return 0, err
+		}
+		sections = fallback
+	}
+	if err := writeCredentialSections(replacing, sections); err != nil {
+		return 0, err
+	}
+	return len(sessions), nil
+}
+
+// writeCredentialSections replaces the sections of the replacing profiles in
+// ~/.aws/credentials with sections for sessions, dropping those without a session (as
+// for profiles that moved to their own files). Sections are compared by a hash of their
+// content and replaced where they are, so unchanged profiles keep their lines and the
+// file is only written when a profile actually changed: tools watching it (the VS Code
+// AWS extension) don't reload after every refresh check.
+func writeCredentialSections(replacing map[string]bool, sessions []*AWSSession) error {
+	credsPath := AWSCredentialsPath()
+
+	// 1. Read existing credentials file
 	content, err := os.ReadFile(credsPath)
-	var existingLines []string
-	if err == nil {
-		existingLines = strings.Split(string(content), "\n")
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read credentials file: %w", err)
+	} else if err != nil && len(sessions) == 0 {
+		return nil
+	}
+
+	rendered := make(map[string][]string, len(sessions))
+	for _, s := range sessions {
+		rendered[s.Profile] = renderCredentialSection(s)
+	}
+
+	// 2. Replace the sections of changed profiles where they are
+	var lines []string
+	written := make(map[string]bool, len(sessions))
+	changed := false
+	for _, sec := range splitConfigSections(string(content)) {
+		profile := strings.TrimSpace(strings.Trim(sec.Header, "[]"))
+		if sec.Header == "" || !replacing[profile] {
+			lines = append(lines, sec.Lines...)
+			continue
+		}
+		prefix, body, suffix := splitSectionLines(sec.Lines)
+		block, ok := rendered[profile]
+		if ok && !written[profile] && sectionHash(body) == sectionHash(block) {
+			lines = append(lines, sec.Lines...)
+		} else if ok && !written[profile] {
+			lines = append(append(append(lines, prefix...), block...), suffix...)
+			changed = true
+		} else {
+			// No session for it any more, or a duplicate of a section already written
+			lines = append(append(lines, prefix...), suffix...)
+			changed = true
+		}
+		written[profile] = true
+	}
+
+	// 3. Append the profiles that had no section
+	for _, s := range sessions {
+		if written[s.Profile] {
+			continue
+		}
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, rendered[s.Profile]...)
+		written[s.Profile] = true
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	// 4. Write back
+	output := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(credsPath, []byte(output), 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+// renderCredentialSection returns the ~/.aws/credentials lines of a session.
+func renderCredentialSection(s *AWSSession) []string {
+	sessionType := "Role Session"
+	if s.RoleArn == "MFA-Session" {
+		sessionType = "MFA Session"
+	}
+	return []string{
+		// Comment identifying it as cloudctl managed
+		fmt.Sprintf("; Managed by cloudctl (%s) - Expires: %s", sessionType, FormatTimeZone(s.Expiration)),
+		fmt.Sprintf("[%s]", s.Profile),
+		fmt.Sprintf("aws_access_key_id = %s", s.AccessKey),
+		fmt.Sprintf("aws_secret_access_key = %s", s.SecretKey),
+		fmt.Sprintf("aws_session_token = %s", s.SessionToken),
 	}
+}
 
-	// 4. Remove cloudctl managed sections and their comments
+// splitSectionLines splits the lines of a credentials file section into the blank lines
+// and comments of the user above it, the section from its "; Managed by cloudctl"
+// comment or header to its last key, and the blank lines and comments after that (only
+// the last section of a file has any).
+func splitSectionLines(lines []string) (prefix, body, suffix []string) {
+	start, end := len(lines), len(lines)
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, managedCommentPrefix) || strings.HasPrefix(trimmed, "[") {
+			start = i
+			break
+		}
+	}
+	for end > start {
+		trimmed := strings.TrimSpace(lines[end-1])
+		if trimmed != "" && !strings.HasPrefix(trimmed, ";") && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		end--
+	}
+	return lines[:start:start], lines[start:end:end], lines[end:]
+}
+
+// sectionHash hashes the content of section lines, ignoring blank lines, indentation and
+// line endings, so a section only counts as changed when a value did.
+func sectionHash(lines []string) [sha256.Size]byte {
+	h := sha256.New()
+	for _, line := range lines {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			h.Write([]byte(trimmed))
+			h.Write([]byte{'\n'})
+		}
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// removeCredentialSections drops the sections of profiles from credentials file lines,
+// along with the "; Managed by cloudctl" comments above them.
+func removeCredentialSections(existingLines []string, profiles map[string]bool) []string {
 	newLines := []string{}
 	skipSection := false
 	for i := 0; i < len(existingLines); i++ {
@@ -52,18 +203,11 @@ func SyncAllToAWS(secret string) (int, error) {
 
 		// Detect section start
 		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
-			profileName := strings.Trim(trimmed, "[]")
-			skipSection = false
-			for _, s := range activeSessions {
-				if s.Profile == profileName {
-					skipSection = true
-					break
-				}
-			}
+			skipSection = profiles[strings.Trim(trimmed, "[]")]
 		}
 
-		// Identify and skip CloudCtl comments if they belong to a profile being replaced
-		if strings.HasPrefix(trimmed, "; Managed by cloudctl") {
+		// Identify and skip CloudCtl comments if they belong to a profile being removed
+		if strings.HasPrefix(trimmed, managedCommentPrefix) {
 			foundHeader := ""
 			// Look ahead for the next profile header
 			for j := i + 1; j < len(existingLines); j++ {
@@ -76,18 +220,8 @@ func SyncAllToAWS(secret string) (int, error) {
 				}
 				break
 			}
-
-			if foundHeader != "" {
-				isReplacing := false
-				for _, s := range activeSessions {
-					if s.Profile == foundHeader {
-						isReplacing = true
-						break
-					}
-				}
-				if isReplacing {
-					continue // Skip this comment line
-				}
+			if foundHeader != "" && profiles[foundHeader] {
+				continue // Skip this comment line
 			}
 		}
 
@@ -95,39 +229,33 @@ func SyncAllToAWS(secret string) (int, error) {
 			newLines = append(newLines, line)
 		}
 	}
+	return newLines
+}
 
-	// 5. Clean up potential trailing empty lines after filtering
-	for len(newLines) > 0 && strings.TrimSpace(newLines[len(newLines)-1]) == "" {
-		newLines = newLines[:len(newLines)-1]
+// RemoveFromAWSCredentials deletes the sections of profiles from ~/.aws/credentials, and
+// the profile files and ~/.aws/config profiles sync wrote for them in "files" mode.
+func RemoveFromAWSCredentials(profiles []string) error {
+	if err := removeProfileFiles(profiles); err != nil {
+		return err
 	}
-
-	// 6. Append synced sessions
-	if len(newLines) > 0 && newLines[len(newLines)-1] != "" {
-		newLines = append(newLines, "")
+	credsPath := AWSCredentialsPath()
+	content, err := os.ReadFile(credsPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read credentials file: %w", err)
 	}
-
-	syncedCount := 0
-	for _, s := range activeSessions {
-		sessionType := "Role Session"
-		if s.RoleArn == "MFA-Session" {
-			sessionType = "MFA Session"
-		}
-
-		// Add comment identifying it as cloudctl managed
-		newLines = append(newLines, fmt.Sprintf("; Managed by cloudctl (%s) - Expires: %s", sessionType, FormatBKK(s.Expiration)))
-		newLines = append(newLines, fmt.Sprintf("[%s]", s.Profile))
-		newLines = append(newLines, fmt.Sprintf("aws_access_key_id = %s", s.AccessKey))
-		newLines = append(newLines, fmt.Sprintf("aws_secret_access_key = %s", s.SecretKey))
-		newLines = append(newLines, fmt.Sprintf("aws_session_token = %s", s.SessionToken))
-		newLines = append(newLines, "")
-		syncedCount++
+	remove := make(map[string]bool, len(profiles))
+	for _, p := range profiles {
+		remove[p] = true
 	}
-
-	// 7. Write back
-	output := strings.Join(newLines, "\n")
-	if err := os.WriteFile(credsPath, []byte(output), 0600); err != nil {
-		return 0, fmt.Errorf("failed to write credentials file: %w", err)
+	lines := strings.Split(string(content), "\n")
+	newLines := removeCredentialSections(lines, remove)
+	if len(newLines) == len(lines) {
+		return nil
 	}
-
-	return syncedCount, nil
+	if err := os.WriteFile(credsPath, []byte(strings.Join(newLines, "\n")), 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
 }
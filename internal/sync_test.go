@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncToAWSOnlyRewritesChangedProfiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	setTestConfig(t, nil)
+	if err := os.MkdirAll(filepath.Join(home, ".aws"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(home, ".aws", "credentials")
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	dev := &AWSSession{Profile: "dev", AccessKey: "ASIADEV", SecretKey: "secret", SessionToken: "token", Expiration: expires}
+	prod := &AWSSession{Profile: "prod", AccessKey: "ASIAPROD", SecretKey: "secret", SessionToken: "token", Expiration: expires}
+	section := func(s *AWSSession) string {
+		return strings.Join(renderCredentialSection(s), "\n") + "\n"
+	}
+	content := "[default]\naws_access_key_id = AKIAUSER\naws_secret_access_key = secret\n\n" +
+		"; my dev account\n" + section(dev) + "\n" + section(prod) + "; end of file\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(path, past, past)
+	if _, err := SyncToAWS([]*AWSSession{dev, prod}); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(past) {
+		t.Error("Expected the credentials file not to be written when no profile changed")
+	}
+
+	// CRLF line endings don't make a section differ
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(content, "\n", "\r\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, past, past)
+	if _, err := SyncToAWS([]*AWSSession{dev, prod}); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(past) {
+		t.Error("Expected CRLF line endings not to count as a change")
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	dev.AccessKey = "ASIADEV2"
+	staging := &AWSSession{Profile: "staging", AccessKey: "ASIASTAGING", SecretKey: "secret", SessionToken: "token", Expiration: expires}
+	if _, err := SyncToAWS([]*AWSSession{dev, prod, staging}); err != nil {
+		t.Fatal(err)
+	}
+	want := "[default]\naws_access_key_id = AKIAUSER\naws_secret_access_key = secret\n\n" +
+		"; my dev account\n" + section(dev) + "\n" + section(prod) + "; end of file\n\n" + section(staging)
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("Expected dev to be replaced in place and staging appended, got:\n%s\nwant:\n%s", got, want)
+	}
+}
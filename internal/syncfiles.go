@@ -0,0 +1,292 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Sync modes
+const (
+	SyncModeCredentials = "credentials"
+	SyncModeFiles       = "files"
+)
+
+const (
+	defaultSyncFileTemplate = "~/.aws/credentials.d/{profile}"
+	// syncManagedMarker marks the ~/.aws/config profiles sync writes in files mode.
+	syncManagedMarker = "; Managed by cloudctl sync"
+)
+
+// FilesMode reports whether sync writes a file per profile.
+func (c SyncConfig) FilesMode() bool {
+	return c.Mode == SyncModeFiles
+}
+
+// ProfileFile returns the path of a profile's file in files mode.
+func (c SyncConfig) ProfileFile(profile string) string {
+	path := c.FileTemplate
+	if path == "" {
+		path = defaultSyncFileTemplate
+	}
+	path = strings.ReplaceAll(path, "{profile}", profile)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = filepath.Join(os.Getenv("HOME"), path[1:])
+	}
+	return filepath.Clean(path)
+}
+
+// ValidateSyncConfig checks the mode and that the file template names one file per
+// profile.
+func ValidateSyncConfig(c SyncConfig) error {
+	switch c.Mode {
+	case "", SyncModeCredentials, SyncModeFiles:
+	default:
+		return fmt.Errorf("unknown mode '%s' (use %s or %s)", c.Mode, SyncModeCredentials, SyncModeFiles)
+	}
+	if c.FileTemplate != "" && !strings.Contains(c.FileTemplate, "{profile}") {
+		return fmt.Errorf("file_template must contain {profile}")
+	}
+	return nil
+}
+
+// syncProfileFiles writes each session to its profile file and makes sure ~/.aws/config
+// has a credential_process profile reading it. Files whose content didn't change are not
+// rewritten, and ~/.aws/config only when a profile is added, so a refresh touches one
+// small file. Sessions that can't get a file are returned: profiles with a hand-written
+// ~/.aws/config section, which the credentials file keeps working next to, and names
+// that aren't file names or aren't safe in a shell command.
+func syncProfileFiles(c SyncConfig, sessions []*AWSSession) ([]*AWSSession, error) {
+	configPath := AWSConfigPath()
+	content, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read AWS config: %w", err)
+	}
+	sections := splitConfigSections(string(content))
+	managed := map[string]int{}
+	handwritten := map[string]bool{}
+	for i, sec := range sections {
+		if sec.Header == "" {
+			continue
+		}
+		if sec.managedBy(syncManagedMarker) {
+			managed[sec.Header] = i
+		} else {
+			handwritten[sec.Header] = true
+		}
+	}
+
+	var fallback []*AWSSession
+	var added []string
+	for _, s := range sessions {
+		header := configSectionHeader(s.Profile)
+		if handwritten[header] || !isProfileFileName(s.Profile) {
+			fallback = append(fallback, s)
+			continue
+		}
+		file := c.ProfileFile(s.Profile)
+		if err := writeProfileFile(file, s); err != nil {
+			return nil, err
+		}
+		block := renderSyncedProfile(s, file)
+		if i, ok := managed[header]; ok {
+			sec := &sections[i]
+			// Keep the blank lines above the block so an unchanged block compares equal
+			for j, line := range sec.Lines {
+				if strings.TrimSpace(line) == syncManagedMarker {
+					sec.Lines = append(sec.Lines[:j:j], block...)
+					break
+				}
+			}
+		} else {
+			added = append(added, strings.Join(block, "\n"))
+		}
+	}
+
+	var lines []string
+	for _, sec := range sections {
+		lines = append(lines, sec.Lines...)
+	}
+	merged := strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	for _, block := range added {
+		if merged != "" {
+			merged += "\n\n"
+		}
+		merged += block
+	}
+	if merged == strings.TrimRight(string(content), "\n") {
+		return fallback, nil
+	}
+	if _, err := writeAWSConfig(func(string) (string, []string) { return merged + "\n", nil }); err != nil {
+		return nil, err
+	}
+	return fallback, nil
+}
+
+// renderSyncedProfile returns the ~/.aws/config lines of a profile reading its file.
+func renderSyncedProfile(s *AWSSession, file string) []string {
+	command := "cat " + quoteCommandArg(file)
+	if runtime.GOOS == "windows" {
+		command = "cmd /c type " + quoteCommandArg(file)
+	}
+	block := []string{syncManagedMarker, configSectionHeader(s.Profile), "credential_process = " + command}
+	if region := sessionRegionOf(s); region != "" {
+		block = append(block, "region = "+region)
+	}
+	return block
+}
+
+// sessionRegionOf returns the region a synced profile is configured with.
+func sessionRegionOf(s *AWSSession) string {
+	if s.Region != "" {
+		return s.Region
+	}
+	return CurrentConfig().AccountRegion(SessionAccountID(s))
+}
+
+// writeProfileFile writes the credential_process output of a session to file, unless it
+// already holds it. The file is replaced in one step so the SDKs never read half of it.
+func writeProfileFile(file string, s *AWSSession) error {
+	b, err := json.MarshalIndent(NewProcessCredentials(s), "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if existing, err := os.ReadFile(file); err == nil && string(existing) == string(b) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(file), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return nil
+}
+
+// isProfileFileName reports whether a profile can get a file: its name is a file name
+// that is also safe in the credential_process command reading it.
+func isProfileFileName(profile string) bool {
+	return profile != "." && profile != ".." && IsCommandSafeName(profile)
+}
+
+// syncedProfile is a ~/.aws/config profile sync wrote in files mode.
+type syncedProfile struct {
+	Profile string
+	File    string
+}
+
+// readSyncedProfiles returns the profiles sync wrote to ~/.aws/config in files mode.
+func readSyncedProfiles() ([]syncedProfile, error) {
+	content, err := os.ReadFile(AWSConfigPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read AWS config: %w", err)
+	}
+	var profiles []syncedProfile
+	for _, sec := range splitConfigSections(string(content)) {
+		if sec.Header != "" && sec.managedBy(syncManagedMarker) {
+			profiles = append(profiles, syncedProfile{Profile: configSectionProfile(sec.Header), File: syncedProfileFile(sec)})
+		}
+	}
+	return profiles, nil
+}
+
+// configSectionProfile returns the profile name of a ~/.aws/config section header.
+func configSectionProfile(header string) string {
+	name := strings.TrimSpace(strings.Trim(header, "[]"))
+	if rest, ok := strings.CutPrefix(name, "profile "); ok {
+		return strings.TrimSpace(rest)
+	}
+	return name
+}
+
+// syncedProfileFile returns the quoted file of a synced profile's credential_process.
+func syncedProfileFile(sec configSection) string {
+	for _, line := range sec.Lines {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "credential_process" {
+			continue
+		}
+		start, end := strings.Index(value, `"`), strings.LastIndex(value, `"`)
+		if start >= 0 && end > start {
+			return unquoteCommandArg(value[start+1 : end])
+		}
+	}
+	return ""
+}
+
+// readSyncedCredentials returns the profile files of synced profiles as managed
+// credentials, for drift detection. A missing or unreadable file has no keys.
+func readSyncedCredentials() ([]ManagedCredential, error) {
+	profiles, err := readSyncedProfiles()
+	if err != nil {
+		return nil, err
+	}
+	creds := make([]ManagedCredential, 0, len(profiles))
+	for _, p := range profiles {
+		m := ManagedCredential{Profile: p.Profile}
+		var pc ProcessCredentials
+		if b, err := os.ReadFile(p.File); err == nil && json.Unmarshal(b, &pc) == nil {
+			m.AccessKey = pc.AccessKeyID
+			m.Expiration, _ = time.Parse(time.RFC3339, pc.Expiration)
+		}
+		creds = append(creds, m)
+	}
+	return creds, nil
+}
+
+// removeProfileFiles deletes the synced ~/.aws/config profiles of profiles and their
+// files.
+func removeProfileFiles(profiles []string) error {
+	remove := make(map[string]bool, len(profiles))
+	for _, p := range profiles {
+		remove[configSectionHeader(p)] = true
+	}
+	content, err := os.ReadFile(AWSConfigPath())
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read AWS config: %w", err)
+	}
+	var kept []string
+	removed := false
+	for _, sec := range splitConfigSections(string(content)) {
+		if sec.Header != "" && remove[sec.Header] && sec.managedBy(syncManagedMarker) {
+			if file := syncedProfileFile(sec); file != "" {
+				if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("failed to remove %s: %w", file, err)
+				}
+			}
+			removed = true
+			continue
+		}
+		kept = append(kept, sec.Lines...)
+	}
+	if !removed {
+		return nil
+	}
+	merged := strings.TrimRight(strings.Join(kept, "\n"), "\n") + "\n"
+	_, err = writeAWSConfig(func(string) (string, []string) { return merged, nil })
+	return err
+}
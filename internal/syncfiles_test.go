@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupFilesMode(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	setTestConfig(t, func(c *Config) { c.Sync = SyncConfig{Mode: SyncModeFiles} })
+	if err := os.MkdirAll(filepath.Join(home, ".aws"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func TestSyncToAWSFilesMode(t *testing.T) {
+	home := setupFilesMode(t)
+	configPath := filepath.Join(home, ".aws", "config")
+	credsPath := filepath.Join(home, ".aws", "credentials")
+	userConfig := "[default]\nregion = eu-west-1\n\n[profile handmade]\nregion = us-east-1\n"
+	if err := os.WriteFile(configPath, []byte(userConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	dev := &AWSSession{Profile: "dev", AccessKey: "ASIADEV", SecretKey: "secret", SessionToken: "token", Expiration: expires, Region: "ap-southeast-1"}
+	handmade := &AWSSession{Profile: "handmade", AccessKey: "ASIAHAND", SecretKey: "secret", SessionToken: "token", Expiration: expires}
+	if n, err := SyncToAWS([]*AWSSession{dev, handmade}); err != nil || n != 2 {
+		t.Fatalf("SyncToAWS = %d, %v", n, err)
+	}
+
+	devFile := filepath.Join(home, ".aws", "credentials.d", "dev")
+	var pc ProcessCredentials
+	b, err := os.ReadFile(devFile)
+	if err != nil || json.Unmarshal(b, &pc) != nil || pc.AccessKeyID != "ASIADEV" {
+		t.Fatalf("Expected the dev profile file to hold its credentials, got %s (%v)", b, err)
+	}
+	if info, _ := os.Stat(devFile); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the profile file to be 0600, got %v", info.Mode().Perm())
+	}
+
+	config, _ := os.ReadFile(configPath)
+	if !strings.HasPrefix(string(config), userConfig) {
+		t.Errorf("Expected the user's config to be kept, got:\n%s", config)
+	}
+	if !strings.Contains(string(config), "[profile dev]\ncredential_process = cat \""+devFile+"\"\nregion = ap-southeast-1") {
+		t.Errorf("Expected a credential_process profile for dev, got:\n%s", config)
+	}
+	creds, _ := os.ReadFile(credsPath)
+	if !strings.Contains(string(creds), "[handmade]") || strings.Contains(string(creds), "[dev]") {
+		t.Errorf("Expected only the hand-written profile in the credentials file, got:\n%s", creds)
+	}
+
+	// A refresh rewrites the profile file and nothing else
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(configPath, past, past)
+	os.Chtimes(credsPath, past, past)
+	dev.AccessKey = "ASIADEV2"
+	if _, err := SyncToAWS([]*AWSSession{dev, handmade}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{configPath, credsPath} {
+		if info, _ := os.Stat(path); !info.ModTime().Equal(past) {
+			t.Errorf("Expected %s not to be rewritten on refresh", path)
+		}
+	}
+	if b, _ := os.ReadFile(devFile); !strings.Contains(string(b), "ASIADEV2") {
+		t.Errorf("Expected the refreshed keys in the profile file, got %s", b)
+	}
+
+	managed, err := ReadManagedCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, m := range managed {
+		if m.Profile == "dev" {
+			found = m.AccessKey == "ASIADEV2" && m.Expiration.Equal(expires)
+		}
+	}
+	if !found {
+		t.Errorf("Expected the dev profile file among the managed credentials, got %+v", managed)
+	}
+
+	if err := RemoveFromAWSCredentials([]string{"dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(devFile); !os.IsNotExist(err) {
+		t.Error("Expected the profile file to be removed")
+	}
+	if config, _ := os.ReadFile(configPath); string(config) != userConfig {
+		t.Errorf("Expected only the synced profile to be removed from the config, got:\n%s", config)
+	}
+}
+
+func TestSyncFilesModeQuoting(t *testing.T) {
+	home := setupFilesMode(t)
+	configPath := filepath.Join(home, ".aws", "config")
+	credsPath := filepath.Join(home, ".aws", "credentials")
+
+	// Names that aren't safe in the shell command keep using the credentials file
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	unsafe := &AWSSession{Profile: `dev"$(touch pwned)`, AccessKey: "ASIAUNSAFE", SecretKey: "secret", SessionToken: "token", Expiration: expires}
+	if _, err := SyncToAWS([]*AWSSession{unsafe}); err != nil {
+		t.Fatal(err)
+	}
+	if config, _ := os.ReadFile(configPath); strings.Contains(string(config), "credential_process") {
+		t.Errorf("Expected no credential_process profile for an unsafe name, got:\n%s", config)
+	}
+	if creds, _ := os.ReadFile(credsPath); !strings.Contains(string(creds), "ASIAUNSAFE") {
+		t.Errorf("Expected the unsafe name in the credentials file, got:\n%s", creds)
+	}
+
+	// Paths are escaped for sh and read back as they are
+	if runtime.GOOS == "windows" {
+		return
+	}
+	file := "/tmp/we`ird $dir \"x\"/dev"
+	block := renderSyncedProfile(&AWSSession{Profile: "dev"}, file)
+	if want := "credential_process = cat \"/tmp/we\\`ird \\$dir \\\"x\\\"/dev\""; block[2] != want {
+		t.Errorf("Expected the path escaped for sh, got %s, want %s", block[2], want)
+	}
+	if got := syncedProfileFile(configSection{Lines: block}); got != file {
+		t.Errorf("Expected the path read back as %q, got %q", file, got)
+	}
+}
+
+func TestSyncConfigProfileFile(t *testing.T) {
+	t.Setenv("HOME", "/home/alice")
+	if got := (SyncConfig{}).ProfileFile("dev"); got != filepath.FromSlash("/home/alice/.aws/credentials.d/dev") {
+		t.Errorf("Unexpected default profile file %s", got)
+	}
+	if got := (SyncConfig{FileTemplate: "/run/aws/{profile}.json"}).ProfileFile("dev"); got != filepath.FromSlash("/run/aws/dev.json") {
+		t.Errorf("Unexpected profile file %s", got)
+	}
+
+	for _, c := range []SyncConfig{{Mode: "include"}, {Mode: SyncModeFiles, FileTemplate: "~/.aws/all"}} {
+		if err := ValidateSyncConfig(c); err == nil {
+			t.Errorf("Expected %+v to be rejected", c)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Icon names used by status, prompt and login output
+const (
+	IconSuccess  = "success"
+	IconError    = "error"
+	IconWarning  = "warning"
+	IconTip      = "tip"
+	IconEmpty    = "empty"
+	IconActive   = "active"
+	IconExpiring = "expiring"
+	IconExpired  = "expired"
+	IconMFA      = "mfa"
+	IconPrompt   = "prompt"
+	IconRole     = "role"
+	IconConsole  = "console"
+	IconCurrent  = "current"
+	IconRule     = "rule"
+	IconKey      = "key"
+	IconLocked   = "locked"
+	IconUnlocked = "unlocked"
+)
+
+// Color names used by status, prompt and login output
+const (
+	ColorAccent     = "accent"
+	ColorActive     = "active"
+	ColorExpiring   = "expiring"
+	ColorExpired    = "expired"
+	ColorProfile    = "profile"
+	ColorRole       = "role"
+	ColorMuted      = "muted"
+	ColorTime       = "time"
+	ColorProduction = "production"
+)
+
+// Built-in theme names accepted by theme.name
+const (
+	ThemeEmoji = "emoji"
+	ThemeASCII = "ascii"
+)
+
+// Theme is a resolved set of icons and colors. Colors are hex ("#7ED321") or
+// ANSI 256 palette numbers ("2"), the same values lipgloss accepts.
+type Theme struct {
+	Icons  map[string]string
+	Colors map[string]string
+}
+
+var defaultColors = map[string]string{
+	ColorAccent:     "#4A90E2",
+	ColorActive:     "#7ED321",
+	ColorExpiring:   "#F5A623",
+	ColorExpired:    "#D0021B",
+	ColorProfile:    "#FFFFFF",
+	ColorRole:       "#B0BEC5",
+	ColorMuted:      "#78909C",
+	ColorTime:       "#90A4AE",
+	ColorProduction: "#D0021B",
+}
+
+var builtinThemes = map[string]Theme{
+	ThemeEmoji: {
+		Icons: map[string]string{
+			IconSuccess: "✅",
+			IconError:   "❌",
+			// Trailing space: most terminals draw these one cell narrower than they advance
+			IconWarning:  "⚠️ ",
+			IconTip:      "💡",
+			IconEmpty:    "📭",
+			IconActive:   "🟢",
+			IconExpiring: "🟡",
+			IconExpired:  "🔴",
+			IconMFA:      "🔒",
+			IconPrompt:   "☁️ ",
+			IconRole:     "🎭",
+			IconConsole:  "🌐",
+			IconCurrent:  "←",
+			IconRule:     "─",
+			IconKey:      "🔑",
+			IconLocked:   "🔒",
+			IconUnlocked: "🔓",
+		},
+		Colors: defaultColors,
+	},
+	ThemeASCII: {
+		Icons: map[string]string{
+			IconSuccess:  "[ok]",
+			IconError:    "[error]",
+			IconWarning:  "[warn]",
+			IconTip:      "[tip]",
+			IconEmpty:    "[-]",
+			IconActive:   "[+]",
+			IconExpiring: "[!]",
+			IconExpired:  "[x]",
+			IconMFA:      "[m]",
+			IconPrompt:   "aws:",
+			IconRole:     "[role]",
+			IconConsole:  "[web]",
+			IconCurrent:  "<-",
+			IconRule:     "-",
+			IconKey:      "[key]",
+			IconLocked:   "[locked]",
+			IconUnlocked: "[unlocked]",
+		},
+		Colors: defaultColors,
+	},
+}
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// ThemeNames returns the built-in theme names.
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveTheme applies theme.icons / theme.colors overrides on top of the named base theme.
+func ResolveTheme(cfg ThemeConfig) (*Theme, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Name))
+	if name == "" {
+		name = ThemeEmoji
+	}
+	base, ok := builtinThemes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme '%s' (use %s)", cfg.Name, strings.Join(ThemeNames(), " or "))
+	}
+
+	theme := &Theme{Icons: make(map[string]string), Colors: make(map[string]string)}
+	for k, v := range base.Icons {
+		theme.Icons[k] = v
+	}
+	for k, v := range base.Colors {
+		theme.Colors[k] = v
+	}
+
+	for k, v := range cfg.Icons {
+		if _, ok := theme.Icons[k]; !ok {
+			return nil, fmt.Errorf("unknown icon '%s'", k)
+		}
+		theme.Icons[k] = v
+	}
+	for k, v := range cfg.Colors {
+		if _, ok := theme.Colors[k]; !ok {
+			return nil, fmt.Errorf("unknown color '%s'", k)
+		}
+		if err := validateColor(v); err != nil {
+			return nil, fmt.Errorf("color '%s': %w", k, err)
+		}
+		theme.Colors[k] = v
+	}
+	return theme, nil
+}
+
+func validateColor(value string) error {
+	if value == "" || hexColorPattern.MatchString(value) {
+		return nil
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 255 {
+		return nil
+	}
+	return fmt.Errorf("invalid value '%s' (use \"#RRGGBB\" or an ANSI color number 0-255)", value)
+}
+
+// CurrentTheme returns the theme from the loaded config, falling back to the emoji theme.
+func CurrentTheme() *Theme {
+	theme, err := ResolveTheme(CurrentConfig().Theme)
+	if err != nil {
+		theme, _ = ResolveTheme(ThemeConfig{})
+	}
+	return theme
+}
+
+// Icon returns the configured icon for name.
+func Icon(name string) string {
+	return CurrentTheme().Icons[name]
+}
+
+// ThemeColor returns the configured color for name, suitable for lipgloss.Color.
+// An empty string means "no color".
+func ThemeColor(name string) string {
+	return CurrentTheme().Colors[name]
+}
+
+// ANSIColor returns the raw escape sequence for a theme color, for output that is
+// not rendered through lipgloss (e.g. the shell prompt, where stdout is not a TTY).
+func ANSIColor(name string) string {
+	value := ThemeColor(name)
+	if value == "" {
+		return ""
+	}
+	if hexColorPattern.MatchString(value) {
+		r, _ := strconv.ParseUint(value[1:3], 16, 8)
+		g, _ := strconv.ParseUint(value[3:5], 16, 8)
+		b, _ := strconv.ParseUint(value[5:7], 16, 8)
+		return fmt.Sprintf("\033[38;2;%d;%d;%dm", r, g, b)
+	}
+	return fmt.Sprintf("\033[38;5;%sm", value)
+}
+
+// ANSIReset ends a sequence started with ANSIColor.
+const ANSIReset = "\033[0m"
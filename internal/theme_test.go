@@ -0,0 +1,62 @@
+package internal
+
+import "testing"
+
+func TestResolveThemeOverrides(t *testing.T) {
+	theme, err := ResolveTheme(ThemeConfig{
+		Name:   "ascii",
+		Icons:  map[string]string{IconSuccess: "OK"},
+		Colors: map[string]string{ColorActive: "2", ColorAccent: ""},
+	})
+	if err != nil {
+		t.Fatalf("ResolveTheme failed: %v", err)
+	}
+	if theme.Icons[IconSuccess] != "OK" {
+		t.Errorf("Icon override not applied: %q", theme.Icons[IconSuccess])
+	}
+	if theme.Icons[IconError] != "[error]" {
+		t.Errorf("Expected ascii base icon, got %q", theme.Icons[IconError])
+	}
+	if theme.Colors[ColorActive] != "2" || theme.Colors[ColorAccent] != "" {
+		t.Errorf("Color overrides not applied: %v", theme.Colors)
+	}
+
+	// Overrides must not leak into the built-in theme
+	if builtinThemes[ThemeASCII].Icons[IconSuccess] != "[ok]" {
+		t.Error("Built-in theme was modified")
+	}
+}
+
+func TestResolveThemeInvalid(t *testing.T) {
+	tests := []ThemeConfig{
+		{Name: "neon"},
+		{Icons: map[string]string{"sucess": "OK"}},
+		{Colors: map[string]string{ColorActive: "green"}},
+		{Colors: map[string]string{ColorActive: "300"}},
+	}
+	for _, cfg := range tests {
+		if _, err := ResolveTheme(cfg); err == nil {
+			t.Errorf("Expected error for %+v", cfg)
+		}
+	}
+
+	setupTestConfig(t, `{"theme": {"name": "neon"}}`)
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected LoadConfig to reject unknown theme")
+	}
+}
+
+func TestASCIIThemeHasNoEmoji(t *testing.T) {
+	for name, icon := range builtinThemes[ThemeASCII].Icons {
+		for _, r := range icon {
+			if r > 127 {
+				t.Errorf("ascii icon %q contains non-ASCII %q", name, icon)
+			}
+		}
+	}
+	for name := range builtinThemes[ThemeEmoji].Icons {
+		if _, ok := builtinThemes[ThemeASCII].Icons[name]; !ok {
+			t.Errorf("ascii theme is missing icon %q", name)
+		}
+	}
+}
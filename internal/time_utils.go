@@ -1,28 +1,120 @@
 package internal
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // IANA zones must resolve even on hosts without zoneinfo
+
+	"github.com/chukul/cloudctl/internal/i18n"
+)
 
 const (
 	// DisplayTimeFormat is the standard time format used across the application
 	DisplayTimeFormat = "2006-01-02 15:04:05"
+	// DisplayTimeFormatZone is DisplayTimeFormat with the UTC offset, used where the
+	// text is persisted (synced credentials comments) and may be read in another zone
+	DisplayTimeFormatZone = "2006-01-02 15:04:05 -07:00"
 	// LogTimeFormat is the short time format used in daemon logs
 	LogTimeFormat = "15:04:05"
 )
 
-// BangkokLocation is the fixed timezone for Asia/Bangkok (UTC+7)
-var BangkokLocation = time.FixedZone("Asia/Bangkok", 7*60*60)
+// ResolveTimezone turns a display.timezone value into a location.
+// Empty and "local" mean the system time zone.
+func ResolveTimezone(name string) (*time.Location, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone '%s' (use \"local\", \"UTC\" or an IANA name like \"Asia/Bangkok\")", name)
+	}
+	return loc, nil
+}
+
+// DisplayLocation returns the configured display time zone, falling back to local time.
+func DisplayLocation() *time.Location {
+	loc, err := ResolveTimezone(CurrentConfig().Display.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// InDisplayZone returns the given time converted to the configured display time zone
+func InDisplayZone(t time.Time) time.Time {
+	return t.In(DisplayLocation())
+}
+
+// FormatTime formats the given time in the standard display format (display time zone)
+func FormatTime(t time.Time) string {
+	return InDisplayZone(t).Format(DisplayTimeFormat)
+}
+
+// FormatTimeZone formats the given time with its UTC offset (display time zone)
+func FormatTimeZone(t time.Time) string {
+	return InDisplayZone(t).Format(DisplayTimeFormatZone)
+}
+
+// FormatLogTime formats the given time in the short log format (display time zone)
+func FormatLogTime(t time.Time) string {
+	return InDisplayZone(t).Format(LogTimeFormat)
+}
+
+// Expiry display formats accepted by display.expiry_format
+const (
+	ExpiryFormatRelative = "relative"
+	ExpiryFormatAbsolute = "absolute"
+	ExpiryFormatBoth     = "both"
+)
+
+// ValidateExpiryFormat checks a display.expiry_format value.
+func ValidateExpiryFormat(format string) error {
+	switch format {
+	case ExpiryFormatRelative, ExpiryFormatAbsolute, ExpiryFormatBoth:
+		return nil
+	}
+	return fmt.Errorf("unknown expiry format '%s' (use relative, absolute or both)", format)
+}
+
+// FormatDurationShort renders a duration as "1h5m" or "12m", rounded down to the minute.
+func FormatDurationShort(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
 
-// InBKK returns the given time converted to Bangkok time zone
-func InBKK(t time.Time) time.Time {
-	return t.In(BangkokLocation)
+// FormatRelative describes an expiry relative to now, e.g. "45m remaining" or "expired 3m ago".
+func FormatRelative(expiration time.Time) string {
+	remaining := time.Until(expiration)
+	if remaining <= 0 {
+		return i18n.T("time.expired_ago", FormatDurationShort(remaining))
+	}
+	return i18n.T("time.remaining", FormatDurationShort(remaining))
 }
 
-// FormatBKK formats the given time in the standard display format (BKK time)
-func FormatBKK(t time.Time) string {
-	return InBKK(t).Format(DisplayTimeFormat)
+// FormatExpiry renders an expiry timestamp according to display.expiry_format.
+func FormatExpiry(expiration time.Time) string {
+	switch CurrentConfig().Display.ExpiryFormat {
+	case ExpiryFormatRelative:
+		return FormatRelative(expiration)
+	case ExpiryFormatAbsolute:
+		return FormatTime(expiration)
+	default:
+		return fmt.Sprintf("%s (%s)", FormatTime(expiration), FormatRelative(expiration))
+	}
 }
 
-// FormatBKKLog formats the given time in the short log format (BKK time)
-func FormatBKKLog(t time.Time) string {
-	return InBKK(t).Format(LogTimeFormat)
+// FormatISO renders a timestamp as ISO-8601 (RFC 3339, UTC) for machine-readable output.
+func FormatISO(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
 }
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"sort"
+	"time"
+)
+
+// Kinds of timeline entries
+const (
+	TimelineLogin      = "login"
+	TimelineMFALogin   = "mfa-login"
+	TimelineRefresh    = "refresh"
+	TimelineConsole    = "console"
+	TimelineExport     = "export"
+	TimelineBreakGlass = "break-glass"
+	TimelineRootLogin  = "root-login"
+	TimelineApproval   = "approval"
+)
+
+// timelineKinds maps the audit events shown in the timeline to their kind. STS calls
+// are left out: every login and refresh makes several.
+var timelineKinds = map[string]string{
+	AuditRoleUse:           TimelineLogin,
+	AuditMFALogin:          TimelineMFALogin,
+	AuditRefresh:           TimelineRefresh,
+	AuditConsoleFederation: TimelineConsole,
+	AuditExport:            TimelineExport,
+	AuditBreakGlass:        TimelineBreakGlass,
+	AuditRootLogin:         TimelineRootLogin,
+	AuditApprovalUsed:      TimelineApproval,
+}
+
+// recordMFALogin records an MFA login for `timeline`, best-effort like other usage
+// events.
+func recordMFALogin(s *AWSSession) {
+	_ = AppendAudit(AuditEvent{
+		Event:   AuditMFALogin,
+		Profile: s.Profile,
+		Source:  s.SourceProfile,
+		Region:  s.Region,
+		Detail:  s.MfaArn,
+		Command: auditCommand,
+	})
+}
+
+// RecordExport records that the credentials of profile left cloudctl; how is "shell",
+// "clipboard" or "exec".
+func RecordExport(profile, how string) {
+	_ = AppendAudit(AuditEvent{Event: AuditExport, Profile: profile, Detail: how, Command: auditCommand})
+}
+
+// TimelineEntry is one thing done with a session.
+type TimelineEntry struct {
+	Time    time.Time
+	Kind    string
+	Profile string
+	// Role is the role the profile held at the time, "" when unknown.
+	Role   string
+	Detail string
+	Failed bool
+}
+
+// TimelineGroup holds the entries of one account, oldest first. AccountID is "" for
+// MFA sessions and profiles whose role isn't in the log.
+type TimelineGroup struct {
+	AccountID string
+	Entries   []TimelineEntry
+}
+
+// BuildTimeline turns the audit events since since into a timeline grouped by account,
+// accounts sorted by ID with the "" group last. Events that only name a profile, such
+// as refreshes and exports, get the role the profile was last logged in to before them,
+// so a profile reused for another role is attributed correctly.
+func BuildTimeline(events []AuditEvent, since time.Time) []TimelineGroup {
+	sorted := make([]AuditEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	profileRole := make(map[string]string)
+	byAccount := make(map[string]*TimelineGroup)
+	for _, e := range sorted {
+		switch {
+		case e.Event == AuditMFALogin:
+			profileRole[e.Profile] = "MFA-Session"
+		case e.Role != "" && e.Profile != "":
+			profileRole[e.Profile] = e.Role
+		}
+
+		kind, ok := timelineKinds[e.Event]
+		if !ok || e.Time.Before(since) {
+			continue
+		}
+		role := e.Role
+		if role == "" {
+			role = profileRole[e.Profile]
+		}
+		var account string
+		if a, err := ParseARN(role); err == nil {
+			account = a.AccountID
+		}
+		entry := TimelineEntry{Time: e.Time, Kind: kind, Profile: e.Profile, Role: role, Detail: e.Detail, Failed: e.Failed}
+		if role == "MFA-Session" {
+			entry.Role = ""
+		}
+		if e.Event == AuditApprovalUsed && e.Approver != "" {
+			entry.Detail = "approved by " + e.Approver
+		}
+
+		g, ok := byAccount[account]
+		if !ok {
+			g = &TimelineGroup{AccountID: account}
+			byAccount[account] = g
+		}
+		g.Entries = append(g.Entries, entry)
+	}
+
+	groups := make([]TimelineGroup, 0, len(byAccount))
+	for _, id := range sortedKeys(byAccount) {
+		if id != "" {
+			groups = append(groups, *byAccount[id])
+		}
+	}
+	if g, ok := byAccount[""]; ok {
+		groups = append(groups, *g)
+	}
+	return groups
+}
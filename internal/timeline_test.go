@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	admin := "arn:aws:iam::111111111111:role/Admin"
+	dev := "arn:aws:iam::222222222222:role/Developer"
+	now := time.Now()
+	at := func(ago time.Duration) time.Time { return now.Add(-ago) }
+	events := []AuditEvent{
+		// Before the window, but tells which role "work" held
+		{Time: at(48 * time.Hour), Event: AuditRoleUse, Profile: "work", Role: admin},
+		{Time: at(5 * time.Hour), Event: AuditMFALogin, Profile: "mfa-session", Detail: "arn:aws:iam::999999999999:mfa/me"},
+		{Time: at(4 * time.Hour), Event: AuditRefresh, Profile: "work", Detail: "silent"},
+		{Time: at(4 * time.Hour), Event: AuditSTSCall, Profile: "work", Detail: "AssumeRole"},
+		{Time: at(3 * time.Hour), Event: AuditExport, Profile: "work", Detail: "shell"},
+		// The profile now holds another role
+		{Time: at(2 * time.Hour), Event: AuditRoleUse, Profile: "work", Role: dev},
+		{Time: at(time.Hour), Event: AuditConsoleFederation, Profile: "work", Failed: true},
+		{Time: at(time.Hour), Event: AuditRefresh, Profile: "mfa-session"},
+		{Time: at(30 * time.Minute), Event: AuditApprovalUsed, Role: admin, Approver: "bob"},
+	}
+
+	groups := BuildTimeline(events, now.Add(-24*time.Hour))
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %+v", groups)
+	}
+	kinds := func(g TimelineGroup) []string {
+		var k []string
+		for _, e := range g.Entries {
+			k = append(k, e.Kind)
+		}
+		return k
+	}
+	if g := groups[0]; g.AccountID != "111111111111" || len(g.Entries) != 3 ||
+		g.Entries[0].Kind != TimelineRefresh || g.Entries[1].Kind != TimelineExport || g.Entries[2].Kind != TimelineApproval {
+		t.Errorf("Unexpected admin account group %s: %v", g.AccountID, kinds(g))
+	} else if g.Entries[2].Detail != "approved by bob" {
+		t.Errorf("Expected the approver, got %q", g.Entries[2].Detail)
+	}
+	if g := groups[1]; g.AccountID != "222222222222" || len(g.Entries) != 2 ||
+		g.Entries[0].Kind != TimelineLogin || g.Entries[1].Kind != TimelineConsole || !g.Entries[1].Failed {
+		t.Errorf("Unexpected dev account group %s: %+v", g.AccountID, g.Entries)
+	}
+	if g := groups[2]; g.AccountID != "" || len(g.Entries) != 2 ||
+		g.Entries[0].Kind != TimelineMFALogin || g.Entries[1].Kind != TimelineRefresh || g.Entries[1].Role != "" {
+		t.Errorf("Unexpected MFA group %s: %+v", g.AccountID, g.Entries)
+	}
+}
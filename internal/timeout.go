@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// WithCallTimeout is a config.LoadDefaultConfig option that bounds every API call made
+// by clients of the config with network.call_timeout_seconds.
+func WithCallTimeout(o *config.LoadOptions) error {
+	if d := CurrentConfig().Network.CallTimeout(); d > 0 {
+		o.APIOptions = append(o.APIOptions, callTimeoutMiddleware(d))
+	}
+	return nil
+}
+
+func callTimeoutMiddleware(d time.Duration) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CloudctlCallTimeout",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				callCtx, cancel := context.WithTimeout(ctx, d)
+				// Streamed bodies such as S3 objects are read after the call returns, so
+				// the context is released at its deadline rather than on return
+				context.AfterFunc(callCtx, cancel)
+				out, md, err := next.HandleInitialize(callCtx, in)
+				if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+					err = &CallTimeoutError{Operation: awsmiddleware.GetOperationName(ctx), Timeout: d, Err: err}
+				}
+				return out, md, err
+			}), middleware.After)
+	}
+}
+
+// CallTimeoutError is returned by an AWS call that ran past network.call_timeout_seconds.
+type CallTimeoutError struct {
+	Operation string
+	Timeout   time.Duration
+	Err       error
+}
+
+func (e *CallTimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s (network.call_timeout_seconds)", e.Operation, e.Timeout)
+}
+
+func (e *CallTimeoutError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestCallTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cfg := aws.Config{
+		Region:       "eu-west-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKIATEST", "any", ""),
+		APIOptions:   []func(*middleware.Stack) error{callTimeoutMiddleware(100 * time.Millisecond)},
+	}
+	start := time.Now()
+	_, err := sts.NewFromConfig(cfg).GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{})
+	var timeout *CallTimeoutError
+	if !errors.As(err, &timeout) || timeout.Operation != "GetCallerIdentity" {
+		t.Fatalf("Expected a CallTimeoutError, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the error to wrap context.DeadlineExceeded: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Call took %s despite the timeout", elapsed)
+	}
+
+	// Cancelling the caller's context is not reported as a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg.APIOptions = []func(*middleware.Stack) error{callTimeoutMiddleware(time.Minute)}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err == nil || errors.As(err, &timeout) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancellation error, got %v", err)
+	}
+}
+
+func TestCallTimeoutConfig(t *testing.T) {
+	if got := DefaultConfig().Network.CallTimeout(); got != DefaultCallTimeoutSeconds*time.Second {
+		t.Errorf("Expected a default of %ds, got %s", DefaultCallTimeoutSeconds, got)
+	}
+	cfg, err := ParseConfig([]byte(`{"network": {"call_timeout_seconds": 0}}`), true)
+	if err != nil || cfg.Network.CallTimeout() != 0 {
+		t.Errorf("Expected 0 to disable the timeout: %v, %v", cfg, err)
+	}
+	if _, err := ParseConfig([]byte(`{"network": {"call_timeout_seconds": -1}}`), true); err == nil {
+		t.Error("Expected an error for a negative timeout")
+	}
+}
@@ -25,4 +25,21 @@ type AWSSession struct {
 	Duration int32
 	// Revoked indicates if the session has been manually invalidated.
 	Revoked bool
+	// Access is the level derived from the role's policies by `login --check-access`:
+	// read-only, admin or custom. Empty when it wasn't checked.
+	Access string
+	// AccountID, PrincipalArn and UserID are resolved with sts:GetCallerIdentity at login
+	// (display.resolve_identity); empty for sessions stored without it.
+	AccountID    string
+	PrincipalArn string
+	UserID       string
+	// AccountAlias is the account's IAM alias from iam:ListAccountAliases, resolved with
+	// the identity; empty when the role may not list it or the account has none.
+	AccountAlias string
+	// SelfDestruct is a local hard deadline set with `login --self-destruct`, before
+	// Expiration. After it, cloudctl stops handing out the session and the daemon deletes it.
+	SelfDestruct time.Time
+	// RootTask is the task policy ARN of a root session from sts:AssumeRoot (`root-login`);
+	// empty for other sessions. Root sessions have the account's root ARN as RoleArn.
+	RootTask string
 }
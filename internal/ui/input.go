@@ -20,6 +20,21 @@ func GetInput(prompt string, placeholder string, password bool) (string, error)
 		ti.EchoCharacter = '•'
 	}
 
+	return runInput(ti, prompt)
+}
+
+// GetInputValue asks for a value like GetInput, starting from value, which Enter accepts
+// as it is.
+func GetInputValue(prompt string, value string) (string, error) {
+	ti := textinput.New()
+	ti.SetValue(value)
+	ti.Focus()
+	ti.CharLimit = 156
+	ti.Width = 40
+	return runInput(ti, prompt)
+}
+
+func runInput(ti textinput.Model, prompt string) (string, error) {
 	m := inputModel{
 		textInput: ti,
 		prompt:    prompt,
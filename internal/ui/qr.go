@@ -0,0 +1,17 @@
+package ui
+
+import (
+	"fmt"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QRCode renders text as a QR code for the terminal, using half-block characters so
+// two module rows fit on one line. It is drawn light-on-dark, with a quiet zone.
+func QRCode(text string) (string, error) {
+	q, err := qrcode.New(text, qrcode.Low)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return q.ToSmallString(false), nil
+}
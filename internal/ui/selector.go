@@ -40,6 +40,13 @@ var (
 type item string
 
 func (i item) FilterValue() string { return string(i) }
+func (i item) choiceKey() string   { return string(i) }
+
+// choiceItem is a list item that can be picked; choiceKey is what the picker returns.
+type choiceItem interface {
+	list.Item
+	choiceKey() string
+}
 
 type itemDelegate struct{}
 
@@ -68,9 +75,17 @@ type model struct {
 	list     list.Model
 	choice   string
 	quitting bool
+	// title and header are drawn above the list when the list doesn't show its own title.
+	title  string
+	header string
+	// filterOnStart opens the filter right away, so typing narrows the list.
+	filterOnStart bool
 }
 
 func (m model) Init() tea.Cmd {
+	if m.filterOnStart {
+		return func() tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}} }
+	}
 	return nil
 }
 
@@ -87,9 +102,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "enter":
-			i, ok := m.list.SelectedItem().(item)
+			i, ok := m.list.SelectedItem().(choiceItem)
 			if ok {
-				m.choice = string(i)
+				m.choice = i.choiceKey()
 			}
 			return m, tea.Quit
 		}
@@ -107,6 +122,9 @@ func (m model) View() string {
 	if m.quitting {
 		return quitTextStyle.Render("Cancelled.")
 	}
+	if m.header != "" {
+		return "\n" + containerStyle.Render(titleStyle.Render(m.title)+"\n"+headerStyle.Render(m.header)+"\n"+m.list.View())
+	}
 	return "\n" + containerStyle.Render(m.list.View())
 }
 
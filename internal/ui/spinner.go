@@ -1,12 +1,14 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
 )
 
 var (
@@ -25,6 +27,7 @@ type spinnerModel struct {
 	spinner  spinner.Model
 	text     string
 	task     func() (any, error)
+	cancel   context.CancelFunc
 	result   any
 	err      error
 	quitting bool
@@ -44,6 +47,7 @@ func (m spinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		if msg.Type == tea.KeyCtrlC {
+			m.cancel()
 			m.err = fmt.Errorf("cancelled by user")
 			m.quitting = true
 			return m, tea.Quit
@@ -80,8 +84,17 @@ func (m spinnerModel) View() string {
 
 // Spin runs a blocking task with a spinner overlay.
 // The task function returns a result (any) and an error.
-// Spin returns (any, error).
-func Spin(text string, task func() (any, error)) (any, error) {
+// Spin returns (any, error). The task's context is cancelled when ctx is done or the
+// user presses Ctrl-C, so a hung AWS call doesn't keep running behind the prompt.
+// Without a terminal on stderr, as under automation, the task just runs.
+func Spin(ctx context.Context, text string, task func(ctx context.Context) (any, error)) (any, error) {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return task(ctx)
+	}
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = spinnerStyle
@@ -89,13 +102,17 @@ func Spin(text string, task func() (any, error)) (any, error) {
 	m := spinnerModel{
 		spinner: s,
 		text:    text,
-		task:    task,
+		task:    func() (any, error) { return task(taskCtx) },
+		cancel:  cancel,
 	}
 
 	// Use stderr to avoid polluting stdout
-	p := tea.NewProgram(m, tea.WithOutput(os.Stderr))
+	p := tea.NewProgram(m, tea.WithOutput(os.Stderr), tea.WithContext(ctx))
 	finalModel, err := p.Run()
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
 
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var headerStyle = lipgloss.NewStyle().
+	PaddingLeft(4).
+	Foreground(lipgloss.Color("#90A4AE")).
+	Bold(true)
+
+// Row is one choice of SelectRow. Columns are shown aligned under the headers and all
+// of them are matched by the filter; Key is returned when the row is picked.
+type Row struct {
+	Key     string
+	Columns []string
+}
+
+type rowItem struct {
+	key  string
+	text string
+}
+
+func (i rowItem) FilterValue() string { return i.text }
+func (i rowItem) choiceKey() string   { return i.key }
+
+type rowDelegate struct{}
+
+func (d rowDelegate) Height() int                             { return 1 }
+func (d rowDelegate) Spacing() int                            { return 0 }
+func (d rowDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+func (d rowDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	i, ok := listItem.(rowItem)
+	if !ok {
+		return
+	}
+	if index == m.Index() {
+		fmt.Fprint(w, selectedItemStyle.Render("> "+i.text))
+		return
+	}
+	fmt.Fprint(w, itemStyle.Render(i.text))
+}
+
+// alignColumns pads each column of lines to its widest cell.
+func alignColumns(lines [][]string) []string {
+	var widths []int
+	for _, cells := range lines {
+		for c, cell := range cells {
+			if c == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[c] = max(widths[c], lipgloss.Width(cell))
+		}
+	}
+	out := make([]string, len(lines))
+	for n, cells := range lines {
+		var b strings.Builder
+		for c, cell := range cells {
+			if c > 0 {
+				b.WriteString("  ")
+			}
+			b.WriteString(cell)
+			if c < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[c]-lipgloss.Width(cell)))
+			}
+		}
+		out[n] = b.String()
+	}
+	return out
+}
+
+// SelectRow lets the user pick one of rows, shown as a table under headers. The filter
+// is open from the start: typing narrows the rows on any column, the arrow keys move
+// through the matches and enter picks one.
+func SelectRow(title string, headers []string, rows []Row) (string, error) {
+	lines := [][]string{headers}
+	for _, r := range rows {
+		lines = append(lines, r.Columns)
+	}
+	aligned := alignColumns(lines)
+
+	items := make([]list.Item, 0, len(rows))
+	width := lipgloss.Width(aligned[0])
+	for n, r := range rows {
+		items = append(items, rowItem{key: r.Key, text: aligned[n+1]})
+		width = max(width, lipgloss.Width(aligned[n+1]))
+	}
+
+	const listHeight = 16
+
+	l := list.New(items, rowDelegate{}, width+8, listHeight)
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(true)
+	l.FilterInput.Prompt = "Filter: "
+	l.Styles.PaginationStyle = paginationStyle
+	l.Styles.HelpStyle = helpStyle
+
+	m := model{list: l, title: title, header: aligned[0], filterOnStart: true}
+
+	// Uses os.Stderr to avoid polluting stdout (important for eval)
+	p := tea.NewProgram(m, tea.WithOutput(os.Stderr))
+	finalModel, err := p.Run()
+	if err != nil {
+		return "", err
+	}
+
+	if m, ok := finalModel.(model); ok && m.choice != "" {
+		return m.choice, nil
+	}
+
+	return "", fmt.Errorf("no selection")
+}
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultUpMFAProfile is the name `cloudctl up` stores its MFA session as.
+	defaultUpMFAProfile = "mfa-session"
+	// upMinRemaining is how long a stored session must still be usable for `up` to keep
+	// it instead of logging in again.
+	upMinRemaining = 30 * time.Minute
+)
+
+// MFASessionProfile returns the name the MFA session is stored as.
+func (c UpConfig) MFASessionProfile() string {
+	if c.MFAProfile != "" {
+		return c.MFAProfile
+	}
+	return defaultUpMFAProfile
+}
+
+// STSRegion returns the region of the MFA login.
+func (c UpConfig) STSRegion() string {
+	if c.Region != "" {
+		return c.Region
+	}
+	return DefaultRegion
+}
+
+// ValidateUpConfig checks the shape of the up section; aliases are resolved when it
+// runs.
+func ValidateUpConfig(c UpConfig) error {
+	if c.Source == "" && (c.MFADevice != "" || len(c.Roles) > 0) {
+		return fmt.Errorf("source is required for the MFA login and roles")
+	}
+	profiles := map[string]bool{c.MFASessionProfile(): c.MFADevice != ""}
+	for i, r := range c.Roles {
+		if r.Role == "" {
+			return fmt.Errorf("roles[%d]: role is required", i)
+		}
+		if r.Duration < 0 {
+			return fmt.Errorf("roles[%d]: duration must not be negative", i)
+		}
+		if r.Profile != "" {
+			if profiles[r.Profile] {
+				return fmt.Errorf("roles[%d]: profile '%s' is used twice", i, r.Profile)
+			}
+			profiles[r.Profile] = true
+		}
+	}
+	for i, k := range c.Kubeconfig {
+		if k.Profile == "" || k.Cluster == "" {
+			return fmt.Errorf("kubeconfig[%d]: profile and cluster are required", i)
+		}
+	}
+	return nil
+}
+
+// UpLogin is a role login of the work session with the alias and account defaults
+// applied.
+type UpLogin struct {
+	Profile  string
+	RoleArn  string
+	Region   string
+	Duration int32
+}
+
+// ResolveUpRoles resolves the roles of the up section. Profiles without a name are
+// named like `cloudctl login` names them, taking only the other logins of the session
+// into account so that every run stores the same profiles. Break-glass and dual-control
+// roles can't be part of it: they need a justification or an approval for each login.
+func ResolveUpRoles(c UpConfig) ([]UpLogin, error) {
+	cfg := CurrentConfig()
+	taken := map[string]bool{}
+	if c.MFADevice != "" {
+		taken[c.MFASessionProfile()] = true
+	}
+	for _, r := range c.Roles {
+		if r.Profile != "" {
+			taken[r.Profile] = true
+		}
+	}
+
+	logins := make([]UpLogin, 0, len(c.Roles))
+	for _, r := range c.Roles {
+		login := UpLogin{Profile: r.Profile, RoleArn: r.Role, Region: r.Region, Duration: r.Duration}
+		var aliasName string
+		var alias *RoleAlias
+		if a, found := GetRoleAlias(r.Role); found {
+			aliasName, alias = r.Role, &a
+			login.RoleArn = a.ARN
+			if login.Region == "" {
+				login.Region = a.Region
+			}
+			if login.Duration == 0 {
+				login.Duration = a.Duration
+			}
+		}
+		if _, err := ParseRoleARN(login.RoleArn); err != nil {
+			return nil, fmt.Errorf("role '%s': %w", r.Role, err)
+		}
+		if cfg.IsBreakGlass(login.RoleArn) {
+			return nil, fmt.Errorf("role '%s' is a break-glass role; log in to it with cloudctl login", r.Role)
+		}
+		if cfg.RequiresDualControl(login.RoleArn) {
+			return nil, fmt.Errorf("role '%s' requires dual control; log in to it with cloudctl login", r.Role)
+		}
+		if login.Region == "" {
+			login.Region = cfg.AccountRegion(RoleAccountID(login.RoleArn))
+		}
+		if login.Region == "" {
+			login.Region = DefaultRegion
+		}
+		if login.Profile == "" {
+			login.Profile = DefaultSessionName(login.RoleArn, aliasName, alias, func(name string) bool { return taken[name] })
+			taken[login.Profile] = true
+		}
+		logins = append(logins, login)
+	}
+	return logins, nil
+}
+
+// UpSessionFresh reports whether a stored session of roleArn stays usable long enough
+// for `up` to keep it.
+func UpSessionFresh(s *AWSSession, roleArn string, now time.Time) bool {
+	return s != nil && s.RoleArn == roleArn && s.UsableUntil().Sub(now) > upMinRemaining
+}
+
+// kubeconfigArgs returns the AWS CLI arguments that update the kubeconfig entry of c.
+func kubeconfigArgs(c UpCluster, region string) []string {
+	if c.Region != "" {
+		region = c.Region
+	}
+	args := []string{"eks", "update-kubeconfig", "--name", c.Cluster, "--region", region, "--profile", c.Profile}
+	if c.Alias != "" {
+		args = append(args, "--alias", c.Alias)
+	}
+	return args
+}
+
+// UpdateKubeconfig adds or updates the kubeconfig entry of an EKS cluster with the AWS
+// CLI, authenticating as the synced profile c.Profile. region is used when c has none.
+func UpdateKubeconfig(ctx context.Context, c UpCluster, region string) error {
+	if _, err := exec.LookPath("aws"); err != nil {
+		return fmt.Errorf("the AWS CLI is needed to update kubeconfigs: %w", err)
+	}
+	out, err := exec.CommandContext(ctx, "aws", kubeconfigArgs(c, region)...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("aws eks update-kubeconfig failed: %s", msg)
+		}
+		return fmt.Errorf("aws eks update-kubeconfig failed: %w", err)
+	}
+	return nil
+}
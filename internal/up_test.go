@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateUpConfig(t *testing.T) {
+	valid := UpConfig{
+		Source:     "default",
+		MFADevice:  "phone",
+		Roles:      []UpRole{{Role: "prod-admin"}, {Role: "arn:aws:iam::222222222222:role/Dev", Profile: "dev"}},
+		Kubeconfig: []UpCluster{{Profile: "dev", Cluster: "dev-cluster"}},
+	}
+	if err := ValidateUpConfig(valid); err != nil {
+		t.Errorf("Expected a valid config, got %v", err)
+	}
+	if err := ValidateUpConfig(UpConfig{}); err != nil {
+		t.Errorf("Expected an empty section to be valid, got %v", err)
+	}
+
+	for name, c := range map[string]UpConfig{
+		"no source":         {Roles: []UpRole{{Role: "prod-admin"}}},
+		"no role":           {Source: "default", Roles: []UpRole{{Profile: "dev"}}},
+		"profile twice":     {Source: "default", Roles: []UpRole{{Role: "a", Profile: "dev"}, {Role: "b", Profile: "dev"}}},
+		"mfa profile taken": {Source: "default", MFADevice: "phone", Roles: []UpRole{{Role: "a", Profile: "mfa-session"}}},
+		"no cluster":        {Kubeconfig: []UpCluster{{Profile: "dev"}}},
+	} {
+		if err := ValidateUpConfig(c); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestResolveUpRoles(t *testing.T) {
+	setTestConfig(t, func(c *Config) {
+		c.Accounts = map[string]AccountConfig{"222222222222": {Region: "eu-west-1", Env: "dev"}}
+		c.Security.BreakGlassRoles = []string{"arn:aws:iam::*:role/BreakGlass"}
+	})
+	setupTestRoles(t, "")
+	if err := SaveRoleAlias("prod-admin", RoleAlias{ARN: "arn:aws:iam::111111111111:role/Admin", Region: "us-east-1", Duration: 7200}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := UpConfig{
+		Source:    "default",
+		MFADevice: "phone",
+		Roles: []UpRole{
+			{Role: "prod-admin"},
+			{Role: "arn:aws:iam::222222222222:role/Developer"},
+			{Role: "arn:aws:iam::333333333333:role/ReadOnly", Profile: "audit", Region: "ap-northeast-1"},
+		},
+	}
+	got, err := ResolveUpRoles(c)
+	if err != nil {
+		t.Fatalf("ResolveUpRoles failed: %v", err)
+	}
+	want := []UpLogin{
+		{Profile: "prod-admin", RoleArn: "arn:aws:iam::111111111111:role/Admin", Region: "us-east-1", Duration: 7200},
+		{Profile: "dev-developer", RoleArn: "arn:aws:iam::222222222222:role/Developer", Region: "eu-west-1"},
+		{Profile: "audit", RoleArn: "arn:aws:iam::333333333333:role/ReadOnly", Region: "ap-northeast-1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	c.Roles = []UpRole{{Role: "arn:aws:iam::111111111111:role/BreakGlass"}}
+	if _, err := ResolveUpRoles(c); err == nil || !strings.Contains(err.Error(), "break-glass") {
+		t.Errorf("Expected a break-glass error, got %v", err)
+	}
+	c.Roles = []UpRole{{Role: "not-an-alias"}}
+	if _, err := ResolveUpRoles(c); err == nil {
+		t.Error("Expected an error for an unknown alias")
+	}
+}
+
+func TestUpSessionFresh(t *testing.T) {
+	now := time.Now()
+	role := "arn:aws:iam::111111111111:role/Admin"
+	if !UpSessionFresh(&AWSSession{RoleArn: role, Expiration: now.Add(time.Hour)}, role, now) {
+		t.Error("Expected a session valid for an hour to be fresh")
+	}
+	if UpSessionFresh(&AWSSession{RoleArn: role, Expiration: now.Add(10 * time.Minute)}, role, now) {
+		t.Error("Expected a session about to expire not to be fresh")
+	}
+	if UpSessionFresh(&AWSSession{RoleArn: "arn:aws:iam::111111111111:role/Other", Expiration: now.Add(time.Hour)}, role, now) {
+		t.Error("Expected a session of another role not to be fresh")
+	}
+	if UpSessionFresh(nil, role, now) {
+		t.Error("Expected no session not to be fresh")
+	}
+}
+
+func TestKubeconfigArgs(t *testing.T) {
+	got := kubeconfigArgs(UpCluster{Profile: "dev", Cluster: "dev-cluster", Alias: "dev"}, "eu-west-1")
+	want := []string{"eks", "update-kubeconfig", "--name", "dev-cluster", "--region", "eu-west-1", "--profile", "dev", "--alias", "dev"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := kubeconfigArgs(UpCluster{Profile: "dev", Cluster: "c", Region: "us-east-2"}, "eu-west-1"); got[5] != "us-east-2" {
+		t.Errorf("Expected the cluster region, got %v", got)
+	}
+}
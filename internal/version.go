@@ -1,12 +1,14 @@
 package internal
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -22,57 +24,70 @@ type GitHubRelease struct {
 	HTMLURL string `json:"html_url"`
 }
 
+// VersionCheck is the cached answer of the last release check.
 type VersionCheck struct {
 	LastChecked   time.Time `json:"last_checked"`
 	LatestVersion string    `json:"latest_version"`
+	URL           string    `json:"url,omitempty"`
+	ETag          string    `json:"etag,omitempty"`
 }
 
-// CheckForUpdates checks if a new version is available (non-blocking)
-func CheckForUpdates() {
-	// Check if we should skip (checked recently)
-	if !shouldCheck() {
-		return
-	}
-
-	go func() {
-		latest, url, err := FetchLatestVersion()
-		if err != nil {
-			return // Silently fail
-		}
+func versionCheckPath() string {
+	return filepath.Join(storeDir, "version_check.json")
+}
 
-		if IsNewer(latest, CurrentVersion) {
-			fmt.Fprintf(os.Stderr, "\n💡 Update available: %s → %s\n", CurrentVersion, latest)
-			fmt.Fprintf(os.Stderr, "   Download: %s\n\n", url)
+// CheckForUpdates returns the latest release if it is newer than this version, and
+// empty strings otherwise. GitHub is asked at most once per CheckInterval; in between,
+// the cached answer is used. It blocks for the request, so run it where its lifetime is
+// controlled, like the daemon, rather than in the background of a command.
+func CheckForUpdates(ctx context.Context) (string, string, error) {
+	check := loadVersionCheck()
+	latest, url := check.LatestVersion, check.URL
+	if latest == "" || time.Since(check.LastChecked) > CheckInterval {
+		var err error
+		if latest, url, err = FetchLatestVersion(ctx); err != nil {
+			return "", "", err
 		}
-
-		saveLastCheck(latest)
-	}()
+	}
+	if !IsNewer(latest, CurrentVersion) {
+		return "", "", nil
+	}
+	return latest, url, nil
 }
 
-func shouldCheck() bool {
-	cachePath := filepath.Join(os.Getenv("HOME"), ".cloudctl", "version_check.json")
-	data, err := os.ReadFile(cachePath)
+// FetchLatestVersion asks GitHub for the latest release and caches the answer. The
+// ETag of the cached answer is sent along, so an unchanged release costs a 304 that
+// doesn't count against GitHub's rate limit.
+func FetchLatestVersion(ctx context.Context) (string, string, error) {
+	check := loadVersionCheck()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, GitHubAPI, nil)
 	if err != nil {
-		return true
+		return "", "", err
 	}
-
-	var check VersionCheck
-	if err := json.Unmarshal(data, &check); err != nil {
-		return true
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if check.ETag != "" && check.LatestVersion != "" {
+		req.Header.Set("If-None-Match", check.ETag)
 	}
 
-	return time.Since(check.LastChecked) > CheckInterval
-}
-
-func FetchLatestVersion() (string, string, error) {
 	client := &http.Client{Timeout: 3 * time.Second}
-	resp, err := client.Get(GitHubAPI)
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		check.LastChecked = time.Now()
+		saveVersionCheck(check)
+		return check.LatestVersion, check.URL, nil
+	case http.StatusOK:
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return "", "", fmt.Errorf("GitHub API rate limit reached, try again after %s", FormatTime(time.Unix(reset, 0)))
+		}
+		return "", "", fmt.Errorf("status %d", resp.StatusCode)
+	default:
 		return "", "", fmt.Errorf("status %d", resp.StatusCode)
 	}
 
@@ -82,6 +97,12 @@ func FetchLatestVersion() (string, string, error) {
 		return "", "", err
 	}
 
+	saveVersionCheck(VersionCheck{
+		LastChecked:   time.Now(),
+		LatestVersion: release.TagName,
+		URL:           release.HTMLURL,
+		ETag:          resp.Header.Get("ETag"),
+	})
 	return release.TagName, release.HTMLURL, nil
 }
 
@@ -92,12 +113,16 @@ func IsNewer(latest, current string) bool {
 	return latest > current
 }
 
-func saveLastCheck(version string) {
-	cachePath := filepath.Join(os.Getenv("HOME"), ".cloudctl", "version_check.json")
-	check := VersionCheck{
-		LastChecked:   time.Now(),
-		LatestVersion: version,
+// loadVersionCheck returns the cached release check, or an empty one.
+func loadVersionCheck() VersionCheck {
+	var check VersionCheck
+	if data, err := os.ReadFile(versionCheckPath()); err == nil {
+		json.Unmarshal(data, &check)
 	}
+	return check
+}
+
+func saveVersionCheck(check VersionCheck) {
 	data, _ := json.Marshal(check)
-	os.WriteFile(cachePath, data, 0600)
+	os.WriteFile(versionCheckPath(), data, 0600)
 }
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setupReleaseServer(t *testing.T, tag string) *atomic.Int32 {
+	t.Helper()
+	var requests atomic.Int32
+	etag := `"` + tag + `"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`{"tag_name": "` + tag + `", "html_url": "https://example.com/` + tag + `"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	originalAPI, originalDir, originalVersion := GitHubAPI, storeDir, CurrentVersion
+	GitHubAPI, storeDir, CurrentVersion = server.URL, t.TempDir(), "v1.0.0"
+	t.Cleanup(func() { GitHubAPI, storeDir, CurrentVersion = originalAPI, originalDir, originalVersion })
+	return &requests
+}
+
+func TestFetchLatestVersionETag(t *testing.T) {
+	requests := setupReleaseServer(t, "v1.1.0")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		latest, url, err := FetchLatestVersion(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The second answer is a 304, served from the cache
+		if latest != "v1.1.0" || url != "https://example.com/v1.1.0" {
+			t.Errorf("Request %d: unexpected release %s %s", i+1, latest, url)
+		}
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("Expected 2 requests, got %d", n)
+	}
+	if check := loadVersionCheck(); check.ETag != `"v1.1.0"` || time.Since(check.LastChecked) > time.Minute {
+		t.Errorf("Unexpected cache %+v", check)
+	}
+}
+
+func TestCheckForUpdates(t *testing.T) {
+	requests := setupReleaseServer(t, "v1.1.0")
+	ctx := context.Background()
+
+	latest, _, err := CheckForUpdates(ctx)
+	if err != nil || latest != "v1.1.0" {
+		t.Fatalf("Expected v1.1.0, got %q (%v)", latest, err)
+	}
+	// Within the interval the cache answers
+	if latest, _, _ := CheckForUpdates(ctx); latest != "v1.1.0" || requests.Load() != 1 {
+		t.Errorf("Expected a cached answer, got %q after %d requests", latest, requests.Load())
+	}
+
+	CurrentVersion = "v1.1.0"
+	if latest, _, _ := CheckForUpdates(ctx); latest != "" {
+		t.Errorf("Expected no update for the latest version, got %q", latest)
+	}
+
+	// A stale cache is revalidated
+	check := loadVersionCheck()
+	check.LastChecked = time.Now().Add(-2 * CheckInterval)
+	saveVersionCheck(check)
+	CheckForUpdates(ctx)
+	if requests.Load() != 2 {
+		t.Errorf("Expected the stale cache to be revalidated, got %d requests", requests.Load())
+	}
+}
+
+func TestFetchLatestVersionRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	originalAPI, originalDir := GitHubAPI, storeDir
+	GitHubAPI, storeDir = server.URL, t.TempDir()
+	defer func() { GitHubAPI, storeDir = originalAPI, originalDir }()
+	setTestConfig(t, nil)
+
+	_, _, err := FetchLatestVersion(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("Expected a rate limit error, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// StoreEventOp is what happened to a stored session.
+type StoreEventOp string
+
+const (
+	SessionAdded   StoreEventOp = "added"
+	SessionUpdated StoreEventOp = "updated"
+	SessionRemoved StoreEventOp = "removed"
+)
+
+// StoreEvent is a change of one stored session, by this or another process.
+type StoreEvent struct {
+	Op      StoreEventOp `json:"op"`
+	Profile string       `json:"profile"`
+}
+
+// watchDebounce collects the writes of one store update (credentials file, then index)
+// into one pass.
+const watchDebounce = 100 * time.Millisecond
+
+// WatchStore reports sessions that are added, updated (refreshed or changed) or removed
+// in the credential store until ctx is done, when the channel is closed. Changes are
+// found by comparing the encrypted entries, so no secret is needed.
+func WatchStore(ctx context.Context) (<-chan StoreEvent, error) {
+	dir := filepath.Dir(storePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch the store: %w", err)
+	}
+	// The directory is watched rather than the file, which is removed with the last
+	// session and may be replaced
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	previous, err := processStore.snapshot()
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	name := filepath.Base(storePath)
+	events := make(chan StoreEvent)
+	go func() {
+		defer close(events)
+		defer watcher.Close()
+
+		var settle <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Base(e.Name) == name {
+					settle = time.After(watchDebounce)
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				// Events may have been dropped; compare again to catch up
+				settle = time.After(watchDebounce)
+			case <-settle:
+				settle = nil
+				current, err := processStore.snapshot()
+				if err != nil {
+					// A write in progress; the next event retries
+					continue
+				}
+				for _, e := range diffStore(previous, current) {
+					select {
+					case events <- e:
+					case <-ctx.Done():
+						return
+					}
+				}
+				previous = current
+			}
+		}
+	}()
+	return events, nil
+}
+
+// diffStore returns the changes from one set of store entries to the next, sorted by
+// profile.
+func diffStore(before, after map[string]map[string]string) []StoreEvent {
+	var changes []StoreEvent
+	for profile, enc := range after {
+		old, ok := before[profile]
+		switch {
+		case !ok:
+			changes = append(changes, StoreEvent{Op: SessionAdded, Profile: profile})
+		case !maps.Equal(old, enc):
+			changes = append(changes, StoreEvent{Op: SessionUpdated, Profile: profile})
+		}
+	}
+	for profile := range before {
+		if _, ok := after[profile]; !ok {
+			changes = append(changes, StoreEvent{Op: SessionRemoved, Profile: profile})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Profile < changes[j].Profile })
+	return changes
+}
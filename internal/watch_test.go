@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchStore(t *testing.T) {
+	setupTestDir(t)
+	key := "1234567890ABCDEF1234567890ABCDEF"
+	if err := SaveCredentials("dev", testSession("dev"), key); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := WatchStore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		for range events {
+		}
+	})
+	next := func() StoreEvent {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("No store event")
+		}
+		return StoreEvent{}
+	}
+
+	if err := SaveCredentials("prod", testSession("prod"), key); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(); e != (StoreEvent{Op: SessionAdded, Profile: "prod"}) {
+		t.Errorf("Expected prod to be added, got %+v", e)
+	}
+
+	// Another process refreshes dev and removes prod in one write
+	data, _ := processStore.snapshot()
+	data["dev"]["access_key"] = "changed"
+	delete(data, "prod")
+	writeTestStore(t, data)
+	got := []StoreEvent{next(), next()}
+	want := []StoreEvent{{Op: SessionUpdated, Profile: "dev"}, {Op: SessionRemoved, Profile: "prod"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %+v, want %+v", got, want)
+	}
+
+	// The file goes away with the last session
+	if err := os.Remove(storePath); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(); e != (StoreEvent{Op: SessionRemoved, Profile: "dev"}) {
+		t.Errorf("Expected dev to be removed, got %+v", e)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("Expected no further events")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Channel was not closed after cancel")
+	}
+}
+
+func TestDiffStore(t *testing.T) {
+	before := map[string]map[string]string{"a": {"k": "1"}, "b": {"k": "1"}}
+	after := map[string]map[string]string{"b": {"k": "2"}, "c": {"k": "1"}}
+	got := diffStore(before, after)
+	want := []StoreEvent{{SessionRemoved, "a"}, {SessionUpdated, "b"}, {SessionAdded, "c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %+v, want %+v", got, want)
+	}
+	if changes := diffStore(after, after); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+}
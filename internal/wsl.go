@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// The secret is kept in the Windows Credential Manager (Web Credentials) under this
+// resource and user name when running inside WSL.
+const (
+	windowsCredentialResource = "cloudctl"
+	windowsCredentialUser     = "master-key"
+)
+
+// passwordVaultType loads the WinRT PasswordVault class into Windows PowerShell.
+const passwordVaultType = `$ErrorActionPreference = 'Stop'
+[void][Windows.Security.Credentials.PasswordVault, Windows.Security.Credentials, ContentType = WindowsRuntime]
+$vault = New-Object Windows.Security.Credentials.PasswordVault
+`
+
+var (
+	isWSL     bool
+	isWSLOnce sync.Once
+)
+
+// IsWSL reports whether cloudctl runs inside Windows Subsystem for Linux, where the
+// Windows browser and Credential Manager are reachable through interop.
+func IsWSL() bool {
+	isWSLOnce.Do(func() {
+		if runtime.GOOS != "linux" {
+			return
+		}
+		if os.Getenv("WSL_DISTRO_NAME") != "" {
+			isWSL = true
+			return
+		}
+		release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+		isWSL = err == nil && isWSLKernel(string(release))
+	})
+	return isWSL
+}
+
+// isWSLKernel reports whether a kernel release string belongs to a WSL kernel
+// (e.g. "5.15.153.1-microsoft-standard-WSL2").
+func isWSLKernel(release string) bool {
+	return strings.Contains(strings.ToLower(release), "microsoft")
+}
+
+// powershellStartProcess returns a command that opens rawURL with the Windows default
+// browser. The URL is single-quoted so '&' in query strings reaches the browser intact.
+func powershellStartProcess(rawURL string) []string {
+	quoted := "'" + strings.ReplaceAll(rawURL, "'", "''") + "'"
+	return []string{"powershell.exe", "-NoProfile", "-NonInteractive", "-Command", "Start-Process " + quoted}
+}
+
+// runPowerShell runs a script with Windows PowerShell through WSL interop, feeding
+// stdin to it so secrets never appear on a command line.
+func runPowerShell(script, stdin string) (string, error) {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", script)
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("powershell.exe failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("powershell.exe failed: %w", err)
+	}
+	return string(out), nil
+}
+
+// getWindowsCredential reads the secret from the Windows Credential Manager.
+func getWindowsCredential() (string, error) {
+	out, err := runPowerShell(passwordVaultType+fmt.Sprintf(`$cred = $vault.Retrieve('%s', '%s')
+$cred.RetrievePassword()
+[Console]::Out.Write($cred.Password)
+`, windowsCredentialResource, windowsCredentialUser), "")
+	if err != nil {
+		return "", fmt.Errorf("secret not found in Windows Credential Manager: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// storeWindowsCredential saves the secret to the Windows Credential Manager, replacing
+// any earlier one.
+func storeWindowsCredential(secret string) error {
+	_, err := runPowerShell(passwordVaultType+fmt.Sprintf(`try { $vault.Remove($vault.Retrieve('%[1]s', '%[2]s')) } catch {}
+$secret = [Console]::In.ReadToEnd().Trim()
+$vault.Add((New-Object Windows.Security.Credentials.PasswordCredential('%[1]s', '%[2]s', $secret)))
+`, windowsCredentialResource, windowsCredentialUser), secret)
+	if err != nil {
+		return fmt.Errorf("failed to save to Windows Credential Manager: %w", err)
+	}
+	return nil
+}
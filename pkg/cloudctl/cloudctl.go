@@ -0,0 +1,236 @@
+package cloudctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/chukul/cloudctl/internal"
+)
+
+var (
+	// ErrNotFound is returned for a profile without a stored session.
+	ErrNotFound = errors.New("cloudctl: session not found")
+	// ErrExpired is returned by Config for a session past its expiration or
+	// self-destruct deadline.
+	ErrExpired = errors.New("cloudctl: session expired")
+	// ErrLocked is returned while the store is auto-locked; run `cloudctl unlock`.
+	ErrLocked = internal.ErrStoreLocked
+	// ErrTampered is returned when the store fails its integrity check.
+	ErrTampered = internal.ErrStoreTampered
+	// ErrNeedsRelogin is returned for sessions stored by an older version that are
+	// incomplete and need a new login.
+	ErrNeedsRelogin = internal.ErrNeedsRelogin
+)
+
+// Session is a session in the cloudctl store.
+type Session struct {
+	// Profile is the name the session is stored under.
+	Profile string
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+
+	// RoleArn is the assumed role; empty for MFA sessions. For root sessions it is
+	// the account's root ARN.
+	RoleArn string
+	// MFA marks a session from GetSessionToken (`cloudctl mfa-login`).
+	MFA bool
+	// RootTask is the task policy of a root session from `cloudctl root-login`.
+	RootTask string
+	// SourceProfile is the cloudctl session or AWS CLI profile the session was made from.
+	SourceProfile string
+	// MFASerial is the MFA device used to log in, if any.
+	MFASerial string
+	Region    string
+	// Duration is the session length asked for at login.
+	Duration time.Duration
+
+	// AccountID and PrincipalArn are empty unless resolved at login.
+	AccountID    string
+	PrincipalArn string
+	// SelfDestruct is a local deadline before Expiration; zero when not set.
+	SelfDestruct time.Time
+}
+
+// Expired reports whether the session is past its expiration or self-destruct deadline.
+func (s *Session) Expired() bool {
+	now := time.Now()
+	return now.After(s.Expiration) || (!s.SelfDestruct.IsZero() && now.After(s.SelfDestruct))
+}
+
+// Credentials returns the session's credentials for the AWS SDK.
+func (s *Session) Credentials() aws.Credentials {
+	return aws.Credentials{
+		AccessKeyID:     s.AccessKeyID,
+		SecretAccessKey: s.SecretAccessKey,
+		SessionToken:    s.SessionToken,
+		Source:          "cloudctl",
+		CanExpire:       true,
+		Expires:         s.Expiration,
+	}
+}
+
+func fromInternal(s *internal.AWSSession) *Session {
+	out := &Session{
+		Profile:         s.Profile,
+		AccessKeyID:     s.AccessKey,
+		SecretAccessKey: s.SecretKey,
+		SessionToken:    s.SessionToken,
+		Expiration:      s.Expiration,
+		RoleArn:         s.RoleArn,
+		RootTask:        s.RootTask,
+		SourceProfile:   s.SourceProfile,
+		MFASerial:       s.MfaArn,
+		Region:          s.Region,
+		Duration:        time.Duration(s.Duration) * time.Second,
+		AccountID:       internal.SessionAccountID(s),
+		PrincipalArn:    s.PrincipalArn,
+		SelfDestruct:    s.SelfDestruct,
+	}
+	if s.RoleArn == "MFA-Session" {
+		out.RoleArn, out.MFA = "", true
+	}
+	return out
+}
+
+// Options configures a Client.
+type Options struct {
+	// Secret decrypts the store. When empty it is looked up like the CLI does.
+	Secret string
+	// Warn receives problems that don't fail a call, such as unreachable shared
+	// remote state. They are dropped when Warn is nil.
+	Warn func(msg string)
+}
+
+// Client reads and writes the cloudctl store. It is safe for concurrent use.
+type Client struct {
+	secret   string
+	provider internal.CryptoProvider
+	warn     func(msg string)
+}
+
+// New opens the store with the encryption provider of the config file.
+func New(opts Options) (*Client, error) {
+	// A broken config file is an error here, not a warning on stderr
+	if _, err := internal.LoadConfig(); err != nil {
+		return nil, fmt.Errorf("cloudctl: %w", err)
+	}
+	secret, err := internal.GetSecret(opts.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("cloudctl: %w", err)
+	}
+	provider, err := internal.StoreProvider(secret)
+	if err != nil {
+		return nil, fmt.Errorf("cloudctl: %w", err)
+	}
+	warn := opts.Warn
+	if warn == nil {
+		warn = func(string) {}
+	}
+	return &Client{secret: secret, provider: provider, warn: warn}, nil
+}
+
+func (c *Client) warnf(format string, args ...any) {
+	c.warn(fmt.Sprintf(format, args...))
+}
+
+// Sessions returns every stored session, sorted by profile.
+func (c *Client) Sessions(ctx context.Context) ([]*Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	stored, err := internal.ListAllSessionsWith(c.provider)
+	if err != nil {
+		return nil, fmt.Errorf("cloudctl: %w", err)
+	}
+	sessions := make([]*Session, 0, len(stored))
+	for _, s := range stored {
+		sessions = append(sessions, fromInternal(s))
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Profile < sessions[j].Profile })
+	return sessions, nil
+}
+
+// Session returns the session stored for profile, which may have expired.
+func (c *Client) Session(ctx context.Context, profile string) (*Session, error) {
+	s, err := c.load(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	return fromInternal(s), nil
+}
+
+func (c *Client) load(ctx context.Context, profile string) (*internal.AWSSession, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s, err := internal.LoadCredentials(profile, c.secret)
+	if errors.Is(err, internal.ErrProfileNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cloudctl: %w", err)
+	}
+	return s, nil
+}
+
+// Config returns an AWS config with the credentials and region of profile's session.
+// It fails with ErrExpired instead of returning credentials AWS would reject.
+func (c *Client) Config(ctx context.Context, profile string, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+	s, err := c.Session(ctx, profile)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if s.Expired() {
+		return aws.Config{}, fmt.Errorf("%w: %s at %s", ErrExpired, profile, s.Expiration.Format(time.RFC3339))
+	}
+	opts := []func(*config.LoadOptions) error{
+		config.WithRegion(s.Region),
+		config.WithCredentialsProvider(credentials.StaticCredentialsProvider{Value: s.Credentials()}),
+	}
+	return config.LoadDefaultConfig(ctx, append(opts, optFns...)...)
+}
+
+// Remove deletes profile's session from the store.
+func (c *Client) Remove(ctx context.Context, profile string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := internal.RemoveProfile(profile)
+	if errors.Is(err, internal.ErrProfileNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, profile)
+	}
+	if err != nil {
+		return fmt.Errorf("cloudctl: %w", err)
+	}
+	return nil
+}
+
+// Event is a change of a stored session, made by this or another process.
+type Event = internal.StoreEvent
+
+// Event operations
+const (
+	SessionAdded   = internal.SessionAdded
+	SessionUpdated = internal.SessionUpdated
+	SessionRemoved = internal.SessionRemoved
+)
+
+// Watch reports sessions that are added, refreshed or removed, by any cloudctl process,
+// until ctx is done, when the channel is closed. It needs no secret; use Session to
+// read a changed session.
+func (c *Client) Watch(ctx context.Context) (<-chan Event, error) {
+	events, err := internal.WatchStore(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cloudctl: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,238 @@
+package cloudctl_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/chukul/cloudctl/internal"
+	"github.com/chukul/cloudctl/pkg/cloudctl"
+)
+
+const testSecret = "1234567890ABCDEF1234567890ABCDEF"
+
+// TestMain runs the tests again in a child process with an empty CLOUDCTL_HOME: the
+// store paths are fixed when the package is initialized, so they can't be moved later.
+func TestMain(m *testing.M) {
+	if os.Getenv("CLOUDCTL_PKG_TEST") != "" {
+		os.Exit(m.Run())
+	}
+	home, err := os.MkdirTemp("", "cloudctl-pkg-test")
+	if err != nil {
+		panic(err)
+	}
+	cmd := exec.Command(os.Args[0], os.Args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(),
+		"CLOUDCTL_PKG_TEST=1",
+		"CLOUDCTL_HOME="+home,
+		// The account alias lookup would call IAM, which the mock doesn't cover
+		"CLOUDCTL_DISPLAY_RESOLVE_IDENTITY=false",
+		"AWS_ACCESS_KEY_ID=AKIAPKGTEST",
+		"AWS_SECRET_ACCESS_KEY=pkg-test-secret",
+		"AWS_SESSION_TOKEN=",
+		"AWS_PROFILE=",
+	)
+	code := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			panic(err)
+		}
+		code = exitErr.ExitCode()
+	}
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+// setup returns a client for the test store with every STS call going to a mock.
+func setup(t *testing.T) (*cloudctl.Client, *internal.MockSTSClient) {
+	t.Helper()
+	mock := &internal.MockSTSClient{}
+	t.Cleanup(internal.UseSTSClient(mock))
+	client, err := cloudctl.New(cloudctl.Options{
+		Secret: testSecret,
+		Warn:   func(msg string) { t.Errorf("Unexpected warning: %s", msg) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, mock
+}
+
+func login(t *testing.T, client *cloudctl.Client, profile string) *cloudctl.Session {
+	t.Helper()
+	s, err := client.Login(context.Background(), cloudctl.LoginInput{
+		Profile: profile,
+		RoleArn: "arn:aws:iam::123456789012:role/Deploy",
+		Source:  "@env",
+		Region:  "eu-west-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Remove(context.Background(), profile) })
+	return s
+}
+
+func TestLoginStoresSession(t *testing.T) {
+	ctx := context.Background()
+	client, mock := setup(t)
+
+	s := login(t, client, "ci-deploy")
+	calls := mock.Calls()
+	if len(calls) != 1 || calls[0].Operation != "AssumeRole" {
+		t.Fatalf("Expected one AssumeRole call, got %+v", calls)
+	}
+	in := calls[0].Input.(*sts.AssumeRoleInput)
+	if aws.ToString(in.RoleArn) != "arn:aws:iam::123456789012:role/Deploy" || aws.ToString(in.RoleSessionName) != "ci-deploy" {
+		t.Errorf("Unexpected AssumeRole input: role %s, session name %s", aws.ToString(in.RoleArn), aws.ToString(in.RoleSessionName))
+	}
+	if s.AccessKeyID != "ASIAMOCK00000001" || s.SourceProfile != "@env" || s.MFA || s.Expired() {
+		t.Errorf("Unexpected session from Login: %+v", s)
+	}
+
+	// A new client reads the session back from the store
+	reader, err := cloudctl.New(cloudctl.Options{Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := reader.Session(ctx, "ci-deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.AccessKeyID != s.AccessKeyID || loaded.SessionToken != s.SessionToken || !loaded.Expiration.Equal(s.Expiration) ||
+		loaded.RoleArn != s.RoleArn || loaded.Region != "eu-west-1" || loaded.AccountID != "123456789012" {
+		t.Errorf("Expected the stored session to match the login, got %+v", loaded)
+	}
+
+	sessions, err := reader.Sessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].Profile != "ci-deploy" {
+		t.Errorf("Expected only ci-deploy in the store, got %d sessions", len(sessions))
+	}
+
+	cfg, err := reader.Config(ctx, "ci-deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Region != "eu-west-1" || creds.AccessKeyID != s.AccessKeyID || creds.Source != "cloudctl" {
+		t.Errorf("Expected the session's region and credentials in the config, got %s and %s", cfg.Region, creds.AccessKeyID)
+	}
+}
+
+func TestSessionWithWrongSecret(t *testing.T) {
+	client, _ := setup(t)
+	login(t, client, "ci-deploy")
+
+	other, err := cloudctl.New(cloudctl.Options{Secret: "FEDCBA0987654321FEDCBA0987654321"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Session(context.Background(), "ci-deploy"); err == nil || errors.Is(err, cloudctl.ErrNotFound) {
+		t.Errorf("Expected a decryption error with the wrong secret, got %v", err)
+	}
+}
+
+func TestRefreshExpiredSession(t *testing.T) {
+	ctx := context.Background()
+	client, mock := setup(t)
+
+	// The first credentials expired an hour ago
+	mock.Now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	login(t, client, "ci-deploy")
+	mock.Now = nil
+
+	if _, err := client.Config(ctx, "ci-deploy"); !errors.Is(err, cloudctl.ErrExpired) {
+		t.Fatalf("Expected ErrExpired for an expired session, got %v", err)
+	}
+
+	refreshed, err := client.Refresh(ctx, "ci-deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.AccessKeyID != "ASIAMOCK00000002" || refreshed.Expired() || refreshed.Region != "eu-west-1" {
+		t.Errorf("Expected new valid credentials from Refresh, got %+v", refreshed)
+	}
+	if mock.CallCount("AssumeRole") != 2 {
+		t.Errorf("Expected Refresh to assume the role again, got %d AssumeRole calls", mock.CallCount("AssumeRole"))
+	}
+
+	cfg, err := client.Config(ctx, "ci-deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyID != refreshed.AccessKeyID {
+		t.Errorf("Expected the refreshed credentials to be stored, got %s", creds.AccessKeyID)
+	}
+}
+
+func TestRefreshMFASession(t *testing.T) {
+	ctx := context.Background()
+	client, mock := setup(t)
+
+	s, err := client.MFALogin(ctx, cloudctl.MFALoginInput{
+		Profile:   "mfa",
+		Source:    "@env",
+		MFASerial: "arn:aws:iam::123456789012:mfa/alice",
+		TokenCode: "123456",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Remove(ctx, "mfa") })
+	if !s.MFA || s.RoleArn != "" || s.MFASerial != "arn:aws:iam::123456789012:mfa/alice" {
+		t.Errorf("Unexpected session from MFALogin: %+v", s)
+	}
+
+	if _, err := client.Refresh(ctx, "mfa"); err == nil {
+		t.Error("Expected an MFA session not to refresh")
+	}
+	if mock.CallCount("GetSessionToken") != 1 || mock.CallCount("AssumeRole") != 0 {
+		t.Errorf("Expected only the login to call STS, got %+v", mock.Calls())
+	}
+}
+
+func TestRemove(t *testing.T) {
+	ctx := context.Background()
+	client, _ := setup(t)
+	login(t, client, "ci-deploy")
+
+	if err := client.Remove(ctx, "ci-deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Session(ctx, "ci-deploy"); !errors.Is(err, cloudctl.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after Remove, got %v", err)
+	}
+	if err := client.Remove(ctx, "ci-deploy"); !errors.Is(err, cloudctl.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound removing a missing session, got %v", err)
+	}
+	if _, err := client.Refresh(ctx, "ci-deploy"); !errors.Is(err, cloudctl.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound refreshing a missing session, got %v", err)
+	}
+}
+
+func TestLoginNeedsProfileRoleAndSource(t *testing.T) {
+	client, mock := setup(t)
+	if _, err := client.Login(context.Background(), cloudctl.LoginInput{Profile: "ci-deploy", Source: "@env"}); err == nil {
+		t.Error("Expected Login without a role to fail")
+	}
+	if len(mock.Calls()) != 0 {
+		t.Error("Expected an invalid login not to call STS")
+	}
+}
@@ -0,0 +1,22 @@
+// Package cloudctl gives Go programs access to the sessions cloudctl manages: it reads
+// and writes the encrypted credential store in ~/.cloudctl (or $CLOUDCTL_HOME) and runs
+// the same STS flows as the CLI, so another tool can use a profile logged in with
+// `cloudctl login`, or log in and refresh on its own.
+//
+// The package is the stable API over cloudctl's internals. It never prints or prompts:
+// failures are returned as errors and non-fatal problems go to Options.Warn. Every call
+// takes a context, which bounds the AWS requests it makes.
+//
+//	client, err := cloudctl.New(cloudctl.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	cfg, err := client.Config(ctx, "prod-admin")
+//	if errors.Is(err, cloudctl.ErrExpired) {
+//		_, err = client.Refresh(ctx, "prod-admin")
+//	}
+//
+// The secret is found like the CLI finds it: Options.Secret, then CLOUDCTL_SECRET, then
+// the system keychain. With a KMS, age or TPM encryption provider in the config file no
+// secret is needed.
+package cloudctl
@@ -0,0 +1,54 @@
+package cloudctl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/chukul/cloudctl/pkg/cloudctl"
+)
+
+func ExampleClient_Config() {
+	ctx := context.Background()
+	client, err := cloudctl.New(cloudctl.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg, err := client.Config(ctx, "prod-readonly")
+	if errors.Is(err, cloudctl.ErrExpired) {
+		// Role sessions renew from their source without MFA
+		if _, err = client.Refresh(ctx, "prod-readonly"); err == nil {
+			cfg, err = client.Config(ctx, "prod-readonly")
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	out, err := s3.NewFromConfig(cfg).ListBuckets(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(len(out.Buckets), "buckets")
+}
+
+func ExampleClient_Login() {
+	ctx := context.Background()
+	client, err := cloudctl.New(cloudctl.Options{Warn: func(msg string) { log.Print(msg) }})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s, err := client.Login(ctx, cloudctl.LoginInput{
+		Profile: "ci-deploy",
+		RoleArn: "arn:aws:iam::123456789012:role/Deploy",
+		Source:  "@env",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("valid until", s.Expiration)
+}
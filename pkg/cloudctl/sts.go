@@ -0,0 +1,148 @@
+package cloudctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chukul/cloudctl/internal"
+)
+
+// LoginInput describes a role session for Login.
+type LoginInput struct {
+	// Profile is the name to store the session under.
+	Profile string
+	// RoleArn is the role to assume.
+	RoleArn string
+	// Source is a cloudctl session, an AWS CLI profile, "@env" for the credentials in
+	// the environment or "instance" for the EC2 instance or ECS task role.
+	Source string
+	// Region defaults to the account's region in the config file, then ap-southeast-1.
+	Region string
+	// Duration defaults to one hour.
+	Duration time.Duration
+	// MFASerial and TokenCode authenticate with MFA before assuming the role.
+	MFASerial string
+	TokenCode string
+}
+
+// MFALoginInput describes an MFA session for MFALogin.
+type MFALoginInput struct {
+	// Profile is the name to store the session under.
+	Profile string
+	// Source is an AWS CLI profile with long-lived keys, "@env" or "instance".
+	Source string
+	// Region defaults to ap-southeast-1.
+	Region string
+	// Duration defaults to 12 hours.
+	Duration time.Duration
+	// MFASerial is the MFA device ARN and TokenCode its current code.
+	MFASerial string
+	TokenCode string
+}
+
+// Login assumes a role like `cloudctl login` and stores the session. Roles that need
+// dual control or a break-glass justification can only be used from the CLI.
+func (c *Client) Login(ctx context.Context, in LoginInput) (*Session, error) {
+	if in.Profile == "" || in.RoleArn == "" || in.Source == "" {
+		return nil, errors.New("cloudctl: Profile, RoleArn and Source are required")
+	}
+	cfg := internal.CurrentConfig()
+	if cfg.RequiresDualControl(in.RoleArn) {
+		return nil, fmt.Errorf("cloudctl: role %s requires dual control; log in with the CLI", in.RoleArn)
+	}
+	if cfg.IsBreakGlass(in.RoleArn) {
+		return nil, fmt.Errorf("cloudctl: role %s is a break-glass role; log in with the CLI", in.RoleArn)
+	}
+	region := in.Region
+	if region == "" {
+		if arn, err := internal.ParseARN(in.RoleArn); err == nil {
+			region = cfg.AccountRegion(arn.AccountID)
+		}
+	}
+	if region == "" {
+		region = internal.DefaultRegion
+	}
+
+	src, err := internal.LoadLoginSource(ctx, in.Source, c.secret, region)
+	if err != nil {
+		return nil, fmt.Errorf("cloudctl: %w", err)
+	}
+	s, err := internal.LoginRole(ctx, src.Config, internal.RoleLoginOptions{
+		Profile:       in.Profile,
+		RoleArn:       in.RoleArn,
+		Source:        in.Source,
+		SourceSession: src.Session,
+		Region:        region,
+		Duration:      int32(in.Duration / time.Second),
+		MFASerial:     in.MFASerial,
+		TokenCode:     in.TokenCode,
+		Warn:          c.warnf,
+	})
+	if internal.IsMFAError(err) {
+		return nil, fmt.Errorf("cloudctl: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cloudctl: failed to assume role: %w", err)
+	}
+	return c.store(ctx, s)
+}
+
+// MFALogin gets an MFA session like `cloudctl mfa-login` and stores it, to use as the
+// Source of later logins.
+func (c *Client) MFALogin(ctx context.Context, in MFALoginInput) (*Session, error) {
+	if in.Profile == "" || in.Source == "" || in.MFASerial == "" || in.TokenCode == "" {
+		return nil, errors.New("cloudctl: Profile, Source, MFASerial and TokenCode are required")
+	}
+	region := in.Region
+	if region == "" {
+		region = internal.DefaultRegion
+	}
+	awsCfg, err := internal.LoadProfileConfig(ctx, in.Source, region)
+	if err != nil {
+		return nil, fmt.Errorf("cloudctl: %w", err)
+	}
+	s, err := internal.LoginMFA(ctx, awsCfg, internal.MFALoginOptions{
+		Profile:   in.Profile,
+		Source:    in.Source,
+		Region:    region,
+		Duration:  int32(in.Duration / time.Second),
+		MFASerial: in.MFASerial,
+		TokenCode: in.TokenCode,
+		Warn:      c.warnf,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cloudctl: %w", err)
+	}
+	return c.store(ctx, s)
+}
+
+// store saves the session and publishes it to shared remote state.
+func (c *Client) store(ctx context.Context, s *internal.AWSSession) (*Session, error) {
+	if err := internal.SaveCredentialsWith(s.Profile, s, c.provider); err != nil {
+		return nil, fmt.Errorf("cloudctl: failed to save session: %w", err)
+	}
+	if err := internal.PublishRemoteSession(ctx, s, c.secret); err != nil {
+		c.warnf("Failed to update remote state: %v", err)
+	}
+	return fromInternal(s), nil
+}
+
+// Refresh renews a role session from its source without MFA, like the daemon does.
+// MFA and root sessions can't be refreshed this way and need a new login.
+func (c *Client) Refresh(ctx context.Context, profile string) (*Session, error) {
+	s, err := c.load(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	region := s.Region
+	if region == "" {
+		region = internal.DefaultRegion
+	}
+	refreshed, err := internal.RefreshSession(ctx, s, c.secret, region, c.warnf)
+	if err != nil {
+		return nil, fmt.Errorf("cloudctl: failed to refresh %s: %w", profile, err)
+	}
+	return fromInternal(refreshed), nil
+}